@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/aot"
+	"github.com/nooga/paserati/pkg/builtins"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// runBuild implements "paserati build [-o app] app.ts": compiling a program
+// and the modules it imports into an assembly and embedding that in a copy of
+// this executable, which then runs the program instead of acting as paserati
+func runBuild(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	output := flags.String("o", "", "Output executable (default: input file without extension)")
+	assemblyOnly := flags.Bool("assembly", false, "Write the assembly alone instead of an executable")
+	noTypecheck := flags.Bool("no-typecheck", false, "Ignore TypeScript type errors")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: paserati build [options] <app.ts>\n")
+		flags.PrintDefaults()
+	}
+	// Flags may follow the input, as in "paserati build app.ts -o app"
+	var inputs []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 64
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		inputs = append(inputs, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(inputs) != 1 {
+		flags.Usage()
+		return 64
+	}
+
+	input := inputs[0]
+	sourceBytes, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read file '%s': %s\n", input, err.Error())
+		return 70
+	}
+	source := string(sourceBytes)
+	outputFile := *output
+	if outputFile == "" {
+		outputFile = strings.TrimSuffix(input, filepath.Ext(input))
+		if *assemblyOnly {
+			outputFile += aot.AssemblyExtension
+		}
+	}
+
+	// The program is checked against the globals it will run with
+	initializers := append(builtins.GetStandardInitializers(), driver.NewProcessInitializer([]string{"paserati", input}))
+	paserati := driver.NewPaseratiWithInitializers(initializers)
+	defer paserati.Cleanup()
+	if *noTypecheck {
+		paserati.SetSkipTypeCheck(true)
+	}
+	data, errs := paserati.CompileAssembly(source, input)
+	if len(errs) > 0 {
+		errors.DisplayErrors(errs, source)
+		return 70
+	}
+	if *assemblyOnly {
+		if err := os.WriteFile(outputFile, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write '%s': %s\n", outputFile, err.Error())
+			return 70
+		}
+		return 0
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		var runtime []byte
+		if runtime, err = os.ReadFile(exe); err == nil {
+			err = os.WriteFile(outputFile, aot.EmbedAssembly(runtime, data), 0o755)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write '%s': %s\n", outputFile, err.Error())
+		return 70
+	}
+	return 0
+}
+
+// runEmbeddedAssembly runs the program of an executable made by
+// "paserati build". All arguments go to the program.
+func runEmbeddedAssembly(data []byte) int {
+	// process.argv is [executable, executable, ...args], as Node gives
+	// single-executable applications
+	argv := append([]string{os.Args[0]}, os.Args...)
+	initializers := append(builtins.GetStandardInitializers(), driver.NewProcessInitializer(argv))
+	paserati := driver.NewPaseratiWithInitializers(initializers)
+	defer paserati.Cleanup()
+	if _, errs := paserati.RunAssembly(data, driver.RunOptions{}); len(errs) > 0 {
+		errors.DisplayErrors(errs, "")
+		return 70
+	}
+	return 0
+}
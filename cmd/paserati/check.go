@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/builtins"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/tsconfig"
+)
+
+// runCheck implements "paserati check [files, directories or tsconfig.json]":
+// type checking a project and the modules it imports, without running any
+// of it. Without inputs, the project of the nearest tsconfig.json is
+// checked. It exits with 1 when there are errors.
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	project := flags.String("project", "", "Check the files of the given tsconfig.json or directory containing one")
+	format := flags.String("format", "text", "Output format: text, json or sarif")
+	strictNullChecks := flags.Bool("strict-null-checks", false, "Type check with strictNullChecks")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: paserati check [options] [files, directories or tsconfig.json...]\n")
+		flags.PrintDefaults()
+	}
+	// Flags may follow the inputs, as in "paserati check src -format json"
+	var inputs []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 64
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		inputs = append(inputs, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", *format)
+		flags.Usage()
+		return 64
+	}
+	if *project == "" && len(inputs) == 1 && strings.HasSuffix(inputs[0], ".json") {
+		*project, inputs = inputs[0], nil
+	}
+	if *project != "" && len(inputs) > 0 {
+		fmt.Fprintf(os.Stderr, "Either a project or files can be checked, not both\n")
+		return 64
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 70
+	}
+	if *project == "" && len(inputs) == 0 {
+		if *project = tsconfig.Find(cwd); *project == "" {
+			fmt.Fprintf(os.Stderr, "No %s found; name the files or directories to check\n", tsconfig.FileName)
+			flags.Usage()
+			return 64
+		}
+	}
+
+	// Modules are resolved in the directory of the project, or else the
+	// working directory
+	baseDir := cwd
+	if *project != "" {
+		if info, err := os.Stat(*project); err == nil && info.IsDir() {
+			*project = filepath.Join(*project, tsconfig.FileName)
+		}
+		if *project, err = filepath.Abs(*project); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 70
+		}
+		baseDir = filepath.Dir(*project)
+	}
+
+	// Code is checked against the globals it would run with
+	initializers := append(builtins.GetStandardInitializers(), driver.NewProcessInitializer([]string{"paserati"}))
+	paserati := driver.NewPaseratiWithInitializersAndBaseDir(initializers, baseDir)
+	defer paserati.Cleanup()
+	var files []string
+	if *project != "" {
+		if err := paserati.LoadConfig(*project); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load project: %s\n", err)
+			return 66
+		}
+		if files, err = paserati.Config().SourceFiles(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *project, err)
+			return 66
+		}
+		if len(files) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: no inputs were found\n", *project)
+			return 66
+		}
+	} else {
+		// Named files are checked with the options of the project around them
+		if path, err := paserati.LoadProjectConfig(cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", path, err)
+		}
+		if files, err = sourceFiles(inputs); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 66
+		}
+	}
+	if *strictNullChecks {
+		paserati.SetStrictNullChecks(true)
+	}
+
+	result, err := paserati.CheckFiles(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 66
+	}
+	diagnostics := make([]diagnostic, len(result.Errors))
+	for i, err := range result.Errors {
+		diagnostics[i] = newDiagnostic(err, baseDir, cwd)
+	}
+	switch *format {
+	case "json":
+		err = writeJSONDiagnostics(os.Stdout, result.Files, diagnostics)
+	case "sarif":
+		err = writeSARIF(os.Stdout, diagnostics)
+	default:
+		writeTextDiagnostics(os.Stdout, len(result.Files), diagnostics)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 70
+	}
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// sourceFiles returns the files named by inputs and the TypeScript files in
+// the directories they name, leaving out package and hidden directories
+func sourceFiles(inputs []string) ([]string, error) {
+	var files []string
+	for _, input := range inputs {
+		abs, err := filepath.Abs(input)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !tsconfig.IsSourceFile(abs) {
+				return nil, fmt.Errorf("%s is not a TypeScript file", input)
+			}
+			files = append(files, abs)
+			continue
+		}
+		err = filepath.WalkDir(abs, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() && path != abs && (entry.Name() == "node_modules" || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && tsconfig.IsSourceFile(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// diagnostic is an error found by "paserati check". Lines and columns are
+// 1-based, columns counted in Unicode code points; the end is exclusive.
+type diagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+}
+
+// newDiagnostic converts an error in a module of the project in baseDir to
+// a diagnostic, with its file relative to cwd
+func newDiagnostic(err errors.PaseratiError, baseDir, cwd string) diagnostic {
+	pos := err.Pos()
+	d := diagnostic{
+		Line:      pos.Line,
+		Column:    pos.Column,
+		EndLine:   pos.Line,
+		EndColumn: pos.Column + 1,
+		Severity:  "error",
+		Code:      err.Code(),
+		Kind:      err.Kind(),
+		Message:   err.Message(),
+	}
+	if pos.Source == nil {
+		return d
+	}
+	path := pos.Source.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, filepath.FromSlash(path))
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		path = rel
+	}
+	d.File = filepath.ToSlash(path)
+
+	// Positions are recomputed from the span's offsets, so that columns
+	// count code points
+	content := pos.Source.Content
+	if pos.EndPos > pos.StartPos && pos.EndPos <= len(content) {
+		if line, column := offsetPosition(content, pos.StartPos); line == pos.Line {
+			d.Column = column
+			d.EndLine, d.EndColumn = offsetPosition(content, pos.EndPos)
+		}
+	}
+	return d
+}
+
+// offsetPosition returns the 1-based line and column, counted in code
+// points, of a byte offset in content
+func offsetPosition(content string, offset int) (line, column int) {
+	lineStart := strings.LastIndexByte(content[:offset], '\n') + 1
+	return strings.Count(content[:lineStart], "\n") + 1, utf8.RuneCountInString(content[lineStart:offset]) + 1
+}
+
+// writeTextDiagnostics writes one line per diagnostic, as compilers do, and
+// a summary
+func writeTextDiagnostics(w io.Writer, checked int, diagnostics []diagnostic) {
+	files := make(map[string]bool)
+	for _, d := range diagnostics {
+		fmt.Fprintf(w, "%s:%d:%d: %s %s: %s\n", d.File, d.Line, d.Column, d.Severity, d.Code, d.Message)
+		files[d.File] = true
+	}
+	if len(diagnostics) == 0 {
+		fmt.Fprintf(w, "Checked %s, no errors.\n", plural(checked, "file"))
+		return
+	}
+	fmt.Fprintf(w, "Found %s in %s (%d checked).\n", plural(len(diagnostics), "error"), plural(len(files), "file"), checked)
+}
+
+// plural returns a count of things, as in "1 file" or "2 files"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// writeJSONDiagnostics writes the files checked and the diagnostics as a
+// JSON object
+func writeJSONDiagnostics(w io.Writer, files []string, diagnostics []diagnostic) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Files       []string     `json:"files"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}{files, diagnostics})
+}
+
+// SARIF 2.1.0, the format code scanning services take results of static
+// analysis in
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		ColumnKind string        `json:"columnKind"`
+		Results    []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+)
+
+// writeSARIF writes the diagnostics as a SARIF log with a rule per error
+// code
+func writeSARIF(w io.Writer, diagnostics []diagnostic) error {
+	rules := make(map[string]string)
+	results := make([]sarifResult, len(diagnostics))
+	for i, d := range diagnostics {
+		rules[d.Code] = d.Kind + " error"
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: d.File}}
+		if d.Line > 0 && d.Column > 0 {
+			location.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column, EndLine: d.EndLine, EndColumn: d.EndColumn}
+		}
+		results[i] = sarifResult{
+			RuleID:    d.Code,
+			Level:     d.Severity,
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		}
+	}
+	driver := sarifDriver{Name: "paserati", Version: version, InformationURI: "https://github.com/nooga/paserati", Rules: []sarifRule{}}
+	for id, description := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool { return driver.Rules[i].ID < driver.Rules[j].ID })
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, ColumnKind: "unicodeCodePoints", Results: results}},
+	})
+}
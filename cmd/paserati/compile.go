@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/aot"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// runCompile implements "paserati compile [-o out.psm] file.ts": compiling a
+// module to bytecode that runs without parsing, checking or compiling it
+func runCompile(args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	output := flags.String("o", "", "Output file (default: input file with .psm extension)")
+	noTypecheck := flags.Bool("no-typecheck", false, "Ignore TypeScript type errors")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: paserati compile [options] <input.ts>\n")
+		flags.PrintDefaults()
+	}
+	// Flags may follow the input, as in "paserati compile foo.ts -o foo.psm"
+	var inputs []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 64
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		inputs = append(inputs, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(inputs) != 1 {
+		flags.Usage()
+		return 64
+	}
+
+	input := inputs[0]
+	sourceBytes, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read file '%s': %s\n", input, err.Error())
+		return 70
+	}
+	source := string(sourceBytes)
+	outputFile := *output
+	if outputFile == "" {
+		outputFile = strings.TrimSuffix(input, filepath.Ext(input)) + aot.Extension
+	}
+
+	paserati := driver.NewPaserati()
+	defer paserati.Cleanup()
+	if *noTypecheck {
+		paserati.SetSkipTypeCheck(true)
+	}
+	data, errs := paserati.CompileBinaryModule(source, input)
+	if len(errs) > 0 {
+		errors.DisplayErrors(errs, source)
+		return 70
+	}
+	if err := os.WriteFile(outputFile, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write '%s': %s\n", outputFile, err.Error())
+		return 70
+	}
+	return 0
+}
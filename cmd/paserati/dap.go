@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/nooga/paserati/pkg/builtins"
+	"github.com/nooga/paserati/pkg/dap"
+	"github.com/nooga/paserati/pkg/driver"
+)
+
+// runDAP implements "paserati dap [-port n]": a Debug Adapter Protocol server
+// on stdio, or serving one client at a time on a local TCP port
+func runDAP(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ContinueOnError)
+	port := flags.Int("port", 0, "Listen on this local TCP port instead of stdio")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: paserati dap [-port n]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+
+	// Programs print with fmt, which would corrupt the protocol on stdout.
+	// Capture their output and forward it to the client as output events.
+	protocolOut := os.Stdout
+	output := &dapOutput{}
+	if err := output.capture(); err != nil {
+		fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+		return 70
+	}
+
+	if *port == 0 {
+		server := newDAPServer(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, protocolOut})
+		output.attach(server)
+		if err := server.Serve(); err != nil {
+			fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+			return 70
+		}
+		return 0
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *port))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+		return 70
+	}
+	fmt.Fprintf(os.Stderr, "Debug adapter listening on %s\n", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+			return 70
+		}
+		server := newDAPServer(conn)
+		output.attach(server)
+		if err := server.Serve(); err != nil {
+			fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+		}
+		output.attach(nil)
+		conn.Close()
+	}
+}
+
+// newDAPServer returns a server whose programs get the standard builtins and
+// process.argv
+func newDAPServer(conn io.ReadWriter) *dap.Server {
+	server := dap.NewServer(conn)
+	server.NewSession = func(program string, args []string) *driver.Paserati {
+		argv := append([]string{"paserati", program}, args...)
+		initializers := builtins.GetStandardInitializers()
+		initializers = append(initializers, driver.NewProcessInitializer(argv))
+		return driver.NewPaseratiWithInitializers(initializers)
+	}
+	return server
+}
+
+// dapOutput forwards what programs write to stdout to the attached server
+type dapOutput struct {
+	mu     sync.Mutex
+	server *dap.Server
+}
+
+// capture replaces os.Stdout with a pipe drained into output events
+func (o *dapOutput) capture() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	os.Stdout = w
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				o.mu.Lock()
+				if o.server != nil {
+					o.server.Output("stdout", string(buf[:n]))
+				} else {
+					os.Stderr.Write(buf[:n])
+				}
+				o.mu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (o *dapOutput) attach(server *dap.Server) {
+	o.mu.Lock()
+	o.server = server
+	o.mu.Unlock()
+}
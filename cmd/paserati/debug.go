@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/builtins"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+const debugHelp = `Commands:
+  break [file:]line    set a breakpoint (alias b)
+  delete id            remove a breakpoint
+  breakpoints          list breakpoints
+  continue             resume until the next breakpoint (alias c)
+  next                 step over the current line (alias n)
+  step                 step into calls (alias s)
+  finish               run until the current function returns (alias out)
+  backtrace            show the call stack (alias bt)
+  frame n              select the frame for locals, print and list
+  locals               show the variables of the selected frame
+  print expr           evaluate an expression in the selected frame (alias p)
+  list                 show the source around the selected frame (alias l)
+  quit                 stop the program and exit (alias q)
+`
+
+// runDebugger implements "paserati debug [-no-typecheck] script.ts": it runs
+// the script paused on entry and reads debugger commands from in
+func runDebugger(args []string, in io.Reader, out io.Writer) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	flags.SetOutput(out)
+	noTypecheck := flags.Bool("no-typecheck", false, "Ignore TypeScript type errors")
+	flags.Usage = func() {
+		fmt.Fprintf(out, "Usage: paserati debug [options] <script.ts> [args...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 64
+	}
+	filename := flags.Arg(0)
+	sourceBytes, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(out, "Failed to read file '%s': %s\n", filename, err.Error())
+		return 70
+	}
+	source := string(sourceBytes)
+
+	argv := append([]string{"paserati"}, flags.Args()...)
+	initializers := builtins.GetStandardInitializers()
+	initializers = append(initializers, driver.NewProcessInitializer(argv))
+	paserati := driver.NewPaseratiWithInitializers(initializers)
+	defer paserati.Cleanup()
+	if *noTypecheck {
+		paserati.SetSkipTypeCheck(true)
+	}
+
+	session := &debugSession{
+		d:       paserati.NewDebugger(),
+		in:      bufio.NewScanner(in),
+		out:     out,
+		main:    filename,
+		sources: map[string][]string{},
+	}
+	session.sources[filename] = strings.Split(source, "\n")
+	if err := session.d.Start(source, filename, true); err != nil {
+		fmt.Fprintf(out, "%s\n", err)
+		return 70
+	}
+	return session.run(source)
+}
+
+// debugSession is the state of the command-line debugger
+type debugSession struct {
+	d       *driver.Debugger
+	in      *bufio.Scanner
+	out     io.Writer
+	main    string
+	frame   int // Selected frame
+	quit    bool
+	sources map[string][]string // Source lines by path, loaded on demand
+}
+
+// run handles the program's events until it exits and returns the exit code
+func (s *debugSession) run(source string) int {
+	for ev := range s.d.Events() {
+		switch ev.Kind {
+		case driver.DebugBreakpointChanged:
+			bp := ev.Breakpoint
+			if bp.Verified && bp.ResolvedLine != bp.Line {
+				fmt.Fprintf(s.out, "Breakpoint %d moved to %s:%d\n", bp.ID, bp.Path, bp.ResolvedLine)
+			}
+		case driver.DebugStopped:
+			s.frame = 0
+			s.reportStop(ev)
+			if !s.prompt() {
+				s.quit = true
+				s.d.Terminate()
+			}
+		case driver.DebugExited:
+			if s.quit {
+				return 0
+			}
+			if len(ev.Errors) > 0 {
+				errors.DisplayErrors(ev.Errors, source)
+				return 70
+			}
+			fmt.Fprintf(s.out, "Program exited: %s\n", ev.Value.Inspect())
+		}
+	}
+	return 0
+}
+
+// reportStop prints why and where the program paused
+func (s *debugSession) reportStop(ev driver.DebugEvent) {
+	switch ev.Reason {
+	case driver.StopBreakpoint:
+		ids := make([]string, len(ev.Breakpoints))
+		for i, id := range ev.Breakpoints {
+			ids[i] = strconv.Itoa(id)
+		}
+		fmt.Fprintf(s.out, "Breakpoint %s, ", strings.Join(ids, ", "))
+	case driver.StopDebuggerStatement:
+		fmt.Fprintf(s.out, "Paused at debugger statement, ")
+	case driver.StopEntry:
+		fmt.Fprintf(s.out, "Paused on entry, ")
+	}
+	frames, err := s.d.Stack()
+	if err != nil || len(frames) == 0 {
+		fmt.Fprintln(s.out, "location unknown")
+		return
+	}
+	s.printFrame(frames[0])
+	s.printLine(frames[0].Path, frames[0].Line)
+}
+
+// prompt reads commands until one resumes the program. It returns false when
+// the user quits or input ends.
+func (s *debugSession) prompt() bool {
+	for {
+		fmt.Fprint(s.out, "debug> ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return false
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(s.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "":
+		case "c", "continue":
+			s.d.Continue()
+			return true
+		case "n", "next":
+			s.d.StepOver()
+			return true
+		case "s", "step":
+			s.d.StepIn()
+			return true
+		case "out", "finish":
+			s.d.StepOut()
+			return true
+		case "q", "quit":
+			return false
+		case "b", "break":
+			s.setBreakpoint(arg)
+		case "delete":
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				fmt.Fprintln(s.out, "Usage: delete <breakpoint id>")
+			} else if err := s.d.ClearBreakpoint(id); err != nil {
+				fmt.Fprintln(s.out, err)
+			}
+		case "breakpoints":
+			for _, bp := range s.d.Breakpoints() {
+				status := "pending"
+				if bp.Verified {
+					status = fmt.Sprintf("line %d", bp.ResolvedLine)
+				}
+				fmt.Fprintf(s.out, "%d  %s:%d (%s)\n", bp.ID, bp.Path, bp.Line, status)
+			}
+		case "bt", "backtrace":
+			frames, _ := s.d.Stack()
+			for _, frame := range frames {
+				marker := " "
+				if frame.Index == s.frame {
+					marker = "*"
+				}
+				fmt.Fprintf(s.out, "%s#%d ", marker, frame.Index)
+				s.printFrame(frame)
+			}
+		case "frame":
+			s.selectFrame(arg)
+		case "locals":
+			scopes, err := s.d.Scopes(s.frame)
+			if err != nil {
+				fmt.Fprintln(s.out, err)
+				break
+			}
+			for _, scope := range scopes {
+				if scope.Name == "Local" {
+					for _, v := range scope.Variables {
+						fmt.Fprintf(s.out, "%s = %s\n", v.Name, v.Value.Inspect())
+					}
+				}
+			}
+		case "p", "print":
+			value, err := s.d.Evaluate(s.frame, arg)
+			var jsErr *driver.JSError
+			if stderrors.As(err, &jsErr) {
+				fmt.Fprintf(s.out, "Thrown: %s\n", jsErr.Message)
+			} else if err != nil {
+				fmt.Fprintln(s.out, err)
+			} else {
+				fmt.Fprintln(s.out, value.Inspect())
+			}
+		case "l", "list":
+			s.list()
+		case "h", "help":
+			fmt.Fprint(s.out, debugHelp)
+		default:
+			fmt.Fprintf(s.out, "Unknown command %q, try help\n", command)
+		}
+	}
+}
+
+// setBreakpoint handles "break [file:]line"
+func (s *debugSession) setBreakpoint(arg string) {
+	path, lineText := s.main, arg
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		path, lineText = arg[:i], arg[i+1:]
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		fmt.Fprintln(s.out, "Usage: break [file:]line")
+		return
+	}
+	bp := s.d.SetBreakpoint(path, line)
+	if bp.Verified {
+		fmt.Fprintf(s.out, "Breakpoint %d at %s:%d\n", bp.ID, path, bp.ResolvedLine)
+	} else {
+		fmt.Fprintf(s.out, "Breakpoint %d at %s:%d (pending)\n", bp.ID, path, line)
+	}
+}
+
+// selectFrame handles "frame n"
+func (s *debugSession) selectFrame(arg string) {
+	frames, err := s.d.Stack()
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	index, err := strconv.Atoi(arg)
+	if err != nil || index < 0 || index >= len(frames) {
+		fmt.Fprintf(s.out, "No frame %q, the stack has %d\n", arg, len(frames))
+		return
+	}
+	s.frame = index
+	s.printFrame(frames[index])
+	s.printLine(frames[index].Path, frames[index].Line)
+}
+
+// list prints the source around the selected frame's line
+func (s *debugSession) list() {
+	frames, err := s.d.Stack()
+	if err != nil || s.frame >= len(frames) {
+		fmt.Fprintln(s.out, "No frame selected")
+		return
+	}
+	frame := frames[s.frame]
+	lines := s.source(frame.Path)
+	for n := max(frame.Line-5, 1); n <= min(frame.Line+5, len(lines)); n++ {
+		marker := "  "
+		if n == frame.Line {
+			marker = "=>"
+		}
+		fmt.Fprintf(s.out, "%s %4d  %s\n", marker, n, lines[n-1])
+	}
+}
+
+func (s *debugSession) printFrame(frame driver.DebugFrame) {
+	fmt.Fprintf(s.out, "%s at %s:%d\n", frame.Function, frame.Path, frame.Line)
+}
+
+// printLine prints a single source line
+func (s *debugSession) printLine(path string, line int) {
+	if lines := s.source(path); line >= 1 && line <= len(lines) {
+		fmt.Fprintf(s.out, "%4d  %s\n", line, lines[line-1])
+	}
+}
+
+// source returns the lines of a file, reading it the first time it is needed
+func (s *debugSession) source(path string) []string {
+	if lines, ok := s.sources[path]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(string(data), "\n")
+	}
+	s.sources[path] = lines
+	return lines
+}
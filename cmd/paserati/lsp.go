@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nooga/paserati/pkg/lsp"
+)
+
+// runLSP implements "paserati lsp": a Language Server Protocol server on stdio
+func runLSP(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: paserati lsp\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+
+	// Anything printed with fmt would corrupt the protocol on stdout
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+
+	server := lsp.NewServer(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, protocolOut})
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "lsp: %s\n", err)
+		return 70
+	}
+	return 0
+}
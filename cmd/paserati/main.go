@@ -10,9 +10,12 @@ import (
 	"runtime/pprof"
 	"strings"
 
+	"github.com/nooga/paserati/pkg/aot"
 	"github.com/nooga/paserati/pkg/builtins"
 	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
 	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/vm"
 )
 
 // Version information - set via ldflags at build time
@@ -23,16 +26,49 @@ var (
 )
 
 func main() {
+	// An executable made by "paserati build" runs its program and nothing else
+	if exe, err := os.Executable(); err == nil {
+		if data, err := aot.ReadEmbeddedAssembly(exe); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read embedded program: %s\n", err.Error())
+			os.Exit(70)
+		} else if data != nil {
+			os.Exit(runEmbeddedAssembly(data))
+		}
+	}
+
+	// Subcommands take over the whole command line
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "debug":
+			os.Exit(runDebugger(os.Args[2:], os.Stdin, os.Stdout))
+		case "dap":
+			os.Exit(runDAP(os.Args[2:]))
+		case "lsp":
+			os.Exit(runLSP(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "compile":
+			os.Exit(runCompile(os.Args[2:]))
+		case "build":
+			os.Exit(runBuild(os.Args[2:]))
+		}
+	}
+
 	// Define flags
 	versionFlag := flag.Bool("version", false, "Print version information and exit")
 	exprFlag := flag.String("e", "", "Run the given expression and exit")
 	emitJSFlag := flag.Bool("js", false, "Emit JavaScript from TypeScript source file")
-	jsOutputFile := flag.String("o", "", "Output file for JavaScript emission (default: input file with .js extension)")
+	emitDTSFlag := flag.Bool("dts", false, "Emit a .d.ts declaration file from a TypeScript source file")
+	jsOutputFile := flag.String("o", "", "Output file for JavaScript or declaration emission (default: input file with .js or .d.ts extension)")
+	sourceMapFlag := flag.Bool("source-map", false, "With -js, also write a source map (output file with .map appended)")
 	cacheStatsFlag := flag.Bool("cache-stats", false, "Show inline cache statistics after execution")
 	bytecodeFlag := flag.Bool("bytecode", false, "Show compiled bytecode before execution")
 	disasmFilterFlag := flag.String("disasm-filter", "", "Filter disassembly output by function name")
 	astDumpFlag := flag.Bool("ast", false, "Show AST dump before type checking")
 	noTypecheckFlag := flag.Bool("no-typecheck", false, "Ignore TypeScript type errors (like paserati-test262)")
+	projectFlag := flag.String("project", "", "Use the given tsconfig.json instead of the one found in the current directory or its parents")
+	strictNullChecksFlag := flag.Bool("strict-null-checks", false, "Type check with strictNullChecks: null and undefined must be narrowed away before use")
+	watchFlag := flag.Bool("watch", false, "Run the script again whenever it or a module it imports changes")
 	cpuProfileFlag := flag.String("cpuprofile", "", "Write CPU profile to file (pprof)")
 	memProfileFlag := flag.String("memprofile", "", "Write heap profile to file (pprof)")
 
@@ -94,17 +130,39 @@ func main() {
 		}
 
 		inputFile := flag.Arg(0)
-		ok := driver.WriteJavaScriptFile(inputFile, *jsOutputFile)
+		var ok bool
+		if *sourceMapFlag {
+			ok = driver.WriteJavaScriptFileWithSourceMap(inputFile, *jsOutputFile)
+		} else {
+			ok = driver.WriteJavaScriptFile(inputFile, *jsOutputFile)
+		}
 		if !ok {
 			os.Exit(70) // Exit code 70: internal software error
 		}
 		return
 	}
 
+	project := projectOptions{path: *projectFlag, strictNullChecks: *strictNullChecksFlag}
+
+	// Declaration emission mode
+	if *emitDTSFlag {
+		if flag.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "Usage: paserati -dts [options] <input.ts>\n")
+			os.Exit(64) // Exit code 64: command line usage error
+		}
+
+		paserati := driver.NewPaserati()
+		loadProject(paserati, project, ".")
+		if !paserati.WriteDeclarationFile(flag.Arg(0), *jsOutputFile) {
+			os.Exit(70) // Exit code 70: internal software error
+		}
+		return
+	}
+
 	// Normal execution mode
 	if *exprFlag != "" {
 		// Run the expression provided via -e flag
-		runExpressionWithTypes(*exprFlag, *cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag)
+		runExpressionWithTypes(*exprFlag, *cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag, project)
 		return
 	}
 
@@ -112,10 +170,13 @@ func main() {
 		// Execute the script file provided as an argument
 		// Additional arguments after the script are passed as process.argv
 		scriptArgs := flag.Args() // [script, arg1, arg2, ...]
-		runFileWithTypes(scriptArgs[0], scriptArgs, *cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag)
+		if *watchFlag {
+			os.Exit(runWatch(scriptArgs[0], scriptArgs, *noTypecheckFlag, project))
+		}
+		runFileWithTypes(scriptArgs[0], scriptArgs, *cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag, project)
 	} else {
 		// No file provided, start the REPL
-		runReplWithTypes(*cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag)
+		runReplWithTypes(*cacheStatsFlag, *bytecodeFlag, *noTypecheckFlag, *disasmFilterFlag, project)
 	}
 }
 
@@ -137,8 +198,9 @@ func runExpression(expr string, showCacheStats bool, showBytecode bool) {
 	}
 }
 
-func runExpressionWithTypes(expr string, showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string) {
+func runExpressionWithTypes(expr string, showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string, project projectOptions) {
 	paserati := driver.NewPaserati()
+	loadProject(paserati, project, ".")
 	if ignoreTypes {
 		// Completely skip type checking for pure JS mode
 		paserati.SetSkipTypeCheck(true)
@@ -175,7 +237,7 @@ func runFile(filename string, showCacheStats bool, showBytecode bool) {
 	}
 }
 
-func runFileWithTypes(filename string, scriptArgs []string, showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string) {
+func runFileWithTypes(filename string, scriptArgs []string, showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string, project projectOptions) {
 	sourceBytes, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read file '%s': %s\n", filename, err.Error())
@@ -190,13 +252,26 @@ func runFileWithTypes(filename string, scriptArgs []string, showCacheStats bool,
 	initializers := builtins.GetStandardInitializers()
 	initializers = append(initializers, driver.NewProcessInitializer(argv))
 	paserati := driver.NewPaseratiWithInitializers(initializers)
+	loadProject(paserati, project, ".")
 	if ignoreTypes {
 		// Completely skip type checking for pure JS mode
 		paserati.SetSkipTypeCheck(true)
 	}
 
 	options := driver.RunOptions{ShowCacheStats: showCacheStats, ShowBytecode: showBytecode, ModuleName: filename, DisasmFilter: disasmFilter}
-	value, errs := paserati.RunCode(source, options)
+	var value vm.Value
+	var errs []errors.PaseratiError
+	if strings.HasSuffix(filename, aot.Extension) {
+		// Compiled with "paserati compile"
+		source = ""
+		value, errs = paserati.RunBinaryModule(sourceBytes, options)
+	} else if strings.HasSuffix(filename, aot.AssemblyExtension) {
+		// Built with "paserati build -assembly"
+		source = ""
+		value, errs = paserati.RunAssembly(sourceBytes, options)
+	} else {
+		value, errs = paserati.RunCode(source, options)
+	}
 	ok := paserati.DisplayResult(source, value, errs)
 	if !ok {
 		os.Exit(70)
@@ -246,9 +321,10 @@ func runRepl(showCacheStats bool, showBytecode bool) {
 	}
 }
 
-func runReplWithTypes(showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string) {
+func runReplWithTypes(showCacheStats bool, showBytecode bool, ignoreTypes bool, disasmFilter string, project projectOptions) {
 	reader := bufio.NewReader(os.Stdin)
 	paserati := driver.NewPaserati()
+	loadProject(paserati, project, ".")
 	if ignoreTypes {
 		// Completely skip type checking for pure JS mode
 		paserati.SetSkipTypeCheck(true)
@@ -282,6 +358,30 @@ func runReplWithTypes(showCacheStats bool, showBytecode bool, ignoreTypes bool,
 	}
 }
 
+// projectOptions are the type checking settings given on the command line,
+// which take precedence over the project's tsconfig.json
+type projectOptions struct {
+	path             string // tsconfig.json given with -project
+	strictNullChecks bool
+}
+
+// loadProject applies the tsconfig.json given with -project, exiting when it
+// can't be read, or else the one found in dir or its parents, and then the
+// other options
+func loadProject(paserati *driver.Paserati, project projectOptions, dir string) {
+	if project.path != "" {
+		if err := paserati.LoadConfig(project.path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load project: %s\n", err)
+			os.Exit(66) // Exit code 66: cannot open input
+		}
+	} else if path, err := paserati.LoadProjectConfig(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", path, err)
+	}
+	if project.strictNullChecks {
+		paserati.SetStrictNullChecks(true)
+	}
+}
+
 // containsImportsInString is a simple heuristic to detect import statements in REPL input
 // This avoids the need to fully parse the input just to detect imports
 func containsImportsInString(input string) bool {
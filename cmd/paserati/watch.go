@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nooga/paserati/pkg/builtins"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// runWatch runs a script, and runs it again whenever it or a module it
+// imports changes, until interrupted. A summary of each cycle goes to
+// stderr, so that the script's own output stands apart.
+func runWatch(filename string, scriptArgs []string, ignoreTypes bool, project projectOptions) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "paserati: %s\n", err)
+		return 70
+	}
+	entry := filename
+	if !filepath.IsAbs(entry) {
+		entry = filepath.Join(cwd, entry)
+	}
+	// Modules are resolved from the current directory, or from the script's
+	// when it lies outside
+	baseDir := cwd
+	if rel, err := filepath.Rel(cwd, entry); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		baseDir = filepath.Dir(entry)
+	}
+
+	argv := append([]string{"paserati"}, scriptArgs...)
+	initializers := append(builtins.GetStandardInitializers(), driver.NewProcessInitializer(argv))
+	paserati := driver.NewPaseratiWithInitializersAndBaseDir(initializers, baseDir)
+	loadProject(paserati, project, baseDir)
+	if ignoreTypes {
+		paserati.SetSkipTypeCheck(true)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = paserati.Watch(ctx, entry, driver.WatchOptions{
+		OnCycle: func(cycle *driver.WatchCycle) { writeWatchCycle(os.Stderr, filename, cycle, baseDir, cwd) },
+		OnRun:   func(run *driver.WatchRun) { writeWatchRun(os.Stderr, run, baseDir, cwd) },
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "paserati: %s\n", err)
+		return 66 // Exit code 66: cannot open input
+	}
+	return 0
+}
+
+// writeWatchCycle writes what changed, what was compiled again and the
+// errors found, one line each
+func writeWatchCycle(w io.Writer, filename string, cycle *driver.WatchCycle, baseDir, cwd string) {
+	compiled := fmt.Sprintf("%s compiled, %d cached, in %s", plural(len(cycle.Rebuilt), "module"), cycle.Cached, roundDuration(cycle.Duration))
+	if len(cycle.Changed) == 0 {
+		fmt.Fprintf(w, "[watch] %s: %s\n", filename, compiled)
+	} else {
+		fmt.Fprintf(w, "[watch] %s changed: %s\n", strings.Join(cycle.Changed, ", "), compiled)
+	}
+	writeWatchErrors(w, cycle.Errors, baseDir, cwd)
+	if len(cycle.Errors) > 0 {
+		fmt.Fprintf(w, "[watch] %s, not running; waiting for changes\n", plural(len(cycle.Errors), "error"))
+	}
+}
+
+// writeWatchRun writes how a run of the script ended
+func writeWatchRun(w io.Writer, run *driver.WatchRun, baseDir, cwd string) {
+	switch {
+	case run.Interrupted:
+		fmt.Fprintf(w, "[watch] stopped after %s\n", roundDuration(run.Duration))
+	case len(run.Errors) > 0:
+		writeWatchErrors(w, run.Errors, baseDir, cwd)
+		fmt.Fprintf(w, "[watch] failed after %s; waiting for changes\n", roundDuration(run.Duration))
+	default:
+		fmt.Fprintf(w, "[watch] done in %s; waiting for changes\n", roundDuration(run.Duration))
+	}
+}
+
+// writeWatchErrors writes errors in the format of paserati check
+func writeWatchErrors(w io.Writer, errs []errors.PaseratiError, baseDir, cwd string) {
+	for _, err := range errs {
+		d := newDiagnostic(err, baseDir, cwd)
+		if d.File == "" {
+			fmt.Fprintf(w, "%s %s: %s\n", d.Severity, d.Code, d.Message)
+			continue
+		}
+		fmt.Fprintf(w, "%s:%d:%d: %s %s: %s\n", d.File, d.Line, d.Column, d.Severity, d.Code, d.Message)
+	}
+}
+
+// roundDuration rounds d for display
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
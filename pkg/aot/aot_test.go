@@ -0,0 +1,277 @@
+package aot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nooga/paserati/pkg/compiler"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+const testSource = `
+enum Color { Red, Green = "green", Blue = 4 }
+var counter = 0;
+let big = 12345678901234567890n;
+function tag(strings: TemplateStringsArray, ...values: any[]): string {
+  return strings.raw.join("|") + values.length;
+}
+class Point {
+  #secret = 1;
+  constructor(public x: number, public y: number) {}
+  get sum(): number { return this.x + this.y + this.#secret; }
+}
+function* numbers() { yield 1; yield 2.5; }
+async function later(): Promise<number> { return 1; }
+function guarded(): string {
+  try {
+    throw new Error("boom");
+  } catch (e) {
+    return tag` + "`a${counter}\\n${big}`" + `;
+  } finally {
+    counter++;
+  }
+}
+const arrow = (a: number) => a * 2;
+`
+
+// compileModule compiles source with heap, mapping every allocated name into
+// the module's global table
+func compileModule(t *testing.T, source string, heap *compiler.HeapAlloc) *Module {
+	t.Helper()
+	p := parser.NewParser(lexer.NewLexer(source))
+	program, parseErrs := p.ParseProgram()
+	if len(parseErrs) > 0 {
+		t.Fatalf("parse errors: %v", parseErrs)
+	}
+	c := compiler.NewCompiler()
+	c.SetHeapAlloc(heap)
+	chunk, errs := c.Compile(program)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+	globals := make(map[int]string)
+	for name, index := range heap.GetNameToIndexMap() {
+		globals[index] = name
+	}
+	return &Module{
+		Path:       "test.ts",
+		SourceHash: HashSource(source),
+		Chunk:      chunk,
+		Imports:    []Import{{Specifier: "./dep"}, {Specifier: "./data.json", Type: "json"}},
+		Exports:    map[string]int{"counter": heap.GetOrAssignIndex("counter")},
+		Globals:    globals,
+	}
+}
+
+func encode(t *testing.T, m *Module) []byte {
+	t.Helper()
+	data, err := Encode(m)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return data
+}
+
+// operandNames returns the global names the module's top-level code refers to
+func operandNames(m *Module) []string {
+	var names []string
+	for _, operand := range globalOperands(m.Chunk) {
+		names = append(names, m.Globals[int(binary.BigEndian.Uint16(m.Chunk.Code[operand:]))])
+	}
+	return names
+}
+
+func TestRoundTrip(t *testing.T) {
+	heap := compiler.NewHeapAlloc()
+	m := compileModule(t, testSource, heap)
+	data := encode(t, m)
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.Path != m.Path || decoded.SourceHash != m.SourceHash {
+		t.Fatalf("header mismatch: %+v", decoded)
+	}
+	if len(decoded.Imports) != 2 || decoded.Imports[1] != (Import{"./data.json", "json"}) {
+		t.Fatalf("unexpected imports %+v", decoded.Imports)
+	}
+	if decoded.Exports["counter"] != m.Exports["counter"] {
+		t.Fatalf("unexpected exports %v", decoded.Exports)
+	}
+	want := m.Chunk.DisassembleChunk("test")
+	if got := decoded.Chunk.DisassembleChunk("test"); got != want {
+		t.Fatalf("disassembly differs after round trip:\n--- want\n%s\n--- got\n%s", want, got)
+	}
+	if len(decoded.Chunk.Positions) != len(m.Chunk.Positions) || len(decoded.Chunk.VarGlobalIndices) != len(m.Chunk.VarGlobalIndices) {
+		t.Fatalf("position or var tables differ")
+	}
+
+	// Encoding is deterministic, also for a decoded module
+	if again := encode(t, decoded); !bytes.Equal(again, data) {
+		t.Fatalf("re-encoding a decoded module changed it")
+	}
+}
+
+func TestLinkRelocatesGlobals(t *testing.T) {
+	m := compileModule(t, testSource, compiler.NewHeapAlloc())
+	decoded, err := Decode(encode(t, m))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	names := operandNames(decoded)
+	if len(names) == 0 {
+		t.Fatalf("expected global references in the test module")
+	}
+
+	// A session with other globals already allocated
+	heap := compiler.NewHeapAlloc()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		heap.GetOrAssignIndex(name)
+	}
+	if err := decoded.Link(heap.GetOrAssignIndex); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if got := operandNames(decoded); strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Fatalf("operands refer to other globals after linking: %v, want %v", got, names)
+	}
+	for index, name := range decoded.Globals {
+		if heapIndex, _ := heap.GetIndex(name); heapIndex != index {
+			t.Fatalf("global %s at %d, heap has it at %d", name, index, heapIndex)
+		}
+	}
+	counter, _ := heap.GetIndex("counter")
+	if decoded.Exports["counter"] != counter || counter < 5 {
+		t.Fatalf("export not relocated: %v", decoded.Exports)
+	}
+	for _, index := range decoded.Chunk.VarGlobalIndices {
+		if decoded.Globals[int(index)] == "" {
+			t.Fatalf("var global index %d not relocated", index)
+		}
+	}
+
+	// Linking again does nothing
+	before := append([]byte(nil), decoded.Chunk.Code...)
+	if err := decoded.Link(func(string) int { return 0 }); err != nil || !bytes.Equal(before, decoded.Chunk.Code) {
+		t.Fatalf("second Link changed the module: %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	data := encode(t, compileModule(t, testSource, compiler.NewHeapAlloc()))
+
+	if _, err := Decode([]byte("#!/bin/sh")); err == nil || !strings.Contains(err.Error(), "not a compiled") {
+		t.Fatalf("expected a magic error, got %v", err)
+	}
+	wrongVersion := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(wrongVersion[4:], Version+1)
+	if _, err := Decode(wrongVersion); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected ErrIncompatible, got %v", err)
+	}
+	wrongEngine := append([]byte(nil), data...)
+	wrongEngine[12] ^= 0xff
+	if _, err := Decode(wrongEngine); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected ErrIncompatible, got %v", err)
+	}
+
+	// Every truncation and single-byte corruption is rejected or decodes to
+	// something, but never panics
+	for n := 0; n < len(data); n++ {
+		if _, err := Decode(data[:n]); err == nil {
+			t.Fatalf("truncation to %d bytes decoded", n)
+		}
+	}
+	for i := 24; i < len(data); i++ {
+		corrupt := append([]byte(nil), data...)
+		corrupt[i] ^= 0x5a
+		Decode(corrupt)
+	}
+	if _, err := Decode(append(append([]byte(nil), data...), 0)); err == nil {
+		t.Fatalf("trailing data decoded")
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	m := compileModule(t, testSource, compiler.NewHeapAlloc())
+	m.Globals = map[int]string{}
+	if _, err := Encode(m); err == nil || !strings.Contains(err.Error(), "unnamed heap index") {
+		t.Fatalf("expected an unnamed global error, got %v", err)
+	}
+
+	chunk := vm.NewChunk()
+	chunk.AddConstant(vm.NewObject(vm.DefaultObjectPrototype))
+	if _, err := Encode(&Module{Path: "x.ts", Chunk: chunk}); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected an unsupported constant error, got %v", err)
+	}
+}
+
+func TestAssemblyRoundTrip(t *testing.T) {
+	heap := compiler.NewHeapAlloc()
+	a := &Assembly{
+		Entry:   "main.ts",
+		Modules: map[string]*Module{"main.ts": compileModule(t, testSource, heap), "./dep": compileModule(t, "export const x = 1;", heap)},
+		JSON:    map[string]string{"./data.json": `{"a": 1}`},
+	}
+	data, err := EncodeAssembly(a)
+	if err != nil {
+		t.Fatalf("EncodeAssembly: %v", err)
+	}
+	decoded, err := DecodeAssembly(data)
+	if err != nil {
+		t.Fatalf("DecodeAssembly: %v", err)
+	}
+	if decoded.Entry != "main.ts" || len(decoded.Modules) != 2 || decoded.JSON["./data.json"] != `{"a": 1}` {
+		t.Fatalf("unexpected assembly %+v", decoded)
+	}
+	want := a.Modules["main.ts"].Chunk.DisassembleChunk("test")
+	if got := decoded.Modules["main.ts"].Chunk.DisassembleChunk("test"); got != want {
+		t.Fatalf("entry disassembly differs after round trip")
+	}
+
+	r := NewAssemblyResolver(decoded, compiler.NewHeapAlloc().GetOrAssignIndex)
+	if !r.CanResolve("./dep") || !r.CanResolve("./data.json") || r.CanResolve("./other") {
+		t.Fatalf("resolver serves the wrong specifiers")
+	}
+	if _, err := r.Resolve("./other", "main.ts"); err == nil {
+		t.Fatalf("expected an error for a module outside the assembly")
+	}
+
+	for n := 0; n < len(data); n++ {
+		if _, err := DecodeAssembly(data[:n]); err == nil {
+			t.Fatalf("truncation to %d bytes decoded", n)
+		}
+	}
+	if _, err := EncodeAssembly(&Assembly{Entry: "missing.ts"}); err == nil {
+		t.Fatalf("expected an error for a missing entry")
+	}
+}
+
+func TestEmbedAssembly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app")
+	exe := []byte("\x7fELF not really an executable")
+	if err := os.WriteFile(path, exe, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ReadEmbeddedAssembly(path); err != nil || data != nil {
+		t.Fatalf("expected no assembly, got %q, %v", data, err)
+	}
+
+	// Embedding again replaces the assembly
+	built := EmbedAssembly(EmbedAssembly(exe, []byte("first")), []byte("second"))
+	if err := os.WriteFile(path, built, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ReadEmbeddedAssembly(path); err != nil || string(data) != "second" {
+		t.Fatalf("expected the second assembly, got %q, %v", data, err)
+	}
+	if !bytes.HasPrefix(built, exe) || len(built) != len(exe)+len("second")+trailerSize {
+		t.Fatalf("unexpected executable layout")
+	}
+}
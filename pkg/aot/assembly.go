@@ -0,0 +1,207 @@
+package aot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/modules"
+)
+
+// An assembly bundles the modules reachable from an entry point, so that a
+// program runs from a single blob without touching the file system. Modules
+// are stored under the specifier they are imported with, which is how the
+// module loader identifies them.
+//
+// The layout, all integers being unsigned varints unless noted:
+//
+//	magic "PSRA", version uint32 LE, flags uint32 LE, engine uint32 LE
+//	entry:    length-prefixed specifier
+//	modules:  count, then {kind byte, length-prefixed specifier,
+//	          length-prefixed payload}
+//
+// A payload is a .psm file for assemblyModule entries and the JSON text for
+// assemblyJSON entries.
+
+// AssemblyMagic identifies an assembly
+const AssemblyMagic = "PSRA"
+
+// AssemblyExtension is the file extension of assemblies
+const AssemblyExtension = ".psra"
+
+// Assembly entry kinds
+const (
+	assemblyModule = 0x01
+	assemblyJSON   = 0x02
+)
+
+// Assembly is a program compiled to a set of modules
+type Assembly struct {
+	Entry   string             // Key of the module the program starts with
+	Modules map[string]*Module // Compiled modules, by specifier
+	JSON    map[string]string  // JSON modules' text, by specifier
+}
+
+// EncodeAssembly serializes an assembly. Each module is encoded as by Encode.
+func EncodeAssembly(a *Assembly) ([]byte, error) {
+	if a.Modules[a.Entry] == nil {
+		return nil, fmt.Errorf("aot: assembly entry %s is not one of its modules", a.Entry)
+	}
+	specifiers := make([]string, 0, len(a.Modules)+len(a.JSON))
+	for specifier := range a.Modules {
+		specifiers = append(specifiers, specifier)
+	}
+	for specifier := range a.JSON {
+		if a.Modules[specifier] != nil {
+			return nil, fmt.Errorf("aot: %s is both a module and a JSON module", specifier)
+		}
+		specifiers = append(specifiers, specifier)
+	}
+	sort.Strings(specifiers)
+
+	var out buffer
+	out = append(out, AssemblyMagic...)
+	out.uint32(Version)
+	out.uint32(0) // Flags, reserved
+	out.uint32(engineHash)
+	out.bytes([]byte(a.Entry))
+	out.uint(len(specifiers))
+	for _, specifier := range specifiers {
+		if m := a.Modules[specifier]; m != nil {
+			data, err := Encode(m)
+			if err != nil {
+				return nil, err
+			}
+			out.byte(assemblyModule)
+			out.bytes([]byte(specifier))
+			out.bytes(data)
+		} else {
+			out.byte(assemblyJSON)
+			out.bytes([]byte(specifier))
+			out.bytes([]byte(a.JSON[specifier]))
+		}
+	}
+	return out, nil
+}
+
+// DecodeAssembly parses an assembly. Its modules must be linked with
+// Module.Link before they run.
+func DecodeAssembly(data []byte) (*Assembly, error) {
+	r := &reader{data: data}
+	if string(r.fixed(len(AssemblyMagic))) != AssemblyMagic {
+		return nil, errors.New("aot: not a Paserati assembly")
+	}
+	version := r.uint32()
+	r.uint32() // Flags, reserved
+	engine := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if version != Version || engine != engineHash {
+		return nil, ErrIncompatible
+	}
+
+	a := &Assembly{
+		Entry:   string(r.fixed(r.count())),
+		Modules: make(map[string]*Module),
+		JSON:    make(map[string]string),
+	}
+	for i, n := 0, r.count(); i < n && r.err == nil; i++ {
+		kind := r.byte()
+		specifier := string(r.fixed(r.count()))
+		payload := r.fixed(r.count())
+		if r.err != nil {
+			break
+		}
+		switch kind {
+		case assemblyModule:
+			m, err := Decode(payload)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", specifier, err)
+			}
+			a.Modules[specifier] = m
+		case assemblyJSON:
+			a.JSON[specifier] = string(payload)
+		default:
+			r.fail("unknown assembly entry kind %d", kind)
+		}
+	}
+	if r.err == nil && r.pos != len(r.data) {
+		r.fail("%d trailing bytes", len(r.data)-r.pos)
+	}
+	if r.err == nil && a.Modules[a.Entry] == nil {
+		r.fail("assembly entry %s is missing", a.Entry)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return a, nil
+}
+
+// AssemblyResolver is a module resolver serving the modules of an assembly.
+// It runs ahead of the file system resolvers, so that an assembled program
+// never picks up modules from the machine it runs on.
+type AssemblyResolver struct {
+	assembly *Assembly
+	indexOf  func(name string) int
+	priority int
+}
+
+// NewAssemblyResolver creates a resolver for the modules of assembly. Loaded
+// modules are linked with indexOf, which must be the loading session's heap
+// allocator.
+func NewAssemblyResolver(assembly *Assembly, indexOf func(name string) int) *AssemblyResolver {
+	return &AssemblyResolver{
+		assembly: assembly,
+		indexOf:  indexOf,
+		priority: 10, // After native modules, ahead of everything else
+	}
+}
+
+// Name returns the resolver name
+func (r *AssemblyResolver) Name() string {
+	return "Assembly"
+}
+
+// CanResolve returns true for the specifiers the assembly holds
+func (r *AssemblyResolver) CanResolve(specifier string) bool {
+	_, isJSON := r.assembly.JSON[specifier]
+	return isJSON || r.assembly.Modules[specifier] != nil
+}
+
+// Priority returns the resolver priority
+func (r *AssemblyResolver) Priority() int {
+	return r.priority
+}
+
+// SetPriority sets the resolver priority
+func (r *AssemblyResolver) SetPriority(priority int) {
+	r.priority = priority
+}
+
+// Resolve resolves a specifier to one of the assembly's modules
+func (r *AssemblyResolver) Resolve(specifier string, fromPath string) (*modules.ResolvedModule, error) {
+	if text, ok := r.assembly.JSON[specifier]; ok {
+		return &modules.ResolvedModule{
+			Specifier:    specifier,
+			ResolvedPath: specifier,
+			Source:       io.NopCloser(strings.NewReader(text)),
+			Resolver:     r.Name(),
+		}, nil
+	}
+	m := r.assembly.Modules[specifier]
+	if m == nil {
+		return nil, fmt.Errorf("module %s is not in the assembly", specifier)
+	}
+	if err := m.Link(r.indexOf); err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Path, err)
+	}
+	return &modules.ResolvedModule{
+		Specifier:    specifier,
+		ResolvedPath: m.Path,
+		Source:       &ModuleSource{Module: m},
+		Resolver:     r.Name(),
+	}, nil
+}
@@ -0,0 +1,418 @@
+package aot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// ErrIncompatible is returned by Decode for files written by a Paserati
+// build with a different format version or instruction encoding
+var ErrIncompatible = errors.New("aot: compiled by an incompatible Paserati version")
+
+// maxValueDepth bounds the nesting of constants, which only templates and
+// enums introduce
+const maxValueDepth = 8
+
+// reader decodes the format's primitives from a byte slice. The first error
+// sticks; later reads return zero values so that decoding can finish its loop
+// and report it once.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("aot: "+format, args...)
+	}
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint at offset %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *reader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint at offset %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+// uint reads an unsigned varint that must not exceed max
+func (r *reader) uint(max int) int {
+	v := r.uvarint()
+	if v > uint64(max) {
+		r.fail("value %d out of range at offset %d", v, r.pos)
+		return 0
+	}
+	return int(v)
+}
+
+// int reads a signed varint that fits in an int32
+func (r *reader) int() int {
+	v := r.varint()
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		r.fail("value %d out of range at offset %d", v, r.pos)
+		return 0
+	}
+	return int(v)
+}
+
+// count reads a length prefix, bounded by the bytes left so that corrupt
+// input cannot cause huge allocations
+func (r *reader) count() int {
+	return r.uint(len(r.data) - r.pos)
+}
+
+func (r *reader) fixed(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data)-r.pos < n {
+		r.fail("unexpected end of data")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.fixed(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.fixed(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// flags reads a flags bitmask as written by buffer.flags
+func (r *reader) flags() uint64 {
+	return r.uvarint()
+}
+
+// decoder resolves string and chunk references while reading
+type decoder struct {
+	reader
+	strings []string
+	chunks  []*vm.Chunk
+	relocs  map[*vm.Chunk][]int
+}
+
+func (d *decoder) string() string {
+	if len(d.strings) == 0 {
+		d.fail("string reference without a string table")
+		return ""
+	}
+	if index := d.uint(len(d.strings) - 1); d.err == nil {
+		return d.strings[index]
+	}
+	return ""
+}
+
+// Decode parses a .psm file. The module it returns must be linked with
+// Module.Link before its chunk runs.
+func Decode(data []byte) (*Module, error) {
+	d := &decoder{reader: reader{data: data}}
+	if string(d.fixed(len(Magic))) != Magic {
+		return nil, errors.New("aot: not a compiled Paserati module")
+	}
+	version := d.uint32()
+	d.uint32() // Flags, reserved
+	engine := d.uint32()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if version != Version || engine != engineHash {
+		return nil, ErrIncompatible
+	}
+	m := &Module{SourceHash: d.uint64()}
+
+	d.strings = make([]string, d.count())
+	for i := range d.strings {
+		d.strings[i] = string(d.fixed(d.count()))
+	}
+	m.Path = d.string()
+
+	m.Globals = make(map[int]string)
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		index := d.uint(math.MaxUint16)
+		m.Globals[index] = d.string()
+	}
+	m.Imports = make([]Import, d.count())
+	for i := range m.Imports {
+		m.Imports[i] = Import{Specifier: d.string(), Type: d.string()}
+	}
+	m.Exports = make(map[string]int)
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		name := d.string()
+		m.Exports[name] = d.global(m)
+	}
+
+	// Chunks are allocated up front so that function constants can refer to
+	// chunks that come after them
+	d.chunks = make([]*vm.Chunk, d.count())
+	if len(d.chunks) == 0 {
+		d.fail("module has no chunks")
+	}
+	for i := range d.chunks {
+		d.chunks[i] = vm.NewChunk()
+	}
+	d.relocs = make(map[*vm.Chunk][]int, len(d.chunks))
+	for _, chunk := range d.chunks {
+		if d.err != nil {
+			break
+		}
+		d.chunk(m, chunk)
+	}
+	if d.err == nil && d.pos != len(d.data) {
+		d.fail("%d trailing bytes", len(d.data)-d.pos)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	m.Chunk = d.chunks[0]
+	m.relocs = d.relocs
+	return m, nil
+}
+
+// global reads a heap index, which must be named in the globals table
+func (d *decoder) global(m *Module) int {
+	index := d.uint(math.MaxUint16)
+	if _, ok := m.Globals[index]; !ok && d.err == nil {
+		d.fail("unnamed heap index %d", index)
+	}
+	return index
+}
+
+func (d *decoder) chunk(m *Module, c *vm.Chunk) {
+	c.Code = append([]byte(nil), d.fixed(d.count())...)
+	c.Positions = make([]vm.PositionRun, d.count())
+	offset, line := 0, 0
+	for i := range c.Positions {
+		offset += d.uint(len(c.Code))
+		line += d.int()
+		if offset >= len(c.Code) && d.err == nil {
+			d.fail("position run at %d outside code", offset)
+		}
+		c.Positions[i] = vm.PositionRun{Offset: offset, Line: line, Column: d.uint(math.MaxInt32)}
+	}
+
+	c.Constants = make([]vm.Value, d.count())
+	for i := range c.Constants {
+		c.Constants[i] = d.value(0)
+	}
+
+	c.ExceptionTable = make([]vm.ExceptionHandler, d.count())
+	for i := range c.ExceptionTable {
+		h := &c.ExceptionTable[i]
+		h.TryStart = d.uint(len(c.Code))
+		h.TryEnd = d.uint(len(c.Code))
+		h.HandlerPC = d.uint(len(c.Code))
+		h.CatchReg = d.int()
+		h.FinallyReg = d.int()
+		flags := d.flags()
+		h.IsCatch = flags&1 != 0
+		h.IsFinally = flags&2 != 0
+		h.IsIteratorCleanup = flags&4 != 0
+	}
+
+	c.MaxRegs = d.uint(math.MaxInt32)
+	c.NumSpillSlots = d.uint(math.MaxInt32)
+	flags := d.flags()
+	c.IsStrict = flags&1 != 0
+	c.HasSimpleParameterList = flags&2 != 0
+	if flags&4 != 0 {
+		c.ScopeDesc = d.scope()
+	}
+
+	if n := d.count(); n > 0 {
+		c.VarGlobalIndices = make([]uint16, n)
+		for i := range c.VarGlobalIndices {
+			c.VarGlobalIndices[i] = uint16(d.global(m))
+		}
+	}
+
+	relocs := make([]int, d.count())
+	operand := 0
+	for i := range relocs {
+		operand += d.uint(len(c.Code))
+		if operand+2 > len(c.Code) {
+			d.fail("relocation at %d outside code", operand)
+			return
+		}
+		if index := int(binary.BigEndian.Uint16(c.Code[operand:])); d.err == nil {
+			if _, ok := m.Globals[index]; !ok {
+				d.fail("unnamed heap index %d", index)
+			}
+		}
+		relocs[i] = operand
+	}
+	d.relocs[c] = relocs
+}
+
+func (d *decoder) value(depth int) vm.Value {
+	if depth > maxValueDepth {
+		d.fail("constants nested too deeply")
+		return vm.Undefined
+	}
+	switch tag := d.byte(); tag {
+	case tagUndefined:
+		return vm.Undefined
+	case tagNull:
+		return vm.Null
+	case tagTrue:
+		return vm.True
+	case tagFalse:
+		return vm.False
+	case tagInteger:
+		return vm.IntegerValue(int32(d.int()))
+	case tagFloat:
+		return vm.NumberValue(math.Float64frombits(d.uint64()))
+	case tagBigInt:
+		n, ok := new(big.Int).SetString(d.string(), 10)
+		if !ok {
+			d.fail("bad bigint constant")
+			return vm.Undefined
+		}
+		return vm.NewBigInt(n)
+	case tagString:
+		return vm.NewString(d.string())
+	case tagFunction:
+		chunk := d.chunks[d.uint(len(d.chunks)-1)]
+		name := d.string()
+		arity := d.uint(math.MaxInt32)
+		length := d.uint(math.MaxInt32)
+		upvalueCount := d.uint(math.MaxInt32)
+		registerSize := d.uint(math.MaxInt32)
+		nameBinding := d.int()
+		flags := d.flags()
+		fn := vm.NewFunction(arity, length, upvalueCount, registerSize, flags&1 != 0, name, chunk,
+			flags&2 != 0, flags&4 != 0, flags&8 != 0, flags&64 != 0)
+		f := fn.AsFunction()
+		f.IsDerivedConstructor = flags&16 != 0
+		f.IsClassConstructor = flags&32 != 0
+		f.NameBindingRegister = nameBinding
+		return fn
+	case tagTemplate:
+		cookedValue := vm.NewArray()
+		cooked := cookedValue.AsArray()
+		for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+			cooked.Append(d.value(depth + 1))
+		}
+		rawValue := vm.NewArray()
+		raw := rawValue.AsArray()
+		for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+			raw.Append(vm.NewString(d.string()))
+		}
+		raw.SetExtensible(false)
+		raw.SetFrozen(true)
+		cooked.DefineOwnProperty("raw", rawValue, false, false, false)
+		cooked.SetExtensible(false)
+		cooked.SetFrozen(true)
+		return cookedValue
+	case tagEnum:
+		enumValue := vm.NewDictObject(vm.DefaultObjectPrototype)
+		enum := enumValue.AsDictObject()
+		for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+			key := d.string()
+			enum.SetOwn(key, d.value(depth+1))
+		}
+		return enumValue
+	default:
+		d.fail("unknown constant tag 0x%02x", tag)
+		return vm.Undefined
+	}
+}
+
+func (d *decoder) scope() *vm.ScopeDescriptor {
+	s := &vm.ScopeDescriptor{
+		LocalNames:      d.strs(),
+		LexicalBindings: d.strs(),
+	}
+	flags := d.flags()
+	s.HasArgumentsBinding = flags&1 != 0
+	s.InDefaultParameterScope = flags&2 != 0
+	s.HasSuperBinding = flags&4 != 0
+	s.InClassFieldInitializer = flags&8 != 0
+	s.CurrentPrivateBrand = d.int()
+	if flags&16 != 0 {
+		info := d.brand()
+		s.CurrentPrivateBrandInfo = &info
+	}
+	if n := d.count(); n > 0 {
+		s.PrivateBrandStack = make([]vm.PrivateBrandInfoVM, n)
+		for i := range s.PrivateBrandStack {
+			s.PrivateBrandStack[i] = d.brand()
+		}
+	}
+	return s
+}
+
+func (d *decoder) brand() vm.PrivateBrandInfoVM {
+	info := vm.PrivateBrandInfoVM{
+		BrandID:        d.int(),
+		DeclaredFields: make(map[string]bool),
+		MemberKinds:    make(map[string]vm.PrivateMemberKindVM),
+	}
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		name := d.string()
+		info.DeclaredFields[name] = d.flags()&1 != 0
+	}
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		name := d.string()
+		info.MemberKinds[name] = vm.PrivateMemberKindVM(d.uint(math.MaxUint8))
+	}
+	return info
+}
+
+func (d *decoder) strs() []string {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	list := make([]string, n)
+	for i := range list {
+		list[i] = d.string()
+	}
+	return list
+}
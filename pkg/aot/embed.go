@@ -0,0 +1,74 @@
+package aot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// An executable carrying an assembly is a copy of the paserati binary with
+// the assembly appended, followed by a trailer: the assembly's length as a
+// uint64 LE and payloadMagic. Executable formats ignore data past their
+// sections, so the copy still runs, and finds its program by reading its own
+// file backwards.
+
+// payloadMagic ends an executable with an embedded assembly
+const payloadMagic = "PSRAEXE\x00"
+
+const trailerSize = 8 + len(payloadMagic)
+
+// EmbedAssembly returns the executable exe with assembly appended. An
+// assembly already embedded in exe is replaced.
+func EmbedAssembly(exe []byte, assembly []byte) []byte {
+	if n, ok := payloadSize(exe[max(0, len(exe)-trailerSize):], int64(len(exe))); ok {
+		exe = exe[:len(exe)-trailerSize-int(n)]
+	}
+	out := make(buffer, 0, len(exe)+len(assembly)+trailerSize)
+	out = append(out, exe...)
+	out = append(out, assembly...)
+	out.uint64(uint64(len(assembly)))
+	return append(out, payloadMagic...)
+}
+
+// ReadEmbeddedAssembly returns the assembly embedded in the executable at
+// path, or nil if it has none
+func ReadEmbeddedAssembly(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < int64(trailerSize) {
+		return nil, nil
+	}
+	trailer := make([]byte, trailerSize)
+	if _, err := f.ReadAt(trailer, size-int64(trailerSize)); err != nil {
+		return nil, err
+	}
+	n, ok := payloadSize(trailer, size)
+	if !ok {
+		return nil, nil
+	}
+	data := make([]byte, n)
+	if _, err := f.ReadAt(data, size-int64(trailerSize)-int64(n)); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read embedded assembly: %w", err)
+	}
+	return data, nil
+}
+
+// payloadSize decodes a trailer, checking the length it records against the
+// size of the file it ends
+func payloadSize(trailer []byte, fileSize int64) (uint64, bool) {
+	if len(trailer) != trailerSize || !bytes.Equal(trailer[8:], []byte(payloadMagic)) {
+		return 0, false
+	}
+	n := binary.LittleEndian.Uint64(trailer)
+	return n, n <= uint64(fileSize-int64(trailerSize))
+}
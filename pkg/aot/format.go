@@ -0,0 +1,118 @@
+// Package aot serializes compiled modules to the .psm binary format and loads
+// them back, so that a module can run without being parsed, checked and
+// compiled again. See docs/aot-compilation-design.md for the background.
+//
+// A .psm file holds a module's chunk tree (bytecode, constants including
+// nested function templates, source positions, exception table, scope descriptors
+// and var global indices) together with the names of the heap slots its
+// bytecode refers to. Heap indices are only meaningful within the session
+// that compiled the module, so a loaded module must be linked against the
+// loading session's heap layout with Module.Link before it runs.
+//
+// The layout, all integers being unsigned varints unless noted:
+//
+//	magic "PSRT", version uint32 LE, flags uint32 LE, engine uint32 LE,
+//	source hash uint64 LE
+//	strings:  count, then length-prefixed bytes for each
+//	path:     string index
+//	globals:  count, then {heap index, name string index}
+//	imports:  count, then {specifier string index, type string index}
+//	exports:  count, then {name string index, heap index}
+//	chunks:   count, then each chunk; chunk 0 is the module's top level
+//
+// Function constants refer to their chunk by its index in the chunk table.
+package aot
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Magic identifies a .psm file
+const Magic = "PSRT"
+
+// Version is the version of the format written by Encode. Decode rejects
+// other versions.
+const Version = 2
+
+// Extension is the file extension of compiled modules
+const Extension = ".psm"
+
+// Constant pool tags
+const (
+	tagUndefined = 0x01
+	tagNull      = 0x02
+	tagTrue      = 0x03
+	tagFalse     = 0x04
+	tagInteger   = 0x10 // zigzag varint
+	tagFloat     = 0x11 // float64 bits, LE
+	tagBigInt    = 0x12 // decimal digits as a string index
+	tagString    = 0x20 // string index
+	tagFunction  = 0x30 // chunk index + function metadata
+	tagTemplate  = 0x40 // tagged template object: cooked and raw strings
+	tagEnum      = 0x50 // enum object: {key, value} pairs
+)
+
+// Module is a compiled module as stored in a .psm file
+type Module struct {
+	Path       string         // Module path the source was compiled as
+	SourceHash uint64         // HashSource of the source, to detect stale files
+	Chunk      *vm.Chunk      // Top-level chunk
+	Imports    []Import       // Modules imported statically, in source order
+	Exports    map[string]int // Exported names and the heap indices holding them
+	Globals    map[int]string // Names of the heap indices the module refers to
+
+	linked bool
+	relocs map[*vm.Chunk][]int // Offsets of global index operands, by chunk
+}
+
+// Import is a static import of a module
+type Import struct {
+	Specifier string
+	Type      string // Value of the "type" import attribute, e.g. "json"
+}
+
+// HashSource returns the hash recorded in Module.SourceHash for a source text
+func HashSource(source string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(source))
+	return h.Sum64()
+}
+
+// engineHash fingerprints the opcode set, so that files written by a
+// Paserati build with a different instruction encoding are rejected
+var engineHash = func() uint32 {
+	h := fnv.New32a()
+	var buf [binary.MaxVarintLen32]byte
+	for op := 0; op < 256; op++ {
+		name := vm.OpCode(op).String()
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(name)))])
+		h.Write([]byte(name))
+	}
+	return h.Sum32()
+}()
+
+// globalOperand returns the offset of the heap index operand of the
+// instruction at offset, or -1 if it has none
+func globalOperand(op vm.OpCode, offset int) int {
+	switch op {
+	case vm.OpGetGlobal, vm.OpDeleteGlobal: // Rx Idx16
+		return offset + 2
+	case vm.OpSetGlobal, vm.OpSetGlobalInit: // Idx16 Ry
+		return offset + 1
+	}
+	return -1
+}
+
+// globalOperands returns the offsets of the heap index operands in chunk's code
+func globalOperands(chunk *vm.Chunk) []int {
+	var offsets []int
+	for _, offset := range chunk.InstructionOffsets() {
+		if operand := globalOperand(chunk.OriginalOpCode(offset), offset); operand >= 0 {
+			offsets = append(offsets, operand)
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,118 @@
+package aot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Link rewrites the module's heap indices for the loading session. indexOf
+// returns the session's heap index for a global name, allocating one if
+// needed; the driver passes its HeapAlloc's GetOrAssignIndex. Since globals
+// and module exports share the heap by name, a linked module reads the same
+// slots as freshly compiled code would. Link is a no-op after the first call.
+func (m *Module) Link(indexOf func(name string) int) error {
+	if m.linked {
+		return nil
+	}
+
+	// Sort the names so that allocation order does not depend on map order
+	old := make([]int, 0, len(m.Globals))
+	for index := range m.Globals {
+		old = append(old, index)
+	}
+	sort.Ints(old)
+	mapping := make(map[int]int, len(old))
+	globals := make(map[int]string, len(old))
+	for _, index := range old {
+		name := m.Globals[index]
+		target := indexOf(name)
+		if target < 0 || target > math.MaxUint16 {
+			return fmt.Errorf("aot: heap index %d for %q out of range", target, name)
+		}
+		mapping[index] = target
+		globals[target] = name
+	}
+	lookup := func(index int) (int, error) {
+		target, ok := mapping[index]
+		if !ok {
+			return 0, fmt.Errorf("aot: module %s refers to unnamed heap index %d", m.Path, index)
+		}
+		return target, nil
+	}
+
+	// Patch every chunk before touching any, so that an error leaves the
+	// module unchanged
+	type patch struct {
+		code   []byte
+		offset int
+		index  int
+	}
+	var patches []patch
+	var vars [][]uint16
+	var varIndices [][]uint16
+	visited := make(map[*vm.Chunk]bool)
+	var walk func(c *vm.Chunk) error
+	walk = func(c *vm.Chunk) error {
+		if c == nil || visited[c] {
+			return nil
+		}
+		visited[c] = true
+		relocs, ok := m.relocs[c]
+		if !ok {
+			relocs = globalOperands(c)
+		}
+		for _, operand := range relocs {
+			target, err := lookup(int(binary.BigEndian.Uint16(c.Code[operand:])))
+			if err != nil {
+				return err
+			}
+			patches = append(patches, patch{c.Code, operand, target})
+		}
+		if len(c.VarGlobalIndices) > 0 {
+			indices := make([]uint16, len(c.VarGlobalIndices))
+			for i, index := range c.VarGlobalIndices {
+				target, err := lookup(int(index))
+				if err != nil {
+					return err
+				}
+				indices[i] = uint16(target)
+			}
+			vars = append(vars, c.VarGlobalIndices)
+			varIndices = append(varIndices, indices)
+		}
+		for _, constant := range c.Constants {
+			if constant.Type() == vm.TypeFunction {
+				if err := walk(constant.AsFunction().Chunk); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(m.Chunk); err != nil {
+		return err
+	}
+	exports := make(map[string]int, len(m.Exports))
+	for name, index := range m.Exports {
+		target, err := lookup(index)
+		if err != nil {
+			return err
+		}
+		exports[name] = target
+	}
+
+	for _, p := range patches {
+		binary.BigEndian.PutUint16(p.code[p.offset:], uint16(p.index))
+	}
+	for i, indices := range vars {
+		copy(indices, varIndices[i])
+	}
+	m.Exports = exports
+	m.Globals = globals
+	m.linked = true
+	return nil
+}
@@ -0,0 +1,173 @@
+package aot
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// sourceExtensions are the source files a .psm file can stand in for
+var sourceExtensions = []string{".ts", ".tsx", ".js", ".jsx"}
+
+// Resolver is a module resolver that loads compiled .psm files. A specifier
+// that resolves to a source file is served from the .psm file next to it, as
+// long as that was compiled from the same source text; a stale or missing
+// .psm file makes resolution fall through to the file system resolver. A
+// specifier with no source file resolves to a .psm file alone, so that
+// deployments can ship compiled modules only.
+type Resolver struct {
+	fs       fs.FS
+	indexOf  func(name string) int
+	source   *modules.FileSystemResolver // Finds the source a specifier names
+	compiled *modules.FileSystemResolver // Finds .psm files when there is no source
+	priority int
+}
+
+// NewResolver creates a resolver for compiled modules in filesystem. Loaded
+// modules are linked with indexOf, which must be the loading session's heap
+// allocator.
+func NewResolver(filesystem fs.FS, baseDir string, indexOf func(name string) int) *Resolver {
+	source := modules.NewFileSystemResolver(filesystem, baseDir)
+	source.SetExtensions(sourceExtensions)
+	compiled := modules.NewFileSystemResolver(filesystem, baseDir)
+	compiled.SetExtensions([]string{Extension})
+	compiled.SetIndexFiles([]string{"index" + Extension})
+	return &Resolver{
+		fs:       filesystem,
+		indexOf:  indexOf,
+		source:   source,
+		compiled: compiled,
+		priority: 90, // Ahead of the file system resolver
+	}
+}
+
+// Name returns the resolver name
+func (r *Resolver) Name() string {
+	return "Compiled"
+}
+
+// CanResolve returns true for the path specifiers the file system resolver
+// handles
+func (r *Resolver) CanResolve(specifier string) bool {
+	return r.source.CanResolve(specifier)
+}
+
+// Priority returns the resolver priority
+func (r *Resolver) Priority() int {
+	return r.priority
+}
+
+// SetPriority sets the resolver priority
+func (r *Resolver) SetPriority(priority int) {
+	r.priority = priority
+}
+
+// Resolve resolves a specifier to a compiled module
+func (r *Resolver) Resolve(specifier string, fromPath string) (*modules.ResolvedModule, error) {
+	if strings.HasSuffix(specifier, Extension) {
+		return r.resolveCompiled(specifier, fromPath)
+	}
+
+	resolved, err := r.source.Resolve(specifier, fromPath)
+	if err != nil {
+		// No source: look for the compiled module alone, also under the
+		// specifier with its extension dropped
+		if compiled, err := r.resolveCompiled(specifier, fromPath); err == nil {
+			return compiled, nil
+		}
+		for _, ext := range sourceExtensions {
+			if strings.HasSuffix(specifier, ext) {
+				return r.resolveCompiled(strings.TrimSuffix(specifier, ext), fromPath)
+			}
+		}
+		return nil, err
+	}
+	defer resolved.Source.Close()
+
+	compiledPath := strings.TrimSuffix(resolved.ResolvedPath, pathpkg.Ext(resolved.ResolvedPath)) + Extension
+	data, err := fs.ReadFile(r.fs, compiledPath)
+	if err != nil {
+		return nil, fmt.Errorf("no compiled module for %s", resolved.ResolvedPath)
+	}
+	content, err := io.ReadAll(resolved.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", resolved.ResolvedPath, err)
+	}
+	m, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", compiledPath, err)
+	}
+	if m.SourceHash != HashSource(string(content)) {
+		return nil, fmt.Errorf("%s is stale: %s changed since it was compiled", compiledPath, resolved.ResolvedPath)
+	}
+	return r.resolved(specifier, compiledPath, m)
+}
+
+func (r *Resolver) resolveCompiled(specifier string, fromPath string) (*modules.ResolvedModule, error) {
+	resolved, err := r.compiled.Resolve(specifier, fromPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resolved.Source)
+	resolved.Source.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", resolved.ResolvedPath, err)
+	}
+	m, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resolved.ResolvedPath, err)
+	}
+	return r.resolved(specifier, resolved.ResolvedPath, m)
+}
+
+func (r *Resolver) resolved(specifier, path string, m *Module) (*modules.ResolvedModule, error) {
+	if err := m.Link(r.indexOf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &modules.ResolvedModule{
+		Specifier:    specifier,
+		ResolvedPath: path,
+		Source:       &ModuleSource{Module: m},
+		Resolver:     r.Name(),
+	}, nil
+}
+
+// ModuleSource is the Source of a module resolved to compiled bytecode. It
+// implements modules.CompiledModuleSource and reads as empty.
+type ModuleSource struct {
+	Module *Module
+}
+
+func (s *ModuleSource) Read(p []byte) (int, error) { return 0, io.EOF }
+func (s *ModuleSource) Close() error               { return nil }
+
+// CompiledChunk returns the module's linked top-level chunk
+func (s *ModuleSource) CompiledChunk() *vm.Chunk {
+	return s.Module.Chunk
+}
+
+// ExportIndices returns the heap indices of the module's exports
+func (s *ModuleSource) ExportIndices() map[string]uint16 {
+	indices := make(map[string]uint16, len(s.Module.Exports))
+	for name, index := range s.Module.Exports {
+		indices[name] = uint16(index)
+	}
+	return indices
+}
+
+// ImportSpecs returns the module's static imports
+func (s *ModuleSource) ImportSpecs() []*modules.ImportSpec {
+	specs := make([]*modules.ImportSpec, len(s.Module.Imports))
+	for i, imp := range s.Module.Imports {
+		specs[i] = &modules.ImportSpec{ModulePath: imp.Specifier}
+		if imp.Type != "" {
+			specs[i].Attributes = map[string]string{"type": imp.Type}
+		}
+	}
+	return specs
+}
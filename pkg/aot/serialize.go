@@ -0,0 +1,325 @@
+package aot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// buffer is an append-only byte buffer with the format's primitive encodings
+type buffer []byte
+
+func (b *buffer) byte(v byte)      { *b = append(*b, v) }
+func (b *buffer) uvarint(v uint64) { *b = binary.AppendUvarint(*b, v) }
+func (b *buffer) varint(v int64)   { *b = binary.AppendVarint(*b, v) }
+func (b *buffer) uint(v int)       { b.uvarint(uint64(v)) }
+func (b *buffer) int(v int)        { b.varint(int64(v)) }
+func (b *buffer) uint32(v uint32)  { *b = binary.LittleEndian.AppendUint32(*b, v) }
+func (b *buffer) uint64(v uint64)  { *b = binary.LittleEndian.AppendUint64(*b, v) }
+
+func (b *buffer) bytes(v []byte) {
+	b.uint(len(v))
+	*b = append(*b, v...)
+}
+
+func (b *buffer) flags(bits ...bool) {
+	var v uint64
+	for i, bit := range bits {
+		if bit {
+			v |= 1 << i
+		}
+	}
+	b.uvarint(v)
+}
+
+// encoder flattens a module's chunk tree and interns its strings
+type encoder struct {
+	body    buffer
+	strings map[string]int
+	list    []string
+	chunks  map[*vm.Chunk]int
+	queue   []*vm.Chunk
+	used    map[int]bool // Heap indices the bytecode refers to
+}
+
+// Encode serializes a module to the .psm format. Every heap index the
+// module's bytecode, var declarations and exports refer to must be named in
+// m.Globals.
+func Encode(m *Module) ([]byte, error) {
+	if m.Chunk == nil {
+		return nil, fmt.Errorf("aot: module %s has no chunk", m.Path)
+	}
+	e := &encoder{
+		strings: make(map[string]int),
+		chunks:  make(map[*vm.Chunk]int),
+		used:    make(map[int]bool),
+	}
+	e.body.uint(e.string(m.Path))
+
+	// Chunks are encoded first so that the global table covers every heap
+	// index they use
+	var chunks buffer
+	e.chunk(m.Chunk)
+	for i := 0; i < len(e.queue); i++ {
+		if err := e.encodeChunk(&chunks, e.queue[i], m.relocs[e.queue[i]]); err != nil {
+			return nil, err
+		}
+	}
+
+	exportNames := make([]string, 0, len(m.Exports))
+	for name, index := range m.Exports {
+		exportNames = append(exportNames, name)
+		e.used[index] = true
+	}
+	sort.Strings(exportNames)
+
+	globals := make([]int, 0, len(e.used))
+	for index := range e.used {
+		if _, ok := m.Globals[index]; !ok {
+			return nil, fmt.Errorf("aot: module %s refers to unnamed heap index %d", m.Path, index)
+		}
+		globals = append(globals, index)
+	}
+	sort.Ints(globals)
+	e.body.uint(len(globals))
+	for _, index := range globals {
+		e.body.uint(index)
+		e.body.uint(e.string(m.Globals[index]))
+	}
+
+	e.body.uint(len(m.Imports))
+	for _, imp := range m.Imports {
+		e.body.uint(e.string(imp.Specifier))
+		e.body.uint(e.string(imp.Type))
+	}
+
+	e.body.uint(len(exportNames))
+	for _, name := range exportNames {
+		e.body.uint(e.string(name))
+		e.body.uint(m.Exports[name])
+	}
+
+	e.body.uint(len(e.queue))
+	e.body = append(e.body, chunks...)
+
+	out := make(buffer, 0, len(e.body)+64)
+	out = append(out, Magic...)
+	out.uint32(Version)
+	out.uint32(0) // Flags, reserved
+	out.uint32(engineHash)
+	out.uint64(m.SourceHash)
+	out.uint(len(e.list))
+	for _, s := range e.list {
+		out.bytes([]byte(s))
+	}
+	return append(out, e.body...), nil
+}
+
+// string interns s and returns its index in the string table
+func (e *encoder) string(s string) int {
+	if index, ok := e.strings[s]; ok {
+		return index
+	}
+	index := len(e.list)
+	e.strings[s] = index
+	e.list = append(e.list, s)
+	return index
+}
+
+// chunk returns the index of c in the chunk table, queueing it for encoding
+// the first time it is seen
+func (e *encoder) chunk(c *vm.Chunk) int {
+	if index, ok := e.chunks[c]; ok {
+		return index
+	}
+	index := len(e.queue)
+	e.chunks[c] = index
+	e.queue = append(e.queue, c)
+	return index
+}
+
+func (e *encoder) encodeChunk(b *buffer, c *vm.Chunk, relocs []int) error {
+	if relocs == nil {
+		relocs = globalOperands(c)
+	}
+	for _, operand := range relocs {
+		e.used[int(binary.BigEndian.Uint16(c.Code[operand:]))] = true
+	}
+	for _, index := range c.VarGlobalIndices {
+		e.used[int(index)] = true
+	}
+
+	b.bytes(c.Code)
+	b.uint(len(c.Positions))
+	offset, line := 0, 0
+	for _, run := range c.Positions {
+		b.uint(run.Offset - offset)
+		b.int(run.Line - line)
+		b.uint(run.Column)
+		offset, line = run.Offset, run.Line
+	}
+
+	b.uint(len(c.Constants))
+	for _, constant := range c.Constants {
+		if err := e.value(b, constant); err != nil {
+			return err
+		}
+	}
+
+	b.uint(len(c.ExceptionTable))
+	for _, h := range c.ExceptionTable {
+		b.uint(h.TryStart)
+		b.uint(h.TryEnd)
+		b.uint(h.HandlerPC)
+		b.int(h.CatchReg)
+		b.int(h.FinallyReg)
+		b.flags(h.IsCatch, h.IsFinally, h.IsIteratorCleanup)
+	}
+
+	b.uint(c.MaxRegs)
+	b.uint(c.NumSpillSlots)
+	b.flags(c.IsStrict, c.HasSimpleParameterList, c.ScopeDesc != nil)
+	if c.ScopeDesc != nil {
+		e.scope(b, c.ScopeDesc)
+	}
+
+	b.uint(len(c.VarGlobalIndices))
+	for _, index := range c.VarGlobalIndices {
+		b.uint(int(index))
+	}
+
+	b.uint(len(relocs))
+	previous := 0
+	for _, operand := range relocs {
+		b.uint(operand - previous)
+		previous = operand
+	}
+	return nil
+}
+
+// value encodes a constant pool entry
+func (e *encoder) value(b *buffer, v vm.Value) error {
+	switch v.Type() {
+	case vm.TypeUndefined:
+		b.byte(tagUndefined)
+	case vm.TypeNull:
+		b.byte(tagNull)
+	case vm.TypeBoolean:
+		if v.AsBoolean() {
+			b.byte(tagTrue)
+		} else {
+			b.byte(tagFalse)
+		}
+	case vm.TypeIntegerNumber:
+		b.byte(tagInteger)
+		b.varint(int64(v.AsInteger()))
+	case vm.TypeFloatNumber:
+		b.byte(tagFloat)
+		b.uint64(math.Float64bits(v.AsFloat()))
+	case vm.TypeBigInt:
+		b.byte(tagBigInt)
+		b.uint(e.string(v.AsBigInt().String()))
+	case vm.TypeString:
+		b.byte(tagString)
+		b.uint(e.string(v.AsString()))
+	case vm.TypeFunction:
+		fn := v.AsFunction()
+		if fn.Chunk == nil {
+			return fmt.Errorf("aot: function %q has no chunk", fn.Name)
+		}
+		b.byte(tagFunction)
+		b.uint(e.chunk(fn.Chunk))
+		b.uint(e.string(fn.Name))
+		b.uint(fn.Arity)
+		b.uint(fn.Length)
+		b.uint(fn.UpvalueCount)
+		b.uint(fn.RegisterSize)
+		b.int(fn.NameBindingRegister)
+		b.flags(fn.Variadic, fn.IsGenerator, fn.IsAsync, fn.IsArrowFunction,
+			fn.IsDerivedConstructor, fn.IsClassConstructor, fn.HasLocalCaptures)
+	case vm.TypeArray:
+		// Tagged templates keep their frozen strings array in the pool
+		cooked := v.AsArray()
+		rawValue, ok := cooked.GetOwn("raw")
+		if !ok || rawValue.Type() != vm.TypeArray {
+			return fmt.Errorf("aot: unsupported array constant %s", v.Inspect())
+		}
+		raw := rawValue.AsArray()
+		b.byte(tagTemplate)
+		b.uint(cooked.Length())
+		for i := 0; i < cooked.Length(); i++ {
+			if err := e.value(b, cooked.Get(i)); err != nil {
+				return err
+			}
+		}
+		b.uint(raw.Length())
+		for i := 0; i < raw.Length(); i++ {
+			b.uint(e.string(raw.Get(i).ToString()))
+		}
+	case vm.TypeDictObject:
+		// Enums are compiled to constant objects
+		dict := v.AsDictObject()
+		keys := dict.OwnKeys()
+		b.byte(tagEnum)
+		b.uint(len(keys))
+		for _, key := range keys {
+			value, _ := dict.GetOwn(key)
+			b.uint(e.string(key))
+			if err := e.value(b, value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("aot: unsupported %s constant %s", v.Type(), v.Inspect())
+	}
+	return nil
+}
+
+func (e *encoder) scope(b *buffer, s *vm.ScopeDescriptor) {
+	e.strs(b, s.LocalNames)
+	e.strs(b, s.LexicalBindings)
+	b.flags(s.HasArgumentsBinding, s.InDefaultParameterScope, s.HasSuperBinding,
+		s.InClassFieldInitializer, s.CurrentPrivateBrandInfo != nil)
+	b.int(s.CurrentPrivateBrand)
+	if s.CurrentPrivateBrandInfo != nil {
+		e.brand(b, s.CurrentPrivateBrandInfo)
+	}
+	b.uint(len(s.PrivateBrandStack))
+	for i := range s.PrivateBrandStack {
+		e.brand(b, &s.PrivateBrandStack[i])
+	}
+}
+
+func (e *encoder) brand(b *buffer, info *vm.PrivateBrandInfoVM) {
+	b.int(info.BrandID)
+	fields := make([]string, 0, len(info.DeclaredFields))
+	for name := range info.DeclaredFields {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	b.uint(len(fields))
+	for _, name := range fields {
+		b.uint(e.string(name))
+		b.flags(info.DeclaredFields[name])
+	}
+	members := make([]string, 0, len(info.MemberKinds))
+	for name := range info.MemberKinds {
+		members = append(members, name)
+	}
+	sort.Strings(members)
+	b.uint(len(members))
+	for _, name := range members {
+		b.uint(e.string(name))
+		b.uint(int(info.MemberKinds[name]))
+	}
+}
+
+func (e *encoder) strs(b *buffer, list []string) {
+	b.uint(len(list))
+	for _, s := range list {
+		b.uint(e.string(s))
+	}
+}
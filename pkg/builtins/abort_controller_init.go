@@ -1,8 +1,9 @@
 package builtins
 
 import (
-	"sync"
+	"math"
 
+	"github.com/nooga/paserati/pkg/runtime"
 	"github.com/nooga/paserati/pkg/types"
 	"github.com/nooga/paserati/pkg/vm"
 )
@@ -21,17 +22,23 @@ func (a *AbortControllerInitializer) Priority() int {
 }
 
 func (a *AbortControllerInitializer) InitTypes(ctx *TypeContext) error {
-	// AbortSignal type
+	// AbortSignal type, an EventTarget
+	var eventTargetType types.Type = types.Any
+	if ctor, ok := ctx.GetType("EventTarget"); ok {
+		if obj, ok := ctor.(*types.ObjectType); ok && obj.Properties["prototype"] != nil {
+			eventTargetType = obj.Properties["prototype"]
+		}
+	}
 	abortSignalType := types.NewObjectType().
 		WithProperty("aborted", types.Boolean).
 		WithProperty("reason", types.Any).
 		WithProperty("throwIfAborted", types.NewSimpleFunction([]types.Type{}, types.Undefined)).
-		WithProperty("addEventListener", types.NewSimpleFunction([]types.Type{types.String, types.Any}, types.Undefined)).
-		WithProperty("removeEventListener", types.NewSimpleFunction([]types.Type{types.String, types.Any}, types.Undefined))
+		WithProperty("onabort", types.Any). // ((event: Event) => any) | null
+		Inherits(eventTargetType)
 
 	// AbortSignal static methods
 	abortSignalConstructorType := types.NewObjectType().
-		WithProperty("abort", types.NewSimpleFunction([]types.Type{}, abortSignalType)).
+		WithProperty("abort", types.NewOptionalFunction([]types.Type{types.Any}, abortSignalType, []bool{true})).
 		WithProperty("timeout", types.NewSimpleFunction([]types.Type{types.Number}, abortSignalType)).
 		WithProperty("any", types.NewSimpleFunction([]types.Type{types.Any}, abortSignalType)). // signals array
 		WithProperty("prototype", abortSignalType)
@@ -39,15 +46,19 @@ func (a *AbortControllerInitializer) InitTypes(ctx *TypeContext) error {
 	if err := ctx.DefineGlobal("AbortSignal", abortSignalConstructorType); err != nil {
 		return err
 	}
+	if err := ctx.DefineTypeAlias("AbortSignal", abortSignalType); err != nil {
+		return err
+	}
 
 	// AbortController type
 	abortControllerType := types.NewObjectType().
 		WithProperty("signal", abortSignalType).
-		WithProperty("abort", types.NewSimpleFunction([]types.Type{}, types.Undefined))
+		WithProperty("abort", types.NewOptionalFunction([]types.Type{types.Any}, types.Undefined, []bool{true}))
 
 	// AbortController constructor
 	abortControllerConstructorType := types.NewObjectType().
 		WithSimpleCallSignature([]types.Type{}, abortControllerType).
+		WithSimpleConstructSignature([]types.Type{}, abortControllerType).
 		WithProperty("prototype", abortControllerType)
 
 	return ctx.DefineGlobal("AbortController", abortControllerConstructorType)
@@ -56,106 +67,150 @@ func (a *AbortControllerInitializer) InitTypes(ctx *TypeContext) error {
 func (a *AbortControllerInitializer) InitRuntime(ctx *RuntimeContext) error {
 	vmInstance := ctx.VM
 
-	// Create AbortSignal.prototype
-	signalProto := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	// Create AbortSignal.prototype, inheriting from EventTarget.prototype
+	eventTarget, hasEventTarget := vm.Undefined, false
+	if ctx.GetGlobal != nil {
+		eventTarget, hasEventTarget = ctx.GetGlobal("EventTarget")
+	}
+	eventTargetProto := vmInstance.ObjectPrototype
+	if hasEventTarget {
+		if proto, err := vmInstance.GetProperty(eventTarget, "prototype"); err == nil && proto.IsObject() {
+			eventTargetProto = proto
+		}
+	}
+	signalProto := newTaggedPrototype(vmInstance, eventTargetProto, "AbortSignal")
+	initAbortSignalPrototype(vmInstance, signalProto)
 
 	// AbortSignal is not directly constructible, but we need the static methods
-	signalConstructor := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	signalConstructor, err := defineClass(ctx, "AbortSignal", 0, signalProto, func(args []vm.Value) (vm.Value, error) {
+		return vm.Undefined, vmInstance.NewTypeError("Illegal constructor")
+	})
+	if err != nil {
+		return err
+	}
+	if hasEventTarget {
+		setPrototypeOf(signalConstructor, eventTarget)
+	}
+	statics := signalConstructor.AsNativeFunctionWithProps().Properties
 
 	// AbortSignal.abort(reason?) - creates an already-aborted signal
-	signalConstructor.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
-		var reason vm.Value
+	statics.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
+		signal := newAbortSignal(vmInstance)
+		signal.aborted, signal.reason = true, abortReason(args)
+		return signal.object(), nil
+	}))
+
+	// AbortSignal.timeout(ms) - creates a signal that aborts with a
+	// "TimeoutError" after ms milliseconds, on a timer of the async runtime
+	statics.SetOwnNonEnumerable("timeout", vm.NewNativeFunction(1, false, "timeout", func(args []vm.Value) (vm.Value, error) {
+		ms := math.NaN()
 		if len(args) > 0 {
-			reason = args[0]
-		} else {
-			// Default reason is DOMException with name "AbortError"
-			reason = vm.NewString("AbortError: signal is aborted without reason")
+			ms = args[0].ToFloat()
 		}
-		signal := &AbortSignal{
-			aborted:   true,
-			reason:    reason,
-			listeners: make([]vm.Value, 0),
+		if math.IsNaN(ms) || math.IsInf(ms, 0) || ms < 0 {
+			return vm.Undefined, vmInstance.NewTypeError("AbortSignal.timeout: milliseconds must be a non-negative finite number")
 		}
-		return createAbortSignalObject(vmInstance, signal, signalProto), nil
-	}))
-
-	// AbortSignal.timeout(ms) - creates a signal that aborts after timeout
-	signalConstructor.SetOwnNonEnumerable("timeout", vm.NewNativeFunction(1, false, "timeout", func(args []vm.Value) (vm.Value, error) {
-		// For now, return a non-aborted signal (timeout would need async runtime support)
-		// This is a simplified implementation
-		signal := &AbortSignal{
-			aborted:   false,
-			reason:    vm.Undefined,
-			listeners: make([]vm.Value, 0),
+		rt, ok := vmInstance.GetAsyncRuntime().(runtime.TimerRuntime)
+		if !ok {
+			return vm.Undefined, vmInstance.NewTypeError("AbortSignal.timeout is not supported by the current async runtime")
 		}
-		return createAbortSignalObject(vmInstance, signal, signalProto), nil
+		signal := newAbortSignal(vmInstance)
+		rt.SetTimer(timerDelay(ms), false, func() error {
+			signal.Abort(domExceptionValue(vmInstance, "TimeoutError", "The operation was aborted due to timeout"))
+			return nil
+		})
+		return signal.object(), nil
 	}))
 
 	// AbortSignal.any(signals) - creates a signal that aborts when any input signal aborts
-	signalConstructor.SetOwnNonEnumerable("any", vm.NewNativeFunction(1, false, "any", func(args []vm.Value) (vm.Value, error) {
-		signal := &AbortSignal{
-			aborted:   false,
-			reason:    vm.Undefined,
-			listeners: make([]vm.Value, 0),
-		}
-		// Check if any input signal is already aborted
+	statics.SetOwnNonEnumerable("any", vm.NewNativeFunction(1, false, "any", func(args []vm.Value) (vm.Value, error) {
+		signal := newAbortSignal(vmInstance)
+		var sources []*AbortSignal
 		if len(args) > 0 {
 			if arr := args[0].AsArray(); arr != nil {
 				for i := 0; i < arr.Length(); i++ {
-					elem := arr.Get(i)
-					if obj := elem.AsPlainObject(); obj != nil {
-						if aborted, exists := obj.GetOwn("aborted"); exists && aborted.IsBoolean() && aborted.AsBoolean() {
-							signal.aborted = true
-							if reason, exists := obj.GetOwn("reason"); exists {
-								signal.reason = reason
-							}
-							break
-						}
+					source := abortSignalOf(arr.Get(i))
+					if source == nil {
+						return vm.Undefined, vmInstance.NewTypeError("AbortSignal.any: every signal must be an AbortSignal")
 					}
+					sources = append(sources, source)
 				}
 			}
 		}
-		return createAbortSignalObject(vmInstance, signal, signalProto), nil
+		// An input signal that's already aborted aborts the new one right away
+		for _, source := range sources {
+			if source.aborted {
+				signal.aborted, signal.reason = true, source.reason
+				return signal.object(), nil
+			}
+		}
+		for _, source := range sources {
+			source.dependents = append(source.dependents, signal)
+		}
+		return signal.object(), nil
 	}))
 
-	signalConstructor.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(signalProto))
-
-	if err := ctx.DefineGlobal("AbortSignal", vm.NewValueFromPlainObject(signalConstructor)); err != nil {
-		return err
-	}
-
 	// Create AbortController.prototype
-	controllerProto := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	controllerProto := newTaggedPrototype(vmInstance, vmInstance.ObjectPrototype, "AbortController")
+
+	controllerProto.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
+		var controller *AbortController
+		if this := vmInstance.GetThis(); this.Type() == vm.TypeObject {
+			controller, _ = this.AsPlainObject().HostData().(*AbortController)
+		}
+		if controller == nil {
+			return vm.Undefined, vmInstance.NewTypeError("AbortController.prototype.abort called on an object that is not an AbortController")
+		}
+		controller.signal.Abort(abortReason(args))
+		return vm.Undefined, nil
+	}))
 
 	// AbortController constructor
 	controllerConstructorFn := func(args []vm.Value) (vm.Value, error) {
-		signal := &AbortSignal{
-			aborted:   false,
-			reason:    vm.Undefined,
-			listeners: make([]vm.Value, 0),
-		}
 		controller := &AbortController{
-			signal: signal,
+			signal: newAbortSignal(vmInstance),
 		}
-		return createAbortControllerObject(vmInstance, controller, controllerProto, signalProto), nil
+		obj := vm.NewObject(instancePrototype(vmInstance, controllerProto)).AsPlainObject()
+		obj.SetHostData(controller)
+		obj.SetOwn("signal", controller.signal.object())
+		return vm.NewValueFromPlainObject(obj), nil
 	}
 
-	controllerConstructor := vm.NewConstructorWithProps(0, false, "AbortController", controllerConstructorFn)
-	if ctorProps := controllerConstructor.AsNativeFunctionWithProps(); ctorProps != nil {
-		ctorProps.Properties.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(controllerProto))
-	}
+	_, err = defineClass(ctx, "AbortController", 0, controllerProto, controllerConstructorFn)
+	return err
+}
 
-	controllerProto.SetOwnNonEnumerable("constructor", controllerConstructor)
+// abortReason returns the reason passed to abort, or the default reason
+func abortReason(args []vm.Value) vm.Value {
+	if len(args) > 0 && args[0].Type() != vm.TypeUndefined {
+		return args[0]
+	}
+	// Default reason is DOMException with name "AbortError"
+	return vm.NewString("AbortError: signal is aborted without reason")
+}
 
-	return ctx.DefineGlobal("AbortController", controllerConstructor)
+// domExceptionValue returns the value of a DOMException named name. There is
+// no DOMException, so it's an Error carrying the DOMException's name.
+func domExceptionValue(vmInstance *vm.VM, name, message string) vm.Value {
+	errorCtor, _ := vmInstance.GetGlobal("Error")
+	errValue, err := vmInstance.Call(errorCtor, vm.Undefined, []vm.Value{vm.NewString(message)})
+	if err != nil {
+		return thrownValue(err)
+	}
+	if errValue.Type() == vm.TypeObject {
+		errValue.AsPlainObject().SetOwnNonEnumerable("name", vm.NewString(name))
+	}
+	return errValue
 }
 
-// AbortSignal represents the signal object
+// AbortSignal represents the signal object, an EventTarget firing "abort"
 type AbortSignal struct {
-	mu        sync.Mutex
-	aborted   bool
-	reason    vm.Value
-	listeners []vm.Value
+	target     *eventTarget
+	aborted    bool
+	reason     vm.Value
+	algorithms []*func()      // run when the signal aborts, before its listeners
+	dependents []*AbortSignal // signals from AbortSignal.any, aborted after this one
+	obj        vm.Value
 }
 
 // AbortController represents the controller object
@@ -163,107 +218,118 @@ type AbortController struct {
 	signal *AbortSignal
 }
 
-// Abort aborts the signal with an optional reason
-func (s *AbortSignal) Abort(reason vm.Value) {
-	s.mu.Lock()
-	if s.aborted {
-		s.mu.Unlock()
-		return
-	}
-	s.aborted = true
-	s.reason = reason
-	listeners := make([]vm.Value, len(s.listeners))
-	copy(listeners, s.listeners)
-	s.mu.Unlock()
-
-	// Note: We don't call listeners here because we don't have access to the VM
-	// The listeners would need to be called from the VM context
+func newAbortSignal(vmInstance *vm.VM) *AbortSignal {
+	return &AbortSignal{target: newEventTarget(vmInstance), reason: vm.Undefined, obj: vm.Undefined}
 }
 
-func createAbortSignalObject(vmInstance *vm.VM, signal *AbortSignal, _ *vm.PlainObject) vm.Value {
-	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
-
-	// Store the signal reference for internal use
-	signalRef := signal
-
-	// aborted property (getter-like behavior via direct access)
-	obj.SetOwn("aborted", boolToValue(signal.aborted))
-	obj.SetOwn("reason", signal.reason)
-
-	// throwIfAborted() - throws if the signal is aborted
-	obj.SetOwnNonEnumerable("throwIfAborted", vm.NewNativeFunction(0, false, "throwIfAborted", func(args []vm.Value) (vm.Value, error) {
-		signalRef.mu.Lock()
-		aborted := signalRef.aborted
-		reason := signalRef.reason
-		signalRef.mu.Unlock()
+// abortSignalOf returns the state of an AbortSignal object, or nil
+func abortSignalOf(v vm.Value) *AbortSignal {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	signal, _ := v.AsPlainObject().HostData().(*AbortSignal)
+	return signal
+}
 
-		if aborted {
-			if reason.Type() == vm.TypeUndefined {
-				return vm.Undefined, &AbortError{Message: "signal is aborted without reason"}
+// onAbort adds an abort algorithm: fn runs when the signal aborts. The
+// returned function removes it again.
+func (s *AbortSignal) onAbort(fn func()) (remove func()) {
+	algorithm := &fn
+	s.algorithms = append(s.algorithms, algorithm)
+	return func() {
+		for i, other := range s.algorithms {
+			if other == algorithm {
+				s.algorithms = append(s.algorithms[:i:i], s.algorithms[i+1:]...)
+				return
 			}
-			return vm.Undefined, &AbortError{Message: reason.ToString()}
 		}
-		return vm.Undefined, nil
-	}))
+	}
+}
 
-	// addEventListener(type, listener)
-	obj.SetOwnNonEnumerable("addEventListener", vm.NewNativeFunction(2, false, "addEventListener", func(args []vm.Value) (vm.Value, error) {
-		if len(args) < 2 {
-			return vm.Undefined, nil
-		}
-		eventType := args[0].ToString()
-		if eventType == "abort" {
-			signalRef.mu.Lock()
-			signalRef.listeners = append(signalRef.listeners, args[1])
-			signalRef.mu.Unlock()
+// Abort aborts the signal with reason: it runs the abort algorithms, then
+// fires "abort" at the signal, then does the same for signals that depend on
+// it. It must run on the VM's goroutine.
+func (s *AbortSignal) Abort(reason vm.Value) {
+	if s.aborted {
+		return
+	}
+	s.aborted = true
+	s.reason = reason
+	var dependents []*AbortSignal
+	for _, dependent := range s.dependents {
+		if !dependent.aborted {
+			dependent.aborted, dependent.reason = true, reason
+			dependents = append(dependents, dependent)
 		}
-		return vm.Undefined, nil
-	}))
-
-	// removeEventListener(type, listener)
-	obj.SetOwnNonEnumerable("removeEventListener", vm.NewNativeFunction(2, false, "removeEventListener", func(args []vm.Value) (vm.Value, error) {
-		// Simplified - just return undefined
-		return vm.Undefined, nil
-	}))
-
-	return vm.NewValueFromPlainObject(obj)
+	}
+	s.dependents = nil
+	s.runAbortSteps()
+	for _, dependent := range dependents {
+		dependent.runAbortSteps()
+	}
 }
 
-func createAbortControllerObject(vmInstance *vm.VM, controller *AbortController, _ *vm.PlainObject, signalProto *vm.PlainObject) vm.Value {
-	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
-
-	// Create the signal object
-	signalObj := createAbortSignalObject(vmInstance, controller.signal, signalProto)
-	signalPlain := signalObj.AsPlainObject()
+// runAbortSteps runs the abort algorithms, then fires "abort"
+func (s *AbortSignal) runAbortSteps() {
+	algorithms := s.algorithms
+	s.algorithms = nil
+	for _, algorithm := range algorithms {
+		(*algorithm)()
+	}
+	s.target.fire(s.object(), "abort")
+}
 
-	// signal property
-	obj.SetOwn("signal", signalObj)
+// object returns the signal's JS object, creating it the first time
+func (s *AbortSignal) object() vm.Value {
+	if s.obj.Type() == vm.TypeUndefined {
+		vmInstance := s.target.vm
+		obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "AbortSignal"))).AsPlainObject()
+		obj.SetHostData(s)
+		s.obj = vm.NewValueFromPlainObject(obj)
+	}
+	return s.obj
+}
 
-	// abort(reason?) method
-	obj.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
-		var reason vm.Value
-		if len(args) > 0 {
-			reason = args[0]
-		} else {
-			reason = vm.NewString("AbortError: signal is aborted without reason")
+func initAbortSignalPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	thisSignal := func(method string) (*AbortSignal, error) {
+		if signal := abortSignalOf(vmInstance.GetThis()); signal != nil {
+			return signal, nil
 		}
+		return nil, vmInstance.NewTypeError("AbortSignal.prototype." + method + " called on an object that is not an AbortSignal")
+	}
 
-		controller.signal.mu.Lock()
-		if !controller.signal.aborted {
-			controller.signal.aborted = true
-			controller.signal.reason = reason
-			// Update the signal object's properties
-			if signalPlain != nil {
-				signalPlain.SetOwn("aborted", vm.True)
-				signalPlain.SetOwn("reason", reason)
+	getters := []struct {
+		name string
+		get  func(s *AbortSignal) vm.Value
+	}{
+		{"aborted", func(s *AbortSignal) vm.Value { return boolToValue(s.aborted) }},
+		{"reason", func(s *AbortSignal) vm.Value { return s.reason }},
+	}
+	for _, getter := range getters {
+		fn := vm.NewNativeFunction(0, false, "get "+getter.name, func(args []vm.Value) (vm.Value, error) {
+			signal, err := thisSignal(getter.name)
+			if err != nil {
+				return vm.Undefined, err
 			}
-		}
-		controller.signal.mu.Unlock()
+			return getter.get(signal), nil
+		})
+		e, c := true, true
+		proto.DefineAccessorProperty(getter.name, fn, true, vm.Undefined, false, &e, &c)
+	}
 
+	// throwIfAborted() - throws the reason if the signal is aborted
+	proto.SetOwnNonEnumerable("throwIfAborted", vm.NewNativeFunction(0, false, "throwIfAborted", func(args []vm.Value) (vm.Value, error) {
+		signal, err := thisSignal("throwIfAborted")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if signal.aborted {
+			return vm.Undefined, vmInstance.NewExceptionError(signal.reason)
+		}
 		return vm.Undefined, nil
 	}))
 
-	return vm.NewValueFromPlainObject(obj)
+	defineEventHandler(vmInstance, proto, "abort")
 }
 
 // AbortError represents an abort error
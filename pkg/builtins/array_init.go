@@ -250,6 +250,9 @@ func (a *ArrayInitializer) InitRuntime(ctx *RuntimeContext) error {
 		if thisArray == nil {
 			return vm.NumberValue(0), nil
 		}
+		if err := vmInstance.ChargeAllocation(int64(len(args)) * vm.ElementAllocCost); err != nil {
+			return vm.Undefined, err
+		}
 		for i := 0; i < len(args); i++ {
 			thisArray.Append(args[i])
 		}
@@ -490,7 +493,11 @@ func (a *ArrayInitializer) InitRuntime(ctx *RuntimeContext) error {
 		}
 		result := thisArray.Get(0).ToString()
 		for i := 1; i < thisArray.Length(); i++ {
-			result += separator + thisArray.Get(i).ToString()
+			elem := thisArray.Get(i).ToString()
+			if err := vmInstance.ChargeAllocation(int64(len(separator) + len(elem))); err != nil {
+				return vm.Undefined, err
+			}
+			result += separator + elem
 		}
 		return vm.NewString(result), nil
 	}))
@@ -60,49 +60,9 @@ func (g *AsyncGeneratorInitializer) InitRuntime(ctx *RuntimeContext) error {
 			sentValue = args[0]
 		}
 
-		// For now, treat AsyncGenerator like a regular Generator
-		// ExecuteGenerator works with GeneratorObject, so we need to cast
-		// This is a simplification - proper implementation would need separate ExecuteAsyncGenerator
-		genObj := &vm.GeneratorObject{
-			Function:     thisGen.Function,
-			State:        thisGen.State,
-			Frame:        thisGen.Frame,
-			YieldedValue: thisGen.YieldedValue,
-			ReturnValue:  thisGen.ReturnValue,
-			Done:         thisGen.Done,
-			Args:         thisGen.Args,
-			This:         thisGen.This, // BUGFIX: Copy the 'this' value so method context works
-		}
-
-		result, err := vmInstance.ExecuteGenerator(genObj, sentValue)
-
-		// Sync back the state
-		thisGen.State = genObj.State
-		thisGen.Frame = genObj.Frame
-		thisGen.YieldedValue = genObj.YieldedValue
-		thisGen.ReturnValue = genObj.ReturnValue
-		thisGen.Done = genObj.Done
-
-		if err != nil {
-			// Async generators convert exceptions to rejected promises
-			// Mark as completed since async generators don't resume after exception
-			thisGen.State = vm.GeneratorCompleted
-			thisGen.Done = true
-			thisGen.Frame = nil // OK for async - won't resume
-
-			// Clear recorded errors since we're handling the exception by returning a rejected promise
-			// This prevents "Uncaught exception" from being printed when the exception is actually caught
-			vmInstance.ClearErrors()
-
-			// Extract exception value and wrap in rejected promise
-			if ee, ok := err.(vm.ExceptionError); ok {
-				return vmInstance.NewRejectedPromise(ee.GetExceptionValue()), nil
-			}
-			// Fallback for other errors
-			return vmInstance.NewRejectedPromise(vm.NewString(err.Error())), nil
-		}
-
-		return vmInstance.NewResolvedPromise(result), nil
+		return resumeAsyncGenerator(vmInstance, thisGen, func(genObj *vm.GeneratorObject) (vm.Value, error) {
+			return vmInstance.ExecuteGenerator(genObj, sentValue)
+		}), nil
 	}))
 
 	// return(value?) - Returns Promise that resolves to force generator completion
@@ -117,18 +77,20 @@ func (g *AsyncGeneratorInitializer) InitRuntime(ctx *RuntimeContext) error {
 		if len(args) > 0 {
 			returnValue = args[0]
 		}
-		thisGen.ReturnValue = returnValue
-		thisGen.State = vm.GeneratorCompleted
-		thisGen.Done = true
-		thisGen.Frame = nil
 
-		// Return a promise that resolves to { value: returnValue, done: true }
-		result := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
-		result.SetOwnNonEnumerable("value", returnValue)
-		result.SetOwnNonEnumerable("done", vm.BooleanValue(true))
-		resultVal := vm.NewValueFromPlainObject(result)
+		// If generator is completed, return resolved promise with { value: returnValue, done: true }
+		if thisGen.Done || thisGen.State == vm.GeneratorCompleted {
+			result := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+			result.SetOwnNonEnumerable("value", returnValue)
+			result.SetOwnNonEnumerable("done", vm.BooleanValue(true))
+			return vmInstance.NewResolvedPromise(vm.NewValueFromPlainObject(result)), nil
+		}
 
-		return vmInstance.NewResolvedPromise(resultVal), nil
+		// Resume the generator with a return so its finally blocks and
+		// using declarations run before it completes
+		return resumeAsyncGenerator(vmInstance, thisGen, func(genObj *vm.GeneratorObject) (vm.Value, error) {
+			return vmInstance.ExecuteGeneratorWithReturn(genObj, returnValue)
+		}), nil
 	}))
 
 	// throw(exception?) - Returns Promise that may reject based on generator handling
@@ -149,8 +111,10 @@ func (g *AsyncGeneratorInitializer) InitRuntime(ctx *RuntimeContext) error {
 			return vmInstance.NewRejectedPromise(exception), nil
 		}
 
-		// For now, just reject - proper implementation would throw into the generator
-		return vmInstance.NewRejectedPromise(exception), nil
+		// Throw into the generator so its catch and finally blocks can handle it
+		return resumeAsyncGenerator(vmInstance, thisGen, func(genObj *vm.GeneratorObject) (vm.Value, error) {
+			return vmInstance.ExecuteGeneratorWithException(genObj, exception)
+		}), nil
 	}))
 
 	// Add Symbol.asyncIterator - async generators are their own async iterators
@@ -200,3 +164,53 @@ func (g *AsyncGeneratorInitializer) InitRuntime(ctx *RuntimeContext) error {
 
 	return nil
 }
+
+// resumeAsyncGenerator runs resume on a GeneratorObject standing in for
+// thisGen, syncs the state back and wraps the outcome in a promise.
+// For now an AsyncGenerator is driven like a regular Generator; this is a
+// simplification - proper implementation would need separate ExecuteAsyncGenerator
+func resumeAsyncGenerator(vmInstance *vm.VM, thisGen *vm.AsyncGeneratorObject, resume func(genObj *vm.GeneratorObject) (vm.Value, error)) vm.Value {
+	genObj := &vm.GeneratorObject{
+		Function:     thisGen.Function,
+		State:        thisGen.State,
+		Frame:        thisGen.Frame,
+		YieldedValue: thisGen.YieldedValue,
+		ReturnValue:  thisGen.ReturnValue,
+		Done:         thisGen.Done,
+		Args:         thisGen.Args,
+		This:         thisGen.This, // BUGFIX: Copy the 'this' value so method context works
+	}
+
+	result, err := resume(genObj)
+
+	// Sync back the state
+	thisGen.State = genObj.State
+	thisGen.Frame = genObj.Frame
+	thisGen.YieldedValue = genObj.YieldedValue
+	thisGen.ReturnValue = genObj.ReturnValue
+	thisGen.Done = genObj.Done
+
+	if err != nil {
+		// Async generators convert exceptions to rejected promises
+		// Mark as completed since async generators don't resume after exception
+		thisGen.State = vm.GeneratorCompleted
+		thisGen.Done = true
+		thisGen.Frame = nil // OK for async - won't resume
+
+		// Clear recorded errors since we're handling the exception by returning a rejected promise
+		// This prevents "Uncaught exception" from being printed when the exception is actually caught
+		vmInstance.ClearErrors()
+		// An exception rethrown by a finally block leaves the VM unwinding, which would
+		// otherwise carry on into the caller
+		vmInstance.ClearUnwindingState()
+
+		// Extract exception value and wrap in rejected promise
+		if ee, ok := err.(vm.ExceptionError); ok {
+			return vmInstance.NewRejectedPromise(ee.GetExceptionValue())
+		}
+		// Fallback for other errors
+		return vmInstance.NewRejectedPromise(vm.NewString(err.Error()))
+	}
+
+	return vmInstance.NewResolvedPromise(result)
+}
@@ -28,7 +28,7 @@ func (b *BigIntInitializer) InitTypes(ctx *TypeContext) error {
 	// Note: 'this' is implicit and not included in type signatures
 	bigintProtoType := types.NewObjectType().
 		WithProperty("toString", types.NewOptionalFunction([]types.Type{types.Number}, types.String, []bool{true})).
-		WithProperty("toLocaleString", types.NewOptionalFunction([]types.Type{types.String, types.Any}, types.String, []bool{true, true})).
+		WithProperty("toLocaleString", types.NewOptionalFunction([]types.Type{types.Any, types.Any}, types.String, []bool{true, true})).
 		WithProperty("valueOf", types.NewSimpleFunction([]types.Type{}, types.BigInt)).
 		WithProperty("constructor", types.Any) // Avoid circular reference, use Any for constructor property
 
@@ -112,9 +112,8 @@ func (b *BigIntInitializer) InitRuntime(ctx *RuntimeContext) error {
 			return vm.NewString(thisBigInt.ToString()), nil
 		}
 
-		// For now, just return the string representation (proper locale support would be complex)
-		// TODO: Implement proper locale formatting
-		return vm.NewString(primitiveBigInt.AsBigInt().String()), nil
+		// Formatted like new Intl.NumberFormat(locales, options).format(x)
+		return formatNumberToLocaleString(vmInstance, primitiveBigInt, argOrUndefined(args, 0), argOrUndefined(args, 1))
 	}))
 
 	bigintProto.SetOwnNonEnumerable("valueOf", vm.NewNativeFunction(0, false, "valueOf", func(args []vm.Value) (vm.Value, error) {
@@ -27,7 +27,7 @@ func (b *BlobInitializer) InitTypes(ctx *TypeContext) error {
 		WithProperty("bytes", types.NewSimpleFunction([]types.Type{}, types.Any)).       // Returns Promise<Uint8Array>
 		WithProperty("text", types.NewSimpleFunction([]types.Type{}, types.Any)).        // Returns Promise<string>
 		WithProperty("slice", types.NewSimpleFunction([]types.Type{types.Number, types.Number, types.String}, types.Any)).
-		WithProperty("stream", types.NewSimpleFunction([]types.Type{}, types.Any)) // Returns ReadableStream (stub)
+		WithProperty("stream", types.NewSimpleFunction([]types.Type{}, readableStreamType(ctx)))
 
 	// BlobPropertyBag type
 	blobOptionsType := types.NewObjectType().
@@ -135,8 +135,18 @@ func blobPartToBytes(part vm.Value) []byte {
 	return []byte{}
 }
 
+// blobOf returns the Blob of a Blob object, or nil
+func blobOf(v vm.Value) *Blob {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	blob, _ := v.AsPlainObject().HostData().(*Blob)
+	return blob
+}
+
 func createBlobObject(vmInstance *vm.VM, blob *Blob, _ *vm.PlainObject) vm.Value {
 	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	obj.SetHostData(blob)
 
 	// size property (read-only)
 	obj.SetOwn("size", vm.NumberValue(float64(len(blob.data))))
@@ -208,10 +218,9 @@ func createBlobObject(vmInstance *vm.VM, blob *Blob, _ *vm.PlainObject) vm.Value
 		return createBlobObject(vmInstance, newBlob, nil), nil
 	}))
 
-	// stream() -> ReadableStream (stub - returns undefined for now)
+	// stream() -> ReadableStream of the blob's bytes
 	obj.SetOwnNonEnumerable("stream", vm.NewNativeFunction(0, false, "stream", func(args []vm.Value) (vm.Value, error) {
-		// ReadableStream would require significant infrastructure
-		return vm.Undefined, nil
+		return newBytesStream(vmInstance, blob.data).object(), nil
 	}))
 
 	return vm.NewValueFromPlainObject(obj)
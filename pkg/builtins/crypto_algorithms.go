@@ -0,0 +1,632 @@
+package builtins
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// cryptoError is a failed Web Crypto operation, named for the DOMException
+// the spec calls for (NotSupportedError, OperationError, InvalidAccessError,
+// DataError or SyntaxError), or TypeError for bad arguments
+type cryptoError struct {
+	name    string
+	message string
+}
+
+func (e *cryptoError) Error() string {
+	return e.name + ": " + e.message
+}
+
+func newCryptoError(name, format string, args ...any) error {
+	return &cryptoError{name: name, message: fmt.Sprintf(format, args...)}
+}
+
+// cryptoHashes are the digest algorithms, by their Web Crypto names
+var cryptoHashes = map[string]crypto.Hash{
+	"SHA-1":   crypto.SHA1,
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+// cryptoAlgorithms are the algorithms each operation supports, by their
+// names as the spec registers them; "get key length" is how deriveKey
+// sizes the key it derives
+var cryptoAlgorithms = map[string][]string{
+	"digest":         {"SHA-1", "SHA-256", "SHA-384", "SHA-512"},
+	"sign":           {"HMAC", "ECDSA", "Ed25519", "RSA-PSS"},
+	"verify":         {"HMAC", "ECDSA", "Ed25519", "RSA-PSS"},
+	"encrypt":        {"AES-GCM", "AES-CBC", "AES-CTR"},
+	"decrypt":        {"AES-GCM", "AES-CBC", "AES-CTR"},
+	"generateKey":    {"HMAC", "AES-GCM", "AES-CBC", "AES-CTR", "ECDSA", "ECDH", "Ed25519", "X25519", "RSA-PSS"},
+	"importKey":      {"HMAC", "AES-GCM", "AES-CBC", "AES-CTR", "ECDSA", "ECDH", "Ed25519", "X25519", "RSA-PSS", "PBKDF2", "HKDF"},
+	"deriveBits":     {"ECDH", "X25519", "PBKDF2", "HKDF"},
+	"get key length": {"HMAC", "AES-GCM", "AES-CBC", "AES-CTR", "PBKDF2", "HKDF"},
+}
+
+// algorithmParams is a normalized algorithm: its registered name, and the
+// members of the dictionary the algorithm takes for the operation
+type algorithmParams struct {
+	name           string
+	hash           string
+	length         int // AES and HMAC key length, or AES-CTR counter length
+	hasLength      bool
+	namedCurve     string
+	modulusLength  int
+	publicExponent []byte
+	iv             []byte // AES-GCM and AES-CBC, and AES-CTR's counter
+	additionalData []byte
+	tagLength      int
+	saltLength     int
+	salt           []byte
+	info           []byte
+	iterations     int
+	public         *cryptoKey
+}
+
+// normalizeAlgorithm implements the spec's algorithm normalization for op:
+// alg is an algorithm name or a dictionary naming one
+func normalizeAlgorithm(vmInstance *vm.VM, alg vm.Value, op string) (*algorithmParams, error) {
+	d := &algorithmDict{vm: vmInstance, obj: alg}
+	var name string
+	if alg.IsObject() {
+		nameValue := d.member("name", true)
+		if d.err != nil {
+			return nil, d.err
+		}
+		name = nameValue.ToString()
+	} else {
+		name = alg.ToString()
+	}
+
+	params := &algorithmParams{}
+	for _, registered := range cryptoAlgorithms[op] {
+		if strings.EqualFold(registered, name) {
+			params.name = registered
+		}
+	}
+	if params.name == "" {
+		return nil, newCryptoError("NotSupportedError", "Unrecognized algorithm name")
+	}
+
+	switch op {
+	case "sign", "verify":
+		switch params.name {
+		case "ECDSA":
+			params.hash = d.hash("hash")
+		case "RSA-PSS":
+			params.saltLength = d.unsigned("saltLength", true, 0)
+		}
+	case "encrypt", "decrypt":
+		switch params.name {
+		case "AES-GCM":
+			params.iv = d.bytes("iv", true)
+			params.additionalData = d.bytes("additionalData", false)
+			params.tagLength = d.unsigned("tagLength", false, 128)
+		case "AES-CBC":
+			params.iv = d.bytes("iv", true)
+		case "AES-CTR":
+			params.iv = d.bytes("counter", true)
+			params.length = d.unsigned("length", true, 0)
+		}
+	case "generateKey", "importKey", "get key length":
+		switch params.name {
+		case "HMAC":
+			params.hash = d.hash("hash")
+			params.length, params.hasLength = d.optionalUnsigned("length")
+		case "AES-GCM", "AES-CBC", "AES-CTR":
+			if op != "importKey" {
+				params.length, params.hasLength = d.unsigned("length", true, 0), true
+			}
+		case "ECDSA", "ECDH":
+			params.namedCurve = d.str("namedCurve")
+		case "RSA-PSS":
+			params.hash = d.hash("hash")
+			if op == "generateKey" {
+				params.modulusLength = d.unsigned("modulusLength", true, 0)
+				params.publicExponent = d.bytes("publicExponent", true)
+			}
+		}
+	case "deriveBits":
+		switch params.name {
+		case "ECDH", "X25519":
+			params.public = d.key("public")
+		case "PBKDF2":
+			params.salt = d.bytes("salt", true)
+			params.iterations = d.unsigned("iterations", true, 0)
+			params.hash = d.hash("hash")
+		case "HKDF":
+			params.hash = d.hash("hash")
+			params.salt = d.bytes("salt", true)
+			params.info = d.bytes("info", true)
+		}
+	}
+	return params, d.err
+}
+
+// algorithmDict reads the members of an algorithm dictionary, keeping the
+// first error reading one ran into
+type algorithmDict struct {
+	vm  *vm.VM
+	obj vm.Value
+	err error
+}
+
+func (d *algorithmDict) member(name string, required bool) vm.Value {
+	if d.err != nil {
+		return vm.Undefined
+	}
+	v := vm.Undefined
+	if d.obj.IsObject() {
+		var err error
+		if v, err = d.vm.GetProperty(d.obj, name); err != nil {
+			d.err = err
+			return vm.Undefined
+		}
+	}
+	if required && v.Type() == vm.TypeUndefined {
+		d.err = newCryptoError("TypeError", "Algorithm: %s: Missing required member", name)
+	}
+	return v
+}
+
+// bytes reads a BufferSource member, copying its bytes
+func (d *algorithmDict) bytes(name string, required bool) []byte {
+	v := d.member(name, required)
+	if d.err != nil || v.Type() == vm.TypeUndefined {
+		return nil
+	}
+	data, ok := bufferSourceBytes(v)
+	if !ok {
+		d.err = newCryptoError("TypeError", "Algorithm: %s: Not a BufferSource", name)
+		return nil
+	}
+	return append([]byte{}, data...)
+}
+
+// unsigned reads an [EnforceRange] unsigned long member, def if it's
+// optional and missing
+func (d *algorithmDict) unsigned(name string, required bool, def int) int {
+	if n, present := d.readUnsigned(name, required); present {
+		return n
+	}
+	return def
+}
+
+// optionalUnsigned reads an optional unsigned long member, and whether it
+// was there
+func (d *algorithmDict) optionalUnsigned(name string) (int, bool) {
+	return d.readUnsigned(name, false)
+}
+
+func (d *algorithmDict) readUnsigned(name string, required bool) (int, bool) {
+	v := d.member(name, required)
+	if d.err != nil || v.Type() == vm.TypeUndefined {
+		return 0, false
+	}
+	n, ok := enforceUnsigned(v)
+	if !ok {
+		d.err = newCryptoError("TypeError", "Algorithm: %s: Outside the range of an unsigned long", name)
+	}
+	return n, ok
+}
+
+func (d *algorithmDict) str(name string) string {
+	v := d.member(name, true)
+	if d.err != nil {
+		return ""
+	}
+	return v.ToString()
+}
+
+// hash reads a HashAlgorithmIdentifier member, returning the hash's name
+func (d *algorithmDict) hash(name string) string {
+	v := d.member(name, true)
+	if d.err != nil {
+		return ""
+	}
+	params, err := normalizeAlgorithm(d.vm, v, "digest")
+	if err != nil {
+		d.err = err
+		return ""
+	}
+	return params.name
+}
+
+func (d *algorithmDict) key(name string) *cryptoKey {
+	v := d.member(name, true)
+	if d.err != nil {
+		return nil
+	}
+	key := cryptoKeyOf(v)
+	if key == nil {
+		d.err = newCryptoError("TypeError", "Algorithm: %s: Not a CryptoKey", name)
+	}
+	return key
+}
+
+// enforceUnsigned converts v to an [EnforceRange] unsigned long
+func enforceUnsigned(v vm.Value) (int, bool) {
+	n := v.ToFloat()
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	n = math.Trunc(n)
+	if n < 0 || n > math.MaxUint32 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// hashBlockBits is the block size of a hash in bits, the default length of
+// HMAC keys for it
+func hashBlockBits(hash string) int {
+	return cryptoHashes[hash].New().BlockSize() * 8
+}
+
+func digest(hash string, data []byte) []byte {
+	h := cryptoHashes[hash].New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// checkUsage is what every operation checks of the key it's given: that
+// it's for the algorithm the operation names, and allows usage
+func (k *cryptoKey) checkUsage(algorithm, usage string) error {
+	if k.algorithm.name != algorithm {
+		return newCryptoError("InvalidAccessError", "The requested operation is not valid for the provided key")
+	}
+	for _, u := range k.usages {
+		if u == usage {
+			return nil
+		}
+	}
+	return newCryptoError("InvalidAccessError", "The key does not support the %q operation", usage)
+}
+
+// checkType checks that key is a public or private key, as kind says
+func (k *cryptoKey) checkType(kind string) error {
+	if k.kind != kind {
+		return newCryptoError("InvalidAccessError", "The key is not a %s key", kind)
+	}
+	return nil
+}
+
+// cryptoSign implements sign for the algorithms that support it
+func cryptoSign(params *algorithmParams, key *cryptoKey, data []byte) ([]byte, error) {
+	switch params.name {
+	case "HMAC":
+		mac := hmac.New(cryptoHashes[key.algorithm.hash].New, key.handle.([]byte))
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+
+	if err := key.checkType("private"); err != nil {
+		return nil, err
+	}
+	switch params.name {
+	case "ECDSA":
+		priv := key.handle.(*ecdsa.PrivateKey)
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest(params.hash, data))
+		if err != nil {
+			return nil, newCryptoError("OperationError", "%v", err)
+		}
+		// Signatures are r and s side by side, each the size of the curve
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+	case "Ed25519":
+		return ed25519.Sign(key.handle.(ed25519.PrivateKey), data), nil
+	case "RSA-PSS":
+		opts, err := pssOptions(params, key)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := rsa.SignPSS(rand.Reader, key.handle.(*rsa.PrivateKey), opts.Hash, digest(key.algorithm.hash, data), opts)
+		if err != nil {
+			return nil, newCryptoError("OperationError", "%v", err)
+		}
+		return signature, nil
+	}
+	return nil, newCryptoError("NotSupportedError", "Unrecognized algorithm name")
+}
+
+// cryptoVerify implements verify for the algorithms that support it
+func cryptoVerify(params *algorithmParams, key *cryptoKey, signature, data []byte) (bool, error) {
+	switch params.name {
+	case "HMAC":
+		expected, _ := cryptoSign(params, key, data)
+		return hmac.Equal(expected, signature), nil
+	}
+
+	if err := key.checkType("public"); err != nil {
+		return false, err
+	}
+	switch params.name {
+	case "ECDSA":
+		pub := key.handle.(*ecdsa.PublicKey)
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false, nil
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest(params.hash, data), r, s), nil
+	case "Ed25519":
+		return len(signature) == ed25519.SignatureSize && ed25519.Verify(key.handle.(ed25519.PublicKey), data, signature), nil
+	case "RSA-PSS":
+		opts, err := pssOptions(params, key)
+		if err != nil {
+			return false, err
+		}
+		return rsa.VerifyPSS(key.handle.(*rsa.PublicKey), opts.Hash, digest(key.algorithm.hash, data), signature, opts) == nil, nil
+	}
+	return false, newCryptoError("NotSupportedError", "Unrecognized algorithm name")
+}
+
+// pssOptions returns the options for signing or verifying with an RSA-PSS
+// key. A salt length of zero means "automatic" to Go, so it isn't supported.
+func pssOptions(params *algorithmParams, key *cryptoKey) (*rsa.PSSOptions, error) {
+	if params.saltLength == 0 {
+		return nil, newCryptoError("NotSupportedError", "RSA-PSS with a saltLength of 0 is not supported")
+	}
+	return &rsa.PSSOptions{SaltLength: params.saltLength, Hash: cryptoHashes[key.algorithm.hash]}, nil
+}
+
+// aesCrypt implements encrypt, and decrypt when decrypting, for the AES
+// algorithms
+func aesCrypt(params *algorithmParams, key *cryptoKey, data []byte, decrypting bool) ([]byte, error) {
+	block, err := aes.NewCipher(key.handle.([]byte))
+	if err != nil {
+		return nil, newCryptoError("OperationError", "%v", err)
+	}
+
+	switch params.name {
+	case "AES-GCM":
+		aead, err := newGCM(block, len(params.iv), params.tagLength)
+		if err != nil {
+			return nil, err
+		}
+		if !decrypting {
+			return aead.Seal(nil, params.iv, data, params.additionalData), nil
+		}
+		if len(data) < aead.Overhead() {
+			return nil, newCryptoError("OperationError", "The ciphertext is shorter than the tag")
+		}
+		plaintext, err := aead.Open(nil, params.iv, data, params.additionalData)
+		if err != nil {
+			return nil, newCryptoError("OperationError", "The operation failed for an operation-specific reason")
+		}
+		return plaintext, nil
+
+	case "AES-CBC":
+		if len(params.iv) != aes.BlockSize {
+			return nil, newCryptoError("OperationError", "The iv must be 16 bytes long")
+		}
+		if !decrypting {
+			// PKCS#7 padding, a whole block of it when data is block-aligned
+			padding := aes.BlockSize - len(data)%aes.BlockSize
+			padded := append(append([]byte{}, data...), make([]byte, padding)...)
+			for i := len(data); i < len(padded); i++ {
+				padded[i] = byte(padding)
+			}
+			cipher.NewCBCEncrypter(block, params.iv).CryptBlocks(padded, padded)
+			return padded, nil
+		}
+		if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, newCryptoError("OperationError", "The ciphertext is not a whole number of blocks")
+		}
+		plaintext := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, params.iv).CryptBlocks(plaintext, data)
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, newCryptoError("OperationError", "Bad padding")
+		}
+		for _, b := range plaintext[len(plaintext)-padding:] {
+			if int(b) != padding {
+				return nil, newCryptoError("OperationError", "Bad padding")
+			}
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	case "AES-CTR":
+		if len(params.iv) != aes.BlockSize {
+			return nil, newCryptoError("OperationError", "The counter must be 16 bytes long")
+		}
+		if params.length == 0 || params.length > 128 {
+			return nil, newCryptoError("OperationError", "The counter length must be between 1 and 128")
+		}
+		return aesCTR(block, params.iv, params.length, data)
+	}
+	return nil, newCryptoError("NotSupportedError", "Unrecognized algorithm name")
+}
+
+// newGCM returns AES-GCM with the nonce and tag sizes asked for. Go lets one
+// or the other differ from the standard 12 and 16 bytes, not both, and
+// doesn't do 32 and 64 bit tags.
+func newGCM(block cipher.Block, nonceSize, tagLength int) (cipher.AEAD, error) {
+	switch tagLength {
+	case 32, 64:
+		return nil, newCryptoError("NotSupportedError", "AES-GCM tags of %d bits are not supported", tagLength)
+	case 96, 104, 112, 120, 128:
+	default:
+		return nil, newCryptoError("OperationError", "%d is not a valid AES-GCM tag length", tagLength)
+	}
+	if nonceSize == 0 {
+		return nil, newCryptoError("OperationError", "The iv must not be empty")
+	}
+	var aead cipher.AEAD
+	var err error
+	switch {
+	case nonceSize == 12:
+		aead, err = cipher.NewGCMWithTagSize(block, tagLength/8)
+	case tagLength == 128:
+		aead, err = cipher.NewGCMWithNonceSize(block, nonceSize)
+	default:
+		return nil, newCryptoError("NotSupportedError", "AES-GCM with both a %d byte iv and %d bit tags is not supported", nonceSize, tagLength)
+	}
+	if err != nil {
+		return nil, newCryptoError("OperationError", "%v", err)
+	}
+	return aead, nil
+}
+
+// aesCTR encrypts or decrypts data in CTR mode, where only the rightmost
+// length bits of the counter block are incremented, wrapping around
+func aesCTR(block cipher.Block, counter []byte, length int, data []byte) ([]byte, error) {
+	blocks := (len(data) + aes.BlockSize - 1) / aes.BlockSize
+	if length < 64 && uint64(blocks) > 1<<length {
+		return nil, newCryptoError("OperationError", "The counter would wrap around")
+	}
+
+	var maskHi, maskLo uint64
+	if length >= 64 {
+		maskLo = math.MaxUint64
+		maskHi = 1<<(length-64) - 1
+	} else {
+		maskLo = 1<<length - 1
+	}
+	hi := binary.BigEndian.Uint64(counter[:8])
+	lo := binary.BigEndian.Uint64(counter[8:])
+
+	out := make([]byte, len(data))
+	counterBlock := make([]byte, aes.BlockSize)
+	keystream := make([]byte, aes.BlockSize)
+	for i := 0; i < len(data); i += aes.BlockSize {
+		binary.BigEndian.PutUint64(counterBlock[:8], hi)
+		binary.BigEndian.PutUint64(counterBlock[8:], lo)
+		block.Encrypt(keystream, counterBlock)
+		subtle.XORBytes(out[i:], data[i:], keystream)
+
+		nextLo, nextHi := lo+1, hi
+		if nextLo == 0 {
+			nextHi++
+		}
+		lo = lo&^maskLo | nextLo&maskLo
+		hi = hi&^maskHi | nextHi&maskHi
+	}
+	return out, nil
+}
+
+// deriveBits implements deriveBits for the algorithms that support it.
+// length is nil when deriving all the bits ECDH and X25519 can.
+func deriveBits(params *algorithmParams, key *cryptoKey, length *int) ([]byte, error) {
+	switch params.name {
+	case "ECDH", "X25519":
+		if err := key.checkType("private"); err != nil {
+			return nil, err
+		}
+		public := params.public
+		if public.kind != "public" {
+			return nil, newCryptoError("InvalidAccessError", "The public member is not a public key")
+		}
+		if public.algorithm.name != key.algorithm.name || public.algorithm.namedCurve != key.algorithm.namedCurve {
+			return nil, newCryptoError("InvalidAccessError", "The public key is not of the same algorithm and curve")
+		}
+		priv, pub, err := ecdhKeys(key, public)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := priv.ECDH(pub)
+		if err != nil {
+			return nil, newCryptoError("OperationError", "%v", err)
+		}
+		if length == nil {
+			return secret, nil
+		}
+		if *length > len(secret)*8 {
+			return nil, newCryptoError("OperationError", "The length is longer than the shared secret")
+		}
+		bits := secret[:(*length+7)/8]
+		if rest := *length % 8; rest != 0 {
+			bits[len(bits)-1] &= 0xff << (8 - rest)
+		}
+		return bits, nil
+
+	case "PBKDF2", "HKDF":
+		if length == nil || *length%8 != 0 {
+			return nil, newCryptoError("OperationError", "The length must be a multiple of 8")
+		}
+		if *length == 0 {
+			return []byte{}, nil
+		}
+		secret := key.handle.([]byte)
+		hash := cryptoHashes[params.hash]
+		var bits []byte
+		var err error
+		if params.name == "PBKDF2" {
+			if params.iterations == 0 {
+				return nil, newCryptoError("OperationError", "The iterations must not be 0")
+			}
+			bits, err = pbkdf2.Key(hash.New, string(secret), params.salt, params.iterations, *length/8)
+		} else {
+			bits, err = hkdf.Key(hash.New, secret, params.salt, string(params.info), *length/8)
+		}
+		if err != nil {
+			return nil, newCryptoError("OperationError", "%v", err)
+		}
+		return bits, nil
+	}
+	return nil, newCryptoError("NotSupportedError", "Unrecognized algorithm name")
+}
+
+// ecdhKeys returns the private and public key of an ECDH or X25519 key
+// agreement; ECDH keys are held as ECDSA ones, like the curves' other keys
+func ecdhKeys(private, public *cryptoKey) (*ecdh.PrivateKey, *ecdh.PublicKey, error) {
+	if private.algorithm.name == "X25519" {
+		return private.handle.(*ecdh.PrivateKey), public.handle.(*ecdh.PublicKey), nil
+	}
+	priv, err := private.handle.(*ecdsa.PrivateKey).ECDH()
+	if err != nil {
+		return nil, nil, newCryptoError("OperationError", "%v", err)
+	}
+	pub, err := public.handle.(*ecdsa.PublicKey).ECDH()
+	if err != nil {
+		return nil, nil, newCryptoError("OperationError", "%v", err)
+	}
+	return priv, pub, nil
+}
+
+// keyLength implements "get key length" for deriveKey's derivedKeyType,
+// returning nil for the algorithms whose keys have no set length
+func keyLength(params *algorithmParams) (*int, error) {
+	switch params.name {
+	case "AES-GCM", "AES-CBC", "AES-CTR":
+		if params.length != 128 && params.length != 192 && params.length != 256 {
+			return nil, newCryptoError("OperationError", "AES key length must be 128, 192 or 256 bits")
+		}
+		return &params.length, nil
+	case "HMAC":
+		length := hashBlockBits(params.hash)
+		if params.hasLength {
+			if params.length == 0 {
+				return nil, newCryptoError("TypeError", "HMAC key length must not be 0")
+			}
+			length = params.length
+		}
+		return &length, nil
+	}
+	return nil, nil
+}
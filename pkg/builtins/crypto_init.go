@@ -0,0 +1,515 @@
+package builtins
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for Web Crypto
+const PriorityCrypto = 430 // After the typed arrays, whose views getRandomValues fills
+
+// CryptoInitializer implements the Web Crypto API: the crypto global, with
+// getRandomValues, randomUUID and the SubtleCrypto at crypto.subtle
+type CryptoInitializer struct{}
+
+func (c *CryptoInitializer) Name() string {
+	return "Crypto"
+}
+
+func (c *CryptoInitializer) Priority() int {
+	return PriorityCrypto
+}
+
+func (c *CryptoInitializer) InitTypes(ctx *TypeContext) error {
+	keyUsagesType := &types.ArrayType{ElementType: types.String}
+	cryptoKeyType := types.NewObjectType().
+		WithProperty("type", types.String).
+		WithProperty("extractable", types.Boolean).
+		WithProperty("algorithm", types.Any).
+		WithProperty("usages", keyUsagesType)
+	cryptoKeyPairType := types.NewObjectType().
+		WithProperty("publicKey", cryptoKeyType).
+		WithProperty("privateKey", cryptoKeyType)
+
+	// Algorithms are names or dictionaries, and key data BufferSources or
+	// JWKs, so those parameters are any, as are the promises
+	bufferSource := types.Any
+	promise := types.Any // Promise<ArrayBuffer>, Promise<CryptoKey> and so on
+	method := func(params ...types.Type) types.Type {
+		return types.NewSimpleFunction(params, promise)
+	}
+	subtleType := types.NewObjectType().
+		WithProperty("encrypt", method(types.Any, cryptoKeyType, bufferSource)).
+		WithProperty("decrypt", method(types.Any, cryptoKeyType, bufferSource)).
+		WithProperty("sign", method(types.Any, cryptoKeyType, bufferSource)).
+		WithProperty("verify", method(types.Any, cryptoKeyType, bufferSource, bufferSource)).
+		WithProperty("digest", method(types.Any, bufferSource)).
+		WithProperty("generateKey", method(types.Any, types.Boolean, keyUsagesType)).
+		WithProperty("deriveKey", method(types.Any, cryptoKeyType, types.Any, types.Boolean, keyUsagesType)).
+		WithProperty("deriveBits", types.NewOptionalFunction([]types.Type{types.Any, cryptoKeyType, types.NewUnionType(types.Number, types.Null)}, promise, []bool{false, false, true})).
+		WithProperty("importKey", method(types.String, types.Any, types.Any, types.Boolean, keyUsagesType)).
+		WithProperty("exportKey", method(types.String, cryptoKeyType))
+
+	// getRandomValues<T>(array: T): T
+	tParam := &types.TypeParameter{Name: "T", Index: 0}
+	tType := &types.TypeParameterType{Parameter: tParam}
+	cryptoType := types.NewObjectType().
+		WithProperty("subtle", subtleType).
+		WithProperty("getRandomValues", &types.GenericType{
+			Name:           "getRandomValues",
+			TypeParameters: []*types.TypeParameter{tParam},
+			Body:           types.NewSimpleFunction([]types.Type{tType}, tType),
+		}).
+		WithProperty("randomUUID", types.NewSimpleFunction([]types.Type{}, types.String))
+
+	classes := []struct {
+		name     string
+		instance types.Type
+	}{
+		{"Crypto", cryptoType},
+		{"SubtleCrypto", subtleType},
+		{"CryptoKey", cryptoKeyType},
+	}
+	for _, class := range classes {
+		// Constructing them throws, but as in lib.dom.d.ts they have a
+		// construct signature, which instanceof needs
+		ctorType := types.NewObjectType().
+			WithSimpleConstructSignature([]types.Type{}, class.instance).
+			WithProperty("prototype", class.instance)
+		if err := ctx.DefineGlobal(class.name, ctorType); err != nil {
+			return err
+		}
+		if err := ctx.DefineTypeAlias(class.name, class.instance); err != nil {
+			return err
+		}
+	}
+	if err := ctx.DefineTypeAlias("CryptoKeyPair", cryptoKeyPairType); err != nil {
+		return err
+	}
+	return ctx.DefineGlobal("crypto", cryptoType)
+}
+
+func (c *CryptoInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	keyProto, err := defineIllegalConstructor(ctx, "CryptoKey")
+	if err != nil {
+		return err
+	}
+	initCryptoKeyPrototype(vmInstance, keyProto)
+
+	subtleProto, err := defineIllegalConstructor(ctx, "SubtleCrypto")
+	if err != nil {
+		return err
+	}
+	initSubtleCryptoPrototype(vmInstance, subtleProto, keyProto)
+
+	cryptoProto, err := defineIllegalConstructor(ctx, "Crypto")
+	if err != nil {
+		return err
+	}
+	subtleObj := vm.NewObject(vm.NewValueFromPlainObject(subtleProto))
+	defineGetter(cryptoProto, "subtle", func() vm.Value { return subtleObj })
+
+	// getRandomValues(array) fills an integer typed array with random values
+	cryptoProto.SetOwnNonEnumerable("getRandomValues", vm.NewNativeFunction(1, false, "getRandomValues", func(args []vm.Value) (vm.Value, error) {
+		array := argOrUndefined(args, 0)
+		if array.Type() != vm.TypeTypedArray {
+			return vm.Undefined, vmInstance.NewTypeError("Failed to execute 'getRandomValues' on 'Crypto': parameter 1 is not of type 'ArrayBufferView'")
+		}
+		switch array.AsTypedArray().GetElementType() {
+		case vm.TypedArrayFloat32, vm.TypedArrayFloat64:
+			return vm.Undefined, cryptoException(vmInstance, newCryptoError("TypeMismatchError", "The data provided is not an integer-type array"))
+		}
+		data, _ := bufferSourceBytes(array)
+		if len(data) > 65536 {
+			return vm.Undefined, cryptoException(vmInstance, newCryptoError("QuotaExceededError", "The ArrayBufferView's byte length (%d) exceeds the number of bytes of entropy available via this API (65536)", len(data)))
+		}
+		rand.Read(data)
+		return array, nil
+	}))
+
+	// randomUUID() returns a random version 4 UUID
+	cryptoProto.SetOwnNonEnumerable("randomUUID", vm.NewNativeFunction(0, false, "randomUUID", func(args []vm.Value) (vm.Value, error) {
+		b := randomBytes(16)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return vm.NewString(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])), nil
+	}))
+
+	return ctx.DefineGlobal("crypto", vm.NewObject(vm.NewValueFromPlainObject(cryptoProto)))
+}
+
+// cryptoErrorValue returns the value a failed Web Crypto operation rejects
+// or throws with
+func cryptoErrorValue(vmInstance *vm.VM, err error) vm.Value {
+	ce, ok := err.(*cryptoError)
+	if !ok {
+		return thrownValue(err)
+	}
+	if ce.name == "TypeError" {
+		return typeErrorValue(vmInstance, ce.message)
+	}
+	return domExceptionValue(vmInstance, ce.name, ce.message)
+}
+
+// cryptoException returns err as the error a native function throws
+func cryptoException(vmInstance *vm.VM, err error) error {
+	return vmInstance.NewExceptionError(cryptoErrorValue(vmInstance, err))
+}
+
+func initCryptoKeyPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	getters := []struct {
+		name string
+		get  func(k *cryptoKey) vm.Value
+	}{
+		{"type", func(k *cryptoKey) vm.Value { return vm.NewString(k.kind) }},
+		{"extractable", func(k *cryptoKey) vm.Value { return vm.BooleanValue(k.extractable) }},
+		{"algorithm", func(k *cryptoKey) vm.Value {
+			if k.algorithmObj.Type() == vm.TypeUndefined {
+				k.algorithmObj = k.algorithm.object(vmInstance)
+			}
+			return k.algorithmObj
+		}},
+		{"usages", func(k *cryptoKey) vm.Value {
+			if k.usagesObj.Type() == vm.TypeUndefined {
+				k.usagesObj = stringsToArray(k.usages)
+			}
+			return k.usagesObj
+		}},
+	}
+	for _, getter := range getters {
+		fn := vm.NewNativeFunction(0, false, "get "+getter.name, func(args []vm.Value) (vm.Value, error) {
+			key := cryptoKeyOf(vmInstance.GetThis())
+			if key == nil {
+				return vm.Undefined, vmInstance.NewTypeError("CryptoKey.prototype." + getter.name + " called on an object that is not a CryptoKey")
+			}
+			return getter.get(key), nil
+		})
+		e, c := true, true
+		proto.DefineAccessorProperty(getter.name, fn, true, vm.Undefined, false, &e, &c)
+	}
+}
+
+// cryptoWork is the part of a SubtleCrypto method the spec runs in
+// parallel. It runs off the VM's goroutine, so it mustn't touch the VM, and
+// returns a function making the promise's value once back on it.
+type cryptoWork func() (func() vm.Value, error)
+
+// defineSubtleMethod defines the SubtleCrypto method name. fn reads the
+// arguments and returns the work to do; either failing rejects the promise
+// the method returns.
+func defineSubtleMethod(vmInstance *vm.VM, proto *vm.PlainObject, name string, arity int, fn func(args []vm.Value) (cryptoWork, error)) {
+	proto.SetOwnNonEnumerable(name, vm.NewNativeFunction(arity, false, name, func(args []vm.Value) (vm.Value, error) {
+		work, err := fn(args)
+		if err != nil {
+			return vmInstance.NewRejectedPromise(cryptoErrorValue(vmInstance, err)), nil
+		}
+		promise, resolve, reject := settled(vmInstance)
+		vmInstance.GetAsyncRuntime().BeginExternalOp()
+		go func() {
+			result, err := work()
+			runOnEventLoop(vmInstance, func() {
+				if err != nil {
+					reject(cryptoErrorValue(vmInstance, err))
+					return
+				}
+				resolve(result())
+			})
+		}()
+		return promise, nil
+	}))
+}
+
+// arrayBufferResult returns a cryptoWork result making an ArrayBuffer
+func arrayBufferResult(data []byte) func() vm.Value {
+	return func() vm.Value {
+		buffer := vm.NewArrayBuffer(len(data))
+		copy(buffer.AsArrayBuffer().GetData(), data)
+		return buffer
+	}
+}
+
+// cryptoKeyArg reads an argument that must be a CryptoKey
+func cryptoKeyArg(args []vm.Value, i int) (*cryptoKey, error) {
+	key := cryptoKeyOf(argOrUndefined(args, i))
+	if key == nil {
+		return nil, newCryptoError("TypeError", "parameter %d is not of type 'CryptoKey'", i+1)
+	}
+	return key, nil
+}
+
+// bufferSourceArg reads an argument that must be a BufferSource, copying
+// its bytes as the spec does before going in parallel
+func bufferSourceArg(args []vm.Value, i int) ([]byte, error) {
+	data, ok := bufferSourceBytes(argOrUndefined(args, i))
+	if !ok {
+		return nil, newCryptoError("TypeError", "parameter %d is not of type 'ArrayBuffer' or 'ArrayBufferView'", i+1)
+	}
+	return append([]byte{}, data...), nil
+}
+
+// keyUsagesArg reads an argument that must be a sequence of KeyUsages
+func keyUsagesArg(vmInstance *vm.VM, args []vm.Value, i int) ([]string, error) {
+	usages, err := stringSequence(vmInstance, argOrUndefined(args, i), fmt.Sprintf("parameter %d", i+1))
+	if err != nil {
+		return nil, err
+	}
+	for _, usage := range usages {
+		if !containsString(validKeyUsages, usage) {
+			return nil, newCryptoError("TypeError", "%q is not a valid KeyUsage", usage)
+		}
+	}
+	return usages, nil
+}
+
+// keyFormatArg reads an argument that must be a KeyFormat
+func keyFormatArg(args []vm.Value, i int) (string, error) {
+	format := argOrUndefined(args, i).ToString()
+	switch format {
+	case "raw", "spki", "pkcs8", "jwk":
+		return format, nil
+	}
+	return "", newCryptoError("TypeError", "%q is not a valid KeyFormat", format)
+}
+
+func initSubtleCryptoPrototype(vmInstance *vm.VM, proto, keyProto *vm.PlainObject) {
+	for _, op := range []string{"encrypt", "decrypt"} {
+		decrypting := op == "decrypt"
+		defineSubtleMethod(vmInstance, proto, op, 3, func(args []vm.Value) (cryptoWork, error) {
+			params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), op)
+			if err != nil {
+				return nil, err
+			}
+			key, err := cryptoKeyArg(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			data, err := bufferSourceArg(args, 2)
+			if err != nil {
+				return nil, err
+			}
+			return func() (func() vm.Value, error) {
+				if err := key.checkUsage(params.name, op); err != nil {
+					return nil, err
+				}
+				result, err := aesCrypt(params, key, data, decrypting)
+				return arrayBufferResult(result), err
+			}, nil
+		})
+	}
+
+	defineSubtleMethod(vmInstance, proto, "sign", 3, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "sign")
+		if err != nil {
+			return nil, err
+		}
+		key, err := cryptoKeyArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		data, err := bufferSourceArg(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			if err := key.checkUsage(params.name, "sign"); err != nil {
+				return nil, err
+			}
+			signature, err := cryptoSign(params, key, data)
+			return arrayBufferResult(signature), err
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "verify", 4, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "verify")
+		if err != nil {
+			return nil, err
+		}
+		key, err := cryptoKeyArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := bufferSourceArg(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		data, err := bufferSourceArg(args, 3)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			if err := key.checkUsage(params.name, "verify"); err != nil {
+				return nil, err
+			}
+			ok, err := cryptoVerify(params, key, signature, data)
+			return func() vm.Value { return vm.BooleanValue(ok) }, err
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "digest", 2, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "digest")
+		if err != nil {
+			return nil, err
+		}
+		data, err := bufferSourceArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			return arrayBufferResult(digest(params.name, data)), nil
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "generateKey", 3, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "generateKey")
+		if err != nil {
+			return nil, err
+		}
+		extractable := argOrUndefined(args, 1).IsTruthy()
+		usages, err := keyUsagesArg(vmInstance, args, 2)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			key, pair, err := generateKey(params, extractable, usages)
+			if err != nil {
+				return nil, err
+			}
+			return func() vm.Value {
+				if key != nil {
+					return key.object(keyProto)
+				}
+				obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+				obj.SetOwn("publicKey", pair.publicKey.object(keyProto))
+				obj.SetOwn("privateKey", pair.privateKey.object(keyProto))
+				return vm.NewValueFromPlainObject(obj)
+			}, nil
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "deriveBits", 2, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "deriveBits")
+		if err != nil {
+			return nil, err
+		}
+		key, err := cryptoKeyArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		var length *int
+		if lengthArg := argOrUndefined(args, 2); lengthArg.Type() != vm.TypeUndefined && lengthArg.Type() != vm.TypeNull {
+			n, ok := enforceUnsigned(lengthArg)
+			if !ok {
+				return nil, newCryptoError("TypeError", "parameter 3 is outside the range of an unsigned long")
+			}
+			length = &n
+		}
+		return func() (func() vm.Value, error) {
+			if err := key.checkUsage(params.name, "deriveBits"); err != nil {
+				return nil, err
+			}
+			bits, err := deriveBits(params, key, length)
+			return arrayBufferResult(bits), err
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "deriveKey", 5, func(args []vm.Value) (cryptoWork, error) {
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 0), "deriveBits")
+		if err != nil {
+			return nil, err
+		}
+		key, err := cryptoKeyArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		importParams, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 2), "importKey")
+		if err != nil {
+			return nil, err
+		}
+		lengthParams, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 2), "get key length")
+		if err != nil {
+			return nil, err
+		}
+		extractable := argOrUndefined(args, 3).IsTruthy()
+		usages, err := keyUsagesArg(vmInstance, args, 4)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			if err := key.checkUsage(params.name, "deriveKey"); err != nil {
+				return nil, err
+			}
+			length, err := keyLength(lengthParams)
+			if err != nil {
+				return nil, err
+			}
+			bits, err := deriveBits(params, key, length)
+			if err != nil {
+				return nil, err
+			}
+			derived, err := importKey("raw", bits, nil, importParams, extractable, usages)
+			if err != nil {
+				return nil, err
+			}
+			return func() vm.Value { return derived.object(keyProto) }, nil
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "importKey", 5, func(args []vm.Value) (cryptoWork, error) {
+		format, err := keyFormatArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		var keyData []byte
+		var jwk *jsonWebKey
+		if format == "jwk" {
+			jwk, err = readJSONWebKey(vmInstance, argOrUndefined(args, 1))
+		} else {
+			keyData, err = bufferSourceArg(args, 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		params, err := normalizeAlgorithm(vmInstance, argOrUndefined(args, 2), "importKey")
+		if err != nil {
+			return nil, err
+		}
+		extractable := argOrUndefined(args, 3).IsTruthy()
+		usages, err := keyUsagesArg(vmInstance, args, 4)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			key, err := importKey(format, keyData, jwk, params, extractable, usages)
+			if err != nil {
+				return nil, err
+			}
+			return func() vm.Value { return key.object(keyProto) }, nil
+		}, nil
+	})
+
+	defineSubtleMethod(vmInstance, proto, "exportKey", 2, func(args []vm.Value) (cryptoWork, error) {
+		format, err := keyFormatArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		key, err := cryptoKeyArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return func() (func() vm.Value, error) {
+			data, jwk, err := exportKey(format, key)
+			if err != nil {
+				return nil, err
+			}
+			if jwk != nil {
+				return func() vm.Value { return jwk.object(vmInstance) }, nil
+			}
+			return arrayBufferResult(data), nil
+		}, nil
+	})
+}
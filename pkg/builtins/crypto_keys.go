@@ -0,0 +1,846 @@
+package builtins
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// cryptoKey is the state of a CryptoKey. Its handle is the key material as
+// the Go crypto packages take it: []byte for secret keys, and the public or
+// private key types of crypto/ecdsa (ECDSA and ECDH), crypto/ed25519,
+// crypto/ecdh (X25519) and crypto/rsa for the others.
+type cryptoKey struct {
+	kind        string // "secret", "public" or "private"
+	extractable bool
+	algorithm   keyAlgorithm
+	usages      []string
+	handle      any
+
+	// The objects the algorithm and usages getters return every time
+	algorithmObj vm.Value
+	usagesObj    vm.Value
+}
+
+// cryptoKeyOf returns the state of a CryptoKey object, or nil
+func cryptoKeyOf(v vm.Value) *cryptoKey {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	key, _ := v.AsPlainObject().HostData().(*cryptoKey)
+	return key
+}
+
+func (k *cryptoKey) object(proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto))
+	obj.AsPlainObject().SetHostData(k)
+	return obj
+}
+
+// cryptoKeyPair is what generateKey makes for the asymmetric algorithms
+type cryptoKeyPair struct {
+	publicKey  *cryptoKey
+	privateKey *cryptoKey
+}
+
+// keyAlgorithm is a key's [[algorithm]], of which script sees the members
+// its algorithm has
+type keyAlgorithm struct {
+	name           string
+	length         int    // AES and HMAC, in bits
+	hash           string // HMAC and RSA-PSS
+	namedCurve     string // ECDSA and ECDH
+	modulusLength  int    // RSA-PSS
+	publicExponent []byte // RSA-PSS
+}
+
+func (a keyAlgorithm) object(vmInstance *vm.VM) vm.Value {
+	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	obj.SetOwn("name", vm.NewString(a.name))
+	if a.length != 0 {
+		obj.SetOwn("length", vm.NumberValue(float64(a.length)))
+	}
+	if a.namedCurve != "" {
+		obj.SetOwn("namedCurve", vm.NewString(a.namedCurve))
+	}
+	if a.modulusLength != 0 {
+		obj.SetOwn("modulusLength", vm.NumberValue(float64(a.modulusLength)))
+		obj.SetOwn("publicExponent", newUint8Array(a.publicExponent))
+	}
+	if a.hash != "" {
+		hash := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+		hash.SetOwn("name", vm.NewString(a.hash))
+		obj.SetOwn("hash", vm.NewValueFromPlainObject(hash))
+	}
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// usageSet is the usages an algorithm's keys may have. Secret keys take the
+// private usages; a nil public list means the algorithm has no public keys.
+type usageSet struct {
+	private []string
+	public  []string
+}
+
+var (
+	signUsages   = usageSet{private: []string{"sign"}, public: []string{"verify"}}
+	cipherUsages = usageSet{private: []string{"encrypt", "decrypt", "wrapKey", "unwrapKey"}}
+	deriveUsages = []string{"deriveKey", "deriveBits"}
+)
+
+var keyUsages = map[string]usageSet{
+	"HMAC":    {private: []string{"sign", "verify"}},
+	"AES-GCM": cipherUsages,
+	"AES-CBC": cipherUsages,
+	"AES-CTR": cipherUsages,
+	"ECDSA":   signUsages,
+	"Ed25519": signUsages,
+	"RSA-PSS": signUsages,
+	"ECDH":    {private: deriveUsages, public: []string{}},
+	"X25519":  {private: deriveUsages, public: []string{}},
+	"PBKDF2":  {private: deriveUsages},
+	"HKDF":    {private: deriveUsages},
+}
+
+// validKeyUsages are the values of the KeyUsage enum
+var validKeyUsages = []string{"encrypt", "decrypt", "sign", "verify", "deriveKey", "deriveBits", "wrapKey", "unwrapKey"}
+
+// checkUsages checks that usages are all in allowed
+func checkUsages(usages, allowed []string) error {
+	for _, usage := range usages {
+		if !containsString(allowed, usage) {
+			return newCryptoError("SyntaxError", "Cannot create a key using the specified key usages")
+		}
+	}
+	return nil
+}
+
+// intersectUsages returns the usages in allowed, in allowed's order
+func intersectUsages(usages, allowed []string) []string {
+	result := []string{}
+	for _, usage := range allowed {
+		if containsString(usages, usage) {
+			result = append(result, usage)
+		}
+	}
+	return result
+}
+
+var namedCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// newKey returns a key, whose usages are those in usages its kind allows
+func newKey(kind string, algorithm keyAlgorithm, extractable bool, usages []string, handle any) *cryptoKey {
+	allowed := keyUsages[algorithm.name]
+	if kind == "public" {
+		usages = intersectUsages(usages, allowed.public)
+	} else {
+		usages = intersectUsages(usages, allowed.private)
+	}
+	return &cryptoKey{kind: kind, extractable: extractable, algorithm: algorithm, usages: usages, handle: handle}
+}
+
+// generateKey implements generateKey, returning a secret key or a key pair
+func generateKey(params *algorithmParams, extractable bool, usages []string) (*cryptoKey, *cryptoKeyPair, error) {
+	allowed := keyUsages[params.name]
+	if err := checkUsages(usages, append(append([]string{}, allowed.private...), allowed.public...)); err != nil {
+		return nil, nil, err
+	}
+
+	algorithm := keyAlgorithm{name: params.name}
+	var private, public any
+	switch params.name {
+	case "HMAC":
+		algorithm.hash = params.hash
+		length, err := keyLength(params)
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "HMAC key length must not be 0")
+		}
+		algorithm.length = *length
+		private = randomBytes((algorithm.length + 7) / 8)
+	case "AES-GCM", "AES-CBC", "AES-CTR":
+		length, err := keyLength(params)
+		if err != nil {
+			return nil, nil, err
+		}
+		algorithm.length = *length
+		private = randomBytes(algorithm.length / 8)
+	case "ECDSA", "ECDH":
+		curve, ok := namedCurves[params.namedCurve]
+		if !ok {
+			return nil, nil, newCryptoError("NotSupportedError", "Unsupported named curve %q", params.namedCurve)
+		}
+		algorithm.namedCurve = params.namedCurve
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		private, public = priv, &priv.PublicKey
+	case "Ed25519":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		private, public = priv, pub
+	case "X25519":
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		private, public = priv, priv.PublicKey()
+	case "RSA-PSS":
+		// Go only generates keys with the usual public exponent
+		if !bytes.Equal(bytes.TrimLeft(params.publicExponent, "\x00"), []byte{1, 0, 1}) {
+			return nil, nil, newCryptoError("OperationError", "Only a publicExponent of 65537 is supported")
+		}
+		priv, err := rsa.GenerateKey(rand.Reader, params.modulusLength)
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		algorithm.hash = params.hash
+		algorithm.modulusLength = params.modulusLength
+		algorithm.publicExponent = []byte{1, 0, 1}
+		private, public = priv, &priv.PublicKey
+	}
+
+	if public == nil {
+		key := newKey("secret", algorithm, extractable, usages, private)
+		if len(key.usages) == 0 {
+			return nil, nil, newCryptoError("SyntaxError", "Usages cannot be empty when creating a key")
+		}
+		return key, nil, nil
+	}
+	pair := &cryptoKeyPair{
+		publicKey:  newKey("public", algorithm, true, usages, public),
+		privateKey: newKey("private", algorithm, extractable, usages, private),
+	}
+	if len(pair.privateKey.usages) == 0 {
+		return nil, nil, newCryptoError("SyntaxError", "Usages cannot be empty when creating a key")
+	}
+	return nil, pair, nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// importKey implements importKey. keyData holds the key for every format
+// but "jwk", whose key is jwk.
+func importKey(format string, keyData []byte, jwk *jsonWebKey, params *algorithmParams, extractable bool, usages []string) (*cryptoKey, error) {
+	allowed := keyUsages[params.name]
+	kind, allowedUsages := "secret", allowed.private
+	if allowed.public != nil {
+		kind, allowedUsages = "public", allowed.public
+		if format == "pkcs8" || (format == "jwk" && jwk.d != "") {
+			kind, allowedUsages = "private", allowed.private
+		}
+	}
+	if err := checkUsages(usages, allowedUsages); err != nil {
+		return nil, err
+	}
+	if format == "jwk" {
+		if err := jwk.check(params.name, extractable, usages); err != nil {
+			return nil, err
+		}
+	}
+
+	var key *cryptoKey
+	var err error
+	switch params.name {
+	case "HMAC", "AES-GCM", "AES-CBC", "AES-CTR", "PBKDF2", "HKDF":
+		key, err = importSecretKey(format, keyData, jwk, params, extractable)
+	case "ECDSA", "ECDH":
+		key, err = importECKey(format, keyData, jwk, kind, params)
+	case "Ed25519", "X25519":
+		key, err = importOKPKey(format, keyData, jwk, kind, params)
+	case "RSA-PSS":
+		key, err = importRSAKey(format, keyData, jwk, kind, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.kind, key.extractable, key.usages = kind, extractable, usages
+	if kind != "public" && len(usages) == 0 {
+		return nil, newCryptoError("SyntaxError", "Usages cannot be empty when creating a key")
+	}
+	return key, nil
+}
+
+func importSecretKey(format string, keyData []byte, jwk *jsonWebKey, params *algorithmParams, extractable bool) (*cryptoKey, error) {
+	switch format {
+	case "raw":
+	case "jwk":
+		var err error
+		if keyData, err = jwk.decode("k", jwk.k); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+	}
+
+	algorithm := keyAlgorithm{name: params.name}
+	switch params.name {
+	case "HMAC":
+		algorithm.hash = params.hash
+		algorithm.length = len(keyData) * 8
+		if algorithm.length == 0 {
+			return nil, newCryptoError("DataError", "HMAC key data must not be empty")
+		}
+		if params.hasLength {
+			if params.length > algorithm.length || params.length <= algorithm.length-8 {
+				return nil, newCryptoError("DataError", "The length does not match the key data")
+			}
+			algorithm.length = params.length
+		}
+	case "AES-GCM", "AES-CBC", "AES-CTR":
+		if len(keyData) != 16 && len(keyData) != 24 && len(keyData) != 32 {
+			return nil, newCryptoError("DataError", "AES key data must be 128, 192 or 256 bits")
+		}
+		algorithm.length = len(keyData) * 8
+	case "PBKDF2", "HKDF":
+		if extractable {
+			return nil, newCryptoError("SyntaxError", "%s keys cannot be extractable", params.name)
+		}
+	}
+	if format == "jwk" && jwk.alg != "" && jwk.alg != jwkAlg(algorithm) {
+		return nil, newCryptoError("DataError", "The JWK \"alg\" member was not %q", jwkAlg(algorithm))
+	}
+	return &cryptoKey{algorithm: algorithm, handle: keyData}, nil
+}
+
+func importECKey(format string, keyData []byte, jwk *jsonWebKey, kind string, params *algorithmParams) (*cryptoKey, error) {
+	curve, ok := namedCurves[params.namedCurve]
+	if !ok {
+		return nil, newCryptoError("NotSupportedError", "Unsupported named curve %q", params.namedCurve)
+	}
+	algorithm := keyAlgorithm{name: params.name, namedCurve: params.namedCurve}
+
+	var handle any
+	var keyCurve elliptic.Curve
+	switch format {
+	case "raw":
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, keyData)
+		if err != nil {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, keyCurve = pub, pub.Curve
+	case "spki":
+		parsed, err := x509.ParsePKIXPublicKey(keyData)
+		pub, ok := parsed.(*ecdsa.PublicKey)
+		if err != nil || !ok {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, keyCurve = pub, pub.Curve
+	case "pkcs8":
+		parsed, err := x509.ParsePKCS8PrivateKey(keyData)
+		priv, ok := parsed.(*ecdsa.PrivateKey)
+		if err != nil || !ok {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, keyCurve = priv, priv.Curve
+	case "jwk":
+		if jwk.crv != params.namedCurve {
+			return nil, newCryptoError("DataError", "The JWK \"crv\" member was not %q", params.namedCurve)
+		}
+		if params.name == "ECDSA" && jwk.alg != "" && jwk.alg != jwkAlg(algorithm) {
+			return nil, newCryptoError("DataError", "The JWK \"alg\" member was not %q", jwkAlg(algorithm))
+		}
+		x, err := jwk.decode("x", jwk.x)
+		if err != nil {
+			return nil, err
+		}
+		y, err := jwk.decode("y", jwk.y)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, keyCurve = pub, curve
+		if kind == "private" {
+			d, err := jwk.decode("d", jwk.d)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := ecdsa.ParseRawPrivateKey(curve, d)
+			if err != nil || !priv.PublicKey.Equal(pub) {
+				return nil, newCryptoError("DataError", "Invalid key data")
+			}
+			handle = priv
+		}
+	default:
+		return nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+	}
+	if keyCurve != curve {
+		return nil, newCryptoError("DataError", "The key is not on the %s curve", params.namedCurve)
+	}
+	return &cryptoKey{algorithm: algorithm, handle: handle}, nil
+}
+
+// importOKPKey imports Ed25519 and X25519 keys, which JWK calls octet key
+// pairs
+func importOKPKey(format string, keyData []byte, jwk *jsonWebKey, kind string, params *algorithmParams) (*cryptoKey, error) {
+	ed := params.name == "Ed25519"
+	var handle any
+	var ok bool
+	switch format {
+	case "raw":
+		if len(keyData) != 32 {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		if ed {
+			handle, ok = ed25519.PublicKey(keyData), true
+		} else {
+			handle, ok = x25519PublicKey(keyData)
+		}
+	case "spki":
+		parsed, err := x509.ParsePKIXPublicKey(keyData)
+		if err == nil {
+			handle, ok = okpKey(parsed, ed)
+		}
+	case "pkcs8":
+		parsed, err := x509.ParsePKCS8PrivateKey(keyData)
+		if err == nil {
+			handle, ok = okpKey(parsed, ed)
+		}
+	case "jwk":
+		if jwk.crv != params.name {
+			return nil, newCryptoError("DataError", "The JWK \"crv\" member was not %q", params.name)
+		}
+		if ed && jwk.alg != "" && jwk.alg != "Ed25519" && jwk.alg != "EdDSA" {
+			return nil, newCryptoError("DataError", "The JWK \"alg\" member was not \"Ed25519\"")
+		}
+		x, err := jwk.decode("x", jwk.x)
+		if err != nil {
+			return nil, err
+		}
+		if kind == "public" {
+			return importOKPKey("raw", x, nil, kind, params)
+		}
+		d, err := jwk.decode("d", jwk.d)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) != 32 {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		var public []byte
+		if ed {
+			priv := ed25519.NewKeyFromSeed(d)
+			handle, public = priv, priv.Public().(ed25519.PublicKey)
+		} else if priv, err := ecdh.X25519().NewPrivateKey(d); err == nil {
+			handle, public = priv, priv.PublicKey().Bytes()
+		}
+		ok = bytes.Equal(public, x)
+	default:
+		return nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+	}
+	if !ok {
+		return nil, newCryptoError("DataError", "Invalid key data")
+	}
+	return &cryptoKey{algorithm: keyAlgorithm{name: params.name}, handle: handle}, nil
+}
+
+func x25519PublicKey(data []byte) (any, bool) {
+	pub, err := ecdh.X25519().NewPublicKey(data)
+	return pub, err == nil
+}
+
+// okpKey returns a key x509 parsed if it's an Ed25519 key, when ed, or an
+// X25519 one
+func okpKey(parsed any, ed bool) (any, bool) {
+	switch key := parsed.(type) {
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return key, ed
+	case *ecdh.PublicKey:
+		return key, !ed && key.Curve() == ecdh.X25519()
+	case *ecdh.PrivateKey:
+		return key, !ed && key.Curve() == ecdh.X25519()
+	}
+	return nil, false
+}
+
+func importRSAKey(format string, keyData []byte, jwk *jsonWebKey, kind string, params *algorithmParams) (*cryptoKey, error) {
+	algorithm := keyAlgorithm{name: params.name, hash: params.hash}
+	var handle any
+	var pub *rsa.PublicKey
+	switch format {
+	case "spki":
+		parsed, err := x509.ParsePKIXPublicKey(keyData)
+		key, ok := parsed.(*rsa.PublicKey)
+		if err != nil || !ok {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, pub = key, key
+	case "pkcs8":
+		parsed, err := x509.ParsePKCS8PrivateKey(keyData)
+		key, ok := parsed.(*rsa.PrivateKey)
+		if err != nil || !ok {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		handle, pub = key, &key.PublicKey
+	case "jwk":
+		if jwk.alg != "" && jwk.alg != jwkAlg(algorithm) {
+			return nil, newCryptoError("DataError", "The JWK \"alg\" member was not %q", jwkAlg(algorithm))
+		}
+		members := map[string]*big.Int{}
+		names := []string{"n", "e"}
+		if kind == "private" {
+			names = append(names, "d", "p", "q")
+		}
+		for _, name := range names {
+			b, err := jwk.decode(name, *jwk.member(name))
+			if err != nil {
+				return nil, err
+			}
+			members[name] = new(big.Int).SetBytes(b)
+		}
+		if !members["e"].IsInt64() || members["e"].Int64() > 1<<31-1 {
+			return nil, newCryptoError("DataError", "Invalid key data")
+		}
+		pub = &rsa.PublicKey{N: members["n"], E: int(members["e"].Int64())}
+		handle = pub
+		if kind == "private" {
+			priv := &rsa.PrivateKey{PublicKey: *pub, D: members["d"], Primes: []*big.Int{members["p"], members["q"]}}
+			if err := priv.Validate(); err != nil {
+				return nil, newCryptoError("DataError", "Invalid key data")
+			}
+			priv.Precompute()
+			handle, pub = priv, &priv.PublicKey
+		}
+	default:
+		return nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+	}
+	algorithm.modulusLength = pub.N.BitLen()
+	algorithm.publicExponent = big.NewInt(int64(pub.E)).Bytes()
+	return &cryptoKey{algorithm: algorithm, handle: handle}, nil
+}
+
+// exportKey implements exportKey, returning the key's bytes, or its JWK
+// when format is "jwk"
+func exportKey(format string, key *cryptoKey) ([]byte, *jsonWebKey, error) {
+	if !key.extractable {
+		return nil, nil, newCryptoError("InvalidAccessError", "The key is not extractable")
+	}
+	switch format {
+	case "jwk":
+		jwk, err := key.jwk()
+		return nil, jwk, err
+	case "raw":
+		if key.kind == "private" {
+			return nil, nil, newCryptoError("InvalidAccessError", "Private keys cannot be exported as raw")
+		}
+		var data []byte
+		var err error
+		switch handle := key.handle.(type) {
+		case []byte:
+			data = append([]byte{}, handle...)
+		case *ecdsa.PublicKey:
+			data, err = handle.Bytes()
+		case ed25519.PublicKey:
+			data = append([]byte{}, handle...)
+		case *ecdh.PublicKey:
+			data = handle.Bytes()
+		default:
+			return nil, nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+		}
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		return data, nil, nil
+	case "spki", "pkcs8":
+		if key.kind == "secret" {
+			return nil, nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+		}
+		if (format == "spki") != (key.kind == "public") {
+			return nil, nil, newCryptoError("InvalidAccessError", "The key is not a %s key", map[bool]string{true: "public", false: "private"}[format == "spki"])
+		}
+		var data []byte
+		var err error
+		if format == "spki" {
+			data, err = x509.MarshalPKIXPublicKey(key.handle)
+		} else {
+			data, err = x509.MarshalPKCS8PrivateKey(key.handle)
+		}
+		if err != nil {
+			return nil, nil, newCryptoError("OperationError", "%v", err)
+		}
+		return data, nil, nil
+	}
+	return nil, nil, newCryptoError("NotSupportedError", "Unsupported key format %q", format)
+}
+
+// jsonWebKey is the JsonWebKey dictionary. Its key members hold base64url,
+// as in the JWK.
+type jsonWebKey struct {
+	kty, use, alg, crv                 string
+	k, x, y, d, n, e, p, q, dp, dq, qi string
+	keyOps                             []string
+	hasKeyOps                          bool
+	ext                                *bool
+}
+
+// jwkStringMembers are the string members of a JWK, in the order exported
+// JWKs have them
+var jwkStringMembers = []string{"kty", "crv", "alg", "use", "k", "x", "y", "n", "e", "d", "p", "q", "dp", "dq", "qi"}
+
+func (jwk *jsonWebKey) member(name string) *string {
+	switch name {
+	case "kty":
+		return &jwk.kty
+	case "use":
+		return &jwk.use
+	case "alg":
+		return &jwk.alg
+	case "crv":
+		return &jwk.crv
+	case "k":
+		return &jwk.k
+	case "x":
+		return &jwk.x
+	case "y":
+		return &jwk.y
+	case "d":
+		return &jwk.d
+	case "n":
+		return &jwk.n
+	case "e":
+		return &jwk.e
+	case "p":
+		return &jwk.p
+	case "q":
+		return &jwk.q
+	case "dp":
+		return &jwk.dp
+	case "dq":
+		return &jwk.dq
+	case "qi":
+		return &jwk.qi
+	}
+	return nil
+}
+
+// readJSONWebKey converts v to a JsonWebKey
+func readJSONWebKey(vmInstance *vm.VM, v vm.Value) (*jsonWebKey, error) {
+	if !v.IsObject() {
+		return nil, newCryptoError("TypeError", "Key data must be an object for JWK import")
+	}
+	jwk := &jsonWebKey{}
+	for _, name := range jwkStringMembers {
+		value, err := vmInstance.GetProperty(v, name)
+		if err != nil {
+			return nil, err
+		}
+		if value.Type() != vm.TypeUndefined {
+			*jwk.member(name) = value.ToString()
+		}
+	}
+	if jwk.kty == "" {
+		return nil, newCryptoError("TypeError", "JsonWebKey: kty: Missing required member")
+	}
+
+	keyOps, err := vmInstance.GetProperty(v, "key_ops")
+	if err != nil {
+		return nil, err
+	}
+	if keyOps.Type() != vm.TypeUndefined {
+		if jwk.keyOps, err = stringSequence(vmInstance, keyOps, "key_ops"); err != nil {
+			return nil, err
+		}
+		jwk.hasKeyOps = true
+	}
+	ext, err := vmInstance.GetProperty(v, "ext")
+	if err != nil {
+		return nil, err
+	}
+	if ext.Type() != vm.TypeUndefined {
+		b := ext.IsTruthy()
+		jwk.ext = &b
+	}
+	return jwk, nil
+}
+
+// stringSequence converts v to a sequence<DOMString>
+func stringSequence(vmInstance *vm.VM, v vm.Value, what string) ([]string, error) {
+	method := vm.Undefined
+	if v.IsObject() {
+		method, _ = vmInstance.GetSymbolProperty(v, SymbolIterator)
+	}
+	if !method.IsCallable() {
+		return nil, newCryptoError("TypeError", "%s: The provided value cannot be converted to a sequence", what)
+	}
+	values, err := iterableToList(vmInstance, v, method)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]string, len(values))
+	for i, value := range values {
+		list[i] = value.ToString()
+	}
+	return list, nil
+}
+
+func (jwk *jsonWebKey) object(vmInstance *vm.VM) vm.Value {
+	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	for _, name := range jwkStringMembers {
+		if value := *jwk.member(name); value != "" {
+			obj.SetOwn(name, vm.NewString(value))
+		}
+	}
+	if jwk.hasKeyOps {
+		obj.SetOwn("key_ops", stringsToArray(jwk.keyOps))
+	}
+	if jwk.ext != nil {
+		obj.SetOwn("ext", vm.BooleanValue(*jwk.ext))
+	}
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// jwkKeyTypes are the "kty" and "use" a JWK for each algorithm has
+var jwkKeyTypes = map[string][2]string{
+	"HMAC":    {"oct", "sig"},
+	"AES-GCM": {"oct", "enc"},
+	"AES-CBC": {"oct", "enc"},
+	"AES-CTR": {"oct", "enc"},
+	"ECDSA":   {"EC", "sig"},
+	"ECDH":    {"EC", "enc"},
+	"Ed25519": {"OKP", "sig"},
+	"X25519":  {"OKP", "enc"},
+	"RSA-PSS": {"RSA", "sig"},
+}
+
+// check does the checks importing any JWK does: of its "kty" and "use", of
+// its "key_ops" against usages, and of its "ext" against extractable
+func (jwk *jsonWebKey) check(algorithm string, extractable bool, usages []string) error {
+	types, ok := jwkKeyTypes[algorithm]
+	if !ok {
+		return newCryptoError("NotSupportedError", "Unsupported key format \"jwk\"")
+	}
+	if jwk.kty != types[0] {
+		return newCryptoError("DataError", "The JWK \"kty\" member was not %q", types[0])
+	}
+	if jwk.use != "" && len(usages) > 0 && jwk.use != types[1] {
+		return newCryptoError("DataError", "The JWK \"use\" member was not %q", types[1])
+	}
+	if jwk.hasKeyOps {
+		for _, usage := range usages {
+			if !containsString(jwk.keyOps, usage) {
+				return newCryptoError("DataError", "The JWK \"key_ops\" member does not include %q", usage)
+			}
+		}
+	}
+	if jwk.ext != nil && !*jwk.ext && extractable {
+		return newCryptoError("DataError", "The JWK \"ext\" member was false but the key is extractable")
+	}
+	return nil
+}
+
+// decode returns the bytes of the base64url key member name
+func (jwk *jsonWebKey) decode(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, newCryptoError("DataError", "The JWK %q member is missing", name)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, newCryptoError("DataError", "The JWK %q member is not base64url", name)
+	}
+	return b, nil
+}
+
+// jwkAlg returns the JWK "alg" of keys of algorithm, or "" if they have none
+func jwkAlg(algorithm keyAlgorithm) string {
+	hashBits := strings.TrimPrefix(algorithm.hash, "SHA-")
+	switch algorithm.name {
+	case "HMAC":
+		return "HS" + hashBits
+	case "RSA-PSS":
+		return "PS" + hashBits
+	case "AES-GCM", "AES-CBC", "AES-CTR":
+		return fmt.Sprintf("A%d%s", algorithm.length, strings.TrimPrefix(algorithm.name, "AES-"))
+	case "ECDSA":
+		return "ES" + map[string]string{"P-256": "256", "P-384": "384", "P-521": "512"}[algorithm.namedCurve]
+	case "Ed25519":
+		return "Ed25519"
+	}
+	return ""
+}
+
+// jwk returns the JWK of the key, which exportKey checked is extractable
+func (k *cryptoKey) jwk() (*jsonWebKey, error) {
+	ext := k.extractable
+	jwk := &jsonWebKey{
+		kty:       jwkKeyTypes[k.algorithm.name][0],
+		alg:       jwkAlg(k.algorithm),
+		keyOps:    k.usages,
+		hasKeyOps: true,
+		ext:       &ext,
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch handle := k.handle.(type) {
+	case []byte:
+		if k.algorithm.name == "PBKDF2" || k.algorithm.name == "HKDF" {
+			return nil, newCryptoError("NotSupportedError", "Unsupported key format \"jwk\"")
+		}
+		jwk.k = b64(handle)
+	case *ecdsa.PublicKey:
+		return jwk, jwk.setECPoint(k.algorithm.namedCurve, handle)
+	case *ecdsa.PrivateKey:
+		d, err := handle.Bytes()
+		if err != nil {
+			return nil, newCryptoError("OperationError", "%v", err)
+		}
+		jwk.d = b64(d)
+		return jwk, jwk.setECPoint(k.algorithm.namedCurve, &handle.PublicKey)
+	case ed25519.PublicKey:
+		jwk.crv, jwk.x = "Ed25519", b64(handle)
+	case ed25519.PrivateKey:
+		jwk.crv, jwk.x, jwk.d = "Ed25519", b64(handle.Public().(ed25519.PublicKey)), b64(handle.Seed())
+	case *ecdh.PublicKey:
+		jwk.crv, jwk.x = "X25519", b64(handle.Bytes())
+	case *ecdh.PrivateKey:
+		jwk.crv, jwk.x, jwk.d = "X25519", b64(handle.PublicKey().Bytes()), b64(handle.Bytes())
+	case *rsa.PublicKey:
+		jwk.n, jwk.e = b64(handle.N.Bytes()), b64(big.NewInt(int64(handle.E)).Bytes())
+	case *rsa.PrivateKey:
+		if len(handle.Primes) != 2 {
+			return nil, newCryptoError("OperationError", "Multi-prime RSA keys cannot be exported as JWK")
+		}
+		jwk.n, jwk.e = b64(handle.N.Bytes()), b64(big.NewInt(int64(handle.E)).Bytes())
+		jwk.d = b64(handle.D.Bytes())
+		jwk.p, jwk.q = b64(handle.Primes[0].Bytes()), b64(handle.Primes[1].Bytes())
+		jwk.dp, jwk.dq = b64(handle.Precomputed.Dp.Bytes()), b64(handle.Precomputed.Dq.Bytes())
+		jwk.qi = b64(handle.Precomputed.Qinv.Bytes())
+	}
+	return jwk, nil
+}
+
+// setECPoint sets the curve and coordinates of an EC JWK
+func (jwk *jsonWebKey) setECPoint(curve string, pub *ecdsa.PublicKey) error {
+	point, err := pub.Bytes()
+	if err != nil {
+		return newCryptoError("OperationError", "%v", err)
+	}
+	// point is the uncompressed 0x04 || x || y
+	size := (len(point) - 1) / 2
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk.crv, jwk.x, jwk.y = curve, b64(point[1:1+size]), b64(point[1+size:])
+	return nil
+}
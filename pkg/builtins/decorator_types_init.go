@@ -0,0 +1,76 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// DecoratorTypesInitializer registers the types decorators of accessor
+// fields are written against
+type DecoratorTypesInitializer struct{}
+
+func (d *DecoratorTypesInitializer) Name() string {
+	return "DecoratorTypes"
+}
+
+func (d *DecoratorTypesInitializer) Priority() int {
+	return PriorityObject - 1 // Type-only, like the utility types
+}
+
+func (d *DecoratorTypesInitializer) InitTypes(ctx *TypeContext) error {
+	// Both default to unknown, as in lib.decorators.d.ts
+	thisParam := &types.TypeParameter{Name: "This", Default: types.Unknown, Index: 0}
+	valueParam := &types.TypeParameter{Name: "Value", Default: types.Unknown, Index: 1}
+	thisType := &types.TypeParameterType{Parameter: thisParam}
+	valueType := &types.TypeParameterType{Parameter: valueParam}
+	typeParams := []*types.TypeParameter{thisParam, valueParam}
+
+	// ClassAccessorDecoratorTarget<This, Value> = { get(): Value; set(value: Value): void }
+	target := types.NewObjectType().
+		WithProperty("get", types.NewSimpleFunction([]types.Type{}, valueType)).
+		WithProperty("set", types.NewSimpleFunction([]types.Type{valueType}, types.Void))
+	if err := ctx.DefineTypeAlias("ClassAccessorDecoratorTarget", types.NewGenericType("ClassAccessorDecoratorTarget", typeParams, target)); err != nil {
+		return err
+	}
+
+	// ClassAccessorDecoratorResult<This, Value> = { get?(): Value; set?(value: Value): void; init?(value: Value): Value }
+	result := types.NewObjectType().
+		WithOptionalProperty("get", types.NewSimpleFunction([]types.Type{}, valueType)).
+		WithOptionalProperty("set", types.NewSimpleFunction([]types.Type{valueType}, types.Void)).
+		WithOptionalProperty("init", types.NewSimpleFunction([]types.Type{valueType}, valueType))
+	if err := ctx.DefineTypeAlias("ClassAccessorDecoratorResult", types.NewGenericType("ClassAccessorDecoratorResult", typeParams, result)); err != nil {
+		return err
+	}
+
+	// DecoratorMetadataObject: what decorators of a class share as
+	// context.metadata, and the class's Symbol.metadata
+	metadata := types.NewObjectType()
+	metadata.IndexSignatures = append(metadata.IndexSignatures,
+		&types.IndexSignature{KeyType: types.String, ValueType: types.Unknown},
+		&types.IndexSignature{KeyType: types.Symbol, ValueType: types.Unknown})
+	if err := ctx.DefineTypeAlias("DecoratorMetadataObject", metadata); err != nil {
+		return err
+	}
+
+	// ClassAccessorDecoratorContext<This, Value>: what the compiler passes
+	// accessor decorators (see createDecoratorContext and
+	// compileAutoAccessorAccess)
+	access := types.NewObjectType().
+		WithProperty("get", types.NewSimpleFunction([]types.Type{thisType}, valueType)).
+		WithProperty("set", types.NewSimpleFunction([]types.Type{thisType, valueType}, types.Void)).
+		WithProperty("has", types.NewSimpleFunction([]types.Type{thisType}, types.Boolean))
+	context := types.NewObjectType().
+		WithProperty("kind", &types.LiteralType{Value: vm.NewString("accessor")}).
+		WithProperty("name", types.NewUnionType(types.String, types.Symbol)).
+		WithProperty("static", types.Boolean).
+		WithProperty("private", types.Boolean).
+		WithProperty("access", access).
+		WithProperty("addInitializer", types.NewSimpleFunction([]types.Type{types.NewSimpleFunction([]types.Type{}, types.Void)}, types.Void)).
+		WithProperty("metadata", metadata)
+	return ctx.DefineTypeAlias("ClassAccessorDecoratorContext", types.NewGenericType("ClassAccessorDecoratorContext", typeParams, context))
+}
+
+func (d *DecoratorTypesInitializer) InitRuntime(ctx *RuntimeContext) error {
+	// Decorator types are compile-time only, no runtime representation needed
+	return nil
+}
@@ -0,0 +1,205 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for DisposableStack and AsyncDisposableStack
+const PriorityDisposableStack = 120 // After Symbol, Promise and the Error types
+
+// DisposableStackInitializer implements DisposableStack and
+// AsyncDisposableStack, which collect resources to dispose of together, the
+// way a block's using and await using declarations do
+type DisposableStackInitializer struct{}
+
+func (d *DisposableStackInitializer) Name() string {
+	return "DisposableStack"
+}
+
+func (d *DisposableStackInitializer) Priority() int {
+	return PriorityDisposableStack
+}
+
+func (d *DisposableStackInitializer) InitTypes(ctx *TypeContext) error {
+	for _, async := range []bool{false, true} {
+		name, disposeName := "DisposableStack", "dispose"
+		var disposeResult types.Type = types.Undefined
+		if async {
+			name, disposeName = "AsyncDisposableStack", "disposeAsync"
+			disposeResult = types.Any // Promise<void>
+		}
+		stackType := types.NewObjectType().
+			WithProperty("disposed", types.Boolean).
+			WithProperty(disposeName, types.NewSimpleFunction([]types.Type{}, disposeResult)).
+			WithProperty("use", types.NewSimpleFunction([]types.Type{types.Any}, types.Any)).
+			WithProperty("adopt", types.NewSimpleFunction([]types.Type{types.Any, types.NewSimpleFunction([]types.Type{types.Any}, types.Any)}, types.Any)).
+			WithProperty("defer", types.NewSimpleFunction([]types.Type{types.NewSimpleFunction([]types.Type{}, types.Any)}, types.Undefined))
+		stackType = stackType.WithProperty("move", types.NewSimpleFunction([]types.Type{}, stackType))
+
+		ctorType := types.NewObjectType().
+			WithSimpleConstructSignature([]types.Type{}, stackType).
+			WithProperty("prototype", stackType)
+		if err := ctx.DefineGlobal(name, ctorType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DisposableStackInitializer) InitRuntime(ctx *RuntimeContext) error {
+	for _, async := range []bool{false, true} {
+		if err := initDisposableStack(ctx, async); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initDisposableStack defines DisposableStack, or AsyncDisposableStack when async
+func initDisposableStack(ctx *RuntimeContext, async bool) error {
+	vmInstance := ctx.VM
+	name := "DisposableStack"
+	if async {
+		name = "AsyncDisposableStack"
+	}
+
+	proto := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	if vmInstance.SymbolToStringTag.Type() == vm.TypeSymbol {
+		w, e, c := false, false, true
+		proto.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolToStringTag), vm.NewString(name), &w, &e, &c)
+	}
+
+	ctor := vm.NewConstructorWithProps(0, false, name, func(args []vm.Value) (vm.Value, error) {
+		return createDisposableStackObject(vmInstance, &disposableStack{name: name, async: async}, proto), nil
+	})
+	if ctorProps := ctor.AsNativeFunctionWithProps(); ctorProps != nil {
+		ctorProps.Properties.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(proto))
+	}
+	proto.SetOwnNonEnumerable("constructor", ctor)
+
+	return ctx.DefineGlobal(name, ctor)
+}
+
+// disposableStack is the state of a DisposableStack or AsyncDisposableStack
+type disposableStack struct {
+	name      string
+	async     bool
+	disposed  bool
+	resources []vm.DisposableResource
+}
+
+// checkNotDisposed returns the ReferenceError for using the stack once disposed
+func (s *disposableStack) checkNotDisposed(vmInstance *vm.VM, method string) error {
+	if s.disposed {
+		return vmInstance.NewReferenceError(s.name + "." + method + ": the stack is already disposed")
+	}
+	return nil
+}
+
+func createDisposableStackObject(vmInstance *vm.VM, stack *disposableStack, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+
+	disposedGetter := vm.NewNativeFunction(0, false, "get disposed", func(args []vm.Value) (vm.Value, error) {
+		return vm.BooleanValue(stack.disposed), nil
+	})
+	e, c := false, true
+	obj.DefineAccessorProperty("disposed", disposedGetter, true, vm.Undefined, false, &e, &c)
+
+	// use(value) adds a disposable value, and returns it
+	obj.SetOwnNonEnumerable("use", vm.NewNativeFunction(1, false, "use", func(args []vm.Value) (vm.Value, error) {
+		if err := stack.checkNotDisposed(vmInstance, "use"); err != nil {
+			return vm.Undefined, err
+		}
+		value := vm.Undefined
+		if len(args) > 0 {
+			value = args[0]
+		}
+		resource, ok, err := vmInstance.NewDisposableResource(value, stack.async)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if ok {
+			stack.resources = append(stack.resources, resource)
+		}
+		return value, nil
+	}))
+
+	// adopt(value, onDispose) adds value, disposed of by calling onDispose(value)
+	obj.SetOwnNonEnumerable("adopt", vm.NewNativeFunction(2, false, "adopt", func(args []vm.Value) (vm.Value, error) {
+		if err := stack.checkNotDisposed(vmInstance, "adopt"); err != nil {
+			return vm.Undefined, err
+		}
+		value, onDispose := vm.Undefined, vm.Undefined
+		if len(args) > 0 {
+			value = args[0]
+		}
+		if len(args) > 1 {
+			onDispose = args[1]
+		}
+		if !onDispose.IsCallable() {
+			return vm.Undefined, vmInstance.NewTypeError(stack.name + ".adopt: onDispose must be a function")
+		}
+		method := vm.NewNativeFunction(0, false, "", func(args []vm.Value) (vm.Value, error) {
+			return vmInstance.Call(onDispose, vm.Undefined, []vm.Value{value})
+		})
+		stack.resources = append(stack.resources, vm.DisposableResource{Value: vm.Undefined, Method: method, Async: stack.async})
+		return value, nil
+	}))
+
+	// defer(onDispose) adds a callback to call when the stack is disposed of
+	obj.SetOwnNonEnumerable("defer", vm.NewNativeFunction(1, false, "defer", func(args []vm.Value) (vm.Value, error) {
+		if err := stack.checkNotDisposed(vmInstance, "defer"); err != nil {
+			return vm.Undefined, err
+		}
+		if len(args) == 0 || !args[0].IsCallable() {
+			return vm.Undefined, vmInstance.NewTypeError(stack.name + ".defer: onDispose must be a function")
+		}
+		stack.resources = append(stack.resources, vm.DisposableResource{Value: vm.Undefined, Method: args[0], Async: stack.async})
+		return vm.Undefined, nil
+	}))
+
+	// move() hands the resources over to a new stack, disposing of this one
+	// without disposing of them
+	obj.SetOwnNonEnumerable("move", vm.NewNativeFunction(0, false, "move", func(args []vm.Value) (vm.Value, error) {
+		if err := stack.checkNotDisposed(vmInstance, "move"); err != nil {
+			return vm.Undefined, err
+		}
+		moved := &disposableStack{name: stack.name, async: stack.async, resources: stack.resources}
+		stack.resources, stack.disposed = nil, true
+		return createDisposableStackObject(vmInstance, moved, proto), nil
+	}))
+
+	// dispose() / disposeAsync() dispose of the resources in reverse order;
+	// the stack's Symbol.dispose / Symbol.asyncDispose is the same function
+	if stack.async {
+		disposeAsync := vm.NewNativeFunction(0, false, "disposeAsync", func(args []vm.Value) (vm.Value, error) {
+			if stack.disposed {
+				return vmInstance.NewResolvedPromise(vm.Undefined), nil
+			}
+			resources := stack.resources
+			stack.resources, stack.disposed = nil, true
+			return vmInstance.DisposeResourcesAsync(resources, vm.Undefined, false), nil
+		})
+		obj.SetOwnNonEnumerable("disposeAsync", disposeAsync)
+		w, e, c := true, false, true
+		obj.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolAsyncDispose), disposeAsync, &w, &e, &c)
+	} else {
+		dispose := vm.NewNativeFunction(0, false, "dispose", func(args []vm.Value) (vm.Value, error) {
+			if stack.disposed {
+				return vm.Undefined, nil
+			}
+			resources := stack.resources
+			stack.resources, stack.disposed = nil, true
+			if err, hasErr := vmInstance.DisposeResources(resources, vm.Undefined, false); hasErr {
+				return vm.Undefined, vmInstance.NewExceptionError(err)
+			}
+			return vm.Undefined, nil
+		})
+		obj.SetOwnNonEnumerable("dispose", dispose)
+		w, e, c := true, false, true
+		obj.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolDispose), dispose, &w, &e, &c)
+	}
+
+	return vm.NewValueFromPlainObject(obj)
+}
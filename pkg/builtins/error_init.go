@@ -348,6 +348,75 @@ func (e *AggregateErrorInitializer) InitRuntime(ctx *RuntimeContext) error {
 	return ctx.DefineGlobal("AggregateError", ctor)
 }
 
+// SuppressedError - thrown when disposing of a resource fails while its
+// scope is left with an error already (see vm.DisposeResources)
+type SuppressedErrorInitializer struct{}
+
+func (e *SuppressedErrorInitializer) Name() string  { return "SuppressedError" }
+func (e *SuppressedErrorInitializer) Priority() int { return 22 }
+func (e *SuppressedErrorInitializer) InitTypes(ctx *TypeContext) error {
+	// SuppressedError(error, suppressed, message?)
+	t := types.NewObjectType().
+		WithSimpleCallSignature([]types.Type{types.Any, types.Any}, types.Any).
+		WithSimpleCallSignature([]types.Type{types.Any, types.Any, types.String}, types.Any).
+		WithSimpleConstructSignature([]types.Type{types.Any, types.Any}, types.Any).
+		WithSimpleConstructSignature([]types.Type{types.Any, types.Any, types.String}, types.Any)
+	return ctx.DefineGlobal("SuppressedError", t)
+}
+func (e *SuppressedErrorInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	// Create SuppressedError.prototype inheriting from Error.prototype
+	proto := vm.NewObject(vmInstance.ErrorPrototype).AsPlainObject()
+	proto.SetOwnNonEnumerable("name", vm.NewString("SuppressedError"))
+	proto.SetOwnNonEnumerable("message", vm.NewString(""))
+
+	// SuppressedError constructor: SuppressedError(error, suppressed, message?)
+	ctor := vm.NewNativeFunction(3, false, "SuppressedError", func(args []vm.Value) (vm.Value, error) {
+		errorVal, suppressed := vm.Undefined, vm.Undefined
+		if len(args) > 0 {
+			errorVal = args[0]
+		}
+		if len(args) > 1 {
+			suppressed = args[1]
+		}
+		var message string
+		if len(args) > 2 && args[2].Type() != vm.TypeUndefined {
+			message = args[2].ToString()
+		}
+
+		inst := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+		inst.SetOwnNonEnumerable("[[ErrorData]]", vm.Undefined)
+		if message != "" {
+			inst.SetOwnNonEnumerable("message", vm.NewString(message))
+		}
+		inst.SetOwnNonEnumerable("stack", vm.NewString(vmInstance.CaptureStackTrace()))
+
+		// Per the explicit resource management spec, error and suppressed are
+		// own non-enumerable data properties
+		inst.SetOwnNonEnumerable("error", errorVal)
+		inst.SetOwnNonEnumerable("suppressed", suppressed)
+
+		return vm.NewValueFromPlainObject(inst), nil
+	})
+
+	if nf := ctor.AsNativeFunction(); nf != nil {
+		withProps := vm.NewConstructorWithProps(nf.Arity, nf.Variadic, nf.Name, nf.Fn)
+		ctorProps := withProps.AsNativeFunctionWithProps()
+		ctorProps.Properties.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(proto))
+
+		if !vmInstance.ErrorConstructor.IsUndefined() {
+			ctorProps.Properties.SetPrototype(vmInstance.ErrorConstructor)
+		}
+
+		proto.SetOwnNonEnumerable("constructor", withProps)
+		return ctx.DefineGlobal("SuppressedError", withProps)
+	}
+
+	proto.SetOwnNonEnumerable("constructor", ctor)
+	return ctx.DefineGlobal("SuppressedError", ctor)
+}
+
 // helper to initialize simple Error subclasses inheriting Error.prototype
 func initErrorSubclass(ctx *RuntimeContext, name string) error {
 	vmInstance := ctx.VM
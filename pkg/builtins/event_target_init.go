@@ -0,0 +1,746 @@
+package builtins
+
+import (
+	"time"
+
+	"github.com/nooga/paserati/pkg/runtime"
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for EventTarget, Event and CustomEvent
+const PriorityEventTarget = 192 // Before AbortController, whose signals are EventTargets
+
+// Event phases, the values of Event.prototype.eventPhase
+const (
+	eventPhaseNone      = 0
+	eventPhaseCapturing = 1
+	eventPhaseAtTarget  = 2
+	eventPhaseBubbling  = 3
+)
+
+// EventTargetInitializer implements EventTarget, Event and CustomEvent.
+// Targets aren't part of a tree, so an event's path is only its target:
+// its capturing listeners run before the rest, all at the AT_TARGET phase.
+type EventTargetInitializer struct{}
+
+func (e *EventTargetInitializer) Name() string {
+	return "EventTarget"
+}
+
+func (e *EventTargetInitializer) Priority() int {
+	return PriorityEventTarget
+}
+
+func (e *EventTargetInitializer) InitTypes(ctx *TypeContext) error {
+	noArgs := func(returnType types.Type) types.Type {
+		return types.NewSimpleFunction([]types.Type{}, returnType)
+	}
+
+	eventType := types.NewObjectType().
+		WithProperty("type", types.String).
+		WithProperty("target", types.Any).
+		WithProperty("currentTarget", types.Any).
+		WithProperty("srcElement", types.Any).
+		WithProperty("eventPhase", types.Number).
+		WithProperty("bubbles", types.Boolean).
+		WithProperty("cancelable", types.Boolean).
+		WithProperty("composed", types.Boolean).
+		WithProperty("defaultPrevented", types.Boolean).
+		WithProperty("isTrusted", types.Boolean).
+		WithProperty("timeStamp", types.Number).
+		WithProperty("returnValue", types.Boolean).
+		WithProperty("cancelBubble", types.Boolean).
+		WithProperty("composedPath", noArgs(&types.ArrayType{ElementType: types.Any})).
+		WithProperty("stopPropagation", noArgs(types.Undefined)).
+		WithProperty("stopImmediatePropagation", noArgs(types.Undefined)).
+		WithProperty("preventDefault", noArgs(types.Undefined)).
+		WithProperty("initEvent", types.NewOptionalFunction([]types.Type{types.String, types.Boolean, types.Boolean}, types.Undefined, []bool{false, true, true}))
+
+	customEventType := types.NewObjectType().
+		WithProperty("detail", types.Any).
+		WithProperty("initCustomEvent", types.NewOptionalFunction([]types.Type{types.String, types.Boolean, types.Boolean, types.Any}, types.Undefined, []bool{false, true, true, true})).
+		Inherits(eventType)
+
+	// CustomEvent<T> types detail, which is any without a type argument
+	detailParam := &types.TypeParameter{Name: "T", Default: types.Any, Index: 0}
+	customEventGeneric := &types.GenericType{
+		Name:           "CustomEvent",
+		TypeParameters: []*types.TypeParameter{detailParam},
+		Body: types.NewObjectType().
+			WithProperty("detail", &types.TypeParameterType{Parameter: detailParam}).
+			Inherits(customEventType),
+	}
+
+	// Listeners are functions or objects with a handleEvent method, and
+	// options are a capture flag or {capture, once, passive, signal}
+	listenerMethod := types.NewOptionalFunction([]types.Type{types.String, types.Any, types.Any}, types.Undefined, []bool{false, false, true})
+	eventTargetType := types.NewObjectType().
+		WithProperty("addEventListener", listenerMethod).
+		WithProperty("removeEventListener", listenerMethod).
+		WithProperty("dispatchEvent", types.NewSimpleFunction([]types.Type{eventType}, types.Boolean))
+
+	withPhases := func(ctor *types.ObjectType) *types.ObjectType {
+		for _, phase := range []string{"NONE", "CAPTURING_PHASE", "AT_TARGET", "BUBBLING_PHASE"} {
+			ctor = ctor.WithProperty(phase, types.Number)
+		}
+		return ctor
+	}
+	eventCtor := withPhases(types.NewObjectType().
+		WithConstructSignature(types.SigOptional([]types.Type{types.String, types.Any}, eventType, []bool{false, true})).
+		WithProperty("prototype", eventType))
+	customEventCtor := withPhases(types.NewObjectType().
+		WithConstructSignature(types.SigOptional([]types.Type{types.String, types.Any}, customEventType, []bool{false, true})).
+		WithProperty("prototype", customEventType))
+	eventTargetCtor := types.NewObjectType().
+		WithSimpleConstructSignature([]types.Type{}, eventTargetType).
+		WithProperty("prototype", eventTargetType)
+
+	globals := []struct {
+		name     string
+		typ      types.Type
+		instance types.Type
+	}{
+		{"Event", eventCtor, eventType},
+		{"CustomEvent", customEventCtor, customEventGeneric},
+		{"EventTarget", eventTargetCtor, eventTargetType},
+	}
+	for _, global := range globals {
+		if err := ctx.DefineGlobal(global.name, global.typ); err != nil {
+			return err
+		}
+		if err := ctx.DefineTypeAlias(global.name, global.instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EventTargetInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	eventProto := newTaggedPrototype(vmInstance, vmInstance.ObjectPrototype, "Event")
+	initEventPrototype(vmInstance, eventProto)
+	eventCtor, err := defineClass(ctx, "Event", 1, eventProto, func(args []vm.Value) (vm.Value, error) {
+		ev, err := constructEvent(vmInstance, "Event", args)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return ev.object(instancePrototype(vmInstance, eventProto)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	customEventProto := newTaggedPrototype(vmInstance, vm.NewValueFromPlainObject(eventProto), "CustomEvent")
+	defineThisGetter(vmInstance, customEventProto, "detail", func(ev *event) vm.Value { return ev.detail })
+	customEventProto.SetOwnNonEnumerable("initCustomEvent", vm.NewNativeFunction(4, false, "initCustomEvent", func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, "initCustomEvent")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if !ev.dispatching {
+			ev.init(argOrUndefined(args, 0).ToString(), argOrUndefined(args, 1).IsTruthy(), argOrUndefined(args, 2).IsTruthy())
+			ev.detail = argOrUndefined(args, 3)
+		}
+		return vm.Undefined, nil
+	}))
+	customEventCtor, err := defineClass(ctx, "CustomEvent", 1, customEventProto, func(args []vm.Value) (vm.Value, error) {
+		ev, err := constructEvent(vmInstance, "CustomEvent", args)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if init := argOrUndefined(args, 1); init.IsObject() {
+			if ev.detail, err = vmInstance.GetProperty(init, "detail"); err != nil {
+				return vm.Undefined, err
+			}
+		}
+		return ev.object(instancePrototype(vmInstance, customEventProto)), nil
+	})
+	if err != nil {
+		return err
+	}
+	setPrototypeOf(customEventCtor, eventCtor)
+
+	for _, obj := range []*vm.PlainObject{eventProto, eventCtor.AsNativeFunctionWithProps().Properties, customEventCtor.AsNativeFunctionWithProps().Properties} {
+		for i, phase := range []string{"NONE", "CAPTURING_PHASE", "AT_TARGET", "BUBBLING_PHASE"} {
+			w, e, c := false, true, false
+			obj.DefineOwnProperty(phase, vm.NumberValue(float64(i)), &w, &e, &c)
+		}
+	}
+
+	targetProto := newTaggedPrototype(vmInstance, vmInstance.ObjectPrototype, "EventTarget")
+	initEventTargetPrototype(vmInstance, targetProto)
+	_, err = defineClass(ctx, "EventTarget", 0, targetProto, func(args []vm.Value) (vm.Value, error) {
+		if vmInstance.GetNewTarget().Type() == vm.TypeUndefined {
+			return vm.Undefined, vmInstance.NewTypeError("Class constructor EventTarget cannot be invoked without 'new'")
+		}
+		target := newEventTarget(vmInstance)
+		obj := vm.NewObject(instancePrototype(vmInstance, targetProto)).AsPlainObject()
+		obj.SetHostData(target)
+		return vm.NewValueFromPlainObject(obj), nil
+	})
+	return err
+}
+
+// newTaggedPrototype creates a class prototype inheriting from parent, with
+// name as its Symbol.toStringTag
+func newTaggedPrototype(vmInstance *vm.VM, parent vm.Value, name string) *vm.PlainObject {
+	proto := vm.NewObject(parent).AsPlainObject()
+	if vmInstance.SymbolToStringTag.Type() == vm.TypeSymbol {
+		w, e, c := false, false, true
+		proto.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolToStringTag), vm.NewString(name), &w, &e, &c)
+	}
+	return proto
+}
+
+// instancePrototype returns the prototype of the instance the running
+// constructor creates: new.target's, so that subclasses get their own, or
+// proto when new.target has none
+func instancePrototype(vmInstance *vm.VM, proto *vm.PlainObject) vm.Value {
+	if nt := vmInstance.GetNewTarget(); nt.Type() != vm.TypeUndefined {
+		if p, err := vmInstance.GetProperty(nt, "prototype"); err == nil && p.IsObject() {
+			return p
+		}
+	}
+	return vm.NewValueFromPlainObject(proto)
+}
+
+// setPrototypeOf makes the constructor ctor inherit the static side of
+// parent, as class ctor extends parent would
+func setPrototypeOf(ctor, parent vm.Value) {
+	if ctorProps := ctor.AsNativeFunctionWithProps(); ctorProps != nil {
+		ctorProps.Properties.SetPrototype(parent)
+	}
+}
+
+// event is the state of an Event or CustomEvent
+type event struct {
+	typ        string
+	bubbles    bool
+	cancelable bool
+	composed   bool
+	isTrusted  bool
+	timeStamp  float64
+	detail     vm.Value
+
+	target        vm.Value
+	currentTarget vm.Value
+	phase         int
+	initialized   bool
+	dispatching   bool
+	canceled      bool
+	inPassive     bool
+	stopped       bool // stopPropagation
+	stoppedNow    bool // stopImmediatePropagation
+
+	obj vm.Value
+}
+
+// newEvent creates an event of type typ, like the ones builtins fire
+func newEvent(typ string) *event {
+	ev := &event{detail: vm.Null, target: vm.Null, currentTarget: vm.Null, obj: vm.Undefined}
+	ev.init(typ, false, false)
+	ev.timeStamp = float64(time.Since(performanceOrigin).Nanoseconds()) / 1e6
+	return ev
+}
+
+// constructEvent reads the arguments of the Event or CustomEvent constructor
+func constructEvent(vmInstance *vm.VM, name string, args []vm.Value) (*event, error) {
+	if vmInstance.GetNewTarget().Type() == vm.TypeUndefined {
+		return nil, vmInstance.NewTypeError("Class constructor " + name + " cannot be invoked without 'new'")
+	}
+	if len(args) == 0 {
+		return nil, vmInstance.NewTypeError("Failed to construct '" + name + "': 1 argument required, but only 0 present.")
+	}
+	ev := newEvent(args[0].ToString())
+	if init := argOrUndefined(args, 1); init.IsObject() {
+		for option, flag := range map[string]*bool{"bubbles": &ev.bubbles, "cancelable": &ev.cancelable, "composed": &ev.composed} {
+			v, err := vmInstance.GetProperty(init, option)
+			if err != nil {
+				return nil, err
+			}
+			*flag = v.IsTruthy()
+		}
+	}
+	return ev, nil
+}
+
+// init implements initializing an event, resetting its flags
+func (ev *event) init(typ string, bubbles, cancelable bool) {
+	ev.initialized = true
+	ev.stopped, ev.stoppedNow, ev.canceled = false, false, false
+	ev.isTrusted = false
+	ev.target = vm.Null
+	ev.typ, ev.bubbles, ev.cancelable = typ, bubbles, cancelable
+}
+
+// preventDefault cancels the event, unless a passive listener is running
+func (ev *event) preventDefault() {
+	if ev.cancelable && !ev.inPassive {
+		ev.canceled = true
+	}
+}
+
+// object returns the event's JS object, creating it with proto the first time
+func (ev *event) object(proto vm.Value) vm.Value {
+	if ev.obj.Type() == vm.TypeUndefined {
+		obj := vm.NewObject(proto).AsPlainObject()
+		obj.SetHostData(ev)
+		ev.obj = vm.NewValueFromPlainObject(obj)
+	}
+	return ev.obj
+}
+
+// eventOf returns the event of an Event object, or nil
+func eventOf(v vm.Value) *event {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	ev, _ := v.AsPlainObject().HostData().(*event)
+	return ev
+}
+
+// thisEvent returns the event the Event method being run was called on
+func thisEvent(vmInstance *vm.VM, method string) (*event, error) {
+	if ev := eventOf(vmInstance.GetThis()); ev != nil {
+		return ev, nil
+	}
+	return nil, vmInstance.NewTypeError("Event.prototype." + method + " called on an object that is not an Event")
+}
+
+// defineThisGetter defines the accessor name on an event prototype
+func defineThisGetter(vmInstance *vm.VM, proto *vm.PlainObject, name string, get func(ev *event) vm.Value) {
+	getter := vm.NewNativeFunction(0, false, "get "+name, func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, name)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return get(ev), nil
+	})
+	e, c := true, true
+	proto.DefineAccessorProperty(name, getter, true, vm.Undefined, false, &e, &c)
+}
+
+// defineThisAccessor defines the read-write accessor name on an event prototype
+func defineThisAccessor(vmInstance *vm.VM, proto *vm.PlainObject, name string, get func(ev *event) vm.Value, set func(ev *event, v vm.Value)) {
+	getter := vm.NewNativeFunction(0, false, "get "+name, func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, name)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return get(ev), nil
+	})
+	setter := vm.NewNativeFunction(1, false, "set "+name, func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, name)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		set(ev, argOrUndefined(args, 0))
+		return vm.Undefined, nil
+	})
+	e, c := true, true
+	proto.DefineAccessorProperty(name, getter, true, setter, true, &e, &c)
+}
+
+func initEventPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	defineThisGetter(vmInstance, proto, "type", func(ev *event) vm.Value { return vm.NewString(ev.typ) })
+	defineThisGetter(vmInstance, proto, "target", func(ev *event) vm.Value { return ev.target })
+	defineThisGetter(vmInstance, proto, "srcElement", func(ev *event) vm.Value { return ev.target })
+	defineThisGetter(vmInstance, proto, "currentTarget", func(ev *event) vm.Value { return ev.currentTarget })
+	defineThisGetter(vmInstance, proto, "eventPhase", func(ev *event) vm.Value { return vm.NumberValue(float64(ev.phase)) })
+	defineThisGetter(vmInstance, proto, "bubbles", func(ev *event) vm.Value { return vm.BooleanValue(ev.bubbles) })
+	defineThisGetter(vmInstance, proto, "cancelable", func(ev *event) vm.Value { return vm.BooleanValue(ev.cancelable) })
+	defineThisGetter(vmInstance, proto, "composed", func(ev *event) vm.Value { return vm.BooleanValue(ev.composed) })
+	defineThisGetter(vmInstance, proto, "defaultPrevented", func(ev *event) vm.Value { return vm.BooleanValue(ev.canceled) })
+	defineThisGetter(vmInstance, proto, "isTrusted", func(ev *event) vm.Value { return vm.BooleanValue(ev.isTrusted) })
+	defineThisGetter(vmInstance, proto, "timeStamp", func(ev *event) vm.Value { return vm.NumberValue(ev.timeStamp) })
+
+	// returnValue and cancelBubble are the legacy spellings of
+	// !defaultPrevented and of stopPropagation()
+	defineThisAccessor(vmInstance, proto, "returnValue", func(ev *event) vm.Value {
+		return vm.BooleanValue(!ev.canceled)
+	}, func(ev *event, v vm.Value) {
+		if !v.IsTruthy() {
+			ev.preventDefault()
+		}
+	})
+	defineThisAccessor(vmInstance, proto, "cancelBubble", func(ev *event) vm.Value {
+		return vm.BooleanValue(ev.stopped)
+	}, func(ev *event, v vm.Value) {
+		if v.IsTruthy() {
+			ev.stopped = true
+		}
+	})
+
+	methods := []struct {
+		name string
+		fn   func(ev *event)
+	}{
+		{"stopPropagation", func(ev *event) { ev.stopped = true }},
+		{"stopImmediatePropagation", func(ev *event) { ev.stopped, ev.stoppedNow = true, true }},
+		{"preventDefault", (*event).preventDefault},
+	}
+	for _, method := range methods {
+		proto.SetOwnNonEnumerable(method.name, vm.NewNativeFunction(0, false, method.name, func(args []vm.Value) (vm.Value, error) {
+			ev, err := thisEvent(vmInstance, method.name)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			method.fn(ev)
+			return vm.Undefined, nil
+		}))
+	}
+
+	// composedPath() -> the target while dispatching, and [] otherwise
+	proto.SetOwnNonEnumerable("composedPath", vm.NewNativeFunction(0, false, "composedPath", func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, "composedPath")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if ev.currentTarget.Type() == vm.TypeNull {
+			return vm.NewArray(), nil
+		}
+		return vm.NewArrayWithArgs([]vm.Value{ev.currentTarget}), nil
+	}))
+
+	// initEvent(type, bubbles?, cancelable?) reinitializes an event that
+	// isn't being dispatched
+	proto.SetOwnNonEnumerable("initEvent", vm.NewNativeFunction(3, false, "initEvent", func(args []vm.Value) (vm.Value, error) {
+		ev, err := thisEvent(vmInstance, "initEvent")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if !ev.dispatching {
+			ev.init(argOrUndefined(args, 0).ToString(), argOrUndefined(args, 1).IsTruthy(), argOrUndefined(args, 2).IsTruthy())
+		}
+		return vm.Undefined, nil
+	}))
+}
+
+// eventListener is an entry of a target's event listener list
+type eventListener struct {
+	callback vm.Value
+	capture  bool
+	once     bool
+	passive  bool
+	removed  bool
+	// unsubscribe stops listening to the AbortSignal given as the signal
+	// option, which removes the listener
+	unsubscribe func()
+}
+
+// eventTarget is the state of an EventTarget
+type eventTarget struct {
+	vm        *vm.VM
+	listeners map[string][]*eventListener
+	handlers  map[string]*eventHandler
+}
+
+// eventHandler is the state of an event handler attribute like onabort,
+// which calls the current handler from a listener added when it's first set
+type eventHandler struct {
+	value    vm.Value
+	listener *eventListener
+}
+
+func newEventTarget(vmInstance *vm.VM) *eventTarget {
+	return &eventTarget{vm: vmInstance, listeners: map[string][]*eventListener{}, handlers: map[string]*eventHandler{}}
+}
+
+// eventTargetOf returns the state of an EventTarget object, or nil
+func eventTargetOf(v vm.Value) *eventTarget {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	switch data := v.AsPlainObject().HostData().(type) {
+	case *eventTarget:
+		return data
+	case *AbortSignal:
+		return data.target
+	}
+	return nil
+}
+
+// thisEventTarget returns the target the EventTarget method being run was called on
+func thisEventTarget(vmInstance *vm.VM, method string) (vm.Value, *eventTarget, error) {
+	this := vmInstance.GetThis()
+	if t := eventTargetOf(this); t != nil {
+		return this, t, nil
+	}
+	return vm.Undefined, nil, vmInstance.NewTypeError("EventTarget.prototype." + method + " called on an object that is not an EventTarget")
+}
+
+// find returns the listener for callback in the capture or bubbling list of typ
+func (t *eventTarget) find(typ string, callback vm.Value, capture bool) *eventListener {
+	for _, l := range t.listeners[typ] {
+		if l.capture == capture && l.callback.StrictlyEquals(callback) {
+			return l
+		}
+	}
+	return nil
+}
+
+// add implements adding an event listener, unless an equal one was added
+func (t *eventTarget) add(typ string, l *eventListener, signal *AbortSignal) {
+	if signal != nil && signal.aborted {
+		return
+	}
+	if t.find(typ, l.callback, l.capture) != nil {
+		return
+	}
+	t.listeners[typ] = append(t.listeners[typ], l)
+	if signal != nil {
+		l.unsubscribe = signal.onAbort(func() { t.remove(typ, l) })
+	}
+}
+
+// remove implements removing an event listener. A dispatch that's already
+// running skips it too.
+func (t *eventTarget) remove(typ string, l *eventListener) {
+	l.removed = true
+	if l.unsubscribe != nil {
+		l.unsubscribe()
+		l.unsubscribe = nil
+	}
+	listeners := t.listeners[typ]
+	for i, other := range listeners {
+		if other == l {
+			t.listeners[typ] = append(listeners[:i:i], listeners[i+1:]...)
+			break
+		}
+	}
+}
+
+// dispatch implements dispatching ev to the target obj, returning false if
+// a listener canceled it
+func (t *eventTarget) dispatch(obj vm.Value, ev *event) (bool, error) {
+	if ev.dispatching || !ev.initialized {
+		return false, t.vm.NewTypeError("Failed to execute 'dispatchEvent' on 'EventTarget': The event is already being dispatched.")
+	}
+	ev.dispatching = true
+	ev.target, ev.currentTarget, ev.phase = obj, obj, eventPhaseAtTarget
+
+	// Listeners added while dispatching don't run, removed ones are skipped
+	listeners := append([]*eventListener(nil), t.listeners[ev.typ]...)
+	for _, capture := range []bool{true, false} {
+		if ev.stopped {
+			break
+		}
+		t.invoke(listeners, ev, capture)
+	}
+
+	ev.dispatching = false
+	ev.currentTarget, ev.phase = vm.Null, eventPhaseNone
+	ev.stopped, ev.stoppedNow = false, false
+	return !ev.canceled, nil
+}
+
+// invoke calls the capturing or the other listeners for ev, in the order
+// they were added
+func (t *eventTarget) invoke(listeners []*eventListener, ev *event, capture bool) {
+	vmInstance := t.vm
+	for _, l := range listeners {
+		if l.removed || l.capture != capture {
+			continue
+		}
+		if l.once {
+			t.remove(ev.typ, l)
+		}
+
+		callback, this := l.callback, ev.currentTarget
+		var err error
+		if !callback.IsCallable() {
+			// A listener object's handleEvent is looked up on every event
+			this = callback
+			if callback, err = vmInstance.GetProperty(l.callback, "handleEvent"); err == nil && !callback.IsCallable() {
+				err = vmInstance.NewTypeError("The listener's handleEvent is not a function")
+			}
+		}
+		if err == nil {
+			ev.inPassive = l.passive
+			_, err = vmInstance.Call(callback, this, []vm.Value{ev.object(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "Event")))})
+			ev.inPassive = false
+		}
+		if err != nil {
+			reportException(vmInstance, err)
+		}
+		if ev.stoppedNow {
+			return
+		}
+	}
+}
+
+// reportException reports err, thrown by an event listener, as an uncaught
+// exception without interrupting the dispatch it was thrown in: a
+// macrotask throws it again, like a timer callback throwing would
+func reportException(vmInstance *vm.VM, err error) {
+	if rt, ok := vmInstance.GetAsyncRuntime().(runtime.TimerRuntime); ok {
+		rt.SetImmediate(func() error { return err })
+	}
+}
+
+// parseListenerOptions reads the options of addEventListener and
+// removeEventListener: a capture flag, or {capture, once, passive, signal}
+func parseListenerOptions(vmInstance *vm.VM, options vm.Value, full bool) (l *eventListener, signal *AbortSignal, err error) {
+	l = &eventListener{}
+	if !options.IsObject() {
+		l.capture = options.IsTruthy()
+		return l, nil, nil
+	}
+	flags := map[string]*bool{"capture": &l.capture}
+	if full {
+		flags["once"], flags["passive"] = &l.once, &l.passive
+	}
+	for name, flag := range flags {
+		v, err := vmInstance.GetProperty(options, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		*flag = v.IsTruthy()
+	}
+	if !full {
+		return l, nil, nil
+	}
+	s, err := vmInstance.GetProperty(options, "signal")
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Type() != vm.TypeUndefined {
+		if signal = abortSignalOf(s); signal == nil {
+			return nil, nil, vmInstance.NewTypeError("Failed to execute 'addEventListener' on 'EventTarget': member signal is not of type 'AbortSignal'.")
+		}
+	}
+	return l, signal, nil
+}
+
+func initEventTargetPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	// callbackArg returns the listener argument, or false for null and undefined
+	callbackArg := func(method string, args []vm.Value) (vm.Value, bool, error) {
+		callback := argOrUndefined(args, 1)
+		switch {
+		case callback.Type() == vm.TypeUndefined || callback.Type() == vm.TypeNull:
+			return vm.Undefined, false, nil
+		case !callback.IsObject() && !callback.IsCallable():
+			return vm.Undefined, false, vmInstance.NewTypeError("Failed to execute '" + method + "' on 'EventTarget': parameter 2 is not of type 'Object'.")
+		}
+		return callback, true, nil
+	}
+
+	// addEventListener(type, callback, options?)
+	proto.SetOwnNonEnumerable("addEventListener", vm.NewNativeFunction(2, false, "addEventListener", func(args []vm.Value) (vm.Value, error) {
+		_, target, err := thisEventTarget(vmInstance, "addEventListener")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		callback, ok, err := callbackArg("addEventListener", args)
+		if err != nil || !ok {
+			return vm.Undefined, err
+		}
+		l, signal, err := parseListenerOptions(vmInstance, argOrUndefined(args, 2), true)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		l.callback = callback
+		target.add(argOrUndefined(args, 0).ToString(), l, signal)
+		return vm.Undefined, nil
+	}))
+
+	// removeEventListener(type, callback, options?)
+	proto.SetOwnNonEnumerable("removeEventListener", vm.NewNativeFunction(2, false, "removeEventListener", func(args []vm.Value) (vm.Value, error) {
+		_, target, err := thisEventTarget(vmInstance, "removeEventListener")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		callback, ok, err := callbackArg("removeEventListener", args)
+		if err != nil || !ok {
+			return vm.Undefined, err
+		}
+		opts, _, err := parseListenerOptions(vmInstance, argOrUndefined(args, 2), false)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		typ := argOrUndefined(args, 0).ToString()
+		if l := target.find(typ, callback, opts.capture); l != nil {
+			target.remove(typ, l)
+		}
+		return vm.Undefined, nil
+	}))
+
+	// dispatchEvent(event) -> false if a listener canceled event
+	proto.SetOwnNonEnumerable("dispatchEvent", vm.NewNativeFunction(1, false, "dispatchEvent", func(args []vm.Value) (vm.Value, error) {
+		this, target, err := thisEventTarget(vmInstance, "dispatchEvent")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		ev := eventOf(argOrUndefined(args, 0))
+		if ev == nil {
+			return vm.Undefined, vmInstance.NewTypeError("Failed to execute 'dispatchEvent' on 'EventTarget': parameter 1 is not of type 'Event'.")
+		}
+		ev.isTrusted = false
+		notCanceled, err := target.dispatch(this, ev)
+		return vm.BooleanValue(notCanceled), err
+	}))
+}
+
+// fire implements firing a trusted event of type typ at the target obj
+func (t *eventTarget) fire(obj vm.Value, typ string) {
+	ev := newEvent(typ)
+	ev.isTrusted = true
+	_, _ = t.dispatch(obj, ev)
+}
+
+// defineEventHandler defines the event handler attribute "on"+typ on proto
+func defineEventHandler(vmInstance *vm.VM, proto *vm.PlainObject, typ string) {
+	name := "on" + typ
+	getter := vm.NewNativeFunction(0, false, "get "+name, func(args []vm.Value) (vm.Value, error) {
+		_, target, err := thisEventTarget(vmInstance, name)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if h := target.handlers[typ]; h != nil {
+			return h.value, nil
+		}
+		return vm.Null, nil
+	})
+	setter := vm.NewNativeFunction(1, false, "set "+name, func(args []vm.Value) (vm.Value, error) {
+		_, target, err := thisEventTarget(vmInstance, name)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		value := argOrUndefined(args, 0)
+		h := target.handlers[typ]
+		if !value.IsCallable() {
+			// Setting a non-function deactivates the handler, so setting one
+			// again adds its listener at the end of the list
+			if h != nil {
+				target.remove(typ, h.listener)
+				delete(target.handlers, typ)
+			}
+			return vm.Undefined, nil
+		}
+		if h != nil {
+			h.value = value
+			return vm.Undefined, nil
+		}
+		h = &eventHandler{value: value}
+		h.listener = &eventListener{callback: vm.NewNativeFunction(1, false, name, func(args []vm.Value) (vm.Value, error) {
+			result, err := vmInstance.Call(h.value, vmInstance.GetThis(), args)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			// A handler returning false cancels the event
+			if result.Type() == vm.TypeBoolean && !result.AsBoolean() {
+				if ev := eventOf(argOrUndefined(args, 0)); ev != nil {
+					ev.preventDefault()
+				}
+			}
+			return vm.Undefined, nil
+		})}
+		target.handlers[typ] = h
+		target.add(typ, h.listener, nil)
+		return vm.Undefined, nil
+	})
+	e, c := true, true
+	proto.DefineAccessorProperty(name, getter, true, setter, true, &e, &c)
+}
@@ -28,6 +28,8 @@ func (f *FetchInitializer) Priority() int {
 }
 
 func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
+	urlInput := urlInputType(ctx)
+
 	// Headers type (prototype) with iterator methods
 	headersType := types.NewObjectType().
 		WithProperty("get", types.NewSimpleFunction([]types.Type{types.String}, types.String)).
@@ -57,6 +59,7 @@ func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
 		WithProperty("statusText", types.String).
 		WithProperty("url", types.String).
 		WithProperty("headers", headersType).
+		WithProperty("body", types.NewUnionType(readableStreamType(ctx), types.Null)).
 		WithProperty("bodyUsed", types.Boolean).
 		WithProperty("redirected", types.Boolean).
 		WithProperty("type", types.String).
@@ -80,7 +83,7 @@ func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
 		WithSimpleCallSignature([]types.Type{types.Any, responseInitType}, responseType).         // Response(body, init)
 		WithProperty("prototype", responseType).
 		WithProperty("error", types.NewSimpleFunction([]types.Type{}, responseType)).             // Response.error()
-		WithProperty("redirect", types.NewSimpleFunction([]types.Type{types.String, types.Number}, responseType)). // Response.redirect(url, status)
+		WithProperty("redirect", types.NewOptionalFunction([]types.Type{urlInput, types.Number}, responseType, []bool{false, true})). // Response.redirect(url, status?)
 		WithProperty("json", types.NewSimpleFunction([]types.Type{types.Any, responseInitType}, responseType))     // Response.json(data, init)
 
 	if err := ctx.DefineGlobal("Response", responseConstructorType); err != nil {
@@ -106,7 +109,7 @@ func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
 		WithProperty("method", types.String).
 		WithProperty("url", types.String).
 		WithProperty("headers", headersType).
-		WithProperty("body", types.Any). // ReadableStream or null
+		WithProperty("body", types.NewUnionType(readableStreamType(ctx), types.Null)).
 		WithProperty("bodyUsed", types.Boolean).
 		WithProperty("cache", types.String).
 		WithProperty("credentials", types.String).
@@ -126,8 +129,8 @@ func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
 
 	// Request constructor type
 	requestConstructorType := types.NewObjectType().
-		WithSimpleCallSignature([]types.Type{types.String}, requestType).                 // Request(url)
-		WithSimpleCallSignature([]types.Type{types.String, requestInitType}, requestType). // Request(url, init)
+		WithSimpleCallSignature([]types.Type{urlInput}, requestType).                  // Request(url)
+		WithSimpleCallSignature([]types.Type{urlInput, requestInitType}, requestType). // Request(url, init)
 		WithSimpleCallSignature([]types.Type{requestType}, requestType).                  // Request(request)
 		WithSimpleCallSignature([]types.Type{requestType, requestInitType}, requestType). // Request(request, init)
 		WithProperty("prototype", requestType)
@@ -136,10 +139,10 @@ func (f *FetchInitializer) InitTypes(ctx *TypeContext) error {
 		return err
 	}
 
-	// fetch function type: (url: string | Request, init?: RequestInit) => Promise<Response>
+	// fetch function type: (url: string | URL | Request, init?: RequestInit) => Promise<Response>
 	// Second parameter is optional
 	fetchType := types.NewOptionalFunction(
-		[]types.Type{types.NewUnionType(types.String, requestType), requestInitType},
+		[]types.Type{types.NewUnionType(urlInput, requestType), requestInitType},
 		types.Any,
 		[]bool{false, true}, // url/request is required, init is optional
 	)
@@ -200,13 +203,24 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 	// Create Response constructor
 	responseConstructorFn := func(args []vm.Value) (vm.Value, error) {
 		var bodyBytes []byte
+		var bodyStream *readableStream
 		status := 200
 		statusText := "OK"
 		headers := &FetchHeaders{headers: make(http.Header)}
 
 		// Parse body if provided
 		if len(args) > 0 && args[0].Type() != vm.TypeUndefined && args[0].Type() != vm.TypeNull {
-			bodyBytes = valueToBytes(args[0])
+			if bodyStream = readableStreamOf(args[0]); bodyStream != nil {
+				if bodyStream.locked() || bodyStream.disturbed {
+					return vm.Undefined, vmInstance.NewTypeError("Response body stream is locked or disturbed")
+				}
+			} else {
+				bodyBytes = valueToBytes(args[0])
+			}
+		}
+		bodyType := ""
+		if len(args) > 0 {
+			bodyType = bodyContentType(args[0])
 		}
 
 		// Parse init options if provided
@@ -236,6 +250,10 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 			}
 		}
 
+		if bodyType != "" && headers.headers.Get("Content-Type") == "" {
+			headers.headers.Set("Content-Type", bodyType)
+		}
+
 		response := &FetchResponse{
 			vm:         vmInstance,
 			OK:         status >= 200 && status < 300,
@@ -244,6 +262,7 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 			URL:        "",
 			Headers:    headers,
 			body:       bodyBytes,
+			stream:     bodyStream,
 			bodyUsed:   false,
 			Redirected: false,
 			Type:       "default",
@@ -278,7 +297,11 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 			if len(args) < 1 {
 				return vm.Undefined, vmInstance.NewTypeError("Response.redirect requires a URL")
 			}
-			url := args[0].ToString()
+			parsed, err := parseURL(toUSVString(vmInstance, args[0]), nil)
+			if err != nil {
+				return vm.Undefined, vmInstance.NewTypeError("Response.redirect: Invalid URL")
+			}
+			url := parsed.String()
 			status := 302 // Default redirect status
 			if len(args) > 1 && args[1].IsNumber() {
 				status = int(args[1].ToFloat())
@@ -424,10 +447,10 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 					req.Signal = s
 				}
 			} else {
-				req.URL = input.ToString()
+				req.URL = toUSVString(vmInstance, input)
 			}
 		} else {
-			req.URL = input.ToString()
+			req.URL = toUSVString(vmInstance, input)
 		}
 
 		// Parse init options if provided
@@ -459,14 +482,14 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 			return vm.Undefined, vmInstance.NewTypeError("fetch requires at least 1 argument")
 		}
 
-		url := args[0].ToString()
+		url := requestURL(vmInstance, args[0])
 		var init vm.Value = vm.Undefined
 		if len(args) > 1 {
 			init = args[1]
 		}
 
-		// Check for pre-aborted signal synchronously before spawning goroutine
-		// This avoids race conditions with very fast abort checks
+		// The init's signal, if any, aborts the request
+		var signal *AbortSignal
 		if init.Type() != vm.TypeUndefined && init.Type() != vm.TypeNull {
 			var initObj interface {
 				GetOwn(string) (vm.Value, bool)
@@ -477,109 +500,93 @@ func (f *FetchInitializer) InitRuntime(ctx *RuntimeContext) error {
 				initObj = init.AsDictObject()
 			}
 			if initObj != nil {
-				if s, exists := initObj.GetOwn("signal"); exists && s.Type() != vm.TypeUndefined && s.Type() == vm.TypeObject {
-					signalObj := s.AsPlainObject()
-					if aborted, exists := signalObj.GetOwn("aborted"); exists {
-						if aborted.IsBoolean() && aborted.AsBoolean() {
-							// Signal is already aborted - reject immediately without async
-							reason := "AbortError: signal is aborted without reason"
-							if r, exists := signalObj.GetOwn("reason"); exists && r.Type() != vm.TypeUndefined {
-								reason = "AbortError: " + r.ToString()
-							}
-							promise := vmInstance.NewPendingPromise()
-							promiseObj := promise.AsPromise()
-							vmInstance.RejectPromise(promiseObj, vm.NewString(reason))
-							return promise, nil
-						}
-					}
+				if s, exists := initObj.GetOwn("signal"); exists {
+					signal = abortSignalOf(s)
 				}
 			}
 		}
 
+		// Check for pre-aborted signal synchronously before spawning goroutine
+		if signal != nil && signal.aborted {
+			// Signal is already aborted - reject immediately without async
+			reason := "AbortError: signal is aborted without reason"
+			if signal.reason.Type() != vm.TypeUndefined {
+				reason = "AbortError: " + signal.reason.ToString()
+			}
+			return vmInstance.NewRejectedPromise(vm.NewString(reason)), nil
+		}
+
+		// The body is read before the request starts, and a stream body whole
+		bodyBytes, bodyStream, bodyType, err := fetchRequestBody(vmInstance, init)
+		if err != nil {
+			return vmInstance.NewRejectedPromise(thrownValue(err)), nil
+		}
+
 		// Create pending promise
 		promise := vmInstance.NewPendingPromise()
 		promiseObj := promise.AsPromise()
 
-		// Get the async runtime to track external operations
-		rt := vmInstance.GetAsyncRuntime()
+		start := func(bodyBytes []byte) {
+			// Get the async runtime to track external operations
+			rt := vmInstance.GetAsyncRuntime()
 
-		// Mark that we're starting an external async operation
-		rt.BeginExternalOp()
+			// Mark that we're starting an external async operation
+			rt.BeginExternalOp()
 
-		// Create a cancellable context for the request
-		ctx, cancel := context.WithCancel(context.Background())
+			// Create a cancellable context for the request, which lives
+			// until the response body is closed
+			ctx, cancel := context.WithCancel(context.Background())
 
-		// Extract signal for abort monitoring
-		var signalObj *vm.PlainObject
-		if init.Type() != vm.TypeUndefined && init.Type() != vm.TypeNull {
-			var initObj interface {
-				GetOwn(string) (vm.Value, bool)
-			}
-			if init.Type() == vm.TypeObject {
-				initObj = init.AsPlainObject()
-			} else if init.Type() == vm.TypeDictObject {
-				initObj = init.AsDictObject()
+			// Aborting the signal cancels the request, or the reading of
+			// its response body
+			if signal != nil {
+				signal.onAbort(cancel)
 			}
-			if initObj != nil {
-				if s, exists := initObj.GetOwn("signal"); exists && s.Type() == vm.TypeObject {
-					signalObj = s.AsPlainObject()
+
+			// errorValue is what the request, or reading the response body,
+			// failing with err rejects with
+			errorValue := func(err error) vm.Value {
+				// Check if this was a context cancellation (abort)
+				if ctx.Err() == context.Canceled {
+					reason := "AbortError: The operation was aborted"
+					if signal != nil && signal.reason.Type() != vm.TypeUndefined {
+						reason = "AbortError: " + signal.reason.ToString()
+					}
+					return vm.NewString(reason)
 				}
+				return vm.NewString(err.Error())
 			}
-		}
 
-		// If we have a signal, set up abort monitoring
-		var abortOnce sync.Once
-		if signalObj != nil {
-			// Start a goroutine to poll for abort
-			// This is a simple polling approach - a more sophisticated approach
-			// would use event listeners on the signal
+			var body io.Reader
+			if bodyBytes != nil {
+				body = bytes.NewReader(bodyBytes)
+			}
+
+			// Perform HTTP request asynchronously in a goroutine
 			go func() {
-				ticker := time.NewTicker(10 * time.Millisecond)
-				defer ticker.Stop()
-				for {
-					select {
-					case <-ctx.Done():
+				response, responseBody, err := doFetchRequestWithContext(ctx, vmInstance, url, init, body, bodyType)
+
+				// Settle the promise on the event loop, which also marks the
+				// external operation complete
+				runOnEventLoop(vmInstance, func() {
+					if err != nil {
+						vmInstance.RejectPromise(promiseObj, errorValue(err))
+						cancel()
 						return
-					case <-ticker.C:
-						if aborted, exists := signalObj.GetOwn("aborted"); exists {
-							if aborted.IsBoolean() && aborted.AsBoolean() {
-								abortOnce.Do(func() {
-									cancel()
-								})
-								return
-							}
-						}
 					}
-				}
+					response.stream = newNetworkBodyStream(vmInstance, responseBody, cancel, errorValue)
+					vmInstance.ResolvePromise(promiseObj, createResponseObject(vmInstance, response))
+				})
 			}()
 		}
 
-		// Perform HTTP request asynchronously in a goroutine
-		go func() {
-			defer cancel() // Clean up context when done
-
-			response, err := doFetchRequestWithContext(ctx, vmInstance, url, init)
-
-			if err != nil {
-				// Check if this was a context cancellation (abort)
-				if ctx.Err() == context.Canceled {
-					reason := "AbortError: The operation was aborted"
-					if signalObj != nil {
-						if r, exists := signalObj.GetOwn("reason"); exists && r.Type() != vm.TypeUndefined {
-							reason = "AbortError: " + r.ToString()
-						}
-					}
-					vmInstance.RejectPromise(promiseObj, vm.NewString(reason))
-				} else {
-					vmInstance.RejectPromise(promiseObj, vm.NewString(err.Error()))
-				}
-			} else {
-				vmInstance.ResolvePromise(promiseObj, response)
-			}
-
-			// Mark that the external operation is complete
-			rt.EndExternalOp()
-		}()
+		if bodyStream != nil {
+			bodyStream.readAll(start, func(reason vm.Value) {
+				vmInstance.RejectPromise(promiseObj, reason)
+			})
+		} else {
+			start(bodyBytes)
+		}
 
 		return promise, nil
 	})
@@ -601,6 +608,7 @@ type FetchResponse struct {
 	URL         string
 	Headers     *FetchHeaders
 	body        []byte
+	stream      *readableStream // body as a stream; the only body of network responses
 	bodyUsed    bool
 	Redirected  bool   // Whether this response is the result of a redirect
 	Type        string // Response type: "basic", "cors", "default", "error", "opaque", "opaqueredirect"
@@ -807,6 +815,69 @@ func createHeadersObject(vmInstance *vm.VM, h *FetchHeaders) vm.Value {
 	return vm.NewValueFromPlainObject(obj)
 }
 
+// consumeBody reads a Response or Request body whole, for text(), json()
+// and the like, and returns a promise of what convert makes of its bytes.
+// Bodies held in memory are converted right away; streamed ones once read.
+func consumeBody(vmInstance *vm.VM, data []byte, stream *readableStream, used *bool, convert func([]byte) (vm.Value, error)) vm.Value {
+	if *used || stream != nil && (stream.disturbed || stream.locked()) {
+		return vmInstance.NewRejectedPromise(vm.NewString("body already used"))
+	}
+	*used = true
+	if stream == nil {
+		result, err := convert(data)
+		if err != nil {
+			return vmInstance.NewRejectedPromise(vm.NewString(err.Error()))
+		}
+		return vmInstance.NewResolvedPromise(result)
+	}
+	promise, resolve, reject := settled(vmInstance)
+	stream.readAll(func(data []byte) {
+		result, err := convert(data)
+		if err != nil {
+			reject(vm.NewString(err.Error()))
+			return
+		}
+		resolve(result)
+	}, reject)
+	return promise
+}
+
+// bodyStreamObject returns the ReadableStream of a body, creating it from
+// data the first time, or null when there is no body
+func bodyStreamObject(vmInstance *vm.VM, data []byte, stream **readableStream, used bool) vm.Value {
+	if *stream == nil {
+		if data == nil {
+			return vm.Null
+		}
+		*stream = newBytesStream(vmInstance, data)
+		if used {
+			// Read by text() and the like before anyone asked for the stream
+			(*stream).cancel(vm.Undefined)
+		}
+	}
+	return (*stream).object()
+}
+
+// teeBody splits a streamed body between a Response or Request and its clone
+func teeBody(stream **readableStream) (*readableStream, error) {
+	if *stream == nil {
+		return nil, nil
+	}
+	branches, err := (*stream).tee()
+	if err != nil {
+		return nil, err
+	}
+	*stream = branches[0]
+	return branches[1], nil
+}
+
+// bodyBytesArrayBuffer returns an ArrayBuffer holding a copy of data
+func bodyBytesArrayBuffer(data []byte) vm.Value {
+	arrayBufferValue := vm.NewArrayBuffer(len(data))
+	copy(arrayBufferValue.AsArrayBuffer().GetData(), data)
+	return arrayBufferValue
+}
+
 // createResponseObject creates a Response object for the VM with async methods
 func createResponseObject(vmInstance *vm.VM, r *FetchResponse) vm.Value {
 	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
@@ -817,88 +888,66 @@ func createResponseObject(vmInstance *vm.VM, r *FetchResponse) vm.Value {
 	obj.SetOwn("statusText", vm.NewString(r.StatusText))
 	obj.SetOwn("url", vm.NewString(r.URL))
 	obj.SetOwn("headers", createHeadersObject(vmInstance, r.Headers))
-	obj.SetOwn("bodyUsed", boolToValue(r.bodyUsed))
 	obj.SetOwn("redirected", boolToValue(r.Redirected))
 	obj.SetOwn("type", vm.NewString(r.Type))
 
+	// body -> ReadableStream | null, and bodyUsed, which reading the stream also sets
+	defineGetter(obj, "body", func() vm.Value {
+		return bodyStreamObject(vmInstance, r.body, &r.stream, r.bodyUsed)
+	})
+	defineGetter(obj, "bodyUsed", func() vm.Value {
+		return boolToValue(r.bodyUsed || r.stream != nil && r.stream.disturbed)
+	})
+
 	// text() -> Promise<string>
 	obj.SetOwnNonEnumerable("text", vm.NewNativeFunction(0, false, "text", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		r.bodyUsed = true
-		// Update bodyUsed property on the object
-		obj.SetOwn("bodyUsed", vm.True)
-		return vmInstance.NewResolvedPromise(vm.NewString(string(r.body))), nil
+		return consumeBody(vmInstance, r.body, r.stream, &r.bodyUsed, func(data []byte) (vm.Value, error) {
+			return vm.NewString(string(data)), nil
+		}), nil
 	}))
 
 	// json() -> Promise<any>
 	obj.SetOwnNonEnumerable("json", vm.NewNativeFunction(0, false, "json", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		r.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		var result vm.Value
-		if err := result.UnmarshalJSON(r.body); err != nil {
-			return vmInstance.NewRejectedPromise(vm.NewString(err.Error())), nil
-		}
-		return vmInstance.NewResolvedPromise(result), nil
+		return consumeBody(vmInstance, r.body, r.stream, &r.bodyUsed, func(data []byte) (vm.Value, error) {
+			var result vm.Value
+			if err := result.UnmarshalJSON(data); err != nil {
+				return vm.Undefined, err
+			}
+			return result, nil
+		}), nil
 	}))
 
 	// blob() -> Promise<Uint8Array>
 	obj.SetOwnNonEnumerable("blob", vm.NewNativeFunction(0, false, "blob", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		r.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		// Create Uint8Array from body bytes
-		arrayBufferValue := vm.NewArrayBuffer(len(r.body))
-		buffer := arrayBufferValue.AsArrayBuffer()
-		copy(buffer.GetData(), r.body)
-		uint8Array := vm.NewTypedArray(vm.TypedArrayUint8, buffer, 0, 0)
-		return vmInstance.NewResolvedPromise(uint8Array), nil
+		return consumeBody(vmInstance, r.body, r.stream, &r.bodyUsed, func(data []byte) (vm.Value, error) {
+			return newUint8Array(data), nil
+		}), nil
 	}))
 
 	// arrayBuffer() -> Promise<ArrayBuffer>
 	obj.SetOwnNonEnumerable("arrayBuffer", vm.NewNativeFunction(0, false, "arrayBuffer", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		r.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		// Create ArrayBuffer from body bytes
-		arrayBufferValue := vm.NewArrayBuffer(len(r.body))
-		buffer := arrayBufferValue.AsArrayBuffer()
-		copy(buffer.GetData(), r.body)
-		return vmInstance.NewResolvedPromise(arrayBufferValue), nil
+		return consumeBody(vmInstance, r.body, r.stream, &r.bodyUsed, func(data []byte) (vm.Value, error) {
+			return bodyBytesArrayBuffer(data), nil
+		}), nil
 	}))
 
 	// bytes() -> Promise<Uint8Array> (same as blob, but standard name)
 	obj.SetOwnNonEnumerable("bytes", vm.NewNativeFunction(0, false, "bytes", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		r.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		// Create Uint8Array from body bytes
-		arrayBufferValue := vm.NewArrayBuffer(len(r.body))
-		buffer := arrayBufferValue.AsArrayBuffer()
-		copy(buffer.GetData(), r.body)
-		uint8Array := vm.NewTypedArray(vm.TypedArrayUint8, buffer, 0, 0)
-		return vmInstance.NewResolvedPromise(uint8Array), nil
+		return consumeBody(vmInstance, r.body, r.stream, &r.bodyUsed, func(data []byte) (vm.Value, error) {
+			return newUint8Array(data), nil
+		}), nil
 	}))
 
 	// clone() -> Response (creates a copy of the response)
 	obj.SetOwnNonEnumerable("clone", vm.NewNativeFunction(0, false, "clone", func(args []vm.Value) (vm.Value, error) {
-		if r.bodyUsed {
+		if r.bodyUsed || r.stream != nil && (r.stream.disturbed || r.stream.locked()) {
 			return vm.Undefined, vmInstance.NewTypeError("Response body is already used")
 		}
+		// A streamed body is teed between the two
+		clonedStream, err := teeBody(&r.stream)
+		if err != nil {
+			return vm.Undefined, err
+		}
 
 		// Create a copy of the response with the same body
 		clonedResponse := &FetchResponse{
@@ -909,6 +958,7 @@ func createResponseObject(vmInstance *vm.VM, r *FetchResponse) vm.Value {
 			URL:        r.URL,
 			Headers:    &FetchHeaders{headers: r.Headers.headers.Clone()},
 			body:       r.body, // Share the same body bytes (they're not modified)
+			stream:     clonedStream,
 			bodyUsed:   false,
 			Redirected: r.Redirected,
 			Type:       r.Type,
@@ -919,13 +969,109 @@ func createResponseObject(vmInstance *vm.VM, r *FetchResponse) vm.Value {
 	return vm.NewValueFromPlainObject(obj)
 }
 
-// doFetchRequestWithContext performs the HTTP request with context support for cancellation
-func doFetchRequestWithContext(ctx context.Context, vmInstance *vm.VM, url string, init vm.Value) (vm.Value, error) {
+// fetchRequestBody returns the body init gives a request, as bytes or as a
+// ReadableStream, and the Content-Type it implies. It runs before the
+// request starts, since only the VM's goroutine can look at the body.
+func fetchRequestBody(vmInstance *vm.VM, init vm.Value) ([]byte, *readableStream, string, error) {
+	var initObj interface {
+		GetOwn(string) (vm.Value, bool)
+	}
+	if init.Type() == vm.TypeObject {
+		initObj = init.AsPlainObject()
+	} else if init.Type() == vm.TypeDictObject {
+		initObj = init.AsDictObject()
+	}
+	if initObj == nil {
+		return nil, nil, "", nil
+	}
+	b, exists := initObj.GetOwn("body")
+	if !exists || b.Type() == vm.TypeUndefined {
+		return nil, nil, "", nil
+	}
+	if stream := readableStreamOf(b); stream != nil {
+		if stream.locked() || stream.disturbed {
+			return nil, nil, "", vmInstance.NewTypeError("fetch: body stream is locked or disturbed")
+		}
+		return nil, stream, "", nil
+	}
+	if params := urlSearchParamsOf(b); params != nil {
+		return []byte(params.String()), nil, formURLEncodedContentType, nil
+	}
+
+	switch b.Type() {
+	case vm.TypeString:
+		return []byte(b.ToString()), nil, "", nil
+	default:
+		// For objects, check if we should auto-stringify
+		contentType := ""
+		if h, exists := initObj.GetOwn("headers"); exists {
+			contentType = parseHeaders(h).headers.Get("Content-Type")
+		}
+		if strings.Contains(strings.ToLower(contentType), "application/json") || b.Type() == vm.TypeObject || b.Type() == vm.TypeDictObject {
+			// Auto-stringify objects, and anything sent as JSON
+			jsonBytes, err := b.MarshalJSON()
+			if err != nil {
+				return nil, nil, "", vmInstance.NewTypeError("failed to serialize body to JSON: " + err.Error())
+			}
+			return jsonBytes, nil, "", nil
+		}
+		return []byte(b.ToString()), nil, "", nil
+	}
+}
+
+// newNetworkBodyStream streams the body of a network response, reading it
+// on a goroutine each time the stream pulls. done runs once the body is
+// closed, and abortReason gives the error for reads the request's abort
+// signal cut short.
+func newNetworkBodyStream(vmInstance *vm.VM, body io.ReadCloser, done func(), abortReason func(error) vm.Value) *readableStream {
+	var closeOnce sync.Once
+	closeBody := func() {
+		closeOnce.Do(func() {
+			body.Close()
+			done()
+		})
+	}
+	s, _ := newReadableStream(vmInstance, readableSource{
+		pull: func(c *readableController) vm.Value {
+			promise, resolve, _ := settled(vmInstance)
+			vmInstance.GetAsyncRuntime().BeginExternalOp()
+			go func() {
+				buf := make([]byte, 32*1024)
+				n, err := body.Read(buf)
+				runOnEventLoop(vmInstance, func() {
+					defer resolve(vm.Undefined)
+					if !c.canCloseOrEnqueue() {
+						return // canceled while reading
+					}
+					if n > 0 {
+						_ = c.enqueue(newUint8Array(buf[:n]))
+					}
+					switch {
+					case err == io.EOF:
+						closeBody()
+						_ = c.close()
+					case err != nil:
+						closeBody()
+						c.error(abortReason(err))
+					}
+				})
+			}()
+			return promise
+		},
+		cancel: func(reason vm.Value) vm.Value {
+			closeBody()
+			return vm.Undefined
+		},
+	}, queuingStrategy{size: vm.Undefined}, true)
+	return s
+}
+
+// doFetchRequestWithContext performs the HTTP request with context support
+// for cancellation. The response's body is left open for its stream to read.
+func doFetchRequestWithContext(ctx context.Context, vmInstance *vm.VM, url string, init vm.Value, body io.Reader, bodyType string) (*FetchResponse, io.ReadCloser, error) {
 	// Default options
 	method := "GET"
 	headers := &FetchHeaders{headers: make(http.Header)}
-	var body io.Reader
-	var abortSignal *AbortSignal
 	redirectMode := "follow" // "follow", "error", "manual"
 
 	// Parse init options if provided
@@ -965,52 +1111,6 @@ func doFetchRequestWithContext(ctx context.Context, vmInstance *vm.VM, url strin
 				}
 			}
 
-			// Body
-			if b, exists := initObj.GetOwn("body"); exists && b.Type() != vm.TypeUndefined {
-				switch b.Type() {
-				case vm.TypeString:
-					body = strings.NewReader(b.ToString())
-				default:
-					// For objects, check if we should auto-stringify
-					contentType := headers.headers.Get("Content-Type")
-					if strings.Contains(strings.ToLower(contentType), "application/json") {
-						// Auto-stringify objects for JSON content type
-						jsonBytes, err := b.MarshalJSON()
-						if err != nil {
-							return vm.Undefined, vmInstance.NewTypeError("failed to serialize body to JSON: " + err.Error())
-						}
-						body = bytes.NewReader(jsonBytes)
-					} else if b.Type() == vm.TypeObject || b.Type() == vm.TypeDictObject {
-						// Default to JSON for objects
-						jsonBytes, err := b.MarshalJSON()
-						if err != nil {
-							return vm.Undefined, vmInstance.NewTypeError("failed to serialize body to JSON: " + err.Error())
-						}
-						body = bytes.NewReader(jsonBytes)
-					} else {
-						body = strings.NewReader(b.ToString())
-					}
-				}
-			}
-
-			// Signal (AbortSignal)
-			if s, exists := initObj.GetOwn("signal"); exists && s.Type() == vm.TypeObject {
-				signalObj := s.AsPlainObject()
-				// Check if signal is already aborted
-				if aborted, exists := signalObj.GetOwn("aborted"); exists {
-					if aborted.IsBoolean() && aborted.AsBoolean() {
-						reason := vm.NewString("AbortError: signal is aborted without reason")
-						if r, exists := signalObj.GetOwn("reason"); exists && r.Type() != vm.TypeUndefined {
-							reason = r
-						}
-						return vm.Undefined, &AbortError{Message: reason.ToString()}
-					}
-				}
-				// Store reference for potential future abort (would need more infrastructure)
-				abortSignal = &AbortSignal{aborted: false}
-				_ = abortSignal // Avoid unused variable warning
-			}
-
 			// Redirect mode
 			if r, exists := initObj.GetOwn("redirect"); exists && r.Type() == vm.TypeString {
 				redirectMode = r.ToString()
@@ -1046,23 +1146,19 @@ func doFetchRequestWithContext(ctx context.Context, vmInstance *vm.VM, url strin
 	// Create request with context for cancellation support
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return vm.Undefined, err
+		return nil, nil, err
 	}
 
-	// Set headers
+	// Set headers, and the body's Content-Type unless they have one
 	req.Header = headers.headers
+	if bodyType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", bodyType)
+	}
 
 	// Perform request
 	resp, err := client.Do(req)
 	if err != nil {
-		return vm.Undefined, err
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return vm.Undefined, err
+		return nil, nil, err
 	}
 
 	// Create response headers
@@ -1084,13 +1180,12 @@ func doFetchRequestWithContext(ctx context.Context, vmInstance *vm.VM, url strin
 		StatusText: resp.Status,
 		URL:        respURL,
 		Headers:    responseHeaders,
-		body:       bodyBytes,
 		bodyUsed:   false,
 		Redirected: redirected,
 		Type:       responseType,
 	}
 
-	return createResponseObject(vmInstance, response), nil
+	return response, resp.Body, nil
 }
 
 // FetchRequest represents the Request object
@@ -1100,6 +1195,7 @@ type FetchRequest struct {
 	URL            string
 	Headers        *FetchHeaders
 	body           []byte
+	stream         *readableStream // body as a stream, when constructed from one or read as one
 	bodyUsed       bool
 	Cache          string
 	Credentials    string
@@ -1114,6 +1210,9 @@ type FetchRequest struct {
 
 // valueToBytes converts various value types to bytes
 func valueToBytes(v vm.Value) []byte {
+	if params := urlSearchParamsOf(v); params != nil {
+		return []byte(params.String())
+	}
 	switch v.Type() {
 	case vm.TypeString:
 		return []byte(v.ToString())
@@ -1143,6 +1242,28 @@ func valueToBytes(v vm.Value) []byte {
 	return []byte{}
 }
 
+// formURLEncodedContentType is the Content-Type of URLSearchParams bodies
+const formURLEncodedContentType = "application/x-www-form-urlencoded;charset=UTF-8"
+
+// bodyContentType returns the Content-Type a body value implies, or ""
+func bodyContentType(v vm.Value) string {
+	if urlSearchParamsOf(v) != nil {
+		return formURLEncodedContentType
+	}
+	return ""
+}
+
+// requestURL returns the URL a fetch input names: a Request's url, or the
+// input as a string, which is how URL objects give their href
+func requestURL(vmInstance *vm.VM, input vm.Value) string {
+	if input.Type() == vm.TypeObject && urlObjectOf(input) == nil {
+		if u, exists := input.AsPlainObject().GetOwn("url"); exists && u.Type() == vm.TypeString {
+			return u.ToString()
+		}
+	}
+	return toUSVString(vmInstance, input)
+}
+
 // parseHeaders parses a value into FetchHeaders
 func parseHeaders(v vm.Value) *FetchHeaders {
 	headers := &FetchHeaders{headers: make(http.Header)}
@@ -1173,7 +1294,12 @@ func parseRequestInit(req *FetchRequest, initObj *vm.PlainObject) {
 		req.Headers = parseHeaders(h)
 	}
 	if b, exists := initObj.GetOwn("body"); exists && b.Type() != vm.TypeUndefined && b.Type() != vm.TypeNull {
-		req.body = valueToBytes(b)
+		if req.stream = readableStreamOf(b); req.stream == nil {
+			req.body = valueToBytes(b)
+		}
+		if bodyType := bodyContentType(b); bodyType != "" && req.Headers.headers.Get("Content-Type") == "" {
+			req.Headers.headers.Set("Content-Type", bodyType)
+		}
 	}
 	if c, exists := initObj.GetOwn("cache"); exists && c.Type() == vm.TypeString {
 		req.Cache = c.ToString()
@@ -1207,7 +1333,12 @@ func parseRequestInitDict(req *FetchRequest, initObj *vm.DictObject) {
 		req.Headers = parseHeaders(h)
 	}
 	if b, exists := initObj.GetOwn("body"); exists && b.Type() != vm.TypeUndefined && b.Type() != vm.TypeNull {
-		req.body = valueToBytes(b)
+		if req.stream = readableStreamOf(b); req.stream == nil {
+			req.body = valueToBytes(b)
+		}
+		if bodyType := bodyContentType(b); bodyType != "" && req.Headers.headers.Get("Content-Type") == "" {
+			req.Headers.headers.Set("Content-Type", bodyType)
+		}
 	}
 	if c, exists := initObj.GetOwn("cache"); exists && c.Type() == vm.TypeString {
 		req.Cache = c.ToString()
@@ -1240,8 +1371,6 @@ func createRequestObject(vmInstance *vm.VM, req *FetchRequest, _ *vm.PlainObject
 	obj.SetOwn("method", vm.NewString(req.Method))
 	obj.SetOwn("url", vm.NewString(req.URL))
 	obj.SetOwn("headers", createHeadersObject(vmInstance, req.Headers))
-	obj.SetOwn("body", vm.Null) // Body is null for most requests
-	obj.SetOwn("bodyUsed", boolToValue(req.bodyUsed))
 	obj.SetOwn("cache", vm.NewString(req.Cache))
 	obj.SetOwn("credentials", vm.NewString(req.Credentials))
 	obj.SetOwn("destination", vm.NewString(req.Destination))
@@ -1252,11 +1381,23 @@ func createRequestObject(vmInstance *vm.VM, req *FetchRequest, _ *vm.PlainObject
 	obj.SetOwn("referrerPolicy", vm.NewString(req.ReferrerPolicy))
 	obj.SetOwn("signal", req.Signal)
 
+	// body -> ReadableStream | null; null for most requests
+	defineGetter(obj, "body", func() vm.Value {
+		return bodyStreamObject(vmInstance, req.body, &req.stream, req.bodyUsed)
+	})
+	defineGetter(obj, "bodyUsed", func() vm.Value {
+		return boolToValue(req.bodyUsed || req.stream != nil && req.stream.disturbed)
+	})
+
 	// clone() -> Request
 	obj.SetOwnNonEnumerable("clone", vm.NewNativeFunction(0, false, "clone", func(args []vm.Value) (vm.Value, error) {
-		if req.bodyUsed {
+		if req.bodyUsed || req.stream != nil && (req.stream.disturbed || req.stream.locked()) {
 			return vm.Undefined, vmInstance.NewTypeError("Request body is already used")
 		}
+		clonedStream, err := teeBody(&req.stream)
+		if err != nil {
+			return vm.Undefined, err
+		}
 
 		clonedReq := &FetchRequest{
 			vm:             vmInstance,
@@ -1264,6 +1405,7 @@ func createRequestObject(vmInstance *vm.VM, req *FetchRequest, _ *vm.PlainObject
 			URL:            req.URL,
 			Headers:        &FetchHeaders{headers: req.Headers.headers.Clone()},
 			body:           req.body,
+			stream:         clonedStream,
 			bodyUsed:       false,
 			Cache:          req.Cache,
 			Credentials:    req.Credentials,
@@ -1280,72 +1422,36 @@ func createRequestObject(vmInstance *vm.VM, req *FetchRequest, _ *vm.PlainObject
 
 	// arrayBuffer() -> Promise<ArrayBuffer>
 	obj.SetOwnNonEnumerable("arrayBuffer", vm.NewNativeFunction(0, false, "arrayBuffer", func(args []vm.Value) (vm.Value, error) {
-		if req.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		req.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		if req.body == nil {
-			return vmInstance.NewResolvedPromise(vm.NewArrayBuffer(0)), nil
-		}
-
-		arrayBuffer := vm.NewArrayBuffer(len(req.body))
-		buf := arrayBuffer.AsArrayBuffer()
-		copy(buf.GetData(), req.body)
-		return vmInstance.NewResolvedPromise(arrayBuffer), nil
+		return consumeBody(vmInstance, req.body, req.stream, &req.bodyUsed, func(data []byte) (vm.Value, error) {
+			return bodyBytesArrayBuffer(data), nil
+		}), nil
 	}))
 
 	// blob() -> Promise<Blob>
 	obj.SetOwnNonEnumerable("blob", vm.NewNativeFunction(0, false, "blob", func(args []vm.Value) (vm.Value, error) {
-		if req.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		req.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		if req.body == nil {
-			return vmInstance.NewResolvedPromise(vm.NewArrayBuffer(0)), nil
-		}
-
-		arrayBuffer := vm.NewArrayBuffer(len(req.body))
-		buf := arrayBuffer.AsArrayBuffer()
-		copy(buf.GetData(), req.body)
-		uint8Array := vm.NewTypedArray(vm.TypedArrayUint8, buf, 0, 0)
-		return vmInstance.NewResolvedPromise(uint8Array), nil
+		return consumeBody(vmInstance, req.body, req.stream, &req.bodyUsed, func(data []byte) (vm.Value, error) {
+			if data == nil {
+				return vm.NewArrayBuffer(0), nil
+			}
+			return newUint8Array(data), nil
+		}), nil
 	}))
 
 	// json() -> Promise<any>
 	obj.SetOwnNonEnumerable("json", vm.NewNativeFunction(0, false, "json", func(args []vm.Value) (vm.Value, error) {
-		if req.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		req.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		if req.body == nil {
-			return vmInstance.NewRejectedPromise(vm.NewString("Unexpected end of JSON input")), nil
-		}
-
-		parsed, err := parseJSONToValue(string(req.body))
-		if err != nil {
-			return vmInstance.NewRejectedPromise(vm.NewString(err.Error())), nil
-		}
-		return vmInstance.NewResolvedPromise(parsed), nil
+		return consumeBody(vmInstance, req.body, req.stream, &req.bodyUsed, func(data []byte) (vm.Value, error) {
+			if data == nil {
+				return vm.Undefined, errors.New("Unexpected end of JSON input")
+			}
+			return parseJSONToValue(string(data))
+		}), nil
 	}))
 
 	// text() -> Promise<string>
 	obj.SetOwnNonEnumerable("text", vm.NewNativeFunction(0, false, "text", func(args []vm.Value) (vm.Value, error) {
-		if req.bodyUsed {
-			return vmInstance.NewRejectedPromise(vm.NewString("body already used")), nil
-		}
-		req.bodyUsed = true
-		obj.SetOwn("bodyUsed", vm.True)
-
-		if req.body == nil {
-			return vmInstance.NewResolvedPromise(vm.NewString("")), nil
-		}
-		return vmInstance.NewResolvedPromise(vm.NewString(string(req.body))), nil
+		return consumeBody(vmInstance, req.body, req.stream, &req.bodyUsed, func(data []byte) (vm.Value, error) {
+			return vm.NewString(string(data)), nil
+		}), nil
 	}))
 
 	// formData() -> Promise<FormData> (stub - would need FormData parsing)
@@ -85,7 +85,7 @@ func createFormDataObject(vmInstance *vm.VM, fd *FormData, _ *vm.PlainObject) vm
 			return vm.Undefined, nil
 		}
 		name := args[0].ToString()
-		value := args[1]
+		value := formDataValue(vmInstance, args[1])
 		filename := ""
 		if len(args) > 2 && args[2].Type() == vm.TypeString {
 			filename = args[2].ToString()
@@ -164,7 +164,7 @@ func createFormDataObject(vmInstance *vm.VM, fd *FormData, _ *vm.PlainObject) vm
 			return vm.Undefined, nil
 		}
 		name := args[0].ToString()
-		value := args[1]
+		value := formDataValue(vmInstance, args[1])
 		filename := ""
 		if len(args) > 2 && args[2].Type() == vm.TypeString {
 			filename = args[2].ToString()
@@ -221,6 +221,18 @@ func createFormDataObject(vmInstance *vm.VM, fd *FormData, _ *vm.PlainObject) vm
 		return result, nil
 	}))
 
+	// [Symbol.iterator]() -> iterator of [name, value] pairs, like entries()
+	iteratorFn := vm.NewNativeFunction(0, false, "[Symbol.iterator]", func(args []vm.Value) (vm.Value, error) {
+		entries, _ := obj.GetOwn("entries")
+		arr, err := vmInstance.Call(entries, vm.NewValueFromPlainObject(obj), nil)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createArrayIterator(vmInstance, arr.AsArray()), nil
+	})
+	w, e, c := true, false, true
+	obj.DefineOwnPropertyByKey(vm.NewSymbolKey(SymbolIterator), iteratorFn, &w, &e, &c)
+
 	// forEach(callback)
 	obj.SetOwnNonEnumerable("forEach", vm.NewNativeFunction(1, false, "forEach", func(args []vm.Value) (vm.Value, error) {
 		if len(args) < 1 || !args[0].IsCallable() {
@@ -240,6 +252,15 @@ func createFormDataObject(vmInstance *vm.VM, fd *FormData, _ *vm.PlainObject) vm
 	return vm.NewValueFromPlainObject(obj)
 }
 
+// formDataValue converts an entry value: Blobs stay as they are, and
+// anything else, URLs and URLSearchParams included, becomes a string
+func formDataValue(vmInstance *vm.VM, v vm.Value) vm.Value {
+	if blobOf(v) != nil {
+		return v
+	}
+	return vm.NewString(toUSVString(vmInstance, v))
+}
+
 // GetEntries returns the form data entries for serialization
 func (fd *FormData) GetEntries() []FormDataEntry {
 	return fd.entries
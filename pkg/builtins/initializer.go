@@ -46,6 +46,9 @@ type RuntimeContext struct {
 	// Define a global value
 	DefineGlobal func(name string, value vm.Value) error
 
+	// Get a global value an earlier initializer defined
+	GetGlobal func(name string) (vm.Value, bool)
+
 	// Get built-in prototypes (set as initializers run)
 	ObjectPrototype   vm.Value
 	FunctionPrototype vm.Value
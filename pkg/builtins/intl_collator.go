@@ -0,0 +1,136 @@
+package builtins
+
+import (
+	"sync"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collator is the state of an Intl.Collator. collate.Collator isn't safe for
+// concurrent use, so comparisons hold mu.
+type collator struct {
+	mu                sync.Mutex
+	collator          *collate.Collator
+	locale            language.Tag
+	usage             string
+	sensitivity       string
+	ignorePunctuation bool
+	numeric           bool
+	caseFirst         string
+	collation         string
+}
+
+func newCollator(vmInstance *vm.VM, tag language.Tag, opts intlOptions) (*collator, error) {
+	col := &collator{locale: tag, caseFirst: "false", collation: "default"}
+	var err error
+	if col.usage, err = opts.getString("usage", []string{"sort", "search"}, "sort"); err != nil {
+		return nil, err
+	}
+	if _, err = opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if _, err = opts.getString("collation", nil, ""); err != nil {
+		return nil, err
+	}
+	numeric, _, err := opts.getBool("numeric")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = opts.getString("caseFirst", []string{"upper", "lower", "false"}, ""); err != nil {
+		return nil, err
+	}
+	if col.sensitivity, err = opts.getString("sensitivity", []string{"base", "accent", "case", "variant"}, "variant"); err != nil {
+		return nil, err
+	}
+	if col.ignorePunctuation, _, err = opts.getBool("ignorePunctuation"); err != nil {
+		return nil, err
+	}
+
+	col.numeric = numeric
+	col.collator = collate.New(tag, col.collateOptions()...)
+	return col, nil
+}
+
+// collateOptions maps the collator's options to x/text's
+func (col *collator) collateOptions() []collate.Option {
+	var options []collate.Option
+	switch col.sensitivity {
+	case "base":
+		options = append(options, collate.IgnoreCase, collate.IgnoreDiacritics)
+	case "accent":
+		options = append(options, collate.IgnoreCase)
+	case "case":
+		options = append(options, collate.IgnoreDiacritics)
+	}
+	if col.numeric {
+		options = append(options, collate.Numeric)
+	}
+	if col.ignorePunctuation {
+		options = append(options, collate.OptionsFromTag(language.MustParse("und-u-ka-shifted")))
+	}
+	return options
+}
+
+// compare returns -1, 0 or 1 as a sorts before, with or after b
+func (col *collator) compare(a, b string) int {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	return col.collator.CompareString(a, b)
+}
+
+func (col *collator) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	return resolvedOptionsObject(vmInstance,
+		"locale", col.locale.String(),
+		"usage", col.usage,
+		"sensitivity", col.sensitivity,
+		"ignorePunctuation", col.ignorePunctuation,
+		"collation", col.collation,
+		"numeric", col.numeric,
+		"caseFirst", col.caseFirst,
+	)
+}
+
+func createCollatorObject(vmInstance *vm.VM, col *collator, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+
+	// compare is bound to the instance, so it can be passed to sort
+	obj.SetOwnNonEnumerable("compare", vm.NewNativeFunction(2, false, "compare", func(args []vm.Value) (vm.Value, error) {
+		a, b := argOrUndefined(args, 0).ToString(), argOrUndefined(args, 1).ToString()
+		return vm.NumberValue(float64(col.compare(a, b))), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return col.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
+
+var (
+	defaultCollatorOnce sync.Once
+	defaultCollator     *collator
+)
+
+// localeCompare implements String.prototype.localeCompare, sharing one
+// collator between calls that pass no locales or options
+func localeCompare(vmInstance *vm.VM, a, b string, locales, options vm.Value) (int, error) {
+	if locales.Type() == vm.TypeUndefined && options.Type() == vm.TypeUndefined {
+		defaultCollatorOnce.Do(func() {
+			defaultCollator, _ = newCollator(vmInstance, language.MustParse(intlDefaultLocale), intlOptions{})
+		})
+		return defaultCollator.compare(a, b), nil
+	}
+	requested, err := canonicalizeLocaleList(vmInstance, locales)
+	if err != nil {
+		return 0, err
+	}
+	opts, err := newIntlOptions(vmInstance, options)
+	if err != nil {
+		return 0, err
+	}
+	col, err := newCollator(vmInstance, resolveLocale(requested, intlCommonLocale), opts)
+	if err != nil {
+		return 0, err
+	}
+	return col.compare(a, b), nil
+}
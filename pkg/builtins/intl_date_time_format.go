@@ -0,0 +1,756 @@
+package builtins
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/language"
+)
+
+// dateLocale is the calendar data DateTimeFormat has for a locale: x/text
+// doesn't export CLDR's date patterns, so the locales Intl formats dates in
+// are the ones listed in dateLocales
+type dateLocale struct {
+	months, monthsShort     [12]string
+	weekdays, weekdaysShort [7]string // from Sunday
+	am, pm                  string
+	eras                    [2]string // BC, AD
+	hour12                  bool      // whether the locale uses a 12-hour clock
+	padHour                 bool      // whether 24-hour clocks show 09:05
+	padNumericDate          bool      // whether all-numeric dates show 05/01/2024
+	dayPeriodFirst          bool      // whether AM/PM precedes the time
+
+	// Date templates by the fields they show (ymd, ym, md), over {year},
+	// {month} and {day}; numericDate is for numeric months
+	textDate, numericDate map[string]string
+	weekdayDate           string    // how a weekday joins a date, {weekday} and {date}
+	shortDate             [3]string // dateStyle short's year, month and day options
+	dateTime              string    // between date and time
+	longDateTime          string    // between a dateStyle full or long date and time
+}
+
+var enUSDateLocale = &dateLocale{
+	months:        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	monthsShort:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	weekdaysShort: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	am:            "AM",
+	pm:            "PM",
+	eras:          [2]string{"BC", "AD"},
+	hour12:        true,
+	textDate:      map[string]string{"ymd": "{month} {day}, {year}", "ym": "{month} {year}", "md": "{month} {day}"},
+	numericDate:   map[string]string{"ymd": "{month}/{day}/{year}", "ym": "{month}/{year}", "md": "{month}/{day}"},
+	weekdayDate:   "{weekday}, {date}",
+	shortDate:     [3]string{"2-digit", "numeric", "numeric"},
+	dateTime:      ", ",
+	longDateTime:  " at ",
+}
+
+var enGBDateLocale = func() *dateLocale {
+	gb := *enUSDateLocale
+	gb.hour12 = false
+	gb.padHour = true
+	gb.padNumericDate = true
+	gb.textDate = map[string]string{"ymd": "{day} {month} {year}", "ym": "{month} {year}", "md": "{day} {month}"}
+	gb.numericDate = map[string]string{"ymd": "{day}/{month}/{year}", "ym": "{month}/{year}", "md": "{day}/{month}"}
+	gb.weekdayDate = "{weekday} {date}"
+	gb.shortDate = [3]string{"numeric", "2-digit", "2-digit"}
+	return &gb
+}()
+
+// enDMY12DateLocale is for English locales with day-first dates and a
+// 12-hour clock, like en-AU and en-IN
+var enDMY12DateLocale = func() *dateLocale {
+	l := *enGBDateLocale
+	l.hour12 = true
+	l.padHour = false
+	return &l
+}()
+
+var dateLocales = map[string]*dateLocale{
+	"en": enUSDateLocale,
+	"de": {
+		months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsShort:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		weekdaysShort: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		am:            "AM",
+		pm:            "PM",
+		eras:          [2]string{"v. Chr.", "n. Chr."},
+		padHour:       true,
+		textDate:      map[string]string{"ymd": "{day}. {month} {year}", "ym": "{month} {year}", "md": "{day}. {month}"},
+		numericDate:   map[string]string{"ymd": "{day}.{month}.{year}", "ym": "{month}/{year}", "md": "{day}.{month}."},
+		weekdayDate:   "{weekday}, {date}",
+		shortDate:     [3]string{"2-digit", "2-digit", "2-digit"},
+		dateTime:      ", ",
+		longDateTime:  " um ",
+	},
+	"fr": {
+		months:         [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsShort:    [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:       [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		weekdaysShort:  [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		am:             "AM",
+		pm:             "PM",
+		eras:           [2]string{"av. J.-C.", "ap. J.-C."},
+		padHour:        true,
+		padNumericDate: true,
+		textDate:       map[string]string{"ymd": "{day} {month} {year}", "ym": "{month} {year}", "md": "{day} {month}"},
+		numericDate:    map[string]string{"ymd": "{day}/{month}/{year}", "ym": "{month}/{year}", "md": "{day}/{month}"},
+		weekdayDate:    "{weekday} {date}",
+		shortDate:      [3]string{"numeric", "2-digit", "2-digit"},
+		dateTime:       " ",
+		longDateTime:   " à ",
+	},
+	"es": {
+		months:        [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		weekdaysShort: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		am:            "a. m.",
+		pm:            "p. m.",
+		eras:          [2]string{"a. C.", "d. C."},
+		textDate:      map[string]string{"ymd": "{day} de {month} de {year}", "ym": "{month} de {year}", "md": "{day} de {month}"},
+		numericDate:   map[string]string{"ymd": "{day}/{month}/{year}", "ym": "{month}/{year}", "md": "{day}/{month}"},
+		weekdayDate:   "{weekday}, {date}",
+		shortDate:     [3]string{"2-digit", "numeric", "numeric"},
+		dateTime:      ", ",
+		longDateTime:  ", ",
+	},
+	"ja": {
+		months:         [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		monthsShort:    [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:       [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		weekdaysShort:  [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		am:             "午前",
+		pm:             "午後",
+		eras:           [2]string{"紀元前", "西暦"},
+		dayPeriodFirst: true,
+		textDate:       map[string]string{"ymd": "{year}年{month}{day}日", "ym": "{year}年{month}", "md": "{month}{day}日"},
+		numericDate:    map[string]string{"ymd": "{year}/{month}/{day}", "ym": "{year}/{month}", "md": "{month}/{day}"},
+		weekdayDate:    "{date}{weekday}",
+		shortDate:      [3]string{"numeric", "2-digit", "2-digit"},
+		dateTime:       " ",
+		longDateTime:   " ",
+	},
+}
+
+// getDateLocale returns the calendar data for tag, if there is any
+func getDateLocale(tag language.Tag) (*dateLocale, bool) {
+	base, _ := tag.Base()
+	data, ok := dateLocales[base.String()]
+	if ok && base.String() == "en" {
+		region, _ := tag.Region()
+		switch region.String() {
+		case "GB", "IE":
+			data = enGBDateLocale
+		case "AU", "NZ", "IN", "ZA":
+			data = enDMY12DateLocale
+		}
+	}
+	return data, ok
+}
+
+// intlDateLocale reports whether DateTimeFormat and RelativeTimeFormat have
+// data for tag's language
+func intlDateLocale(tag language.Tag) bool {
+	_, ok := getDateLocale(tag)
+	return ok
+}
+
+// narrowName is a name's narrow form, its first letter
+func narrowName(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	if unicode.IsDigit(r) {
+		return name
+	}
+	return string(unicode.ToUpper(r))
+}
+
+// dateTimeFormat is the state of an Intl.DateTimeFormat
+type dateTimeFormat struct {
+	locale   language.Tag
+	data     *dateLocale
+	symbols  *numberSymbols
+	timeZone string
+	location *time.Location
+
+	hourCycle                          string
+	weekday, era, year, month, day     string
+	hour, minute, second, timeZoneName string
+	fractionalSecondDigits             int
+	dateStyle, timeStyle               string
+}
+
+var dateTimeComponents = []struct {
+	name   string
+	values []string
+}{
+	{"weekday", []string{"narrow", "short", "long"}},
+	{"era", []string{"narrow", "short", "long"}},
+	{"year", []string{"2-digit", "numeric"}},
+	{"month", []string{"2-digit", "numeric", "narrow", "short", "long"}},
+	{"day", []string{"2-digit", "numeric"}},
+	{"dayPeriod", []string{"narrow", "short", "long"}},
+	{"hour", []string{"2-digit", "numeric"}},
+	{"minute", []string{"2-digit", "numeric"}},
+	{"second", []string{"2-digit", "numeric"}},
+}
+
+// newDateTimeFormat implements CreateDateTimeFormat. required says which
+// fields must be shown (date, time or any), and defaults which to show when
+// the options ask for none (date, time or all).
+func newDateTimeFormat(vmInstance *vm.VM, tag language.Tag, opts intlOptions, required, defaults string) (*dateTimeFormat, error) {
+	data, ok := getDateLocale(tag)
+	if !ok {
+		tag = language.MustParse(intlDefaultLocale)
+		data, _ = getDateLocale(tag)
+	}
+	df := &dateTimeFormat{locale: tag, data: data, symbols: getNumberSymbols(tag)}
+
+	if _, err := opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if _, err := opts.getString("calendar", nil, ""); err != nil {
+		return nil, err
+	}
+	if _, err := opts.getString("numberingSystem", nil, ""); err != nil {
+		return nil, err
+	}
+	hour12, hour12Set, err := opts.getBool("hour12")
+	if err != nil {
+		return nil, err
+	}
+	hourCycle, err := opts.getString("hourCycle", []string{"h11", "h12", "h23", "h24"}, "")
+	if err != nil {
+		return nil, err
+	}
+	timeZone, err := opts.getString("timeZone", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if df.timeZone, df.location, err = resolveTimeZone(vmInstance, timeZone); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, component := range dateTimeComponents {
+		if values[component.name], err = opts.getString(component.name, component.values, ""); err != nil {
+			return nil, err
+		}
+	}
+	if df.fractionalSecondDigits, _, err = opts.getNumber("fractionalSecondDigits", 1, 3); err != nil {
+		return nil, err
+	}
+	if values["timeZoneName"], err = opts.getString("timeZoneName", []string{"short", "long", "shortOffset", "longOffset", "shortGeneric", "longGeneric"}, ""); err != nil {
+		return nil, err
+	}
+	if _, err := opts.getString("formatMatcher", []string{"basic", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	styles := []string{"full", "long", "medium", "short"}
+	if df.dateStyle, err = opts.getString("dateStyle", styles, ""); err != nil {
+		return nil, err
+	}
+	if df.timeStyle, err = opts.getString("timeStyle", styles, ""); err != nil {
+		return nil, err
+	}
+
+	explicit := df.fractionalSecondDigits != 0
+	for _, v := range values {
+		explicit = explicit || v != ""
+	}
+	if df.dateStyle != "" || df.timeStyle != "" {
+		if explicit {
+			return nil, vmInstance.NewTypeError("Can't set option " + firstSetComponent(values, df.fractionalSecondDigits) + " when dateStyle or timeStyle is used")
+		}
+		if required == "date" && df.dateStyle == "" || required == "time" && df.timeStyle == "" {
+			return nil, vmInstance.NewTypeError("Invalid option : " + required + "Style must be set")
+		}
+		df.expandStyles(values)
+	} else {
+		needDefaults := true
+		if required == "date" || required == "any" {
+			needDefaults = needDefaults && values["weekday"] == "" && values["year"] == "" && values["month"] == "" && values["day"] == ""
+		}
+		if required == "time" || required == "any" {
+			needDefaults = needDefaults && values["dayPeriod"] == "" && values["hour"] == "" && values["minute"] == "" && values["second"] == "" && df.fractionalSecondDigits == 0
+		}
+		if needDefaults && (defaults == "date" || defaults == "all") {
+			values["year"], values["month"], values["day"] = "numeric", "numeric", "numeric"
+		}
+		if needDefaults && (defaults == "time" || defaults == "all") {
+			values["hour"], values["minute"], values["second"] = "numeric", "numeric", "numeric"
+		}
+	}
+
+	df.weekday, df.era, df.year, df.month, df.day = values["weekday"], values["era"], values["year"], values["month"], values["day"]
+	df.hour, df.minute, df.second, df.timeZoneName = values["hour"], values["minute"], values["second"], values["timeZoneName"]
+
+	if df.hour != "" {
+		switch {
+		case hour12Set && hour12:
+			df.hourCycle = "h12"
+		case hour12Set:
+			df.hourCycle = "h23"
+		case hourCycle != "":
+			df.hourCycle = hourCycle
+		case data.hour12:
+			df.hourCycle = "h12"
+		default:
+			df.hourCycle = "h23"
+		}
+	}
+	return df, nil
+}
+
+// firstSetComponent names the first component option that was set
+func firstSetComponent(values map[string]string, fractionalSecondDigits int) string {
+	for _, component := range dateTimeComponents {
+		if values[component.name] != "" {
+			return component.name
+		}
+	}
+	if fractionalSecondDigits != 0 {
+		return "fractionalSecondDigits"
+	}
+	return "timeZoneName"
+}
+
+// expandStyles sets the fields dateStyle and timeStyle stand for
+func (df *dateTimeFormat) expandStyles(values map[string]string) {
+	switch df.dateStyle {
+	case "full":
+		values["weekday"] = "long"
+		fallthrough
+	case "long":
+		values["year"], values["month"], values["day"] = "numeric", "long", "numeric"
+	case "medium":
+		values["year"], values["month"], values["day"] = "numeric", "short", "numeric"
+	case "short":
+		values["year"], values["month"], values["day"] = df.data.shortDate[0], df.data.shortDate[1], df.data.shortDate[2]
+	}
+	switch df.timeStyle {
+	case "full":
+		values["timeZoneName"] = "long"
+		values["hour"], values["minute"], values["second"] = "numeric", "2-digit", "2-digit"
+	case "long":
+		values["timeZoneName"] = "short"
+		values["hour"], values["minute"], values["second"] = "numeric", "2-digit", "2-digit"
+	case "medium":
+		values["hour"], values["minute"], values["second"] = "numeric", "2-digit", "2-digit"
+	case "short":
+		values["hour"], values["minute"] = "numeric", "2-digit"
+	}
+}
+
+// resolveTimeZone validates the timeZone option, returning its canonical name
+func resolveTimeZone(vmInstance *vm.VM, name string) (string, *time.Location, error) {
+	if name == "" {
+		zone := intlDefaultTimeZone()
+		if loc, err := time.LoadLocation(zone); err == nil {
+			return zone, loc, nil
+		}
+		return "UTC", time.UTC, nil
+	}
+	switch strings.ToUpper(name) {
+	case "UTC", "GMT", "ETC/UTC", "ETC/GMT", "ETC/UCT", "UCT", "ETC/ZULU", "ZULU":
+		return "UTC", time.UTC, nil
+	}
+	if name[0] == '+' || name[0] == '-' {
+		if offset, ok := parseUTCOffset(name[1:]); ok {
+			if name[0] == '-' {
+				offset = -offset
+			}
+			canonical := formatOffset(offset)
+			return canonical, time.FixedZone(canonical, offset), nil
+		}
+	} else if loc, err := time.LoadLocation(name); err == nil {
+		return name, loc, nil
+	}
+	return "", nil, vmInstance.NewRangeError("Invalid time zone specified: " + name)
+}
+
+// parseUTCOffset parses the HH, HHMM or HH:MM of an offset time zone into seconds
+func parseUTCOffset(s string) (int, bool) {
+	s = strings.Replace(s, ":", "", 1)
+	if len(s) != 2 && len(s) != 4 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(s[:2])
+	if err != nil || hours > 23 {
+		return 0, false
+	}
+	minutes := 0
+	if len(s) == 4 {
+		if minutes, err = strconv.Atoi(s[2:]); err != nil || minutes > 59 {
+			return 0, false
+		}
+	}
+	return hours*3600 + minutes*60, true
+}
+
+// toDateTimeValue converts a format argument to a time value in
+// milliseconds, the current time when it's undefined
+func toDateTimeValue(vmInstance *vm.VM, date vm.Value) (float64, error) {
+	var ms float64
+	if date.Type() == vm.TypeUndefined {
+		ms = float64(time.Now().UnixMilli())
+	} else if timestamp, ok := getDateTimestamp(date); ok {
+		ms = timestamp
+	} else {
+		ms = vmInstance.ToNumber(date)
+	}
+	if math.IsNaN(ms) || math.Abs(ms) > 8.64e15 {
+		return 0, vmInstance.NewRangeError("Invalid time value")
+	}
+	return math.Trunc(ms), nil
+}
+
+// pad2 formats n with at least two digits
+func pad2(n int) string {
+	if n < 10 && n >= 0 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// format formats the time value ms into parts
+func (df *dateTimeFormat) format(ms float64) intlParts {
+	t := time.UnixMilli(int64(ms)).In(df.location)
+	data := df.data
+	digits := df.symbols.localizeDigits
+
+	field := func(parts *intlParts, name string) {
+		switch name {
+		case "year":
+			year := t.Year()
+			if df.era != "" && year <= 0 {
+				year = 1 - year
+			}
+			if df.year == "2-digit" {
+				parts.add("year", digits(pad2(((year%100)+100)%100)))
+			} else {
+				parts.add("year", digits(strconv.Itoa(year)))
+			}
+		case "month":
+			month := int(t.Month()) - 1
+			switch df.month {
+			case "long":
+				parts.add("month", data.months[month])
+			case "short":
+				parts.add("month", data.monthsShort[month])
+			case "narrow":
+				parts.add("month", narrowName(data.months[month]))
+			case "2-digit":
+				parts.add("month", digits(pad2(month+1)))
+			default:
+				if data.padNumericDate && df.year != "" && df.day != "" {
+					parts.add("month", digits(pad2(month+1)))
+				} else {
+					parts.add("month", digits(strconv.Itoa(month+1)))
+				}
+			}
+		case "day":
+			if df.day == "2-digit" || data.padNumericDate && df.isNumericDate() && df.year != "" {
+				parts.add("day", digits(pad2(t.Day())))
+			} else {
+				parts.add("day", digits(strconv.Itoa(t.Day())))
+			}
+		case "weekday":
+			weekday := int(t.Weekday())
+			switch df.weekday {
+			case "long":
+				parts.add("weekday", data.weekdays[weekday])
+			case "short":
+				parts.add("weekday", data.weekdaysShort[weekday])
+			default:
+				parts.add("weekday", narrowName(data.weekdays[weekday]))
+			}
+		}
+	}
+
+	var parts intlParts
+	hasDate := df.year != "" || df.month != "" || df.day != "" || df.weekday != ""
+	hasTime := df.hour != "" || df.minute != "" || df.second != "" || df.fractionalSecondDigits != 0
+
+	if hasDate {
+		if df.weekday != "" && (df.year != "" || df.month != "" || df.day != "") {
+			renderTemplate(&parts, data.weekdayDate, func(name string) {
+				if name == "date" {
+					df.formatDate(&parts, field)
+				} else {
+					field(&parts, name)
+				}
+			})
+		} else {
+			df.formatDate(&parts, field)
+		}
+		if df.era != "" {
+			parts.add("literal", " ")
+			era := data.eras[1]
+			if t.Year() <= 0 {
+				era = data.eras[0]
+			}
+			parts.add("era", era)
+		}
+	}
+	if hasTime {
+		if hasDate {
+			if df.dateStyle == "full" || df.dateStyle == "long" {
+				parts.add("literal", data.longDateTime)
+			} else {
+				parts.add("literal", data.dateTime)
+			}
+		}
+		df.formatTime(&parts, t)
+	}
+	if df.timeZoneName != "" {
+		if hasTime {
+			parts.add("literal", " ")
+		} else if hasDate {
+			parts.add("literal", data.dateTime)
+		}
+		parts.add("timeZoneName", df.zoneName(t))
+	}
+	return parts
+}
+
+// isNumericDate reports whether the date shows its month as a number
+func (df *dateTimeFormat) isNumericDate() bool {
+	return df.month == "" || df.month == "numeric" || df.month == "2-digit"
+}
+
+// formatDate lays out the year, month and day with the locale's template
+func (df *dateTimeFormat) formatDate(parts *intlParts, field func(*intlParts, string)) {
+	key := ""
+	if df.year != "" {
+		key += "y"
+	}
+	if df.month != "" {
+		key += "m"
+	}
+	if df.day != "" {
+		key += "d"
+	}
+	templates := df.data.textDate
+	if df.isNumericDate() {
+		templates = df.data.numericDate
+	}
+	switch key {
+	case "ymd", "ym", "md":
+		renderTemplate(parts, templates[key], func(name string) { field(parts, name) })
+	case "yd":
+		field(parts, "day")
+		parts.add("literal", " ")
+		field(parts, "year")
+	case "y":
+		field(parts, "year")
+	case "m":
+		field(parts, "month")
+	case "d":
+		field(parts, "day")
+	}
+}
+
+// formatTime lays out the hour, minute, second and day period
+func (df *dateTimeFormat) formatTime(parts *intlParts, t time.Time) {
+	digits := df.symbols.localizeDigits
+	twelveHour := df.hourCycle == "h11" || df.hourCycle == "h12"
+	dayPeriod := df.data.am
+	if t.Hour() >= 12 {
+		dayPeriod = df.data.pm
+	}
+	if twelveHour && df.data.dayPeriodFirst {
+		parts.add("dayPeriod", dayPeriod)
+	}
+
+	first := true
+	separator := func() {
+		if !first {
+			parts.add("literal", ":")
+		}
+		first = false
+	}
+	if df.hour != "" {
+		hour := t.Hour()
+		switch df.hourCycle {
+		case "h11":
+			hour %= 12
+		case "h12":
+			if hour %= 12; hour == 0 {
+				hour = 12
+			}
+		case "h24":
+			if hour == 0 {
+				hour = 24
+			}
+		}
+		separator()
+		if df.hour == "2-digit" || !twelveHour && df.data.padHour && (df.minute != "" || df.second != "") {
+			parts.add("hour", digits(pad2(hour)))
+		} else {
+			parts.add("hour", digits(strconv.Itoa(hour)))
+		}
+	}
+	if df.minute != "" {
+		separator()
+		if df.hour != "" || df.minute == "2-digit" {
+			parts.add("minute", digits(pad2(t.Minute())))
+		} else {
+			parts.add("minute", digits(strconv.Itoa(t.Minute())))
+		}
+	}
+	if df.second != "" {
+		separator()
+		if df.hour != "" || df.minute != "" || df.second == "2-digit" {
+			parts.add("second", digits(pad2(t.Second())))
+		} else {
+			parts.add("second", digits(strconv.Itoa(t.Second())))
+		}
+	}
+	if df.fractionalSecondDigits != 0 {
+		if df.second != "" {
+			parts.add("literal", df.symbols.decimal)
+		}
+		millis := strconv.Itoa(t.Nanosecond()/1e6 + 1000)[1:]
+		parts.add("fractionalSecond", digits(millis[:df.fractionalSecondDigits]))
+	}
+	if twelveHour && !df.data.dayPeriodFirst && df.hour != "" {
+		parts.add("literal", " ")
+		parts.add("dayPeriod", dayPeriod)
+	}
+}
+
+// zoneName names t's time zone as the timeZoneName option asks
+func (df *dateTimeFormat) zoneName(t time.Time) string {
+	abbreviation, offset := t.Zone()
+	long := df.timeZoneName == "long" || df.timeZoneName == "longGeneric" || df.timeZoneName == "longOffset"
+	if df.timeZone == "UTC" && df.timeZoneName != "shortOffset" && df.timeZoneName != "longOffset" {
+		if long {
+			return "Coordinated Universal Time"
+		}
+		return "UTC"
+	}
+	if df.timeZoneName == "short" || df.timeZoneName == "shortGeneric" {
+		// CLDR abbreviates the zones of a locale's own region only, which
+		// for the locales here means US zones in en-US
+		region, _ := df.locale.Region()
+		us := strings.HasPrefix(df.timeZone, "America/") || strings.HasPrefix(df.timeZone, "US/") || df.timeZone == "Pacific/Honolulu"
+		if region.String() == "US" && us && strings.IndexFunc(abbreviation, unicode.IsDigit) < 0 {
+			return abbreviation
+		}
+	}
+	if offset == 0 {
+		return "GMT"
+	}
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	hours, minutes := offset/3600, offset%3600/60
+	if long {
+		return "GMT" + sign + pad2(hours) + ":" + pad2(minutes)
+	}
+	if minutes != 0 {
+		return "GMT" + sign + strconv.Itoa(hours) + ":" + pad2(minutes)
+	}
+	return "GMT" + sign + strconv.Itoa(hours)
+}
+
+// renderTemplate adds a template's literal text as literal parts, and its
+// {name} placeholders through field
+func renderTemplate(parts *intlParts, template string, field func(name string)) {
+	for template != "" {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			parts.add("literal", template)
+			return
+		}
+		parts.add("literal", template[:open])
+		end := strings.IndexByte(template[open:], '}') + open
+		field(template[open+1 : end])
+		template = template[end+1:]
+	}
+}
+
+func (df *dateTimeFormat) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	pairs := []any{
+		"locale", df.locale.String(),
+		"calendar", "gregory",
+		"numberingSystem", "latn",
+		"timeZone", df.timeZone,
+	}
+	if df.hourCycle != "" {
+		pairs = append(pairs, "hourCycle", df.hourCycle, "hour12", df.hourCycle == "h11" || df.hourCycle == "h12")
+	}
+	if df.dateStyle == "" && df.timeStyle == "" {
+		pairs = append(pairs,
+			"weekday", df.weekday,
+			"era", df.era,
+			"year", df.year,
+			"month", df.month,
+			"day", df.day,
+			"hour", df.hour,
+			"minute", df.minute,
+			"second", df.second,
+		)
+		if df.fractionalSecondDigits != 0 {
+			pairs = append(pairs, "fractionalSecondDigits", df.fractionalSecondDigits)
+		}
+		pairs = append(pairs, "timeZoneName", df.timeZoneName)
+	}
+	pairs = append(pairs, "dateStyle", df.dateStyle, "timeStyle", df.timeStyle)
+	return resolvedOptionsObject(vmInstance, pairs...)
+}
+
+func createDateTimeFormatObject(vmInstance *vm.VM, df *dateTimeFormat, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+
+	// format is bound to the instance, so it can be passed around
+	obj.SetOwnNonEnumerable("format", vm.NewNativeFunction(1, false, "format", func(args []vm.Value) (vm.Value, error) {
+		ms, err := toDateTimeValue(vmInstance, argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vm.NewString(df.format(ms).String()), nil
+	}))
+	obj.SetOwnNonEnumerable("formatToParts", vm.NewNativeFunction(1, false, "formatToParts", func(args []vm.Value) (vm.Value, error) {
+		ms, err := toDateTimeValue(vmInstance, argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return df.format(ms).toArray(vmInstance), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return df.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// formatDateToLocaleString implements Date.prototype.toLocaleString,
+// toLocaleDateString and toLocaleTimeString, which differ in the fields they
+// require and default to
+func formatDateToLocaleString(vmInstance *vm.VM, ms float64, locales, options vm.Value, required, defaults string) (vm.Value, error) {
+	if math.IsNaN(ms) {
+		return vm.NewString("Invalid Date"), nil
+	}
+	requested, err := canonicalizeLocaleList(vmInstance, locales)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	opts, err := newIntlOptions(vmInstance, options)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	df, err := newDateTimeFormat(vmInstance, resolveLocale(requested, intlDateLocale), opts, required, defaults)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	return vm.NewString(df.format(ms).String()), nil
+}
@@ -0,0 +1,537 @@
+package builtins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // DateTimeFormat takes IANA zones even where the system has no zoneinfo
+
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const PriorityIntl = 106 // After Date and Temporal
+
+// intlDefaultLocale is the locale used when none of the requested ones is
+// available. It doesn't follow the environment so scripts format the same
+// everywhere.
+const intlDefaultLocale = "en-US"
+
+// IntlInitializer implements the Intl namespace on top of golang.org/x/text:
+// locale negotiation, NumberFormat, DateTimeFormat, Collator, PluralRules,
+// RelativeTimeFormat and Segmenter
+type IntlInitializer struct{}
+
+func (i *IntlInitializer) Name() string {
+	return "Intl"
+}
+
+func (i *IntlInitializer) Priority() int {
+	return PriorityIntl
+}
+
+// intlServiceType builds the type of an Intl constructor whose instances have
+// instanceType: callable with or without new, taking (locales?, options?)
+func intlServiceType(instanceType *types.ObjectType) *types.ObjectType {
+	sig := &types.Signature{
+		ParameterTypes: []types.Type{types.Any, types.Any},
+		OptionalParams: []bool{true, true},
+		ReturnType:     instanceType,
+	}
+	return types.NewObjectType().
+		WithCallSignature(sig).
+		WithConstructSignature(sig).
+		WithProperty("supportedLocalesOf", types.NewOptionalFunction([]types.Type{types.Any, types.Any}, &types.ArrayType{ElementType: types.String}, []bool{false, true})).
+		WithProperty("prototype", instanceType)
+}
+
+func (i *IntlInitializer) InitTypes(ctx *TypeContext) error {
+	stringArray := &types.ArrayType{ElementType: types.String}
+	partsType := &types.ArrayType{ElementType: types.NewObjectType().
+		WithProperty("type", types.String).
+		WithProperty("value", types.String)}
+	resolvedOptions := types.NewSimpleFunction([]types.Type{}, types.Any)
+
+	numberFormatType := types.NewObjectType().
+		WithProperty("format", types.NewSimpleFunction([]types.Type{types.Any}, types.String)).
+		WithProperty("formatToParts", types.NewSimpleFunction([]types.Type{types.Any}, partsType)).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	dateTimeFormatType := types.NewObjectType().
+		WithProperty("format", types.NewOptionalFunction([]types.Type{types.Any}, types.String, []bool{true})).
+		WithProperty("formatToParts", types.NewOptionalFunction([]types.Type{types.Any}, partsType, []bool{true})).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	collatorType := types.NewObjectType().
+		WithProperty("compare", types.NewSimpleFunction([]types.Type{types.String, types.String}, types.Number)).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	pluralRulesType := types.NewObjectType().
+		WithProperty("select", types.NewSimpleFunction([]types.Type{types.Number}, types.String)).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	relativeTimeFormatType := types.NewObjectType().
+		WithProperty("format", types.NewSimpleFunction([]types.Type{types.Number, types.String}, types.String)).
+		WithProperty("formatToParts", types.NewSimpleFunction([]types.Type{types.Number, types.String}, partsType)).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	segmentType := types.NewObjectType().
+		WithProperty("segment", types.String).
+		WithProperty("index", types.Number).
+		WithProperty("input", types.String).
+		WithOptionalProperty("isWordLike", types.Boolean)
+	segmentsType := types.NewObjectType().
+		WithProperty("containing", types.NewOptionalFunction([]types.Type{types.Number}, types.NewUnionType(segmentType, types.Undefined), []bool{true}))
+	if iteratorType, found := ctx.GetType("__IteratorGeneric__"); found {
+		if iteratorGeneric, ok := iteratorType.(*types.GenericType); ok {
+			iterator := &types.InstantiatedType{Generic: iteratorGeneric, TypeArguments: []types.Type{segmentType}}
+			segmentsType = segmentsType.WithProperty("__COMPUTED_PROPERTY__", types.NewSimpleFunction([]types.Type{}, iterator.Substitute()))
+		}
+	}
+	segmenterType := types.NewObjectType().
+		WithProperty("segment", types.NewSimpleFunction([]types.Type{types.String}, segmentsType)).
+		WithProperty("resolvedOptions", resolvedOptions)
+
+	intlType := types.NewObjectType().
+		WithProperty("getCanonicalLocales", types.NewOptionalFunction([]types.Type{types.Any}, stringArray, []bool{true})).
+		WithProperty("NumberFormat", intlServiceType(numberFormatType)).
+		WithProperty("DateTimeFormat", intlServiceType(dateTimeFormatType)).
+		WithProperty("Collator", intlServiceType(collatorType)).
+		WithProperty("PluralRules", intlServiceType(pluralRulesType)).
+		WithProperty("RelativeTimeFormat", intlServiceType(relativeTimeFormatType)).
+		WithProperty("Segmenter", intlServiceType(segmenterType))
+
+	return ctx.DefineGlobal("Intl", intlType)
+}
+
+func (i *IntlInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	intlObj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	if vmInstance.SymbolToStringTag.Type() == vm.TypeSymbol {
+		w, e, c := false, false, true
+		intlObj.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolToStringTag), vm.NewString("Intl"), &w, &e, &c)
+	}
+
+	intlObj.SetOwnNonEnumerable("getCanonicalLocales", vm.NewNativeFunction(1, false, "getCanonicalLocales", func(args []vm.Value) (vm.Value, error) {
+		locales, err := canonicalizeLocaleList(vmInstance, argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return stringsToArray(locales), nil
+	}))
+
+	intlObj.SetOwnNonEnumerable("NumberFormat", newIntlService(vmInstance, "NumberFormat", intlCommonLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		nf, err := newNumberFormat(vmInstance, tag, opts)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createNumberFormatObject(vmInstance, nf, proto), nil
+	}))
+	intlObj.SetOwnNonEnumerable("DateTimeFormat", newIntlService(vmInstance, "DateTimeFormat", intlDateLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		df, err := newDateTimeFormat(vmInstance, tag, opts, "any", "date")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createDateTimeFormatObject(vmInstance, df, proto), nil
+	}))
+	intlObj.SetOwnNonEnumerable("Collator", newIntlService(vmInstance, "Collator", intlCommonLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		col, err := newCollator(vmInstance, tag, opts)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createCollatorObject(vmInstance, col, proto), nil
+	}))
+	intlObj.SetOwnNonEnumerable("PluralRules", newIntlService(vmInstance, "PluralRules", intlCommonLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		if !vmInstance.IsConstructorCall() {
+			return vm.Undefined, vmInstance.NewTypeError("Constructor Intl.PluralRules requires 'new'")
+		}
+		pr, err := newPluralRules(vmInstance, tag, opts)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createPluralRulesObject(vmInstance, pr, proto), nil
+	}))
+	intlObj.SetOwnNonEnumerable("RelativeTimeFormat", newIntlService(vmInstance, "RelativeTimeFormat", intlDateLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		if !vmInstance.IsConstructorCall() {
+			return vm.Undefined, vmInstance.NewTypeError("Constructor Intl.RelativeTimeFormat requires 'new'")
+		}
+		rtf, err := newRelativeTimeFormat(vmInstance, tag, opts)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createRelativeTimeFormatObject(vmInstance, rtf, proto), nil
+	}))
+	intlObj.SetOwnNonEnumerable("Segmenter", newIntlService(vmInstance, "Segmenter", intlAnyLocale, func(tag language.Tag, opts intlOptions, proto *vm.PlainObject) (vm.Value, error) {
+		if !vmInstance.IsConstructorCall() {
+			return vm.Undefined, vmInstance.NewTypeError("Constructor Intl.Segmenter requires 'new'")
+		}
+		seg, err := newSegmenter(vmInstance, tag, opts)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createSegmenterObject(vmInstance, seg, proto), nil
+	}))
+
+	return ctx.DefineGlobal("Intl", vm.NewValueFromPlainObject(intlObj))
+}
+
+// newIntlService creates the constructor Intl.<name>, with its prototype and
+// supportedLocalesOf. create builds an instance for the negotiated locale;
+// available says which locales the service has data for.
+func newIntlService(vmInstance *vm.VM, name string, available func(language.Tag) bool, create func(language.Tag, intlOptions, *vm.PlainObject) (vm.Value, error)) vm.Value {
+	proto := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	if vmInstance.SymbolToStringTag.Type() == vm.TypeSymbol {
+		w, e, c := false, false, true
+		proto.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolToStringTag), vm.NewString("Intl."+name), &w, &e, &c)
+	}
+
+	ctor := vm.NewConstructorWithProps(0, false, name, func(args []vm.Value) (vm.Value, error) {
+		locales, err := canonicalizeLocaleList(vmInstance, argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		opts, err := newIntlOptions(vmInstance, argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return create(resolveLocale(locales, available), opts, proto)
+	})
+	if ctorProps := ctor.AsNativeFunctionWithProps(); ctorProps != nil {
+		ctorProps.Properties.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(proto))
+		ctorProps.Properties.SetOwnNonEnumerable("supportedLocalesOf", vm.NewNativeFunction(1, false, "supportedLocalesOf", func(args []vm.Value) (vm.Value, error) {
+			locales, err := canonicalizeLocaleList(vmInstance, argOrUndefined(args, 0))
+			if err != nil {
+				return vm.Undefined, err
+			}
+			opts, err := newIntlOptions(vmInstance, argOrUndefined(args, 1))
+			if err != nil {
+				return vm.Undefined, err
+			}
+			if _, err := opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+				return vm.Undefined, err
+			}
+			supported := []string{}
+			for _, locale := range locales {
+				if tag, err := language.Parse(locale); err == nil && available(tag) {
+					supported = append(supported, locale)
+				}
+			}
+			return stringsToArray(supported), nil
+		}))
+	}
+	proto.SetOwnNonEnumerable("constructor", ctor)
+	return ctor
+}
+
+// canonicalizeLocaleList implements CanonicalizeLocaleList: locales is
+// undefined, a locale string, or a list of them
+func canonicalizeLocaleList(vmInstance *vm.VM, locales vm.Value) ([]string, error) {
+	if locales.Type() == vm.TypeUndefined {
+		return nil, nil
+	}
+	var list []vm.Value
+	switch {
+	case locales.Type() == vm.TypeString:
+		list = []vm.Value{locales}
+	case locales.Type() == vm.TypeNull:
+		return nil, vmInstance.NewTypeError("Cannot convert null to a list of locales")
+	case locales.Type() == vm.TypeArray:
+		arr := locales.AsArray()
+		for i := 0; i < arr.Length(); i++ {
+			list = append(list, arr.Get(i))
+		}
+	case locales.IsObject():
+		lengthVal, err := vmInstance.GetProperty(locales, "length")
+		if err != nil {
+			return nil, err
+		}
+		n := int(vmInstance.ToNumber(lengthVal))
+		for i := 0; i < n; i++ {
+			v, err := vmInstance.GetProperty(locales, vm.NumberValue(float64(i)).ToString())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	}
+
+	var result []string
+	seen := map[string]bool{}
+	for _, v := range list {
+		if v.Type() != vm.TypeString && !v.IsObject() {
+			return nil, vmInstance.NewTypeError("Language ID should be a string or object")
+		}
+		tag, err := language.Parse(v.ToString())
+		canonical := tag.String()
+		var unknown language.ValueError
+		if errors.As(err, &unknown) {
+			// Well-formed tags x/text doesn't know are kept, but no service
+			// will find them available
+			canonical = canonicalizeTagCase(v.ToString())
+		} else if err != nil || strings.Contains(v.ToString(), "_") {
+			// x/text also takes "_" as a separator, which BCP 47 doesn't
+			return nil, vmInstance.NewRangeError("Incorrect locale information provided: " + v.ToString())
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			result = append(result, canonical)
+		}
+	}
+	return result, nil
+}
+
+// canonicalizeTagCase applies BCP 47's case conventions to a tag: lower case,
+// with title-case scripts and upper-case regions
+func canonicalizeTagCase(locale string) string {
+	subtags := strings.Split(strings.ToLower(locale), "-")
+	for i := 1; i < len(subtags); i++ {
+		if len(subtags[i]) == 1 {
+			break // extensions and private use stay lower case
+		}
+		switch {
+		case len(subtags[i]) == 2:
+			subtags[i] = strings.ToUpper(subtags[i])
+		case len(subtags[i]) == 4 && i == 1:
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+		}
+	}
+	return strings.Join(subtags, "-")
+}
+
+// resolveLocale picks the first requested locale available to a service,
+// without its Unicode extensions, falling back to the default locale
+func resolveLocale(requested []string, available func(language.Tag) bool) language.Tag {
+	for _, locale := range requested {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			continue
+		}
+		if available(tag) {
+			if stripped, err := tag.SetTypeForKey("", ""); err == nil {
+				tag = stripped
+			}
+			if base, err := language.Compose(tag.Raw()); err == nil {
+				return base
+			}
+			return tag
+		}
+	}
+	return language.MustParse(intlDefaultLocale)
+}
+
+var (
+	intlLanguagesOnce sync.Once
+	intlLanguages     map[language.Base]bool
+)
+
+// intlCommonLocale reports whether x/text has the data the number, collation
+// and plural services use for tag's language
+func intlCommonLocale(tag language.Tag) bool {
+	intlLanguagesOnce.Do(func() {
+		intlLanguages = map[language.Base]bool{}
+		for _, supported := range collate.Supported() {
+			base, _ := supported.Base()
+			intlLanguages[base] = true
+		}
+	})
+	base, confidence := tag.Base()
+	return confidence != language.No && intlLanguages[base]
+}
+
+// intlAnyLocale accepts every locale, for services that don't need locale data
+func intlAnyLocale(tag language.Tag) bool {
+	return tag != language.Und
+}
+
+// localeCase implements String.prototype.toLocaleLowerCase and
+// toLocaleUpperCase, which differ from the plain ones in languages like
+// Turkish and Lithuanian
+func localeCase(vmInstance *vm.VM, s string, locales vm.Value, upper bool) (string, error) {
+	requested, err := canonicalizeLocaleList(vmInstance, locales)
+	if err != nil {
+		return "", err
+	}
+	tag := resolveLocale(requested, intlAnyLocale)
+	if upper {
+		return cases.Upper(tag).String(s), nil
+	}
+	return cases.Lower(tag).String(s), nil
+}
+
+// intlOptions reads the options argument of an Intl constructor
+type intlOptions struct {
+	vm      *vm.VM
+	options vm.Value
+}
+
+func newIntlOptions(vmInstance *vm.VM, options vm.Value) (intlOptions, error) {
+	if options.Type() == vm.TypeNull {
+		return intlOptions{}, vmInstance.NewTypeError("Cannot convert null to object")
+	}
+	return intlOptions{vm: vmInstance, options: options}, nil
+}
+
+// get returns the option name, or undefined
+func (o intlOptions) get(name string) (vm.Value, error) {
+	if o.vm == nil || !o.options.IsObject() {
+		return vm.Undefined, nil
+	}
+	return o.vm.GetProperty(o.options, name)
+}
+
+// getString returns the string option name, one of allowed unless allowed is
+// nil, or fallback when it isn't set
+func (o intlOptions) getString(name string, allowed []string, fallback string) (string, error) {
+	v, err := o.get(name)
+	if err != nil || v.Type() == vm.TypeUndefined {
+		return fallback, err
+	}
+	s := v.ToString()
+	if allowed != nil && !containsString(allowed, s) {
+		return "", o.vm.NewRangeError("Value " + s + " out of range for option " + name)
+	}
+	return s, nil
+}
+
+// getBool returns the boolean option name and whether it was set
+func (o intlOptions) getBool(name string) (value bool, set bool, err error) {
+	v, err := o.get(name)
+	if err != nil || v.Type() == vm.TypeUndefined {
+		return false, false, err
+	}
+	return v.IsTruthy(), true, nil
+}
+
+// getNumber returns the integral option name in [min, max], and whether it was set
+func (o intlOptions) getNumber(name string, min, max int) (value int, set bool, err error) {
+	v, err := o.get(name)
+	if err != nil || v.Type() == vm.TypeUndefined {
+		return 0, false, err
+	}
+	f := o.vm.ToNumber(v)
+	if f != f || f < float64(min) || f > float64(max) {
+		return 0, false, o.vm.NewRangeError(name + " value is out of range")
+	}
+	return int(f), true, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func argOrUndefined(args []vm.Value, i int) vm.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return vm.Undefined
+}
+
+func stringsToArray(list []string) vm.Value {
+	arr := vm.NewArray()
+	for _, s := range list {
+		arr.AsArray().Append(vm.NewString(s))
+	}
+	return arr
+}
+
+// intlParts collects the {type, value} parts formatToParts methods return
+type intlParts []intlPart
+
+type intlPart struct {
+	typ, value string
+	unit       string // RelativeTimeFormat parts name the unit they format
+}
+
+func (p *intlParts) add(typ, value string) {
+	if value == "" {
+		return
+	}
+	if n := len(*p); n > 0 && typ == "literal" && (*p)[n-1].typ == "literal" {
+		(*p)[n-1].value += value
+		return
+	}
+	*p = append(*p, intlPart{typ: typ, value: value})
+}
+
+func (p intlParts) String() string {
+	var sb strings.Builder
+	for _, part := range p {
+		sb.WriteString(part.value)
+	}
+	return sb.String()
+}
+
+func (p intlParts) toArray(vmInstance *vm.VM) vm.Value {
+	arr := vm.NewArray()
+	for _, part := range p {
+		obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+		obj.SetOwn("type", vm.NewString(part.typ))
+		obj.SetOwn("value", vm.NewString(part.value))
+		if part.unit != "" {
+			obj.SetOwn("unit", vm.NewString(part.unit))
+		}
+		arr.AsArray().Append(vm.NewValueFromPlainObject(obj))
+	}
+	return arr
+}
+
+// resolvedOptionsObject builds a resolvedOptions() result from name/value
+// pairs, skipping undefined values
+func resolvedOptionsObject(vmInstance *vm.VM, pairs ...any) vm.Value {
+	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name := pairs[i].(string)
+		switch v := pairs[i+1].(type) {
+		case string:
+			if v != "" {
+				obj.SetOwn(name, vm.NewString(v))
+			}
+		case int:
+			obj.SetOwn(name, vm.NumberValue(float64(v)))
+		case bool:
+			obj.SetOwn(name, vm.BooleanValue(v))
+		case vm.Value:
+			if v.Type() != vm.TypeUndefined {
+				obj.SetOwn(name, v)
+			}
+		}
+	}
+	return vm.NewValueFromPlainObject(obj)
+}
+
+var (
+	intlLocalZoneOnce sync.Once
+	intlLocalZone     string
+)
+
+// intlDefaultTimeZone names the zone Date's local time is in, the way
+// resolvedOptions().timeZone reports it
+func intlDefaultTimeZone() string {
+	intlLocalZoneOnce.Do(func() {
+		intlLocalZone = "UTC"
+		if tz := os.Getenv("TZ"); tz != "" {
+			if _, err := time.LoadLocation(strings.TrimPrefix(tz, ":")); err == nil {
+				intlLocalZone = strings.TrimPrefix(tz, ":")
+			}
+			return
+		}
+		if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+			if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+				intlLocalZone = target[i+len("zoneinfo/"):]
+			}
+		}
+	})
+	return intlLocalZone
+}
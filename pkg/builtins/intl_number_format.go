@@ -0,0 +1,995 @@
+package builtins
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// intlDecimal is a non-negative decimal 0.d1d2d3... × 10^exp, the exact
+// value NumberFormat rounds and lays out. digits has no leading or trailing
+// zeros; zero has no digits.
+type intlDecimal struct {
+	digits []byte
+	exp    int
+}
+
+// newIntlDecimal converts |f| for finite f, using the shortest decimal that
+// round-trips, as ICU does
+func newIntlDecimal(f float64) intlDecimal {
+	if f == 0 {
+		return intlDecimal{}
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'e', -1, 64) // d.ddde±x
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	exp, _ := strconv.Atoi(exponent)
+	d := intlDecimal{digits: []byte(strings.Replace(mantissa, ".", "", 1)), exp: exp + 1}
+	d.trim()
+	return d
+}
+
+// newIntlDecimalFromBigInt converts |n|
+func newIntlDecimalFromBigInt(n *big.Int) intlDecimal {
+	s := new(big.Int).Abs(n).String()
+	d := intlDecimal{digits: []byte(s), exp: len(s)}
+	d.trim()
+	return d
+}
+
+func (d *intlDecimal) trim() {
+	for len(d.digits) > 0 && d.digits[len(d.digits)-1] == '0' {
+		d.digits = d.digits[:len(d.digits)-1]
+	}
+	if len(d.digits) == 0 {
+		d.exp = 0
+	}
+}
+
+func (d intlDecimal) isZero() bool {
+	return len(d.digits) == 0
+}
+
+// roundTo keeps the first n digits, rounding half away from zero
+func (d intlDecimal) roundTo(n int) intlDecimal {
+	if n >= len(d.digits) {
+		return d
+	}
+	if n < 0 {
+		return intlDecimal{}
+	}
+	roundUp := d.digits[n] >= '5'
+	digits := append([]byte(nil), d.digits[:n]...)
+	exp := d.exp
+	if roundUp {
+		i := len(digits) - 1
+		for ; i >= 0 && digits[i] == '9'; i-- {
+			digits[i] = '0'
+		}
+		if i >= 0 {
+			digits[i]++
+		} else {
+			digits = append([]byte{'1'}, digits...)
+			exp++
+		}
+	}
+	r := intlDecimal{digits: digits, exp: exp}
+	r.trim()
+	return r
+}
+
+// roundFraction rounds to maxFrac fraction digits
+func (d intlDecimal) roundFraction(maxFrac int) intlDecimal {
+	return d.roundTo(d.exp + maxFrac)
+}
+
+// integerDigits returns how many digits the integer part has
+func (d intlDecimal) integerDigits() int {
+	if d.exp < 1 {
+		return 1
+	}
+	return d.exp
+}
+
+// split lays d out as integer and fraction digit strings
+func (d intlDecimal) split() (integer, fraction string) {
+	if d.exp <= 0 {
+		return "0", strings.Repeat("0", -d.exp) + string(d.digits)
+	}
+	if d.exp >= len(d.digits) {
+		return string(d.digits) + strings.Repeat("0", d.exp-len(d.digits)), ""
+	}
+	return string(d.digits[:d.exp]), string(d.digits[d.exp:])
+}
+
+// numberSymbols are a locale's digits and separators, read off x/text's
+// formatting of sample numbers
+type numberSymbols struct {
+	digits         [10]string
+	decimal, group string
+	primaryGroup   int
+	secondaryGroup int
+	minus          string
+	percentPrefix  string
+	percentSuffix  string
+}
+
+var numberSymbolsCache sync.Map // language.Tag string -> *numberSymbols
+
+func getNumberSymbols(tag language.Tag) *numberSymbols {
+	if cached, ok := numberSymbolsCache.Load(tag.String()); ok {
+		return cached.(*numberSymbols)
+	}
+	p := message.NewPrinter(tag)
+	sym := &numberSymbols{decimal: ".", group: ",", primaryGroup: 3, secondaryGroup: 3, minus: "-"}
+	for i := range sym.digits {
+		sym.digits[i] = strconv.Itoa(i)
+	}
+
+	// Digits: 1234567890 without separators
+	if probe := []rune(p.Sprint(number.Decimal(1234567890, number.NoSeparator()))); len(probe) == 10 {
+		for i, r := range probe {
+			sym.digits[(i+1)%10] = string(r)
+		}
+	}
+
+	// Separators and grouping: 12345678.5
+	var runs []string
+	var separators []string
+	current, sep := "", ""
+	for _, r := range p.Sprint(number.Decimal(12345678.5, number.MinFractionDigits(1))) {
+		if unicode.IsDigit(r) {
+			if sep != "" {
+				separators = append(separators, sep)
+				runs = append(runs, current)
+				current, sep = "", ""
+			}
+			current += string(r)
+		} else if current != "" {
+			sep += string(r)
+		}
+	}
+	runs = append(runs, current)
+	if len(separators) >= 1 && len(runs) >= 2 {
+		sym.decimal = separators[len(separators)-1]
+		intRuns := runs[:len(runs)-1]
+		if len(separators) >= 2 {
+			sym.group = separators[0]
+			sym.primaryGroup = utf8.RuneCountInString(intRuns[len(intRuns)-1])
+			sym.secondaryGroup = sym.primaryGroup
+			if len(intRuns) >= 3 {
+				sym.secondaryGroup = utf8.RuneCountInString(intRuns[len(intRuns)-2])
+			}
+		}
+	}
+
+	// Minus sign: whatever precedes the 1 in -1
+	if probe := p.Sprint(number.Decimal(-1)); strings.HasSuffix(probe, sym.digits[1]) {
+		sym.minus = strings.TrimSuffix(probe, sym.digits[1])
+	}
+
+	// Percent pattern: around the 50 in 50%
+	probe := p.Sprint(number.Percent(0.5))
+	if i := strings.Index(probe, sym.digits[5]+sym.digits[0]); i >= 0 {
+		sym.percentPrefix = probe[:i]
+		sym.percentSuffix = probe[i+len(sym.digits[5]+sym.digits[0]):]
+	} else {
+		sym.percentSuffix = "%"
+	}
+
+	numberSymbolsCache.Store(tag.String(), sym)
+	return sym
+}
+
+// localizeDigits maps ASCII digits to the locale's
+func (s *numberSymbols) localizeDigits(ascii string) string {
+	if s.digits[0] == "0" {
+		return ascii
+	}
+	var sb strings.Builder
+	for _, r := range ascii {
+		sb.WriteString(s.digits[r-'0'])
+	}
+	return sb.String()
+}
+
+// addAffix adds a pattern affix such as " %" as literal and typed parts,
+// where sign is the character typ stands for
+func addAffix(parts *intlParts, affix, sign, typ string) {
+	if i := strings.Index(affix, sign); i >= 0 {
+		parts.add("literal", affix[:i])
+		parts.add(typ, affix[i:i+len(sign)])
+		parts.add("literal", affix[i+len(sign):])
+		return
+	}
+	parts.add("literal", affix)
+}
+
+// numberFormat is the state of an Intl.NumberFormat
+type numberFormat struct {
+	locale          language.Tag
+	symbols         *numberSymbols
+	style           string
+	currency        string
+	currencyDisplay string
+	currencySign    string
+	unit            string
+	unitDisplay     string
+	notation        string
+	compactDisplay  string
+	signDisplay     string
+	useGrouping     string
+
+	minimumIntegerDigits     int
+	minimumFractionDigits    int
+	maximumFractionDigits    int
+	minimumSignificantDigits int
+	maximumSignificantDigits int
+	roundingType             string // fractionDigits, significantDigits or compactRounding
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+func newNumberFormat(vmInstance *vm.VM, tag language.Tag, opts intlOptions) (*numberFormat, error) {
+	nf := &numberFormat{locale: tag, symbols: getNumberSymbols(tag)}
+	var err error
+	if _, err = opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if nf.style, err = opts.getString("style", []string{"decimal", "percent", "currency", "unit"}, "decimal"); err != nil {
+		return nil, err
+	}
+	if nf.currency, err = opts.getString("currency", nil, ""); err != nil {
+		return nil, err
+	}
+	if nf.currency != "" && !currencyCodePattern.MatchString(nf.currency) {
+		return nil, vmInstance.NewRangeError("Invalid currency code : " + nf.currency)
+	}
+	nf.currency = strings.ToUpper(nf.currency)
+	if nf.currencyDisplay, err = opts.getString("currencyDisplay", []string{"code", "symbol", "narrowSymbol", "name"}, "symbol"); err != nil {
+		return nil, err
+	}
+	if nf.currencySign, err = opts.getString("currencySign", []string{"standard", "accounting"}, "standard"); err != nil {
+		return nil, err
+	}
+	if nf.unit, err = opts.getString("unit", nil, ""); err != nil {
+		return nil, err
+	}
+	if nf.unitDisplay, err = opts.getString("unitDisplay", []string{"short", "narrow", "long"}, "short"); err != nil {
+		return nil, err
+	}
+	if nf.style == "currency" && nf.currency == "" {
+		return nil, vmInstance.NewTypeError("Currency code is required with currency style.")
+	}
+	if nf.style == "unit" {
+		if nf.unit == "" {
+			return nil, vmInstance.NewTypeError("Unit is required with unit style.")
+		}
+		if !isSanctionedUnit(nf.unit) {
+			return nil, vmInstance.NewRangeError("Invalid unit argument for Intl.NumberFormat() '" + nf.unit + "'")
+		}
+	}
+	if nf.style != "currency" {
+		nf.currency, nf.currencyDisplay, nf.currencySign = "", "", ""
+	}
+	if nf.style != "unit" {
+		nf.unit, nf.unitDisplay = "", ""
+	}
+	if nf.notation, err = opts.getString("notation", []string{"standard", "scientific", "engineering", "compact"}, "standard"); err != nil {
+		return nil, err
+	}
+
+	minFracDefault, maxFracDefault := 0, 3
+	if nf.style == "currency" {
+		minFracDefault = currencyDigits(nf.currency)
+		maxFracDefault = minFracDefault
+	} else if nf.style == "percent" {
+		maxFracDefault = 0
+	}
+	if err := nf.setDigitOptions(vmInstance, opts, minFracDefault, maxFracDefault); err != nil {
+		return nil, err
+	}
+
+	if nf.compactDisplay, err = opts.getString("compactDisplay", []string{"short", "long"}, "short"); err != nil {
+		return nil, err
+	}
+	if nf.notation != "compact" {
+		nf.compactDisplay = ""
+	}
+	groupingDefault := "auto"
+	if nf.notation == "compact" {
+		groupingDefault = "min2"
+	}
+	grouping, err := opts.get("useGrouping")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case grouping.Type() == vm.TypeUndefined:
+		nf.useGrouping = groupingDefault
+	case grouping.Type() == vm.TypeBoolean:
+		nf.useGrouping = "false"
+		if grouping.AsBoolean() {
+			nf.useGrouping = "always"
+		}
+	case grouping.Type() == vm.TypeString && containsString([]string{"min2", "auto", "always"}, grouping.ToString()):
+		nf.useGrouping = grouping.ToString()
+	case grouping.Type() == vm.TypeString && (grouping.ToString() == "true" || grouping.ToString() == "false"):
+		nf.useGrouping = groupingDefault
+	case !grouping.IsTruthy():
+		nf.useGrouping = "false"
+	default:
+		return nil, vmInstance.NewRangeError("Value " + grouping.ToString() + " out of range for option useGrouping")
+	}
+	if nf.signDisplay, err = opts.getString("signDisplay", []string{"auto", "never", "always", "exceptZero", "negative"}, "auto"); err != nil {
+		return nil, err
+	}
+	return nf, nil
+}
+
+// setDigitOptions implements SetNumberFormatDigitOptions
+func (nf *numberFormat) setDigitOptions(vmInstance *vm.VM, opts intlOptions, minFracDefault, maxFracDefault int) error {
+	minInt, _, err := opts.getNumber("minimumIntegerDigits", 1, 21)
+	if err != nil {
+		return err
+	}
+	if minInt == 0 {
+		minInt = 1
+	}
+	nf.minimumIntegerDigits = minInt
+
+	minFrac, hasMinFrac, err := opts.getNumber("minimumFractionDigits", 0, 100)
+	if err != nil {
+		return err
+	}
+	maxFrac, hasMaxFrac, err := opts.getNumber("maximumFractionDigits", 0, 100)
+	if err != nil {
+		return err
+	}
+	minSig, hasMinSig, err := opts.getNumber("minimumSignificantDigits", 1, 21)
+	if err != nil {
+		return err
+	}
+	maxSig, hasMaxSig, err := opts.getNumber("maximumSignificantDigits", 1, 21)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case hasMinSig || hasMaxSig:
+		nf.roundingType = "significantDigits"
+		if !hasMinSig {
+			minSig = 1
+		}
+		if !hasMaxSig {
+			maxSig = 21
+		}
+		if minSig > maxSig {
+			return vmInstance.NewRangeError("maximumSignificantDigits value is out of range")
+		}
+		nf.minimumSignificantDigits, nf.maximumSignificantDigits = minSig, maxSig
+	case hasMinFrac || hasMaxFrac || nf.notation != "compact":
+		nf.roundingType = "fractionDigits"
+		switch {
+		case !hasMinFrac && !hasMaxFrac:
+			minFrac, maxFrac = minFracDefault, maxFracDefault
+		case !hasMinFrac:
+			minFrac = min(minFracDefault, maxFrac)
+		case !hasMaxFrac:
+			maxFrac = max(maxFracDefault, minFrac)
+		case minFrac > maxFrac:
+			return vmInstance.NewRangeError("maximumFractionDigits value is out of range")
+		}
+		nf.minimumFractionDigits, nf.maximumFractionDigits = minFrac, maxFrac
+	default:
+		nf.roundingType = "compactRounding"
+		nf.minimumFractionDigits, nf.maximumFractionDigits = 0, 0
+	}
+	return nil
+}
+
+// round applies the digit options to d
+func (nf *numberFormat) round(d intlDecimal) intlDecimal {
+	switch nf.roundingType {
+	case "significantDigits":
+		return d.roundTo(nf.maximumSignificantDigits)
+	case "compactRounding":
+		if d.integerDigits() < 2 {
+			return d.roundTo(2)
+		}
+		return d.roundFraction(0)
+	default:
+		return d.roundFraction(nf.maximumFractionDigits)
+	}
+}
+
+// formatNumber formats the number or BigInt x into parts
+func (nf *numberFormat) formatNumber(x vm.Value) intlParts {
+	if x.Type() == vm.TypeBigInt {
+		n := x.AsBigInt()
+		return nf.formatDecimal(newIntlDecimalFromBigInt(n), n.Sign() < 0)
+	}
+	f := x.ToFloat()
+	switch {
+	case math.IsNaN(f):
+		return nf.formatSpecial("nan", "NaN", false)
+	case math.IsInf(f, 0):
+		return nf.formatSpecial("infinity", "∞", f < 0)
+	}
+	return nf.formatDecimal(newIntlDecimal(f), math.Signbit(f))
+}
+
+// formatSpecial formats NaN and the infinities
+func (nf *numberFormat) formatSpecial(typ, value string, negative bool) intlParts {
+	var number intlParts
+	number.add(typ, value)
+	return nf.decorate(number, negative, typ == "nan", false)
+}
+
+// formatDecimal formats |value| into parts, signed by negative
+func (nf *numberFormat) formatDecimal(value intlDecimal, negative bool) intlParts {
+	if nf.style == "percent" && !value.isZero() {
+		value.exp += 2
+	}
+
+	var number intlParts
+	switch nf.notation {
+	case "scientific", "engineering":
+		exponent := 0
+		if !value.isZero() {
+			step := 1
+			if nf.notation == "engineering" {
+				step = 3
+			}
+			exponent = int(math.Floor(float64(value.exp-1)/float64(step))) * step
+			value.exp -= exponent
+			value = nf.round(value)
+			// Rounding may carry out of the mantissa: 9.9996E0 is 1E1
+			if value.exp > step {
+				value.exp -= step
+				exponent += step
+			}
+		}
+		nf.layoutDigits(&number, value)
+		number.add("exponentSeparator", "E")
+		if exponent < 0 {
+			number.add("exponentMinusSign", nf.symbols.minus)
+			exponent = -exponent
+		}
+		number.add("exponentInteger", nf.symbols.localizeDigits(strconv.Itoa(exponent)))
+	case "compact":
+		nf.layoutCompact(&number, value)
+	default:
+		nf.layoutDigits(&number, nf.round(value))
+	}
+
+	zero := true
+	for _, part := range number {
+		if (part.typ == "integer" || part.typ == "fraction") && strings.Trim(part.value, nf.symbols.digits[0]) != "" {
+			zero = false
+		}
+	}
+	return nf.decorate(number, negative, false, zero)
+}
+
+// compactPattern is how a locale abbreviates numbers from 10^power
+type compactPattern struct {
+	power                int
+	short, long, longOne string
+}
+
+// compactPatterns are the compact notation data for the locales that have
+// them; the others use English
+var compactPatterns = map[string][]compactPattern{
+	"en": {
+		{3, "K", " thousand", ""},
+		{6, "M", " million", ""},
+		{9, "B", " billion", ""},
+		{12, "T", " trillion", ""},
+	},
+	"de": {
+		{3, "", " Tausend", ""},
+		{6, " Mio.", " Millionen", " Million"},
+		{9, " Mrd.", " Milliarden", " Milliarde"},
+		{12, " Bio.", " Billionen", " Billion"},
+	},
+	"fr": {
+		{3, " k", " mille", ""},
+		{6, " M", " millions", " million"},
+		{9, " Md", " milliards", " milliard"},
+		{12, " Bn", " billions", " billion"},
+	},
+	"es": {
+		{3, " mil", " mil", ""},
+		{6, " M", " millones", " millón"},
+		{9, " mil M", " mil millones", ""},
+		{12, " B", " billones", " billón"},
+	},
+	"ja": {
+		{4, "万", "万", ""},
+		{8, "億", "億", ""},
+		{12, "兆", "兆", ""},
+	},
+}
+
+// layoutCompact lays value out in compact notation
+func (nf *numberFormat) layoutCompact(parts *intlParts, value intlDecimal) {
+	base, _ := nf.locale.Base()
+	patterns, ok := compactPatterns[base.String()]
+	if !ok {
+		patterns = compactPatterns["en"]
+	}
+
+	pick := func(v intlDecimal) *compactPattern {
+		var chosen *compactPattern
+		for i := range patterns {
+			if !v.isZero() && v.exp-1 >= patterns[i].power {
+				chosen = &patterns[i]
+			}
+		}
+		return chosen
+	}
+	scale := func(v intlDecimal, pattern *compactPattern, sign int) intlDecimal {
+		if pattern != nil && !v.isZero() {
+			v.exp -= sign * pattern.power
+		}
+		return v
+	}
+
+	pattern := pick(value)
+	scaled := nf.round(scale(value, pattern, 1))
+	// Rounding may carry into the next abbreviation: 999.95K is 1M
+	if next := pick(scale(scaled, pattern, -1)); next != pattern {
+		pattern = next
+		scaled = nf.round(scale(value, pattern, 1))
+	}
+
+	nf.layoutDigits(parts, scaled)
+	if pattern == nil {
+		return
+	}
+	suffix := pattern.short
+	if nf.compactDisplay == "long" {
+		suffix = pattern.long
+		if pattern.longOne != "" && nf.pluralForm(scaled) == plural.One {
+			suffix = pattern.longOne
+		}
+	}
+	if suffix == "" {
+		// The locale doesn't abbreviate this magnitude
+		*parts = nil
+		nf.layoutDigits(parts, nf.round(value))
+		return
+	}
+	trimmed := strings.TrimLeft(suffix, "  ")
+	parts.add("literal", suffix[:len(suffix)-len(trimmed)])
+	parts.add("compact", trimmed)
+}
+
+// layoutDigits lays out the integer, group, decimal and fraction parts of a
+// rounded value
+func (nf *numberFormat) layoutDigits(parts *intlParts, value intlDecimal) {
+	integer, fraction := value.split()
+	if len(integer) < nf.minimumIntegerDigits {
+		integer = strings.Repeat("0", nf.minimumIntegerDigits-len(integer)) + integer
+	}
+	switch nf.roundingType {
+	case "significantDigits":
+		significant := len(value.digits)
+		if value.isZero() {
+			significant = 1
+		}
+		if significant < nf.minimumSignificantDigits {
+			fraction += strings.Repeat("0", nf.minimumSignificantDigits-significant)
+		}
+	default:
+		if len(fraction) < nf.minimumFractionDigits {
+			fraction += strings.Repeat("0", nf.minimumFractionDigits-len(fraction))
+		}
+	}
+
+	sym := nf.symbols
+	grouping := nf.useGrouping != "false"
+	if nf.useGrouping == "min2" && len(integer) < sym.primaryGroup+2 {
+		grouping = false
+	}
+	if grouping && len(integer) > sym.primaryGroup {
+		var groups []string
+		rest := integer
+		size := sym.primaryGroup
+		for len(rest) > size {
+			groups = append([]string{rest[len(rest)-size:]}, groups...)
+			rest = rest[:len(rest)-size]
+			size = sym.secondaryGroup
+		}
+		groups = append([]string{rest}, groups...)
+		for i, group := range groups {
+			if i > 0 {
+				parts.add("group", sym.group)
+			}
+			parts.add("integer", sym.localizeDigits(group))
+		}
+	} else {
+		parts.add("integer", sym.localizeDigits(integer))
+	}
+	if fraction != "" {
+		parts.add("decimal", sym.decimal)
+		parts.add("fraction", sym.localizeDigits(fraction))
+	}
+}
+
+// decorate adds the sign, and the percent sign, currency or unit around the
+// number parts
+func (nf *numberFormat) decorate(number intlParts, negative, isNaN, zero bool) intlParts {
+	sign := ""
+	switch nf.signDisplay {
+	case "auto":
+		if negative && !isNaN {
+			sign = "-"
+		}
+	case "always":
+		sign = "+"
+		if negative && !isNaN {
+			sign = "-"
+		}
+	case "exceptZero":
+		if !zero && !isNaN {
+			sign = "+"
+			if negative {
+				sign = "-"
+			}
+		}
+	case "negative":
+		if negative && !zero && !isNaN {
+			sign = "-"
+		}
+	}
+
+	accounting := nf.style == "currency" && nf.currencySign == "accounting" && sign == "-"
+	var parts intlParts
+	if accounting {
+		parts.add("literal", "(")
+	} else if sign == "-" {
+		addAffix(&parts, nf.symbols.minus, "-", "minusSign")
+	} else if sign == "+" {
+		addAffix(&parts, strings.Replace(nf.symbols.minus, "-", "+", 1), "+", "plusSign")
+	}
+
+	switch nf.style {
+	case "percent":
+		addAffix(&parts, nf.symbols.percentPrefix, "%", "percentSign")
+		parts = append(parts, number...)
+		addAffix(&parts, nf.symbols.percentSuffix, "%", "percentSign")
+	case "currency":
+		nf.addCurrency(&parts, number)
+	case "unit":
+		nf.addUnit(&parts, number)
+	default:
+		parts = append(parts, number...)
+	}
+
+	if accounting {
+		parts.add("literal", ")")
+	}
+	return parts
+}
+
+// currencySuffixLanguages put the currency after the amount
+var currencySuffixLanguages = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true, "es": true,
+	"et": true, "fi": true, "fr": true, "hr": true, "hu": true, "it": true, "lt": true,
+	"lv": true, "nb": true, "nn": true, "no": true, "pl": true, "ro": true, "ru": true,
+	"sk": true, "sl": true, "sr": true, "sv": true, "uk": true, "vi": true,
+}
+
+// currencyNames are the English names of common currencies, singular and plural
+var currencyNames = map[string][2]string{
+	"USD": {"US dollar", "US dollars"},
+	"EUR": {"euro", "euros"},
+	"GBP": {"British pound", "British pounds"},
+	"JPY": {"Japanese yen", "Japanese yen"},
+	"CNY": {"Chinese yuan", "Chinese yuan"},
+	"CHF": {"Swiss franc", "Swiss francs"},
+	"CAD": {"Canadian dollar", "Canadian dollars"},
+	"AUD": {"Australian dollar", "Australian dollars"},
+	"INR": {"Indian rupee", "Indian rupees"},
+	"PLN": {"Polish zloty", "Polish zlotys"},
+}
+
+// currencyDigits returns the number of fraction digits amounts in code have
+func currencyDigits(code string) int {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return 2
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	return scale
+}
+
+func (nf *numberFormat) addCurrency(parts *intlParts, number intlParts) {
+	symbol := nf.currency
+	if unit, err := currency.ParseISO(nf.currency); err == nil {
+		p := message.NewPrinter(nf.locale)
+		switch nf.currencyDisplay {
+		case "symbol":
+			symbol = p.Sprint(currency.Symbol(unit))
+		case "narrowSymbol":
+			symbol = p.Sprint(currency.NarrowSymbol(unit))
+		}
+	}
+
+	if nf.currencyDisplay == "name" {
+		*parts = append(*parts, number...)
+		name := nf.currency
+		if names, ok := currencyNames[nf.currency]; ok {
+			name = names[1]
+			if nf.pluralFormOfParts(number) == plural.One {
+				name = names[0]
+			}
+		}
+		parts.add("literal", " ")
+		parts.add("currency", name)
+		return
+	}
+
+	base, _ := nf.locale.Base()
+	_, region := nf.locale.Region()
+	letters := strings.IndexFunc(symbol, unicode.IsLetter) >= 0
+	if currencySuffixLanguages[base.String()] || (base.String() == "pt" && region.String() == "PT") {
+		*parts = append(*parts, number...)
+		parts.add("literal", " ")
+		parts.add("currency", symbol)
+		return
+	}
+	parts.add("currency", symbol)
+	if letters && !strings.HasSuffix(symbol, ".") || base.String() == "nl" || base.String() == "pt" {
+		parts.add("literal", " ")
+	}
+	*parts = append(*parts, number...)
+}
+
+// units are the short, narrow and long (singular, plural) English names of
+// the sanctioned simple units
+var units = map[string][4]string{
+	"acre":              {" ac", "ac", " acre", " acres"},
+	"bit":               {" bit", "bit", " bit", " bits"},
+	"byte":              {" byte", "B", " byte", " bytes"},
+	"celsius":           {"°C", "°C", " degree Celsius", " degrees Celsius"},
+	"centimeter":        {" cm", "cm", " centimeter", " centimeters"},
+	"day":               {" day", "d", " day", " days"},
+	"degree":            {" deg", "°", " degree", " degrees"},
+	"fahrenheit":        {"°F", "°", " degree Fahrenheit", " degrees Fahrenheit"},
+	"fluid-ounce":       {" fl oz", "fl oz", " fluid ounce", " fluid ounces"},
+	"foot":              {" ft", "′", " foot", " feet"},
+	"gallon":            {" gal", "gal", " gallon", " gallons"},
+	"gigabit":           {" Gb", "Gb", " gigabit", " gigabits"},
+	"gigabyte":          {" GB", "GB", " gigabyte", " gigabytes"},
+	"gram":              {" g", "g", " gram", " grams"},
+	"hectare":           {" ha", "ha", " hectare", " hectares"},
+	"hour":              {" hr", "h", " hour", " hours"},
+	"inch":              {" in", "″", " inch", " inches"},
+	"kilobit":           {" kb", "kb", " kilobit", " kilobits"},
+	"kilobyte":          {" kB", "kB", " kilobyte", " kilobytes"},
+	"kilogram":          {" kg", "kg", " kilogram", " kilograms"},
+	"kilometer":         {" km", "km", " kilometer", " kilometers"},
+	"liter":             {" L", "L", " liter", " liters"},
+	"megabit":           {" Mb", "Mb", " megabit", " megabits"},
+	"megabyte":          {" MB", "MB", " megabyte", " megabytes"},
+	"meter":             {" m", "m", " meter", " meters"},
+	"microsecond":       {" μs", "μs", " microsecond", " microseconds"},
+	"mile":              {" mi", "mi", " mile", " miles"},
+	"mile-scandinavian": {" smi", "smi", " mile-scandinavian", " miles-scandinavian"},
+	"milliliter":        {" mL", "mL", " milliliter", " milliliters"},
+	"millimeter":        {" mm", "mm", " millimeter", " millimeters"},
+	"millisecond":       {" ms", "ms", " millisecond", " milliseconds"},
+	"minute":            {" min", "m", " minute", " minutes"},
+	"month":             {" mth", "m", " month", " months"},
+	"nanosecond":        {" ns", "ns", " nanosecond", " nanoseconds"},
+	"ounce":             {" oz", "oz", " ounce", " ounces"},
+	"percent":           {"%", "%", " percent", " percent"},
+	"petabyte":          {" PB", "PB", " petabyte", " petabytes"},
+	"pound":             {" lb", "lb", " pound", " pounds"},
+	"second":            {" sec", "s", " second", " seconds"},
+	"stone":             {" st", "st", " stone", " stones"},
+	"terabit":           {" Tb", "Tb", " terabit", " terabits"},
+	"terabyte":          {" TB", "TB", " terabyte", " terabytes"},
+	"week":              {" wk", "w", " week", " weeks"},
+	"yard":              {" yd", "yd", " yard", " yards"},
+	"year":              {" yr", "y", " year", " years"},
+}
+
+// compoundUnits have their own short names
+var compoundUnits = map[string]string{
+	"kilometer-per-hour":  " km/h",
+	"mile-per-hour":       " mph",
+	"meter-per-second":    " m/s",
+	"liter-per-kilometer": " L/km",
+	"mile-per-gallon":     " mpg",
+}
+
+// isSanctionedUnit reports whether unit is a simple unit, or two joined by -per-
+func isSanctionedUnit(unit string) bool {
+	if _, ok := units[unit]; ok {
+		return true
+	}
+	numerator, denominator, ok := strings.Cut(unit, "-per-")
+	_, numeratorOK := units[numerator]
+	_, denominatorOK := units[denominator]
+	return ok && numeratorOK && denominatorOK
+}
+
+func (nf *numberFormat) addUnit(parts *intlParts, number intlParts) {
+	*parts = append(*parts, number...)
+	one := nf.pluralFormOfParts(number) == plural.One
+
+	name := func(unit string) string {
+		names := units[unit]
+		switch nf.unitDisplay {
+		case "narrow":
+			return names[1]
+		case "long":
+			if one {
+				return names[2]
+			}
+			return names[3]
+		}
+		if unit == "day" && !one {
+			return " days"
+		}
+		return names[0]
+	}
+
+	var suffix string
+	if numerator, denominator, ok := strings.Cut(nf.unit, "-per-"); ok {
+		if short, ok := compoundUnits[nf.unit]; ok && nf.unitDisplay != "long" {
+			suffix = short
+		} else if nf.unitDisplay == "long" {
+			suffix = name(numerator) + " per" + units[denominator][2]
+		} else {
+			suffix = name(numerator) + "/" + strings.TrimSpace(units[denominator][0])
+		}
+	} else {
+		suffix = name(nf.unit)
+	}
+	trimmed := strings.TrimLeft(suffix, " ")
+	parts.add("literal", suffix[:len(suffix)-len(trimmed)])
+	parts.add("unit", trimmed)
+}
+
+// pluralForm returns the cardinal plural category of the rounded value
+func (nf *numberFormat) pluralForm(value intlDecimal) plural.Form {
+	integer, fraction := value.split()
+	return pluralFormOf(nf.locale, plural.Cardinal, integer, fraction)
+}
+
+// pluralFormOfParts returns the plural category of already formatted digits
+func (nf *numberFormat) pluralFormOfParts(number intlParts) plural.Form {
+	var integer, fraction string
+	for _, part := range number {
+		switch part.typ {
+		case "integer":
+			integer += part.value
+		case "fraction":
+			fraction += part.value
+		}
+	}
+	delocalize := func(s string) string {
+		for i, d := range nf.symbols.digits {
+			s = strings.ReplaceAll(s, d, strconv.Itoa(i))
+		}
+		return s
+	}
+	return pluralFormOf(nf.locale, plural.Cardinal, delocalize(integer), delocalize(fraction))
+}
+
+// pluralFormOf matches the plural rules of tag against the decimal with the
+// given integer and visible fraction digits
+func pluralFormOf(tag language.Tag, rules *plural.Rules, integer, fraction string) plural.Form {
+	i, err := strconv.Atoi(integer)
+	if err != nil || len(integer) > 9 {
+		i = 1000000 // only the last digits matter to the rules, and "many"
+	}
+	trimmed := strings.TrimRight(fraction, "0")
+	f, _ := strconv.Atoi(fraction)
+	t, _ := strconv.Atoi(trimmed)
+	if len(fraction) > 9 {
+		f, t = 1, 1
+	}
+	return rules.MatchPlural(tag, i, len(fraction), len(trimmed), f, t)
+}
+
+func (nf *numberFormat) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	pairs := []any{
+		"locale", nf.locale.String(),
+		"numberingSystem", "latn",
+		"style", nf.style,
+		"currency", nf.currency,
+		"currencyDisplay", nf.currencyDisplay,
+		"currencySign", nf.currencySign,
+		"unit", nf.unit,
+		"unitDisplay", nf.unitDisplay,
+		"minimumIntegerDigits", nf.minimumIntegerDigits,
+	}
+	if nf.roundingType == "significantDigits" {
+		pairs = append(pairs, "minimumSignificantDigits", nf.minimumSignificantDigits, "maximumSignificantDigits", nf.maximumSignificantDigits)
+	} else {
+		pairs = append(pairs, "minimumFractionDigits", nf.minimumFractionDigits, "maximumFractionDigits", nf.maximumFractionDigits)
+	}
+	var grouping vm.Value = vm.NewString(nf.useGrouping)
+	if nf.useGrouping == "false" {
+		grouping = vm.BooleanValue(false)
+	}
+	pairs = append(pairs,
+		"useGrouping", grouping,
+		"notation", nf.notation,
+		"compactDisplay", nf.compactDisplay,
+		"signDisplay", nf.signDisplay,
+		"roundingMode", "halfExpand",
+	)
+	return resolvedOptionsObject(vmInstance, pairs...)
+}
+
+// toIntlNumeric converts a format argument to a number or BigInt
+func toIntlNumeric(vmInstance *vm.VM, x vm.Value) vm.Value {
+	if x.Type() == vm.TypeBigInt {
+		return x
+	}
+	if x.IsObject() {
+		x = vmInstance.ToPrimitive(x, "number")
+		if x.Type() == vm.TypeBigInt {
+			return x
+		}
+	}
+	if x.Type() == vm.TypeString {
+		s := strings.TrimSpace(x.ToString())
+		if n, ok := new(big.Int).SetString(s, 10); ok && len(s) > 15 {
+			return vm.NewBigInt(n)
+		}
+	}
+	return vm.NumberValue(vmInstance.ToNumber(x))
+}
+
+func createNumberFormatObject(vmInstance *vm.VM, nf *numberFormat, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+
+	// format is bound to the instance, so it can be passed around
+	obj.SetOwnNonEnumerable("format", vm.NewNativeFunction(1, false, "format", func(args []vm.Value) (vm.Value, error) {
+		return vm.NewString(nf.formatNumber(toIntlNumeric(vmInstance, argOrUndefined(args, 0))).String()), nil
+	}))
+	obj.SetOwnNonEnumerable("formatToParts", vm.NewNativeFunction(1, false, "formatToParts", func(args []vm.Value) (vm.Value, error) {
+		return nf.formatNumber(toIntlNumeric(vmInstance, argOrUndefined(args, 0))).toArray(vmInstance), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return nf.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// formatNumberToLocaleString implements Number.prototype.toLocaleString and
+// BigInt.prototype.toLocaleString
+func formatNumberToLocaleString(vmInstance *vm.VM, x vm.Value, locales, options vm.Value) (vm.Value, error) {
+	requested, err := canonicalizeLocaleList(vmInstance, locales)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	opts, err := newIntlOptions(vmInstance, options)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	nf, err := newNumberFormat(vmInstance, resolveLocale(requested, intlCommonLocale), opts)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	return vm.NewString(nf.formatNumber(x).String()), nil
+}
@@ -0,0 +1,134 @@
+package builtins
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// pluralCategoryNames are the plural.Form names, in the order
+// resolvedOptions().pluralCategories lists them
+var pluralCategoryNames = []struct {
+	form plural.Form
+	name string
+}{
+	{plural.Zero, "zero"},
+	{plural.One, "one"},
+	{plural.Two, "two"},
+	{plural.Few, "few"},
+	{plural.Many, "many"},
+	{plural.Other, "other"},
+}
+
+func pluralCategoryName(form plural.Form) string {
+	for _, category := range pluralCategoryNames {
+		if category.form == form {
+			return category.name
+		}
+	}
+	return "other"
+}
+
+// pluralRules is the state of an Intl.PluralRules. Numbers are formatted with
+// its digit options before their category is picked, so 1.0 with
+// minimumFractionDigits: 1 is "other" in English.
+type pluralRules struct {
+	locale language.Tag
+	typ    string
+	rules  *plural.Rules
+	digits *numberFormat
+}
+
+func newPluralRules(vmInstance *vm.VM, tag language.Tag, opts intlOptions) (*pluralRules, error) {
+	pr := &pluralRules{locale: tag, rules: plural.Cardinal}
+	var err error
+	if _, err = opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if pr.typ, err = opts.getString("type", []string{"cardinal", "ordinal"}, "cardinal"); err != nil {
+		return nil, err
+	}
+	if pr.typ == "ordinal" {
+		pr.rules = plural.Ordinal
+	}
+	pr.digits = &numberFormat{locale: tag, symbols: getNumberSymbols(tag), notation: "standard"}
+	if err := pr.digits.setDigitOptions(vmInstance, opts, 0, 3); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// selectCategory returns the plural category of n
+func (pr *pluralRules) selectCategory(n float64) string {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return "other"
+	}
+	value := pr.digits.round(newIntlDecimal(n))
+	integer, fraction := value.split()
+	switch pr.digits.roundingType {
+	case "significantDigits":
+		for significant := max(len(value.digits), 1); significant < pr.digits.minimumSignificantDigits; significant++ {
+			fraction += "0"
+		}
+	default:
+		for len(fraction) < pr.digits.minimumFractionDigits {
+			fraction += "0"
+		}
+	}
+	return pluralCategoryName(pluralFormOf(pr.locale, pr.rules, integer, fraction))
+}
+
+// categories returns the plural categories the locale uses, found by
+// matching a spread of integers and decimals against its rules
+func (pr *pluralRules) categories() []string {
+	seen := map[plural.Form]bool{}
+	for i := 0; i <= 200; i++ {
+		seen[pluralFormOf(pr.locale, pr.rules, strconv.Itoa(i), "")] = true
+	}
+	for _, n := range []int{1000, 10000, 100000, 1000000} {
+		seen[pluralFormOf(pr.locale, pr.rules, strconv.Itoa(n), "")] = true
+	}
+	if pr.typ == "cardinal" {
+		for _, fraction := range []string{"0", "1", "2", "5", "00", "01", "10"} {
+			for _, integer := range []string{"0", "1", "2", "5"} {
+				seen[pluralFormOf(pr.locale, pr.rules, integer, fraction)] = true
+			}
+		}
+	}
+	var categories []string
+	for _, category := range pluralCategoryNames {
+		if seen[category.form] {
+			categories = append(categories, category.name)
+		}
+	}
+	return categories
+}
+
+func (pr *pluralRules) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	pairs := []any{
+		"locale", pr.locale.String(),
+		"type", pr.typ,
+		"minimumIntegerDigits", pr.digits.minimumIntegerDigits,
+	}
+	if pr.digits.roundingType == "significantDigits" {
+		pairs = append(pairs, "minimumSignificantDigits", pr.digits.minimumSignificantDigits, "maximumSignificantDigits", pr.digits.maximumSignificantDigits)
+	} else {
+		pairs = append(pairs, "minimumFractionDigits", pr.digits.minimumFractionDigits, "maximumFractionDigits", pr.digits.maximumFractionDigits)
+	}
+	pairs = append(pairs, "pluralCategories", stringsToArray(pr.categories()), "roundingMode", "halfExpand")
+	return resolvedOptionsObject(vmInstance, pairs...)
+}
+
+func createPluralRulesObject(vmInstance *vm.VM, pr *pluralRules, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetOwnNonEnumerable("select", vm.NewNativeFunction(1, false, "select", func(args []vm.Value) (vm.Value, error) {
+		return vm.NewString(pr.selectCategory(vmInstance.ToNumber(argOrUndefined(args, 0)))), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return pr.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
@@ -0,0 +1,236 @@
+package builtins
+
+import (
+	"math"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+var relativeTimeUnits = []string{"year", "quarter", "month", "week", "day", "hour", "minute", "second"}
+
+// relativeTimeLocale is the data RelativeTimeFormat has for a locale. Unit
+// names are [one, other]; locales without short names use the long ones.
+type relativeTimeLocale struct {
+	future, past string // templates over {0}, the number, and {unit}
+	long, short  map[string][2]string
+	auto         map[string]map[int]string // numeric: "auto" phrases by unit and offset
+	// lastThisNext builds the phrases for units auto doesn't list from a
+	// unit name, when the locale has a regular form for them
+	lastThisNext func(name string) [3]string
+}
+
+var relativeTimeLocales = map[string]*relativeTimeLocale{
+	"en": {
+		future: "in {0} {unit}",
+		past:   "{0} {unit} ago",
+		long: map[string][2]string{
+			"year": {"year", "years"}, "quarter": {"quarter", "quarters"}, "month": {"month", "months"}, "week": {"week", "weeks"},
+			"day": {"day", "days"}, "hour": {"hour", "hours"}, "minute": {"minute", "minutes"}, "second": {"second", "seconds"},
+		},
+		short: map[string][2]string{
+			"year": {"yr.", "yr."}, "quarter": {"qtr.", "qtrs."}, "month": {"mo.", "mo."}, "week": {"wk.", "wk."},
+			"day": {"day", "days"}, "hour": {"hr.", "hr."}, "minute": {"min.", "min."}, "second": {"sec.", "sec."},
+		},
+		auto: map[string]map[int]string{
+			"day":    {-1: "yesterday", 0: "today", 1: "tomorrow"},
+			"hour":   {0: "this hour"},
+			"minute": {0: "this minute"},
+			"second": {0: "now"},
+		},
+		lastThisNext: func(name string) [3]string {
+			return [3]string{"last " + name, "this " + name, "next " + name}
+		},
+	},
+	"de": {
+		future: "in {0} {unit}",
+		past:   "vor {0} {unit}",
+		long: map[string][2]string{
+			"year": {"Jahr", "Jahren"}, "quarter": {"Quartal", "Quartalen"}, "month": {"Monat", "Monaten"}, "week": {"Woche", "Wochen"},
+			"day": {"Tag", "Tagen"}, "hour": {"Stunde", "Stunden"}, "minute": {"Minute", "Minuten"}, "second": {"Sekunde", "Sekunden"},
+		},
+		auto: map[string]map[int]string{
+			"year":   {-1: "letztes Jahr", 0: "dieses Jahr", 1: "nächstes Jahr"},
+			"month":  {-1: "letzten Monat", 0: "diesen Monat", 1: "nächsten Monat"},
+			"week":   {-1: "letzte Woche", 0: "diese Woche", 1: "nächste Woche"},
+			"day":    {-2: "vorgestern", -1: "gestern", 0: "heute", 1: "morgen", 2: "übermorgen"},
+			"second": {0: "jetzt"},
+		},
+	},
+	"fr": {
+		future: "dans {0} {unit}",
+		past:   "il y a {0} {unit}",
+		long: map[string][2]string{
+			"year": {"an", "ans"}, "quarter": {"trimestre", "trimestres"}, "month": {"mois", "mois"}, "week": {"semaine", "semaines"},
+			"day": {"jour", "jours"}, "hour": {"heure", "heures"}, "minute": {"minute", "minutes"}, "second": {"seconde", "secondes"},
+		},
+		auto: map[string]map[int]string{
+			"year":   {-1: "l’année dernière", 0: "cette année", 1: "l’année prochaine"},
+			"month":  {-1: "le mois dernier", 0: "ce mois-ci", 1: "le mois prochain"},
+			"week":   {-1: "la semaine dernière", 0: "cette semaine", 1: "la semaine prochaine"},
+			"day":    {-2: "avant-hier", -1: "hier", 0: "aujourd’hui", 1: "demain", 2: "après-demain"},
+			"second": {0: "maintenant"},
+		},
+	},
+	"es": {
+		future: "dentro de {0} {unit}",
+		past:   "hace {0} {unit}",
+		long: map[string][2]string{
+			"year": {"año", "años"}, "quarter": {"trimestre", "trimestres"}, "month": {"mes", "meses"}, "week": {"semana", "semanas"},
+			"day": {"día", "días"}, "hour": {"hora", "horas"}, "minute": {"minuto", "minutos"}, "second": {"segundo", "segundos"},
+		},
+		auto: map[string]map[int]string{
+			"year":   {-1: "el año pasado", 0: "este año", 1: "el próximo año"},
+			"month":  {-1: "el mes pasado", 0: "este mes", 1: "el próximo mes"},
+			"week":   {-1: "la semana pasada", 0: "esta semana", 1: "la próxima semana"},
+			"day":    {-2: "anteayer", -1: "ayer", 0: "hoy", 1: "mañana", 2: "pasado mañana"},
+			"second": {0: "ahora"},
+		},
+	},
+	"ja": {
+		future: "{0} {unit}後",
+		past:   "{0} {unit}前",
+		long: map[string][2]string{
+			"year": {"年", "年"}, "quarter": {"四半期", "四半期"}, "month": {"か月", "か月"}, "week": {"週間", "週間"},
+			"day": {"日", "日"}, "hour": {"時間", "時間"}, "minute": {"分", "分"}, "second": {"秒", "秒"},
+		},
+		auto: map[string]map[int]string{
+			"year":   {-1: "昨年", 0: "今年", 1: "来年"},
+			"month":  {-1: "先月", 0: "今月", 1: "来月"},
+			"week":   {-1: "先週", 0: "今週", 1: "来週"},
+			"day":    {-2: "一昨日", -1: "昨日", 0: "今日", 1: "明日", 2: "明後日"},
+			"second": {0: "今"},
+		},
+	},
+}
+
+// relativeTimeFormat is the state of an Intl.RelativeTimeFormat
+type relativeTimeFormat struct {
+	locale  language.Tag
+	data    *relativeTimeLocale
+	number  *numberFormat
+	style   string
+	numeric string
+}
+
+func newRelativeTimeFormat(vmInstance *vm.VM, tag language.Tag, opts intlOptions) (*relativeTimeFormat, error) {
+	base, _ := tag.Base()
+	data, ok := relativeTimeLocales[base.String()]
+	if !ok {
+		tag = language.MustParse(intlDefaultLocale)
+		data = relativeTimeLocales["en"]
+	}
+	rtf := &relativeTimeFormat{locale: tag, data: data}
+	var err error
+	if _, err = opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if _, err = opts.getString("numberingSystem", nil, ""); err != nil {
+		return nil, err
+	}
+	if rtf.style, err = opts.getString("style", []string{"long", "short", "narrow"}, "long"); err != nil {
+		return nil, err
+	}
+	if rtf.numeric, err = opts.getString("numeric", []string{"always", "auto"}, "always"); err != nil {
+		return nil, err
+	}
+	if rtf.number, err = newNumberFormat(vmInstance, tag, intlOptions{}); err != nil {
+		return nil, err
+	}
+	return rtf, nil
+}
+
+// singularUnit validates a format unit, accepting plurals like "days"
+func singularUnit(vmInstance *vm.VM, unit string) (string, error) {
+	singular := strings.TrimSuffix(unit, "s")
+	if !containsString(relativeTimeUnits, singular) {
+		return "", vmInstance.NewRangeError("Invalid unit argument for format() '" + unit + "'")
+	}
+	return singular, nil
+}
+
+// unitName returns the name of unit for the style and plural form
+func (rtf *relativeTimeFormat) unitName(unit string, form plural.Form) string {
+	names := rtf.data.long[unit]
+	if short, ok := rtf.data.short[unit]; ok && rtf.style != "long" {
+		names = short
+	}
+	if form == plural.One {
+		return names[0]
+	}
+	return names[1]
+}
+
+// format formats value units from now into parts
+func (rtf *relativeTimeFormat) format(vmInstance *vm.VM, value vm.Value, unitValue vm.Value) (intlParts, error) {
+	n := vmInstance.ToNumber(value)
+	unit, err := singularUnit(vmInstance, unitValue.ToString())
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return nil, vmInstance.NewRangeError("Invalid value " + value.ToString() + " for format()")
+	}
+
+	var parts intlParts
+	if rtf.numeric == "auto" && n == math.Trunc(n) && math.Abs(n) <= 2 {
+		if phrase, ok := rtf.data.auto[unit][int(n)]; ok {
+			parts.add("literal", phrase)
+			return parts, nil
+		}
+		if rtf.data.lastThisNext != nil && math.Abs(n) <= 1 && containsString([]string{"year", "quarter", "month", "week"}, unit) {
+			parts.add("literal", rtf.data.lastThisNext(rtf.unitName(unit, plural.One))[int(n)+1])
+			return parts, nil
+		}
+	}
+
+	number := rtf.number.formatNumber(vm.NumberValue(math.Abs(n)))
+	template := rtf.data.future
+	if math.Signbit(n) {
+		template = rtf.data.past
+	}
+	renderTemplate(&parts, template, func(name string) {
+		if name == "unit" {
+			parts.add("literal", rtf.unitName(unit, rtf.number.pluralFormOfParts(number)))
+			return
+		}
+		for _, part := range number {
+			part.unit = unit
+			parts = append(parts, part)
+		}
+	})
+	return parts, nil
+}
+
+func (rtf *relativeTimeFormat) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	return resolvedOptionsObject(vmInstance,
+		"locale", rtf.locale.String(),
+		"style", rtf.style,
+		"numeric", rtf.numeric,
+		"numberingSystem", "latn",
+	)
+}
+
+func createRelativeTimeFormatObject(vmInstance *vm.VM, rtf *relativeTimeFormat, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetOwnNonEnumerable("format", vm.NewNativeFunction(2, false, "format", func(args []vm.Value) (vm.Value, error) {
+		parts, err := rtf.format(vmInstance, argOrUndefined(args, 0), argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vm.NewString(parts.String()), nil
+	}))
+	obj.SetOwnNonEnumerable("formatToParts", vm.NewNativeFunction(2, false, "formatToParts", func(args []vm.Value) (vm.Value, error) {
+		parts, err := rtf.format(vmInstance, argOrUndefined(args, 0), argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return parts.toArray(vmInstance), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return rtf.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
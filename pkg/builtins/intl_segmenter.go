@@ -0,0 +1,281 @@
+package builtins
+
+import (
+	"math"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/vm"
+	"golang.org/x/text/language"
+)
+
+// segmenter is the state of an Intl.Segmenter. Its breaks follow the
+// language-independent parts of UAX #29: grapheme clusters, words as runs of
+// letters and digits, and sentences ending in terminal punctuation.
+type segmenter struct {
+	locale      language.Tag
+	granularity string
+}
+
+func newSegmenter(vmInstance *vm.VM, tag language.Tag, opts intlOptions) (*segmenter, error) {
+	seg := &segmenter{locale: tag}
+	var err error
+	if _, err = opts.getString("localeMatcher", []string{"lookup", "best fit"}, "best fit"); err != nil {
+		return nil, err
+	}
+	if seg.granularity, err = opts.getString("granularity", []string{"grapheme", "word", "sentence"}, "grapheme"); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// segment is one piece of a segmented string; index is in UTF-16 code units
+type segment struct {
+	text     string
+	index    int
+	wordLike bool
+}
+
+// split breaks input into segments
+func (seg *segmenter) split(input string) []segment {
+	var segments []segment
+	index := 0
+	for rest := input; rest != ""; {
+		var n int
+		wordLike := false
+		switch seg.granularity {
+		case "word":
+			n, wordLike = nextWord(rest)
+		case "sentence":
+			n = nextSentence(rest)
+		default:
+			n = nextGrapheme(rest)
+		}
+		segments = append(segments, segment{text: rest[:n], index: index, wordLike: wordLike})
+		index += vm.UTF16Length(rest[:n])
+		rest = rest[n:]
+	}
+	return segments
+}
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == 0x200D || // zero width joiner
+		r >= 0xFE00 && r <= 0xFE0F || // variation selectors
+		r >= 0x1F3FB && r <= 0x1F3FF || // emoji skin tone modifiers
+		r >= 0xE0020 && r <= 0xE007F // emoji tag sequences
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// hangulType classifies Hangul jamo and syllables as L, V, T, LV or LVT
+func hangulType(r rune) string {
+	switch {
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return "L"
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return "V"
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return "T"
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return "LV"
+		}
+		return "LVT"
+	}
+	return ""
+}
+
+// nextGrapheme returns the byte length of the grapheme cluster s starts with
+func nextGrapheme(s string) int {
+	prev, n := utf8.DecodeRuneInString(s)
+	if prev == '\r' && len(s) > 1 && s[1] == '\n' {
+		return 2
+	}
+	if prev == '\r' || prev == '\n' {
+		return n
+	}
+	regionalIndicators := 0
+	if isRegionalIndicator(prev) {
+		regionalIndicators = 1
+	}
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		join := isGraphemeExtend(r) ||
+			prev == 0x200D && r > 0x7F && !unicode.IsSpace(r) ||
+			isRegionalIndicator(r) && regionalIndicators%2 == 1
+		if !join {
+			switch prevType, nextType := hangulType(prev), hangulType(r); prevType {
+			case "L":
+				join = nextType != "T" && nextType != ""
+			case "V", "LV":
+				join = nextType == "V" || nextType == "T"
+			case "LVT", "T":
+				join = nextType == "T"
+			}
+		}
+		if !join {
+			break
+		}
+		if isRegionalIndicator(r) {
+			regionalIndicators++
+		}
+		prev = r
+		n += size
+	}
+	return n
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_' || r == 0x200D
+}
+
+// nextWord returns the byte length of the word, whitespace run or
+// punctuation s starts with, and whether it's a word
+func nextWord(s string) (int, bool) {
+	first, n := utf8.DecodeRuneInString(s)
+	switch {
+	case isWordRune(first):
+		for n < len(s) {
+			r, size := utf8.DecodeRuneInString(s[n:])
+			if isWordRune(r) {
+				n += size
+				continue
+			}
+			// Apostrophes and periods join letters (can't, e.g), and
+			// periods and commas digits (3.14, 1,000)
+			if r == '\'' || r == '’' || r == '.' || r == ',' || r == ':' {
+				next, nextSize := utf8.DecodeRuneInString(s[n+size:])
+				prev, _ := utf8.DecodeLastRuneInString(s[:n])
+				letters := unicode.IsLetter(prev) && unicode.IsLetter(next) && r != ','
+				digits := unicode.IsDigit(prev) && unicode.IsDigit(next) && r != ':'
+				if letters || digits {
+					n += size + nextSize
+					continue
+				}
+			}
+			break
+		}
+		return n, true
+	case unicode.IsSpace(first) && first != '\n' && first != '\r':
+		for n < len(s) {
+			r, size := utf8.DecodeRuneInString(s[n:])
+			if !unicode.IsSpace(r) || r == '\n' || r == '\r' {
+				break
+			}
+			n += size
+		}
+		return n, false
+	case first == '\r' && len(s) > 1 && s[1] == '\n':
+		return 2, false
+	}
+	return nextGrapheme(s), false
+}
+
+// nextSentence returns the byte length of the sentence s starts with,
+// including the whitespace after it
+func nextSentence(s string) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		n += size
+		if r == '\n' || r == 0x2029 {
+			return n
+		}
+		if r != '.' && r != '!' && r != '?' && r != '。' {
+			continue
+		}
+		// Take the rest of the terminator, closing punctuation and spaces
+		end := n
+		for end < len(s) {
+			r, size := utf8.DecodeRuneInString(s[end:])
+			if r != '.' && r != '!' && r != '?' && !unicode.In(r, unicode.Pe, unicode.Pf) && r != '"' && r != '\'' {
+				break
+			}
+			end += size
+		}
+		spaces := end
+		for spaces < len(s) {
+			r, size := utf8.DecodeRuneInString(s[spaces:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			spaces += size
+			if r == '\n' {
+				break
+			}
+		}
+		if spaces == len(s) {
+			return spaces
+		}
+		// "e.g. this" and "3.5" don't end sentences
+		next, _ := utf8.DecodeRuneInString(s[spaces:])
+		if spaces > end && !(r == '.' && unicode.IsLower(next)) || r == '。' {
+			return spaces
+		}
+		n = end
+	}
+	return n
+}
+
+func (seg *segmenter) segmentObject(vmInstance *vm.VM, input string, s segment) vm.Value {
+	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	obj.SetOwn("segment", vm.NewString(s.text))
+	obj.SetOwn("index", vm.NumberValue(float64(s.index)))
+	obj.SetOwn("input", vm.NewString(input))
+	if seg.granularity == "word" {
+		obj.SetOwn("isWordLike", vm.BooleanValue(s.wordLike))
+	}
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// segmentsObject creates the Segments object segment() returns
+func (seg *segmenter) segmentsObject(vmInstance *vm.VM, input string) vm.Value {
+	segments := seg.split(input)
+	length := vm.UTF16Length(input)
+	obj := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+
+	obj.SetOwnNonEnumerable("containing", vm.NewNativeFunction(1, false, "containing", func(args []vm.Value) (vm.Value, error) {
+		n := vmInstance.ToNumber(argOrUndefined(args, 0))
+		if math.IsNaN(n) {
+			n = 0
+		}
+		n = math.Trunc(n)
+		if n < 0 || n >= float64(length) {
+			return vm.Undefined, nil
+		}
+		i := sort.Search(len(segments), func(i int) bool { return segments[i].index > int(n) }) - 1
+		return seg.segmentObject(vmInstance, input, segments[i]), nil
+	}))
+	iterator := vm.NewNativeFunction(0, false, "[Symbol.iterator]", func(args []vm.Value) (vm.Value, error) {
+		arr := vm.NewArray()
+		for _, s := range segments {
+			arr.AsArray().Append(seg.segmentObject(vmInstance, input, s))
+		}
+		return createArrayIterator(vmInstance, arr.AsArray()), nil
+	})
+	w, e, c := true, false, true
+	obj.DefineOwnPropertyByKey(vm.NewSymbolKey(SymbolIterator), iterator, &w, &e, &c)
+	return vm.NewValueFromPlainObject(obj)
+}
+
+func (seg *segmenter) resolvedOptions(vmInstance *vm.VM) vm.Value {
+	return resolvedOptionsObject(vmInstance,
+		"locale", seg.locale.String(),
+		"granularity", seg.granularity,
+	)
+}
+
+func createSegmenterObject(vmInstance *vm.VM, seg *segmenter, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetOwnNonEnumerable("segment", vm.NewNativeFunction(1, false, "segment", func(args []vm.Value) (vm.Value, error) {
+		return seg.segmentsObject(vmInstance, argOrUndefined(args, 0).ToString()), nil
+	}))
+	obj.SetOwnNonEnumerable("resolvedOptions", vm.NewNativeFunction(0, false, "resolvedOptions", func(args []vm.Value) (vm.Value, error) {
+		return seg.resolvedOptions(vmInstance), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
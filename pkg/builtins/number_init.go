@@ -197,7 +197,7 @@ func (n *NumberInitializer) InitTypes(ctx *TypeContext) error {
 	// Note: 'this' is implicit and not included in type signatures
 	numberProtoType := types.NewObjectType().
 		WithProperty("toString", types.NewOptionalFunction([]types.Type{types.Number}, types.String, []bool{true})).
-		WithProperty("toLocaleString", types.NewOptionalFunction([]types.Type{types.String, types.Any}, types.String, []bool{true, true})).
+		WithProperty("toLocaleString", types.NewOptionalFunction([]types.Type{types.Any, types.Any}, types.String, []bool{true, true})).
 		WithProperty("valueOf", types.NewSimpleFunction([]types.Type{}, types.Number)).
 		WithProperty("toFixed", types.NewOptionalFunction([]types.Type{types.Number}, types.String, []bool{true})).
 		WithProperty("toExponential", types.NewOptionalFunction([]types.Type{types.Number}, types.String, []bool{true})).
@@ -305,14 +305,19 @@ func (n *NumberInitializer) InitRuntime(ctx *RuntimeContext) error {
 	numberProto.SetOwnNonEnumerable("toLocaleString", vm.NewNativeFunction(0, false, "toLocaleString", func(args []vm.Value) (vm.Value, error) {
 		thisNum := vmInstance.GetThis()
 
-		// Check if this is a number
-		if thisNum.Type() != vm.TypeFloatNumber && thisNum.Type() != vm.TypeIntegerNumber && thisNum.Type() != vm.TypeBigInt {
-			return vm.NewString(thisNum.ToString()), nil
+		// Extract primitive value from wrapper if needed
+		if thisNum.IsObject() {
+			if primitiveVal, exists := thisNum.AsPlainObject().GetOwn("[[PrimitiveValue]]"); exists {
+				thisNum = primitiveVal
+			}
+		}
+
+		if thisNum.Type() != vm.TypeFloatNumber && thisNum.Type() != vm.TypeIntegerNumber {
+			return vm.Undefined, vmInstance.NewTypeError("Number.prototype.toLocaleString requires that 'this' be a Number")
 		}
 
-		// For now, just return the string representation (proper locale support would be complex)
-		// TODO: Implement proper locale formatting
-		return vm.NewString(thisNum.ToString()), nil
+		// Formatted like new Intl.NumberFormat(locales, options).format(x)
+		return formatNumberToLocaleString(vmInstance, thisNum, argOrUndefined(args, 0), argOrUndefined(args, 1))
 	}))
 
 	numberProto.SetOwnNonEnumerable("valueOf", vm.NewNativeFunction(0, false, "valueOf", func(args []vm.Value) (vm.Value, error) {
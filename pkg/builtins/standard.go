@@ -11,6 +11,7 @@ func GetStandardInitializers() []BuiltinInitializer {
 
 	// Utility types (Readonly<T>, etc.)
 	initializers = append(initializers, &UtilityTypesInitializer{})
+	initializers = append(initializers, &DecoratorTypesInitializer{})
 
 	// Core builtins
 	initializers = append(initializers, &ObjectInitializer{})
@@ -45,6 +46,8 @@ func GetStandardInitializers() []BuiltinInitializer {
 	initializers = append(initializers, &RangeErrorInitializer{})
 	initializers = append(initializers, &URIErrorInitializer{})
 	initializers = append(initializers, &AggregateErrorInitializer{})
+	initializers = append(initializers, &SuppressedErrorInitializer{})
+	initializers = append(initializers, &DisposableStackInitializer{})
 	initializers = append(initializers, &MathInitializer{})
 	initializers = append(initializers, &JSONInitializer{})
 	// Install Reflect after Object so it can delegate to Object.__ownKeys
@@ -53,11 +56,15 @@ func GetStandardInitializers() []BuiltinInitializer {
 	initializers = append(initializers, &ConsoleInitializer{})
 	initializers = append(initializers, &BlobInitializer{})
 	initializers = append(initializers, &FormDataInitializer{})
+	initializers = append(initializers, &EventTargetInitializer{})
+	initializers = append(initializers, &URLInitializer{})
 	initializers = append(initializers, &AbortControllerInitializer{})
 	initializers = append(initializers, &FetchInitializer{})
 	initializers = append(initializers, &DateInitializer{})
 	initializers = append(initializers, &TemporalInitializer{})
+	initializers = append(initializers, &IntlInitializer{})
 	initializers = append(initializers, &PerformanceInitializer{})
+	initializers = append(initializers, &TimersInitializer{})
 	initializers = append(initializers, &ArrayBufferInitializer{})
 	initializers = append(initializers, &SharedArrayBufferInitializer{})
 	initializers = append(initializers, &DataViewInitializer{})
@@ -76,6 +83,8 @@ func GetStandardInitializers() []BuiltinInitializer {
 	initializers = append(initializers, &AtomicsInitializer{})
 	initializers = append(initializers, &TextEncoderInitializer{})
 	initializers = append(initializers, &TextDecoderInitializer{})
+	initializers = append(initializers, &StreamsInitializer{})
+	initializers = append(initializers, &CryptoInitializer{})
 
 	// Paserati intrinsics (compile-time type reflection)
 	initializers = append(initializers, &PaseratiInitializer{})
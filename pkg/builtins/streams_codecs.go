@@ -0,0 +1,476 @@
+package builtins
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// defineQueuingStrategy defines a queuing strategy class whose instances
+// measure chunks with size
+func defineQueuingStrategy(ctx *RuntimeContext, name string, size vm.Value) error {
+	vmInstance := ctx.VM
+	proto := newStreamPrototype(vmInstance, name)
+	proto.SetOwnNonEnumerable("size", size)
+	_, err := defineClass(ctx, name, 1, proto, func(args []vm.Value) (vm.Value, error) {
+		init := argOrUndefined(args, 0)
+		if !init.IsObject() {
+			return vm.Undefined, vmInstance.NewTypeError(name + ": argument must be an object with a highWaterMark")
+		}
+		hwm, err := vmInstance.GetProperty(init, "highWaterMark")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+		obj.SetOwn("highWaterMark", vm.NumberValue(vmInstance.ToNumber(hwm)))
+		return vm.NewValueFromPlainObject(obj), nil
+	})
+	return err
+}
+
+func initQueuingStrategies(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+	byteLength := vm.NewNativeFunction(1, false, "size", func(args []vm.Value) (vm.Value, error) {
+		return vmInstance.GetProperty(argOrUndefined(args, 0), "byteLength")
+	})
+	if err := defineQueuingStrategy(ctx, "ByteLengthQueuingStrategy", byteLength); err != nil {
+		return err
+	}
+	count := vm.NewNativeFunction(0, false, "size", func(args []vm.Value) (vm.Value, error) {
+		return vm.NumberValue(1), nil
+	})
+	return defineQueuingStrategy(ctx, "CountQueuingStrategy", count)
+}
+
+// The queuing strategies of the codec streams, which are TransformStreams
+// constructed without any
+var (
+	defaultWritableStrategy = queuingStrategy{highWaterMark: 1, size: vm.Undefined}
+	defaultReadableStrategy = queuingStrategy{highWaterMark: 0, size: vm.Undefined}
+)
+
+// chunkBytes returns the bytes of a chunk written to a byte-consuming stream
+func chunkBytes(vmInstance *vm.VM, chunk vm.Value) ([]byte, error) {
+	data, ok := bufferSourceBytes(chunk)
+	if !ok {
+		return nil, vmInstance.NewTypeError("chunk must be an ArrayBuffer or ArrayBufferView")
+	}
+	return data, nil
+}
+
+// rejectedWith returns a promise rejected with the value err throws
+func rejectedWith(vmInstance *vm.VM, err error) vm.Value {
+	return vmInstance.NewRejectedPromise(thrownValue(err))
+}
+
+// utf8StreamDecoder decodes UTF-8 split across chunks, holding back the
+// bytes of a character the chunk ends in the middle of
+type utf8StreamDecoder struct {
+	fatal     bool
+	ignoreBOM bool
+	bomSeen   bool
+	pending   []byte
+}
+
+// decode decodes the complete characters in pending plus data. Invalid
+// bytes become U+FFFD, or an error when fatal.
+func (d *utf8StreamDecoder) decode(vmInstance *vm.VM, data []byte, end bool) (string, error) {
+	input := append(d.pending, data...)
+	d.pending = nil
+	if !end {
+		// Hold back a character cut off at the end of the chunk
+		for i := len(input) - 1; i >= 0 && i >= len(input)-3; i-- {
+			if utf8.RuneStart(input[i]) {
+				if !utf8.FullRune(input[i:]) {
+					d.pending = append([]byte(nil), input[i:]...)
+					input = input[:i]
+				}
+				break
+			}
+		}
+	}
+	var sb strings.Builder
+	for len(input) > 0 {
+		r, size := utf8.DecodeRune(input)
+		if r == utf8.RuneError && size <= 1 {
+			if d.fatal {
+				return "", vmInstance.NewTypeError("The encoded data was not valid UTF-8")
+			}
+		}
+		if !d.bomSeen {
+			d.bomSeen = true
+			if r == '\uFEFF' && !d.ignoreBOM {
+				input = input[size:]
+				continue
+			}
+		}
+		sb.WriteRune(r)
+		input = input[size:]
+	}
+	return sb.String(), nil
+}
+
+// normalizeEncodingLabel returns the encoding a TextDecoderStream label
+// names; only UTF-8 is supported
+func normalizeEncodingLabel(vmInstance *vm.VM, label vm.Value) (string, error) {
+	if label.Type() == vm.TypeUndefined {
+		return "utf-8", nil
+	}
+	switch strings.ToLower(strings.TrimSpace(label.ToString())) {
+	case "utf-8", "utf8", "unicode-1-1-utf-8":
+		return "utf-8", nil
+	}
+	return "", vmInstance.NewRangeError("The encoding label provided ('" + label.ToString() + "') is invalid")
+}
+
+// compressionFormat validates the format argument of the compression streams
+func compressionFormat(vmInstance *vm.VM, name string, format vm.Value) (string, error) {
+	switch f := format.ToString(); f {
+	case "gzip", "deflate", "deflate-raw":
+		return f, nil
+	}
+	return "", vmInstance.NewTypeError(name + ": unsupported compression format '" + format.ToString() + "'")
+}
+
+// newCompressor returns a compressor for format writing to out
+func newCompressor(format string, out io.Writer) io.WriteCloser {
+	switch format {
+	case "gzip":
+		return gzip.NewWriter(out)
+	case "deflate":
+		return zlib.NewWriter(out)
+	}
+	w, _ := flate.NewWriter(out, flate.DefaultCompression)
+	return w
+}
+
+// decompressor runs a gzip, zlib or flate reader, which pull their input,
+// on a goroutine that the chunks written to a DecompressionStream are fed
+// to. Each chunk is decompressed before transform returns, so the goroutine
+// and the stream never touch out at the same time.
+type decompressor struct {
+	input       chan []byte
+	inputClosed bool
+	ready       chan struct{} // receives when the reader wants input, closed when it's done
+	out         bytes.Buffer
+	err         error
+	finished    bool
+}
+
+// decompressorInput is the reader the goroutine's decompressor reads from
+type decompressorInput struct {
+	d        *decompressor
+	buf      []byte
+	received bool
+	eof      bool
+}
+
+// fill waits for the next chunk once buf is used up, telling the stream it
+// is done with the last one. It returns false once the input is closed.
+func (in *decompressorInput) fill() bool {
+	for len(in.buf) == 0 {
+		if in.eof {
+			return false
+		}
+		if in.received {
+			in.d.ready <- struct{}{}
+		}
+		data, ok := <-in.d.input
+		if !ok {
+			in.eof = true
+			return false
+		}
+		in.received = true
+		in.buf = data
+	}
+	return true
+}
+
+func (in *decompressorInput) Read(p []byte) (int, error) {
+	if !in.fill() {
+		return 0, io.EOF
+	}
+	n := copy(p, in.buf)
+	in.buf = in.buf[n:]
+	return n, nil
+}
+
+// ReadByte keeps flate from buffering past the end of the compressed data
+func (in *decompressorInput) ReadByte() (byte, error) {
+	if !in.fill() {
+		return 0, io.EOF
+	}
+	b := in.buf[0]
+	in.buf = in.buf[1:]
+	return b, nil
+}
+
+func newDecompressor(format string) *decompressor {
+	d := &decompressor{input: make(chan []byte), ready: make(chan struct{})}
+	go func() {
+		defer close(d.ready)
+		defer func() { d.finished = true }()
+		in := &decompressorInput{d: d}
+		var r io.Reader
+		switch format {
+		case "gzip":
+			gz, err := gzip.NewReader(in)
+			if err != nil {
+				d.err = err
+				return
+			}
+			r = gz
+		case "deflate":
+			zr, err := zlib.NewReader(in)
+			if err != nil {
+				d.err = err
+				return
+			}
+			r = zr
+		default:
+			r = flate.NewReader(in)
+		}
+		// Not io.Copy: out's ReadFrom would read into out while the stream
+		// takes the output between chunks
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			d.out.Write(buf[:n])
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				d.err = err
+				return
+			}
+		}
+		if len(in.buf) > 0 {
+			d.err = errTrailingData
+		}
+	}()
+	return d
+}
+
+type decompressError string
+
+func (e decompressError) Error() string { return string(e) }
+
+const errTrailingData = decompressError("junk found after end of compressed data")
+
+// write feeds data to the decompressor and returns what it decompressed.
+// Once the goroutine is done it only reads the input it was given, so
+// finished and err are safe to look at between writes.
+func (d *decompressor) write(data []byte) ([]byte, error) {
+	if len(data) > 0 {
+		if d.finished {
+			if d.err == nil {
+				d.err = errTrailingData
+			}
+		} else {
+			d.input <- append([]byte(nil), data...)
+			<-d.ready
+		}
+	}
+	return d.take()
+}
+
+// close ends the input and returns the rest of the output
+func (d *decompressor) close() ([]byte, error) {
+	d.closeInput()
+	<-d.ready
+	return d.take()
+}
+
+// closeInput ends the input, which stops the goroutine
+func (d *decompressor) closeInput() {
+	if !d.inputClosed {
+		d.inputClosed = true
+		close(d.input)
+	}
+}
+
+func (d *decompressor) take() ([]byte, error) {
+	out := append([]byte(nil), d.out.Bytes()...)
+	d.out.Reset()
+	if d.finished && d.err != nil {
+		return out, d.err
+	}
+	return out, nil
+}
+
+func initStreamCodecs(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	// enqueueBytes enqueues data as a Uint8Array, unless it's empty
+	enqueueBytes := func(c *transformController, data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
+		return c.enqueue(newUint8Array(data))
+	}
+
+	encoderProto := newStreamPrototype(vmInstance, "TextEncoderStream")
+	_, err := defineClass(ctx, "TextEncoderStream", 0, encoderProto, func(args []vm.Value) (vm.Value, error) {
+		ts, err := newTransformStream(vmInstance, transformer{
+			transform: func(chunk vm.Value, c *transformController) vm.Value {
+				if err := enqueueBytes(c, []byte(chunk.ToString())); err != nil {
+					return rejectedWith(vmInstance, err)
+				}
+				return vm.Undefined
+			},
+		}, defaultWritableStrategy, defaultReadableStrategy)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		obj := createTransformStreamObject(vmInstance, ts, encoderProto)
+		defineGetter(obj.AsPlainObject(), "encoding", func() vm.Value { return vm.NewString("utf-8") })
+		return obj, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	decoderProto := newStreamPrototype(vmInstance, "TextDecoderStream")
+	_, err = defineClass(ctx, "TextDecoderStream", 0, decoderProto, func(args []vm.Value) (vm.Value, error) {
+		encoding, err := normalizeEncodingLabel(vmInstance, argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		decoder := &utf8StreamDecoder{}
+		if options := argOrUndefined(args, 1); options.IsObject() {
+			fatal, err := vmInstance.GetProperty(options, "fatal")
+			if err != nil {
+				return vm.Undefined, err
+			}
+			ignoreBOM, err := vmInstance.GetProperty(options, "ignoreBOM")
+			if err != nil {
+				return vm.Undefined, err
+			}
+			decoder.fatal, decoder.ignoreBOM = fatal.IsTruthy(), ignoreBOM.IsTruthy()
+		}
+		decode := func(c *transformController, data []byte, end bool) vm.Value {
+			text, err := decoder.decode(vmInstance, data, end)
+			if err == nil && text != "" {
+				err = c.enqueue(vm.NewString(text))
+			}
+			if err != nil {
+				return rejectedWith(vmInstance, err)
+			}
+			return vm.Undefined
+		}
+		ts, err := newTransformStream(vmInstance, transformer{
+			transform: func(chunk vm.Value, c *transformController) vm.Value {
+				data, err := chunkBytes(vmInstance, chunk)
+				if err != nil {
+					return rejectedWith(vmInstance, err)
+				}
+				return decode(c, data, false)
+			},
+			flush: func(c *transformController) vm.Value {
+				return decode(c, nil, true)
+			},
+		}, defaultWritableStrategy, defaultReadableStrategy)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		obj := createTransformStreamObject(vmInstance, ts, decoderProto).AsPlainObject()
+		defineGetter(obj, "encoding", func() vm.Value { return vm.NewString(encoding) })
+		defineGetter(obj, "fatal", func() vm.Value { return vm.BooleanValue(decoder.fatal) })
+		defineGetter(obj, "ignoreBOM", func() vm.Value { return vm.BooleanValue(decoder.ignoreBOM) })
+		return vm.NewValueFromPlainObject(obj), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	compressionProto := newStreamPrototype(vmInstance, "CompressionStream")
+	_, err = defineClass(ctx, "CompressionStream", 1, compressionProto, func(args []vm.Value) (vm.Value, error) {
+		format, err := compressionFormat(vmInstance, "CompressionStream", argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		var out bytes.Buffer
+		w := newCompressor(format, &out)
+		// emit enqueues what the compressor has written so far
+		emit := func(c *transformController) vm.Value {
+			data := out.Bytes()
+			out.Reset()
+			if err := enqueueBytes(c, data); err != nil {
+				return rejectedWith(vmInstance, err)
+			}
+			return vm.Undefined
+		}
+		ts, err := newTransformStream(vmInstance, transformer{
+			transform: func(chunk vm.Value, c *transformController) vm.Value {
+				data, err := chunkBytes(vmInstance, chunk)
+				if err != nil {
+					return rejectedWith(vmInstance, err)
+				}
+				_, _ = w.Write(data) // writes to a bytes.Buffer don't fail
+				return emit(c)
+			},
+			flush: func(c *transformController) vm.Value {
+				_ = w.Close()
+				return emit(c)
+			},
+		}, defaultWritableStrategy, defaultReadableStrategy)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createTransformStreamObject(vmInstance, ts, compressionProto), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	decompressionProto := newStreamPrototype(vmInstance, "DecompressionStream")
+	_, err = defineClass(ctx, "DecompressionStream", 1, decompressionProto, func(args []vm.Value) (vm.Value, error) {
+		format, err := compressionFormat(vmInstance, "DecompressionStream", argOrUndefined(args, 0))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		d := newDecompressor(format)
+		emit := func(c *transformController, data []byte, err error) vm.Value {
+			if err == nil {
+				err = enqueueBytes(c, data)
+			} else {
+				err = vmInstance.NewTypeError("DecompressionStream: " + err.Error())
+			}
+			if err != nil {
+				d.closeInput()
+				return rejectedWith(vmInstance, err)
+			}
+			return vm.Undefined
+		}
+		ts, err := newTransformStream(vmInstance, transformer{
+			transform: func(chunk vm.Value, c *transformController) vm.Value {
+				data, err := chunkBytes(vmInstance, chunk)
+				if err != nil {
+					d.closeInput()
+					return rejectedWith(vmInstance, err)
+				}
+				out, err := d.write(data)
+				return emit(c, out, err)
+			},
+			flush: func(c *transformController) vm.Value {
+				out, err := d.close()
+				return emit(c, out, err)
+			},
+			cancel: func(vm.Value) vm.Value {
+				d.closeInput()
+				return vm.Undefined
+			},
+		}, defaultWritableStrategy, defaultReadableStrategy)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createTransformStreamObject(vmInstance, ts, decompressionProto), nil
+	})
+	return err
+}
@@ -0,0 +1,425 @@
+package builtins
+
+import (
+	"math"
+
+	"github.com/nooga/paserati/pkg/runtime"
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for the Streams API
+const PriorityStreams = 185 // Before Blob and fetch, whose bodies are streams
+
+// StreamsInitializer implements the WHATWG Streams standard: ReadableStream,
+// WritableStream and TransformStream with their readers, writers and
+// controllers, the queuing strategies, and the encoding and compression
+// transform streams
+type StreamsInitializer struct{}
+
+func (s *StreamsInitializer) Name() string {
+	return "Streams"
+}
+
+func (s *StreamsInitializer) Priority() int {
+	return PriorityStreams
+}
+
+func (s *StreamsInitializer) InitTypes(ctx *TypeContext) error {
+	optionalArg := func(returnType types.Type) types.Type {
+		return types.NewOptionalFunction([]types.Type{types.Any}, returnType, []bool{true})
+	}
+	noArgs := func(returnType types.Type) types.Type {
+		return types.NewSimpleFunction([]types.Type{}, returnType)
+	}
+	desiredSize := types.NewUnionType(types.Number, types.Null)
+
+	readableControllerType := types.NewObjectType().
+		WithProperty("desiredSize", desiredSize).
+		WithProperty("enqueue", optionalArg(types.Undefined)).
+		WithProperty("close", noArgs(types.Undefined)).
+		WithProperty("error", optionalArg(types.Undefined)).
+		WithProperty("byobRequest", types.Any)
+
+	// read() takes a view for BYOB readers, so one reader type covers both
+	readerType := types.NewObjectType().
+		WithProperty("closed", types.Any).              // Promise<undefined>
+		WithProperty("read", optionalArg(types.Any)).   // Promise<{value, done}>
+		WithProperty("cancel", optionalArg(types.Any)). // Promise<undefined>
+		WithProperty("releaseLock", noArgs(types.Undefined))
+
+	writableControllerType := types.NewObjectType().
+		WithProperty("signal", types.Any). // AbortSignal
+		WithProperty("error", optionalArg(types.Undefined))
+
+	writerType := types.NewObjectType().
+		WithProperty("closed", types.Any). // Promise<undefined>
+		WithProperty("ready", types.Any).  // Promise<undefined>
+		WithProperty("desiredSize", desiredSize).
+		WithProperty("write", optionalArg(types.Any)). // Promise<undefined>
+		WithProperty("close", noArgs(types.Any)).      // Promise<undefined>
+		WithProperty("abort", optionalArg(types.Any)). // Promise<undefined>
+		WithProperty("releaseLock", noArgs(types.Undefined))
+
+	writableType := types.NewObjectType().
+		WithProperty("locked", types.Boolean).
+		WithProperty("abort", optionalArg(types.Any)). // Promise<undefined>
+		WithProperty("close", noArgs(types.Any)).      // Promise<undefined>
+		WithProperty("getWriter", noArgs(writerType))
+
+	readableType := types.NewObjectType()
+	readableType.
+		WithProperty("locked", types.Boolean).
+		WithProperty("cancel", optionalArg(types.Any)). // Promise<undefined>
+		WithProperty("getReader", optionalArg(readerType)).
+		WithProperty("pipeTo", types.NewOptionalFunction([]types.Type{writableType, types.Any}, types.Any, []bool{false, true})). // Promise<undefined>
+		WithProperty("pipeThrough", types.NewOptionalFunction([]types.Type{types.Any, types.Any}, readableType, []bool{false, true})).
+		WithProperty("tee", noArgs(&types.ArrayType{ElementType: readableType})).
+		WithProperty("values", optionalArg(types.Any)) // AsyncIterator
+
+	transformControllerType := types.NewObjectType().
+		WithProperty("desiredSize", desiredSize).
+		WithProperty("enqueue", optionalArg(types.Undefined)).
+		WithProperty("error", optionalArg(types.Undefined)).
+		WithProperty("terminate", noArgs(types.Undefined))
+
+	// Transform streams are {readable, writable} pairs, with extra
+	// properties for the encoding streams
+	pairType := func() *types.ObjectType {
+		return types.NewObjectType().
+			WithProperty("readable", readableType).
+			WithProperty("writable", writableType)
+	}
+	transformType := pairType()
+
+	constructor := func(params []types.Type, optional []bool, instanceType types.Type) *types.ObjectType {
+		return types.NewObjectType().
+			WithConstructSignature(types.SigOptional(params, instanceType, optional)).
+			WithProperty("prototype", instanceType)
+	}
+	illegalConstructor := func(instanceType types.Type) *types.ObjectType {
+		return types.NewObjectType().WithProperty("prototype", instanceType)
+	}
+
+	strategyType := types.NewObjectType().
+		WithProperty("highWaterMark", types.Number).
+		WithProperty("size", optionalArg(types.Number))
+
+	readableCtor := constructor([]types.Type{types.Any, types.Any}, []bool{true, true}, readableType).
+		WithProperty("from", types.NewSimpleFunction([]types.Type{types.Any}, readableType))
+
+	globals := []struct {
+		name     string
+		typ      types.Type
+		instance types.Type // also defined as a type alias, when set
+	}{
+		{"ReadableStream", readableCtor, readableType},
+		{"ReadableStreamDefaultReader", constructor([]types.Type{readableType}, []bool{false}, readerType), readerType},
+		{"ReadableStreamBYOBReader", constructor([]types.Type{readableType}, []bool{false}, readerType), nil},
+		{"ReadableStreamDefaultController", illegalConstructor(readableControllerType), readableControllerType},
+		{"ReadableByteStreamController", illegalConstructor(readableControllerType), nil},
+		{"WritableStream", constructor([]types.Type{types.Any, types.Any}, []bool{true, true}, writableType), writableType},
+		{"WritableStreamDefaultWriter", constructor([]types.Type{writableType}, []bool{false}, writerType), writerType},
+		{"WritableStreamDefaultController", illegalConstructor(writableControllerType), writableControllerType},
+		{"TransformStream", constructor([]types.Type{types.Any, types.Any, types.Any}, []bool{true, true, true}, transformType), transformType},
+		{"TransformStreamDefaultController", illegalConstructor(transformControllerType), transformControllerType},
+		{"ByteLengthQueuingStrategy", constructor([]types.Type{types.Any}, []bool{false}, strategyType), nil},
+		{"CountQueuingStrategy", constructor([]types.Type{types.Any}, []bool{false}, strategyType), nil},
+		{"TextEncoderStream", constructor(nil, nil, pairType().WithProperty("encoding", types.String)), nil},
+		{"TextDecoderStream", constructor([]types.Type{types.String, types.Any}, []bool{true, true}, pairType().
+			WithProperty("encoding", types.String).
+			WithProperty("fatal", types.Boolean).
+			WithProperty("ignoreBOM", types.Boolean)), nil},
+		{"CompressionStream", constructor([]types.Type{types.String}, []bool{false}, pairType()), nil},
+		{"DecompressionStream", constructor([]types.Type{types.String}, []bool{false}, pairType()), nil},
+	}
+	for _, global := range globals {
+		if err := ctx.DefineGlobal(global.name, global.typ); err != nil {
+			return err
+		}
+		if global.instance != nil {
+			if err := ctx.DefineTypeAlias(global.name, global.instance); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *StreamsInitializer) InitRuntime(ctx *RuntimeContext) error {
+	for _, init := range []func(*RuntimeContext) error{
+		initReadableStream,
+		initWritableStream,
+		initTransformStream,
+		initQueuingStrategies,
+		initStreamCodecs,
+	} {
+		if err := init(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readableStreamType returns the ReadableStream instance type, for the
+// builtins whose bodies are streams
+func readableStreamType(ctx *TypeContext) types.Type {
+	if ctor, ok := ctx.GetType("ReadableStream"); ok {
+		if obj, ok := ctor.(*types.ObjectType); ok && obj.Properties["prototype"] != nil {
+			return obj.Properties["prototype"]
+		}
+	}
+	return types.Any
+}
+
+// newStreamPrototype creates the prototype for one of the stream classes
+func newStreamPrototype(vmInstance *vm.VM, name string) *vm.PlainObject {
+	proto := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	if vmInstance.SymbolToStringTag.Type() == vm.TypeSymbol {
+		w, e, c := false, false, true
+		proto.DefineOwnPropertyByKey(vm.NewSymbolKey(vmInstance.SymbolToStringTag), vm.NewString(name), &w, &e, &c)
+	}
+	return proto
+}
+
+// defineClass defines the global constructor name for proto
+func defineClass(ctx *RuntimeContext, name string, arity int, proto *vm.PlainObject, fn func(args []vm.Value) (vm.Value, error)) (vm.Value, error) {
+	ctor := vm.NewConstructorWithProps(arity, false, name, fn)
+	if ctorProps := ctor.AsNativeFunctionWithProps(); ctorProps != nil {
+		ctorProps.Properties.SetOwnNonEnumerable("prototype", vm.NewValueFromPlainObject(proto))
+	}
+	proto.SetOwnNonEnumerable("constructor", ctor)
+	return ctor, ctx.DefineGlobal(name, ctor)
+}
+
+// defineIllegalConstructor defines a class user code can't construct, like
+// the controllers streams create for their sources and sinks
+func defineIllegalConstructor(ctx *RuntimeContext, name string) (*vm.PlainObject, error) {
+	vmInstance := ctx.VM
+	proto := newStreamPrototype(vmInstance, name)
+	_, err := defineClass(ctx, name, 0, proto, func(args []vm.Value) (vm.Value, error) {
+		return vm.Undefined, vmInstance.NewTypeError("Illegal constructor")
+	})
+	return proto, err
+}
+
+// globalPrototype returns the prototype of the global class name, for
+// objects created outside their constructors (streams by Blob, fetch and
+// tee, say)
+func globalPrototype(vmInstance *vm.VM, name string) *vm.PlainObject {
+	if ctor, ok := vmInstance.GetGlobal(name); ok {
+		if proto, err := vmInstance.GetProperty(ctor, "prototype"); err == nil && proto.Type() == vm.TypeObject {
+			return proto.AsPlainObject()
+		}
+	}
+	return vmInstance.ObjectPrototype.AsPlainObject()
+}
+
+// defineGetter defines the read-only accessor name on obj
+func defineGetter(obj *vm.PlainObject, name string, get func() vm.Value) {
+	getter := vm.NewNativeFunction(0, false, "get "+name, func(args []vm.Value) (vm.Value, error) {
+		return get(), nil
+	})
+	e, c := false, true
+	obj.DefineAccessorProperty(name, getter, true, vm.Undefined, false, &e, &c)
+}
+
+// thrownValue returns the value a call that failed with err threw
+func thrownValue(err error) vm.Value {
+	if ee, ok := err.(vm.ExceptionError); ok {
+		return ee.GetExceptionValue()
+	}
+	return vm.NewString(err.Error())
+}
+
+// typeErrorValue returns a TypeError object, for rejecting promises with
+func typeErrorValue(vmInstance *vm.VM, message string) vm.Value {
+	return thrownValue(vmInstance.NewTypeError(message))
+}
+
+// promiseOf returns v if it's a promise, or a promise fulfilled with it
+func promiseOf(vmInstance *vm.VM, v vm.Value) vm.Value {
+	if v.Type() == vm.TypePromise {
+		return v
+	}
+	return vmInstance.NewResolvedPromise(v)
+}
+
+// promiseReact calls onFulfilled or onRejected, either of which may be nil,
+// once the promise p settles; p may also be a plain value
+func promiseReact(vmInstance *vm.VM, p vm.Value, onFulfilled, onRejected func(vm.Value)) {
+	p = promiseOf(vmInstance, p)
+	if onFulfilled != nil {
+		vmInstance.AddPromiseReaction(p, true, onFulfilled)
+	}
+	if onRejected != nil {
+		vmInstance.AddPromiseReaction(p, false, onRejected)
+	}
+}
+
+// settled returns a pending promise and functions settling it
+func settled(vmInstance *vm.VM) (promise vm.Value, resolve func(vm.Value), reject func(vm.Value)) {
+	promise = vmInstance.NewPendingPromise()
+	p := promise.AsPromise()
+	return promise, func(v vm.Value) { vmInstance.ResolvePromise(p, v) }, func(r vm.Value) { vmInstance.RejectPromise(p, r) }
+}
+
+// callUnderlying calls the method of an underlying source, sink or
+// transformer obj, returning a promise of its result that a throw rejects
+func callUnderlying(vmInstance *vm.VM, method, obj vm.Value, args ...vm.Value) vm.Value {
+	if !method.IsCallable() {
+		return vmInstance.NewResolvedPromise(vm.Undefined)
+	}
+	result, err := vmInstance.Call(method, obj, args)
+	if err != nil {
+		return vmInstance.NewRejectedPromise(thrownValue(err))
+	}
+	return promiseOf(vmInstance, result)
+}
+
+// underlyingMethod returns the method name of obj, checking it's callable
+func underlyingMethod(vmInstance *vm.VM, obj vm.Value, name, owner string) (vm.Value, error) {
+	if !obj.IsObject() {
+		return vm.Undefined, nil
+	}
+	method, err := vmInstance.GetProperty(obj, name)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	if method.Type() != vm.TypeUndefined && !method.IsCallable() {
+		return vm.Undefined, vmInstance.NewTypeError(owner + "." + name + " must be a function")
+	}
+	return method, nil
+}
+
+// queuingStrategy is a stream's high water mark and chunk size function
+type queuingStrategy struct {
+	highWaterMark float64
+	size          vm.Value // undefined counts every chunk as 1
+}
+
+// extractStrategy reads a queuing strategy object, with defaultHWM as the
+// high water mark when it doesn't set one
+func extractStrategy(vmInstance *vm.VM, strategy vm.Value, defaultHWM float64) (queuingStrategy, error) {
+	qs := queuingStrategy{highWaterMark: defaultHWM, size: vm.Undefined}
+	if !strategy.IsObject() {
+		return qs, nil
+	}
+	hwm, err := vmInstance.GetProperty(strategy, "highWaterMark")
+	if err != nil {
+		return qs, err
+	}
+	if hwm.Type() != vm.TypeUndefined {
+		qs.highWaterMark = vmInstance.ToNumber(hwm)
+		if math.IsNaN(qs.highWaterMark) || qs.highWaterMark < 0 {
+			return qs, vmInstance.NewRangeError("Invalid highWaterMark")
+		}
+	}
+	if qs.size, err = underlyingMethod(vmInstance, strategy, "size", "strategy"); err != nil {
+		return qs, err
+	}
+	return qs, nil
+}
+
+// queuedChunk is a chunk waiting in a stream's queue with its size
+type queuedChunk struct {
+	value vm.Value
+	size  float64
+	close bool // the close sentinel of a writable stream's queue
+}
+
+// chunkQueue is the queue-with-sizes streams use to measure backpressure
+type chunkQueue struct {
+	chunks    []queuedChunk
+	totalSize float64
+}
+
+func (q *chunkQueue) enqueue(vmInstance *vm.VM, value vm.Value, size float64) error {
+	if math.IsNaN(size) || size < 0 || math.IsInf(size, 1) {
+		return vmInstance.NewRangeError("The return value of a queuing strategy's size function must be a finite, non-NaN, non-negative number")
+	}
+	q.chunks = append(q.chunks, queuedChunk{value: value, size: size})
+	q.totalSize += size
+	return nil
+}
+
+func (q *chunkQueue) dequeue() queuedChunk {
+	chunk := q.chunks[0]
+	q.chunks = q.chunks[1:]
+	q.totalSize = math.Max(q.totalSize-chunk.size, 0) // rounding can leave it just below 0
+	return chunk
+}
+
+func (q *chunkQueue) reset() {
+	q.chunks = nil
+	q.totalSize = 0
+}
+
+// chunkSize calls the strategy's size function on chunk
+func (qs queuingStrategy) chunkSize(vmInstance *vm.VM, chunk vm.Value) (float64, error) {
+	if !qs.size.IsCallable() {
+		return 1, nil
+	}
+	size, err := vmInstance.Call(qs.size, vm.Undefined, []vm.Value{chunk})
+	if err != nil {
+		return 0, err
+	}
+	return vmInstance.ToNumber(size), nil
+}
+
+// iterResult creates a {value, done} object
+func iterResult(vmInstance *vm.VM, value vm.Value, done bool) vm.Value {
+	result := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	result.SetOwn("value", value)
+	result.SetOwn("done", vm.BooleanValue(done))
+	return vm.NewValueFromPlainObject(result)
+}
+
+// bufferSourceBytes returns the bytes of an ArrayBuffer or ArrayBufferView
+// without copying them
+func bufferSourceBytes(v vm.Value) ([]byte, bool) {
+	switch v.Type() {
+	case vm.TypeArrayBuffer:
+		return v.AsArrayBuffer().GetData(), true
+	case vm.TypeSharedArrayBuffer:
+		return v.AsSharedArrayBuffer().GetData(), true
+	case vm.TypeTypedArray:
+		ta := v.AsTypedArray()
+		return viewBytes(ta.GetBufferData().GetData(), ta.GetByteOffset(), ta.GetByteLength()), true
+	case vm.TypeDataView:
+		dv := v.AsDataView()
+		return viewBytes(dv.GetBufferData().GetData(), dv.GetByteOffset(), dv.GetByteLength()), true
+	}
+	return nil, false
+}
+
+// viewBytes returns the bytes of a view, or none if its buffer was detached
+func viewBytes(data []byte, offset, length int) []byte {
+	if offset+length > len(data) {
+		return nil
+	}
+	return data[offset : offset+length]
+}
+
+// newUint8Array returns a Uint8Array holding a copy of data
+func newUint8Array(data []byte) vm.Value {
+	buffer := vm.NewArrayBuffer(len(data)).AsArrayBuffer()
+	copy(buffer.GetData(), data)
+	return vm.NewTypedArray(vm.TypedArrayUint8, buffer, 0, len(data))
+}
+
+// runOnEventLoop runs fn on the VM's goroutine on the next turn of its event
+// loop. It's for goroutines started after BeginExternalOp, and ends that
+// operation.
+func runOnEventLoop(vmInstance *vm.VM, fn func()) {
+	rt := vmInstance.GetAsyncRuntime()
+	if timers, ok := rt.(runtime.TimerRuntime); ok {
+		timers.SetImmediate(func() error {
+			fn()
+			return nil
+		})
+	} else {
+		rt.ScheduleMicrotask(fn)
+	}
+	rt.EndExternalOp()
+}
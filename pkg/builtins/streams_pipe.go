@@ -0,0 +1,205 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// pipeOptions are the options of pipeTo and pipeThrough
+type pipeOptions struct {
+	preventClose  bool
+	preventAbort  bool
+	preventCancel bool
+	signal        *AbortSignal
+}
+
+func parsePipeOptions(vmInstance *vm.VM, options vm.Value) (pipeOptions, error) {
+	var opts pipeOptions
+	if !options.IsObject() {
+		return opts, nil
+	}
+	for name, flag := range map[string]*bool{"preventClose": &opts.preventClose, "preventAbort": &opts.preventAbort, "preventCancel": &opts.preventCancel} {
+		v, err := vmInstance.GetProperty(options, name)
+		if err != nil {
+			return opts, err
+		}
+		*flag = v.IsTruthy()
+	}
+	signal, err := vmInstance.GetProperty(options, "signal")
+	if err != nil {
+		return opts, err
+	}
+	if signal.Type() != vm.TypeUndefined {
+		if opts.signal = abortSignalOf(signal); opts.signal == nil {
+			return opts, vmInstance.NewTypeError("pipeTo: signal must be an AbortSignal")
+		}
+	}
+	return opts, nil
+}
+
+// allSettledOK returns a promise fulfilled once all of promises fulfill, or
+// rejected with the first rejection
+func allSettledOK(vmInstance *vm.VM, promises []vm.Value) vm.Value {
+	promise, resolve, reject := settled(vmInstance)
+	remaining := len(promises)
+	if remaining == 0 {
+		resolve(vm.Undefined)
+	}
+	for _, p := range promises {
+		promiseReact(vmInstance, p, func(vm.Value) {
+			if remaining--; remaining == 0 {
+				resolve(vm.Undefined)
+			}
+		}, reject)
+	}
+	return promise
+}
+
+// pipeTo implements ReadableStreamPipeTo: it writes the chunks of source to
+// dest one at a time, waiting for dest's backpressure to clear before each
+// read, and propagates closing and errors between them as opts allow
+func pipeTo(source *readableStream, dest *writableStream, opts pipeOptions) vm.Value {
+	vmInstance := source.vm
+	reader, _ := acquireReader(source, false)
+	writer, _ := acquireWriter(dest)
+	source.disturbed = true
+
+	promise, resolve, reject := settled(vmInstance)
+	shuttingDown := false
+	currentWrite := vmInstance.NewResolvedPromise(vm.Undefined)
+
+	removeAbort := func() {}
+	finalize := func(err *vm.Value) {
+		removeAbort()
+		writer.release()
+		reader.release()
+		if err != nil {
+			reject(*err)
+		} else {
+			resolve(vm.Undefined)
+		}
+	}
+	// shutdownWithAction waits for the chunks already read to be written,
+	// then runs action, which may be nil, before unlocking both streams
+	shutdownWithAction := func(action func() vm.Value, originalError *vm.Value) {
+		if shuttingDown {
+			return
+		}
+		shuttingDown = true
+		rest := func() {
+			if action == nil {
+				finalize(originalError)
+				return
+			}
+			promiseReact(vmInstance, action(), func(vm.Value) {
+				finalize(originalError)
+			}, func(r vm.Value) {
+				finalize(&r)
+			})
+		}
+		if dest.state == "writable" && !dest.closeQueuedOrInFlight() {
+			written := func(vm.Value) { rest() }
+			promiseReact(vmInstance, currentWrite, written, written)
+			return
+		}
+		rest()
+	}
+
+	abortPipe := func(reason vm.Value) {
+		var actions []func() vm.Value
+		if !opts.preventAbort {
+			actions = append(actions, func() vm.Value {
+				if dest.state == "writable" {
+					return dest.abort(reason)
+				}
+				return vmInstance.NewResolvedPromise(vm.Undefined)
+			})
+		}
+		if !opts.preventCancel {
+			actions = append(actions, func() vm.Value {
+				if source.state == "readable" {
+					return source.cancel(reason)
+				}
+				return vmInstance.NewResolvedPromise(vm.Undefined)
+			})
+		}
+		shutdownWithAction(func() vm.Value {
+			promises := make([]vm.Value, len(actions))
+			for i, action := range actions {
+				promises[i] = action()
+			}
+			return allSettledOK(vmInstance, promises)
+		}, &reason)
+	}
+	// Aborting opts.signal aborts the pipe
+	if signal := opts.signal; signal != nil {
+		if signal.aborted {
+			abortPipe(signal.reason)
+			return promise
+		}
+		removeAbort = signal.onAbort(func() { abortPipe(signal.reason) })
+	}
+
+	// checkStates propagates the state source or dest ended up in, and
+	// reports whether the pipe is shutting down
+	checkStates := func() bool {
+		switch {
+		case source.state == "errored":
+			storedError := source.storedError
+			if opts.preventAbort {
+				shutdownWithAction(nil, &storedError)
+			} else {
+				shutdownWithAction(func() vm.Value { return dest.abort(storedError) }, &storedError)
+			}
+		case dest.state == "errored" || dest.state == "erroring":
+			storedError := dest.storedError
+			if opts.preventCancel {
+				shutdownWithAction(nil, &storedError)
+			} else {
+				shutdownWithAction(func() vm.Value { return source.cancel(storedError) }, &storedError)
+			}
+		case source.state == "closed":
+			if opts.preventClose {
+				shutdownWithAction(nil, nil)
+			} else {
+				shutdownWithAction(writer.closeWithErrorPropagation, nil)
+			}
+		case dest.closeQueuedOrInFlight() || dest.state == "closed":
+			destClosed := typeErrorValue(vmInstance, "the destination writable stream closed before all data could be piped to it")
+			if opts.preventCancel {
+				shutdownWithAction(nil, &destClosed)
+			} else {
+				shutdownWithAction(func() vm.Value { return source.cancel(destClosed) }, &destClosed)
+			}
+		}
+		return shuttingDown
+	}
+	check := func(vm.Value) { checkStates() }
+	promiseReact(vmInstance, reader.closed, check, check)
+	promiseReact(vmInstance, writer.closed.promise, check, check)
+
+	var step func()
+	step = func() {
+		if checkStates() {
+			return
+		}
+		promiseReact(vmInstance, writer.ready.promise, func(vm.Value) {
+			if checkStates() {
+				return
+			}
+			reader.read(&readRequest{
+				view: vm.Undefined,
+				chunk: func(chunk vm.Value) {
+					if writer.stream == nil {
+						return
+					}
+					currentWrite = writer.write(chunk)
+					step()
+				},
+				close: func() { checkStates() },
+				fail:  func(vm.Value) { checkStates() },
+			})
+		}, check)
+	}
+	step()
+	return promise
+}
@@ -0,0 +1,970 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// readableStream is the state of a ReadableStream
+type readableStream struct {
+	vm          *vm.VM
+	state       string // "readable", "closed" or "errored"
+	storedError vm.Value
+	disturbed   bool
+	reader      *streamReader
+	controller  *readableController
+	obj         vm.Value // the ReadableStream object, once there is one
+}
+
+// readableSource is an underlying source. Sources written in JS and the ones
+// builtins implement in Go, like Blob and fetch bodies, both go through it.
+// pull and cancel return a promise or a plain value.
+type readableSource struct {
+	start  func(c *readableController) (vm.Value, error)
+	pull   func(c *readableController) vm.Value
+	cancel func(reason vm.Value) vm.Value
+}
+
+// readableController is the state of a ReadableStreamDefaultController, or of
+// a ReadableByteStreamController when byteStream is set
+type readableController struct {
+	stream         *readableStream
+	source         readableSource
+	queue          chunkQueue
+	strategy       queuingStrategy
+	byteStream     bool
+	started        bool
+	closeRequested bool
+	pulling        bool
+	pullAgain      bool
+	obj            vm.Value
+}
+
+// readRequest is a pending read from a reader. view is the ArrayBufferView a
+// BYOB reader reads into, and undefined for default readers.
+type readRequest struct {
+	view  vm.Value
+	chunk func(chunk vm.Value)
+	close func()
+	fail  func(err vm.Value)
+}
+
+// streamReader is the state of a ReadableStreamDefaultReader, or of a
+// ReadableStreamBYOBReader when byob is set
+type streamReader struct {
+	vm            *vm.VM
+	stream        *readableStream // nil once released
+	byob          bool
+	requests      []*readRequest
+	closed        vm.Value
+	resolveClosed func(vm.Value)
+	rejectClosed  func(vm.Value)
+}
+
+// newReadableStream creates a stream reading from source
+func newReadableStream(vmInstance *vm.VM, source readableSource, strategy queuingStrategy, byteStream bool) (*readableStream, error) {
+	s := &readableStream{vm: vmInstance, state: "readable", storedError: vm.Undefined, obj: vm.Undefined}
+	c := &readableController{stream: s, source: source, strategy: strategy, byteStream: byteStream, obj: vm.Undefined}
+	s.controller = c
+
+	startResult := vm.Undefined
+	if source.start != nil {
+		result, err := source.start(c)
+		if err != nil {
+			return nil, err
+		}
+		startResult = result
+	}
+	promiseReact(vmInstance, startResult, func(vm.Value) {
+		c.started = true
+		c.callPullIfNeeded()
+	}, c.error)
+	return s, nil
+}
+
+// newReadableStreamFromSource creates a stream from the underlying source
+// and queuing strategy objects passed to the ReadableStream constructor
+func newReadableStreamFromSource(vmInstance *vm.VM, source, strategyArg vm.Value) (*readableStream, error) {
+	byteStream := false
+	if source.IsObject() {
+		typ, err := vmInstance.GetProperty(source, "type")
+		if err != nil {
+			return nil, err
+		}
+		if typ.Type() != vm.TypeUndefined {
+			if typ.ToString() != "bytes" {
+				return nil, vmInstance.NewTypeError("ReadableStream: invalid type '" + typ.ToString() + "'")
+			}
+			byteStream = true
+		}
+	}
+	methods := map[string]vm.Value{}
+	for _, name := range []string{"start", "pull", "cancel"} {
+		method, err := underlyingMethod(vmInstance, source, name, "underlyingSource")
+		if err != nil {
+			return nil, err
+		}
+		methods[name] = method
+	}
+
+	defaultHWM := 1.0
+	if byteStream {
+		defaultHWM = 0
+	}
+	strategy, err := extractStrategy(vmInstance, strategyArg, defaultHWM)
+	if err != nil {
+		return nil, err
+	}
+	if byteStream && strategy.size.Type() != vm.TypeUndefined {
+		return nil, vmInstance.NewRangeError("The strategy for a byte stream cannot have a size function")
+	}
+
+	return newReadableStream(vmInstance, readableSource{
+		start: func(c *readableController) (vm.Value, error) {
+			if !methods["start"].IsCallable() {
+				return vm.Undefined, nil
+			}
+			return vmInstance.Call(methods["start"], source, []vm.Value{c.object()})
+		},
+		pull: func(c *readableController) vm.Value {
+			return callUnderlying(vmInstance, methods["pull"], source, c.object())
+		},
+		cancel: func(reason vm.Value) vm.Value {
+			return callUnderlying(vmInstance, methods["cancel"], source, reason)
+		},
+	}, strategy, byteStream)
+}
+
+// newBytesStream creates a byte stream with data as its only chunk
+func newBytesStream(vmInstance *vm.VM, data []byte) *readableStream {
+	s, _ := newReadableStream(vmInstance, readableSource{
+		pull: func(c *readableController) vm.Value {
+			if len(data) > 0 {
+				_ = c.enqueue(newUint8Array(data))
+			}
+			_ = c.close()
+			return vm.Undefined
+		},
+	}, queuingStrategy{size: vm.Undefined}, true)
+	return s
+}
+
+// readableStreamOf returns the stream of a ReadableStream object, or nil
+func readableStreamOf(v vm.Value) *readableStream {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	s, _ := v.AsPlainObject().HostData().(*readableStream)
+	return s
+}
+
+func (s *readableStream) locked() bool {
+	return s.reader != nil
+}
+
+// close implements ReadableStreamClose
+func (s *readableStream) close() {
+	s.state = "closed"
+	r := s.reader
+	if r == nil {
+		return
+	}
+	r.resolveClosed(vm.Undefined)
+	requests := r.requests
+	r.requests = nil
+	for _, req := range requests {
+		req.close()
+	}
+}
+
+// error implements ReadableStreamError
+func (s *readableStream) error(e vm.Value) {
+	s.state = "errored"
+	s.storedError = e
+	r := s.reader
+	if r == nil {
+		return
+	}
+	r.rejectClosed(e)
+	requests := r.requests
+	r.requests = nil
+	for _, req := range requests {
+		req.fail(e)
+	}
+}
+
+// cancel implements ReadableStreamCancel, returning a promise
+func (s *readableStream) cancel(reason vm.Value) vm.Value {
+	s.disturbed = true
+	switch s.state {
+	case "closed":
+		return s.vm.NewResolvedPromise(vm.Undefined)
+	case "errored":
+		return s.vm.NewRejectedPromise(s.storedError)
+	}
+	s.close()
+	c := s.controller
+	c.queue.reset()
+	cancel := c.source.cancel
+	c.clearAlgorithms()
+	result := vm.Undefined
+	if cancel != nil {
+		result = cancel(reason)
+	}
+	promise, resolve, reject := settled(s.vm)
+	promiseReact(s.vm, result, func(vm.Value) { resolve(vm.Undefined) }, reject)
+	return promise
+}
+
+// readAll reads the stream to the end, calling done with the bytes of its
+// chunks, which must be Uint8Arrays, or fail with the error reading failed with.
+// Like a fully read body, the stream stays locked to its reader.
+func (s *readableStream) readAll(done func([]byte), fail func(vm.Value)) {
+	reader, err := acquireReader(s, false)
+	if err != nil {
+		fail(thrownValue(err))
+		return
+	}
+	rt := s.vm.GetAsyncRuntime()
+	var data []byte
+	var step func()
+	step = func() {
+		reader.read(&readRequest{
+			view: vm.Undefined,
+			chunk: func(chunk vm.Value) {
+				b, ok := bufferSourceBytes(chunk)
+				if chunk.Type() != vm.TypeTypedArray || !ok {
+					err := typeErrorValue(s.vm, "Body chunks must be Uint8Arrays")
+					s.cancel(err)
+					fail(err)
+					return
+				}
+				data = append(data, b...)
+				rt.ScheduleMicrotask(step)
+			},
+			close: func() { done(data) },
+			fail:  fail,
+		})
+	}
+	step()
+}
+
+// tee implements ReadableStreamTee, returning two branches that each get
+// the stream's chunks. Branches of a byte stream get copies of the bytes.
+func (s *readableStream) tee() ([2]*readableStream, error) {
+	var branches [2]*readableStream
+	reader, err := acquireReader(s, false)
+	if err != nil {
+		return branches, err
+	}
+	vmInstance := s.vm
+	byteStream := s.controller.byteStream
+	reading, readAgain := false, false
+	canceled := [2]bool{}
+	reasons := []vm.Value{vm.Undefined, vm.Undefined}
+	cancelPromise, resolveCancel, _ := settled(vmInstance)
+
+	var pull func(*readableController) vm.Value
+	pull = func(*readableController) vm.Value {
+		if reading {
+			readAgain = true
+			return vm.Undefined
+		}
+		reading = true
+		reader.read(&readRequest{
+			view: vm.Undefined,
+			chunk: func(chunk vm.Value) {
+				// Enqueued in a microtask, so an error reading can still
+				// error the branches first
+				vmInstance.GetAsyncRuntime().ScheduleMicrotask(func() {
+					readAgain = false
+					for i, branch := range branches {
+						if canceled[i] {
+							continue
+						}
+						value := chunk
+						if byteStream && i == 1 {
+							b, _ := bufferSourceBytes(chunk)
+							value = newUint8Array(b)
+						}
+						_ = branch.controller.enqueue(value)
+					}
+					reading = false
+					if readAgain {
+						pull(nil)
+					}
+				})
+			},
+			close: func() {
+				reading = false
+				for i, branch := range branches {
+					if !canceled[i] {
+						_ = branch.controller.close()
+					}
+				}
+				if !canceled[0] || !canceled[1] {
+					resolveCancel(vm.Undefined)
+				}
+			},
+			fail: func(vm.Value) {
+				reading = false
+			},
+		})
+		return vm.Undefined
+	}
+
+	for i := range branches {
+		i := i
+		cancel := func(reason vm.Value) vm.Value {
+			canceled[i] = true
+			reasons[i] = reason
+			if canceled[1-i] {
+				resolveCancel(s.cancel(vmInstance.NewArrayFromSlice(reasons)))
+			}
+			return cancelPromise
+		}
+		strategy := queuingStrategy{highWaterMark: 1, size: vm.Undefined}
+		if byteStream {
+			strategy.highWaterMark = 0
+		}
+		branches[i], _ = newReadableStream(vmInstance, readableSource{pull: pull, cancel: cancel}, strategy, byteStream)
+	}
+
+	promiseReact(vmInstance, reader.closed, nil, func(r vm.Value) {
+		for _, branch := range branches {
+			branch.controller.error(r)
+		}
+		if !canceled[0] || !canceled[1] {
+			resolveCancel(vm.Undefined)
+		}
+	})
+	return branches, nil
+}
+
+// canCloseOrEnqueue implements ReadableStreamDefaultControllerCanCloseOrEnqueue
+func (c *readableController) canCloseOrEnqueue() bool {
+	return !c.closeRequested && c.stream.state == "readable"
+}
+
+// desiredSize returns how many more chunks, or bytes, the queue wants, or
+// null once the stream errored
+func (c *readableController) desiredSize() vm.Value {
+	switch c.stream.state {
+	case "errored":
+		return vm.Null
+	case "closed":
+		return vm.NumberValue(0)
+	}
+	return vm.NumberValue(c.strategy.highWaterMark - c.queue.totalSize)
+}
+
+// enqueue adds chunk to the stream, handing it straight to a pending read
+// if there is one
+func (c *readableController) enqueue(chunk vm.Value) error {
+	vmInstance := c.stream.vm
+	if !c.canCloseOrEnqueue() {
+		return vmInstance.NewTypeError("Cannot enqueue a chunk into a readable stream that is closed or has been requested to be closed")
+	}
+	size := 1.0
+	if c.byteStream {
+		data, ok := bufferSourceBytes(chunk)
+		if !ok || chunk.Type() == vm.TypeArrayBuffer || chunk.Type() == vm.TypeSharedArrayBuffer {
+			return vmInstance.NewTypeError("chunk must be an ArrayBufferView")
+		}
+		if len(data) == 0 {
+			return vmInstance.NewTypeError("chunk must have a non-zero byteLength")
+		}
+		chunk = newUint8Array(data)
+		size = float64(len(data))
+	}
+
+	s := c.stream
+	if s.reader != nil && len(s.reader.requests) > 0 {
+		req := s.reader.shiftRequest()
+		if req.view.Type() == vm.TypeUndefined {
+			req.chunk(chunk)
+		} else {
+			_ = c.queue.enqueue(vmInstance, chunk, size)
+			if !c.fillReadInto(req) {
+				s.reader.requests = append([]*readRequest{req}, s.reader.requests...)
+			}
+		}
+	} else {
+		if !c.byteStream {
+			var err error
+			if size, err = c.strategy.chunkSize(vmInstance, chunk); err != nil {
+				c.error(thrownValue(err))
+				return err
+			}
+		}
+		if err := c.queue.enqueue(vmInstance, chunk, size); err != nil {
+			c.error(thrownValue(err))
+			return err
+		}
+	}
+	c.callPullIfNeeded()
+	return nil
+}
+
+// close closes the stream once its queue has been read
+func (c *readableController) close() error {
+	if !c.canCloseOrEnqueue() {
+		return c.stream.vm.NewTypeError("Cannot close a readable stream that is closed or has been requested to be closed")
+	}
+	c.closeRequested = true
+	if len(c.queue.chunks) == 0 {
+		c.clearAlgorithms()
+		c.stream.close()
+	}
+	return nil
+}
+
+// error errors the stream, dropping its queue
+func (c *readableController) error(e vm.Value) {
+	if c.stream.state != "readable" {
+		return
+	}
+	c.queue.reset()
+	c.clearAlgorithms()
+	c.stream.error(e)
+}
+
+// clearAlgorithms drops the source, once the stream won't call it again
+func (c *readableController) clearAlgorithms() {
+	c.source = readableSource{}
+}
+
+func (c *readableController) shouldCallPull() bool {
+	if !c.canCloseOrEnqueue() || !c.started {
+		return false
+	}
+	if r := c.stream.reader; r != nil && len(r.requests) > 0 {
+		return true
+	}
+	return c.strategy.highWaterMark-c.queue.totalSize > 0
+}
+
+// callPullIfNeeded pulls from the source when the stream wants more chunks,
+// one pull at a time
+func (c *readableController) callPullIfNeeded() {
+	if !c.shouldCallPull() {
+		return
+	}
+	if c.pulling {
+		c.pullAgain = true
+		return
+	}
+	c.pulling = true
+	result := vm.Undefined
+	if c.source.pull != nil {
+		result = c.source.pull(c)
+	}
+	promiseReact(c.stream.vm, result, func(vm.Value) {
+		c.pulling = false
+		if c.pullAgain {
+			c.pullAgain = false
+			c.callPullIfNeeded()
+		}
+	}, c.error)
+}
+
+// afterDequeue closes the stream once a requested close has drained the
+// queue, and pulls for more chunks otherwise
+func (c *readableController) afterDequeue() {
+	if c.closeRequested && len(c.queue.chunks) == 0 {
+		c.clearAlgorithms()
+		c.stream.close()
+	} else {
+		c.callPullIfNeeded()
+	}
+}
+
+// pullSteps serves a default read from the queue, or leaves it pending
+func (c *readableController) pullSteps(req *readRequest) {
+	if len(c.queue.chunks) > 0 {
+		chunk := c.queue.dequeue()
+		c.afterDequeue()
+		req.chunk(chunk.value)
+		return
+	}
+	c.stream.reader.requests = append(c.stream.reader.requests, req)
+	c.callPullIfNeeded()
+}
+
+// pullInto serves a BYOB read from the queue, or leaves it pending
+func (c *readableController) pullInto(req *readRequest) {
+	if c.fillReadInto(req) {
+		return
+	}
+	c.stream.reader.requests = append(c.stream.reader.requests, req)
+	c.callPullIfNeeded()
+}
+
+// fillReadInto copies as many whole elements of the queued bytes as fit into
+// the view of a BYOB read, and fulfills it with the filled part. It returns
+// false when the queue doesn't hold a whole element yet.
+func (c *readableController) fillReadInto(req *readRequest) bool {
+	ta := req.view.AsTypedArray()
+	target, _ := bufferSourceBytes(req.view)
+	elementSize := ta.GetBytesPerElement()
+	n := min(len(target), int(c.queue.totalSize))
+	n -= n % elementSize
+	if n == 0 {
+		return false
+	}
+	for filled := 0; filled < n; {
+		head := &c.queue.chunks[0]
+		data, _ := bufferSourceBytes(head.value)
+		copied := copy(target[filled:n], data)
+		filled += copied
+		if copied < len(data) {
+			head.value = newUint8Array(data[copied:])
+			head.size -= float64(copied)
+			c.queue.totalSize -= float64(copied)
+		} else {
+			c.queue.dequeue()
+		}
+	}
+	view := vm.NewTypedArray(ta.GetElementType(), ta.GetBufferData(), ta.GetByteOffset(), n/elementSize)
+	c.afterDequeue()
+	req.chunk(view)
+	return true
+}
+
+// object returns the controller's JS object, creating it the first time
+func (c *readableController) object() vm.Value {
+	if c.obj.Type() != vm.TypeUndefined {
+		return c.obj
+	}
+	vmInstance := c.stream.vm
+	name := "ReadableStreamDefaultController"
+	if c.byteStream {
+		name = "ReadableByteStreamController"
+	}
+	obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, name))).AsPlainObject()
+	obj.SetHostData(c)
+	c.obj = vm.NewValueFromPlainObject(obj)
+
+	defineGetter(obj, "desiredSize", c.desiredSize)
+	if c.byteStream {
+		// Sources respond to BYOB reads by enqueueing, so there are no requests
+		defineGetter(obj, "byobRequest", func() vm.Value { return vm.Null })
+	}
+	obj.SetOwnNonEnumerable("enqueue", vm.NewNativeFunction(1, false, "enqueue", func(args []vm.Value) (vm.Value, error) {
+		return vm.Undefined, c.enqueue(argOrUndefined(args, 0))
+	}))
+	obj.SetOwnNonEnumerable("close", vm.NewNativeFunction(0, false, "close", func(args []vm.Value) (vm.Value, error) {
+		return vm.Undefined, c.close()
+	}))
+	obj.SetOwnNonEnumerable("error", vm.NewNativeFunction(1, false, "error", func(args []vm.Value) (vm.Value, error) {
+		c.error(argOrUndefined(args, 0))
+		return vm.Undefined, nil
+	}))
+	return c.obj
+}
+
+// acquireReader locks s to a new reader
+func acquireReader(s *readableStream, byob bool) (*streamReader, error) {
+	if s.locked() {
+		return nil, s.vm.NewTypeError("ReadableStream is locked to a reader")
+	}
+	if byob && !s.controller.byteStream {
+		return nil, s.vm.NewTypeError("Cannot use a BYOB reader with a stream that isn't a byte stream")
+	}
+	r := &streamReader{vm: s.vm, stream: s, byob: byob}
+	r.closed, r.resolveClosed, r.rejectClosed = settled(s.vm)
+	switch s.state {
+	case "closed":
+		r.resolveClosed(vm.Undefined)
+	case "errored":
+		r.rejectClosed(s.storedError)
+	}
+	s.reader = r
+	return r, nil
+}
+
+func (r *streamReader) shiftRequest() *readRequest {
+	req := r.requests[0]
+	r.requests = r.requests[1:]
+	return req
+}
+
+// read reads the next chunk, or fills req.view for BYOB readers
+func (r *streamReader) read(req *readRequest) {
+	s := r.stream
+	s.disturbed = true
+	switch {
+	case s.state == "closed":
+		req.close()
+	case s.state == "errored":
+		req.fail(s.storedError)
+	case req.view.Type() != vm.TypeUndefined:
+		s.controller.pullInto(req)
+	default:
+		s.controller.pullSteps(req)
+	}
+}
+
+// readPromise implements read(), returning a promise of {value, done}
+func (r *streamReader) readPromise(view vm.Value) vm.Value {
+	if r.stream == nil {
+		return r.vm.NewRejectedPromise(typeErrorValue(r.vm, "Cannot read from a released reader"))
+	}
+	promise, resolve, reject := settled(r.vm)
+	req := &readRequest{
+		view:  vm.Undefined,
+		chunk: func(chunk vm.Value) { resolve(iterResult(r.vm, chunk, false)) },
+		close: func() { resolve(iterResult(r.vm, vm.Undefined, true)) },
+		fail:  reject,
+	}
+	if r.byob {
+		if view.Type() != vm.TypeTypedArray {
+			return r.vm.NewRejectedPromise(typeErrorValue(r.vm, "view must be a TypedArray"))
+		}
+		if view.AsTypedArray().GetByteLength() == 0 {
+			return r.vm.NewRejectedPromise(typeErrorValue(r.vm, "view must have a non-zero byteLength"))
+		}
+		req.view = view
+		req.close = func() {
+			// A closed stream fulfills BYOB reads with an empty view
+			kind := view.AsTypedArray().GetElementType()
+			resolve(iterResult(r.vm, vm.NewTypedArray(kind, 0, 0, 0), true))
+		}
+	}
+	r.read(req)
+	return promise
+}
+
+// release implements ReadableStreamReaderGenericRelease: pending reads fail
+// and the stream is unlocked
+func (r *streamReader) release() {
+	s := r.stream
+	if s == nil {
+		return
+	}
+	err := typeErrorValue(r.vm, "Reader was released")
+	if s.state == "readable" {
+		r.rejectClosed(err)
+	} else {
+		r.closed = r.vm.NewRejectedPromise(err)
+	}
+	requests := r.requests
+	r.requests = nil
+	for _, req := range requests {
+		req.fail(err)
+	}
+	s.reader = nil
+	r.stream = nil
+}
+
+func createReaderObject(vmInstance *vm.VM, r *streamReader) vm.Value {
+	name := "ReadableStreamDefaultReader"
+	if r.byob {
+		name = "ReadableStreamBYOBReader"
+	}
+	obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, name))).AsPlainObject()
+	obj.SetHostData(r)
+
+	defineGetter(obj, "closed", func() vm.Value { return r.closed })
+	obj.SetOwnNonEnumerable("read", vm.NewNativeFunction(0, false, "read", func(args []vm.Value) (vm.Value, error) {
+		return r.readPromise(argOrUndefined(args, 0)), nil
+	}))
+	obj.SetOwnNonEnumerable("releaseLock", vm.NewNativeFunction(0, false, "releaseLock", func(args []vm.Value) (vm.Value, error) {
+		r.release()
+		return vm.Undefined, nil
+	}))
+	obj.SetOwnNonEnumerable("cancel", vm.NewNativeFunction(1, false, "cancel", func(args []vm.Value) (vm.Value, error) {
+		if r.stream == nil {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot cancel a stream using a released reader")), nil
+		}
+		return r.stream.cancel(argOrUndefined(args, 0)), nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// asyncIterator creates the async iterator values() returns, which reads
+// the stream through a reader and cancels it when the loop exits early,
+// unless preventCancel is set
+func (s *readableStream) asyncIterator(preventCancel bool) (vm.Value, error) {
+	vmInstance := s.vm
+	reader, err := acquireReader(s, false)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	it := vm.NewObject(vmInstance.ObjectPrototype).AsPlainObject()
+	it.SetOwnNonEnumerable("next", vm.NewNativeFunction(0, false, "next", func(args []vm.Value) (vm.Value, error) {
+		if reader.stream == nil {
+			return vmInstance.NewResolvedPromise(iterResult(vmInstance, vm.Undefined, true)), nil
+		}
+		promise, resolve, reject := settled(vmInstance)
+		reader.read(&readRequest{
+			view:  vm.Undefined,
+			chunk: func(chunk vm.Value) { resolve(iterResult(vmInstance, chunk, false)) },
+			close: func() {
+				reader.release()
+				resolve(iterResult(vmInstance, vm.Undefined, true))
+			},
+			fail: func(e vm.Value) {
+				reader.release()
+				reject(e)
+			},
+		})
+		return promise, nil
+	}))
+	it.SetOwnNonEnumerable("return", vm.NewNativeFunction(1, false, "return", func(args []vm.Value) (vm.Value, error) {
+		value := argOrUndefined(args, 0)
+		done := iterResult(vmInstance, value, true)
+		if reader.stream == nil {
+			return vmInstance.NewResolvedPromise(done), nil
+		}
+		if preventCancel {
+			reader.release()
+			return vmInstance.NewResolvedPromise(done), nil
+		}
+		canceled := reader.stream.cancel(value)
+		reader.release()
+		promise, resolve, reject := settled(vmInstance)
+		promiseReact(vmInstance, canceled, func(vm.Value) { resolve(done) }, reject)
+		return promise, nil
+	}))
+	w, e, c := true, false, true
+	it.DefineOwnPropertyByKey(vm.NewSymbolKey(SymbolAsyncIterator), vm.NewNativeFunction(0, false, "[Symbol.asyncIterator]", func(args []vm.Value) (vm.Value, error) {
+		return vm.NewValueFromPlainObject(it), nil
+	}), &w, &e, &c)
+	return vm.NewValueFromPlainObject(it), nil
+}
+
+// readableStreamFrom implements ReadableStream.from, reading the chunks of
+// an async or sync iterable
+func readableStreamFrom(vmInstance *vm.VM, iterable vm.Value) (*readableStream, error) {
+	async := true
+	method, _ := vmInstance.GetSymbolProperty(iterable, SymbolAsyncIterator)
+	if !method.IsCallable() {
+		async = false
+		method, _ = vmInstance.GetSymbolProperty(iterable, SymbolIterator)
+	}
+	if !method.IsCallable() {
+		return nil, vmInstance.NewTypeError("ReadableStream.from: argument is not iterable")
+	}
+	iterator, err := vmInstance.Call(method, iterable, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !iterator.IsObject() {
+		return nil, vmInstance.NewTypeError("ReadableStream.from: iterator is not an object")
+	}
+	next, err := vmInstance.GetProperty(iterator, "next")
+	if err != nil {
+		return nil, err
+	}
+
+	return newReadableStream(vmInstance, readableSource{
+		pull: func(c *readableController) vm.Value {
+			result, err := vmInstance.Call(next, iterator, nil)
+			if err != nil {
+				return vmInstance.NewRejectedPromise(thrownValue(err))
+			}
+			promise, resolve, reject := settled(vmInstance)
+			promiseReact(vmInstance, result, func(result vm.Value) {
+				if !result.IsObject() {
+					reject(typeErrorValue(vmInstance, "iterator result is not an object"))
+					return
+				}
+				done, _ := vmInstance.GetProperty(result, "done")
+				if done.IsTruthy() {
+					_ = c.close()
+					resolve(vm.Undefined)
+					return
+				}
+				value, _ := vmInstance.GetProperty(result, "value")
+				if async {
+					_ = c.enqueue(value)
+					resolve(vm.Undefined)
+					return
+				}
+				// Values of sync iterators are awaited, like for await does
+				promiseReact(vmInstance, value, func(value vm.Value) {
+					_ = c.enqueue(value)
+					resolve(vm.Undefined)
+				}, reject)
+			}, reject)
+			return promise
+		},
+		cancel: func(reason vm.Value) vm.Value {
+			ret, err := vmInstance.GetProperty(iterator, "return")
+			if err != nil {
+				return vmInstance.NewRejectedPromise(thrownValue(err))
+			}
+			return callUnderlying(vmInstance, ret, iterator, reason)
+		},
+	}, queuingStrategy{size: vm.Undefined}, false)
+}
+
+// object returns the stream's ReadableStream object, creating it the first time
+func (s *readableStream) object() vm.Value {
+	if s.obj.Type() == vm.TypeUndefined {
+		createReadableStreamObject(s.vm, s, globalPrototype(s.vm, "ReadableStream"))
+	}
+	return s.obj
+}
+
+func createReadableStreamObject(vmInstance *vm.VM, s *readableStream, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetHostData(s)
+	s.obj = vm.NewValueFromPlainObject(obj)
+
+	defineGetter(obj, "locked", func() vm.Value { return vm.BooleanValue(s.locked()) })
+
+	obj.SetOwnNonEnumerable("cancel", vm.NewNativeFunction(1, false, "cancel", func(args []vm.Value) (vm.Value, error) {
+		if s.locked() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot cancel a stream that already has a reader")), nil
+		}
+		return s.cancel(argOrUndefined(args, 0)), nil
+	}))
+
+	obj.SetOwnNonEnumerable("getReader", vm.NewNativeFunction(0, false, "getReader", func(args []vm.Value) (vm.Value, error) {
+		byob := false
+		if options := argOrUndefined(args, 0); options.IsObject() {
+			mode, err := vmInstance.GetProperty(options, "mode")
+			if err != nil {
+				return vm.Undefined, err
+			}
+			switch {
+			case mode.Type() == vm.TypeUndefined:
+			case mode.ToString() == "byob":
+				byob = true
+			default:
+				return vm.Undefined, vmInstance.NewTypeError("getReader: invalid mode '" + mode.ToString() + "'")
+			}
+		}
+		r, err := acquireReader(s, byob)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createReaderObject(vmInstance, r), nil
+	}))
+
+	obj.SetOwnNonEnumerable("pipeTo", vm.NewNativeFunction(1, false, "pipeTo", func(args []vm.Value) (vm.Value, error) {
+		dest := writableStreamOf(argOrUndefined(args, 0))
+		if dest == nil {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "pipeTo: destination is not a WritableStream")), nil
+		}
+		options, err := parsePipeOptions(vmInstance, argOrUndefined(args, 1))
+		if err != nil {
+			return vmInstance.NewRejectedPromise(thrownValue(err)), nil
+		}
+		if s.locked() || dest.locked() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "pipeTo: cannot pipe a locked stream")), nil
+		}
+		return pipeTo(s, dest, options), nil
+	}))
+
+	obj.SetOwnNonEnumerable("pipeThrough", vm.NewNativeFunction(1, false, "pipeThrough", func(args []vm.Value) (vm.Value, error) {
+		transform := argOrUndefined(args, 0)
+		if !transform.IsObject() {
+			return vm.Undefined, vmInstance.NewTypeError("pipeThrough: transform must be an object with readable and writable streams")
+		}
+		writable, err := vmInstance.GetProperty(transform, "writable")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		readable, err := vmInstance.GetProperty(transform, "readable")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		dest := writableStreamOf(writable)
+		if dest == nil || readableStreamOf(readable) == nil {
+			return vm.Undefined, vmInstance.NewTypeError("pipeThrough: transform must be an object with readable and writable streams")
+		}
+		options, err := parsePipeOptions(vmInstance, argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if s.locked() {
+			return vm.Undefined, vmInstance.NewTypeError("pipeThrough: cannot pipe a locked stream")
+		}
+		if dest.locked() {
+			return vm.Undefined, vmInstance.NewTypeError("pipeThrough: cannot pipe to a locked stream")
+		}
+		pipeTo(s, dest, options)
+		return readable, nil
+	}))
+
+	obj.SetOwnNonEnumerable("tee", vm.NewNativeFunction(0, false, "tee", func(args []vm.Value) (vm.Value, error) {
+		branches, err := s.tee()
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vmInstance.NewArrayFromSlice([]vm.Value{branches[0].object(), branches[1].object()}), nil
+	}))
+
+	values := vm.NewNativeFunction(0, false, "values", func(args []vm.Value) (vm.Value, error) {
+		preventCancel := false
+		if options := argOrUndefined(args, 0); options.IsObject() {
+			v, err := vmInstance.GetProperty(options, "preventCancel")
+			if err != nil {
+				return vm.Undefined, err
+			}
+			preventCancel = v.IsTruthy()
+		}
+		return s.asyncIterator(preventCancel)
+	})
+	obj.SetOwnNonEnumerable("values", values)
+	w, e, c := true, false, true
+	obj.DefineOwnPropertyByKey(vm.NewSymbolKey(SymbolAsyncIterator), values, &w, &e, &c)
+
+	return s.obj
+}
+
+func initReadableStream(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	proto := newStreamPrototype(vmInstance, "ReadableStream")
+	ctor, err := defineClass(ctx, "ReadableStream", 0, proto, func(args []vm.Value) (vm.Value, error) {
+		s, err := newReadableStreamFromSource(vmInstance, argOrUndefined(args, 0), argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createReadableStreamObject(vmInstance, s, proto), nil
+	})
+	if err != nil {
+		return err
+	}
+	if ctorProps := ctor.AsNativeFunctionWithProps(); ctorProps != nil {
+		ctorProps.Properties.SetOwnNonEnumerable("from", vm.NewNativeFunction(1, false, "from", func(args []vm.Value) (vm.Value, error) {
+			s, err := readableStreamFrom(vmInstance, argOrUndefined(args, 0))
+			if err != nil {
+				return vm.Undefined, err
+			}
+			return createReadableStreamObject(vmInstance, s, proto), nil
+		}))
+	}
+
+	for _, byob := range []bool{false, true} {
+		byob := byob
+		name := "ReadableStreamDefaultReader"
+		if byob {
+			name = "ReadableStreamBYOBReader"
+		}
+		_, err := defineClass(ctx, name, 1, newStreamPrototype(vmInstance, name), func(args []vm.Value) (vm.Value, error) {
+			s := readableStreamOf(argOrUndefined(args, 0))
+			if s == nil {
+				return vm.Undefined, vmInstance.NewTypeError(name + ": argument is not a ReadableStream")
+			}
+			r, err := acquireReader(s, byob)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			return createReaderObject(vmInstance, r), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{"ReadableStreamDefaultController", "ReadableByteStreamController"} {
+		if _, err := defineIllegalConstructor(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
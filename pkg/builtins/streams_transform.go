@@ -0,0 +1,367 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// transformer is the Go side of a TransformStream's transformer. transform
+// may be nil, to pass chunks through unchanged; the rest return a promise or
+// a plain value, like the methods of sources and sinks.
+type transformer struct {
+	start     func(c *transformController) (vm.Value, error)
+	transform func(chunk vm.Value, c *transformController) vm.Value
+	flush     func(c *transformController) vm.Value
+	cancel    func(reason vm.Value) vm.Value
+}
+
+// transformStream is the state of a TransformStream: a writable side whose
+// chunks the transformer turns into the chunks of the readable side
+type transformStream struct {
+	vm                        *vm.VM
+	readable                  *readableStream
+	writable                  *writableStream
+	controller                *transformController
+	backpressure              bool
+	backpressureChangePromise *pendingPromise
+	obj                       vm.Value
+}
+
+// transformController is the state of a TransformStreamDefaultController
+type transformController struct {
+	stream        *transformStream
+	transformer   transformer
+	finishPromise *pendingPromise
+	obj           vm.Value
+}
+
+// newTransformStream creates a stream running chunks through t
+func newTransformStream(vmInstance *vm.VM, t transformer, writableStrategy, readableStrategy queuingStrategy) (*transformStream, error) {
+	ts := &transformStream{vm: vmInstance, obj: vm.Undefined}
+	c := &transformController{stream: ts, transformer: t, obj: vm.Undefined}
+	ts.controller = c
+	start := newPendingPromise(vmInstance)
+	startAlgorithm := func() vm.Value { return start.promise }
+
+	var err error
+	ts.writable, err = newWritableStream(vmInstance, writableSink{
+		start: func(*writableController) (vm.Value, error) { return startAlgorithm(), nil },
+		write: func(chunk vm.Value, _ *writableController) vm.Value { return ts.sinkWrite(chunk) },
+		close: ts.sinkClose,
+		abort: ts.sinkAbort,
+	}, writableStrategy)
+	if err != nil {
+		return nil, err
+	}
+	ts.readable, err = newReadableStream(vmInstance, readableSource{
+		start:  func(*readableController) (vm.Value, error) { return startAlgorithm(), nil },
+		pull:   func(*readableController) vm.Value { return ts.sourcePull() },
+		cancel: ts.sourceCancel,
+	}, readableStrategy, false)
+	if err != nil {
+		return nil, err
+	}
+	ts.setBackpressure(true)
+
+	startResult := vm.Undefined
+	if t.start != nil {
+		result, err := t.start(c)
+		if err != nil {
+			return nil, err
+		}
+		startResult = result
+	}
+	start.resolve(startResult)
+	return ts, nil
+}
+
+// newTransformStreamFromTransformer creates a stream from the transformer
+// and strategy objects passed to the TransformStream constructor
+func newTransformStreamFromTransformer(vmInstance *vm.VM, t, writableStrategyArg, readableStrategyArg vm.Value) (*transformStream, error) {
+	if t.IsObject() {
+		for _, name := range []string{"readableType", "writableType"} {
+			typ, err := vmInstance.GetProperty(t, name)
+			if err != nil {
+				return nil, err
+			}
+			if typ.Type() != vm.TypeUndefined {
+				return nil, vmInstance.NewRangeError("TransformStream: invalid " + name)
+			}
+		}
+	}
+	methods := map[string]vm.Value{}
+	for _, name := range []string{"start", "transform", "flush", "cancel"} {
+		method, err := underlyingMethod(vmInstance, t, name, "transformer")
+		if err != nil {
+			return nil, err
+		}
+		methods[name] = method
+	}
+	writableStrategy, err := extractStrategy(vmInstance, writableStrategyArg, 1)
+	if err != nil {
+		return nil, err
+	}
+	readableStrategy, err := extractStrategy(vmInstance, readableStrategyArg, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	goTransformer := transformer{
+		start: func(c *transformController) (vm.Value, error) {
+			if !methods["start"].IsCallable() {
+				return vm.Undefined, nil
+			}
+			return vmInstance.Call(methods["start"], t, []vm.Value{c.object()})
+		},
+		flush: func(c *transformController) vm.Value {
+			return callUnderlying(vmInstance, methods["flush"], t, c.object())
+		},
+		cancel: func(reason vm.Value) vm.Value {
+			return callUnderlying(vmInstance, methods["cancel"], t, reason)
+		},
+	}
+	if methods["transform"].IsCallable() {
+		goTransformer.transform = func(chunk vm.Value, c *transformController) vm.Value {
+			return callUnderlying(vmInstance, methods["transform"], t, chunk, c.object())
+		}
+	}
+	return newTransformStream(vmInstance, goTransformer, writableStrategy, readableStrategy)
+}
+
+// setBackpressure records whether the readable side wants chunks, settling
+// the promise writes blocked on backpressure wait for
+func (ts *transformStream) setBackpressure(backpressure bool) {
+	if ts.backpressureChangePromise != nil {
+		ts.backpressureChangePromise.resolve(vm.Undefined)
+	}
+	ts.backpressureChangePromise = newPendingPromise(ts.vm)
+	ts.backpressure = backpressure
+}
+
+func (ts *transformStream) unblockWrite() {
+	if ts.backpressure {
+		ts.setBackpressure(false)
+	}
+}
+
+func (ts *transformStream) errorWritableAndUnblockWrite(e vm.Value) {
+	ts.controller.clearAlgorithms()
+	ts.writable.controller.errorIfNeeded(e)
+	ts.unblockWrite()
+}
+
+// errorBoth errors both sides of the stream
+func (ts *transformStream) errorBoth(e vm.Value) {
+	ts.readable.controller.error(e)
+	ts.errorWritableAndUnblockWrite(e)
+}
+
+// sinkWrite transforms a chunk written to the writable side, waiting for
+// the readable side to want it first
+func (ts *transformStream) sinkWrite(chunk vm.Value) vm.Value {
+	if !ts.backpressure {
+		return ts.controller.performTransform(chunk)
+	}
+	promise, resolve, reject := settled(ts.vm)
+	promiseReact(ts.vm, ts.backpressureChangePromise.promise, func(vm.Value) {
+		if ts.writable.state == "erroring" {
+			reject(ts.writable.storedError)
+			return
+		}
+		promiseReact(ts.vm, ts.controller.performTransform(chunk), resolve, reject)
+	}, nil)
+	return promise
+}
+
+func (ts *transformStream) sinkAbort(reason vm.Value) vm.Value {
+	c := ts.controller
+	if c.finishPromise != nil {
+		return c.finishPromise.promise
+	}
+	finish := newPendingPromise(ts.vm)
+	c.finishPromise = finish
+	result := vm.Undefined
+	if c.transformer.cancel != nil {
+		result = c.transformer.cancel(reason)
+	}
+	c.clearAlgorithms()
+	promiseReact(ts.vm, result, func(vm.Value) {
+		if ts.readable.state == "errored" {
+			finish.reject(ts.readable.storedError)
+			return
+		}
+		ts.readable.controller.error(reason)
+		finish.resolve(vm.Undefined)
+	}, func(r vm.Value) {
+		ts.readable.controller.error(r)
+		finish.reject(r)
+	})
+	return finish.promise
+}
+
+func (ts *transformStream) sinkClose() vm.Value {
+	c := ts.controller
+	if c.finishPromise != nil {
+		return c.finishPromise.promise
+	}
+	finish := newPendingPromise(ts.vm)
+	c.finishPromise = finish
+	result := vm.Undefined
+	if c.transformer.flush != nil {
+		result = c.transformer.flush(c)
+	}
+	c.clearAlgorithms()
+	promiseReact(ts.vm, result, func(vm.Value) {
+		if ts.readable.state == "errored" {
+			finish.reject(ts.readable.storedError)
+			return
+		}
+		if ts.readable.controller.canCloseOrEnqueue() {
+			_ = ts.readable.controller.close()
+		}
+		finish.resolve(vm.Undefined)
+	}, func(r vm.Value) {
+		ts.readable.controller.error(r)
+		finish.reject(r)
+	})
+	return finish.promise
+}
+
+func (ts *transformStream) sourcePull() vm.Value {
+	ts.setBackpressure(false)
+	return ts.backpressureChangePromise.promise
+}
+
+func (ts *transformStream) sourceCancel(reason vm.Value) vm.Value {
+	c := ts.controller
+	if c.finishPromise != nil {
+		return c.finishPromise.promise
+	}
+	finish := newPendingPromise(ts.vm)
+	c.finishPromise = finish
+	result := vm.Undefined
+	if c.transformer.cancel != nil {
+		result = c.transformer.cancel(reason)
+	}
+	c.clearAlgorithms()
+	promiseReact(ts.vm, result, func(vm.Value) {
+		if ts.writable.state == "errored" {
+			finish.reject(ts.writable.storedError)
+			return
+		}
+		ts.writable.controller.errorIfNeeded(reason)
+		ts.unblockWrite()
+		finish.resolve(vm.Undefined)
+	}, func(r vm.Value) {
+		ts.writable.controller.errorIfNeeded(r)
+		ts.unblockWrite()
+		finish.reject(r)
+	})
+	return finish.promise
+}
+
+func (c *transformController) clearAlgorithms() {
+	c.transformer = transformer{transform: func(vm.Value, *transformController) vm.Value { return vm.Undefined }}
+}
+
+// performTransform runs the transformer on chunk, erroring both sides if
+// it fails
+func (c *transformController) performTransform(chunk vm.Value) vm.Value {
+	vmInstance := c.stream.vm
+	var result vm.Value
+	if c.transformer.transform == nil {
+		if err := c.enqueue(chunk); err != nil {
+			result = vmInstance.NewRejectedPromise(thrownValue(err))
+		} else {
+			result = vmInstance.NewResolvedPromise(vm.Undefined)
+		}
+	} else {
+		result = c.transformer.transform(chunk, c)
+	}
+	promise, resolve, reject := settled(vmInstance)
+	promiseReact(vmInstance, result, resolve, func(r vm.Value) {
+		c.stream.errorBoth(r)
+		reject(r)
+	})
+	return promise
+}
+
+// enqueue adds chunk to the readable side
+func (c *transformController) enqueue(chunk vm.Value) error {
+	ts := c.stream
+	rc := ts.readable.controller
+	if !rc.canCloseOrEnqueue() {
+		return ts.vm.NewTypeError("Readable side is not in a state that permits enqueue")
+	}
+	if err := rc.enqueue(chunk); err != nil {
+		ts.errorWritableAndUnblockWrite(thrownValue(err))
+		return err
+	}
+	if backpressure := !rc.shouldCallPull(); backpressure && !ts.backpressure {
+		ts.setBackpressure(true)
+	}
+	return nil
+}
+
+// terminate closes the readable side and errors the writable side
+func (c *transformController) terminate() {
+	ts := c.stream
+	if ts.readable.controller.canCloseOrEnqueue() {
+		_ = ts.readable.controller.close()
+	}
+	ts.errorWritableAndUnblockWrite(typeErrorValue(ts.vm, "TransformStream terminated"))
+}
+
+// object returns the controller's JS object, creating it the first time
+func (c *transformController) object() vm.Value {
+	if c.obj.Type() != vm.TypeUndefined {
+		return c.obj
+	}
+	vmInstance := c.stream.vm
+	obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "TransformStreamDefaultController"))).AsPlainObject()
+	obj.SetHostData(c)
+	c.obj = vm.NewValueFromPlainObject(obj)
+
+	defineGetter(obj, "desiredSize", c.stream.readable.controller.desiredSize)
+	obj.SetOwnNonEnumerable("enqueue", vm.NewNativeFunction(1, false, "enqueue", func(args []vm.Value) (vm.Value, error) {
+		return vm.Undefined, c.enqueue(argOrUndefined(args, 0))
+	}))
+	obj.SetOwnNonEnumerable("error", vm.NewNativeFunction(1, false, "error", func(args []vm.Value) (vm.Value, error) {
+		c.stream.errorBoth(argOrUndefined(args, 0))
+		return vm.Undefined, nil
+	}))
+	obj.SetOwnNonEnumerable("terminate", vm.NewNativeFunction(0, false, "terminate", func(args []vm.Value) (vm.Value, error) {
+		c.terminate()
+		return vm.Undefined, nil
+	}))
+	return c.obj
+}
+
+// createTransformStreamObject creates the object of a TransformStream, or of
+// one of the codec streams built on it, which have the same shape
+func createTransformStreamObject(vmInstance *vm.VM, ts *transformStream, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetHostData(ts)
+	ts.obj = vm.NewValueFromPlainObject(obj)
+	defineGetter(obj, "readable", ts.readable.object)
+	defineGetter(obj, "writable", ts.writable.object)
+	return ts.obj
+}
+
+func initTransformStream(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	proto := newStreamPrototype(vmInstance, "TransformStream")
+	_, err := defineClass(ctx, "TransformStream", 0, proto, func(args []vm.Value) (vm.Value, error) {
+		ts, err := newTransformStreamFromTransformer(vmInstance, argOrUndefined(args, 0), argOrUndefined(args, 1), argOrUndefined(args, 2))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createTransformStreamObject(vmInstance, ts, proto), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = defineIllegalConstructor(ctx, "TransformStreamDefaultController")
+	return err
+}
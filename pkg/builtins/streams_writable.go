@@ -0,0 +1,685 @@
+package builtins
+
+import (
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// writableStream is the state of a WritableStream
+type writableStream struct {
+	vm           *vm.VM
+	state        string // "writable", "erroring", "errored" or "closed"
+	storedError  vm.Value
+	writer       *streamWriter
+	controller   *writableController
+	backpressure bool
+
+	// Promises of writes and of the close that haven't finished, with the
+	// functions settling them
+	writeRequests        []*pendingPromise
+	inFlightWriteRequest *pendingPromise
+	closeRequest         *pendingPromise
+	inFlightCloseRequest *pendingPromise
+	pendingAbortRequest  *abortRequest
+
+	obj vm.Value
+}
+
+// pendingPromise is a promise together with the functions settling it
+type pendingPromise struct {
+	promise vm.Value
+	resolve func(vm.Value)
+	reject  func(vm.Value)
+}
+
+func newPendingPromise(vmInstance *vm.VM) *pendingPromise {
+	p := &pendingPromise{}
+	p.promise, p.resolve, p.reject = settled(vmInstance)
+	return p
+}
+
+// isPending reports whether the promise hasn't settled yet
+func (p *pendingPromise) isPending() bool {
+	return p.promise.AsPromise().State == vm.PromisePending
+}
+
+type abortRequest struct {
+	*pendingPromise
+	reason             vm.Value
+	wasAlreadyErroring bool
+}
+
+// writableSink is an underlying sink. Like readableSource, write, close
+// and abort return a promise or a plain value.
+type writableSink struct {
+	start func(c *writableController) (vm.Value, error)
+	write func(chunk vm.Value, c *writableController) vm.Value
+	close func() vm.Value
+	abort func(reason vm.Value) vm.Value
+}
+
+// writableController is the state of a WritableStreamDefaultController. Its
+// queue holds the chunks waiting to be written, and the close sentinel.
+type writableController struct {
+	stream   *writableStream
+	sink     writableSink
+	queue    chunkQueue
+	strategy queuingStrategy
+	started  bool
+	signal   *AbortSignal // nil until something asks for it
+	obj      vm.Value
+}
+
+// streamWriter is the state of a WritableStreamDefaultWriter
+type streamWriter struct {
+	vm     *vm.VM
+	stream *writableStream // nil once released
+	ready  *pendingPromise
+	closed *pendingPromise
+}
+
+// newWritableStream creates a stream writing to sink
+func newWritableStream(vmInstance *vm.VM, sink writableSink, strategy queuingStrategy) (*writableStream, error) {
+	s := &writableStream{vm: vmInstance, state: "writable", storedError: vm.Undefined, obj: vm.Undefined}
+	c := &writableController{stream: s, sink: sink, strategy: strategy, obj: vm.Undefined}
+	s.controller = c
+	s.updateBackpressure(c.backpressure())
+
+	startResult := vm.Undefined
+	if sink.start != nil {
+		result, err := sink.start(c)
+		if err != nil {
+			return nil, err
+		}
+		startResult = result
+	}
+	promiseReact(vmInstance, startResult, func(vm.Value) {
+		c.started = true
+		c.advanceQueueIfNeeded()
+	}, func(r vm.Value) {
+		c.started = true
+		s.dealWithRejection(r)
+	})
+	return s, nil
+}
+
+// newWritableStreamFromSink creates a stream from the underlying sink and
+// queuing strategy objects passed to the WritableStream constructor
+func newWritableStreamFromSink(vmInstance *vm.VM, sink, strategyArg vm.Value) (*writableStream, error) {
+	if sink.IsObject() {
+		typ, err := vmInstance.GetProperty(sink, "type")
+		if err != nil {
+			return nil, err
+		}
+		if typ.Type() != vm.TypeUndefined {
+			return nil, vmInstance.NewRangeError("WritableStream: invalid type")
+		}
+	}
+	methods := map[string]vm.Value{}
+	for _, name := range []string{"start", "write", "close", "abort"} {
+		method, err := underlyingMethod(vmInstance, sink, name, "underlyingSink")
+		if err != nil {
+			return nil, err
+		}
+		methods[name] = method
+	}
+	strategy, err := extractStrategy(vmInstance, strategyArg, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWritableStream(vmInstance, writableSink{
+		start: func(c *writableController) (vm.Value, error) {
+			if !methods["start"].IsCallable() {
+				return vm.Undefined, nil
+			}
+			return vmInstance.Call(methods["start"], sink, []vm.Value{c.object()})
+		},
+		write: func(chunk vm.Value, c *writableController) vm.Value {
+			return callUnderlying(vmInstance, methods["write"], sink, chunk, c.object())
+		},
+		close: func() vm.Value {
+			return callUnderlying(vmInstance, methods["close"], sink)
+		},
+		abort: func(reason vm.Value) vm.Value {
+			return callUnderlying(vmInstance, methods["abort"], sink, reason)
+		},
+	}, strategy)
+}
+
+// writableStreamOf returns the stream of a WritableStream object, or nil
+func writableStreamOf(v vm.Value) *writableStream {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	s, _ := v.AsPlainObject().HostData().(*writableStream)
+	return s
+}
+
+func (s *writableStream) locked() bool {
+	return s.writer != nil
+}
+
+func (s *writableStream) closeQueuedOrInFlight() bool {
+	return s.closeRequest != nil || s.inFlightCloseRequest != nil
+}
+
+func (s *writableStream) hasOperationMarkedInFlight() bool {
+	return s.inFlightWriteRequest != nil || s.inFlightCloseRequest != nil
+}
+
+// abort implements WritableStreamAbort, returning a promise
+func (s *writableStream) abort(reason vm.Value) vm.Value {
+	if s.state == "closed" || s.state == "errored" {
+		return s.vm.NewResolvedPromise(vm.Undefined)
+	}
+	s.controller.signalAbort(reason)
+	// Aborting the signal runs user code, which may have closed the stream
+	if s.state == "closed" || s.state == "errored" {
+		return s.vm.NewResolvedPromise(vm.Undefined)
+	}
+	if s.pendingAbortRequest != nil {
+		return s.pendingAbortRequest.promise
+	}
+	wasAlreadyErroring := s.state == "erroring"
+	if wasAlreadyErroring {
+		reason = vm.Undefined
+	}
+	req := &abortRequest{
+		pendingPromise:     newPendingPromise(s.vm),
+		reason:             reason,
+		wasAlreadyErroring: wasAlreadyErroring,
+	}
+	s.pendingAbortRequest = req
+	if !wasAlreadyErroring {
+		s.startErroring(reason)
+	}
+	return req.promise
+}
+
+// close implements WritableStreamClose, returning a promise
+func (s *writableStream) close() vm.Value {
+	if s.state == "closed" || s.state == "errored" {
+		return s.vm.NewRejectedPromise(typeErrorValue(s.vm, "Cannot close a stream that is closed or errored"))
+	}
+	req := newPendingPromise(s.vm)
+	s.closeRequest = req
+	if s.writer != nil && s.backpressure && s.state == "writable" {
+		s.writer.ready.resolve(vm.Undefined)
+	}
+	s.controller.queue.chunks = append(s.controller.queue.chunks, queuedChunk{close: true})
+	s.controller.advanceQueueIfNeeded()
+	return req.promise
+}
+
+// dealWithRejection errors the stream after its sink failed
+func (s *writableStream) dealWithRejection(e vm.Value) {
+	if s.state == "writable" {
+		s.startErroring(e)
+		return
+	}
+	s.finishErroring()
+}
+
+// startErroring moves the stream to "erroring", where it waits for the
+// write or close in flight before erroring
+func (s *writableStream) startErroring(reason vm.Value) {
+	s.state = "erroring"
+	s.storedError = reason
+	if s.writer != nil {
+		s.writer.ensureReadyRejected(reason)
+	}
+	if !s.hasOperationMarkedInFlight() && s.controller.started {
+		s.finishErroring()
+	}
+}
+
+// finishErroring errors the stream, failing the queued writes, and aborts
+// the sink if abort() was called
+func (s *writableStream) finishErroring() {
+	s.state = "errored"
+	s.controller.queue.reset()
+	for _, req := range s.writeRequests {
+		req.reject(s.storedError)
+	}
+	s.writeRequests = nil
+
+	req := s.pendingAbortRequest
+	if req == nil {
+		s.rejectCloseAndClosedIfNeeded()
+		return
+	}
+	s.pendingAbortRequest = nil
+	if req.wasAlreadyErroring {
+		req.reject(s.storedError)
+		s.rejectCloseAndClosedIfNeeded()
+		return
+	}
+	result := vm.Undefined
+	if abort := s.controller.sink.abort; abort != nil {
+		result = abort(req.reason)
+	}
+	s.controller.clearAlgorithms()
+	promiseReact(s.vm, result, func(vm.Value) {
+		req.resolve(vm.Undefined)
+		s.rejectCloseAndClosedIfNeeded()
+	}, func(r vm.Value) {
+		req.reject(r)
+		s.rejectCloseAndClosedIfNeeded()
+	})
+}
+
+func (s *writableStream) rejectCloseAndClosedIfNeeded() {
+	if s.closeRequest != nil {
+		s.closeRequest.reject(s.storedError)
+		s.closeRequest = nil
+	}
+	if s.writer != nil {
+		s.writer.closed.reject(s.storedError)
+	}
+}
+
+func (s *writableStream) finishInFlightWrite() {
+	s.inFlightWriteRequest.resolve(vm.Undefined)
+	s.inFlightWriteRequest = nil
+}
+
+func (s *writableStream) finishInFlightWriteWithError(e vm.Value) {
+	s.inFlightWriteRequest.reject(e)
+	s.inFlightWriteRequest = nil
+	s.dealWithRejection(e)
+}
+
+func (s *writableStream) finishInFlightClose() {
+	s.inFlightCloseRequest.resolve(vm.Undefined)
+	s.inFlightCloseRequest = nil
+	if s.state == "erroring" {
+		// The close won the race with abort()
+		s.storedError = vm.Undefined
+		if s.pendingAbortRequest != nil {
+			s.pendingAbortRequest.resolve(vm.Undefined)
+			s.pendingAbortRequest = nil
+		}
+	}
+	s.state = "closed"
+	if s.writer != nil {
+		s.writer.closed.resolve(vm.Undefined)
+	}
+}
+
+func (s *writableStream) finishInFlightCloseWithError(e vm.Value) {
+	s.inFlightCloseRequest.reject(e)
+	s.inFlightCloseRequest = nil
+	if s.pendingAbortRequest != nil {
+		s.pendingAbortRequest.reject(e)
+		s.pendingAbortRequest = nil
+	}
+	s.dealWithRejection(e)
+}
+
+// updateBackpressure swaps the writer's ready promise for a pending one
+// when the queue fills up, and resolves it once it drains
+func (s *writableStream) updateBackpressure(backpressure bool) {
+	if s.writer != nil && backpressure != s.backpressure {
+		if backpressure {
+			s.writer.ready = newPendingPromise(s.vm)
+		} else {
+			s.writer.ready.resolve(vm.Undefined)
+		}
+	}
+	s.backpressure = backpressure
+}
+
+func (c *writableController) desiredSize() float64 {
+	return c.strategy.highWaterMark - c.queue.totalSize
+}
+
+func (c *writableController) backpressure() bool {
+	return c.desiredSize() <= 0
+}
+
+func (c *writableController) clearAlgorithms() {
+	c.sink = writableSink{}
+	c.strategy.size = vm.Undefined
+}
+
+// chunkSize measures chunk with the strategy, erroring the stream when the
+// size function throws
+func (c *writableController) chunkSize(chunk vm.Value) float64 {
+	size, err := c.strategy.chunkSize(c.stream.vm, chunk)
+	if err != nil {
+		c.errorIfNeeded(thrownValue(err))
+		return 1
+	}
+	return size
+}
+
+// write queues chunk for the sink
+func (c *writableController) write(chunk vm.Value, size float64) {
+	s := c.stream
+	if err := c.queue.enqueue(s.vm, chunk, size); err != nil {
+		c.errorIfNeeded(thrownValue(err))
+		return
+	}
+	if !s.closeQueuedOrInFlight() && s.state == "writable" {
+		s.updateBackpressure(c.backpressure())
+	}
+	c.advanceQueueIfNeeded()
+}
+
+// advanceQueueIfNeeded hands the sink the next chunk, or the close, once it
+// finished with the previous one
+func (c *writableController) advanceQueueIfNeeded() {
+	s := c.stream
+	if !c.started || s.inFlightWriteRequest != nil {
+		return
+	}
+	if s.state == "erroring" {
+		s.finishErroring()
+		return
+	}
+	if len(c.queue.chunks) == 0 {
+		return
+	}
+	if c.queue.chunks[0].close {
+		c.processClose()
+	} else {
+		c.processWrite(c.queue.chunks[0].value)
+	}
+}
+
+func (c *writableController) processClose() {
+	s := c.stream
+	s.inFlightCloseRequest = s.closeRequest
+	s.closeRequest = nil
+	c.queue.dequeue()
+	result := vm.Undefined
+	if c.sink.close != nil {
+		result = c.sink.close()
+	}
+	c.clearAlgorithms()
+	promiseReact(s.vm, result, func(vm.Value) { s.finishInFlightClose() }, s.finishInFlightCloseWithError)
+}
+
+func (c *writableController) processWrite(chunk vm.Value) {
+	s := c.stream
+	s.inFlightWriteRequest = s.writeRequests[0]
+	s.writeRequests = s.writeRequests[1:]
+	result := vm.Undefined
+	if c.sink.write != nil {
+		result = c.sink.write(chunk, c)
+	}
+	promiseReact(s.vm, result, func(vm.Value) {
+		s.finishInFlightWrite()
+		c.queue.dequeue()
+		if !s.closeQueuedOrInFlight() && s.state == "writable" {
+			s.updateBackpressure(c.backpressure())
+		}
+		c.advanceQueueIfNeeded()
+	}, func(r vm.Value) {
+		if s.state == "writable" {
+			c.clearAlgorithms()
+		}
+		s.finishInFlightWriteWithError(r)
+	})
+}
+
+// errorIfNeeded errors a stream that's still writable
+func (c *writableController) errorIfNeeded(e vm.Value) {
+	if c.stream.state == "writable" {
+		c.error(e)
+	}
+}
+
+func (c *writableController) error(e vm.Value) {
+	c.clearAlgorithms()
+	c.stream.startErroring(e)
+}
+
+// abortSignal returns the controller's signal, creating it the first time
+func (c *writableController) abortSignal() *AbortSignal {
+	if c.signal == nil {
+		c.signal = newAbortSignal(c.stream.vm)
+	}
+	return c.signal
+}
+
+// signalAbort aborts the controller's signal, if anything asked for it
+func (c *writableController) signalAbort(reason vm.Value) {
+	if c.signal != nil {
+		c.signal.Abort(reason)
+	}
+}
+
+// object returns the controller's JS object, creating it the first time
+func (c *writableController) object() vm.Value {
+	if c.obj.Type() != vm.TypeUndefined {
+		return c.obj
+	}
+	vmInstance := c.stream.vm
+	obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "WritableStreamDefaultController"))).AsPlainObject()
+	obj.SetHostData(c)
+	c.obj = vm.NewValueFromPlainObject(obj)
+
+	defineGetter(obj, "signal", func() vm.Value { return c.abortSignal().object() })
+	obj.SetOwnNonEnumerable("error", vm.NewNativeFunction(1, false, "error", func(args []vm.Value) (vm.Value, error) {
+		if c.stream.state == "writable" {
+			c.error(argOrUndefined(args, 0))
+		}
+		return vm.Undefined, nil
+	}))
+	return c.obj
+}
+
+// acquireWriter locks s to a new writer
+func acquireWriter(s *writableStream) (*streamWriter, error) {
+	if s.locked() {
+		return nil, s.vm.NewTypeError("WritableStream is locked to a writer")
+	}
+	w := &streamWriter{vm: s.vm, stream: s, ready: newPendingPromise(s.vm), closed: newPendingPromise(s.vm)}
+	switch s.state {
+	case "writable":
+		if s.closeQueuedOrInFlight() || !s.backpressure {
+			w.ready.resolve(vm.Undefined)
+		}
+	case "erroring":
+		w.ready.reject(s.storedError)
+	case "closed":
+		w.ready.resolve(vm.Undefined)
+		w.closed.resolve(vm.Undefined)
+	case "errored":
+		w.ready.reject(s.storedError)
+		w.closed.reject(s.storedError)
+	}
+	s.writer = w
+	return w, nil
+}
+
+func (w *streamWriter) ensureReadyRejected(e vm.Value) {
+	if !w.ready.isPending() {
+		w.ready = newPendingPromise(w.vm)
+	}
+	w.ready.reject(e)
+}
+
+func (w *streamWriter) ensureClosedRejected(e vm.Value) {
+	if !w.closed.isPending() {
+		w.closed = newPendingPromise(w.vm)
+	}
+	w.closed.reject(e)
+}
+
+// desiredSize returns how much more the stream's queue wants, or null once
+// it errored
+func (w *streamWriter) desiredSize() vm.Value {
+	switch w.stream.state {
+	case "errored", "erroring":
+		return vm.Null
+	case "closed":
+		return vm.NumberValue(0)
+	}
+	return vm.NumberValue(w.stream.controller.desiredSize())
+}
+
+// write implements WritableStreamDefaultWriterWrite, returning a promise
+func (w *streamWriter) write(chunk vm.Value) vm.Value {
+	s := w.stream
+	size := s.controller.chunkSize(chunk)
+	if s != w.stream {
+		return w.vm.NewRejectedPromise(typeErrorValue(w.vm, "Writer was released"))
+	}
+	switch {
+	case s.state == "errored":
+		return w.vm.NewRejectedPromise(s.storedError)
+	case s.closeQueuedOrInFlight() || s.state == "closed":
+		return w.vm.NewRejectedPromise(typeErrorValue(w.vm, "Cannot write to a stream that is closing or closed"))
+	case s.state == "erroring":
+		return w.vm.NewRejectedPromise(s.storedError)
+	}
+	req := newPendingPromise(w.vm)
+	s.writeRequests = append(s.writeRequests, req)
+	s.controller.write(chunk, size)
+	return req.promise
+}
+
+// closeWithErrorPropagation closes the stream unless it's already closing,
+// for pipes
+func (w *streamWriter) closeWithErrorPropagation() vm.Value {
+	s := w.stream
+	switch {
+	case s.closeQueuedOrInFlight() || s.state == "closed":
+		return w.vm.NewResolvedPromise(vm.Undefined)
+	case s.state == "errored":
+		return w.vm.NewRejectedPromise(s.storedError)
+	}
+	return s.close()
+}
+
+// release unlocks the stream, rejecting the writer's promises
+func (w *streamWriter) release() {
+	s := w.stream
+	if s == nil {
+		return
+	}
+	err := typeErrorValue(w.vm, "Writer was released")
+	w.ensureReadyRejected(err)
+	w.ensureClosedRejected(err)
+	s.writer = nil
+	w.stream = nil
+}
+
+func releasedWriterError(vmInstance *vm.VM) vm.Value {
+	return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Writer was released"))
+}
+
+func createWriterObject(vmInstance *vm.VM, w *streamWriter) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "WritableStreamDefaultWriter"))).AsPlainObject()
+	obj.SetHostData(w)
+
+	defineGetter(obj, "closed", func() vm.Value { return w.closed.promise })
+	defineGetter(obj, "ready", func() vm.Value { return w.ready.promise })
+	defineGetter(obj, "desiredSize", func() vm.Value {
+		if w.stream == nil {
+			return vm.Null
+		}
+		return w.desiredSize()
+	})
+	obj.SetOwnNonEnumerable("write", vm.NewNativeFunction(1, false, "write", func(args []vm.Value) (vm.Value, error) {
+		if w.stream == nil {
+			return releasedWriterError(vmInstance), nil
+		}
+		return w.write(argOrUndefined(args, 0)), nil
+	}))
+	obj.SetOwnNonEnumerable("close", vm.NewNativeFunction(0, false, "close", func(args []vm.Value) (vm.Value, error) {
+		if w.stream == nil {
+			return releasedWriterError(vmInstance), nil
+		}
+		if w.stream.closeQueuedOrInFlight() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot close a stream that is already closing")), nil
+		}
+		return w.stream.close(), nil
+	}))
+	obj.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
+		if w.stream == nil {
+			return releasedWriterError(vmInstance), nil
+		}
+		return w.stream.abort(argOrUndefined(args, 0)), nil
+	}))
+	obj.SetOwnNonEnumerable("releaseLock", vm.NewNativeFunction(0, false, "releaseLock", func(args []vm.Value) (vm.Value, error) {
+		w.release()
+		return vm.Undefined, nil
+	}))
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// object returns the stream's WritableStream object, creating it the first time
+func (s *writableStream) object() vm.Value {
+	if s.obj.Type() == vm.TypeUndefined {
+		createWritableStreamObject(s.vm, s, globalPrototype(s.vm, "WritableStream"))
+	}
+	return s.obj
+}
+
+func createWritableStreamObject(vmInstance *vm.VM, s *writableStream, proto *vm.PlainObject) vm.Value {
+	obj := vm.NewObject(vm.NewValueFromPlainObject(proto)).AsPlainObject()
+	obj.SetHostData(s)
+	s.obj = vm.NewValueFromPlainObject(obj)
+
+	defineGetter(obj, "locked", func() vm.Value { return vm.BooleanValue(s.locked()) })
+	obj.SetOwnNonEnumerable("abort", vm.NewNativeFunction(1, false, "abort", func(args []vm.Value) (vm.Value, error) {
+		if s.locked() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot abort a stream that already has a writer")), nil
+		}
+		return s.abort(argOrUndefined(args, 0)), nil
+	}))
+	obj.SetOwnNonEnumerable("close", vm.NewNativeFunction(0, false, "close", func(args []vm.Value) (vm.Value, error) {
+		if s.locked() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot close a stream that already has a writer")), nil
+		}
+		if s.closeQueuedOrInFlight() {
+			return vmInstance.NewRejectedPromise(typeErrorValue(vmInstance, "Cannot close a stream that is already closing")), nil
+		}
+		return s.close(), nil
+	}))
+	obj.SetOwnNonEnumerable("getWriter", vm.NewNativeFunction(0, false, "getWriter", func(args []vm.Value) (vm.Value, error) {
+		w, err := acquireWriter(s)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createWriterObject(vmInstance, w), nil
+	}))
+	return s.obj
+}
+
+func initWritableStream(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	proto := newStreamPrototype(vmInstance, "WritableStream")
+	_, err := defineClass(ctx, "WritableStream", 0, proto, func(args []vm.Value) (vm.Value, error) {
+		s, err := newWritableStreamFromSink(vmInstance, argOrUndefined(args, 0), argOrUndefined(args, 1))
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createWritableStreamObject(vmInstance, s, proto), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = defineClass(ctx, "WritableStreamDefaultWriter", 1, newStreamPrototype(vmInstance, "WritableStreamDefaultWriter"), func(args []vm.Value) (vm.Value, error) {
+		s := writableStreamOf(argOrUndefined(args, 0))
+		if s == nil {
+			return vm.Undefined, vmInstance.NewTypeError("WritableStreamDefaultWriter: argument is not a WritableStream")
+		}
+		w, err := acquireWriter(s)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return createWriterObject(vmInstance, w), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = defineIllegalConstructor(ctx, "WritableStreamDefaultController")
+	return err
+}
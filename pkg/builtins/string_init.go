@@ -299,10 +299,10 @@ func (s *StringInitializer) InitTypes(ctx *TypeContext) error {
 		WithProperty("endsWith", types.NewOptionalFunction([]types.Type{types.String, types.Number}, types.Boolean, []bool{false, true})).
 		WithProperty("toLowerCase", types.NewSimpleFunction([]types.Type{}, types.String)).
 		WithProperty("toUpperCase", types.NewSimpleFunction([]types.Type{}, types.String)).
-		WithProperty("toLocaleLowerCase", types.NewOptionalFunction([]types.Type{types.String}, types.String, []bool{true})).
-		WithProperty("toLocaleUpperCase", types.NewOptionalFunction([]types.Type{types.String}, types.String, []bool{true})).
+		WithProperty("toLocaleLowerCase", types.NewOptionalFunction([]types.Type{types.Any}, types.String, []bool{true})).
+		WithProperty("toLocaleUpperCase", types.NewOptionalFunction([]types.Type{types.Any}, types.String, []bool{true})).
 		WithProperty("normalize", types.NewOptionalFunction([]types.Type{types.String}, types.String, []bool{true})).
-		WithProperty("localeCompare", types.NewSimpleFunction([]types.Type{types.String}, types.Number)).
+		WithProperty("localeCompare", types.NewOptionalFunction([]types.Type{types.String, types.Any, types.Any}, types.Number, []bool{false, true, true})).
 		WithProperty("trim", types.NewSimpleFunction([]types.Type{}, types.String)).
 		WithProperty("trimStart", types.NewSimpleFunction([]types.Type{}, types.String)).
 		WithProperty("trimEnd", types.NewSimpleFunction([]types.Type{}, types.String)).
@@ -966,8 +966,11 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 		if err != nil {
 			return vm.Undefined, err
 		}
-		// For now, use simple lower case (proper locale support requires Intl)
-		return vm.NewString(strings.ToLower(thisStr)), nil
+		result, err := localeCase(vmInstance, thisStr, argOrUndefined(args, 0), false)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vm.NewString(result), nil
 	}))
 
 	// String.prototype.toLocaleUpperCase - returns string converted to upper case, according to locale
@@ -981,8 +984,11 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 		if err != nil {
 			return vm.Undefined, err
 		}
-		// For now, use simple upper case (proper locale support requires Intl)
-		return vm.NewString(strings.ToUpper(thisStr)), nil
+		result, err := localeCase(vmInstance, thisStr, argOrUndefined(args, 0), true)
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vm.NewString(result), nil
 	}))
 
 	// String.prototype.normalize - returns Unicode Normalization Form of the string
@@ -1031,19 +1037,12 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 			return vm.Undefined, err
 		}
 
-		if len(args) < 1 {
-			return vm.NumberValue(0), nil
-		}
-
-		compareStr := args[0].ToString()
-
-		// Simple comparison (proper locale support requires Intl.Collator)
-		if thisStr < compareStr {
-			return vm.NumberValue(-1), nil
-		} else if thisStr > compareStr {
-			return vm.NumberValue(1), nil
+		// Compared like new Intl.Collator(locales, options).compare(this, that)
+		result, err := localeCompare(vmInstance, thisStr, argOrUndefined(args, 0).ToString(), argOrUndefined(args, 1), argOrUndefined(args, 2))
+		if err != nil {
+			return vm.Undefined, err
 		}
-		return vm.NumberValue(0), nil
+		return vm.NumberValue(float64(result)), nil
 	}))
 
 	stringProto.SetOwnNonEnumerable("trim", vm.NewNativeFunction(0, false, "trim", func(args []vm.Value) (vm.Value, error) {
@@ -1181,6 +1180,9 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 		if count == 0 || thisStr == "" {
 			return vm.NewString(""), nil
 		}
+		if err := vmInstance.ChargeAllocation(int64(len(thisStr)) * int64(count)); err != nil {
+			return vm.Undefined, err
+		}
 		return vm.NewString(strings.Repeat(thisStr, count)), nil
 	}))
 
@@ -1226,6 +1228,9 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 
 		// Calculate fill length and build result
 		fillLength := targetLength - stringLength
+		if err := vmInstance.ChargeAllocation(int64(targetLength)); err != nil {
+			return vm.Undefined, err
+		}
 		padUtf16 := vm.StringToUTF16(padString)
 
 		// Build the padding
@@ -1290,6 +1295,9 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 
 		// Calculate fill length and build result
 		fillLength := targetLength - stringLength
+		if err := vmInstance.ChargeAllocation(int64(targetLength)); err != nil {
+			return vm.Undefined, err
+		}
 		padUtf16 := vm.StringToUTF16(padString)
 
 		// Build the padding
@@ -1331,6 +1339,9 @@ func (s *StringInitializer) InitRuntime(ctx *RuntimeContext) error {
 			if argErr != nil {
 				return vm.Undefined, argErr
 			}
+			if err := vmInstance.ChargeAllocation(int64(len(result) + len(argStr))); err != nil {
+				return vm.Undefined, err
+			}
 			result += argStr
 		}
 		return vm.NewString(result), nil
@@ -0,0 +1,169 @@
+package builtins
+
+import (
+	"math"
+	"time"
+
+	"github.com/nooga/paserati/pkg/runtime"
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for timers
+const PriorityTimers = 190 // Before AbortController, whose AbortSignal.timeout schedules timers the same way
+
+// maxTimerDelay is the largest delay accepted by setTimeout (2^31-1 ms).
+// Larger values overflow to 1ms, matching browsers and Node.js.
+const maxTimerDelay = math.MaxInt32
+
+// TimersInitializer implements setTimeout, setInterval, setImmediate and their
+// clear counterparts on top of the VM's runtime.TimerRuntime
+type TimersInitializer struct{}
+
+func (t *TimersInitializer) Name() string {
+	return "timers"
+}
+
+func (t *TimersInitializer) Priority() int {
+	return PriorityTimers
+}
+
+func (t *TimersInitializer) InitTypes(ctx *TypeContext) error {
+	// TimerHandler: (...args: any[]) => void
+	handlerType := types.NewVariadicFunction([]types.Type{}, types.Void, &types.ArrayType{ElementType: types.Any})
+
+	// setTimeout(handler, timeout?, ...args): number
+	timerType := types.NewSignature(handlerType, types.Number).
+		WithOptionalAt(1).
+		WithRest(types.Any).
+		Returns(types.Number).
+		ToFunction()
+
+	// setImmediate(handler, ...args): number
+	immediateType := types.NewSignature(handlerType).
+		WithRest(types.Any).
+		Returns(types.Number).
+		ToFunction()
+
+	// clearTimeout(id?): void
+	clearType := types.NewOptionalFunction([]types.Type{types.Any}, types.Void, []bool{true})
+
+	defs := []struct {
+		name string
+		typ  types.Type
+	}{
+		{"setTimeout", timerType},
+		{"setInterval", timerType},
+		{"setImmediate", immediateType},
+		{"clearTimeout", clearType},
+		{"clearInterval", clearType},
+		{"clearImmediate", clearType},
+	}
+	for _, def := range defs {
+		if err := ctx.DefineGlobal(def.name, def.typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TimersInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	// The runtime is resolved per call so embedders can swap it via
+	// VM.SetAsyncRuntime after builtins are initialized
+	timerRuntime := func() (runtime.TimerRuntime, error) {
+		rt, ok := vmInstance.GetAsyncRuntime().(runtime.TimerRuntime)
+		if !ok {
+			return nil, vmInstance.NewTypeError("timers are not supported by the current async runtime")
+		}
+		return rt, nil
+	}
+
+	// makeCallback wraps a JS handler and its extra arguments as a macrotask
+	makeCallback := func(handler vm.Value, extra []vm.Value) runtime.TimerCallback {
+		return func() error {
+			_, err := vmInstance.Call(handler, vm.Undefined, extra)
+			return err
+		}
+	}
+
+	makeSetter := func(name string, repeat bool) vm.Value {
+		return vm.NewNativeFunction(2, true, name, func(args []vm.Value) (vm.Value, error) {
+			if len(args) == 0 || !args[0].IsCallable() {
+				return vm.Undefined, vmInstance.NewTypeError("The \"callback\" argument must be of type function")
+			}
+			rt, err := timerRuntime()
+			if err != nil {
+				return vm.Undefined, err
+			}
+			delay := 0.0
+			if len(args) > 1 {
+				delay = args[1].ToFloat()
+			}
+			var extra []vm.Value
+			if len(args) > 2 {
+				extra = append(extra, args[2:]...)
+			}
+			id := rt.SetTimer(timerDelay(delay), repeat, makeCallback(args[0], extra))
+			return vm.NumberValue(float64(id)), nil
+		})
+	}
+
+	setImmediateFn := vm.NewNativeFunction(1, true, "setImmediate", func(args []vm.Value) (vm.Value, error) {
+		if len(args) == 0 || !args[0].IsCallable() {
+			return vm.Undefined, vmInstance.NewTypeError("The \"callback\" argument must be of type function")
+		}
+		rt, err := timerRuntime()
+		if err != nil {
+			return vm.Undefined, err
+		}
+		var extra []vm.Value
+		if len(args) > 1 {
+			extra = append(extra, args[1:]...)
+		}
+		id := rt.SetImmediate(makeCallback(args[0], extra))
+		return vm.NumberValue(float64(id)), nil
+	})
+
+	// All clear functions share one ID space, so clearTimeout can cancel an
+	// interval and vice versa, as in browsers
+	makeClear := func(name string) vm.Value {
+		return vm.NewNativeFunction(1, false, name, func(args []vm.Value) (vm.Value, error) {
+			if len(args) == 0 || !args[0].IsNumber() {
+				return vm.Undefined, nil
+			}
+			if rt, ok := vmInstance.GetAsyncRuntime().(runtime.TimerRuntime); ok {
+				rt.ClearTimer(runtime.TimerID(args[0].ToFloat()))
+			}
+			return vm.Undefined, nil
+		})
+	}
+
+	globals := []struct {
+		name  string
+		value vm.Value
+	}{
+		{"setTimeout", makeSetter("setTimeout", false)},
+		{"setInterval", makeSetter("setInterval", true)},
+		{"setImmediate", setImmediateFn},
+		{"clearTimeout", makeClear("clearTimeout")},
+		{"clearInterval", makeClear("clearInterval")},
+		{"clearImmediate", makeClear("clearImmediate")},
+	}
+	for _, g := range globals {
+		if err := ctx.DefineGlobal(g.name, g.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timerDelay converts a JS timeout argument (milliseconds) to a duration.
+// NaN, negative and out-of-range values become the minimum delay.
+func timerDelay(ms float64) time.Duration {
+	if math.IsNaN(ms) || ms < 1 || ms > maxTimerDelay {
+		return time.Millisecond
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
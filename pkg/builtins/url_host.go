@@ -0,0 +1,566 @@
+package builtins
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Host parsing for the URL Standard: domains (with IDNA), IPv4 and IPv6
+// addresses and the opaque hosts of non-special URLs. Hosts are kept
+// serialized, which is all URL needs of them.
+
+var errInvalidHost = errors.New("invalid host")
+
+// parseHost implements the host parser. Hosts of non-special URLs are
+// opaque.
+func parseHost(input string, opaque bool) (string, error) {
+	if strings.HasPrefix(input, "[") {
+		if !strings.HasSuffix(input, "]") {
+			return "", errInvalidHost
+		}
+		address, err := parseIPv6(input[1 : len(input)-1])
+		if err != nil {
+			return "", err
+		}
+		return "[" + serializeIPv6(address) + "]", nil
+	}
+	if opaque {
+		return parseOpaqueHost(input)
+	}
+	domain := strings.ToValidUTF8(string(percentDecode(input)), "�")
+	ascii, err := domainToASCII(domain)
+	if err != nil {
+		return "", err
+	}
+	if endsInANumber(ascii) {
+		address, err := parseIPv4(ascii)
+		if err != nil {
+			return "", err
+		}
+		return serializeIPv4(address), nil
+	}
+	return ascii, nil
+}
+
+// isForbiddenHostCodePoint reports whether hosts may not contain r
+func isForbiddenHostCodePoint(r rune) bool {
+	switch r {
+	case 0, '\t', '\n', '\r', ' ', '#', '/', ':', '<', '>', '?', '@', '[', '\\', ']', '^', '|':
+		return true
+	}
+	return false
+}
+
+// isForbiddenDomainCodePoint reports whether domains may not contain r
+func isForbiddenDomainCodePoint(r rune) bool {
+	return isForbiddenHostCodePoint(r) || r <= 0x1F || r == '%' || r == 0x7F
+}
+
+func parseOpaqueHost(input string) (string, error) {
+	for _, r := range input {
+		if r != '%' && isForbiddenHostCodePoint(r) {
+			return "", errInvalidHost
+		}
+	}
+	return percentEncodeString(input, inC0ControlSet), nil
+}
+
+// endsInANumber reports whether a domain's last label is a number, which
+// makes the domain an IPv4 address
+func endsInANumber(domain string) bool {
+	parts := strings.Split(domain, ".")
+	if parts[len(parts)-1] == "" {
+		if len(parts) == 1 {
+			return false
+		}
+		parts = parts[:len(parts)-1]
+	}
+	last := parts[len(parts)-1]
+	if last != "" && strings.Trim(last, "0123456789") == "" {
+		return true
+	}
+	_, err := parseIPv4Number(last)
+	return err == nil
+}
+
+// parseIPv4 implements the IPv4 parser, which accepts the decimal, octal
+// and hexadecimal shorthands inet_aton does
+func parseIPv4(input string) (uint32, error) {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 4 {
+		return 0, errInvalidHost
+	}
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, err := parseIPv4Number(part)
+		if err != nil {
+			return 0, err
+		}
+		if n > 255 && i != len(parts)-1 {
+			return 0, errInvalidHost
+		}
+		numbers[i] = n
+	}
+	last := numbers[len(numbers)-1]
+	if last >= 1<<(8*(5-len(numbers))) {
+		return 0, errInvalidHost
+	}
+	address := last
+	for i, n := range numbers[:len(numbers)-1] {
+		address += n << (8 * (3 - i))
+	}
+	return uint32(address), nil
+}
+
+func parseIPv4Number(input string) (uint64, error) {
+	if input == "" {
+		return 0, errInvalidHost
+	}
+	base := 10
+	if len(input) >= 2 && (input[:2] == "0x" || input[:2] == "0X") {
+		input, base = input[2:], 16
+	} else if len(input) >= 2 && input[0] == '0' {
+		input, base = input[1:], 8
+	}
+	if input == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(input, base, 64)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+			// Too big to be an address, but still a number
+			return 1 << 40, nil
+		}
+		return 0, errInvalidHost
+	}
+	return n, nil
+}
+
+func serializeIPv4(address uint32) string {
+	return strconv.Itoa(int(address>>24)) + "." + strconv.Itoa(int(address>>16&0xFF)) + "." +
+		strconv.Itoa(int(address>>8&0xFF)) + "." + strconv.Itoa(int(address&0xFF))
+}
+
+// parseIPv6 implements the IPv6 parser, including ::-compression and a
+// trailing dotted IPv4 address
+func parseIPv6(input string) ([8]uint16, error) {
+	var address [8]uint16
+	s := []rune(input)
+	pieceIndex, compress, p := 0, -1, 0
+	at := func(i int) rune {
+		if i < len(s) {
+			return s[i]
+		}
+		return -1
+	}
+
+	if at(p) == ':' {
+		if at(p+1) != ':' {
+			return address, errInvalidHost
+		}
+		p += 2
+		pieceIndex++
+		compress = pieceIndex
+	}
+	for at(p) != -1 {
+		if pieceIndex == 8 {
+			return address, errInvalidHost
+		}
+		if at(p) == ':' {
+			if compress != -1 {
+				return address, errInvalidHost
+			}
+			p++
+			pieceIndex++
+			compress = pieceIndex
+			continue
+		}
+		value, length := 0, 0
+		for length < 4 && isASCIIHexDigit(at(p)) {
+			value = value*16 + hexDigitValue(at(p))
+			p++
+			length++
+		}
+		if at(p) == '.' {
+			// An IPv4 address in the last two pieces
+			if length == 0 {
+				return address, errInvalidHost
+			}
+			p -= length
+			if pieceIndex > 6 {
+				return address, errInvalidHost
+			}
+			numbersSeen := 0
+			for at(p) != -1 {
+				ipv4Piece := -1
+				if numbersSeen > 0 {
+					if at(p) == '.' && numbersSeen < 4 {
+						p++
+					} else {
+						return address, errInvalidHost
+					}
+				}
+				if !isASCIIDigit(at(p)) {
+					return address, errInvalidHost
+				}
+				for isASCIIDigit(at(p)) {
+					n := int(at(p) - '0')
+					if ipv4Piece == -1 {
+						ipv4Piece = n
+					} else if ipv4Piece == 0 {
+						return address, errInvalidHost
+					} else {
+						ipv4Piece = ipv4Piece*10 + n
+					}
+					if ipv4Piece > 255 {
+						return address, errInvalidHost
+					}
+					p++
+				}
+				address[pieceIndex] = address[pieceIndex]*0x100 + uint16(ipv4Piece)
+				numbersSeen++
+				if numbersSeen == 2 || numbersSeen == 4 {
+					pieceIndex++
+				}
+			}
+			if numbersSeen != 4 {
+				return address, errInvalidHost
+			}
+			break
+		} else if at(p) == ':' {
+			p++
+			if at(p) == -1 {
+				return address, errInvalidHost
+			}
+		} else if at(p) != -1 {
+			return address, errInvalidHost
+		}
+		address[pieceIndex] = uint16(value)
+		pieceIndex++
+	}
+	if compress != -1 {
+		swaps := pieceIndex - compress
+		pieceIndex = 7
+		for pieceIndex != 0 && swaps > 0 {
+			address[pieceIndex], address[compress+swaps-1] = address[compress+swaps-1], address[pieceIndex]
+			pieceIndex--
+			swaps--
+		}
+	} else if pieceIndex != 8 {
+		return address, errInvalidHost
+	}
+	return address, nil
+}
+
+// serializeIPv6 writes address in lowercase hex, compressing its first
+// longest run of two or more zero pieces
+func serializeIPv6(address [8]uint16) string {
+	compress, longest := -1, 1
+	for i := 0; i < 8; {
+		if address[i] != 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < 8 && address[j] == 0 {
+			j++
+		}
+		if j-i > longest {
+			compress, longest = i, j-i
+		}
+		i = j
+	}
+	var b strings.Builder
+	ignore0 := false
+	for i := 0; i < 8; i++ {
+		if ignore0 && address[i] == 0 {
+			continue
+		}
+		ignore0 = false
+		if i == compress {
+			if i == 0 {
+				b.WriteString("::")
+			} else {
+				b.WriteString(":")
+			}
+			ignore0 = true
+			continue
+		}
+		b.WriteString(strconv.FormatUint(uint64(address[i]), 16))
+		if i != 7 {
+			b.WriteByte(':')
+		}
+	}
+	return b.String()
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isASCIIHexDigit(r rune) bool {
+	return isASCIIDigit(r) || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+func hexDigitValue(r rune) int {
+	switch {
+	case r >= 'a':
+		return int(r-'a') + 10
+	case r >= 'A':
+		return int(r-'A') + 10
+	}
+	return int(r - '0')
+}
+
+// domainToASCII implements domain to ASCII, UTS #46 processing without
+// the STD3 and DNS length checks. Mapping is approximated by lowercasing
+// and NFKC, which covers the case, width and compatibility mappings URLs
+// meet in practice.
+func domainToASCII(domain string) (string, error) {
+	ascii := true
+	for i := 0; i < len(domain); i++ {
+		if domain[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		domain = strings.ToLower(domain)
+	} else {
+		domain = idnaMap(domain)
+	}
+
+	labels := strings.Split(domain, ".")
+	for i, label := range labels {
+		if strings.HasPrefix(label, "xn--") {
+			// Punycode labels must decode to valid labels that need it
+			decoded, err := punycodeDecode(label[4:])
+			if err != nil || decoded == "" || !validIDNALabel(decoded) || isASCIIString(decoded) {
+				return "", errInvalidHost
+			}
+			continue
+		}
+		if isASCIIString(label) {
+			continue
+		}
+		if !validIDNALabel(label) {
+			return "", errInvalidHost
+		}
+		labels[i] = "xn--" + punycodeEncode(label)
+	}
+	result := strings.Join(labels, ".")
+	if result == "" {
+		return "", errInvalidHost
+	}
+	for _, r := range result {
+		if isForbiddenDomainCodePoint(r) {
+			return "", errInvalidHost
+		}
+	}
+	return result, nil
+}
+
+// idnaMap maps a domain for IDNA: ignored code points go, the ideographic
+// full stops become dots, and the rest is lowercased and NFKC normalized
+func idnaMap(domain string) string {
+	var b strings.Builder
+	for _, r := range domain {
+		switch {
+		case r == 0xAD, r == 0x34F, r >= 0x180B && r <= 0x180F, r == 0x200B, r == 0x2060, r == 0x2064,
+			r >= 0xFE00 && r <= 0xFE0F, r == 0xFEFF, r >= 0x1BCA0 && r <= 0x1BCA3:
+			// Ignored
+		default:
+			b.WriteRune(r)
+		}
+	}
+	mapped := norm.NFKC.String(strings.ToLower(norm.NFKC.String(b.String())))
+	return strings.NewReplacer("。", ".", "．", ".", "｡", ".").Replace(mapped)
+}
+
+// validIDNALabel checks a Unicode label: it must be NFC, must not start
+// with a combining mark, and may only hold letters, marks, digits and
+// hyphens. Joiners are rejected, as CheckJoiners would outside the scripts
+// that use them.
+func validIDNALabel(label string) bool {
+	if !norm.NFC.IsNormalString(label) {
+		return false
+	}
+	for i, r := range label {
+		if i == 0 && unicode.Is(unicode.M, r) {
+			return false
+		}
+		if r == '-' || r < utf8.RuneSelf && !isForbiddenDomainCodePoint(r) {
+			continue
+		}
+		if !unicode.IsLetter(r) && !unicode.Is(unicode.M, r) && !unicode.IsDigit(r) && !unicode.Is(unicode.No, r) && !unicode.Is(unicode.So, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode (RFC 3492) parameters
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+func punycodeAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punycodeThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punycodeTMin
+	case k >= bias+punycodeTMax:
+		return punycodeTMax
+	}
+	return k - bias
+}
+
+// punycodeEncode encodes a label, without the xn-- prefix
+func punycodeEncode(label string) string {
+	input := []rune(label)
+	var out []byte
+	for _, r := range input {
+		if r < 0x80 {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for handled < len(input) {
+		m := int(^uint(0) >> 1)
+		for _, r := range input {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range input {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) == n {
+				q := delta
+				for k := punycodeBase; ; k += punycodeBase {
+					t := punycodeThreshold(k, bias)
+					if q < t {
+						break
+					}
+					out = append(out, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+					q = (q - t) / (punycodeBase - t)
+				}
+				out = append(out, punycodeDigit(q))
+				bias = punycodeAdapt(delta, handled+1, handled == basic)
+				delta = 0
+				handled++
+			}
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+// punycodeDecode decodes a label, without the xn-- prefix
+func punycodeDecode(encoded string) (string, error) {
+	var output []rune
+	rest := encoded
+	if i := strings.LastIndexByte(encoded, '-'); i >= 0 {
+		for _, c := range encoded[:i] {
+			if c >= 0x80 {
+				return "", errInvalidHost
+			}
+			output = append(output, c)
+		}
+		rest = encoded[i+1:]
+	}
+	n, i, bias := punycodeInitialN, 0, punycodeInitialBias
+	for p := 0; p < len(rest); {
+		oldI, w := i, 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if p >= len(rest) {
+				return "", errInvalidHost
+			}
+			c := rest[p]
+			p++
+			var digit int
+			switch {
+			case c >= 'a' && c <= 'z':
+				digit = int(c - 'a')
+			case c >= 'A' && c <= 'Z':
+				digit = int(c - 'A')
+			case c >= '0' && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				return "", errInvalidHost
+			}
+			i += digit * w
+			if i < 0 || i > utf8.MaxRune*(len(output)+1) {
+				return "", errInvalidHost
+			}
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			w *= punycodeBase - t
+		}
+		bias = punycodeAdapt(i-oldI, len(output)+1, oldI == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > utf8.MaxRune || n >= 0xD800 && n <= 0xDFFF {
+			return "", errInvalidHost
+		}
+		output = append(output[:i], append([]rune{rune(n)}, output[i:]...)...)
+		i++
+	}
+	return string(output), nil
+}
@@ -0,0 +1,368 @@
+package builtins
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Priority constant for URL and URLSearchParams
+const PriorityURL = 193 // Before fetch, which takes URLs and sends URLSearchParams
+
+// URLInitializer implements the URL Standard's URL and URLSearchParams
+type URLInitializer struct{}
+
+func (u *URLInitializer) Name() string {
+	return "URL"
+}
+
+func (u *URLInitializer) Priority() int {
+	return PriorityURL
+}
+
+func (u *URLInitializer) InitTypes(ctx *TypeContext) error {
+	optionalString := []bool{false, true}
+
+	searchParamsType := types.NewObjectType().
+		WithProperty("size", types.Number).
+		WithProperty("append", types.NewSimpleFunction([]types.Type{types.String, types.String}, types.Undefined)).
+		WithProperty("delete", types.NewOptionalFunction([]types.Type{types.String, types.String}, types.Undefined, optionalString)).
+		WithProperty("get", types.NewSimpleFunction([]types.Type{types.String}, types.NewUnionType(types.String, types.Null))).
+		WithProperty("getAll", types.NewSimpleFunction([]types.Type{types.String}, &types.ArrayType{ElementType: types.String})).
+		WithProperty("has", types.NewOptionalFunction([]types.Type{types.String, types.String}, types.Boolean, optionalString)).
+		WithProperty("set", types.NewSimpleFunction([]types.Type{types.String, types.String}, types.Undefined)).
+		WithProperty("sort", types.NewSimpleFunction([]types.Type{}, types.Undefined)).
+		WithProperty("toString", types.NewSimpleFunction([]types.Type{}, types.String)).
+		WithProperty("forEach", types.NewOptionalFunction([]types.Type{types.Any, types.Any}, types.Undefined, optionalString))
+	if iteratorType, found := ctx.GetType("__IteratorGeneric__"); found {
+		if iteratorGeneric, ok := iteratorType.(*types.GenericType); ok {
+			iteratorOf := func(t types.Type) types.Type {
+				return types.NewSimpleFunction([]types.Type{}, (&types.InstantiatedType{Generic: iteratorGeneric, TypeArguments: []types.Type{t}}).Substitute())
+			}
+			entries := iteratorOf(&types.TupleType{ElementTypes: []types.Type{types.String, types.String}})
+			searchParamsType = searchParamsType.
+				WithProperty("entries", entries).
+				WithProperty("keys", iteratorOf(types.String)).
+				WithProperty("values", iteratorOf(types.String)).
+				WithProperty("__COMPUTED_PROPERTY__", entries)
+		}
+	}
+
+	// init is a query string, a sequence of pairs or a record
+	searchParamsCtorType := types.NewObjectType().
+		WithConstructSignature(types.SigOptional([]types.Type{types.Any}, searchParamsType, []bool{true})).
+		WithProperty("prototype", searchParamsType)
+
+	urlType := types.NewObjectType().
+		WithProperty("href", types.String).
+		WithProperty("origin", types.String).
+		WithProperty("protocol", types.String).
+		WithProperty("username", types.String).
+		WithProperty("password", types.String).
+		WithProperty("host", types.String).
+		WithProperty("hostname", types.String).
+		WithProperty("port", types.String).
+		WithProperty("pathname", types.String).
+		WithProperty("search", types.String).
+		WithProperty("searchParams", searchParamsType).
+		WithProperty("hash", types.String).
+		WithProperty("toString", types.NewSimpleFunction([]types.Type{}, types.String)).
+		WithProperty("toJSON", types.NewSimpleFunction([]types.Type{}, types.String))
+
+	// URLs and bases are strings or URLs
+	urlInput := types.NewUnionType(types.String, urlType)
+	urlCtorType := types.NewObjectType().
+		WithConstructSignature(types.SigOptional([]types.Type{urlInput, urlInput}, urlType, optionalString)).
+		WithProperty("prototype", urlType).
+		WithProperty("canParse", types.NewOptionalFunction([]types.Type{urlInput, urlInput}, types.Boolean, optionalString)).
+		WithProperty("parse", types.NewOptionalFunction([]types.Type{urlInput, urlInput}, types.NewUnionType(urlType, types.Null), optionalString))
+
+	globals := []struct {
+		name     string
+		typ      types.Type
+		instance types.Type
+	}{
+		{"URLSearchParams", searchParamsCtorType, searchParamsType},
+		{"URL", urlCtorType, urlType},
+	}
+	for _, global := range globals {
+		if err := ctx.DefineGlobal(global.name, global.typ); err != nil {
+			return err
+		}
+		if err := ctx.DefineTypeAlias(global.name, global.instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *URLInitializer) InitRuntime(ctx *RuntimeContext) error {
+	vmInstance := ctx.VM
+
+	searchParamsProto := newTaggedPrototype(vmInstance, vmInstance.ObjectPrototype, "URLSearchParams")
+	initURLSearchParamsPrototype(vmInstance, searchParamsProto)
+	_, err := defineClass(ctx, "URLSearchParams", 0, searchParamsProto, func(args []vm.Value) (vm.Value, error) {
+		if vmInstance.GetNewTarget().Type() == vm.TypeUndefined {
+			return vm.Undefined, vmInstance.NewTypeError("Class constructor URLSearchParams cannot be invoked without 'new'")
+		}
+		params := &urlSearchParams{}
+		if err := initURLSearchParams(vmInstance, params, argOrUndefined(args, 0)); err != nil {
+			return vm.Undefined, err
+		}
+		return params.object(instancePrototype(vmInstance, searchParamsProto)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	urlProto := newTaggedPrototype(vmInstance, vmInstance.ObjectPrototype, "URL")
+	initURLPrototype(vmInstance, urlProto)
+	urlCtor, err := defineClass(ctx, "URL", 1, urlProto, func(args []vm.Value) (vm.Value, error) {
+		if vmInstance.GetNewTarget().Type() == vm.TypeUndefined {
+			return vm.Undefined, vmInstance.NewTypeError("Class constructor URL cannot be invoked without 'new'")
+		}
+		record, err := parseURLArgs(vmInstance, args)
+		if err != nil {
+			return vm.Undefined, vmInstance.NewTypeError("Invalid URL")
+		}
+		return newURLObject(record).object(instancePrototype(vmInstance, urlProto)), nil
+	})
+	if err != nil {
+		return err
+	}
+	statics := urlCtor.AsNativeFunctionWithProps().Properties
+
+	// URL.canParse(url, base?) - whether new URL(url, base) would succeed
+	statics.SetOwnNonEnumerable("canParse", vm.NewNativeFunction(1, false, "canParse", func(args []vm.Value) (vm.Value, error) {
+		_, err := parseURLArgs(vmInstance, args)
+		return vm.BooleanValue(err == nil), nil
+	}))
+
+	// URL.parse(url, base?) - new URL(url, base), or null where that throws
+	statics.SetOwnNonEnumerable("parse", vm.NewNativeFunction(1, false, "parse", func(args []vm.Value) (vm.Value, error) {
+		record, err := parseURLArgs(vmInstance, args)
+		if err != nil {
+			return vm.Null, nil
+		}
+		return newURLObject(record).object(vm.NewValueFromPlainObject(urlProto)), nil
+	}))
+	return nil
+}
+
+// urlInputType is the type of arguments that take a URL: a string or a URL
+// object
+func urlInputType(ctx *TypeContext) types.Type {
+	if ctor, ok := ctx.GetType("URL"); ok {
+		if obj, ok := ctor.(*types.ObjectType); ok && obj.Properties["prototype"] != nil {
+			return types.NewUnionType(types.String, obj.Properties["prototype"])
+		}
+	}
+	return types.String
+}
+
+// parseURLArgs parses the url and base arguments of the URL constructor
+func parseURLArgs(vmInstance *vm.VM, args []vm.Value) (*urlRecord, error) {
+	var base *urlRecord
+	if b := argOrUndefined(args, 1); b.Type() != vm.TypeUndefined {
+		var err error
+		if base, err = parseURL(toUSVString(vmInstance, b), nil); err != nil {
+			return nil, err
+		}
+	}
+	return parseURL(toUSVString(vmInstance, argOrUndefined(args, 0)), base)
+}
+
+// urlObject is the state of a URL object: its URL record and the
+// URLSearchParams that mirror its query
+type urlObject struct {
+	record    *urlRecord
+	params    *urlSearchParams
+	paramsObj vm.Value // created the first time searchParams is read
+}
+
+func newURLObject(record *urlRecord) *urlObject {
+	u := &urlObject{record: record, paramsObj: vm.Undefined}
+	u.params = &urlSearchParams{url: u, list: parseFormURLEncoded(record.query)}
+	return u
+}
+
+// object creates the URL's JS object with proto
+func (u *urlObject) object(proto vm.Value) vm.Value {
+	obj := vm.NewObject(proto).AsPlainObject()
+	obj.SetHostData(u)
+	return vm.NewValueFromPlainObject(obj)
+}
+
+// urlObjectOf returns the state of a URL object, or nil
+func urlObjectOf(v vm.Value) *urlObject {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	u, _ := v.AsPlainObject().HostData().(*urlObject)
+	return u
+}
+
+// object creates the JS object of params with proto
+func (p *urlSearchParams) object(proto vm.Value) vm.Value {
+	obj := vm.NewObject(proto).AsPlainObject()
+	obj.SetHostData(p)
+	return vm.NewValueFromPlainObject(obj)
+}
+
+func initURLPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	thisURL := func(name string) (*urlObject, error) {
+		if u := urlObjectOf(vmInstance.GetThis()); u != nil {
+			return u, nil
+		}
+		return nil, vmInstance.NewTypeError("URL.prototype." + name + " called on an object that is not a URL")
+	}
+
+	// Each component is an accessor; set is nil for the read-only ones
+	accessors := []struct {
+		name string
+		get  func(u *urlObject) string
+		set  func(u *urlObject, value string) error
+	}{
+		{"href", func(u *urlObject) string { return u.record.String() }, func(u *urlObject, value string) error {
+			record, err := parseURL(value, nil)
+			if err != nil {
+				return vmInstance.NewTypeError("Invalid URL")
+			}
+			*u.record = *record
+			u.params.list = parseFormURLEncoded(record.query)
+			return nil
+		}},
+		{"origin", func(u *urlObject) string { return u.record.origin() }, nil},
+		{"protocol", func(u *urlObject) string { return u.record.scheme + ":" }, func(u *urlObject, value string) error {
+			_ = basicParse(value+":", nil, u.record, stateSchemeStart)
+			return nil
+		}},
+		{"username", func(u *urlObject) string { return u.record.username }, func(u *urlObject, value string) error {
+			if !u.record.cannotHaveCredentialsOrPort() {
+				u.record.username = percentEncodeString(value, inUserinfoSet)
+			}
+			return nil
+		}},
+		{"password", func(u *urlObject) string { return u.record.password }, func(u *urlObject, value string) error {
+			if !u.record.cannotHaveCredentialsOrPort() {
+				u.record.password = percentEncodeString(value, inUserinfoSet)
+			}
+			return nil
+		}},
+		{"host", func(u *urlObject) string { return u.record.hostString() }, func(u *urlObject, value string) error {
+			if !u.record.opaquePath {
+				_ = basicParse(value, nil, u.record, stateHost)
+			}
+			return nil
+		}},
+		{"hostname", func(u *urlObject) string { return u.record.host }, func(u *urlObject, value string) error {
+			if !u.record.opaquePath {
+				_ = basicParse(value, nil, u.record, stateHostname)
+			}
+			return nil
+		}},
+		{"port", func(u *urlObject) string {
+			if u.record.port < 0 {
+				return ""
+			}
+			return strconv.Itoa(u.record.port)
+		}, func(u *urlObject, value string) error {
+			if u.record.cannotHaveCredentialsOrPort() {
+				return nil
+			}
+			if value == "" {
+				u.record.port = -1
+			} else {
+				_ = basicParse(value, nil, u.record, statePort)
+			}
+			return nil
+		}},
+		{"pathname", func(u *urlObject) string { return u.record.pathString() }, func(u *urlObject, value string) error {
+			if !u.record.opaquePath {
+				u.record.path = nil
+				_ = basicParse(value, nil, u.record, statePathStart)
+			}
+			return nil
+		}},
+		{"search", func(u *urlObject) string {
+			if u.record.query == "" {
+				return ""
+			}
+			return "?" + u.record.query
+		}, func(u *urlObject, value string) error {
+			if value == "" {
+				u.record.query, u.record.hasQuery = "", false
+				u.params.list = nil
+				u.record.stripTrailingSpacesFromOpaquePath()
+				return nil
+			}
+			input := strings.TrimPrefix(value, "?")
+			u.record.query, u.record.hasQuery = "", true
+			_ = basicParse(input, nil, u.record, stateQuery)
+			u.params.list = parseFormURLEncoded(input)
+			return nil
+		}},
+		{"hash", func(u *urlObject) string {
+			if u.record.fragment == "" {
+				return ""
+			}
+			return "#" + u.record.fragment
+		}, func(u *urlObject, value string) error {
+			if value == "" {
+				u.record.fragment, u.record.hasFrag = "", false
+				u.record.stripTrailingSpacesFromOpaquePath()
+				return nil
+			}
+			u.record.fragment, u.record.hasFrag = "", true
+			_ = basicParse(strings.TrimPrefix(value, "#"), nil, u.record, stateFragment)
+			return nil
+		}},
+	}
+	for _, accessor := range accessors {
+		getter := vm.NewNativeFunction(0, false, "get "+accessor.name, func(args []vm.Value) (vm.Value, error) {
+			u, err := thisURL(accessor.name)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			return vm.NewString(accessor.get(u)), nil
+		})
+		setter, hasSetter := vm.Undefined, accessor.set != nil
+		if hasSetter {
+			setter = vm.NewNativeFunction(1, false, "set "+accessor.name, func(args []vm.Value) (vm.Value, error) {
+				u, err := thisURL(accessor.name)
+				if err != nil {
+					return vm.Undefined, err
+				}
+				return vm.Undefined, accessor.set(u, toUSVString(vmInstance, argOrUndefined(args, 0)))
+			})
+		}
+		e, c := true, true
+		proto.DefineAccessorProperty(accessor.name, getter, true, setter, hasSetter, &e, &c)
+	}
+
+	searchParamsGetter := vm.NewNativeFunction(0, false, "get searchParams", func(args []vm.Value) (vm.Value, error) {
+		u, err := thisURL("searchParams")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		if u.paramsObj.Type() == vm.TypeUndefined {
+			u.paramsObj = u.params.object(vm.NewValueFromPlainObject(globalPrototype(vmInstance, "URLSearchParams")))
+		}
+		return u.paramsObj, nil
+	})
+	e, c := true, true
+	proto.DefineAccessorProperty("searchParams", searchParamsGetter, true, vm.Undefined, false, &e, &c)
+
+	// toString() and toJSON() both return href
+	for _, name := range []string{"toString", "toJSON"} {
+		proto.SetOwnNonEnumerable(name, vm.NewNativeFunction(0, false, name, func(args []vm.Value) (vm.Value, error) {
+			u, err := thisURL(name)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			return vm.NewString(u.record.String()), nil
+		}))
+	}
+}
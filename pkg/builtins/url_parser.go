@@ -0,0 +1,775 @@
+package builtins
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The URL Standard's basic URL parser and serializer. Parsing works on the
+// input's code points, states named as in the standard; setters rerun it
+// from a given state on an existing URL.
+
+var errInvalidURL = errors.New("Invalid URL")
+
+// urlRecord is a parsed URL. Its host, when it has one, is serialized; its
+// path is a list of segments or, for URLs like mailto:x, one opaque string.
+type urlRecord struct {
+	scheme     string
+	username   string
+	password   string
+	host       string
+	hasHost    bool
+	port       int // -1 for none
+	path       []string
+	opaquePath bool
+	query      string
+	hasQuery   bool
+	fragment   string
+	hasFrag    bool
+}
+
+// specialSchemes maps each special scheme to its default port, -1 for none
+var specialSchemes = map[string]int{
+	"ftp":   21,
+	"file":  -1,
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+}
+
+func (u *urlRecord) isSpecial() bool {
+	_, ok := specialSchemes[u.scheme]
+	return ok
+}
+
+func (u *urlRecord) defaultPort() int {
+	if port, ok := specialSchemes[u.scheme]; ok {
+		return port
+	}
+	return -1
+}
+
+func (u *urlRecord) includesCredentials() bool {
+	return u.username != "" || u.password != ""
+}
+
+// cannotHaveCredentialsOrPort reports whether the username, password and
+// port setters do nothing
+func (u *urlRecord) cannotHaveCredentialsOrPort() bool {
+	return !u.hasHost || u.host == "" || u.scheme == "file"
+}
+
+func (u *urlRecord) clone() *urlRecord {
+	c := *u
+	c.path = append([]string(nil), u.path...)
+	return &c
+}
+
+// shortenPath removes the path's last segment, except the drive letter of
+// a file URL
+func (u *urlRecord) shortenPath() {
+	if u.scheme == "file" && len(u.path) == 1 && isNormalizedWindowsDriveLetter(u.path[0]) {
+		return
+	}
+	if len(u.path) > 0 {
+		u.path = u.path[:len(u.path)-1]
+	}
+}
+
+// pathString serializes the path, as pathname returns it
+func (u *urlRecord) pathString() string {
+	if u.opaquePath {
+		if len(u.path) == 0 {
+			return ""
+		}
+		return u.path[0]
+	}
+	var b strings.Builder
+	for _, segment := range u.path {
+		b.WriteByte('/')
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// hostString serializes the host and port, as host returns them
+func (u *urlRecord) hostString() string {
+	if !u.hasHost {
+		return ""
+	}
+	if u.port >= 0 {
+		return u.host + ":" + strconv.Itoa(u.port)
+	}
+	return u.host
+}
+
+// String implements the URL serializer
+func (u *urlRecord) String() string {
+	var b strings.Builder
+	b.WriteString(u.scheme)
+	b.WriteByte(':')
+	if u.hasHost {
+		b.WriteString("//")
+		if u.includesCredentials() {
+			b.WriteString(u.username)
+			if u.password != "" {
+				b.WriteByte(':')
+				b.WriteString(u.password)
+			}
+			b.WriteByte('@')
+		}
+		b.WriteString(u.hostString())
+	} else if !u.opaquePath && len(u.path) > 1 && u.path[0] == "" {
+		b.WriteString("/.")
+	}
+	b.WriteString(u.pathString())
+	if u.hasQuery {
+		b.WriteByte('?')
+		b.WriteString(u.query)
+	}
+	if u.hasFrag {
+		b.WriteByte('#')
+		b.WriteString(u.fragment)
+	}
+	return b.String()
+}
+
+// origin serializes the URL's origin, "null" for opaque origins
+func (u *urlRecord) origin() string {
+	switch u.scheme {
+	case "http", "https", "ws", "wss", "ftp":
+		return u.scheme + "://" + u.hostString()
+	case "blob":
+		if inner, err := parseURL(u.pathString(), nil); err == nil && (inner.scheme == "http" || inner.scheme == "https") {
+			return inner.origin()
+		}
+	}
+	return "null"
+}
+
+// stripTrailingSpacesFromOpaquePath drops the spaces an opaque path may
+// end with once nothing follows it
+func (u *urlRecord) stripTrailingSpacesFromOpaquePath() {
+	if u.opaquePath && !u.hasFrag && !u.hasQuery && len(u.path) > 0 {
+		u.path[0] = strings.TrimRight(u.path[0], " ")
+	}
+}
+
+type urlState int
+
+const (
+	stateNone urlState = iota
+	stateSchemeStart
+	stateScheme
+	stateNoScheme
+	stateSpecialRelativeOrAuthority
+	statePathOrAuthority
+	stateRelative
+	stateRelativeSlash
+	stateSpecialAuthoritySlashes
+	stateSpecialAuthorityIgnoreSlashes
+	stateAuthority
+	stateHost
+	stateHostname
+	statePort
+	stateFile
+	stateFileSlash
+	stateFileHost
+	statePathStart
+	statePath
+	stateOpaquePath
+	stateQuery
+	stateFragment
+)
+
+// parseURL parses input against base, which may be nil
+func parseURL(input string, base *urlRecord) (*urlRecord, error) {
+	u := &urlRecord{port: -1}
+	if err := basicParse(input, base, u, stateNone); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// basicParse implements the basic URL parser. With a state override it
+// changes url in place, as the setters do, and returns errInvalidURL
+// when the input can't be applied; the setters ignore that.
+func basicParse(input string, base *urlRecord, url *urlRecord, stateOverride urlState) error {
+	if stateOverride == stateNone {
+		input = strings.TrimFunc(input, func(r rune) bool { return r <= ' ' })
+	}
+	input = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(input)
+
+	state := stateOverride
+	if state == stateNone {
+		state = stateSchemeStart
+	}
+	var buffer strings.Builder
+	atSignSeen, insideBrackets, passwordTokenSeen := false, false, false
+	codePoints := []rune(strings.ToValidUTF8(input, "�"))
+	const eof = rune(-1)
+	at := func(i int) rune {
+		if i < len(codePoints) {
+			return codePoints[i]
+		}
+		return eof
+	}
+
+	for p := 0; ; p++ {
+		c := at(p)
+		switch state {
+		case stateSchemeStart:
+			if isASCIIAlpha(c) {
+				buffer.WriteRune(toASCIILower(c))
+				state = stateScheme
+			} else if stateOverride == stateNone {
+				state = stateNoScheme
+				p--
+			} else {
+				return errInvalidURL
+			}
+
+		case stateScheme:
+			if isASCIIAlphanumeric(c) || c == '+' || c == '-' || c == '.' {
+				buffer.WriteRune(toASCIILower(c))
+			} else if c == ':' {
+				scheme := buffer.String()
+				if stateOverride != stateNone {
+					_, special := specialSchemes[scheme]
+					if url.isSpecial() != special {
+						return nil
+					}
+					if (url.includesCredentials() || url.port >= 0) && scheme == "file" {
+						return nil
+					}
+					if url.scheme == "file" && url.hasHost && url.host == "" {
+						return nil
+					}
+				}
+				url.scheme = scheme
+				if stateOverride != stateNone {
+					if url.port == url.defaultPort() {
+						url.port = -1
+					}
+					return nil
+				}
+				buffer.Reset()
+				switch {
+				case url.scheme == "file":
+					state = stateFile
+				case url.isSpecial() && base != nil && base.scheme == url.scheme:
+					state = stateSpecialRelativeOrAuthority
+				case url.isSpecial():
+					state = stateSpecialAuthoritySlashes
+				case at(p+1) == '/':
+					state = statePathOrAuthority
+					p++
+				default:
+					url.path = []string{""}
+					url.opaquePath = true
+					state = stateOpaquePath
+				}
+			} else if stateOverride == stateNone {
+				buffer.Reset()
+				state = stateNoScheme
+				p = -1
+			} else {
+				return errInvalidURL
+			}
+
+		case stateNoScheme:
+			if base == nil || base.opaquePath && c != '#' {
+				return errInvalidURL
+			} else if base.opaquePath && c == '#' {
+				url.scheme = base.scheme
+				url.path = append([]string(nil), base.path...)
+				url.opaquePath = true
+				url.query, url.hasQuery = base.query, base.hasQuery
+				url.fragment, url.hasFrag = "", true
+				state = stateFragment
+			} else if base.scheme != "file" {
+				state = stateRelative
+				p--
+			} else {
+				state = stateFile
+				p--
+			}
+
+		case stateSpecialRelativeOrAuthority:
+			if c == '/' && at(p+1) == '/' {
+				state = stateSpecialAuthorityIgnoreSlashes
+				p++
+			} else {
+				state = stateRelative
+				p--
+			}
+
+		case statePathOrAuthority:
+			if c == '/' {
+				state = stateAuthority
+			} else {
+				state = statePath
+				p--
+			}
+
+		case stateRelative:
+			url.scheme = base.scheme
+			if c == '/' {
+				state = stateRelativeSlash
+			} else if url.isSpecial() && c == '\\' {
+				state = stateRelativeSlash
+			} else {
+				url.username, url.password = base.username, base.password
+				url.host, url.hasHost, url.port = base.host, base.hasHost, base.port
+				url.path = append([]string(nil), base.path...)
+				url.query, url.hasQuery = base.query, base.hasQuery
+				if c == '?' {
+					url.query, url.hasQuery = "", true
+					state = stateQuery
+				} else if c == '#' {
+					url.fragment, url.hasFrag = "", true
+					state = stateFragment
+				} else if c != eof {
+					url.query, url.hasQuery = "", false
+					url.shortenPath()
+					state = statePath
+					p--
+				}
+			}
+
+		case stateRelativeSlash:
+			if url.isSpecial() && (c == '/' || c == '\\') {
+				state = stateSpecialAuthorityIgnoreSlashes
+			} else if c == '/' {
+				state = stateAuthority
+			} else {
+				url.username, url.password = base.username, base.password
+				url.host, url.hasHost, url.port = base.host, base.hasHost, base.port
+				state = statePath
+				p--
+			}
+
+		case stateSpecialAuthoritySlashes:
+			if c == '/' && at(p+1) == '/' {
+				state = stateSpecialAuthorityIgnoreSlashes
+				p++
+			} else {
+				state = stateSpecialAuthorityIgnoreSlashes
+				p--
+			}
+
+		case stateSpecialAuthorityIgnoreSlashes:
+			if c != '/' && c != '\\' {
+				state = stateAuthority
+				p--
+			}
+
+		case stateAuthority:
+			if c == '@' {
+				if atSignSeen {
+					s := buffer.String()
+					buffer.Reset()
+					buffer.WriteString("%40")
+					buffer.WriteString(s)
+				}
+				atSignSeen = true
+				for _, r := range buffer.String() {
+					if r == ':' && !passwordTokenSeen {
+						passwordTokenSeen = true
+						continue
+					}
+					encoded := percentEncodeRune(r, inUserinfoSet)
+					if passwordTokenSeen {
+						url.password += encoded
+					} else {
+						url.username += encoded
+					}
+				}
+				buffer.Reset()
+			} else if c == eof || c == '/' || c == '?' || c == '#' || url.isSpecial() && c == '\\' {
+				if atSignSeen && buffer.Len() == 0 {
+					return errInvalidURL
+				}
+				p -= utf8.RuneCountInString(buffer.String()) + 1
+				buffer.Reset()
+				state = stateHost
+			} else {
+				buffer.WriteRune(c)
+			}
+
+		case stateHost, stateHostname:
+			if stateOverride != stateNone && url.scheme == "file" {
+				p--
+				state = stateFileHost
+			} else if c == ':' && !insideBrackets {
+				if buffer.Len() == 0 {
+					return errInvalidURL
+				}
+				if stateOverride == stateHostname {
+					return errInvalidURL
+				}
+				host, err := parseHost(buffer.String(), !url.isSpecial())
+				if err != nil {
+					return errInvalidURL
+				}
+				url.host, url.hasHost = host, true
+				buffer.Reset()
+				state = statePort
+			} else if c == eof || c == '/' || c == '?' || c == '#' || url.isSpecial() && c == '\\' {
+				p--
+				if url.isSpecial() && buffer.Len() == 0 {
+					return errInvalidURL
+				} else if stateOverride != stateNone && buffer.Len() == 0 && (url.includesCredentials() || url.port >= 0) {
+					return errInvalidURL
+				}
+				host, err := parseHost(buffer.String(), !url.isSpecial())
+				if err != nil {
+					return errInvalidURL
+				}
+				url.host, url.hasHost = host, true
+				buffer.Reset()
+				state = statePathStart
+				if stateOverride != stateNone {
+					return nil
+				}
+			} else {
+				if c == '[' {
+					insideBrackets = true
+				}
+				if c == ']' {
+					insideBrackets = false
+				}
+				buffer.WriteRune(c)
+			}
+
+		case statePort:
+			if isASCIIDigit(c) {
+				buffer.WriteRune(c)
+			} else if c == eof || c == '/' || c == '?' || c == '#' || url.isSpecial() && c == '\\' || stateOverride != stateNone {
+				if buffer.Len() != 0 {
+					port, err := strconv.Atoi(buffer.String())
+					if err != nil || port > 65535 {
+						return errInvalidURL
+					}
+					if port == url.defaultPort() {
+						url.port = -1
+					} else {
+						url.port = port
+					}
+					buffer.Reset()
+					if stateOverride != stateNone {
+						return nil
+					}
+				}
+				if stateOverride != stateNone {
+					return errInvalidURL
+				}
+				state = statePathStart
+				p--
+			} else {
+				return errInvalidURL
+			}
+
+		case stateFile:
+			url.scheme = "file"
+			url.host, url.hasHost = "", true
+			if c == '/' || c == '\\' {
+				state = stateFileSlash
+			} else if base != nil && base.scheme == "file" {
+				url.host, url.hasHost = base.host, base.hasHost
+				url.path = append([]string(nil), base.path...)
+				url.query, url.hasQuery = base.query, base.hasQuery
+				if c == '?' {
+					url.query, url.hasQuery = "", true
+					state = stateQuery
+				} else if c == '#' {
+					url.fragment, url.hasFrag = "", true
+					state = stateFragment
+				} else if c != eof {
+					url.query, url.hasQuery = "", false
+					if !startsWithWindowsDriveLetter(codePoints[p:]) {
+						url.shortenPath()
+					} else {
+						url.path = nil
+					}
+					state = statePath
+					p--
+				}
+			} else {
+				state = statePath
+				p--
+			}
+
+		case stateFileSlash:
+			if c == '/' || c == '\\' {
+				state = stateFileHost
+			} else {
+				if base != nil && base.scheme == "file" {
+					url.host, url.hasHost = base.host, base.hasHost
+					if !startsWithWindowsDriveLetter(codePoints[p:]) && len(base.path) > 0 && isNormalizedWindowsDriveLetter(base.path[0]) {
+						url.path = append(url.path, base.path[0])
+					}
+				}
+				state = statePath
+				p--
+			}
+
+		case stateFileHost:
+			if c == eof || c == '/' || c == '\\' || c == '?' || c == '#' {
+				p--
+				if stateOverride == stateNone && isWindowsDriveLetter(buffer.String()) {
+					state = statePath
+				} else if buffer.Len() == 0 {
+					url.host, url.hasHost = "", true
+					if stateOverride != stateNone {
+						return nil
+					}
+					state = statePathStart
+				} else {
+					host, err := parseHost(buffer.String(), !url.isSpecial())
+					if err != nil {
+						return errInvalidURL
+					}
+					if host == "localhost" {
+						host = ""
+					}
+					url.host, url.hasHost = host, true
+					if stateOverride != stateNone {
+						return nil
+					}
+					buffer.Reset()
+					state = statePathStart
+				}
+			} else {
+				buffer.WriteRune(c)
+			}
+
+		case statePathStart:
+			if url.isSpecial() {
+				state = statePath
+				if c != '/' && c != '\\' {
+					p--
+				}
+			} else if stateOverride == stateNone && c == '?' {
+				url.query, url.hasQuery = "", true
+				state = stateQuery
+			} else if stateOverride == stateNone && c == '#' {
+				url.fragment, url.hasFrag = "", true
+				state = stateFragment
+			} else if c != eof {
+				state = statePath
+				if c != '/' {
+					p--
+				}
+			} else if stateOverride != stateNone && !url.hasHost {
+				url.path = append(url.path, "")
+			}
+
+		case statePath:
+			slash := c == '/' || url.isSpecial() && c == '\\'
+			if c == eof || slash || stateOverride == stateNone && (c == '?' || c == '#') {
+				segment := buffer.String()
+				switch {
+				case isDoubleDotSegment(segment):
+					url.shortenPath()
+					if !slash {
+						url.path = append(url.path, "")
+					}
+				case isSingleDotSegment(segment) && !slash:
+					url.path = append(url.path, "")
+				case !isSingleDotSegment(segment):
+					if url.scheme == "file" && len(url.path) == 0 && isWindowsDriveLetter(segment) {
+						segment = segment[:1] + ":"
+					}
+					url.path = append(url.path, segment)
+				}
+				buffer.Reset()
+				if c == '?' {
+					url.query, url.hasQuery = "", true
+					state = stateQuery
+				}
+				if c == '#' {
+					url.fragment, url.hasFrag = "", true
+					state = stateFragment
+				}
+			} else {
+				buffer.WriteString(percentEncodeRune(c, inPathSet))
+			}
+
+		case stateOpaquePath:
+			if c == '?' {
+				url.query, url.hasQuery = "", true
+				state = stateQuery
+			} else if c == '#' {
+				url.fragment, url.hasFrag = "", true
+				state = stateFragment
+			} else if c == ' ' {
+				next := at(p + 1)
+				if next == '?' || next == '#' {
+					url.path[0] += "%20"
+				} else {
+					url.path[0] += " "
+				}
+			} else if c != eof {
+				url.path[0] += percentEncodeRune(c, inC0ControlSet)
+			}
+
+		case stateQuery:
+			if stateOverride == stateNone && c == '#' || c == eof {
+				set := inQuerySet
+				if url.isSpecial() {
+					set = inSpecialQuerySet
+				}
+				url.query += percentEncodeString(buffer.String(), set)
+				buffer.Reset()
+				if c == '#' {
+					url.fragment, url.hasFrag = "", true
+					state = stateFragment
+				}
+			} else if c != eof {
+				buffer.WriteRune(c)
+			}
+
+		case stateFragment:
+			if c != eof {
+				url.fragment += percentEncodeRune(c, inFragmentSet)
+			}
+		}
+
+		if p >= len(codePoints) {
+			break
+		}
+	}
+	return nil
+}
+
+func isASCIIAlpha(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func isASCIIAlphanumeric(r rune) bool {
+	return isASCIIAlpha(r) || isASCIIDigit(r)
+}
+
+func toASCIILower(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+func isSingleDotSegment(s string) bool {
+	return s == "." || strings.EqualFold(s, "%2e")
+}
+
+func isDoubleDotSegment(s string) bool {
+	switch strings.ToLower(s) {
+	case "..", ".%2e", "%2e.", "%2e%2e":
+		return true
+	}
+	return false
+}
+
+// isWindowsDriveLetter reports whether s is like C: or C|
+func isWindowsDriveLetter(s string) bool {
+	return len(s) == 2 && isASCIIAlpha(rune(s[0])) && (s[1] == ':' || s[1] == '|')
+}
+
+// isNormalizedWindowsDriveLetter reports whether s is like C:
+func isNormalizedWindowsDriveLetter(s string) bool {
+	return isWindowsDriveLetter(s) && s[1] == ':'
+}
+
+// startsWithWindowsDriveLetter reports whether s starts with a drive
+// letter that ends it or is followed by /, \, ? or #
+func startsWithWindowsDriveLetter(s []rune) bool {
+	if len(s) < 2 || !isWindowsDriveLetter(string(s[:2])) {
+		return false
+	}
+	if len(s) == 2 {
+		return true
+	}
+	switch s[2] {
+	case '/', '\\', '?', '#':
+		return true
+	}
+	return false
+}
+
+// Percent-encode sets: each reports whether a code point must be encoded
+type percentEncodeSet func(r rune) bool
+
+func inC0ControlSet(r rune) bool {
+	return r < 0x20 || r > 0x7E
+}
+
+func inFragmentSet(r rune) bool {
+	return inC0ControlSet(r) || r == ' ' || r == '"' || r == '<' || r == '>' || r == '`'
+}
+
+func inQuerySet(r rune) bool {
+	return inC0ControlSet(r) || r == ' ' || r == '"' || r == '#' || r == '<' || r == '>'
+}
+
+func inSpecialQuerySet(r rune) bool {
+	return inQuerySet(r) || r == '\''
+}
+
+func inPathSet(r rune) bool {
+	return inQuerySet(r) || r == '?' || r == '^' || r == '`' || r == '{' || r == '}'
+}
+
+func inUserinfoSet(r rune) bool {
+	return inPathSet(r) || r == '/' || r == ':' || r == ';' || r == '=' || r == '@' || r >= '[' && r <= ']' || r == '|'
+}
+
+func inComponentSet(r rune) bool {
+	return inUserinfoSet(r) || r >= '$' && r <= '&' || r == '+' || r == ','
+}
+
+func inFormURLEncodedSet(r rune) bool {
+	return inComponentSet(r) || r == '!' || r >= '\'' && r <= ')' || r == '~'
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// percentEncodeRune encodes r's UTF-8 bytes if set contains it
+func percentEncodeRune(r rune, set percentEncodeSet) string {
+	if !set(r) {
+		return string(r)
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	var b strings.Builder
+	for _, c := range buf[:n] {
+		b.WriteByte('%')
+		b.WriteByte(upperHex[c>>4])
+		b.WriteByte(upperHex[c&0xF])
+	}
+	return b.String()
+}
+
+func percentEncodeString(s string, set percentEncodeSet) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteString(percentEncodeRune(r, set))
+	}
+	return b.String()
+}
+
+// percentDecode decodes %XX sequences, leaving malformed ones as they are
+func percentDecode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isASCIIHexDigit(rune(s[i+1])) && isASCIIHexDigit(rune(s[i+2])) {
+			out = append(out, byte(hexDigitValue(rune(s[i+1]))<<4|hexDigitValue(rune(s[i+2]))))
+			i += 2
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
@@ -0,0 +1,405 @@
+package builtins
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// urlSearchParams is the state of a URLSearchParams object: its list of
+// name-value pairs and, for a URL's searchParams, the URL whose query it
+// mirrors
+type urlSearchParams struct {
+	list []urlParam
+	url  *urlObject
+}
+
+type urlParam struct {
+	name, value string
+}
+
+// urlSearchParamsOf returns the state of a URLSearchParams object, or nil
+func urlSearchParamsOf(v vm.Value) *urlSearchParams {
+	if v.Type() != vm.TypeObject {
+		return nil
+	}
+	params, _ := v.AsPlainObject().HostData().(*urlSearchParams)
+	return params
+}
+
+// parseFormURLEncoded implements the application/x-www-form-urlencoded
+// parser
+func parseFormURLEncoded(input string) []urlParam {
+	var list []urlParam
+	for _, sequence := range strings.Split(input, "&") {
+		if sequence == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(sequence, "=")
+		list = append(list, urlParam{
+			name:  formURLDecode(name),
+			value: formURLDecode(value),
+		})
+	}
+	return list
+}
+
+func formURLDecode(s string) string {
+	return strings.ToValidUTF8(string(percentDecode(strings.ReplaceAll(s, "+", " "))), "�")
+}
+
+// String implements the application/x-www-form-urlencoded serializer
+func (p *urlSearchParams) String() string {
+	var b strings.Builder
+	for i, param := range p.list {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(formURLEncode(param.name))
+		b.WriteByte('=')
+		b.WriteString(formURLEncode(param.value))
+	}
+	return b.String()
+}
+
+func formURLEncode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == ' ' {
+			b.WriteByte('+')
+		} else {
+			b.WriteString(percentEncodeRune(r, inFormURLEncodedSet))
+		}
+	}
+	return b.String()
+}
+
+// update writes the list back to the URL's query, if there is a URL
+func (p *urlSearchParams) update() {
+	if p.url == nil {
+		return
+	}
+	record := p.url.record
+	serialized := p.String()
+	record.query, record.hasQuery = serialized, serialized != ""
+	if serialized == "" {
+		record.stripTrailingSpacesFromOpaquePath()
+	}
+}
+
+func (p *urlSearchParams) remove(name string, value *string) {
+	list := p.list[:0]
+	for _, param := range p.list {
+		if param.name != name || value != nil && param.value != *value {
+			list = append(list, param)
+		}
+	}
+	p.list = list
+}
+
+// sort stably sorts the pairs by name, comparing UTF-16 code units
+func (p *urlSearchParams) sort() {
+	sort.SliceStable(p.list, func(i, j int) bool {
+		return compareUTF16(p.list[i].name, p.list[j].name) < 0
+	})
+}
+
+// compareUTF16 compares strings by their UTF-16 code units, the order JS
+// compares strings in
+func compareUTF16(a, b string) int {
+	ua, ub := vm.StringToUTF16(a), vm.StringToUTF16(b)
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return int(ua[i]) - int(ub[i])
+		}
+	}
+	return len(ua) - len(ub)
+}
+
+// toUSVString converts v to a string as USVString arguments are, replacing
+// lone surrogates with U+FFFD
+func toUSVString(vmInstance *vm.VM, v vm.Value) string {
+	if v.IsObject() || v.IsCallable() {
+		v = vmInstance.ToPrimitive(v, "string")
+	}
+	s := v.ToString()
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if r == utf8.RuneError && size == 1 && len(s) >= 3 && s[0] == 0xED && s[1] >= 0xA0 {
+			// A surrogate, WTF-8 encoded
+			size = 3
+		}
+		b.WriteRune(r)
+		s = s[size:]
+	}
+	return b.String()
+}
+
+// initURLSearchParams fills params from the constructor's init argument: a
+// query string, a sequence of pairs, or a record
+func initURLSearchParams(vmInstance *vm.VM, params *urlSearchParams, init vm.Value) error {
+	if init.Type() == vm.TypeUndefined || init.Type() == vm.TypeNull {
+		return nil
+	}
+	if !init.IsObject() {
+		params.list = parseFormURLEncoded(strings.TrimPrefix(toUSVString(vmInstance, init), "?"))
+		return nil
+	}
+
+	if method, _ := vmInstance.GetSymbolProperty(init, SymbolIterator); method.IsCallable() {
+		pairs, err := iterableToList(vmInstance, init, method)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			if !pair.IsObject() {
+				return vmInstance.NewTypeError("Failed to construct 'URLSearchParams': The provided value cannot be converted to a sequence.")
+			}
+			pairMethod, _ := vmInstance.GetSymbolProperty(pair, SymbolIterator)
+			if !pairMethod.IsCallable() {
+				return vmInstance.NewTypeError("Failed to construct 'URLSearchParams': The provided value cannot be converted to a sequence.")
+			}
+			items, err := iterableToList(vmInstance, pair, pairMethod)
+			if err != nil {
+				return err
+			}
+			if len(items) != 2 {
+				return vmInstance.NewTypeError("Failed to construct 'URLSearchParams': Sequence initializer must only contain pair elements")
+			}
+			params.list = append(params.list, urlParam{toUSVString(vmInstance, items[0]), toUSVString(vmInstance, items[1])})
+		}
+		return nil
+	}
+
+	var keys []string
+	switch init.Type() {
+	case vm.TypeObject:
+		keys = init.AsPlainObject().OwnKeys()
+	case vm.TypeDictObject:
+		keys = init.AsDictObject().OwnKeys()
+	}
+	for _, key := range keys {
+		value, err := vmInstance.GetProperty(init, key)
+		if err != nil {
+			return err
+		}
+		params.list = append(params.list, urlParam{toUSVString(vmInstance, vm.NewString(key)), toUSVString(vmInstance, value)})
+	}
+	return nil
+}
+
+// iterableToList collects the values the iterator method returns
+func iterableToList(vmInstance *vm.VM, iterable, method vm.Value) ([]vm.Value, error) {
+	iterator, err := vmInstance.Call(method, iterable, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !iterator.IsObject() {
+		return nil, vmInstance.NewTypeError("Result of the Symbol.iterator method is not an object")
+	}
+	next, err := vmInstance.GetProperty(iterator, "next")
+	if err != nil {
+		return nil, err
+	}
+	var values []vm.Value
+	for {
+		result, err := vmInstance.Call(next, iterator, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !result.IsObject() {
+			return nil, vmInstance.NewTypeError("Iterator result " + result.ToString() + " is not an object")
+		}
+		done, err := vmInstance.GetProperty(result, "done")
+		if err != nil {
+			return nil, err
+		}
+		if done.IsTruthy() {
+			return values, nil
+		}
+		value, err := vmInstance.GetProperty(result, "value")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
+
+// initURLSearchParamsPrototype defines the URLSearchParams methods, and the
+// prototype of the iterators they return
+func initURLSearchParamsPrototype(vmInstance *vm.VM, proto *vm.PlainObject) {
+	thisParams := func(method string) (*urlSearchParams, error) {
+		if params := urlSearchParamsOf(vmInstance.GetThis()); params != nil {
+			return params, nil
+		}
+		return nil, vmInstance.NewTypeError("URLSearchParams.prototype." + method + " called on an object that is not a URLSearchParams")
+	}
+	argString := func(args []vm.Value, i int) string {
+		return toUSVString(vmInstance, argOrUndefined(args, i))
+	}
+	// optionalValue returns the value argument of delete and has, nil when
+	// it's missing or undefined
+	optionalValue := func(args []vm.Value) *string {
+		if len(args) < 2 || args[1].Type() == vm.TypeUndefined {
+			return nil
+		}
+		value := argString(args, 1)
+		return &value
+	}
+	method := func(name string, arity int, fn func(p *urlSearchParams, args []vm.Value) (vm.Value, error)) {
+		proto.SetOwnNonEnumerable(name, vm.NewNativeFunction(arity, false, name, func(args []vm.Value) (vm.Value, error) {
+			params, err := thisParams(name)
+			if err != nil {
+				return vm.Undefined, err
+			}
+			return fn(params, args)
+		}))
+	}
+
+	method("append", 2, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		p.list = append(p.list, urlParam{argString(args, 0), argString(args, 1)})
+		p.update()
+		return vm.Undefined, nil
+	})
+	method("delete", 1, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		p.remove(argString(args, 0), optionalValue(args))
+		p.update()
+		return vm.Undefined, nil
+	})
+	method("get", 1, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		name := argString(args, 0)
+		for _, param := range p.list {
+			if param.name == name {
+				return vm.NewString(param.value), nil
+			}
+		}
+		return vm.Null, nil
+	})
+	method("getAll", 1, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		name := argString(args, 0)
+		result := vm.NewArray()
+		for _, param := range p.list {
+			if param.name == name {
+				result.AsArray().Append(vm.NewString(param.value))
+			}
+		}
+		return result, nil
+	})
+	method("has", 1, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		name, value := argString(args, 0), optionalValue(args)
+		for _, param := range p.list {
+			if param.name == name && (value == nil || param.value == *value) {
+				return vm.True, nil
+			}
+		}
+		return vm.False, nil
+	})
+	method("set", 2, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		name, value := argString(args, 0), argString(args, 1)
+		// The first pair named name takes the value; the rest go
+		found := false
+		list := p.list[:0]
+		for _, param := range p.list {
+			if param.name == name {
+				if found {
+					continue
+				}
+				found = true
+				param.value = value
+			}
+			list = append(list, param)
+		}
+		p.list = list
+		if !found {
+			p.list = append(p.list, urlParam{name, value})
+		}
+		p.update()
+		return vm.Undefined, nil
+	})
+	method("sort", 0, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		p.sort()
+		p.update()
+		return vm.Undefined, nil
+	})
+	method("toString", 0, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		return vm.NewString(p.String()), nil
+	})
+	method("forEach", 1, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+		callback := argOrUndefined(args, 0)
+		if !callback.IsCallable() {
+			return vm.Undefined, vmInstance.NewTypeError("URLSearchParams.prototype.forEach: callback is not a function")
+		}
+		this := vmInstance.GetThis()
+		// The list may change while the callback runs, so index it afresh
+		for i := 0; i < len(p.list); i++ {
+			param := p.list[i]
+			if _, err := vmInstance.Call(callback, argOrUndefined(args, 1), []vm.Value{vm.NewString(param.value), vm.NewString(param.name), this}); err != nil {
+				return vm.Undefined, err
+			}
+		}
+		return vm.Undefined, nil
+	})
+
+	sizeGetter := vm.NewNativeFunction(0, false, "get size", func(args []vm.Value) (vm.Value, error) {
+		params, err := thisParams("size")
+		if err != nil {
+			return vm.Undefined, err
+		}
+		return vm.NumberValue(float64(len(params.list))), nil
+	})
+	e, c := true, true
+	proto.DefineAccessorProperty("size", sizeGetter, true, vm.Undefined, false, &e, &c)
+
+	// The iterators are live, like Map's: they see pairs added while they run
+	iteratorProto := newTaggedPrototype(vmInstance, vmInstance.IteratorPrototype, "URLSearchParams Iterator")
+	iteratorProto.SetOwnNonEnumerable("next", vm.NewNativeFunction(0, false, "next", func(args []vm.Value) (vm.Value, error) {
+		var it *urlSearchParamsIterator
+		if this := vmInstance.GetThis(); this.Type() == vm.TypeObject {
+			it, _ = this.AsPlainObject().HostData().(*urlSearchParamsIterator)
+		}
+		if it == nil {
+			return vm.Undefined, vmInstance.NewTypeError("next method called on an object that is not a URLSearchParams Iterator")
+		}
+		if it.index >= len(it.params.list) {
+			return iterResult(vmInstance, vm.Undefined, true), nil
+		}
+		param := it.params.list[it.index]
+		it.index++
+		switch it.kind {
+		case "keys":
+			return iterResult(vmInstance, vm.NewString(param.name), false), nil
+		case "values":
+			return iterResult(vmInstance, vm.NewString(param.value), false), nil
+		}
+		pair := vm.NewArray()
+		pair.AsArray().Append(vm.NewString(param.name))
+		pair.AsArray().Append(vm.NewString(param.value))
+		return iterResult(vmInstance, pair, false), nil
+	}))
+
+	for _, kind := range []string{"entries", "keys", "values"} {
+		method(kind, 0, func(p *urlSearchParams, args []vm.Value) (vm.Value, error) {
+			obj := vm.NewObject(vm.NewValueFromPlainObject(iteratorProto)).AsPlainObject()
+			obj.SetHostData(&urlSearchParamsIterator{params: p, kind: kind})
+			return vm.NewValueFromPlainObject(obj), nil
+		})
+	}
+	entries, _ := proto.GetOwn("entries")
+	w, e, c := true, false, true
+	proto.DefineOwnPropertyByKey(vm.NewSymbolKey(SymbolIterator), entries, &w, &e, &c)
+}
+
+// urlSearchParamsIterator is the state of an iterator over a
+// URLSearchParams: the index of the next pair, and whether it yields
+// entries, keys or values
+type urlSearchParamsIterator struct {
+	params *urlSearchParams
+	index  int
+	kind   string
+}
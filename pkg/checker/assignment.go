@@ -130,7 +130,7 @@ func (c *Checker) checkAssignmentExpression(node *parser.AssignmentExpression) {
 			}
 		}
 
-		if !types.IsAssignable(rhsType, targetType) { // <<< Use targetType (usually widened LHS)
+		if !c.isAssignable(rhsType, targetType) { // <<< Use targetType (usually widened LHS)
 			// If the resolved type rejected the RHS, check if we're in a narrowing scope
 			// where the declared type is wider and would accept the assignment.
 			// This handles: if (x === null) { x = "default"; } where x: string | null
@@ -139,7 +139,7 @@ func (c *Checker) checkAssignmentExpression(node *parser.AssignmentExpression) {
 			if identLHS, isIdent := node.Left.(*parser.Identifier); isIdent {
 				declaredType := c.env.ResolveDeclaredType(identLHS.Value)
 				if declaredType != nil && declaredType != targetType {
-					if types.IsAssignable(rhsType, declaredType) {
+					if c.isAssignable(rhsType, declaredType) {
 						allowAssignment = true
 					}
 				}
@@ -147,7 +147,7 @@ func (c *Checker) checkAssignmentExpression(node *parser.AssignmentExpression) {
 
 			// Special case for ??=
 			if !allowAssignment && node.Operator == "??=" && (lhsType == types.Null || lhsType == types.Undefined) {
-				if types.IsAssignable(rhsType, widenedLhsType) {
+				if c.isAssignable(rhsType, widenedLhsType) {
 					allowAssignment = true
 				}
 			}
@@ -343,7 +343,7 @@ func (c *Checker) checkArrayDestructuringWithTuple(node *parser.ArrayDestructuri
 
 			// Check that default value is assignable to expected element type
 			if targetType != types.Undefined && targetType != types.Any {
-				if !types.IsAssignable(defaultType, targetType) {
+				if !c.isAssignable(defaultType, targetType) {
 					c.addError(element.Default, fmt.Sprintf("default value type '%s' is not assignable to expected element type '%s'", defaultType.String(), targetType.String()))
 				}
 			}
@@ -434,7 +434,7 @@ func (c *Checker) checkObjectDestructuringAssignment(node *parser.ObjectDestruct
 
 			// Check that default value is assignable to expected property type
 			if propType != types.Undefined && propType != types.Any {
-				if !types.IsAssignable(defaultType, propType) {
+				if !c.isAssignable(defaultType, propType) {
 					c.addError(prop.Default, fmt.Sprintf("default value type '%s' is not assignable to expected property type '%s'", defaultType.String(), propType.String()))
 				}
 			}
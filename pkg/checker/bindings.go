@@ -0,0 +1,648 @@
+package checker
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// A Binding is a name a program declares, with the identifiers referring to
+// it. Redeclarations, such as overloads and merged declarations, share the
+// binding of the first and count as references to it.
+type Binding struct {
+	Name       *parser.Identifier   // The identifier declaring it
+	Node       parser.Node          // The declaration, nil for variables and destructured names
+	Type       bool                 // Whether it names a type rather than a value
+	References []*parser.Identifier // Identifiers referring to it, in source order
+}
+
+// Bindings resolves the identifiers of program to the declarations they
+// refer to, in declaration order. Globals and other names declared
+// elsewhere have no binding.
+func Bindings(program *parser.Program) []*Binding {
+	w := walkBindings(program)
+	bindings := make([]*Binding, len(w.decls))
+	for i, decl := range w.decls {
+		bindings[i] = &Binding{Name: decl.name, Node: decl.node, Type: decl.kind == unusedType, References: decl.refs}
+		sort.Slice(decl.refs, func(a, b int) bool {
+			return decl.refs[a].Token.StartPos < decl.refs[b].Token.StartPos
+		})
+	}
+	sort.SliceStable(bindings, func(i, j int) bool {
+		return bindings[i].Name.Token.StartPos < bindings[j].Name.Token.StartPos
+	})
+	return bindings
+}
+
+// walkBindings walks program with its own scopes, collecting declarations
+// and references, and resolves every reference once the walk is done.
+// Anything it doesn't recognize is walked generically, with every
+// identifier found counting as a reference.
+func walkBindings(program *parser.Program) *bindingWalker {
+	w := &bindingWalker{visited: make(map[parser.Node]bool)}
+	w.scope = &bindingScope{function: true, global: !isModuleProgram(program)}
+	w.walkStatements(program.Statements)
+	for _, ref := range w.refs {
+		for scope := ref.scope; scope != nil; scope = scope.outer {
+			if decl, ok := scope.decls[ref.name]; ok {
+				decl.used = true
+				if ref.ident != nil {
+					decl.refs = append(decl.refs, ref.ident)
+				}
+				break
+			}
+		}
+	}
+	return w
+}
+
+type bindingDecl struct {
+	name *parser.Identifier
+	node parser.Node
+	kind unusedKind
+	used bool
+	refs []*parser.Identifier
+}
+
+type bindingScope struct {
+	outer    *bindingScope
+	decls    map[string]*bindingDecl
+	function bool // var declarations land here
+	global   bool // Declarations are globals of a script, never reported
+}
+
+type bindingRef struct {
+	name  string
+	ident *parser.Identifier // nil for names in other nodes, such as typeof types
+	scope *bindingScope
+}
+
+type bindingWalker struct {
+	scope   *bindingScope
+	decls   []*bindingDecl
+	refs    []bindingRef
+	visited map[parser.Node]bool
+	dynamic bool // with or eval make references invisible
+}
+
+func (w *bindingWalker) push(function bool) {
+	w.scope = &bindingScope{outer: w.scope, function: function}
+}
+
+func (w *bindingWalker) pop() {
+	w.scope = w.scope.outer
+}
+
+// declare adds name to scope. Reported marks declarations that may be
+// reported; the others only shadow outer ones.
+func (w *bindingWalker) declare(scope *bindingScope, name *parser.Identifier, kind unusedKind, reported bool, node parser.Node) {
+	if name == nil || name.Token == nil || name.Value == "" {
+		return
+	}
+	if scope.decls == nil {
+		scope.decls = make(map[string]*bindingDecl)
+	}
+	if existing, ok := scope.decls[name.Value]; ok {
+		// Redeclarations (var, overloads, merged declarations) share a binding
+		if !reported {
+			existing.used = true
+		}
+		existing.refs = append(existing.refs, name)
+		return
+	}
+	decl := &bindingDecl{name: name, node: node, kind: kind, used: !reported || scope.global}
+	scope.decls[name.Value] = decl
+	w.decls = append(w.decls, decl)
+}
+
+// reference records a reference to name, made by ident if not nil
+func (w *bindingWalker) reference(name string, ident *parser.Identifier) {
+	if name == "eval" {
+		w.dynamic = true
+	}
+	w.refs = append(w.refs, bindingRef{name: name, ident: ident, scope: w.scope})
+}
+
+func (w *bindingWalker) varScope() *bindingScope {
+	scope := w.scope
+	for !scope.function {
+		scope = scope.outer
+	}
+	return scope
+}
+
+func (w *bindingWalker) walkStatements(stmts []parser.Statement) {
+	for _, stmt := range stmts {
+		w.walkStatement(stmt, false)
+	}
+}
+
+// walkStatement walks a statement; exported marks declarations that are
+// exported, and so used
+func (w *bindingWalker) walkStatement(stmt parser.Statement, exported bool) {
+	if isNilNode(stmt) {
+		return
+	}
+	switch n := stmt.(type) {
+	case *parser.LetStatement:
+		w.walkDeclarators(n.Declarations, w.scope, n.Declare || exported)
+	case *parser.ConstStatement:
+		w.walkDeclarators(n.Declarations, w.scope, n.Declare || exported)
+	case *parser.VarStatement:
+		w.walkDeclarators(n.Declarations, w.varScope(), n.Declare || exported)
+	case *parser.ArrayDestructuringDeclaration:
+		w.walk(n.TypeAnnotation)
+		w.walk(n.Value)
+		w.bindElements(n.Elements, w.scope, unusedLocal, !exported)
+	case *parser.ObjectDestructuringDeclaration:
+		w.walk(n.TypeAnnotation)
+		w.walk(n.Value)
+		w.bindProperties(n.Properties, n.RestProperty, w.scope, unusedLocal, !exported)
+	case *parser.ExpressionStatement:
+		switch expr := n.Expression.(type) {
+		case *parser.FunctionLiteral:
+			if expr.Name != nil {
+				w.declare(w.varScope(), expr.Name, unusedLocal, !exported, expr)
+			}
+			w.walkFunction(expr.Parameters, expr.RestParameter, expr.TypeParameters, expr.ReturnTypeAnnotation, expr.Body, false)
+			return
+		case *parser.FunctionSignature:
+			if expr.Name != nil {
+				w.declare(w.varScope(), expr.Name, unusedLocal, !exported && !expr.Declare, expr)
+			}
+			w.walkFunction(expr.Parameters, expr.RestParameter, expr.TypeParameters, expr.ReturnTypeAnnotation, nil, false)
+			return
+		case *parser.EnumDeclaration:
+			w.declare(w.scope, expr.Name, unusedLocal, !exported, expr)
+			for _, member := range expr.Members {
+				if member != nil {
+					w.walk(member.Value)
+				}
+			}
+			return
+		}
+		w.walk(n.Expression)
+	case *parser.ClassDeclaration:
+		w.declare(w.scope, n.Name, unusedLocal, !exported && !n.Declare, n)
+		w.walkClass(n.TypeParameters, n.SuperClass, n.Implements, n.Body, n.Decorators)
+	case *parser.TypeAliasStatement:
+		w.declare(w.scope, n.Name, unusedType, !exported, n)
+		w.push(false)
+		w.declareTypeParameters(n.TypeParameters)
+		w.walk(n.Type)
+		w.pop()
+	case *parser.InterfaceDeclaration:
+		w.declare(w.scope, n.Name, unusedType, !exported, n)
+		w.push(false)
+		w.declareTypeParameters(n.TypeParameters)
+		for _, ext := range n.Extends {
+			w.walk(ext)
+		}
+		for _, prop := range n.Properties {
+			forEachNode(reflect.ValueOf(prop), w.walk)
+		}
+		w.pop()
+	case *parser.NamespaceDeclaration:
+		// Namespace members may be used through the namespace object
+		w.declare(w.scope, n.Name, unusedLocal, !exported && !n.IsExported && !n.Declare, n)
+		w.walk(n.Body)
+	case *parser.ImportDeclaration:
+		for _, spec := range n.Specifiers {
+			switch s := spec.(type) {
+			case *parser.ImportDefaultSpecifier:
+				w.declare(w.scope, s.Local, unusedLocal, true, n)
+			case *parser.ImportNamedSpecifier:
+				w.declare(w.scope, s.Local, unusedLocal, true, n)
+			case *parser.ImportNamespaceSpecifier:
+				w.declare(w.scope, s.Local, unusedLocal, true, n)
+			}
+		}
+	case *parser.ExportNamedDeclaration:
+		if n.Declaration != nil {
+			w.walkStatement(n.Declaration, true)
+			return
+		}
+		if n.Source != nil {
+			return // Re-exports name another module's bindings
+		}
+		for _, spec := range n.Specifiers {
+			if s, ok := spec.(*parser.ExportNamedSpecifier); ok {
+				w.walk(s.Local)
+			}
+		}
+	case *parser.ExportAllDeclaration:
+	case *parser.BlockStatement:
+		w.push(false)
+		w.walkStatements(n.Statements)
+		w.pop()
+	case *parser.ForStatement:
+		w.push(false)
+		w.walkStatement(n.Initializer, false)
+		w.walk(n.Condition)
+		w.walk(n.Update)
+		w.walkStatement(n.Body, false)
+		w.pop()
+	case *parser.ForOfStatement:
+		w.walk(n.Iterable)
+		w.push(false)
+		w.walkLoopVariable(n.Variable)
+		w.walkStatement(n.Body, false)
+		w.pop()
+	case *parser.ForInStatement:
+		w.walk(n.Object)
+		w.push(false)
+		w.walkLoopVariable(n.Variable)
+		w.walkStatement(n.Body, false)
+		w.pop()
+	case *parser.TryStatement:
+		w.walkStatement(n.Body, false)
+		if n.CatchClause != nil {
+			w.push(false)
+			w.bindTarget(n.CatchClause.Parameter, w.scope, unusedLocal, false)
+			w.walkStatement(n.CatchClause.Body, false)
+			w.pop()
+		}
+		w.walkStatement(n.FinallyBlock, false)
+	case *parser.SwitchStatement:
+		w.walk(n.Expression)
+		w.push(false) // Cases share one block
+		for _, sc := range n.Cases {
+			if sc == nil {
+				continue
+			}
+			w.walk(sc.Condition)
+			if sc.Body != nil {
+				w.walkStatements(sc.Body.Statements)
+			}
+		}
+		w.pop()
+	case *parser.LabeledStatement:
+		w.walkStatement(n.Statement, false)
+	case *parser.BreakStatement, *parser.ContinueStatement:
+	case *parser.WithStatement:
+		w.dynamic = true
+	default:
+		if _, isExpr := stmt.(parser.Expression); isExpr {
+			w.walk(stmt)
+		} else {
+			w.walkFields(stmt)
+		}
+	}
+}
+
+// walkDeclarators declares the names of a let, const or var statement in
+// scope. Their initializers are walked first so that `let x = x` reads an
+// outer x.
+func (w *bindingWalker) walkDeclarators(decls []*parser.VarDeclarator, scope *bindingScope, used bool) {
+	for _, decl := range decls {
+		if decl == nil {
+			continue
+		}
+		w.walk(decl.TypeAnnotation)
+		w.walk(decl.Value)
+		w.declare(scope, decl.Name, unusedLocal, !used, nil)
+	}
+}
+
+// walkLoopVariable declares the variable of a for-of or for-in loop, or
+// walks the assignment target the loop writes to
+func (w *bindingWalker) walkLoopVariable(variable parser.Statement) {
+	switch v := variable.(type) {
+	case *parser.VarStatement:
+		for _, decl := range v.Declarations {
+			if decl != nil {
+				w.declare(w.varScope(), decl.Name, unusedLocal, !strings.HasPrefix(decl.Name.Value, "_"), nil)
+			}
+		}
+	case *parser.LetStatement, *parser.ConstStatement, *parser.ArrayDestructuringDeclaration, *parser.ObjectDestructuringDeclaration:
+		w.walkStatement(v, false)
+		for name, decl := range w.scope.decls {
+			if strings.HasPrefix(name, "_") {
+				decl.used = true
+			}
+		}
+	case *parser.ExpressionStatement:
+		if _, ok := v.Expression.(*parser.Identifier); ok {
+			return // Written, not read
+		}
+		w.walk(v.Expression)
+	default:
+		w.walkStatement(variable, false)
+	}
+}
+
+func (w *bindingWalker) declareTypeParameters(params []*parser.TypeParameter) {
+	for _, tp := range params {
+		if tp == nil {
+			continue
+		}
+		w.declare(w.scope, tp.Name, unusedType, false, tp)
+		w.walk(tp.Constraint)
+		w.walk(tp.DefaultType)
+	}
+}
+
+// walkFunction walks a function's signature and body in a new function
+// scope. Parameters of signatures without a body are never reported.
+func (w *bindingWalker) walkFunction(params []*parser.Parameter, rest *parser.RestParameter, typeParams []*parser.TypeParameter, returnType parser.Expression, body parser.Node, constructor bool) {
+	w.push(true)
+	defer w.pop()
+	w.declareTypeParameters(typeParams)
+	hasBody := !isNilNode(body)
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		w.walk(param.TypeAnnotation)
+		w.walk(param.DefaultValue)
+		if param.IsThis {
+			continue
+		}
+		property := constructor && (param.IsPublic || param.IsPrivate || param.IsProtected || param.IsReadonly)
+		reported := hasBody && !property
+		if param.Name != nil {
+			w.declare(w.scope, param.Name, unusedParameter, reported && !strings.HasPrefix(param.Name.Value, "_"), param)
+		} else {
+			w.bindTarget(param.Pattern, w.scope, unusedParameter, reported)
+		}
+	}
+	if rest != nil {
+		w.walk(rest.TypeAnnotation)
+		if rest.Name != nil {
+			w.declare(w.scope, rest.Name, unusedParameter, hasBody && !strings.HasPrefix(rest.Name.Value, "_"), rest)
+		} else {
+			w.bindTarget(rest.Pattern, w.scope, unusedParameter, hasBody)
+		}
+	}
+	w.walk(returnType)
+	switch b := body.(type) {
+	case nil:
+	case *parser.BlockStatement:
+		if b != nil {
+			// The body shares the scope of the parameters
+			w.walkStatements(b.Statements)
+		}
+	default:
+		w.walk(b)
+	}
+}
+
+func (w *bindingWalker) walkClass(typeParams []*parser.TypeParameter, superClass parser.Expression, implements []*parser.Identifier, body *parser.ClassBody, decorators []*parser.Decorator) {
+	for _, d := range decorators {
+		w.walk(d)
+	}
+	w.walk(superClass)
+	for _, impl := range implements {
+		w.walk(impl)
+	}
+	if body == nil {
+		return
+	}
+	w.push(false)
+	defer w.pop()
+	w.declareTypeParameters(typeParams)
+	for _, method := range body.Methods {
+		if method == nil {
+			continue
+		}
+		for _, d := range method.Decorators {
+			w.walk(d)
+		}
+		w.walkPropertyKey(method.Key)
+		if fn := method.Value; fn != nil {
+			var fnBody parser.Node
+			if fn.Body != nil && !method.IsAbstract {
+				fnBody = fn.Body
+			}
+			w.walkFunction(fn.Parameters, fn.RestParameter, fn.TypeParameters, fn.ReturnTypeAnnotation, fnBody, method.Kind == "constructor")
+		}
+	}
+	for _, prop := range body.Properties {
+		if prop == nil {
+			continue
+		}
+		for _, d := range prop.Decorators {
+			w.walk(d)
+		}
+		w.walkPropertyKey(prop.Key)
+		w.walk(prop.TypeAnnotation)
+		w.walk(prop.Value)
+	}
+	for _, sig := range body.ConstructorSigs {
+		if sig != nil {
+			w.walkFunction(sig.Parameters, sig.RestParameter, sig.TypeParameters, sig.ReturnTypeAnnotation, nil, true)
+		}
+	}
+	for _, sig := range body.MethodSigs {
+		if sig != nil {
+			w.walkPropertyKey(sig.Key)
+			w.walkFunction(sig.Parameters, sig.RestParameter, sig.TypeParameters, sig.ReturnTypeAnnotation, nil, false)
+		}
+	}
+	for _, block := range body.StaticInitializers {
+		w.walkStatement(block, false)
+	}
+}
+
+// walkPropertyKey walks a member name, which only references anything when
+// computed
+func (w *bindingWalker) walkPropertyKey(key parser.Expression) {
+	if _, ok := key.(*parser.Identifier); ok {
+		return
+	}
+	w.walk(key)
+}
+
+// bindTarget declares the bindings of a parameter or declaration target
+func (w *bindingWalker) bindTarget(target parser.Expression, scope *bindingScope, kind unusedKind, reported bool) {
+	switch t := target.(type) {
+	case nil:
+	case *parser.Identifier:
+		if kind == unusedParameter && strings.HasPrefix(t.Value, "_") {
+			reported = false
+		}
+		w.declare(scope, t, kind, reported, nil)
+	case *parser.ArrayParameterPattern:
+		w.bindElements(t.Elements, scope, kind, reported)
+	case *parser.ObjectParameterPattern:
+		w.bindProperties(t.Properties, t.RestProperty, scope, kind, reported)
+	default:
+		// Nested literal patterns: their identifiers count as reads
+		w.walk(target)
+	}
+}
+
+func (w *bindingWalker) bindElements(elements []*parser.DestructuringElement, scope *bindingScope, kind unusedKind, reported bool) {
+	for _, el := range elements {
+		if el == nil {
+			continue
+		}
+		w.walk(el.Default)
+		w.bindTarget(el.Target, scope, kind, reported)
+	}
+}
+
+func (w *bindingWalker) bindProperties(props []*parser.DestructuringProperty, rest *parser.DestructuringElement, scope *bindingScope, kind unusedKind, reported bool) {
+	for _, prop := range props {
+		if prop == nil {
+			continue
+		}
+		if _, ok := prop.Key.(*parser.Identifier); !ok {
+			w.walk(prop.Key)
+		}
+		w.walk(prop.Default)
+		// Properties picked out to leave them out of the rest are used
+		w.bindTarget(prop.Target, scope, kind, reported && rest == nil)
+	}
+	if rest != nil {
+		w.walk(rest.Default)
+		w.bindTarget(rest.Target, scope, kind, reported)
+	}
+}
+
+var (
+	typeInterface = reflect.TypeOf((*types.Type)(nil)).Elem()
+	tokenPointer  = reflect.TypeOf((*lexer.Token)(nil))
+	parserPackage = reflect.TypeOf(parser.Program{}).PkgPath()
+)
+
+// walk walks an expression, or any other node, counting the identifiers it
+// reads as references
+func (w *bindingWalker) walk(node parser.Node) {
+	if isNilNode(node) || w.visited[node] {
+		return
+	}
+	w.visited[node] = true
+	switch n := node.(type) {
+	case *parser.Identifier:
+		w.reference(n.Value, n)
+	case *parser.TypeofTypeExpression:
+		w.reference(n.Identifier, nil)
+	case *parser.FunctionLiteral:
+		w.push(true)
+		if n.Name != nil {
+			w.declare(w.scope, n.Name, unusedLocal, false, n) // Named function expressions see themselves
+		}
+		w.walkFunction(n.Parameters, n.RestParameter, n.TypeParameters, n.ReturnTypeAnnotation, n.Body, false)
+		w.pop()
+	case *parser.ArrowFunctionLiteral:
+		w.walkFunction(n.Parameters, n.RestParameter, n.TypeParameters, n.ReturnTypeAnnotation, n.Body, false)
+	case *parser.ShorthandMethod:
+		w.walkFunction(n.Parameters, n.RestParameter, nil, n.ReturnTypeAnnotation, n.Body, false)
+	case *parser.ClassExpression:
+		w.push(false)
+		if n.Name != nil {
+			w.declare(w.scope, n.Name, unusedLocal, false, n)
+		}
+		w.walkClass(n.TypeParameters, n.SuperClass, n.Implements, n.Body, n.Decorators)
+		w.pop()
+	case *parser.FunctionSignature:
+		w.walkFunction(n.Parameters, n.RestParameter, n.TypeParameters, n.ReturnTypeAnnotation, nil, false)
+	case *parser.FunctionTypeExpression:
+		w.push(false)
+		w.declareTypeParameters(n.TypeParameters)
+		for _, param := range n.Parameters {
+			w.walk(param)
+		}
+		w.walk(n.RestParameter)
+		w.walk(n.ReturnType)
+		w.pop()
+	case *parser.Parameter:
+		// Parameters of function types and call signatures: only types count
+		w.walk(n.TypeAnnotation)
+		w.walk(n.DefaultValue)
+	case *parser.ObjectLiteral:
+		for _, prop := range n.Properties {
+			if prop != nil {
+				w.walkPropertyKey(prop.Key)
+				w.walk(prop.Value)
+			}
+		}
+	case *parser.MemberExpression:
+		w.walk(n.Object)
+		w.walkPropertyKey(n.Property)
+	case *parser.OptionalChainingExpression:
+		w.walk(n.Object)
+		w.walkPropertyKey(n.Property)
+		w.walk(n.Continuation)
+	case *parser.AssignmentExpression:
+		if _, ok := n.Left.(*parser.Identifier); !ok || n.Operator != "=" {
+			w.walk(n.Left)
+		}
+		w.walk(n.Value)
+	case parser.Statement:
+		if _, isExpr := node.(parser.Expression); !isExpr {
+			w.walkStatement(n, false)
+			return
+		}
+		w.walkFields(node)
+	default:
+		w.walkFields(node)
+	}
+}
+
+// walkFields walks the nodes held in the fields of node
+func (w *bindingWalker) walkFields(node parser.Node) {
+	forEachChild(node, w.walk)
+}
+
+// forEachChild calls fn with each node held in the fields of node, directly
+// or through helper structs such as *DestructuringElement
+func forEachChild(node parser.Node, fn func(parser.Node)) {
+	v := reflect.ValueOf(node)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			forEachNode(v.Field(i), fn)
+		}
+	}
+}
+
+func forEachNode(v reflect.Value, fn func(parser.Node)) {
+	t := v.Type()
+	if t == tokenPointer || t == typeInterface {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if node, ok := v.Interface().(parser.Node); ok {
+			fn(node)
+		} else if v.Kind() == reflect.Pointer {
+			forEachNode(v.Elem(), fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			forEachNode(v.Index(i), fn)
+		}
+	case reflect.Struct:
+		if t.PkgPath() != parserPackage {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				forEachNode(v.Field(i), fn)
+			}
+		}
+	}
+}
+
+// isNilNode reports whether node is nil, including typed nils
+func isNilNode(node parser.Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -172,7 +172,7 @@ func (c *Checker) checkFixedArgumentsWithSpread(arguments []parser.Expression, p
 				for j, elemType := range tupleType.ElementTypes {
 					if effectiveArgIndex+j < len(paramTypes) {
 						paramType := paramTypes[effectiveArgIndex+j]
-						if !types.IsAssignable(elemType, paramType) {
+						if !c.isAssignable(elemType, paramType) {
 							c.addError(spreadElement, fmt.Sprintf("spread element %d: cannot assign type '%s' to parameter of type '%s'", j+1, elemType.String(), paramType.String()))
 							allOk = false
 						}
@@ -189,7 +189,7 @@ func (c *Checker) checkFixedArgumentsWithSpread(arguments []parser.Expression, p
 							elemType := element.GetComputedType()
 							paramType := paramTypes[effectiveArgIndex+j]
 
-							if elemType != nil && !types.IsAssignable(elemType, paramType) {
+							if elemType != nil && !c.isAssignable(elemType, paramType) {
 								c.addError(element, fmt.Sprintf("spread element %d: cannot assign type '%s' to parameter of type '%s'", j+1, elemType.String(), paramType.String()))
 								allOk = false
 							}
@@ -409,7 +409,7 @@ func (c *Checker) checkSuperCallExpression(node *parser.CallExpression, superExp
 										continue
 									}
 
-									if !types.IsAssignable(argType, constructorSig.RestParameterType) {
+									if !c.isAssignable(argType, constructorSig.RestParameterType) {
 										c.addError(spreadElement, fmt.Sprintf("spread argument: cannot assign type '%s' to rest parameter type '%s'", argType.String(), constructorSig.RestParameterType.String()))
 									}
 								} else {
@@ -423,7 +423,7 @@ func (c *Checker) checkSuperCallExpression(node *parser.CallExpression, superExp
 										continue
 									}
 
-									if !types.IsAssignable(argType, variadicElementType) {
+									if !c.isAssignable(argType, variadicElementType) {
 										c.addError(argNode, fmt.Sprintf("variadic argument %d: cannot assign type '%s' to parameter element type '%s'", i+1, argType.String(), variadicElementType.String()))
 									}
 								}
@@ -512,6 +512,7 @@ func (c *Checker) checkCallExpression(node *parser.CallExpression) {
 		node.SetComputedType(types.Any)
 		return
 	}
+	funcNodeType = c.checkCalleeNotNullish(node.Function, funcNodeType)
 
 	// Handle TypeParameterType by checking its constraint
 	if typeParamType, ok := funcNodeType.(*types.TypeParameterType); ok {
@@ -729,12 +730,12 @@ func (c *Checker) checkCallExpression(node *parser.CallExpression) {
 
 							if c.isSpreadableIterableType(argType) {
 								spreadElementType := c.getSpreadElementType(argType)
-								if !types.IsAssignable(spreadElementType, variadicElementType) {
+								if !c.isAssignable(spreadElementType, variadicElementType) {
 									c.addError(spreadElement, fmt.Sprintf("spread element: cannot assign type '%s' to parameter element type '%s'", spreadElementType.String(), variadicElementType.String()))
 								}
 								continue
 							}
-							if !types.IsAssignable(argType, variadicParamType) {
+							if !c.isAssignable(argType, variadicParamType) {
 								c.addError(spreadElement, fmt.Sprintf("spread argument: cannot assign type '%s' to rest parameter type '%s'", argType.String(), variadicParamType.String()))
 							}
 						} else {
@@ -750,7 +751,7 @@ func (c *Checker) checkCallExpression(node *parser.CallExpression) {
 								continue
 							}
 
-							if !types.IsAssignable(argType, variadicElementType) {
+							if !c.isAssignable(argType, variadicElementType) {
 								c.addError(argNode, fmt.Sprintf("variadic argument %d: cannot assign type '%s' to parameter element type '%s'", i+1, argType.String(), variadicElementType.String()))
 							}
 						}
@@ -858,7 +859,7 @@ func (c *Checker) checkOverloadedCallUnified(node *parser.CallExpression, objTyp
 				// Check fixed parameters first
 				fixedMatch := true
 				for j := 0; j < minRequiredArgs; j++ {
-					if !types.IsAssignable(argTypes[j], signature.ParameterTypes[j]) {
+					if !c.isAssignable(argTypes[j], signature.ParameterTypes[j]) {
 						fixedMatch = false
 						break
 					}
@@ -876,7 +877,7 @@ func (c *Checker) checkOverloadedCallUnified(node *parser.CallExpression, objTyp
 						// Check all remaining arguments against element type
 						variadicMatch := true
 						for j := minRequiredArgs; j < len(argTypes); j++ {
-							if !types.IsAssignable(argTypes[j], elementType) {
+							if !c.isAssignable(argTypes[j], elementType) {
 								variadicMatch = false
 								break
 							}
@@ -897,7 +898,7 @@ func (c *Checker) checkOverloadedCallUnified(node *parser.CallExpression, objTyp
 			allMatch := true
 			for j, argType := range argTypes {
 				paramType := signature.ParameterTypes[j]
-				if !types.IsAssignable(argType, paramType) {
+				if !c.isAssignable(argType, paramType) {
 					allMatch = false
 					break
 				}
@@ -300,6 +300,11 @@ type Checker struct {
 	allowTopLevelReturn    bool
 	skipStrictPropertyInit bool // When true, TS2564 is not emitted (strict-init opt-out)
 	noImplicitOverride     bool // When true, overriding class members require explicit override
+	noImplicitAny          bool // When true, parameters without a type or contextual type are errors
+	noUnusedLocals         bool // When true, unread local declarations are errors
+	noUnusedParameters     bool // When true, unread parameters are errors
+	unknownCatchVariables  bool // When true, catch clause variables are unknown rather than any
+	strictNullChecks       bool // When true, null and undefined are only assignable to types including them
 
 	// --- Loop/switch/label context (reset when entering a new function scope) ---
 	loopDepth    int             // depth of enclosing iteration statements in current function
@@ -335,6 +340,12 @@ type Checker struct {
 	// Computed key expressions in class/interface bodies are checked after Pass 3
 	// so that const/let declarations earlier in the file are already in scope.
 	deferredComputedKeyChecks []deferredComputedKeyCheck
+
+	// --- Ambient declarations (see declarations.go) ---
+	declarations        *Declarations                    // Ambient declarations of the program checked last
+	ambientModules      map[string]map[string]types.Type // Exports of modules declared with `declare module`
+	declarationsHandler func(*Declarations)              // Called with the declarations of each program
+	referenceResolver   ReferenceResolver                // Loads the files of triple-slash references
 }
 
 type deferredComputedKeyCheck struct {
@@ -362,6 +373,8 @@ func NewCheckerWithInitializers(initializers []builtins.BuiltinInitializer) *Che
 		abstractMethods:            make(map[string]map[string]bool),
 		generatorFunctions:         make(map[string]bool),
 		resolvingTypeAliases:       make(map[string]bool),
+		declarations:               NewDeclarations(),
+		ambientModules:             make(map[string]map[string]types.Type),
 	}
 }
 
@@ -370,6 +383,19 @@ func (c *Checker) GetEnvironment() *Environment {
 	return c.env
 }
 
+// DefineGlobal declares a variable in the root environment, replacing the type
+// of an existing global with the same name. Embedders use this to make values
+// injected from Go visible to type-checked code.
+func (c *Checker) DefineGlobal(name string, typ types.Type) {
+	env := c.env
+	for env.outer != nil {
+		env = env.outer
+	}
+	if !env.Update(name, typ) {
+		env.Define(name, typ, false)
+	}
+}
+
 // SetAllowSuperInEval sets whether super expressions are allowed in eval contexts
 // This is used when compiling direct eval code that was called from a method context
 func (c *Checker) SetAllowSuperInEval(allow bool) {
@@ -396,6 +422,37 @@ func (c *Checker) SetNoImplicitOverride(enabled bool) {
 	c.noImplicitOverride = enabled
 }
 
+// SetNoImplicitAny controls whether parameters whose type is neither
+// annotated nor known from context are reported (TS7006). Default false.
+func (c *Checker) SetNoImplicitAny(enabled bool) {
+	c.noImplicitAny = enabled
+}
+
+// SetNoUnusedLocals controls whether local declarations that are never read
+// are reported (TS6133, TS6196 for types). Default false.
+func (c *Checker) SetNoUnusedLocals(enabled bool) {
+	c.noUnusedLocals = enabled
+}
+
+// SetNoUnusedParameters controls whether parameters that are never read are
+// reported (TS6133). Default false.
+func (c *Checker) SetNoUnusedParameters(enabled bool) {
+	c.noUnusedParameters = enabled
+}
+
+// SetUseUnknownInCatchVariables controls whether catch clause variables are
+// typed unknown instead of any. Default false.
+func (c *Checker) SetUseUnknownInCatchVariables(enabled bool) {
+	c.unknownCatchVariables = enabled
+}
+
+// SetStrictNullChecks controls whether null and undefined are distinct types
+// that other types don't include, so that nullable values must be narrowed
+// before use. Default false.
+func (c *Checker) SetStrictNullChecks(enabled bool) {
+	c.strictNullChecks = enabled
+}
+
 // --- Access Control Helper Methods ---
 
 // setClassContext sets the current class context for access control checking
@@ -524,6 +581,8 @@ func (c *Checker) Check(program *parser.Program) []errors.PaseratiError {
 	c.program = program
 	c.source = program.Source           // Cache source for error reporting
 	c.errors = []errors.PaseratiError{} // Reset errors
+	c.declarations = NewDeclarations()
+	c.loadReferences()
 	// DON'T reset the environment - keep it persistent for REPL sessions
 	// c.env = NewGlobalEnvironment()      // Start with a fresh global environment for this check
 	globalEnv := c.env
@@ -643,6 +702,16 @@ func (c *Checker) Check(program *parser.Program) []errors.PaseratiError {
 			c.checkNamespaceDeclaration(nsStmt)
 			nodesProcessedPass1[nsStmt] = true
 			nodesProcessedPass2[nsStmt] = true
+		} else if moduleStmt, ok := stmt.(*parser.AmbientModuleDeclaration); ok {
+			debugPrintf("// [Checker Pass 1] Processing Ambient Module: %s\n", moduleStmt.Name.Value)
+			c.checkAmbientModuleDeclaration(moduleStmt)
+			nodesProcessedPass1[moduleStmt] = true
+			nodesProcessedPass2[moduleStmt] = true
+		} else if globalStmt, ok := stmt.(*parser.GlobalDeclaration); ok {
+			debugPrintf("// [Checker Pass 1] Processing Global Declarations\n")
+			c.checkGlobalDeclaration(globalStmt)
+			nodesProcessedPass1[globalStmt] = true
+			nodesProcessedPass2[globalStmt] = true
 		} else if importStmt, ok := stmt.(*parser.ImportDeclaration); ok {
 			// Add defensive check for nil Source
 			if importStmt.Source != nil {
@@ -1048,7 +1117,11 @@ func (c *Checker) Check(program *parser.Program) []errors.PaseratiError {
 				paramType := funcSignature.ParameterTypes[i]
 				// Skip 'this' parameters as they don't have names and don't go into the scope
 				if !paramNode.IsThis {
-					if !funcEnv.Define(paramNode.Name.Value, paramType, false) {
+					bodyType := paramType
+					if paramNode.Optional && paramNode.DefaultValue == nil {
+						bodyType = c.withUndefined(paramType) // Omitted arguments are undefined
+					}
+					if !funcEnv.Define(paramNode.Name.Value, bodyType, false) {
 						c.addError(paramNode.Name, fmt.Sprintf("duplicate parameter name: %s", paramNode.Name.Value))
 					}
 				}
@@ -1418,6 +1491,12 @@ func (c *Checker) Check(program *parser.Program) []errors.PaseratiError {
 	}
 	c.unresolvedTypeofNodes = nil
 
+	// Emit TS6133/TS6196 for unread declarations under noUnusedLocals/Parameters
+	c.checkUnused(program)
+
+	// Emit TS7006/TS7019 for parameters that are implicitly any under noImplicitAny
+	c.checkImplicitAny(program)
+
 	// Emit TS2391 for any function overload signatures that never got an implementation
 	for _, sigs := range globalEnv.GetAllPendingOverloads() {
 		for _, sig := range sigs {
@@ -1427,6 +1506,7 @@ func (c *Checker) Check(program *parser.Program) []errors.PaseratiError {
 		}
 	}
 
+	c.finishDeclarations(program)
 	return c.errors
 }
 
@@ -1612,6 +1692,15 @@ func (c *Checker) visit(node parser.Node) {
 		// Process function overload signatures
 		c.processFunctionSignature(node)
 
+	case *parser.AmbientModuleDeclaration:
+		c.checkAmbientModuleDeclaration(node)
+
+	case *parser.GlobalDeclaration:
+		c.checkGlobalDeclaration(node)
+
+	case *parser.ExportAssignment:
+		c.checkModuleExportAssignment(node)
+
 	case *parser.ExpressionStatement:
 		c.visit(node.Expression)
 
@@ -1793,6 +1882,16 @@ func (c *Checker) visit(node parser.Node) {
 				declaredType = nil // Indicates type inference is needed
 			}
 
+			// Temporarily define the constant before visiting the initializer so
+			// closures inside it can refer to it (e.g. `const id = setInterval(() => clearInterval(id))`)
+			tempType := declaredType
+			if tempType == nil {
+				tempType = types.Any
+			}
+			if !c.env.Define(declarator.Name.Value, tempType, true) {
+				c.addError(declarator.Name, fmt.Sprintf("constant '%s' already declared in this scope", declarator.Name.Value))
+			}
+
 			// 2. Handle Initializer (Must be present for const)
 			var computedInitializerType types.Type
 			if declarator.Value != nil {
@@ -1855,10 +1954,12 @@ func (c *Checker) visit(node parser.Node) {
 				}
 			}
 
-			// 4. Define variable in the current environment
-			if !c.env.Define(declarator.Name.Value, finalType, true) {
-				c.addError(declarator.Name, fmt.Sprintf("constant '%s' already declared in this scope", declarator.Name.Value))
+			if node.Using && declarator.Value != nil {
+				c.checkUsingValue(declarator.Value, finalType)
 			}
+
+			// 4. Update the constant's type in the current environment
+			c.env.Update(declarator.Name.Value, finalType)
 			// Set computed type on the Name Identifier node itself and the declarator
 			declarator.Name.SetComputedType(finalType)
 			declarator.ComputedType = finalType
@@ -2064,7 +2165,7 @@ func (c *Checker) visit(node parser.Node) {
 			// Special handling for type predicate return types
 			if _, ok := c.currentExpectedReturnType.(*types.TypePredicateType); ok {
 				// Type predicate functions should accept boolean returns
-				if !types.IsAssignable(actualReturnType, types.Boolean) {
+				if !c.isAssignable(actualReturnType, types.Boolean) {
 					msg := fmt.Sprintf("cannot return value of type %s from type predicate function expecting boolean",
 						actualReturnType)
 					c.addError(node.ReturnValue, msg)
@@ -2884,6 +2985,10 @@ func (c *Checker) visit(node parser.Node) {
 		// 3. Check Consequence expression (potentially with narrowed environment)
 		originalEnv := c.env
 		narrowedEnv := c.applyTypeNarrowing(typeGuard)
+		if narrowedEnv == nil {
+			// Truthiness and compound conditions: a.b ? a.b.c : d
+			narrowedEnv = c.applyTypeNarrowingFromCondition(node.Condition)
+		}
 
 		if narrowedEnv != nil {
 			debugPrintf("// [Checker TernaryExpr] Applying type narrowing in consequence expression\n")
@@ -2898,6 +3003,9 @@ func (c *Checker) visit(node parser.Node) {
 
 		// 4. Check Alternative expression - potentially with inverted type narrowing
 		invertedEnv := c.applyInvertedTypeNarrowing(typeGuard)
+		if invertedEnv == nil && typeGuard == nil {
+			invertedEnv = c.applyInvertedTruthinessNarrowing(node.Condition)
+		}
 
 		if invertedEnv != nil {
 			debugPrintf("// [Checker TernaryExpr] Applying inverted type narrowing in alternative expression\n")
@@ -3144,7 +3252,7 @@ func (c *Checker) visit(node parser.Node) {
 				c.env = originalEnv         // Restore original environment
 
 				defaultValueType := param.DefaultValue.GetComputedType()
-				if defaultValueType != nil && !types.IsAssignable(defaultValueType, resolvedParamType) {
+				if defaultValueType != nil && !c.isAssignable(defaultValueType, resolvedParamType) {
 					c.addError(param.DefaultValue, fmt.Sprintf("default value type '%s' is not assignable to parameter type '%s'", defaultValueType.String(), resolvedParamType.String()))
 				}
 			}
@@ -3440,7 +3548,7 @@ func (c *Checker) checkArrayDestructuringDeclaration(node *parser.ArrayDestructu
 	if node.TypeAnnotation != nil {
 		expectedType = c.resolveTypeAnnotation(node.TypeAnnotation)
 		// Verify that the value is assignable to the expected type
-		if !types.IsAssignable(valueType, expectedType) {
+		if !c.isAssignable(valueType, expectedType) {
 			c.addError(node.Value, fmt.Sprintf("cannot assign type '%s' to type '%s'", valueType.String(), expectedType.String()))
 		}
 	}
@@ -3586,7 +3694,7 @@ func (c *Checker) checkObjectDestructuringDeclaration(node *parser.ObjectDestruc
 	if node.TypeAnnotation != nil {
 		expectedType = c.resolveTypeAnnotation(node.TypeAnnotation)
 		// Verify that the value is assignable to the expected type
-		if node.Value != nil && expectedType != nil && valueType != nil && !types.IsAssignable(valueType, expectedType) {
+		if node.Value != nil && expectedType != nil && valueType != nil && !c.isAssignable(valueType, expectedType) {
 			c.addError(node.Value, fmt.Sprintf("cannot assign type '%s' to type '%s'", valueType.String(), expectedType.String()))
 		}
 	}
@@ -3857,14 +3965,19 @@ func (c *Checker) checkCatchClause(clause *parser.CatchClause) {
 
 	// Define the catch parameter if present
 	if clause.Parameter != nil {
-		// In JavaScript/TypeScript, catch parameter is implicitly 'any' type
+		// In JavaScript/TypeScript, catch parameter is implicitly 'any' type,
+		// or 'unknown' under useUnknownInCatchVariables
+		var paramType types.Type = types.Any
+		if c.unknownCatchVariables {
+			paramType = types.Unknown
+		}
 		switch param := clause.Parameter.(type) {
 		case *parser.Identifier:
 			// Simple identifier: catch (e)
-			if !c.env.Define(param.Value, types.Any, false) {
+			if !c.env.Define(param.Value, paramType, false) {
 				c.addError(param, fmt.Sprintf("parameter '%s' already declared", param.Value))
 			}
-			param.SetComputedType(types.Any)
+			param.SetComputedType(paramType)
 		case *parser.ArrayParameterPattern, *parser.ObjectParameterPattern:
 			// Destructuring pattern: catch ([x, y]) or catch ({message})
 			// Type check the pattern and define all bindings
@@ -4179,8 +4292,12 @@ func (c *Checker) processImportBinding(localName, sourceModule, sourceName strin
 	if c.IsModuleMode() {
 		c.moduleEnv.DefineImport(localName, sourceModule, sourceName, importType)
 
-		// Try to resolve the actual type from the source module
-		resolvedType := c.moduleEnv.ResolveImportedType(localName)
+		// Try to resolve the actual type from the source module, unless an
+		// ambient declaration of the module types it
+		resolvedType := c.ambientImportType(sourceModule, sourceName)
+		if resolvedType == nil {
+			resolvedType = c.moduleEnv.ResolveImportedType(localName)
+		}
 		if resolvedType != nil && resolvedType != types.Any {
 			debugPrintf("// [Checker] Imported %s: %s = %s (resolved, type-only: %v)\n", localName, sourceName, resolvedType.String(), isTypeOnly)
 
@@ -4497,6 +4614,34 @@ func (c *Checker) processExportDeclaration(decl parser.Statement) {
 			debugPrintf("// [Checker] Exported class declaration: %s (type: %s)\n", localName, exportType.String())
 		}
 
+	case *parser.FunctionSignature:
+		if node.Name != nil {
+			localName := node.Name.Value
+			// Overloads of an ambient function accumulate in the environment
+			exportType, _, exists := c.env.Resolve(localName)
+			if !exists {
+				exportType = types.Any
+			}
+			if c.IsModuleMode() {
+				c.moduleEnv.DefineExport(localName, localName, exportType, decl)
+			}
+			debugPrintf("// [Checker] Exported function signature: %s (type: %s)\n", localName, exportType.String())
+		}
+
+	case *parser.NamespaceDeclaration:
+		if node.Name != nil {
+			localName := node.Name.Value
+			// Importers see the values the namespace exports
+			exportType, _, exists := c.env.Resolve(localName)
+			if !exists {
+				exportType = types.Any
+			}
+			if c.IsModuleMode() {
+				c.moduleEnv.DefineExport(localName, localName, exportType, decl)
+			}
+			debugPrintf("// [Checker] Exported namespace: %s (type: %s)\n", localName, exportType.String())
+		}
+
 	case *parser.InterfaceDeclaration:
 		if node.Name != nil {
 			localName := node.Name.Value
@@ -4635,6 +4780,15 @@ func (c *Checker) isAsyncGeneratorType(t types.Type) bool {
 	return false
 }
 
+// checkUsingValue reports a value of type t held by a using declaration that
+// can't be disposed of: only objects, null and undefined can be
+func (c *Checker) checkUsingValue(node parser.Node, t types.Type) {
+	switch types.GetWidenedType(t) {
+	case types.String, types.Number, types.Boolean, types.BigInt, types.Symbol:
+		c.addError(node, fmt.Sprintf("type '%s' is not disposable: a 'using' declaration must hold an object with a [Symbol.dispose]() method, null or undefined", t.String()))
+	}
+}
+
 // isInAsyncContext checks if we're currently inside an async function or async generator
 func (c *Checker) isInAsyncContext() bool {
 	// Top level is async (TLA support) or we're inside an async function
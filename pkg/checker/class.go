@@ -129,6 +129,50 @@ func (c *Checker) validateOverloadImplementations(body *parser.ClassBody) {
 	}
 }
 
+// addDeclaredMethods adds to objType the methods body declares with
+// signatures but doesn't implement, the static ones or the instance ones:
+// those of an ambient class, one member per name with a call signature per
+// overload. Abstract and optional signatures are added with the rest of the
+// members.
+func (c *Checker) addDeclaredMethods(body *parser.ClassBody, objType *types.ObjectType, static bool) {
+	implemented := make(map[string]bool)
+	for _, method := range body.Methods {
+		if method.IsStatic == static && method.Kind == "method" {
+			implemented[c.extractPropertyName(method.Key)] = true
+		}
+	}
+	var names []string
+	declared := make(map[string][]*parser.MethodSignature)
+	for _, sig := range body.MethodSigs {
+		if sig.IsStatic != static || sig.IsAbstract || sig.Optional || sig.Kind != "method" {
+			continue
+		}
+		name := c.extractPropertyName(sig.Key)
+		if name == "" || implemented[name] {
+			continue
+		}
+		if declared[name] == nil {
+			names = append(names, name)
+		}
+		declared[name] = append(declared[name], sig)
+	}
+	for _, name := range names {
+		sigs := declared[name]
+		var callSigs []*types.Signature
+		for _, sig := range sigs {
+			if fnType, ok := c.inferMethodTypeFromSignature(sig).(*types.ObjectType); ok {
+				callSigs = append(callSigs, fnType.CallSignatures...)
+			}
+		}
+		if len(callSigs) == 0 {
+			continue
+		}
+		methodType := types.NewOverloadedFunctionType(callSigs)
+		accessLevel := c.getAccessLevel(sigs[0].IsPublic, sigs[0].IsPrivate, sigs[0].IsProtected)
+		objType.WithClassMember(name, methodType, accessLevel, static, false)
+	}
+}
+
 // extractPropertyName extracts the property name from a class member key
 func (c *Checker) extractPropertyName(key parser.Expression) string {
 	switch k := key.(type) {
@@ -444,7 +488,7 @@ func (c *Checker) checkGenericClassDeclaration(node *parser.ClassDeclaration) {
 			if defaultType := c.resolveTypeAnnotation(param.DefaultType); defaultType != nil {
 				typeParam.Default = defaultType
 
-				if typeParam.Constraint != nil && !types.IsAssignable(defaultType, typeParam.Constraint) {
+				if typeParam.Constraint != nil && !c.isAssignable(defaultType, typeParam.Constraint) {
 					c.addError(param.DefaultType, fmt.Sprintf("default type '%s' does not satisfy constraint '%s'", defaultType.String(), typeParam.Constraint.String()))
 				}
 			}
@@ -768,6 +812,11 @@ func (c *Checker) createInstanceTypeInPlace(className string, body *parser.Class
 		}
 	}
 
+	// Signatures without an implementation, as ambient classes declare their
+	// methods, are the methods' types
+	c.setClassContext(className, types.AccessContextInstanceMethod)
+	c.addDeclaredMethods(body, instanceType, false)
+
 	// ADDED: Synthesize parameter properties from constructor before processing regular properties
 	c.synthesizeParameterProperties(body)
 
@@ -1329,12 +1378,29 @@ func (c *Checker) addStaticMembers(body *parser.ClassBody, constructorType *type
 			c.validateClassMemberOverride(method.Key, c.extractPropertyName(method.Key), true, method.IsOverride, false, className, classInstanceType)
 
 			methodType := c.inferMethodType(method)
+			methodName := c.extractPropertyName(method.Key)
+
+			// A static accessor is a property of the type its getter returns,
+			// or else of its setter's parameter
+			if method.Kind == "getter" || method.Kind == "setter" {
+				objType, ok := methodType.(*types.ObjectType)
+				if !ok || len(objType.CallSignatures) == 0 {
+					continue
+				}
+				sig := objType.CallSignatures[0]
+				if method.Kind == "getter" {
+					methodType = sig.ReturnType
+				} else if _, hasGetter := constructorType.Properties[methodName]; hasGetter || len(sig.ParameterTypes) == 0 {
+					continue
+				} else {
+					methodType = sig.ParameterTypes[0]
+				}
+			}
 
 			// Determine access level
 			accessLevel := c.getAccessLevel(method.IsPublic, method.IsPrivate, method.IsProtected)
 
 			// Add method with access control metadata
-			methodName := c.extractPropertyName(method.Key)
 			constructorType.WithClassMember(methodName, methodType, accessLevel, true, false)
 
 			debugPrintf("// [Checker Class] Added static method '%s' to constructor type: %s (%s)\n",
@@ -1342,6 +1408,9 @@ func (c *Checker) addStaticMembers(body *parser.ClassBody, constructorType *type
 		}
 	}
 
+	c.setClassContext(className, types.AccessContextStaticMethod)
+	c.addDeclaredMethods(body, constructorType, true)
+
 	// Add static properties. In static field initializers, `this` refers to the
 	// constructor (class object), so set the this type accordingly.
 	// Also set class context so `super` resolves correctly.
@@ -1900,7 +1969,7 @@ func (c *Checker) validateInterfaceImplementation(classType *types.ObjectType, i
 // and the class has a concrete method implementation (ObjectType with call signatures)
 func (c *Checker) isCompatibleWithInterfaceProperty(classProperty, interfaceProperty types.Type) bool {
 	// First try the standard assignability check
-	if types.IsAssignable(classProperty, interfaceProperty) {
+	if c.isAssignable(classProperty, interfaceProperty) {
 		return true
 	}
 
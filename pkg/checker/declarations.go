@@ -0,0 +1,346 @@
+package checker
+
+import (
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// Ambient declarations describe values that exist without code the checker
+// sees: the globals of a declaration file that has no imports or exports,
+// those of `declare global { ... }` blocks, and the modules of
+// `declare module "name" { ... }` blocks. A checker collects the ones its
+// program makes into Declarations, which embedders install into other
+// checkers so that every file of a program is checked against them.
+
+// Declarations are the ambient declarations of one or more programs
+type Declarations struct {
+	Values  map[string]types.Type            // Global values by name
+	Types   map[string]types.Type            // Global types by name
+	Modules map[string]map[string]types.Type // Exports of ambient modules by module name; nil for `declare module "name";`
+}
+
+// NewDeclarations creates an empty set of declarations
+func NewDeclarations() *Declarations {
+	return &Declarations{
+		Values:  make(map[string]types.Type),
+		Types:   make(map[string]types.Type),
+		Modules: make(map[string]map[string]types.Type),
+	}
+}
+
+// IsEmpty reports whether d declares nothing
+func (d *Declarations) IsEmpty() bool {
+	return len(d.Values) == 0 && len(d.Types) == 0 && len(d.Modules) == 0
+}
+
+// Merge adds the declarations of other to d, replacing those of the same name
+func (d *Declarations) Merge(other *Declarations) {
+	for name, typ := range other.Values {
+		d.Values[name] = typ
+	}
+	for name, typ := range other.Types {
+		d.Types[name] = typ
+	}
+	for name, exports := range other.Modules {
+		d.Modules[name] = exports
+	}
+}
+
+// ReferenceResolver loads the declaration file a triple-slash reference
+// directive of the file at fromPath names, and returns its declarations
+type ReferenceResolver func(directive parser.ReferenceDirective, fromPath string) (*Declarations, error)
+
+// InstallDeclarations makes ambient declarations visible to the programs
+// the checker checks: values and types become globals, and imports of a
+// declared module take their types from its declaration, even when the
+// module itself is native or JavaScript.
+func (c *Checker) InstallDeclarations(d *Declarations) {
+	root := c.env
+	for root.outer != nil {
+		root = root.outer
+	}
+	for name, typ := range d.Values {
+		c.DefineGlobal(name, typ)
+	}
+	for name, typ := range d.Types {
+		root.typeAliases[name] = typ // Declaration files augment the built-in types
+	}
+	for name, exports := range d.Modules {
+		c.ambientModules[name] = exports
+	}
+}
+
+// Declarations returns the ambient declarations of the program checked last
+func (c *Checker) Declarations() *Declarations {
+	return c.declarations
+}
+
+// SetDeclarationsHandler sets a function called with the ambient
+// declarations of each checked program that makes any
+func (c *Checker) SetDeclarationsHandler(handler func(*Declarations)) {
+	c.declarationsHandler = handler
+}
+
+// SetReferenceResolver sets the function that loads the files named by
+// `/// <reference path="..." />` and `/// <reference types="..." />`.
+// Without one, reference directives are ignored.
+func (c *Checker) SetReferenceResolver(resolver ReferenceResolver) {
+	c.referenceResolver = resolver
+}
+
+// loadReferences installs the declarations the reference directives of the
+// program being checked name
+func (c *Checker) loadReferences() {
+	if c.referenceResolver == nil || c.source == nil {
+		return
+	}
+	for _, directive := range parser.ParseReferenceDirectives(c.source.Content) {
+		if directive.Kind == "lib" {
+			continue // The standard library is built in
+		}
+		declarations, err := c.referenceResolver(directive, c.source.Path)
+		if err != nil {
+			c.errors = append(c.errors, &errors.TypeError{
+				Position: errors.Position{Line: directive.Line, Column: 1, Source: c.source},
+				Msg:      err.Error(),
+			})
+			continue
+		}
+		c.InstallDeclarations(declarations)
+	}
+}
+
+// finishDeclarations collects the globals of a declaration file without
+// imports or exports and hands the program's declarations to the handler
+func (c *Checker) finishDeclarations(program *parser.Program) {
+	if c.source != nil && parser.IsDeclarationFile(c.source.DisplayPath()) && !isModuleProgram(program) {
+		c.collectDeclarations(program.Statements)
+	}
+	if c.declarationsHandler != nil && !c.declarations.IsEmpty() {
+		c.declarationsHandler(c.declarations)
+	}
+}
+
+// isModuleProgram reports whether program imports or exports anything, which
+// makes its top-level declarations local to it
+func isModuleProgram(program *parser.Program) bool {
+	for _, stmt := range program.Statements {
+		switch stmt.(type) {
+		case *parser.ImportDeclaration, *parser.ExportNamedDeclaration, *parser.ExportDefaultDeclaration,
+			*parser.ExportAllDeclaration, *parser.ExportAssignment:
+			return true
+		}
+	}
+	return false
+}
+
+// collectDeclarations records the global values and types stmts declare
+func (c *Checker) collectDeclarations(stmts []parser.Statement) {
+	for _, stmt := range stmts {
+		for _, name := range declaredNames(stmt) {
+			if typ, _, found := c.env.Resolve(name); found {
+				c.declarations.Values[name] = typ
+			}
+			if typ, found := c.env.ResolveType(name); found {
+				c.declarations.Types[name] = typ
+			}
+		}
+	}
+}
+
+// declaredNames returns the names a declaration statement binds
+func declaredNames(stmt parser.Statement) []string {
+	var names []string
+	addDeclarators := func(declarators []*parser.VarDeclarator) {
+		for _, d := range declarators {
+			if d != nil && d.Name != nil {
+				names = append(names, d.Name.Value)
+			}
+		}
+	}
+	switch node := stmt.(type) {
+	case *parser.VarStatement:
+		addDeclarators(node.Declarations)
+	case *parser.LetStatement:
+		addDeclarators(node.Declarations)
+	case *parser.ConstStatement:
+		addDeclarators(node.Declarations)
+	case *parser.FunctionSignature:
+		if node.Name != nil {
+			names = append(names, node.Name.Value)
+		}
+	case *parser.ClassDeclaration:
+		if node.Name != nil {
+			names = append(names, node.Name.Value)
+		}
+	case *parser.InterfaceDeclaration:
+		if node.Name != nil {
+			names = append(names, node.Name.Value)
+		}
+	case *parser.TypeAliasStatement:
+		if node.Name != nil {
+			names = append(names, node.Name.Value)
+		}
+	case *parser.NamespaceDeclaration:
+		if node.Name != nil {
+			names = append(names, node.Name.Value)
+		}
+	case *parser.ExpressionStatement:
+		switch expr := node.Expression.(type) {
+		case *parser.FunctionLiteral:
+			if expr.Name != nil {
+				names = append(names, expr.Name.Value)
+			}
+		case *parser.FunctionSignature:
+			if expr.Name != nil {
+				names = append(names, expr.Name.Value)
+			}
+		case *parser.ClassExpression:
+			if expr.Name != nil {
+				names = append(names, expr.Name.Value)
+			}
+		case *parser.EnumDeclaration:
+			if expr.Name != nil {
+				names = append(names, expr.Name.Value)
+			}
+		}
+	}
+	return names
+}
+
+// checkGlobalDeclaration checks `declare global { ... }`, whose
+// declarations are made in the global scope even inside a module
+func (c *Checker) checkGlobalDeclaration(node *parser.GlobalDeclaration) {
+	if node.Body == nil {
+		return
+	}
+	outerEnv := c.env
+	for c.env.outer != nil {
+		c.env = c.env.outer
+	}
+	// Types first, so that the values' annotations can refer to them
+	for _, stmt := range node.Body.Statements {
+		switch n := stmt.(type) {
+		case *parser.InterfaceDeclaration:
+			c.checkInterfaceDeclaration(n)
+		case *parser.TypeAliasStatement:
+			c.checkTypeAliasStatement(n)
+		case *parser.ClassDeclaration:
+			c.checkClassDeclaration(n)
+		case *parser.NamespaceDeclaration:
+			c.checkNamespaceDeclaration(n)
+		}
+	}
+	for _, stmt := range node.Body.Statements {
+		switch stmt.(type) {
+		case *parser.InterfaceDeclaration, *parser.TypeAliasStatement, *parser.ClassDeclaration, *parser.NamespaceDeclaration:
+		default:
+			c.visit(stmt)
+		}
+	}
+	c.collectDeclarations(node.Body.Statements)
+	c.env = outerEnv
+}
+
+// checkAmbientModuleDeclaration checks `declare module "name" { ... }` and
+// records the module's exports. Declarations of a module already declared
+// add to its exports, as module augmentations do.
+func (c *Checker) checkAmbientModuleDeclaration(node *parser.AmbientModuleDeclaration) {
+	name := node.Name.Value
+	if node.Body == nil {
+		// Shorthand: everything the module exports is any
+		if _, declared := c.ambientModules[name]; !declared {
+			c.ambientModules[name] = nil
+			c.declarations.Modules[name] = nil
+		}
+		return
+	}
+
+	nsType := types.NewNamespaceType(name)
+	nsType.Declare = true
+	c.checkNamespaceBody(node.Body, nsType, true)
+
+	exports := make(map[string]types.Type)
+	for exportName, typ := range c.ambientModules[name] {
+		exports[exportName] = typ
+	}
+	for exportName, typ := range nsType.TypeMembers {
+		exports[exportName] = typ
+	}
+	for exportName, typ := range nsType.ValueShape.Properties {
+		exports[exportName] = typ
+	}
+	c.ambientModules[name] = exports
+	c.declarations.Modules[name] = exports
+}
+
+// ambientImportType returns the type of the export sourceName ("*" for the
+// namespace) of the ambient module sourceModule, or nil if no such module
+// is declared
+func (c *Checker) ambientImportType(sourceModule, sourceName string) types.Type {
+	exports, declared := c.ambientModules[sourceModule]
+	if !declared {
+		return nil
+	}
+	if sourceName == "*" {
+		namespace := types.NewObjectType()
+		for name, typ := range exports {
+			namespace.Properties[name] = typ
+		}
+		return namespace
+	}
+	if typ, ok := exports[sourceName]; ok {
+		return typ
+	}
+	return types.Any
+}
+
+// checkExportAssignment checks `export = expression;`, which makes the
+// expression the value of a CommonJS module. Its value is the default
+// export; its members, and the types of a namespace it names, are the named
+// exports.
+func (c *Checker) checkExportAssignment(node *parser.ExportAssignment, values, typeMembers map[string]types.Type) {
+	c.visit(node.Expression)
+	typ := node.Expression.GetComputedType()
+	if typ == nil {
+		typ = types.Any
+	}
+	values["default"] = typ
+	if obj, ok := typ.(*types.ObjectType); ok {
+		for name, member := range obj.Properties {
+			if _, taken := values[name]; !taken {
+				values[name] = member
+			}
+		}
+	}
+	if ident, ok := node.Expression.(*parser.Identifier); ok {
+		if t, found := c.env.ResolveType(ident.Value); found {
+			if ns, ok := t.(*types.NamespaceType); ok {
+				for name, member := range ns.TypeMembers {
+					if _, taken := typeMembers[name]; !taken {
+						typeMembers[name] = member
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkModuleExportAssignment checks a top-level `export = expression;`
+func (c *Checker) checkModuleExportAssignment(node *parser.ExportAssignment) {
+	values := make(map[string]types.Type)
+	typeMembers := make(map[string]types.Type)
+	c.checkExportAssignment(node, values, typeMembers)
+	if !c.IsModuleMode() {
+		return
+	}
+	for name, typ := range typeMembers {
+		if _, isValue := values[name]; !isValue {
+			c.moduleEnv.DefineExport(name, name, typ, node)
+		}
+	}
+	for name, typ := range values {
+		c.moduleEnv.DefineExport(name, name, typ, node)
+	}
+}
@@ -51,12 +51,97 @@ func (c *Checker) checkDecorators(node *parser.ClassDeclaration) {
 
 	// Check property decorators
 	for _, prop := range node.Body.Properties {
+		if prop.IsAccessor {
+			c.checkAccessorDecorators(node, prop)
+			continue
+		}
 		for _, dec := range prop.Decorators {
 			c.checkDecoratorExpression(dec)
 		}
 	}
 }
 
+// checkAccessorDecorators validates the decorators of an accessor field: each
+// must be assignable to
+//
+//	(target: ClassAccessorDecoratorTarget<This, Value>, context: ClassAccessorDecoratorContext<This, Value>) => ClassAccessorDecoratorResult<This, Value> | void
+//
+// where This is the class's instance type (its constructor type for static
+// fields) and Value the field's type.
+func (c *Checker) checkAccessorDecorators(node *parser.ClassDeclaration, prop *parser.PropertyDefinition) {
+	expected, valueType := c.accessorDecoratorType(node, prop)
+	for _, dec := range prop.Decorators {
+		decType := c.resolveDecoratorExprType(dec.Expression)
+		if decType == nil {
+			continue
+		}
+		if !c.isDecoratorCallable(decType) {
+			c.addError(dec, fmt.Sprintf("decorator must be a callable expression, but has type '%s'", decType.String()))
+			continue
+		}
+		if expected != nil && !c.isAssignable(decType, expected) {
+			c.addError(dec, fmt.Sprintf("decorator of type '%s' can't decorate accessor '%s: %s' of class '%s'", decType.String(), prop.Key.String(), valueType.String(), node.Name.Value))
+		}
+	}
+}
+
+// accessorDecoratorType returns the type decorators of the accessor field prop
+// must have, and the field's type; nil if they can't be resolved
+func (c *Checker) accessorDecoratorType(node *parser.ClassDeclaration, prop *parser.PropertyDefinition) (types.Type, types.Type) {
+	var thisType types.Type
+	if prop.IsStatic {
+		thisType, _, _ = c.env.Resolve(node.Name.Value)
+	} else {
+		thisType, _ = c.env.ResolveType(node.Name.Value)
+	}
+	valueType := c.accessorFieldType(thisType, prop)
+	if thisType == nil || valueType == nil {
+		return nil, nil
+	}
+
+	typeArgs := []types.Type{thisType, valueType}
+	instantiate := func(name string) types.Type {
+		alias, exists := c.env.ResolveType(name)
+		if !exists {
+			return nil
+		}
+		generic, ok := alias.(*types.GenericType)
+		if !ok {
+			return nil
+		}
+		return c.instantiateGenericType(generic, typeArgs, nil)
+	}
+	target := instantiate("ClassAccessorDecoratorTarget")
+	context := instantiate("ClassAccessorDecoratorContext")
+	result := instantiate("ClassAccessorDecoratorResult")
+	if target == nil || context == nil || result == nil {
+		return nil, nil
+	}
+	return types.NewSimpleFunction([]types.Type{target, context}, types.NewUnionType(result, types.Void)), valueType
+}
+
+// accessorFieldType returns the type of the accessor field prop: its
+// annotation, else its widened type in the class type holding it, or that of
+// its initializer
+func (c *Checker) accessorFieldType(classType types.Type, prop *parser.PropertyDefinition) types.Type {
+	if prop.TypeAnnotation != nil {
+		return c.resolveTypeAnnotation(prop.TypeAnnotation)
+	}
+	if ident, ok := prop.Key.(*parser.Identifier); ok {
+		if obj, ok := types.GetEffectiveType(classType).(*types.ObjectType); ok {
+			if fieldType, exists := obj.GetEffectiveProperties()[ident.Value]; exists && fieldType != nil {
+				return types.GetWidenedType(fieldType)
+			}
+		}
+	}
+	if prop.Value != nil {
+		if valueType := prop.Value.GetComputedType(); valueType != nil {
+			return types.GetWidenedType(valueType)
+		}
+	}
+	return nil
+}
+
 // checkDecoratorExpression validates that a decorator expression resolves to a callable type.
 func (c *Checker) checkDecoratorExpression(dec *parser.Decorator) {
 	// Resolve the decorator expression type manually from the environment,
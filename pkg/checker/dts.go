@@ -0,0 +1,1673 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// Declaration emit writes the .d.ts of a checked program. Each exported
+// declaration is written with the types the checker gave it, so unannotated
+// variables and functions get their inferred types. Types are written by name
+// when they are the type of a declaration in scope - an interface, alias,
+// class, enum or namespace of the program, an import, or a built-in - and
+// structurally otherwise. Local declarations the written types name are
+// emitted too, without `export`.
+
+// EmitDeclarations returns the declaration file of program, which the
+// checker must have checked without errors
+func (c *Checker) EmitDeclarations(program *parser.Program) string {
+	e := &declarationEmitter{
+		c:        c,
+		names:    make(map[types.Type]typeName),
+		printing: make(map[types.Type]bool),
+		module:   isModuleProgram(program),
+	}
+	top := e.envScope(c.env)
+	e.nameProgram(program, top)
+
+	var imports []*parser.ImportDeclaration
+	var chunks []*dtsChunk
+	for _, stmt := range program.Statements {
+		if imp, ok := stmt.(*parser.ImportDeclaration); ok {
+			imports = append(imports, imp)
+			continue
+		}
+		chunks = append(chunks, e.statementChunks(stmt, program.Statements, top)...)
+	}
+
+	// Local declarations are emitted when an emitted declaration names them
+	declaredBy := make(map[string][]*dtsChunk)
+	var pending []*dtsChunk
+	for _, chunk := range chunks {
+		for _, name := range chunk.declares {
+			declaredBy[name] = append(declaredBy[name], chunk)
+		}
+		if chunk.exported {
+			pending = append(pending, chunk)
+		}
+	}
+	used := make(map[string]bool)
+	emitted := make(map[*dtsChunk]bool)
+	for len(pending) > 0 {
+		chunk := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if emitted[chunk] {
+			continue
+		}
+		emitted[chunk] = true
+		for name := range chunk.uses {
+			used[name] = true
+			for _, local := range declaredBy[name] {
+				if !emitted[local] {
+					pending = append(pending, local)
+				}
+			}
+		}
+	}
+
+	var out strings.Builder
+	for _, imp := range imports {
+		out.WriteString(e.importText(imp, used))
+	}
+	locals := false
+	for _, chunk := range chunks {
+		if emitted[chunk] {
+			out.WriteString(chunk.text)
+			locals = locals || !chunk.exported
+		}
+	}
+	if e.module && locals {
+		out.WriteString("export {};\n") // Keeps the local declarations from being exported
+	}
+	return out.String()
+}
+
+// declarationEmitter writes the declarations of one program
+type declarationEmitter struct {
+	c        *Checker
+	names    map[types.Type]typeName // Types written by name
+	printing map[types.Type]bool     // Types being written, to cut cycles
+	module   bool                    // Whether the program is a module
+
+	out    *strings.Builder // The chunk being written
+	uses   map[string]bool  // Names the chunk being written refers to
+	indent int              // Indentation of the line being written
+	depth  int              // Nesting of the object type being written
+}
+
+// typeName is how a type is written by name
+type typeName struct {
+	text string // e.g. "Point", "typeof Point" or "N.Point"
+	ref  string // The binding text refers to
+}
+
+// dtsChunk is the declaration of one statement
+type dtsChunk struct {
+	text     string
+	declares []string        // Names the declaration binds
+	uses     map[string]bool // Names the declaration refers to
+	exported bool            // Whether it is emitted whether or not it is used
+}
+
+// dtsScope looks up the types of the declarations of a scope
+type dtsScope struct {
+	value func(name string) types.Type
+	typ   func(name string) types.Type
+}
+
+func (e *declarationEmitter) envScope(env *Environment) dtsScope {
+	return dtsScope{
+		value: func(name string) types.Type {
+			if t, _, found := env.Resolve(name); found {
+				return t
+			}
+			return nil
+		},
+		typ: func(name string) types.Type {
+			if t, found := env.ResolveType(name); found {
+				return t
+			}
+			return nil
+		},
+	}
+}
+
+func (e *declarationEmitter) namespaceScope(ns *types.NamespaceType) dtsScope {
+	return dtsScope{
+		value: func(name string) types.Type { return ns.ValueShape.Properties[name] },
+		typ:   func(name string) types.Type { return ns.TypeMembers[name] },
+	}
+}
+
+func (e *declarationEmitter) moduleScope(exports map[string]types.Type) dtsScope {
+	lookup := func(name string) types.Type { return exports[name] }
+	return dtsScope{value: lookup, typ: lookup}
+}
+
+// --- Naming types ---
+
+// nameProgram records the names of the types the program declares, imports
+// and has built in
+func (e *declarationEmitter) nameProgram(program *parser.Program, top dtsScope) {
+	for _, stmt := range program.Statements {
+		if imp, ok := stmt.(*parser.ImportDeclaration); ok {
+			for _, spec := range imp.Specifiers {
+				if local := importLocal(spec); local != "" {
+					e.nameType(top.typ(local), local, local)
+					e.nameValue(top.value(local), local, local)
+				}
+			}
+			continue
+		}
+		e.nameDeclaration(unwrapExport(stmt), top, "")
+	}
+
+	root := e.c.env
+	for root.outer != nil {
+		root = root.outer
+	}
+	builtins := make([]string, 0, len(root.typeAliases))
+	for name := range root.typeAliases {
+		builtins = append(builtins, name)
+	}
+	sort.Strings(builtins)
+	for _, name := range builtins {
+		e.nameType(root.typeAliases[name], name, "")
+	}
+}
+
+// nameDeclaration records the names of the types stmt declares, qualified
+// with the namespace prefix
+func (e *declarationEmitter) nameDeclaration(stmt parser.Statement, scope dtsScope, prefix string) {
+	ref := func(name string) string {
+		if prefix != "" {
+			return strings.SplitN(prefix, ".", 2)[0]
+		}
+		return name
+	}
+	switch node := stmt.(type) {
+	case *parser.InterfaceDeclaration:
+		e.nameType(scope.typ(node.Name.Value), prefix+node.Name.Value, ref(node.Name.Value))
+	case *parser.TypeAliasStatement:
+		e.nameType(scope.typ(node.Name.Value), prefix+node.Name.Value, ref(node.Name.Value))
+	case *parser.ClassDeclaration:
+		if node.Name != nil {
+			e.nameType(scope.typ(node.Name.Value), prefix+node.Name.Value, ref(node.Name.Value))
+			e.nameValue(scope.value(node.Name.Value), prefix+node.Name.Value, ref(node.Name.Value))
+		}
+	case *parser.NamespaceDeclaration:
+		name := node.Name.Value
+		ns, ok := scope.typ(name).(*types.NamespaceType)
+		if !ok {
+			return
+		}
+		e.nameType(ns, prefix+name, ref(name))
+		if _, named := e.names[ns.ValueShape]; !named {
+			e.names[ns.ValueShape] = typeName{text: "typeof " + prefix + name, ref: ref(name)}
+		}
+		if node.Body != nil {
+			for _, inner := range node.Body.Statements {
+				if exp, ok := inner.(*parser.ExportNamedDeclaration); ok && exp.Declaration != nil {
+					e.nameDeclaration(unwrapExport(exp.Declaration), e.namespaceScope(ns), prefix+name+".")
+				} else if nested, ok := inner.(*parser.NamespaceDeclaration); ok && nested.IsExported {
+					e.nameDeclaration(nested, e.namespaceScope(ns), prefix+name+".")
+				}
+			}
+		}
+	case *parser.ExpressionStatement:
+		if enum, ok := node.Expression.(*parser.EnumDeclaration); ok && enum.Name != nil {
+			e.nameType(scope.typ(enum.Name.Value), prefix+enum.Name.Value, ref(enum.Name.Value))
+			e.nameValue(scope.value(enum.Name.Value), prefix+enum.Name.Value, ref(enum.Name.Value))
+		}
+	}
+}
+
+// nameType records that t is written as text. Primitives and literals are
+// always written as themselves, so aliases of them are not recorded.
+func (e *declarationEmitter) nameType(t types.Type, text, ref string) {
+	switch t.(type) {
+	case *types.ObjectType, *types.UnionType, *types.IntersectionType, *types.TupleType, *types.ArrayType,
+		*types.GenericType, *types.MappedType, *types.ConditionalType, *types.NamespaceType:
+		if _, named := e.names[t]; !named {
+			e.names[t] = typeName{text: text, ref: ref}
+		}
+	}
+}
+
+// nameValue records that the type of the class, enum or namespace value
+// named text is written as `typeof text`
+func (e *declarationEmitter) nameValue(t types.Type, text, ref string) {
+	switch value := t.(type) {
+	case *types.EnumType:
+		e.names[value] = typeName{text: "typeof " + text, ref: ref}
+	case *types.GenericType:
+		e.nameValue(value.Body, text, ref)
+	case *types.ObjectType:
+		if _, named := e.names[value]; !named && (len(value.ConstructSignatures) > 0 || value.IsClassConstructor()) {
+			e.names[value] = typeName{text: "typeof " + text, ref: ref}
+		}
+	}
+}
+
+// --- Statements ---
+
+// statementChunks returns the declarations of a top-level statement
+func (e *declarationEmitter) statementChunks(stmt parser.Statement, siblings []parser.Statement, top dtsScope) []*dtsChunk {
+	var chunks []*dtsChunk
+	chunk := func(exported bool, declares []string, write func()) {
+		e.out = &strings.Builder{}
+		e.uses = make(map[string]bool)
+		write()
+		if e.out.Len() > 0 {
+			chunks = append(chunks, &dtsChunk{text: e.out.String(), declares: declares, uses: e.uses, exported: exported})
+		}
+	}
+
+	exportKeyword := ""
+	if e.module {
+		exportKeyword = "export "
+	}
+
+	switch node := stmt.(type) {
+	case *parser.ExportNamedDeclaration:
+		switch {
+		case node.Declaration != nil:
+			chunk(true, nil, func() { e.writeDeclaration(node.Declaration, siblings, top, exportKeyword, "declare ") })
+		case node.Source != nil:
+			chunk(true, nil, func() {
+				e.line(fmt.Sprintf("export %s{ %s } from %s;", typeOnly(node.IsTypeOnly), exportSpecifiers(node.Specifiers), strconv.Quote(node.Source.Value)))
+			})
+		default:
+			chunk(true, nil, func() {
+				for _, spec := range node.Specifiers {
+					if named, ok := spec.(*parser.ExportNamedSpecifier); ok {
+						if ident, ok := named.Local.(*parser.Identifier); ok {
+							e.uses[ident.Value] = true
+						}
+					}
+				}
+				e.line(fmt.Sprintf("export %s{ %s };", typeOnly(node.IsTypeOnly), exportSpecifiers(node.Specifiers)))
+			})
+		}
+	case *parser.ExportAllDeclaration:
+		chunk(true, nil, func() {
+			exported := ""
+			if node.Exported != nil {
+				exported = " as " + specifierName(node.Exported)
+			}
+			e.line(fmt.Sprintf("export %s*%s from %s;", typeOnly(node.IsTypeOnly), exported, strconv.Quote(node.Source.Value)))
+		})
+	case *parser.ExportDefaultDeclaration:
+		chunk(true, nil, func() { e.writeDefaultExport(node, top) })
+	case *parser.ExportAssignment:
+		chunk(true, nil, func() {
+			if ident, ok := node.Expression.(*parser.Identifier); ok {
+				e.uses[ident.Value] = true
+				e.line(fmt.Sprintf("export = %s;", ident.Value))
+				return
+			}
+			e.line(fmt.Sprintf("declare const _exports: %s;", e.tsType(node.Expression.GetComputedType())))
+			e.line("export = _exports;")
+		})
+	case *parser.GlobalDeclaration:
+		chunk(true, nil, func() { e.writeGlobalDeclaration(node) })
+	case *parser.AmbientModuleDeclaration:
+		chunk(true, nil, func() { e.writeAmbientModule(node) })
+	default:
+		if names := declaredNames(stmt); len(names) > 0 {
+			chunk(!e.module, names, func() { e.writeDeclaration(stmt, siblings, top, "", "declare ") })
+		}
+	}
+	return chunks
+}
+
+// writeDeclaration writes the declaration of stmt with the given export and
+// declare keywords
+func (e *declarationEmitter) writeDeclaration(stmt parser.Statement, siblings []parser.Statement, scope dtsScope, exportKeyword, declareKeyword string) {
+	modifiers := exportKeyword + declareKeyword
+	switch node := stmt.(type) {
+	case *parser.ConstStatement:
+		e.writeVariables("const", node.Declarations, scope, modifiers)
+	case *parser.LetStatement:
+		e.writeVariables("let", node.Declarations, scope, modifiers)
+	case *parser.VarStatement:
+		e.writeVariables("var", node.Declarations, scope, modifiers)
+	case *parser.FunctionSignature:
+		e.writeFunction(modifiers+"function ", node.Name.Value, node.GetComputedType(), node.TypeParameters, node.Parameters, node.RestParameter, node.ReturnTypeAnnotation)
+	case *parser.ClassDeclaration:
+		if node.Name != nil {
+			e.writeClass(modifiers, node.Name.Value, node.TypeParameters, node.SuperClass, node.Implements, node.Body, node.IsAbstract,
+				scope.value(node.Name.Value), scope.typ(node.Name.Value))
+		}
+	case *parser.InterfaceDeclaration:
+		e.writeInterface(exportKeyword, node, scope.typ(node.Name.Value))
+	case *parser.TypeAliasStatement:
+		e.writeTypeAlias(exportKeyword, node, scope.typ(node.Name.Value))
+	case *parser.NamespaceDeclaration:
+		e.writeNamespace(modifiers, node, scope)
+	case *parser.ExpressionStatement:
+		switch expr := node.Expression.(type) {
+		case *parser.FunctionLiteral:
+			if expr.Name == nil || hasOverloads(siblings, expr.Name.Value) {
+				return // Overloaded functions are declared by their signatures
+			}
+			fnType := expr.GetComputedType()
+			if fnType == nil {
+				fnType = scope.value(expr.Name.Value)
+			}
+			e.writeFunction(modifiers+"function ", expr.Name.Value, fnType, expr.TypeParameters, expr.Parameters, expr.RestParameter, expr.ReturnTypeAnnotation)
+		case *parser.FunctionSignature:
+			e.writeDeclaration(expr, siblings, scope, exportKeyword, declareKeyword)
+		case *parser.EnumDeclaration:
+			e.writeEnum(modifiers, expr, scope.value(expr.Name.Value))
+		case *parser.ClassExpression:
+			if expr.Name != nil {
+				e.writeClass(modifiers, expr.Name.Value, expr.TypeParameters, expr.SuperClass, expr.Implements, expr.Body, expr.IsAbstract,
+					scope.value(expr.Name.Value), scope.typ(expr.Name.Value))
+			}
+		}
+	}
+}
+
+// writeVariables writes `const x: T;` for each declarator
+func (e *declarationEmitter) writeVariables(kind string, declarators []*parser.VarDeclarator, scope dtsScope, modifiers string) {
+	for _, d := range declarators {
+		if d == nil || d.Name == nil {
+			continue
+		}
+		t := scope.value(d.Name.Value)
+		if t == nil {
+			t = d.ComputedType
+		}
+		if d.TypeAnnotation == nil && d.Value != nil {
+			// Unannotated constants keep primitive literal types, object
+			// literals get mutable properties
+			if _, ok := d.Value.(*parser.ObjectLiteral); ok {
+				t = types.DeeplyWidenType(t)
+			} else if literal, ok := d.Value.GetComputedType().(*types.LiteralType); ok && kind == "const" {
+				t = literal
+			}
+		}
+		if kind != "const" {
+			t = types.GetWidenedType(t)
+		}
+		e.line(fmt.Sprintf("%s%s %s: %s;", modifiers, kind, d.Name.Value, e.tsType(t)))
+	}
+}
+
+// writeFunction writes `function name(params): R;` for each call signature
+// of fnType, the type of a function declaration with the given parameters
+func (e *declarationEmitter) writeFunction(prefix, name string, fnType types.Type, typeParams []*parser.TypeParameter, params []*parser.Parameter, rest *parser.RestParameter, returnAnnotation parser.Expression) {
+	sigs := callSignatures(fnType)
+	if len(sigs) == 0 {
+		e.line(fmt.Sprintf("%s%s(...args: any[]): any;", prefix, name))
+		return
+	}
+	names := declaredParams(params, rest)
+	for _, sig := range sigs {
+		e.line(fmt.Sprintf("%s%s%s: %s;", prefix, name, e.signature(sig, names, typeParameters(sig, typeParams)), e.declaredReturnType(sig, returnAnnotation)))
+	}
+}
+
+// writeDefaultExport writes the declaration of `export default ...`
+func (e *declarationEmitter) writeDefaultExport(node *parser.ExportDefaultDeclaration, scope dtsScope) {
+	switch decl := node.Declaration.(type) {
+	case *parser.FunctionLiteral:
+		if decl.Name != nil {
+			e.writeFunction("export default function ", decl.Name.Value, decl.GetComputedType(), decl.TypeParameters, decl.Parameters, decl.RestParameter, decl.ReturnTypeAnnotation)
+			return
+		}
+		// Function signatures need a name, so an anonymous one gets TypeScript's
+		e.writeFunction("declare function ", "_default", decl.GetComputedType(), decl.TypeParameters, decl.Parameters, decl.RestParameter, decl.ReturnTypeAnnotation)
+		e.line("export default _default;")
+		return
+	case *parser.ClassExpression:
+		if decl.Name != nil {
+			ctor := decl.GetComputedType()
+			e.writeClass("export default ", decl.Name.Value, decl.TypeParameters, decl.SuperClass, decl.Implements, decl.Body, decl.IsAbstract, ctor, nil)
+			return
+		}
+	case *parser.Identifier:
+		if scope.value(decl.Value) != nil || scope.typ(decl.Value) != nil {
+			e.uses[decl.Value] = true
+			e.line(fmt.Sprintf("export default %s;", decl.Value))
+			return
+		}
+	}
+	e.line(fmt.Sprintf("declare const _default: %s;", e.tsType(node.Declaration.GetComputedType())))
+	e.line("export default _default;")
+}
+
+// writeClass writes a class declaration. ctor is the type of the class
+// value and instance that of its instances, derived from ctor when nil.
+func (e *declarationEmitter) writeClass(modifiers, name string, typeParamNodes []*parser.TypeParameter, superClass parser.Expression,
+	implements []*parser.Identifier, body *parser.ClassBody, isAbstract bool, ctor, instance types.Type) {
+	var typeParams []*types.TypeParameter
+	if generic, ok := ctor.(*types.GenericType); ok {
+		ctor = generic.Body
+	}
+	if generic, ok := instance.(*types.GenericType); ok {
+		typeParams = generic.TypeParameters
+		instance = generic.Body
+	}
+	ctorObj, _ := ctor.(*types.ObjectType)
+	instObj, _ := instance.(*types.ObjectType)
+	if instObj == nil && ctorObj != nil && len(ctorObj.ConstructSignatures) > 0 {
+		instObj, _ = ctorObj.ConstructSignatures[0].ReturnType.(*types.ObjectType)
+	}
+	if ctorObj == nil {
+		ctorObj = types.NewObjectType()
+	}
+	if instObj == nil {
+		instObj = types.NewObjectType()
+	}
+	if typeParams == nil {
+		typeParams = astTypeParameters(typeParamNodes)
+	}
+
+	header := modifiers
+	if isAbstract {
+		header += "abstract "
+	}
+	header += "class " + name + e.typeParameterList(typeParams)
+	if superClass != nil {
+		if base := e.heritage(superClass, typeParams); base != "" {
+			header += " extends " + base
+		}
+	}
+	if len(implements) > 0 {
+		interfaces := make([]string, len(implements))
+		for i, ident := range implements {
+			e.uses[ident.Value] = true
+			interfaces[i] = ident.Value
+		}
+		header += " implements " + strings.Join(interfaces, ", ")
+	}
+	e.line(header + " {")
+	e.indent++
+	if body != nil {
+		e.writeClassMembers(body, ctorObj, instObj)
+	}
+	e.indent--
+	e.line("}")
+}
+
+// classMember is a member of a class body in source order
+type classMember struct {
+	pos   int
+	write func()
+}
+
+func (e *declarationEmitter) writeClassMembers(body *parser.ClassBody, ctorObj, instObj *types.ObjectType) {
+	var members []classMember
+	hasPrivateNames := false
+	privateWritten := make(map[string]bool)
+	writePrivate := func(prefix, key string) {
+		if !privateWritten[prefix+key] {
+			privateWritten[prefix+key] = true
+			e.line(prefix + "private " + key + ";")
+		}
+	}
+	memberType := func(static bool, key string) types.Type {
+		if static {
+			return ctorObj.Properties[key]
+		}
+		return instObj.Properties[key]
+	}
+
+	// Parameter properties are declared with the constructor they belong to
+	parameterProperties := make(map[string]bool)
+	for _, method := range body.Methods {
+		if method.Kind != "constructor" {
+			continue
+		}
+		for _, param := range method.Value.Parameters {
+			if param.Name != nil && (param.IsPublic || param.IsPrivate || param.IsProtected || param.IsReadonly) {
+				parameterProperties[param.Name.Value] = true
+			}
+		}
+	}
+
+	for _, prop := range body.Properties {
+		prop := prop
+		key, ok := memberKey(prop.Key)
+		if !ok || (!prop.IsStatic && parameterProperties[key]) {
+			continue
+		}
+		if strings.HasPrefix(key, "#") {
+			hasPrivateNames = true
+			continue
+		}
+		members = append(members, classMember{pos: prop.Token.StartPos, write: func() {
+			prefix := staticPrefix(prop.IsStatic)
+			if prop.IsPrivate {
+				writePrivate(prefix, key)
+				return
+			}
+			if prop.IsProtected {
+				prefix = "protected " + prefix
+			}
+			if prop.Readonly {
+				prefix += "readonly "
+			}
+			if prop.IsAccessor {
+				prefix += "accessor "
+			}
+			t := memberType(prop.IsStatic, key)
+			if !prop.Readonly {
+				t = types.GetWidenedType(t)
+			}
+			optional := ""
+			if prop.Optional {
+				optional = "?"
+				t = withoutUndefined(t)
+			}
+			e.line(fmt.Sprintf("%s%s%s: %s;", prefix, key, optional, e.tsType(t)))
+		}})
+	}
+
+	overloaded := make(map[string][]*parser.MethodSignature)
+	for _, sig := range body.MethodSigs {
+		if key, ok := memberKey(sig.Key); ok {
+			overloaded[staticPrefix(sig.IsStatic)+key] = append(overloaded[staticPrefix(sig.IsStatic)+key], sig)
+		}
+	}
+	written := make(map[string]bool)
+	writeOverloads := func(key string, static bool, access string, sigs []*parser.MethodSignature) {
+		prefix := staticPrefix(static)
+		if written[prefix+key] {
+			return
+		}
+		written[prefix+key] = true
+		if access == "private" {
+			writePrivate(prefix, key)
+			return
+		}
+		if access != "" {
+			prefix = access + " " + prefix
+		}
+		callSigs := callSignatures(memberType(static, key))
+		for i, sig := range callSigs {
+			node := sigs[len(sigs)-1]
+			if len(callSigs) == len(sigs) {
+				node = sigs[i]
+			}
+			abstract, optional := "", ""
+			if node.IsAbstract {
+				abstract = "abstract "
+			}
+			if node.Optional {
+				optional = "?"
+			}
+			e.line(fmt.Sprintf("%s%s%s%s%s: %s;", prefix, abstract, key, optional,
+				e.signature(sig, declaredParams(node.Parameters, node.RestParameter), typeParameters(sig, node.TypeParameters)), e.returnType(sig)))
+		}
+	}
+
+	if len(body.ConstructorSigs) > 0 {
+		sigs := body.ConstructorSigs
+		members = append(members, classMember{pos: sigs[0].Token.StartPos, write: func() {
+			for i, sig := range ctorObj.ConstructSignatures {
+				node := sigs[len(sigs)-1]
+				if len(ctorObj.ConstructSignatures) == len(sigs) {
+					node = sigs[i]
+				}
+				e.line(fmt.Sprintf("%sconstructor%s;", accessPrefix(node.IsPrivate, node.IsProtected),
+					e.signature(sig, declaredParams(node.Parameters, node.RestParameter), nil)))
+			}
+		}})
+	}
+	for _, sig := range body.MethodSigs {
+		sig := sig
+		key, ok := memberKey(sig.Key)
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		members = append(members, classMember{pos: sig.Token.StartPos, write: func() {
+			writeOverloads(key, sig.IsStatic, accessName(sig.IsPrivate, sig.IsProtected), overloaded[staticPrefix(sig.IsStatic)+key])
+		}})
+	}
+
+	for _, method := range body.Methods {
+		method := method
+		if method.Kind == "constructor" {
+			members = append(members, classMember{pos: method.Token.StartPos, write: func() {
+				for _, param := range method.Value.Parameters {
+					if param.Name == nil || !(param.IsPublic || param.IsPrivate || param.IsProtected || param.IsReadonly) {
+						continue
+					}
+					if param.IsPrivate {
+						writePrivate("", param.Name.Value)
+						continue
+					}
+					prefix := accessPrefix(false, param.IsProtected)
+					if param.IsReadonly {
+						prefix += "readonly "
+					}
+					name, t := param.Name.Value, instObj.Properties[param.Name.Value]
+					if param.Optional {
+						name, t = name+"?", withoutUndefined(t)
+					}
+					e.line(fmt.Sprintf("%s%s: %s;", prefix, name, e.tsType(t)))
+				}
+				if len(body.ConstructorSigs) > 0 {
+					return // Declared by its overloads
+				}
+				var sig *types.Signature
+				if len(ctorObj.ConstructSignatures) > 0 {
+					sig = ctorObj.ConstructSignatures[0]
+				} else if sigs := callSignatures(method.Value.GetComputedType()); len(sigs) > 0 {
+					sig = sigs[0]
+				}
+				if sig == nil {
+					return
+				}
+				e.line(fmt.Sprintf("%sconstructor%s;", accessPrefix(method.IsPrivate, method.IsProtected),
+					e.signature(sig, declaredParams(method.Value.Parameters, method.Value.RestParameter), nil)))
+			}})
+			continue
+		}
+		key, ok := memberKey(method.Key)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, "#") {
+			hasPrivateNames = true
+			continue
+		}
+		prefix := staticPrefix(method.IsStatic)
+		if sigs, isOverloaded := overloaded[prefix+key]; isOverloaded && method.Kind == "method" {
+			members = append(members, classMember{pos: method.Token.StartPos, write: func() {
+				writeOverloads(key, method.IsStatic, accessName(method.IsPrivate, method.IsProtected), sigs)
+			}})
+			continue
+		}
+		members = append(members, classMember{pos: method.Token.StartPos, write: func() {
+			if method.IsPrivate {
+				writePrivate(prefix, key)
+				return
+			}
+			linePrefix := accessPrefix(false, method.IsProtected) + prefix
+			if method.IsAbstract {
+				linePrefix += "abstract "
+			}
+			var sig *types.Signature
+			if sigs := callSignatures(method.Value.GetComputedType()); len(sigs) > 0 {
+				sig = sigs[0]
+			}
+			switch method.Kind {
+			case "getter":
+				var t types.Type
+				if sig != nil {
+					t = sig.ReturnType
+				}
+				if t == nil || t == types.Any {
+					t = memberType(method.IsStatic, key)
+				}
+				if method.Value.ReturnTypeAnnotation == nil {
+					t = e.widenInferred(t)
+				}
+				e.line(fmt.Sprintf("%sget %s(): %s;", linePrefix, key, e.tsType(t)))
+			case "setter":
+				paramName := "value"
+				if len(method.Value.Parameters) > 0 && method.Value.Parameters[0].Name != nil {
+					paramName = method.Value.Parameters[0].Name.Value
+				}
+				var t types.Type
+				if sig != nil && len(sig.ParameterTypes) > 0 {
+					t = sig.ParameterTypes[0]
+				} else {
+					t = memberType(method.IsStatic, key)
+				}
+				e.line(fmt.Sprintf("%sset %s(%s: %s);", linePrefix, key, paramName, e.tsType(t)))
+			default:
+				if sig == nil || (sig.ReturnType == types.Any && method.Value.ReturnTypeAnnotation == nil) {
+					if classSigs := callSignatures(memberType(method.IsStatic, key)); len(classSigs) > 0 {
+						sig = classSigs[0]
+					}
+				}
+				if sig == nil {
+					e.line(fmt.Sprintf("%s%s(...args: any[]): any;", linePrefix, key))
+					return
+				}
+				e.line(fmt.Sprintf("%s%s%s: %s;", linePrefix, key,
+					e.signature(sig, declaredParams(method.Value.Parameters, method.Value.RestParameter), typeParameters(sig, method.Value.TypeParameters)),
+					e.declaredReturnType(sig, method.Value.ReturnTypeAnnotation)))
+			}
+		}})
+	}
+
+	if hasPrivateNames {
+		e.line("#private;")
+	}
+	sort.SliceStable(members, func(i, j int) bool { return members[i].pos < members[j].pos })
+	for _, member := range members {
+		member.write()
+	}
+}
+
+// writeInterface writes an interface declaration with the members declared
+// by node, in source order
+func (e *declarationEmitter) writeInterface(exportKeyword string, node *parser.InterfaceDeclaration, t types.Type) {
+	var typeParams []*types.TypeParameter
+	if generic, ok := t.(*types.GenericType); ok {
+		typeParams = generic.TypeParameters
+		t = generic.Body
+	}
+	obj, _ := t.(*types.ObjectType)
+	if obj == nil {
+		obj = types.NewObjectType()
+	}
+
+	header := exportKeyword + "interface " + node.Name.Value + e.typeParameterList(typeParams)
+	if len(node.Extends) > 0 {
+		bases := make([]string, 0, len(node.Extends))
+		for _, expr := range node.Extends {
+			if base := e.heritage(expr, typeParams); base != "" {
+				bases = append(bases, base)
+			}
+		}
+		if len(bases) > 0 {
+			header += " extends " + strings.Join(bases, ", ")
+		}
+	}
+	e.line(header + " {")
+	e.indent++
+	calls, indexes := 0, 0
+	for _, prop := range node.Properties {
+		switch {
+		case prop.IsIndexSignature:
+			if indexes < len(obj.IndexSignatures) {
+				e.line(e.indexSignature(obj.IndexSignatures[indexes], prop.KeyName) + ";")
+				indexes++
+			}
+		case prop.IsConstructorSignature:
+			for _, sig := range constructSignatures(obj.Properties["new"]) {
+				e.line(fmt.Sprintf("new %s: %s;", e.signature(sig, nil, sig.TypeParameters), e.returnType(sig)))
+			}
+		case prop.Name == nil:
+			if calls < len(obj.CallSignatures) {
+				sig := obj.CallSignatures[calls]
+				e.line(fmt.Sprintf("%s: %s;", e.signature(sig, nil, sig.TypeParameters), e.returnType(sig)))
+				calls++
+			}
+		default:
+			name := prop.Name.Value
+			optional := ""
+			if prop.Optional {
+				optional = "?"
+			}
+			readonly := ""
+			if obj.ReadOnlyProperties[name] {
+				readonly = "readonly "
+			}
+			propType := obj.Properties[name]
+			if prop.IsMethod {
+				for _, sig := range callSignatures(propType) {
+					e.line(fmt.Sprintf("%s%s%s: %s;", propertyKey(name), optional, e.signature(sig, nil, sig.TypeParameters), e.returnType(sig)))
+				}
+				continue
+			}
+			if prop.Optional {
+				propType = withoutUndefined(propType)
+			}
+			e.line(fmt.Sprintf("%s%s%s: %s;", readonly, propertyKey(name), optional, e.tsType(propType)))
+		}
+	}
+	e.indent--
+	e.line("}")
+}
+
+// writeTypeAlias writes `type A<T> = ...;`
+func (e *declarationEmitter) writeTypeAlias(exportKeyword string, node *parser.TypeAliasStatement, t types.Type) {
+	var typeParams []*types.TypeParameter
+	if generic, ok := t.(*types.GenericType); ok {
+		typeParams = generic.TypeParameters
+		t = generic.Body
+	}
+	e.line(fmt.Sprintf("%stype %s%s = %s;", exportKeyword, node.Name.Value, e.typeParameterList(typeParams), e.typeBody(t)))
+}
+
+// writeEnum writes an enum declaration with the values of its members
+func (e *declarationEmitter) writeEnum(modifiers string, node *parser.EnumDeclaration, t types.Type) {
+	enum, _ := t.(*types.EnumType)
+	keyword := "enum "
+	if node.IsConst {
+		keyword = "const enum "
+	}
+	e.line(modifiers + keyword + node.Name.Value + " {")
+	e.indent++
+	for i, member := range node.Members {
+		text := propertyKey(member.Name.Value)
+		if enum != nil {
+			if m, ok := enum.Members[member.Name.Value]; ok {
+				switch value := m.Value.(type) {
+				case string:
+					text += " = " + strconv.Quote(value)
+				case nil:
+				default:
+					text += fmt.Sprintf(" = %v", value)
+				}
+			}
+		}
+		if i < len(node.Members)-1 {
+			text += ","
+		}
+		e.line(text)
+	}
+	e.indent--
+	e.line("}")
+}
+
+// writeNamespace writes a namespace with its exported members
+func (e *declarationEmitter) writeNamespace(modifiers string, node *parser.NamespaceDeclaration, scope dtsScope) {
+	ns, ok := scope.typ(node.Name.Value).(*types.NamespaceType)
+	if !ok || node.Body == nil {
+		return
+	}
+	e.line(modifiers + "namespace " + node.Name.Value + " {")
+	e.indent++
+	inner := e.namespaceScope(ns)
+	for _, stmt := range node.Body.Statements {
+		if exp, ok := stmt.(*parser.ExportNamedDeclaration); ok && exp.Declaration != nil {
+			e.writeDeclaration(exp.Declaration, node.Body.Statements, inner, "", "")
+		} else if nested, ok := stmt.(*parser.NamespaceDeclaration); ok && nested.IsExported {
+			e.writeDeclaration(nested, node.Body.Statements, inner, "", "")
+		}
+	}
+	e.indent--
+	e.line("}")
+}
+
+// writeGlobalDeclaration writes a `declare global { ... }` block
+func (e *declarationEmitter) writeGlobalDeclaration(node *parser.GlobalDeclaration) {
+	if node.Body == nil {
+		return
+	}
+	root := e.c.env
+	for root.outer != nil {
+		root = root.outer
+	}
+	e.line("declare global {")
+	e.indent++
+	for _, stmt := range node.Body.Statements {
+		e.writeDeclaration(unwrapExport(stmt), node.Body.Statements, e.envScope(root), "", "")
+	}
+	e.indent--
+	e.line("}")
+}
+
+// writeAmbientModule writes a `declare module "name" { ... }` block
+func (e *declarationEmitter) writeAmbientModule(node *parser.AmbientModuleDeclaration) {
+	if node.Body == nil {
+		e.line(fmt.Sprintf("declare module %s;", strconv.Quote(node.Name.Value)))
+		return
+	}
+	scope := e.moduleScope(e.c.ambientModules[node.Name.Value])
+	e.line(fmt.Sprintf("declare module %s {", strconv.Quote(node.Name.Value)))
+	e.indent++
+	for _, stmt := range node.Body.Statements {
+		if exp, ok := stmt.(*parser.ExportNamedDeclaration); ok && exp.Declaration != nil {
+			e.writeDeclaration(exp.Declaration, node.Body.Statements, scope, "export ", "")
+		}
+	}
+	e.indent--
+	e.line("}")
+}
+
+// importText returns the import declaration of the names of imp that are
+// used, or "" if none is
+func (e *declarationEmitter) importText(imp *parser.ImportDeclaration, used map[string]bool) string {
+	var defaultName, namespace string
+	var named []string
+	for _, spec := range imp.Specifiers {
+		if !used[importLocal(spec)] {
+			continue
+		}
+		switch s := spec.(type) {
+		case *parser.ImportDefaultSpecifier:
+			defaultName = s.Local.Value
+		case *parser.ImportNamespaceSpecifier:
+			namespace = "* as " + s.Local.Value
+		case *parser.ImportNamedSpecifier:
+			text := s.Imported.Value
+			if s.Local.Value != s.Imported.Value {
+				text += " as " + s.Local.Value
+			}
+			if s.IsTypeOnly {
+				text = "type " + text
+			}
+			named = append(named, text)
+		}
+	}
+	var clauses []string
+	if defaultName != "" {
+		clauses = append(clauses, defaultName)
+	}
+	if namespace != "" {
+		clauses = append(clauses, namespace)
+	}
+	if len(named) > 0 {
+		clauses = append(clauses, "{ "+strings.Join(named, ", ")+" }")
+	}
+	if len(clauses) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("import %s%s from %s;\n", typeOnly(imp.IsTypeOnly), strings.Join(clauses, ", "), strconv.Quote(imp.Source.Value))
+}
+
+// heritage returns the text of a type in an extends clause, resolved with the
+// declaration's type parameters in scope
+func (e *declarationEmitter) heritage(expr parser.Expression, typeParams []*types.TypeParameter) string {
+	switch node := expr.(type) {
+	case *parser.Identifier:
+		e.uses[node.Value] = true
+		return node.Value
+	case *parser.GenericTypeRef:
+		e.uses[node.Name.Value] = true
+		args := make([]string, len(node.TypeArguments))
+		for i, arg := range node.TypeArguments {
+			if args[i] = e.typeIn(arg, typeParams); args[i] == "" {
+				args[i] = "any"
+			}
+		}
+		return node.Name.Value + "<" + strings.Join(args, ", ") + ">"
+	}
+	return e.typeIn(expr, typeParams)
+}
+
+// typeIn resolves the type expression expr where typeParams are in scope
+// and returns its text, or "" if it doesn't resolve
+func (e *declarationEmitter) typeIn(expr parser.Expression, typeParams []*types.TypeParameter) string {
+	c := e.c
+	outerEnv := c.env
+	c.env = NewEnclosedEnvironment(outerEnv)
+	for _, param := range typeParams {
+		c.env.DefineTypeParameter(param.Name, param)
+		c.env.DefineTypeAlias(param.Name, &types.TypeParameterType{Parameter: param})
+	}
+	errorCount := len(c.errors)
+	t := c.resolveTypeAnnotation(expr)
+	c.errors = c.errors[:errorCount]
+	c.env = outerEnv
+	if t == nil {
+		return ""
+	}
+	return e.tsType(t)
+}
+
+// --- Types ---
+
+// typeArguments returns `<A, B>` for args, or "" if there are none
+func (e *declarationEmitter) typeArguments(args []types.Type) string {
+	if len(args) == 0 {
+		return ""
+	}
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		texts[i] = e.tsType(arg)
+	}
+	return "<" + strings.Join(texts, ", ") + ">"
+}
+
+// line writes a line at the current indentation
+func (e *declarationEmitter) line(text string) {
+	e.out.WriteString(strings.Repeat("    ", e.indent))
+	e.out.WriteString(text)
+	e.out.WriteString("\n")
+}
+
+// typeBody writes t structurally even if it has a name, as the right-hand
+// side of its own alias
+func (e *declarationEmitter) typeBody(t types.Type) string {
+	if name, named := e.names[t]; named {
+		delete(e.names, t)
+		defer func() { e.names[t] = name }()
+	}
+	return e.tsType(t)
+}
+
+// tsType returns the TypeScript text of t
+func (e *declarationEmitter) tsType(t types.Type) string {
+	if t == nil {
+		return "any"
+	}
+	if name, named := e.names[t]; named {
+		if name.ref != "" {
+			e.uses[name.ref] = true
+		}
+		return name.text
+	}
+	if e.printing[t] {
+		return "any"
+	}
+	e.printing[t] = true
+	defer delete(e.printing, t)
+
+	switch typ := t.(type) {
+	case *types.Primitive:
+		return typ.Name
+	case *types.LiteralType:
+		return literalText(typ.Value)
+	case *types.UnionType:
+		return e.unionText(typ)
+	case *types.IntersectionType:
+		parts := make([]string, len(typ.Types))
+		for i, member := range typ.Types {
+			parts[i] = e.operand(member)
+		}
+		return strings.Join(parts, " & ")
+	case *types.ArrayType:
+		return e.operand(typ.ElementType) + "[]"
+	case *types.TupleType:
+		parts := make([]string, 0, len(typ.ElementTypes)+1)
+		for i, element := range typ.ElementTypes {
+			text := e.tsType(element)
+			if i < len(typ.OptionalElements) && typ.OptionalElements[i] {
+				text += "?"
+			}
+			parts = append(parts, text)
+		}
+		if typ.RestElementType != nil {
+			parts = append(parts, "..."+e.tsType(typ.RestElementType))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *types.ReadonlyType:
+		switch typ.InnerType.(type) {
+		case *types.ArrayType, *types.TupleType:
+			return "readonly " + e.operand(typ.InnerType)
+		}
+		return e.tsType(typ.InnerType) // Readonly members are marked as such
+	case *types.ObjectType:
+		return e.objectText(typ)
+	case *types.ClassType:
+		return "typeof " + typ.Name
+	case *types.AliasType:
+		return typ.Name
+	case *types.TypeParameterType:
+		return typ.Parameter.Name
+	case *types.GenericType:
+		return typ.Name
+	case *types.InstantiatedType:
+		name := typ.Generic.Name
+		if generic, named := e.names[typ.Generic]; named {
+			name = generic.text
+			if generic.ref != "" {
+				e.uses[generic.ref] = true
+			}
+		}
+		return name + e.typeArguments(typ.TypeArguments)
+	case *types.ForwardReferenceType:
+		e.uses[typ.ClassName] = true
+		return typ.ClassName
+	case *types.TypeAliasForwardReference:
+		e.uses[typ.AliasName] = true
+		return typ.AliasName
+	case *types.GenericTypeAliasForwardReference:
+		e.uses[typ.AliasName] = true
+		return typ.AliasName + e.typeArguments(typ.TypeArguments)
+	case *types.ParameterizedForwardReferenceType:
+		e.uses[typ.ClassName] = true
+		return typ.ClassName + e.typeArguments(typ.TypeArguments)
+	case *types.EnumType:
+		e.uses[typ.Name] = true
+		return "typeof " + typ.Name
+	case *types.EnumMemberType:
+		e.uses[typ.EnumName] = true
+		return typ.EnumName + "." + propertyKey(typ.MemberName)
+	case *types.NamespaceType:
+		e.uses[typ.Name] = true
+		return typ.Name
+	case *types.KeyofType:
+		return "keyof " + e.operand(typ.OperandType)
+	case *types.TypeofType:
+		e.uses[strings.SplitN(typ.Identifier, ".", 2)[0]] = true
+		return "typeof " + typ.Identifier
+	case *types.IndexedAccessType:
+		return e.operand(typ.ObjectType) + "[" + e.tsType(typ.IndexType) + "]"
+	case *types.ConditionalType:
+		return fmt.Sprintf("%s extends %s ? %s : %s", e.operand(typ.CheckType), e.operand(typ.ExtendsType), e.tsType(typ.TrueType), e.tsType(typ.FalseType))
+	case *types.TypePredicateType:
+		return typ.ParameterName + " is " + e.tsType(typ.Type)
+	case *types.MappedType:
+		return e.mappedText(typ)
+	case *types.TemplateLiteralType:
+		var out strings.Builder
+		out.WriteString("`")
+		for _, part := range typ.Parts {
+			if part.IsLiteral {
+				out.WriteString(strings.ReplaceAll(strings.ReplaceAll(part.Literal, "`", "\\`"), "$", "\\$"))
+			} else {
+				out.WriteString("${" + e.tsType(part.Type) + "}")
+			}
+		}
+		out.WriteString("`")
+		return out.String()
+	}
+	return t.String()
+}
+
+// operand returns the text of t as the operand of an array, intersection
+// or type operator, parenthesized when it would not bind tightly enough
+func (e *declarationEmitter) operand(t types.Type) string {
+	text := e.tsType(t)
+	if _, named := e.names[t]; named {
+		return text
+	}
+	switch typ := t.(type) {
+	case *types.UnionType, *types.IntersectionType, *types.ConditionalType, *types.KeyofType:
+		return "(" + text + ")"
+	case *types.ObjectType:
+		if strings.Contains(text, "=>") && !strings.HasPrefix(text, "{") {
+			return "(" + text + ")"
+		}
+	case *types.LiteralType:
+		if typ.Value.IsNumber() && strings.HasPrefix(text, "-") {
+			return "(" + text + ")"
+		}
+	}
+	return text
+}
+
+// unionText writes a union, with `true | false` as boolean
+func (e *declarationEmitter) unionText(union *types.UnionType) string {
+	var hasTrue, hasFalse bool
+	for _, member := range union.Types {
+		if literal, ok := member.(*types.LiteralType); ok && literal.Value.IsBoolean() {
+			if literal.Value.AsBoolean() {
+				hasTrue = true
+			} else {
+				hasFalse = true
+			}
+		}
+	}
+	parts := make([]string, 0, len(union.Types))
+	wroteBoolean := false
+	for _, member := range union.Types {
+		if literal, ok := member.(*types.LiteralType); ok && literal.Value.IsBoolean() && hasTrue && hasFalse {
+			if !wroteBoolean {
+				parts = append(parts, "boolean")
+				wroteBoolean = true
+			}
+			continue
+		}
+		text := e.tsType(member)
+		if obj, ok := member.(*types.ObjectType); ok {
+			if _, named := e.names[obj]; !named && strings.Contains(text, "=>") && !strings.HasPrefix(text, "{") {
+				text = "(" + text + ")"
+			}
+		}
+		if _, ok := member.(*types.ConditionalType); ok {
+			text = "(" + text + ")"
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// objectText writes an object type: a lone call or construct signature as a
+// function type, anything else as a type literal
+func (e *declarationEmitter) objectText(obj *types.ObjectType) string {
+	if obj.IsPureFunction() && len(obj.CallSignatures) == 1 {
+		sig := obj.CallSignatures[0]
+		return e.signature(sig, nil, sig.TypeParameters) + " => " + e.returnType(sig)
+	}
+	if len(obj.Properties) == 0 && len(obj.CallSignatures) == 0 && len(obj.ConstructSignatures) == 1 {
+		sig := obj.ConstructSignatures[0]
+		return "new " + e.signature(sig, nil, sig.TypeParameters) + " => " + e.returnType(sig)
+	}
+
+	var members []string
+	e.depth++
+	for _, sig := range obj.CallSignatures {
+		members = append(members, e.signature(sig, nil, sig.TypeParameters)+": "+e.returnType(sig))
+	}
+	for _, sig := range obj.ConstructSignatures {
+		members = append(members, "new "+e.signature(sig, nil, sig.TypeParameters)+": "+e.returnType(sig))
+	}
+	keys := make([]string, 0, len(obj.Properties))
+	for key := range obj.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasPrefix(key, "#") || key == "__COMPUTED_PROPERTY__" {
+			continue // Private names have no type-level spelling
+		}
+		if info := obj.GetMemberAccessInfo(key); info != nil && info.AccessLevel != types.AccessPublic {
+			continue
+		}
+		propType := obj.Properties[key]
+		text := propertyKey(key)
+		if obj.ReadOnlyProperties[key] {
+			text = "readonly " + text
+		}
+		if obj.IsPropertyOptional(key) {
+			text += "?"
+			propType = withoutUndefined(propType)
+		}
+		members = append(members, text+": "+e.tsType(propType))
+	}
+	for _, index := range obj.IndexSignatures {
+		members = append(members, e.indexSignature(index, nil))
+	}
+	e.depth--
+
+	if len(members) == 0 {
+		return "{}"
+	}
+	inner := strings.Repeat("    ", e.indent+e.depth+1)
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, member := range members {
+		out.WriteString(inner + member + ";\n")
+	}
+	out.WriteString(strings.Repeat("    ", e.indent+e.depth) + "}")
+	return out.String()
+}
+
+// indexSignature writes `[key: K]: V`
+func (e *declarationEmitter) indexSignature(index *types.IndexSignature, keyName *parser.Identifier) string {
+	if index.IsMapped {
+		return fmt.Sprintf("[%s in %s]: %s", index.TypeParameter, e.tsType(index.ConstraintType), e.tsType(index.ValueType))
+	}
+	name := "key"
+	if keyName != nil {
+		name = keyName.Value
+	}
+	return fmt.Sprintf("[%s: %s]: %s", name, e.tsType(index.KeyType), e.tsType(index.ValueType))
+}
+
+// mappedText writes `{ [P in K]: V }`
+func (e *declarationEmitter) mappedText(mapped *types.MappedType) string {
+	readonly := ""
+	switch mapped.ReadonlyModifier {
+	case "+":
+		readonly = "readonly "
+	case "-":
+		readonly = "-readonly "
+	}
+	optional := ""
+	switch mapped.OptionalModifier {
+	case "+":
+		optional = "?"
+	case "-":
+		optional = "-?"
+	}
+	return fmt.Sprintf("{ %s[%s in %s]%s: %s }", readonly, mapped.TypeParameter, e.tsType(mapped.ConstraintType), optional, e.tsType(mapped.ValueType))
+}
+
+// dtsParam is a parameter as its declaration names it
+type dtsParam struct {
+	name     string
+	optional bool
+}
+
+// declaredParams returns the names of the parameters of a declaration, the
+// rest parameter last
+func declaredParams(params []*parser.Parameter, rest *parser.RestParameter) []dtsParam {
+	var names []dtsParam
+	for i, param := range params {
+		if param == nil || param.IsThis {
+			continue
+		}
+		name := fmt.Sprintf("__%d", i) // Destructuring patterns are written as their type
+		if param.Name != nil && !param.IsDestructuring {
+			name = param.Name.Value
+		}
+		names = append(names, dtsParam{name: name, optional: param.Optional || param.DefaultValue != nil})
+	}
+	if rest != nil {
+		name := "args"
+		if rest.Name != nil {
+			name = rest.Name.Value
+		}
+		names = append(names, dtsParam{name: name})
+	}
+	return names
+}
+
+// signature writes `<T>(a: A, b?: B, ...rest: R[])` for sig, naming the
+// parameters after params where given
+func (e *declarationEmitter) signature(sig *types.Signature, params []dtsParam, typeParams []*types.TypeParameter) string {
+	paramName := func(i int) string {
+		if i < len(params) {
+			return params[i].name
+		}
+		if i < len(sig.ParameterNames) && sig.ParameterNames[i] != "" {
+			return sig.ParameterNames[i]
+		}
+		return fmt.Sprintf("arg%d", i)
+	}
+	parts := make([]string, 0, len(sig.ParameterTypes)+1)
+	count := len(sig.ParameterTypes)
+	for i, paramType := range sig.ParameterTypes {
+		if sig.IsVariadic && sig.RestParameterType == nil && i == count-1 {
+			parts = append(parts, "..."+paramName(i)+": "+e.tsType(paramType))
+			continue
+		}
+		name := paramName(i)
+		if (i < len(sig.OptionalParams) && sig.OptionalParams[i]) || (i < len(params) && params[i].optional) {
+			name += "?"
+			paramType = withoutUndefined(paramType)
+		}
+		parts = append(parts, name+": "+e.tsType(paramType))
+	}
+	if sig.RestParameterType != nil {
+		parts = append(parts, "..."+paramName(count)+": "+e.tsType(sig.RestParameterType))
+	}
+	return e.typeParameterList(typeParams) + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// returnType returns the text of the return type of sig
+func (e *declarationEmitter) returnType(sig *types.Signature) string {
+	if sig.ReturnType == nil {
+		return "void"
+	}
+	return e.tsType(sig.ReturnType)
+}
+
+// declaredReturnType returns the text of the return type of sig, the
+// signature of a declaration returning what annotation says, if anything.
+// An inferred return type is widened like TypeScript widens it.
+func (e *declarationEmitter) declaredReturnType(sig *types.Signature, annotation parser.Expression) string {
+	if annotation != nil || sig.ReturnType == nil {
+		return e.returnType(sig)
+	}
+	return e.tsType(e.widenInferred(sig.ReturnType))
+}
+
+// widenInferred widens the literal types in an inferred type, down through
+// unions, arrays and the members of object literal types, as TypeScript
+// widens the types of what a function returns. Named types are left alone.
+func (e *declarationEmitter) widenInferred(t types.Type) types.Type {
+	if _, named := e.names[t]; named {
+		return t
+	}
+	switch t := t.(type) {
+	case *types.LiteralType:
+		return types.GetWidenedType(t)
+	case *types.UnionType:
+		members := make([]types.Type, len(t.Types))
+		for i, member := range t.Types {
+			members[i] = e.widenInferred(member)
+		}
+		return types.NewUnionType(members...)
+	case *types.ArrayType:
+		return &types.ArrayType{ElementType: e.widenInferred(t.ElementType)}
+	case *types.ObjectType:
+		if t.ClassMeta != nil || len(t.CallSignatures) > 0 || len(t.ConstructSignatures) > 0 {
+			return t
+		}
+		widened := *t
+		widened.Properties = make(map[string]types.Type, len(t.Properties))
+		for name, propType := range t.Properties {
+			widened.Properties[name] = e.widenInferred(propType)
+		}
+		return &widened
+	}
+	return t
+}
+
+// typeParameterList writes `<T extends C = D, U>`, or "" without parameters
+func (e *declarationEmitter) typeParameterList(params []*types.TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+	parts := make([]string, len(params))
+	for i, param := range params {
+		text := param.Name
+		if param.Constraint != nil && param.Constraint != types.Any {
+			text += " extends " + e.tsType(param.Constraint)
+		}
+		if param.Default != nil {
+			text += " = " + e.tsType(param.Default)
+		}
+		parts[i] = text
+	}
+	return "<" + strings.Join(parts, ", ") + ">"
+}
+
+// --- Helpers ---
+
+// typeParameters returns the type parameters of a signature, taken from the
+// declaration when the signature doesn't carry them
+func typeParameters(sig *types.Signature, nodes []*parser.TypeParameter) []*types.TypeParameter {
+	if len(sig.TypeParameters) > 0 {
+		return sig.TypeParameters
+	}
+	return astTypeParameters(nodes)
+}
+
+// astTypeParameters returns the type parameters the checker resolved for
+// nodes
+func astTypeParameters(nodes []*parser.TypeParameter) []*types.TypeParameter {
+	params := make([]*types.TypeParameter, 0, len(nodes))
+	for _, node := range nodes {
+		if param, ok := node.GetComputedType().(*types.TypeParameterType); ok && param.Parameter != nil {
+			params = append(params, param.Parameter)
+		} else if node.Name != nil {
+			params = append(params, &types.TypeParameter{Name: node.Name.Value})
+		}
+	}
+	return params
+}
+
+// callSignatures returns the call signatures of a function type
+func callSignatures(t types.Type) []*types.Signature {
+	switch typ := t.(type) {
+	case *types.ObjectType:
+		return typ.CallSignatures
+	case *types.GenericType:
+		// A generic method: its type parameters belong on its signatures
+		var sigs []*types.Signature
+		for _, sig := range callSignatures(typ.Body) {
+			if len(sig.TypeParameters) == 0 {
+				copied := *sig
+				copied.TypeParameters = typ.TypeParameters
+				sig = &copied
+			}
+			sigs = append(sigs, sig)
+		}
+		return sigs
+	}
+	return nil
+}
+
+// constructSignatures returns the construct signatures of a constructor type
+func constructSignatures(t types.Type) []*types.Signature {
+	if obj, ok := t.(*types.ObjectType); ok {
+		return obj.ConstructSignatures
+	}
+	return nil
+}
+
+// withoutUndefined removes the undefined an optional member or parameter
+// adds to its type, which `?` already says
+func withoutUndefined(t types.Type) types.Type {
+	union, ok := t.(*types.UnionType)
+	if !ok {
+		return t
+	}
+	var kept []types.Type
+	for _, member := range union.Types {
+		if member != types.Undefined {
+			kept = append(kept, member)
+		}
+	}
+	switch len(kept) {
+	case len(union.Types):
+		return t
+	case 0:
+		return types.Undefined
+	case 1:
+		return kept[0]
+	}
+	return &types.UnionType{Types: kept}
+}
+
+// hasOverloads reports whether stmts declare overload signatures of the
+// function name
+func hasOverloads(stmts []parser.Statement, name string) bool {
+	for _, stmt := range stmts {
+		stmt = unwrapExport(stmt)
+		if expr, ok := stmt.(*parser.ExpressionStatement); ok {
+			if sig, ok := expr.Expression.(*parser.FunctionSignature); ok {
+				stmt = sig
+			}
+		}
+		if sig, ok := stmt.(*parser.FunctionSignature); ok && sig.Name != nil && sig.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapExport returns the declaration of `export <declaration>`
+func unwrapExport(stmt parser.Statement) parser.Statement {
+	if exp, ok := stmt.(*parser.ExportNamedDeclaration); ok && exp.Declaration != nil {
+		return exp.Declaration
+	}
+	return stmt
+}
+
+// importLocal returns the local name an import specifier binds
+func importLocal(spec parser.ImportSpecifier) string {
+	switch s := spec.(type) {
+	case *parser.ImportDefaultSpecifier:
+		return s.Local.Value
+	case *parser.ImportNamedSpecifier:
+		return s.Local.Value
+	case *parser.ImportNamespaceSpecifier:
+		return s.Local.Value
+	}
+	return ""
+}
+
+// exportSpecifiers writes the specifiers of `export { a, b as c }`
+func exportSpecifiers(specs []parser.ExportSpecifier) string {
+	parts := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		named, ok := spec.(*parser.ExportNamedSpecifier)
+		if !ok {
+			continue
+		}
+		local, exported := specifierName(named.Local), specifierName(named.Exported)
+		if exported != "" && exported != local {
+			parts = append(parts, local+" as "+exported)
+		} else {
+			parts = append(parts, local)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// specifierName returns the text of a module export name
+func specifierName(expr parser.Expression) string {
+	switch name := expr.(type) {
+	case *parser.Identifier:
+		return name.Value
+	case *parser.StringLiteral:
+		return strconv.Quote(name.Value)
+	}
+	return ""
+}
+
+func typeOnly(isTypeOnly bool) string {
+	if isTypeOnly {
+		return "type "
+	}
+	return ""
+}
+
+func staticPrefix(isStatic bool) string {
+	if isStatic {
+		return "static "
+	}
+	return ""
+}
+
+func accessName(isPrivate, isProtected bool) string {
+	switch {
+	case isPrivate:
+		return "private"
+	case isProtected:
+		return "protected"
+	}
+	return ""
+}
+
+func accessPrefix(isPrivate, isProtected bool) string {
+	if access := accessName(isPrivate, isProtected); access != "" {
+		return access + " "
+	}
+	return ""
+}
+
+// memberKey returns the text of a class member name, and false for computed
+// names other than well-known symbols
+func memberKey(key parser.Expression) (string, bool) {
+	switch k := key.(type) {
+	case *parser.Identifier:
+		return k.Value, true
+	case *parser.StringLiteral:
+		return propertyKey(k.Value), true
+	case *parser.NumberLiteral:
+		return fmt.Sprintf("%v", k.Value), true
+	case *parser.ComputedPropertyName:
+		if member, ok := k.Expr.(*parser.MemberExpression); ok {
+			if object, ok := member.Object.(*parser.Identifier); ok && object.Value == "Symbol" {
+				if property, ok := member.Property.(*parser.Identifier); ok {
+					return "[Symbol." + property.Value + "]", true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// propertyKey returns a property name as it is written in a type: as is if
+// it is an identifier or number, quoted otherwise
+func propertyKey(name string) string {
+	if symbol, ok := strings.CutPrefix(name, "@@symbol:"); ok {
+		return "[Symbol." + symbol + "]"
+	}
+	if isIdentifierName(name) {
+		return name
+	}
+	if n, err := strconv.ParseFloat(name, 64); err == nil && strconv.FormatFloat(n, 'f', -1, 64) == name {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func isIdentifierName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// literalText returns the text of a literal type
+func literalText(value vm.Value) string {
+	switch {
+	case value.IsString():
+		return strconv.Quote(value.ToString())
+	case value.IsBigInt():
+		return value.AsBigInt().String() + "n"
+	}
+	return value.ToString()
+}
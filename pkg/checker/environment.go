@@ -168,17 +168,22 @@ func NewStandardGlobalEnvironment() *Environment {
 // GetPrimitivePrototypeMethodType returns the type of a method on a primitive prototype
 // This replaces the old builtins.GetPrototypeMethodType function
 func (e *Environment) GetPrimitivePrototypeMethodType(primitiveName, methodName string) types.Type {
+	if prototypeType := e.getPrimitivePrototype(primitiveName); prototypeType != nil {
+		if methodType, found := prototypeType.Properties[methodName]; found {
+			return methodType
+		}
+	}
+	return nil
+}
+
+// getPrimitivePrototype returns the prototype type of a primitive, or nil
+func (e *Environment) getPrimitivePrototype(primitiveName string) *types.ObjectType {
 	// Walk up to find the global environment (which has primitivePrototypes)
 	current := e
 	for current != nil {
 		if current.primitivePrototypes != nil {
 			// Found global environment
-			if prototypeType, exists := current.primitivePrototypes[primitiveName]; exists {
-				if methodType, found := prototypeType.Properties[methodName]; found {
-					return methodType
-				}
-			}
-			break
+			return current.primitivePrototypes[primitiveName]
 		}
 		current = current.outer
 	}
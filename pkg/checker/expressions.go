@@ -165,7 +165,7 @@ func (c *Checker) checkArrayLiteralWithContext(node *parser.ArrayLiteral, contex
 			}
 
 			// Check if the element type is assignable to the expected tuple element type
-			if !types.IsAssignable(actualElemType, expectedElemType) {
+			if !c.isAssignable(actualElemType, expectedElemType) {
 				elementTypesMatch = false
 				debugPrintf("// [Checker ArrayLitContext] Element %d type mismatch: expected %s, got %s\n", i, expectedElemType.String(), actualElemType.String())
 			}
@@ -202,13 +202,13 @@ func (c *Checker) checkArrayLiteralWithContext(node *parser.ArrayLiteral, contex
 
 				// Validate that the spread array's element type is assignable to expected element type
 				if spreadArrayType, isArray := spreadType.(*types.ArrayType); isArray {
-					if !types.IsAssignable(spreadArrayType.ElementType, arrayType.ElementType) {
+					if !c.isAssignable(spreadArrayType.ElementType, arrayType.ElementType) {
 						c.addError(elemNode, fmt.Sprintf("Type '%s' is not assignable to type '%s'",
 							spreadArrayType.ElementType.String(), arrayType.ElementType.String()))
 					}
 				} else if c.isSpreadableIterableType(spreadType) {
 					spreadElementType := c.getSpreadElementType(spreadType)
-					if !types.IsAssignable(spreadElementType, arrayType.ElementType) {
+					if !c.isAssignable(spreadElementType, arrayType.ElementType) {
 						c.addError(elemNode, fmt.Sprintf("Type '%s' is not assignable to type '%s'",
 							spreadElementType.String(), arrayType.ElementType.String()))
 					}
@@ -230,7 +230,7 @@ func (c *Checker) checkArrayLiteralWithContext(node *parser.ArrayLiteral, contex
 					actualElemType = types.Any
 				}
 
-				if !types.IsAssignable(actualElemType, arrayType.ElementType) {
+				if !c.isAssignable(actualElemType, arrayType.ElementType) {
 					c.addError(elemNode, fmt.Sprintf("Type '%s' is not assignable to type '%s'",
 						actualElemType.String(), arrayType.ElementType.String()))
 				}
@@ -1140,6 +1140,7 @@ func (c *Checker) checkMemberExpression(node *parser.MemberExpression) {
 
 	// 3. Widen the object type for checks
 	widenedObjectType := types.GetWidenedType(objectType)
+	widenedObjectType = c.checkNotNullish(node.Object, widenedObjectType)
 
 	var resultType types.Type = types.Never // Default to Never if property not found/invalid access
 
@@ -1615,6 +1616,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 	// Widen literal types to their base types before checking indexability
 	// This allows "lol"[1] to be treated as string[number]
 	leftType = types.GetWidenedType(leftType)
+	leftType = c.checkNotNullish(node.Left, leftType)
 
 	// Expand MappedType (e.g., Record<string, string>) to ObjectType with index signatures
 	leftType = c.expandIfMappedType(leftType)
@@ -1636,14 +1638,14 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 		case *types.ArrayType:
 			// Base is ArrayType
 			// 4. Check index type (number for array elements, string/symbol for properties)
-			if types.IsAssignable(indexType, types.Number) {
+			if c.isAssignable(indexType, types.Number) {
 				// Numeric index - accessing array elements
 				if base.ElementType != nil {
 					resultType = base.ElementType
 				} else {
 					resultType = types.Unknown
 				}
-			} else if types.IsAssignable(indexType, types.String) || types.IsAssignable(indexType, types.Symbol) {
+			} else if c.isAssignable(indexType, types.String) || c.isAssignable(indexType, types.Symbol) {
 				// String or Symbol index - accessing array properties (like Symbol.iterator)
 				resultType = types.Any // Arrays can have arbitrary properties
 			} else {
@@ -1653,7 +1655,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 
 		case *types.TupleType:
 			// Base is TupleType - can index with numeric literal or general number
-			if types.IsAssignable(indexType, types.Number) {
+			if c.isAssignable(indexType, types.Number) {
 				// Check if the index is a numeric literal - if so, we can get the exact element type
 				if litIndex, ok := indexType.(*types.LiteralType); ok && litIndex.Value.IsNumber() {
 					indexValue := vm.AsNumber(litIndex.Value)
@@ -1680,7 +1682,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 					resultType = getTupleElementUnion(base)
 					debugPrintf("// [Checker IndexExpr] Tuple general number index -> %s\n", resultType.String())
 				}
-			} else if types.IsAssignable(indexType, types.String) || types.IsAssignable(indexType, types.Symbol) {
+			} else if c.isAssignable(indexType, types.String) || c.isAssignable(indexType, types.Symbol) {
 				// String or Symbol index - accessing tuple properties (like length, Symbol.iterator)
 				resultType = types.Any
 			} else {
@@ -1760,7 +1762,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 					}
 				case *types.ArrayType:
 					// Check if index is number for array member
-					if types.IsAssignable(indexType, types.Number) {
+					if c.isAssignable(indexType, types.Number) {
 						if member.ElementType != nil {
 							possibleTypes = append(possibleTypes, member.ElementType)
 						} else {
@@ -1772,10 +1774,10 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 					}
 				case *types.Primitive:
 					if member == types.String {
-						if types.IsAssignable(indexType, types.Number) {
+						if c.isAssignable(indexType, types.Number) {
 							// Numeric index - string character access
 							possibleTypes = append(possibleTypes, types.String)
-						} else if types.IsAssignable(indexType, types.String) || types.IsAssignable(indexType, types.Symbol) {
+						} else if c.isAssignable(indexType, types.String) || c.isAssignable(indexType, types.Symbol) {
 							// String/Symbol index - string property access
 							possibleTypes = append(possibleTypes, types.Any)
 						} else {
@@ -1804,7 +1806,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 			// Allow indexing on strings?
 			if base == types.String {
 				// 4. Check index type (number for string characters, string/symbol for properties)
-				if types.IsAssignable(indexType, types.Number) {
+				if c.isAssignable(indexType, types.Number) {
 					// Numeric index - accessing string characters
 					resultType = types.String
 				} else if isIndexStringLiteral {
@@ -1812,7 +1814,7 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 					if resultType == types.Never {
 						c.addError(node.Index, fmt.Sprintf("property '%s' does not exist on type 'string'", indexStringValue))
 					}
-				} else if types.IsAssignable(indexType, types.String) || types.IsAssignable(indexType, types.Symbol) {
+				} else if c.isAssignable(indexType, types.String) || c.isAssignable(indexType, types.Symbol) {
 					// String or Symbol index - accessing string properties (like Symbol.iterator)
 					resultType = types.Any // String properties can have arbitrary types
 				} else {
@@ -1850,11 +1852,11 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 		case *types.EnumType:
 			// Handle index access on enum types (reverse mapping for numeric enums)
 			// Check if this is a numeric index access
-			if types.IsAssignable(indexType, types.Number) {
+			if c.isAssignable(indexType, types.Number) {
 				// Allow reverse mapping for numeric indices (works for both numeric and heterogeneous enums)
 				resultType = types.String // Reverse mapping returns string member name
 				debugPrintf("// [Checker IndexExpr] Enum reverse mapping: %s[number] -> string\n", base.Name)
-			} else if types.IsAssignable(indexType, types.String) {
+			} else if c.isAssignable(indexType, types.String) {
 				// String index access - this is allowed but typically returns undefined for reverse mapping
 				// For type checking purposes, we'll allow it as it could return string | undefined
 				resultType = types.Any // In practice, this would be string | undefined
@@ -1871,9 +1873,9 @@ func (c *Checker) checkIndexExpression(node *parser.IndexExpression) {
 					// Check if this is a Generator type by looking for the next() method
 					if _, hasNext := baseObjectType.Properties["next"]; hasNext {
 						// This is likely a Generator or Iterator - allow Symbol indexing for Symbol.iterator
-						if types.IsAssignable(indexType, types.Symbol) {
+						if c.isAssignable(indexType, types.Symbol) {
 							resultType = types.Any // Symbol.iterator returns the iterator itself
-						} else if types.IsAssignable(indexType, types.String) {
+						} else if c.isAssignable(indexType, types.String) {
 							resultType = types.Any // String properties allowed
 						} else {
 							c.addError(node.Index, fmt.Sprintf("generator/iterator index must be of type string or symbol, got %s", indexType.String()))
@@ -2373,12 +2375,12 @@ func (c *Checker) checkNewExpression(node *parser.NewExpression) {
 
 										if c.isSpreadableIterableType(argType) {
 											spreadElementType := c.getSpreadElementType(argType)
-											if !types.IsAssignable(spreadElementType, variadicElementType) {
+											if !c.isAssignable(spreadElementType, variadicElementType) {
 												c.addError(spreadElement, fmt.Sprintf("spread element: cannot assign type '%s' to parameter element type '%s'", spreadElementType.String(), variadicElementType.String()))
 											}
 											continue
 										}
-										if !types.IsAssignable(argType, constructorSig.RestParameterType) {
+										if !c.isAssignable(argType, constructorSig.RestParameterType) {
 											c.addError(spreadElement, fmt.Sprintf("spread argument: cannot assign type '%s' to rest parameter type '%s'", argType.String(), constructorSig.RestParameterType.String()))
 										}
 									} else {
@@ -2392,7 +2394,7 @@ func (c *Checker) checkNewExpression(node *parser.NewExpression) {
 											continue
 										}
 
-										if !types.IsAssignable(argType, variadicElementType) {
+										if !c.isAssignable(argType, variadicElementType) {
 											c.addError(argNode, fmt.Sprintf("variadic argument %d: cannot assign type '%s' to parameter element type '%s'", i+1, argType.String(), variadicElementType.String()))
 										}
 									}
@@ -2609,7 +2611,7 @@ func (c *Checker) checkSatisfiesExpression(node *parser.SatisfiesExpression) {
 		c.checkObjectLiteralSatisfies(objectLit, targetType, node)
 	} else {
 		// For non-object literals, use regular assignability check
-		if !types.IsAssignable(sourceType, targetType) {
+		if !c.isAssignable(sourceType, targetType) {
 			c.addError(node, fmt.Sprintf("type '%s' does not satisfy the constraint '%s'",
 				sourceType.String(), targetType.String()))
 		}
@@ -2643,7 +2645,7 @@ func (c *Checker) checkObjectLiteralSatisfies(objectLit *parser.ObjectLiteral, t
 	}
 
 	// First check if the source type is assignable to the target type
-	if !types.IsAssignable(sourceType, targetType) {
+	if !c.isAssignable(sourceType, targetType) {
 		c.addError(satisfiesNode, fmt.Sprintf("type '%s' does not satisfy the constraint '%s'",
 			sourceType.String(), targetType.String()))
 		return
@@ -2675,7 +2677,7 @@ func (c *Checker) isValidTypeAssertion(sourceType, targetType types.Type) bool {
 	}
 
 	// Check if either type is assignable to the other
-	if types.IsAssignable(targetType, sourceType) || types.IsAssignable(sourceType, targetType) {
+	if c.isAssignable(targetType, sourceType) || c.isAssignable(sourceType, targetType) {
 		return true
 	}
 
@@ -26,6 +26,9 @@ func (c *Checker) checkFunctionLiteral(node *parser.FunctionLiteral) {
 		AllowSelfReference:      node.Name != nil, // Allow recursion for named functions
 		AllowOverloadCompletion: node.Name != nil, // Check for overloads for named functions
 	}
+	if node.Body == nil {
+		ctx.Body = nil // Not a nil *BlockStatement: there is no body to check
+	}
 
 	// 1. Resolve parameters and signature
 	preliminarySignature, paramTypes, paramNames, restParameterType, restParameterName, typeParamEnv := c.resolveFunctionParameters(ctx)
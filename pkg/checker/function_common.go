@@ -141,7 +141,7 @@ func (c *Checker) resolveFunctionParameters(ctx *FunctionCheckContext) (*types.S
 						// some valid instantiation that would satisfy the constraint.
 						// For now, we're permissive and allow all type parameter defaults.
 						// TODO: Implement proper constraint satisfaction checking
-					} else if !types.IsAssignable(defaultType, constraintType) {
+					} else if !c.isAssignable(defaultType, constraintType) {
 						c.addError(typeParamNode.DefaultType, fmt.Sprintf("default type '%s' does not satisfy constraint '%s'", defaultType.String(), constraintType.String()))
 					}
 				}
@@ -279,7 +279,7 @@ func (c *Checker) resolveFunctionParametersWithContext(ctx *FunctionCheckContext
 				c.env = originalEnv
 
 				// Validate that default type satisfies constraint if both are present
-				if defaultType != nil && constraintType != types.Any && !types.IsAssignable(defaultType, constraintType) {
+				if defaultType != nil && constraintType != types.Any && !c.isAssignable(defaultType, constraintType) {
 					c.addError(typeParamNode.DefaultType, fmt.Sprintf("default type '%s' does not satisfy constraint '%s'", defaultType.String(), constraintType.String()))
 				}
 			}
@@ -385,7 +385,12 @@ func (c *Checker) setupFunctionEnvironment(ctx *FunctionCheckContext, paramTypes
 	// Define regular parameters (skip 'this' parameters which have nil nameNode)
 	for i, nameNode := range paramNames {
 		if i < len(paramTypes) && nameNode != nil {
-			if !funcEnv.Define(nameNode.Value, paramTypes[i], false) {
+			paramType := paramTypes[i]
+			if param := ctx.Parameters[i]; param.Optional && param.DefaultValue == nil {
+				// An omitted argument leaves the parameter undefined
+				paramType = c.withUndefined(paramType)
+			}
+			if !funcEnv.Define(nameNode.Value, paramType, false) {
 				c.addError(nameNode, fmt.Sprintf("duplicate parameter name: %s", nameNode.Value))
 			}
 		}
@@ -413,6 +418,15 @@ func (c *Checker) setupFunctionEnvironment(ctx *FunctionCheckContext, paramTypes
 
 // checkFunctionBody visits the function body and handles return type inference
 func (c *Checker) checkFunctionBody(ctx *FunctionCheckContext, expectedReturnType types.Type) types.Type {
+	// A function without a body, like the accessor of an ambient class, is
+	// a signature: it returns what it's declared to
+	if ctx.Body == nil {
+		if expectedReturnType == nil {
+			return types.Any
+		}
+		return expectedReturnType
+	}
+
 	// Set return context
 	outerExpectedReturnType := c.currentExpectedReturnType
 	outerInferredReturnTypes := c.currentInferredReturnTypes
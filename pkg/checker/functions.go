@@ -54,7 +54,9 @@ func (c *Checker) processFunctionSignature(node *parser.FunctionSignature) {
 	// For now, continue using FunctionType for overloads until we update the entire overload system
 	node.SetComputedType(funcType)
 	if node.Declare {
-		c.env.Define(functionName, funcType, false)
+		if !c.env.Define(functionName, funcType, false) {
+			c.mergeAmbientOverload(functionName, sig)
+		}
 		debugPrintf("// [Checker] Added ambient function signature for '%s': %s\n", functionName, funcType.String())
 		return
 	}
@@ -65,6 +67,23 @@ func (c *Checker) processFunctionSignature(node *parser.FunctionSignature) {
 	debugPrintf("// [Checker] Added overload signature for '%s': %s\n", functionName, funcType.String())
 }
 
+// mergeAmbientOverload adds sig to the call signatures of an ambient function
+// declared earlier in the current scope: repeated `declare function` signatures
+// are the overloads of one function.
+func (c *Checker) mergeAmbientOverload(functionName string, sig *types.Signature) {
+	existing, ok := c.env.symbols[functionName].Type.(*types.ObjectType)
+	if !ok || !existing.IsCallable() {
+		return
+	}
+	for _, other := range existing.CallSignatures {
+		if other.String() == sig.String() {
+			return // Already merged, e.g. when the signature is visited twice
+		}
+	}
+	sigs := append(append([]*types.Signature{}, existing.CallSignatures...), sig)
+	c.env.Update(functionName, types.NewOverloadedFunctionType(sigs))
+}
+
 func (c *Checker) hasPendingOverloads(functionName string) bool {
 	for env := c.env; env != nil; env = env.outer {
 		if len(env.GetPendingOverloads(functionName)) > 0 {
@@ -189,7 +208,7 @@ func (c *Checker) isSignatureCompatible(implementation, overload *types.Signatur
 		}
 		implParam := implementation.ParameterTypes[i]
 		debugPrintf("// [Checker isSignatureCompatible] Checking param %d: overload %s assignable to impl %s\n", i, overloadParam.String(), implParam.String())
-		if !types.IsAssignable(overloadParam, implParam) {
+		if !c.isAssignable(overloadParam, implParam) {
 			debugPrintf("// [Checker isSignatureCompatible] Parameter %d incompatible: %s not assignable to %s\n", i, overloadParam.String(), implParam.String())
 			return false
 		}
@@ -209,7 +228,7 @@ func (c *Checker) isSignatureCompatible(implementation, overload *types.Signatur
 	if implUnion, isUnion := implementation.ReturnType.(*types.UnionType); isUnion {
 		// Check if the overload return type is assignable to any of the union types
 		for _, unionMember := range implUnion.Types {
-			if types.IsAssignable(overload.ReturnType, unionMember) {
+			if c.isAssignable(overload.ReturnType, unionMember) {
 				debugPrintf("// [Checker isSignatureCompatible] Return type compatible via union member %s\n", unionMember.String())
 				return true
 			}
@@ -218,7 +237,7 @@ func (c *Checker) isSignatureCompatible(implementation, overload *types.Signatur
 		return false
 	} else {
 		// Non-union implementation return type - use standard assignability
-		result := types.IsAssignable(implementation.ReturnType, overload.ReturnType)
+		result := c.isAssignable(implementation.ReturnType, overload.ReturnType)
 		debugPrintf("// [Checker isSignatureCompatible] Return type compatible: %t\n", result)
 		return result
 	}
@@ -286,7 +305,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 				// Check fixed parameters first
 				fixedMatch := true
 				for j := 0; j < minRequiredArgs; j++ {
-					if !types.IsAssignable(argTypes[j], overload.ParameterTypes[j]) {
+					if !c.isAssignable(argTypes[j], overload.ParameterTypes[j]) {
 						fixedMatch = false
 						break
 					}
@@ -304,7 +323,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 						// Check all remaining arguments against element type
 						variadicMatch := true
 						for j := minRequiredArgs; j < len(argTypes); j++ {
-							if !types.IsAssignable(argTypes[j], elementType) {
+							if !c.isAssignable(argTypes[j], elementType) {
 								variadicMatch = false
 								break
 							}
@@ -325,7 +344,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 			allMatch := true
 			for j, argType := range argTypes {
 				paramType := overload.ParameterTypes[j]
-				if !types.IsAssignable(argType, paramType) {
+				if !c.isAssignable(argType, paramType) {
 					allMatch = false
 					break
 				}
@@ -385,7 +404,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 			if i < len(argTypes) {
 				argType := argTypes[i]
 				paramType := matchedOverload.ParameterTypes[i]
-				if !types.IsAssignable(argType, paramType) {
+				if !c.isAssignable(argType, paramType) {
 					argNode := node.Arguments[i]
 					c.addError(argNode, fmt.Sprintf("argument %d: cannot assign type '%s' to parameter of type '%s'",
 						i+1, argType.String(), paramType.String()))
@@ -402,7 +421,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 
 			for i := fixedParamCount; i < len(argTypes); i++ {
 				argType := argTypes[i]
-				if !types.IsAssignable(argType, elementType) {
+				if !c.isAssignable(argType, elementType) {
 					argNode := node.Arguments[i]
 					c.addError(argNode, fmt.Sprintf("variadic argument %d: cannot assign type '%s' to rest parameter element type '%s'",
 						i+1, argType.String(), elementType.String()))
@@ -419,7 +438,7 @@ func (c *Checker) checkOverloadedCall(node *parser.CallExpression, overloadedFun
 
 		for i, argType := range argTypes {
 			paramType := matchedOverload.ParameterTypes[i]
-			if !types.IsAssignable(argType, paramType) {
+			if !c.isAssignable(argType, paramType) {
 				// This shouldn't happen if overload matching worked correctly
 				argNode := node.Arguments[i]
 				c.addError(argNode, fmt.Sprintf("argument %d: cannot assign type '%s' to parameter of type '%s'",
@@ -0,0 +1,82 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/parser"
+)
+
+// checkImplicitAny emits TS7006/TS7019 for parameters that end up typed any
+// for lack of an annotation, as enabled by noImplicitAny.
+//
+// Only functions that can't get a contextual type are looked at: function
+// declarations, class constructors and methods other than setters, and
+// functions initializing variables without a type annotation. Callbacks and
+// other function expressions are left alone, as the checker may type their
+// parameters from context.
+func (c *Checker) checkImplicitAny(program *parser.Program) {
+	if !c.noImplicitAny || program == nil {
+		return
+	}
+	visited := make(map[parser.Node]bool)
+	var walk func(node parser.Node)
+	walk = func(node parser.Node) {
+		if isNilNode(node) || visited[node] {
+			return
+		}
+		visited[node] = true
+		switch node := node.(type) {
+		case *parser.ExpressionStatement:
+			if fn, ok := node.Expression.(*parser.FunctionLiteral); ok && fn.Name != nil {
+				c.reportImplicitAnyParameters(fn.Parameters, fn.RestParameter)
+			}
+		case *parser.MethodDefinition:
+			if node.Value != nil && node.Kind != "setter" {
+				c.reportImplicitAnyParameters(node.Value.Parameters, node.Value.RestParameter)
+			}
+		case *parser.LetStatement:
+			c.reportImplicitAnyDeclarators(node.Declarations)
+		case *parser.ConstStatement:
+			c.reportImplicitAnyDeclarators(node.Declarations)
+		case *parser.VarStatement:
+			c.reportImplicitAnyDeclarators(node.Declarations)
+		}
+		forEachChild(node, walk)
+	}
+	for _, stmt := range program.Statements {
+		walk(stmt)
+	}
+}
+
+// reportImplicitAnyDeclarators reports the parameters of functions that
+// initialize unannotated variables
+func (c *Checker) reportImplicitAnyDeclarators(decls []*parser.VarDeclarator) {
+	for _, decl := range decls {
+		if decl == nil || decl.TypeAnnotation != nil {
+			continue
+		}
+		switch fn := decl.Value.(type) {
+		case *parser.FunctionLiteral:
+			c.reportImplicitAnyParameters(fn.Parameters, fn.RestParameter)
+		case *parser.ArrowFunctionLiteral:
+			c.reportImplicitAnyParameters(fn.Parameters, fn.RestParameter)
+		}
+	}
+}
+
+// reportImplicitAnyParameters reports the parameters that have neither a
+// type annotation nor a default value to infer one from
+func (c *Checker) reportImplicitAnyParameters(params []*parser.Parameter, rest *parser.RestParameter) {
+	for _, param := range params {
+		if param == nil || param.Name == nil || param.IsThis || param.IsDestructuring ||
+			param.TypeAnnotation != nil || param.DefaultValue != nil ||
+			strings.HasPrefix(param.Name.Value, "__destructured_param_") {
+			continue
+		}
+		c.addError(param.Name, fmt.Sprintf("Parameter '%s' implicitly has an 'any' type.", param.Name.Value))
+	}
+	if rest != nil && rest.Name != nil && rest.TypeAnnotation == nil {
+		c.addError(rest.Name, fmt.Sprintf("Rest parameter '%s' implicitly has an 'any[]' type.", rest.Name.Value))
+	}
+}
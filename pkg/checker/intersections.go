@@ -10,7 +10,7 @@ import (
 func (c *Checker) isAssignableToIntersection(source types.Type, intersection *types.IntersectionType) bool {
 	// Source must be assignable to ALL members of the intersection
 	for _, member := range intersection.Types {
-		if !types.IsAssignable(source, member) {
+		if !c.isAssignable(source, member) {
 			return false
 		}
 	}
@@ -23,7 +23,7 @@ func (c *Checker) isAssignableToIntersection(source types.Type, intersection *ty
 func (c *Checker) isIntersectionAssignableTo(intersection *types.IntersectionType, target types.Type) bool {
 	// At least one member of the intersection must be assignable to target
 	for _, member := range intersection.Types {
-		if types.IsAssignable(member, target) {
+		if c.isAssignable(member, target) {
 			return true
 		}
 	}
@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// Editors look up what is under the cursor: NodePath finds the identifier
+// at an offset with the nodes enclosing it, and Members lists what member
+// access on a type can reach.
+
+// NodePath returns the identifier of program at offset, a byte offset into
+// its source, preceded by the nodes enclosing it from program down. An
+// identifier ending at offset counts, as the cursor sits right after it.
+// Returns nil if there is no identifier there.
+func NodePath(program *parser.Program, offset int) []parser.Node {
+	var path, found []parser.Node
+	visited := make(map[parser.Node]bool)
+	var visit func(parser.Node)
+	visit = func(node parser.Node) {
+		if found != nil || isNilNode(node) || visited[node] {
+			return
+		}
+		visited[node] = true
+		path = append(path, node)
+		if ident, ok := node.(*parser.Identifier); ok && ident.Token.StartPos < ident.Token.EndPos && ident.Token.StartPos <= offset && offset <= ident.Token.EndPos {
+			found = append([]parser.Node(nil), path...)
+		}
+		forEachChild(node, visit)
+		path = path[:len(path)-1]
+	}
+	visit(program)
+	return found
+}
+
+// Members returns the properties and methods accessible on a value of type
+// typ by name, with their types, as member access resolves them (see
+// checkMemberExpression). Private members are left out. A union has the
+// members all of its types have.
+func (c *Checker) Members(typ types.Type) map[string]types.Type {
+	members := make(map[string]types.Type)
+	switch t := types.RemoveNullUndefined(types.GetWidenedType(typ)).(type) {
+	case *types.UnionType:
+		for i, member := range t.Types {
+			memberMembers := c.Members(member)
+			if i == 0 {
+				members = memberMembers
+				continue
+			}
+			for name, memberType := range members {
+				if other, ok := memberMembers[name]; ok {
+					members[name] = types.NewUnionType(memberType, other)
+				} else {
+					delete(members, name)
+				}
+			}
+		}
+	case *types.IntersectionType:
+		for _, member := range t.Types {
+			for name, memberType := range c.Members(member) {
+				members[name] = memberType
+			}
+		}
+	case *types.ArrayType:
+		c.addPrototypeMembers(members, "array", t.ElementType)
+		members["length"] = types.Number
+	case *types.TupleType:
+		c.addPrototypeMembers(members, "array", getTupleElementUnion(t))
+		members["length"] = types.Number
+	case *types.ObjectType:
+		if t.IsCallable() {
+			c.addPrototypeMembers(members, "function", nil)
+		}
+		for name, memberType := range t.GetEffectiveProperties() {
+			if !strings.HasPrefix(name, "#") && memberType != nil {
+				members[name] = memberType
+			}
+		}
+	default:
+		switch t {
+		case types.String:
+			c.addPrototypeMembers(members, "string", nil)
+			members["length"] = types.Number
+		case types.Number:
+			c.addPrototypeMembers(members, "number", nil)
+		case types.RegExp:
+			c.addPrototypeMembers(members, "RegExp", nil)
+		case types.Symbol:
+			c.addPrototypeMembers(members, "symbol", nil)
+		}
+	}
+	return members
+}
+
+// addPrototypeMembers adds the methods of a primitive's prototype to
+// members, instantiating generic array methods with elementType
+func (c *Checker) addPrototypeMembers(members map[string]types.Type, primitiveName string, elementType types.Type) {
+	prototypeType := c.env.getPrimitivePrototype(primitiveName)
+	if prototypeType == nil {
+		return
+	}
+	for name, memberType := range prototypeType.Properties {
+		if elementType != nil {
+			memberType = c.instantiateGenericMethod(memberType, elementType)
+		}
+		members[name] = memberType
+	}
+}
@@ -44,6 +44,12 @@ func (c *Checker) checkNamespaceDeclaration(node *parser.NamespaceDeclaration) {
 		c.env.Define(name, nsType.ValueShape, false)
 	}
 
+	c.checkNamespaceBody(node.Body, nsType, node.Declare)
+}
+
+// checkNamespaceBody checks the statements of a namespace body in an
+// environment of their own, adding the exported bindings to nsType
+func (c *Checker) checkNamespaceBody(body *parser.BlockStatement, nsType *types.NamespaceType, declare bool) {
 	// 2. Create an enclosed environment for the body.
 	outerEnv := c.env
 	bodyEnv := NewEnclosedEnvironment(outerEnv)
@@ -62,16 +68,16 @@ func (c *Checker) checkNamespaceDeclaration(node *parser.NamespaceDeclaration) {
 	// 3. Walk each body statement and dispatch. We deliberately use a single-
 	//    pass walk inside namespaces (good enough for our smoke tests; can be
 	//    upgraded to multi-pass if needed later).
-	if node.Body != nil {
+	if body != nil {
 		// Mirror the top-level checker passes:
 		//   Pass A: interfaces, type aliases, classes, nested namespaces (types).
 		//   Pass B: hoist function signatures (so interfaces are available for
 		//           parameter type resolution).
 		//   Pass C: visit remaining body statements.
-		c.preprocessNamespaceTypes(node.Body, nsType)
-		c.hoistNamespaceFunctions(node.Body, bodyEnv)
+		c.preprocessNamespaceTypes(body, nsType)
+		c.hoistNamespaceFunctions(body, bodyEnv)
 
-		for _, stmt := range node.Body.Statements {
+		for _, stmt := range body.Statements {
 			if stmt == nil {
 				continue
 			}
@@ -83,7 +89,7 @@ func (c *Checker) checkNamespaceDeclaration(node *parser.NamespaceDeclaration) {
 	// matching how checkBlockStatement validates block scopes. Skipped in
 	// `declare namespace` bodies, where bodiless function declarations are
 	// the norm in ambient contexts.
-	if !node.Declare {
+	if !declare {
 		for _, sigs := range bodyEnv.GetAllPendingOverloads() {
 			for _, sig := range sigs {
 				if sig.Name != nil {
@@ -215,6 +221,24 @@ func (c *Checker) checkNamespaceBodyStatement(stmt parser.Statement, nsType *typ
 		_ = n
 		return
 
+	case *parser.FunctionSignature:
+		c.processFunctionSignature(n)
+		if exported && n.Name != nil {
+			if t, _, found := c.env.Resolve(n.Name.Value); found {
+				nsType.ValueShape.Properties[n.Name.Value] = t
+			}
+		}
+
+	case *parser.ExportDefaultDeclaration:
+		// Only ambient module bodies have a default export
+		c.visit(n.Declaration)
+		if t := n.Declaration.GetComputedType(); t != nil {
+			nsType.ValueShape.Properties["default"] = t
+		}
+
+	case *parser.ExportAssignment:
+		c.checkExportAssignment(n, nsType.ValueShape.Properties, nsType.TypeMembers)
+
 	case *parser.LetStatement:
 		c.visit(n)
 		if exported {
@@ -243,6 +267,16 @@ func (c *Checker) checkNamespaceBodyStatement(stmt parser.Statement, nsType *typ
 			}
 			return
 		}
+		if sig, ok := n.Expression.(*parser.FunctionSignature); ok && sig.Name != nil {
+			// `function f(): T;` in an ambient body
+			c.checkNamespaceBodyStatement(sig, nsType)
+			if exported {
+				if t, _, found := c.env.Resolve(sig.Name.Value); found {
+					nsType.ValueShape.Properties[sig.Name.Value] = t
+				}
+			}
+			return
+		}
 		if enum, ok := n.Expression.(*parser.EnumDeclaration); ok && enum.Name != nil {
 			c.checkEnumDeclaration(enum)
 			if exported {
@@ -806,7 +806,7 @@ func (c *Checker) applyPositiveTypeNarrowing(guard *TypeGuard) *Environment {
 				// Regular type narrowing - union contains the exact target type
 				canNarrow = true
 				narrowedType = guard.NarrowedType
-			} else if types.IsAssignable(guard.NarrowedType, originalType) {
+			} else if c.isAssignable(guard.NarrowedType, originalType) {
 				// Type predicate narrowing - the predicate type is assignable to the original
 				// This handles cases like narrowing DriverNode|ChildMap to ValueNode<unknown>
 				canNarrow = true
@@ -843,7 +843,7 @@ func (c *Checker) applyPositiveTypeNarrowing(guard *TypeGuard) *Environment {
 				}
 			}
 		}
-	} else if guard.NarrowedType != nil && types.IsAssignable(guard.NarrowedType, originalType) {
+	} else if guard.NarrowedType != nil && c.isAssignable(guard.NarrowedType, originalType) {
 		// Allow narrowing if the narrowed type is assignable to the original type
 		// This handles cases like narrowing 'string' to '"foo"' (literal type)
 		canNarrow = true
@@ -1360,12 +1360,12 @@ func (c *Checker) typesHaveOverlap(type1, type2 types.Type) bool {
 
 	if isLiteral1 {
 		// Check if literal type1 is assignable to type2
-		return types.IsAssignable(type1, type2)
+		return c.isAssignable(type1, type2)
 	}
 
 	if isLiteral2 {
 		// Check if literal type2 is assignable to type1
-		return types.IsAssignable(type2, type1)
+		return c.isAssignable(type2, type1)
 	}
 
 	// Handle basic types - for strict equality, different primitive types don't overlap
@@ -1859,7 +1859,7 @@ func (c *Checker) isTypeCompatibleForInstanceof(memberType, targetType types.Typ
 	}
 
 	// Check if memberType is assignable to targetType or vice versa
-	if types.IsAssignable(memberType, targetType) || types.IsAssignable(targetType, memberType) {
+	if c.isAssignable(memberType, targetType) || c.isAssignable(targetType, memberType) {
 		return true
 	}
 
@@ -1912,7 +1912,7 @@ func (c *Checker) memberMatchesDiscriminant(memberType types.Type, propName stri
 	}
 
 	// For general type comparison
-	return types.IsAssignable(expectedValue, propType)
+	return c.isAssignable(expectedValue, propType)
 }
 
 func (c *Checker) resolveObjectTypeForNarrowing(t types.Type) (*types.ObjectType, bool) {
@@ -2190,10 +2190,10 @@ func (c *Checker) computeMergedType(t1, t2 types.Type) types.Type {
 		return t1
 	}
 	// If one is assignable to the other, use the wider type
-	if types.IsAssignable(t1, t2) {
+	if c.isAssignable(t1, t2) {
 		return t2
 	}
-	if types.IsAssignable(t2, t1) {
+	if c.isAssignable(t2, t1) {
 		return t1
 	}
 	// Create a union
@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// Checks specific to strictNullChecks. Without it null and undefined are
+// assignable to every type, so only unions that spell them out can hold them;
+// with it they must be narrowed away (see narrowing.go), asserted away with
+// `!`, or accessed through optional chaining before a value is used.
+
+// nullishMembers reports whether the union t includes null and undefined
+func nullishMembers(t types.Type) (hasNull, hasUndefined bool) {
+	union, ok := t.(*types.UnionType)
+	if !ok {
+		return false, false
+	}
+	for _, member := range union.Types {
+		switch member {
+		case types.Null:
+			hasNull = true
+		case types.Undefined:
+			hasUndefined = true
+		}
+	}
+	return hasNull, hasUndefined
+}
+
+// nullishDescription renders what a value may be, as in "'null' or 'undefined'"
+func nullishDescription(hasNull, hasUndefined bool) string {
+	switch {
+	case hasNull && hasUndefined:
+		return "'null' or 'undefined'"
+	case hasNull:
+		return "'null'"
+	default:
+		return "'undefined'"
+	}
+}
+
+// checkNotNullish reports, under strictNullChecks, the use of expr as an
+// object when its type t may be null or undefined (TS18047-18049, TS2531-2533)
+// and returns t without them, so that checking carries on as if expr had
+// been narrowed
+func (c *Checker) checkNotNullish(expr parser.Expression, t types.Type) types.Type {
+	if !c.strictNullChecks {
+		return t
+	}
+	hasNull, hasUndefined := nullishMembers(t)
+	if !hasNull && !hasUndefined {
+		return t
+	}
+	description := nullishDescription(hasNull, hasUndefined)
+	if name := expressionToNarrowingKey(expr); name != "" {
+		c.addError(expr, fmt.Sprintf("'%s' is possibly %s.", name, description))
+	} else {
+		c.addError(expr, fmt.Sprintf("Object is possibly %s.", description))
+	}
+	return types.RemoveNullUndefined(t)
+}
+
+// checkCalleeNotNullish is checkNotNullish for called values (TS2721-2723)
+func (c *Checker) checkCalleeNotNullish(callee parser.Expression, t types.Type) types.Type {
+	if !c.strictNullChecks {
+		return t
+	}
+	hasNull, hasUndefined := nullishMembers(t)
+	if !hasNull && !hasUndefined {
+		return t
+	}
+	c.addError(callee, fmt.Sprintf("Cannot invoke an object which is possibly %s.", nullishDescription(hasNull, hasUndefined)))
+	return types.RemoveNullUndefined(t)
+}
+
+// withUndefined returns t as seen when reading an optional parameter or
+// property: including undefined under strictNullChecks, unchanged otherwise
+func (c *Checker) withUndefined(t types.Type) types.Type {
+	if !c.strictNullChecks || t == nil || t == types.Any || t == types.Unknown {
+		return t
+	}
+	return types.NewUnionType(t, types.Undefined)
+}
@@ -396,7 +396,7 @@ func (c *Checker) resolveTypeAnnotation(node parser.Expression) types.Type {
 					// We'll use a special GenericForwardReference type that includes type arguments
 					return &types.GenericTypeAliasForwardReference{
 						AliasName:     node.Name.Value,
-						TypeArguments: make([]types.Type, len(node.TypeArguments)), // Placeholder args
+						TypeArguments: c.forwardReferenceTypeArguments(node.TypeArguments),
 					}
 				}
 
@@ -412,7 +412,7 @@ func (c *Checker) resolveTypeAnnotation(node parser.Expression) types.Type {
 				debugPrintf("// [Checker resolveTypeAnno GenericTypeRef] Creating forward reference for unknown type '%s'\n", node.Name.Value)
 				return &types.GenericTypeAliasForwardReference{
 					AliasName:     node.Name.Value,
-					TypeArguments: make([]types.Type, len(node.TypeArguments)),
+					TypeArguments: c.forwardReferenceTypeArguments(node.TypeArguments),
 				}
 			}
 
@@ -587,7 +587,7 @@ func (c *Checker) resolveFunctionTypeSignature(node *parser.FunctionTypeExpressi
 
 	// Create signature
 	sig := &types.Signature{
-		ParameterNames:    functionTypeParameterNameStrings(node.Parameters),
+		ParameterNames:    functionTypeParameterNameStrings(node),
 		ParameterTypes:    paramTypes,
 		ReturnType:        returnType,
 		OptionalParams:    node.OptionalParams,
@@ -672,7 +672,7 @@ func (c *Checker) resolveGenericFunctionType(node *parser.FunctionTypeExpression
 	// Create the function signature
 	sig := &types.Signature{
 		TypeParameters:    typeParams,
-		ParameterNames:    functionTypeParameterNameStrings(node.Parameters),
+		ParameterNames:    functionTypeParameterNameStrings(node),
 		ParameterTypes:    paramTypes,
 		ReturnType:        returnType,
 		OptionalParams:    node.OptionalParams,
@@ -947,6 +947,7 @@ func (c *Checker) resolveConstructorTypeSignature(node *parser.ConstructorTypeEx
 
 	// Create signature
 	sig := &types.Signature{
+		ParameterNames:    node.ParameterNames,
 		ParameterTypes:    paramTypes,
 		ReturnType:        constructedType,
 		IsVariadic:        node.RestParameter != nil,
@@ -1054,7 +1055,7 @@ func (c *Checker) resolveFunctionLiteralSignature(node *parser.FunctionLiteral,
 			c.env = originalEnv             // Restore original environment
 
 			defaultValueType := paramNode.DefaultValue.GetComputedType()
-			if defaultValueType != nil && !types.IsAssignable(defaultValueType, resolvedParamType) {
+			if defaultValueType != nil && !c.isAssignable(defaultValueType, resolvedParamType) {
 				c.addError(paramNode.DefaultValue, fmt.Sprintf("default value type '%s' is not assignable to parameter type '%s'", defaultValueType.String(), resolvedParamType.String()))
 			}
 		}
@@ -1137,8 +1138,8 @@ func (c *Checker) resolveFunctionLiteralSignature(node *parser.FunctionLiteral,
 	}
 }
 
-func functionTypeParameterNameStrings(params []parser.Expression) []string {
-	return nil
+func functionTypeParameterNameStrings(node *parser.FunctionTypeExpression) []string {
+	return node.ParameterNames
 }
 
 // instantiateGenericType creates a concrete type by substituting type arguments
@@ -1196,7 +1197,7 @@ func (c *Checker) instantiateGenericType(genericType *types.GenericType, typeArg
 				argType.String(), constraintType.String())
 
 			// Check if the type argument satisfies the constraint
-			if !types.IsAssignable(argType, constraintType) {
+			if !c.isAssignable(argType, constraintType) {
 				// Create a more detailed error message with proper node position
 				errorMsg := fmt.Sprintf("Type '%s' does not satisfy constraint '%s' for type parameter '%s'",
 					argType.String(), constraintType.String(), typeParam.Name)
@@ -1878,7 +1879,7 @@ func (c *Checker) computeConditionalType(checkType, extendsType, trueType, false
 	}
 
 	// Fallback to basic assignability check without inference
-	if types.IsAssignable(checkType, extendsType) {
+	if c.isAssignable(checkType, extendsType) {
 		debugPrintf("// [ConditionalType] YES: %s extends %s -> %s\n", checkType.String(), extendsType.String(), trueType.String())
 		return trueType
 	} else {
@@ -2307,7 +2308,7 @@ func (c *Checker) isAssignableWithExpansion(source, target types.Type) bool {
 	expandedSource := c.expandIfMappedType(source)
 
 	// Use the standard assignability check with expanded types
-	result := types.IsAssignable(expandedSource, expandedTarget)
+	result := c.isAssignable(expandedSource, expandedTarget)
 	debugPrintf("// [Checker] isAssignableWithExpansion result: %v\n", result)
 	return result
 }
@@ -2420,7 +2421,7 @@ func (c *Checker) tryInferTypes(checkType, extendsType types.Type, inferences ma
 
 	default:
 		// For other types, check if they're structurally compatible
-		return types.IsAssignable(checkType, extendsType)
+		return c.isAssignable(checkType, extendsType)
 	}
 }
 
@@ -2916,3 +2917,16 @@ func (c *Checker) resolveEnumMemberTypeExpression(node *parser.MemberExpression)
 		return nil
 	}
 }
+
+// forwardReferenceTypeArguments resolves the type arguments of a reference to
+// a generic type that isn't defined yet, leaving nil placeholders for those
+// that don't resolve either
+func (c *Checker) forwardReferenceTypeArguments(nodes []parser.Expression) []types.Type {
+	args := make([]types.Type, len(nodes))
+	errorCount := len(c.errors)
+	for i, node := range nodes {
+		args[i] = c.resolveTypeAnnotation(node)
+	}
+	c.errors = c.errors[:errorCount]
+	return args
+}
@@ -91,7 +91,7 @@ func (c *Checker) checkGenericTypeAliasStatement(node *parser.TypeAliasStatement
 				typeParam.Default = defaultType
 
 				// Validate that default type satisfies constraint if both are present
-				if typeParam.Constraint != nil && !types.IsAssignable(defaultType, typeParam.Constraint) {
+				if typeParam.Constraint != nil && !c.isAssignable(defaultType, typeParam.Constraint) {
 					c.addError(param.DefaultType, fmt.Sprintf("default type '%s' does not satisfy constraint '%s'", defaultType.String(), typeParam.Constraint.String()))
 				}
 			}
@@ -350,6 +350,9 @@ func (c *Checker) checkInterfaceDeclaration(node *parser.InterfaceDeclaration) {
 				// Explicitly mark as not optional in case it was inherited as optional
 				optionalProperties[prop.Name.Value] = false
 			}
+			if prop.Readonly {
+				interfaceType.WithReadOnlyProperty(prop.Name.Value, propType)
+			}
 		}
 	}
 
@@ -441,7 +444,7 @@ func (c *Checker) checkGenericInterfaceDeclaration(node *parser.InterfaceDeclara
 				}
 				typeParam.Default = defaultType
 
-				if typeParam.Constraint != nil && !types.IsAssignable(defaultType, typeParam.Constraint) {
+				if typeParam.Constraint != nil && !c.isAssignable(defaultType, typeParam.Constraint) {
 					c.addError(param.DefaultType, fmt.Sprintf("default type '%s' does not satisfy constraint '%s'", defaultType.String(), typeParam.Constraint.String()))
 				}
 			}
@@ -547,6 +550,9 @@ func (c *Checker) checkGenericInterfaceDeclaration(node *parser.InterfaceDeclara
 			if prop.Optional {
 				optionalProperties[prop.Name.Value] = true
 			}
+			if prop.Readonly {
+				bodyType.WithReadOnlyProperty(prop.Name.Value, propType)
+			}
 		}
 	}
 
@@ -901,7 +907,7 @@ func (c *Checker) checkForOfStatement(node *parser.ForOfStatement) {
 				} else {
 					elementType = types.Any
 				}
-			} else if iterableType == types.String || types.IsAssignable(iterableType, types.String) {
+			} else if iterableType == types.String || c.isAssignable(iterableType, types.String) {
 				// String iteration yields individual characters (strings)
 				// This handles both the general string type and string literal types
 				elementType = types.String
@@ -934,7 +940,7 @@ func (c *Checker) checkForOfStatement(node *parser.ForOfStatement) {
 							TypeArguments: []types.Type{types.Any},
 						}
 
-						if types.IsAssignable(iterableType, iterableAny) {
+						if c.isAssignable(iterableType, iterableAny) {
 							// It's iterable, but we can't easily extract the element type
 							// For now, use Any as a safe fallback
 							elementType = types.Any
@@ -972,6 +978,9 @@ func (c *Checker) checkForOfStatement(node *parser.ForOfStatement) {
 					c.env.Define(constStmt.Name.Value, elementType, true) // const = true
 					constStmt.ComputedType = elementType
 					constStmt.Name.SetComputedType(elementType)
+					if constStmt.Using {
+						c.checkUsingValue(node.Iterable, elementType)
+					}
 				}
 			} else if varStmt, ok := node.Variable.(*parser.VarStatement); ok {
 				// Define the loop variable with the element type
@@ -995,7 +1004,7 @@ func (c *Checker) checkForOfStatement(node *parser.ForOfStatement) {
 						varType, _, exists := c.env.Resolve(ident.Value)
 						if !exists {
 							c.addError(ident, fmt.Sprintf("undefined variable '%s'", ident.Value))
-						} else if !types.IsAssignable(elementType, varType) {
+						} else if !c.isAssignable(elementType, varType) {
 							c.addError(ident, fmt.Sprintf("cannot assign element type '%s' to variable type '%s'", elementType.String(), varType.String()))
 						}
 						ident.SetComputedType(elementType)
@@ -1237,7 +1246,7 @@ func (c *Checker) checkForInStatement(node *parser.ForInStatement) {
 							}
 							outer.Define(ident.Value, elementType, false)
 							// No error added; body will see the binding.
-						} else if !types.IsAssignable(elementType, varType) {
+						} else if !c.isAssignable(elementType, varType) {
 							c.addError(ident, fmt.Sprintf("cannot assign property name type '%s' to variable type '%s'", elementType.String(), varType.String()))
 						}
 						ident.SetComputedType(elementType)
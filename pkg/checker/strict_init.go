@@ -13,7 +13,8 @@ import (
 // optional, and are never assigned via `this.<name> = ...` inside the
 // constructor body.
 //
-// Skipped wholesale for ambient (`declare class`) and abstract classes.
+// Skipped wholesale for ambient (`declare class`, or any class of a
+// declaration file) and abstract classes.
 // Per-property abstract is not tracked by the parser today, so abstract classes
 // are treated permissively rather than risk false positives.
 func (c *Checker) checkStrictPropertyInit(node *parser.ClassDeclaration) {
@@ -26,6 +27,9 @@ func (c *Checker) checkStrictPropertyInit(node *parser.ClassDeclaration) {
 	if node.Declare || node.IsAbstract {
 		return
 	}
+	if c.source != nil && parser.IsDeclarationFile(c.source.DisplayPath()) {
+		return
+	}
 
 	assigned := collectStrictInitAssignments(node.Body)
 
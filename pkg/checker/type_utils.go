@@ -27,6 +27,12 @@ func (c *Checker) getBuiltinType(name string) types.Type {
 	return nil
 }
 
+// isAssignable reports whether source is assignable to target under the
+// checker's options
+func (c *Checker) isAssignable(source, target types.Type) bool {
+	return types.IsAssignableWithOptions(source, target, types.AssignabilityOptions{StrictNullChecks: c.strictNullChecks})
+}
+
 // getPropertyTypeFromType returns the type of a property access on the given type
 // isOptionalChaining determines whether to be permissive about missing properties
 // This is now a wrapper around the implementation in the types package.
@@ -0,0 +1,64 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nooga/paserati/pkg/parser"
+)
+
+// checkUnused emits TS6133/TS6196 for declarations that are never read, as
+// enabled by noUnusedLocals and noUnusedParameters.
+//
+// The analysis is syntactic: it resolves the program's bindings (see
+// walkBindings), so hoisted functions and closures over later declarations
+// count. A shape the walk doesn't recognize can hide an unused declaration
+// but never reports a used one. Labels may likewise keep a same-named
+// declaration alive.
+//
+// Not reported: top-level declarations of scripts, which are globals;
+// exported declarations; catch parameters; parameters whose name starts with
+// an underscore, that are parameter properties, or belong to a signature
+// without a body; and object destructuring bindings with a rest sibling.
+// Files using with statements or eval are skipped wholesale.
+func (c *Checker) checkUnused(program *parser.Program) {
+	if (!c.noUnusedLocals && !c.noUnusedParameters) || program == nil {
+		return
+	}
+	w := walkBindings(program)
+	if w.dynamic {
+		return
+	}
+
+	sort.SliceStable(w.decls, func(i, j int) bool {
+		return w.decls[i].name.Token.StartPos < w.decls[j].name.Token.StartPos
+	})
+	for _, decl := range w.decls {
+		if decl.used {
+			continue
+		}
+		switch decl.kind {
+		case unusedParameter:
+			if c.noUnusedParameters {
+				c.addError(decl.name, fmt.Sprintf("'%s' is declared but its value is never read.", decl.name.Value))
+			}
+		case unusedType:
+			if c.noUnusedLocals {
+				c.addError(decl.name, fmt.Sprintf("'%s' is declared but never used.", decl.name.Value))
+			}
+		default:
+			if c.noUnusedLocals {
+				c.addError(decl.name, fmt.Sprintf("'%s' is declared but its value is never read.", decl.name.Value))
+			}
+		}
+	}
+}
+
+// unusedKind is how an unused declaration is reported
+type unusedKind int
+
+const (
+	unusedLocal unusedKind = iota
+	unusedType
+	unusedParameter
+)
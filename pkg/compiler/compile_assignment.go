@@ -428,7 +428,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 			// Step 1: Capture super base FIRST (before key evaluation)
 			baseReg := c.regAlloc.Alloc()
 			tempRegs = append(tempRegs, baseReg)
-			c.chunk.WriteOpCode(vm.OpLoadSuper, line)
+			c.emitOpCode(vm.OpLoadSuper, line)
 			c.chunk.EmitByte(byte(baseReg))
 
 			// Step 2: Compile the index expression (may call toString() which could mutate prototype)
@@ -444,7 +444,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 				// Step 3: For compound assignment, read current value using OpGetSuperComputed
 				currentValueReg = c.regAlloc.Alloc()
 				tempRegs = append(tempRegs, currentValueReg)
-				c.chunk.WriteOpCode(vm.OpGetSuperComputed, line)
+				c.emitOpCode(vm.OpGetSuperComputed, line)
 				c.chunk.EmitByte(byte(currentValueReg)) // destination
 				c.chunk.EmitByte(byte(keyReg))          // key
 			}
@@ -497,7 +497,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 			}
 
 			// Emit OpSetSuperComputedWithBase with the captured base
-			c.chunk.WriteOpCode(vm.OpSetSuperComputedWithBase, line)
+			c.emitOpCode(vm.OpSetSuperComputedWithBase, line)
 			c.chunk.EmitByte(byte(baseReg))
 			c.chunk.EmitByte(byte(keyReg))
 			c.chunk.EmitByte(byte(valueReg))
@@ -556,7 +556,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 				// per ECMAScript spec (GetSuperBase before ToPropertyKey)
 				baseReg := c.regAlloc.Alloc()
 				tempRegs = append(tempRegs, baseReg)
-				c.chunk.WriteOpCode(vm.OpLoadSuper, line)
+				c.emitOpCode(vm.OpLoadSuper, line)
 				c.chunk.EmitByte(byte(baseReg))
 
 				// Step 2: Compile the key expression (may have side effects like changing prototype)
@@ -574,7 +574,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 					// but since the key is already evaluated, this is correct for most cases
 					currentValueReg = c.regAlloc.Alloc()
 					tempRegs = append(tempRegs, currentValueReg)
-					c.chunk.WriteOpCode(vm.OpGetSuperComputed, line)
+					c.emitOpCode(vm.OpGetSuperComputed, line)
 					c.chunk.EmitByte(byte(currentValueReg)) // destination
 					c.chunk.EmitByte(byte(keyReg))          // key
 				}
@@ -627,7 +627,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 				}
 
 				// Step 6: Write back using captured super base
-				c.chunk.WriteOpCode(vm.OpSetSuperComputedWithBase, line)
+				c.emitOpCode(vm.OpSetSuperComputedWithBase, line)
 				c.chunk.EmitByte(byte(baseReg))   // super base
 				c.chunk.EmitByte(byte(keyReg))   // key
 				c.chunk.EmitByte(byte(valueReg)) // value
@@ -647,7 +647,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 					// For compound assignment, read current value first
 					currentValueReg = c.regAlloc.Alloc()
 					tempRegs = append(tempRegs, currentValueReg)
-					c.chunk.WriteOpCode(vm.OpGetSuper, line)
+					c.emitOpCode(vm.OpGetSuper, line)
 					c.chunk.EmitByte(byte(currentValueReg))
 					c.chunk.WriteUint16(nameConstIdx)
 				}
@@ -700,7 +700,7 @@ func (c *Compiler) compileAssignmentExpression(node *parser.AssignmentExpression
 				}
 
 				// Emit OpSetSuper
-				c.chunk.WriteOpCode(vm.OpSetSuper, line)
+				c.emitOpCode(vm.OpSetSuper, line)
 				c.chunk.WriteUint16(nameConstIdx)
 				c.chunk.EmitByte(byte(valueReg))
 
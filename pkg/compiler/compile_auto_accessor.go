@@ -0,0 +1,382 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// --- Auto-Accessors ---
+//
+// An `accessor x = init` field is a getter and setter pair over a private
+// field holding its value:
+//
+//	#storage = init
+//	get x() { return this.#storage }
+//	set x(value) { this.#storage = value }
+//
+// The storage's private name, autoAccessorStorage, can't be written in
+// source. The getter and setter are created once, when the class is defined,
+// so that "accessor" decorators can replace them: each decorator is called
+// with { get, set } and a context whose access gets, sets or tests the field
+// on any object, and may return { get, set, init }. The init functions
+// transform the initial value, in the order the decorators were applied
+// (OpApplyInitializers).
+
+// autoAccessor holds the getter and setter of an accessor field, and the
+// init functions of its decorators, in synthetic variables of the class scope
+type autoAccessor struct {
+	storage string   // Private name of the storage, without '#'
+	getter  Register // The getter, as replaced by decorators
+	setter  Register // The setter, as replaced by decorators
+	inits   string   // Synthetic variable of the array of init functions; "" without decorators
+}
+
+// autoAccessorStorage returns the private name of the storage of the accessor
+// field with index i
+func autoAccessorStorage(i int) string {
+	return fmt.Sprintf("accessor %d", i)
+}
+
+// compileAutoAccessors creates the getters and setters of node's accessor
+// fields, and applies their decorators. Called once the class's private names
+// and decorators are known, before its constructor is compiled, which
+// captures the synthetic variables.
+func (c *Compiler) compileAutoAccessors(node *parser.ClassDeclaration, decorators []*decoratorInfo) errors.PaseratiError {
+	c.autoAccessors = make(map[int]*autoAccessor)
+	for i, property := range node.Body.Properties {
+		if !property.IsAccessor {
+			continue
+		}
+		name := c.extractPropertyName(property.Key)
+		line := property.Token.Line
+		accessor := &autoAccessor{storage: autoAccessorStorage(i)}
+		storageKey := &parser.Identifier{Token: property.Token, Value: "#" + accessor.storage}
+
+		getter := &parser.FunctionLiteral{
+			Token: property.Token,
+			Body: &parser.BlockStatement{Token: property.Token, Statements: []parser.Statement{
+				&parser.ReturnStatement{Token: property.Token, ReturnValue: &parser.MemberExpression{
+					Token:    property.Token,
+					Object:   &parser.ThisExpression{Token: property.Token},
+					Property: storageKey,
+				}},
+			}},
+		}
+		valueParam := &parser.Identifier{Token: property.Token, Value: "value"}
+		setter := &parser.FunctionLiteral{
+			Token:      property.Token,
+			Parameters: []*parser.Parameter{{Token: property.Token, Name: valueParam}},
+			Body: &parser.BlockStatement{Token: property.Token, Statements: []parser.Statement{
+				&parser.ExpressionStatement{Token: property.Token, Expression: &parser.AssignmentExpression{
+					Token:    property.Token,
+					Operator: "=",
+					Left: &parser.MemberExpression{
+						Token:    property.Token,
+						Object:   &parser.ThisExpression{Token: property.Token},
+						Property: storageKey,
+					},
+					Value: valueParam,
+				}},
+			}},
+		}
+
+		// The registers stay allocated while the class scope lives, like the
+		// pre-evaluated computed keys
+		accessor.getter = c.regAlloc.Alloc()
+		accessor.setter = c.regAlloc.Alloc()
+		for _, f := range []struct {
+			reg  Register
+			fn   *parser.FunctionLiteral
+			hint string
+		}{{accessor.getter, getter, "get " + name}, {accessor.setter, setter, "set " + name}} {
+			funcConstIndex, freeSymbols, err := c.compileFunctionLiteralWithThisClass(f.fn, f.hint, node.Name.Value)
+			if err != nil {
+				return err
+			}
+			c.emitClosure(f.reg, funcConstIndex, f.fn, freeSymbols)
+		}
+		c.currentSymbolTable.Define(fmt.Sprintf("__acc_get_%d__", i), accessor.getter)
+		c.currentSymbolTable.Define(fmt.Sprintf("__acc_set_%d__", i), accessor.setter)
+		c.autoAccessors[i] = accessor
+
+		var decs []*decoratorInfo
+		for _, d := range decorators {
+			if d.target == "accessor" && d.name == name {
+				decs = append(decs, d)
+			}
+		}
+		if len(decs) > 0 {
+			accessor.inits = fmt.Sprintf("__acc_init_%d__", i)
+			initsReg := c.regAlloc.Alloc()
+			c.emitMakeEmptyArray(initsReg, line)
+			c.currentSymbolTable.Define(accessor.inits, initsReg)
+			accessReg := c.regAlloc.Alloc()
+			if err := c.compileAutoAccessorAccess(node, property, i, accessReg); err != nil {
+				c.regAlloc.Free(accessReg)
+				return err
+			}
+			isPrivate := len(name) > 0 && name[0] == '#'
+			c.applyAccessorDecorators(decs, accessor, initsReg, accessReg, name, property.IsStatic, isPrivate)
+			c.regAlloc.Free(accessReg)
+		}
+	}
+	return nil
+}
+
+// compileAutoAccessorAccess creates in destReg the access object of the
+// contexts of the accessor field property, with index i:
+//
+//	{ get(o) { return o[key] }, set(o, value) { o[key] = value }, has(o) { return key in o } }
+//
+// A private field's has tests for its storage, which holds its brand
+func (c *Compiler) compileAutoAccessorAccess(node *parser.ClassDeclaration, property *parser.PropertyDefinition, i int, destReg Register) errors.PaseratiError {
+	token := property.Token
+	name := c.extractPropertyName(property.Key)
+	object := &parser.Identifier{Token: token, Value: "o"}
+	value := &parser.Identifier{Token: token, Value: "value"}
+
+	var field parser.Expression
+	var has parser.Expression
+	if len(name) > 0 && name[0] == '#' {
+		field = &parser.MemberExpression{Token: token, Object: object, Property: &parser.Identifier{Token: token, Value: name}}
+		has = &parser.InfixExpression{Token: token, Operator: "in", Left: &parser.PrivateIdentifier{Token: token, Value: "#" + c.autoAccessors[i].storage}, Right: object}
+	} else {
+		var key parser.Expression = &parser.StringLiteral{Token: token, Value: name}
+		if varName, hasPreComputed := c.computedFieldKeyVars[i]; hasPreComputed {
+			key = &parser.Identifier{Token: token, Value: varName}
+		}
+		field = &parser.IndexExpression{Token: token, Left: object, Index: key}
+		has = &parser.InfixExpression{Token: token, Operator: "in", Left: key, Right: object}
+	}
+
+	access := map[string]*parser.FunctionLiteral{
+		"get": {
+			Token:      token,
+			Parameters: []*parser.Parameter{{Token: token, Name: object}},
+			Body:       &parser.BlockStatement{Token: token, Statements: []parser.Statement{&parser.ReturnStatement{Token: token, ReturnValue: field}}},
+		},
+		"set": {
+			Token:      token,
+			Parameters: []*parser.Parameter{{Token: token, Name: object}, {Token: token, Name: value}},
+			Body: &parser.BlockStatement{Token: token, Statements: []parser.Statement{
+				&parser.ExpressionStatement{Token: token, Expression: &parser.AssignmentExpression{Token: token, Operator: "=", Left: field, Value: value}},
+			}},
+		},
+		"has": {
+			Token:      token,
+			Parameters: []*parser.Parameter{{Token: token, Name: object}},
+			Body:       &parser.BlockStatement{Token: token, Statements: []parser.Statement{&parser.ReturnStatement{Token: token, ReturnValue: has}}},
+		},
+	}
+
+	c.emitMakeEmptyObject(destReg, token.Line)
+	fnReg := c.regAlloc.Alloc()
+	defer c.regAlloc.Free(fnReg)
+	for _, member := range []string{"get", "set", "has"} {
+		fn := access[member]
+		funcConstIndex, freeSymbols, err := c.compileFunctionLiteralWithThisClass(fn, member, node.Name.Value)
+		if err != nil {
+			return err
+		}
+		c.emitClosure(fnReg, funcConstIndex, fn, freeSymbols)
+		c.emitSetProp(destReg, fnReg, c.chunk.AddConstant(vm.String(member)), token.Line)
+	}
+	return nil
+}
+
+// applyAccessorDecorators applies the decorators of an accessor field in
+// reverse order, calling each with { get, set } and taking the get, set and
+// init of what it returns. Their contexts have the access object in accessReg.
+func (c *Compiler) applyAccessorDecorators(decs []*decoratorInfo, accessor *autoAccessor, initsReg, accessReg Register, name string, isStatic, isPrivate bool) {
+	line := decs[0].line
+	getIdx := c.chunk.AddConstant(vm.String("get"))
+	setIdx := c.chunk.AddConstant(vm.String("set"))
+	initIdx := c.chunk.AddConstant(vm.String("init"))
+	accessIdx := c.chunk.AddConstant(vm.String("access"))
+
+	// Create initializer array for addInitializer callbacks
+	initArrayReg := c.regAlloc.Alloc()
+	defer c.regAlloc.Free(initArrayReg)
+	c.emitMakeEmptyArray(initArrayReg, line)
+
+	// Appends an init function to the inits array
+	addInitReg := c.regAlloc.Alloc()
+	defer c.regAlloc.Free(addInitReg)
+	c.emitMakeAddInitializer(addInitReg, initsReg, line)
+
+	for i := len(decs) - 1; i >= 0; i-- {
+		dec := decs[i]
+
+		targetReg := c.regAlloc.Alloc()
+		c.emitMakeEmptyObject(targetReg, dec.line)
+		c.emitSetProp(targetReg, accessor.getter, getIdx, dec.line)
+		c.emitSetProp(targetReg, accessor.setter, setIdx, dec.line)
+
+		contextReg := c.regAlloc.Alloc()
+		c.createDecoratorContext(contextReg, "accessor", name, isStatic, isPrivate, initArrayReg, dec.line)
+		c.emitSetProp(contextReg, accessReg, accessIdx, dec.line)
+
+		callRegs := c.regAlloc.AllocContiguous(3)
+		c.emitMove(callRegs, dec.reg, dec.line)
+		c.emitMove(callRegs+1, targetReg, dec.line)
+		c.emitMove(callRegs+2, contextReg, dec.line)
+
+		resultReg := c.regAlloc.Alloc()
+		c.emitCall(resultReg, callRegs, 2, dec.line)
+
+		// Returning undefined keeps the accessor as it is
+		skipJump := c.emitPlaceholderJump(vm.OpJumpIfUndefined, resultReg, dec.line)
+		memberReg := c.regAlloc.Alloc()
+		c.emitGetProp(memberReg, resultReg, getIdx, dec.line)
+		c.emitCheckAndReplace(accessor.getter, memberReg, dec.line)
+		c.emitGetProp(memberReg, resultReg, setIdx, dec.line)
+		c.emitCheckAndReplace(accessor.setter, memberReg, dec.line)
+		c.emitGetProp(memberReg, resultReg, initIdx, dec.line)
+		noInitJump := c.emitPlaceholderJump(vm.OpJumpIfUndefined, memberReg, dec.line)
+		addRegs := c.regAlloc.AllocContiguous(2)
+		c.emitMove(addRegs, addInitReg, dec.line)
+		c.emitMove(addRegs+1, memberReg, dec.line)
+		c.emitCall(addRegs, addRegs, 1, dec.line)
+		c.regAlloc.Free(addRegs + 1)
+		c.regAlloc.Free(addRegs)
+		c.patchJump(noInitJump)
+		c.regAlloc.Free(memberReg)
+		c.patchJump(skipJump)
+
+		c.regAlloc.Free(resultReg)
+		c.regAlloc.Free(callRegs + 2)
+		c.regAlloc.Free(callRegs + 1)
+		c.regAlloc.Free(callRegs)
+		c.regAlloc.Free(contextReg)
+		c.regAlloc.Free(targetReg)
+	}
+
+	// Like method decorators' initializers, run them right away for now
+	undefinedReg := c.regAlloc.Alloc()
+	c.emitLoadUndefined(undefinedReg, line)
+	c.emitRunInitializers(initArrayReg, undefinedReg, line)
+	c.regAlloc.Free(undefinedReg)
+}
+
+// autoAccessorStorageField returns the field holding the value of the
+// accessor field property, with index i: its initializer goes through the
+// accessor's init functions, if it has any
+func (c *Compiler) autoAccessorStorageField(property *parser.PropertyDefinition, i int) *parser.PropertyDefinition {
+	accessor := c.autoAccessors[i]
+	var value parser.Expression = property.Value
+	if value == nil {
+		value = &parser.UndefinedLiteral{Token: property.Token}
+	}
+	if accessor.inits != "" {
+		// this.__applyInitializers__(inits, value)
+		value = &parser.CallExpression{
+			Token: property.Token,
+			Function: &parser.MemberExpression{
+				Token:    property.Token,
+				Object:   &parser.ThisExpression{Token: property.Token},
+				Property: &parser.Identifier{Token: property.Token, Value: "__applyInitializers__"},
+			},
+			Arguments: []parser.Expression{&parser.Identifier{Token: property.Token, Value: accessor.inits}, value},
+		}
+	}
+	return &parser.PropertyDefinition{
+		Token:    property.Token,
+		Key:      &parser.Identifier{Token: property.Token, Value: "#" + accessor.storage},
+		Value:    value,
+		IsStatic: property.IsStatic,
+	}
+}
+
+// autoAccessorPrivateSetup returns the statement installing the getter and
+// setter of the private accessor field with index i on the instance:
+// this.__setPrivateAccessor__(name, getter, setter)
+func (c *Compiler) autoAccessorPrivateSetup(property *parser.PropertyDefinition, i int) parser.Statement {
+	token := property.Token
+	return &parser.ExpressionStatement{Token: token, Expression: &parser.CallExpression{
+		Token: token,
+		Function: &parser.MemberExpression{
+			Token:    token,
+			Object:   &parser.ThisExpression{Token: token},
+			Property: &parser.Identifier{Token: token, Value: "__setPrivateAccessor__"},
+		},
+		Arguments: []parser.Expression{
+			&parser.StringLiteral{Token: token, Value: c.extractPropertyName(property.Key)[1:]},
+			&parser.Identifier{Token: token, Value: fmt.Sprintf("__acc_get_%d__", i)},
+			&parser.Identifier{Token: token, Value: fmt.Sprintf("__acc_set_%d__", i)},
+		},
+	}}
+}
+
+// defineAutoAccessor defines the getter and setter of the public accessor
+// field with index i on targetReg, the prototype or the constructor
+func (c *Compiler) defineAutoAccessor(property *parser.PropertyDefinition, i int, targetReg Register) errors.PaseratiError {
+	accessor := c.autoAccessors[i]
+	line := property.Token.Line
+	if varName, hasPreComputed := c.computedFieldKeyVars[i]; hasPreComputed {
+		keyReg := c.regAlloc.Alloc()
+		defer c.regAlloc.Free(keyReg)
+		if _, err := c.compileNode(&parser.Identifier{Token: property.Token, Value: varName}, keyReg); err != nil {
+			return err
+		}
+		c.emitDefineAccessorDynamic(targetReg, accessor.getter, accessor.setter, keyReg, false, line)
+		return nil
+	}
+	nameIdx := c.chunk.AddConstant(vm.String(c.extractPropertyName(property.Key)))
+	c.emitDefineAccessor(targetReg, accessor.getter, accessor.setter, nameIdx, false, line)
+	return nil
+}
+
+// addStaticAutoAccessor sets up the static accessor field property, with
+// index i, on the constructor: its storage, then its getter and setter
+func (c *Compiler) addStaticAutoAccessor(property *parser.PropertyDefinition, i int, constructorReg Register) errors.PaseratiError {
+	// The storage isn't a computed field, whatever the accessor's key
+	if err := c.addStaticProperty(c.autoAccessorStorageField(property, i), constructorReg, -1); err != nil {
+		return err
+	}
+	name := c.extractPropertyName(property.Key)
+	if len(name) == 0 || name[0] != '#' {
+		return c.defineAutoAccessor(property, i, constructorReg)
+	}
+	accessor := c.autoAccessors[i]
+	nameIdx := c.chunk.AddConstant(vm.String(c.getPrivateFieldKey(name[1:])))
+	c.emitOpCode(vm.OpSetPrivateAccessor, property.Token.Line)
+	c.emitByte(byte(constructorReg))
+	c.emitByte(byte(accessor.getter))
+	c.emitByte(byte(accessor.setter))
+	c.emitUint16(nameIdx)
+	return nil
+}
+
+// compileApplyInitializers compiles a synthetic __applyInitializers__ call,
+// generated for the storage of decorated accessor fields: the initial value
+// (second argument) passed through the init functions (first argument)
+func (c *Compiler) compileApplyInitializers(node *parser.CallExpression, objExpr parser.Expression, hint Register, tempRegs *[]Register) (Register, errors.PaseratiError) {
+	if len(node.Arguments) != 2 {
+		return BadRegister, NewCompileError(node, "__applyInitializers__ requires exactly 2 arguments")
+	}
+	if hint == BadRegister {
+		hint = c.regAlloc.Alloc()
+		*tempRegs = append(*tempRegs, hint)
+	}
+	if _, err := c.compileNode(node.Arguments[1], hint); err != nil {
+		return BadRegister, err
+	}
+	initsReg := c.regAlloc.Alloc()
+	*tempRegs = append(*tempRegs, initsReg)
+	if _, err := c.compileNode(node.Arguments[0], initsReg); err != nil {
+		return BadRegister, err
+	}
+	thisReg := c.regAlloc.Alloc()
+	*tempRegs = append(*tempRegs, thisReg)
+	if _, err := c.compileNode(objExpr, thisReg); err != nil {
+		return BadRegister, err
+	}
+	c.emitOpCode(vm.OpApplyInitializers, node.Token.Line)
+	c.emitByte(byte(hint))
+	c.emitByte(byte(initsReg))
+	c.emitByte(byte(thisReg))
+	return hint, nil
+}
@@ -178,8 +178,16 @@ func (c *Compiler) preEvaluateComputedFieldKeys(node *parser.ClassDeclaration) e
 // fields from the outer class's private fields.
 func (c *Compiler) declareClassPrivateNames(node *parser.ClassDeclaration) {
 	// Declare private properties (fields)
-	for _, property := range node.Body.Properties {
+	for i, property := range node.Body.Properties {
 		propName := c.extractPropertyName(property.Key)
+		if property.IsAccessor {
+			// Auto-accessors: private storage, and a private getter and setter if named #x
+			c.declarePrivateMember(autoAccessorStorage(i), PrivateMemberField)
+			if len(propName) > 0 && propName[0] == '#' {
+				c.declarePrivateMember(propName[1:], PrivateMemberAccessor)
+			}
+			continue
+		}
 		if len(propName) > 0 && propName[0] == '#' {
 			fieldName := propName[1:] // Strip #
 			c.declarePrivateMember(fieldName, PrivateMemberField)
@@ -399,6 +407,26 @@ func (c *Compiler) compileClassDeclaration(node *parser.ClassDeclaration, hint R
 
 	// Store decorators for use by setupClassPrototype
 	c.currentClassDecorators = classDecorators
+	if classDecorators != nil {
+		prevClassMetadata := c.currentClassMetadata
+		defer func() { c.currentClassMetadata = prevClassMetadata }()
+		c.currentClassMetadata = c.regAlloc.Alloc()
+		defer c.regAlloc.Free(c.currentClassMetadata)
+		c.emitMakeDecoratorMetadata(c.currentClassMetadata, superConstructorReg, node.Token.Line)
+	}
+
+	// 1.9. Create the getters and setters of accessor fields, applying their decorators
+	prevAutoAccessors := c.autoAccessors
+	defer func() { c.autoAccessors = prevAutoAccessors }()
+	if err := c.compileAutoAccessors(node, classDecorators); err != nil {
+		if prevSymbolTable != nil {
+			c.currentSymbolTable = prevSymbolTable
+		}
+		if needToFreeSuperReg {
+			c.regAlloc.Free(superConstructorReg)
+		}
+		return BadRegister, err
+	}
 
 	// 2. Create constructor function
 	constructorReg, err := c.compileConstructor(node, superConstructorReg)
@@ -449,8 +477,12 @@ func (c *Compiler) compileClassDeclaration(node *parser.ClassDeclaration, hint R
 		return BadRegister, err
 	}
 
-	// 4.5. Apply class-level decorators (after all members are set up)
+	// 4.5. Apply class-level decorators (after all members are set up), once
+	// the class has the metadata its members' decorators added to
 	if classDecorators != nil {
+		c.emitOpCode(vm.OpDefineDecoratorMetadata, node.Token.Line)
+		c.emitByte(byte(constructorReg))
+		c.emitByte(byte(c.currentClassMetadata))
 		if err := c.applyClassDecorators(node, constructorReg, classDecorators); err != nil {
 			if prevSymbolTable != nil {
 				c.currentSymbolTable = prevSymbolTable
@@ -774,6 +806,18 @@ func (c *Compiler) setupClassPrototype(node *parser.ClassDeclaration, constructo
 		}
 	}
 
+	// Add the getters and setters of public accessor fields
+	for i, property := range node.Body.Properties {
+		if property.IsAccessor && !property.IsStatic {
+			if name := c.extractPropertyName(property.Key); len(name) > 0 && name[0] == '#' {
+				continue // Installed on instances, like private methods
+			}
+			if err := c.defineAutoAccessor(property, i, prototypeReg); err != nil {
+				return err
+			}
+		}
+	}
+
 	debugPrintf("// DEBUG setupClassPrototype: Prototype setup complete for class '%s'\n", node.Name.Value)
 	return nil
 }
@@ -937,12 +981,23 @@ func (c *Compiler) injectFieldInitializers(node *parser.ClassDeclaration, functi
 
 	// Extract field initializers from class properties
 	// Only include instance (non-static) fields - static fields are initialized separately
+	var accessorSetups []parser.Statement
 	for i, property := range node.Body.Properties {
 		// Skip static fields - they're handled by setupStaticMembers, not the constructor
 		if property.IsStatic {
 			continue
 		}
 
+		// Accessor fields initialize their storage; private ones install their
+		// getter and setter first, like private methods
+		if property.IsAccessor {
+			if name := c.extractPropertyName(property.Key); len(name) > 0 && name[0] == '#' {
+				accessorSetups = append(accessorSetups, c.autoAccessorPrivateSetup(property, i))
+			}
+			property = c.autoAccessorStorageField(property, i)
+			i = -1 // The storage isn't a computed field, whatever the accessor's key
+		}
+
 		// Per ECMAScript: class fields without initializers are initialized to undefined
 		initValue := property.Value
 		if initValue == nil {
@@ -1140,6 +1195,8 @@ func (c *Compiler) injectFieldInitializers(node *parser.ClassDeclaration, functi
 		}
 	}
 
+	fieldInitializers = append(accessorSetups, fieldInitializers...)
+
 	// If no field initializers, return original function literal
 	if len(fieldInitializers) == 0 {
 		return functionLiteral
@@ -1211,7 +1268,11 @@ func (c *Compiler) setupStaticMembers(node *parser.ClassDeclaration, constructor
 	// Add static properties
 	// Note: We pass the property index so we can use pre-computed keys from computedFieldKeyVars
 	for i, property := range node.Body.Properties {
-		if property.IsStatic {
+		if property.IsStatic && property.IsAccessor {
+			if err := c.addStaticAutoAccessor(property, i, constructorReg); err != nil {
+				return err
+			}
+		} else if property.IsStatic {
 			err := c.addStaticProperty(property, constructorReg, i)
 			if err != nil {
 				return err
@@ -10,7 +10,7 @@ import (
 type decoratorInfo struct {
 	reg    Register
 	line   int
-	target string // "class", "method", "getter", "setter", "field"
+	target string // "class", "method", "getter", "setter", "field", "accessor"
 	name   string // property name (for class elements)
 }
 
@@ -51,8 +51,12 @@ func (c *Compiler) preEvaluateDecorators(node *parser.ClassDeclaration) ([]*deco
 	}
 
 	for _, prop := range node.Body.Properties {
+		kind := "field"
+		if prop.IsAccessor {
+			kind = "accessor"
+		}
 		for _, dec := range prop.Decorators {
-			info, err := c.evaluateDecoratorExpr(dec, "field", c.extractPropertyName(prop.Key))
+			info, err := c.evaluateDecoratorExpr(dec, kind, c.extractPropertyName(prop.Key))
 			if err != nil {
 				return nil, err
 			}
@@ -93,6 +97,19 @@ func (c *Compiler) emitMakeAddInitializer(destReg, arrayReg Register, line int)
 	c.emitByte(byte(arrayReg))
 }
 
+// emitMakeDecoratorMetadata creates in destReg the metadata object of a class
+// with decorators, inheriting the metadata of the class in superReg, if it
+// isn't BadRegister
+func (c *Compiler) emitMakeDecoratorMetadata(destReg, superReg Register, line int) {
+	if superReg == BadRegister {
+		c.emitLoadUndefined(destReg, line)
+		superReg = destReg
+	}
+	c.emitOpCode(vm.OpMakeDecoratorMetadata, line)
+	c.emitByte(byte(destReg))
+	c.emitByte(byte(superReg))
+}
+
 // emitRunInitializers runs all initializer functions in arrayReg with 'this' = thisReg
 func (c *Compiler) emitRunInitializers(arrayReg, thisReg Register, line int) {
 	c.emitOpCode(vm.OpRunInitializers, line)
@@ -206,7 +223,7 @@ func (c *Compiler) applyMethodDecorators(decs []*decoratorInfo, methodReg Regist
 }
 
 // createDecoratorContext emits bytecode to create a decorator context object.
-// Context structure: { kind, name, static, private, addInitializer }
+// Context structure: { kind, name, static, private, addInitializer, metadata }
 func (c *Compiler) createDecoratorContext(destReg Register, kind, name string, isStatic, isPrivate bool, initArrayReg Register, line int) {
 	c.emitMakeEmptyObject(destReg, line)
 
@@ -255,6 +272,10 @@ func (c *Compiler) createDecoratorContext(destReg Register, kind, name string, i
 	c.emitMakeAddInitializer(addInitReg, initArrayReg, line)
 	c.emitSetProp(destReg, addInitReg, addInitNameIdx, line)
 	c.regAlloc.Free(addInitReg)
+
+	// Set metadata, shared by all the class's decorators
+	metadataNameIdx := c.chunk.AddConstant(vm.String("metadata"))
+	c.emitSetProp(destReg, c.currentClassMetadata, metadataNameIdx, line)
 }
 
 // emitCheckAndReplace emits bytecode that checks if resultReg is not undefined,
@@ -75,19 +75,25 @@ func (c *Compiler) compileTryStatement(node *parser.TryStatement, hint Register)
 		c.tryDepth++
 
 		// Compile statements
-		for _, stmt := range node.Body.Statements {
-			stmtReg, err := c.compileNode(stmt, hint)
-			if err != nil {
-				c.tryDepth--
-				c.currentSymbolTable = previousSymbolTable
-				return BadRegister, err
-			}
-			// If the statement produced a value (expression statement), it's in hint
-			// If not (declaration, etc.), hint still has the previous value or undefined
-			if stmtReg != BadRegister && stmtReg != hint {
-				// Move the result to hint if it ended up in a different register
-				c.emitMove(hint, stmtReg, node.Token.Line)
+		err := c.withDisposeScope(node.Body.Statements, func() errors.PaseratiError {
+			for _, stmt := range node.Body.Statements {
+				stmtReg, err := c.compileNode(stmt, hint)
+				if err != nil {
+					return err
+				}
+				// If the statement produced a value (expression statement), it's in hint
+				// If not (declaration, etc.), hint still has the previous value or undefined
+				if stmtReg != BadRegister && stmtReg != hint {
+					// Move the result to hint if it ended up in a different register
+					c.emitMove(hint, stmtReg, node.Token.Line)
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			c.tryDepth--
+			c.currentSymbolTable = previousSymbolTable
+			return BadRegister, err
 		}
 
 		// Decrement tryDepth after try body - catch/finally can now use TCO
@@ -185,15 +191,21 @@ func (c *Compiler) compileTryStatement(node *parser.TryStatement, hint Register)
 				}
 
 				// Compile catch body - track completion value in hint
-				for _, stmt := range node.CatchClause.Body.Statements {
-					stmtReg, err := c.compileNode(stmt, hint)
-					if err != nil {
-						c.currentSymbolTable = previousSymbolTable
-						return BadRegister, err
-					}
-					if stmtReg != BadRegister && stmtReg != hint {
-						c.emitMove(hint, stmtReg, node.Token.Line)
+				err := c.withDisposeScope(node.CatchClause.Body.Statements, func() errors.PaseratiError {
+					for _, stmt := range node.CatchClause.Body.Statements {
+						stmtReg, err := c.compileNode(stmt, hint)
+						if err != nil {
+							return err
+						}
+						if stmtReg != BadRegister && stmtReg != hint {
+							c.emitMove(hint, stmtReg, node.Token.Line)
+						}
 					}
+					return nil
+				})
+				if err != nil {
+					c.currentSymbolTable = previousSymbolTable
+					return BadRegister, err
 				}
 
 				// Restore the previous symbol table
@@ -226,15 +238,21 @@ func (c *Compiler) compileTryStatement(node *parser.TryStatement, hint Register)
 				}
 
 				// Compile catch body
-				for _, stmt := range node.CatchClause.Body.Statements {
-					stmtReg, err := c.compileNode(stmt, hint)
-					if err != nil {
-						c.currentSymbolTable = catchScopePrev
-						return BadRegister, err
-					}
-					if stmtReg != BadRegister && stmtReg != hint {
-						c.emitMove(hint, stmtReg, node.Token.Line)
+				err := c.withDisposeScope(node.CatchClause.Body.Statements, func() errors.PaseratiError {
+					for _, stmt := range node.CatchClause.Body.Statements {
+						stmtReg, err := c.compileNode(stmt, hint)
+						if err != nil {
+							return err
+						}
+						if stmtReg != BadRegister && stmtReg != hint {
+							c.emitMove(hint, stmtReg, node.Token.Line)
+						}
 					}
+					return nil
+				})
+				if err != nil {
+					c.currentSymbolTable = catchScopePrev
+					return BadRegister, err
 				}
 
 				// Restore scope
@@ -408,15 +426,21 @@ func (c *Compiler) compileTryStatement(node *parser.TryStatement, hint Register)
 				}
 
 				// Compile catch body - track completion value in hint
-				for _, stmt := range node.CatchClause.Body.Statements {
-					stmtReg, err := c.compileNode(stmt, hint)
-					if err != nil {
-						c.currentSymbolTable = previousSymbolTable
-						return BadRegister, err
-					}
-					if stmtReg != BadRegister && stmtReg != hint {
-						c.emitMove(hint, stmtReg, node.Token.Line)
+				err := c.withDisposeScope(node.CatchClause.Body.Statements, func() errors.PaseratiError {
+					for _, stmt := range node.CatchClause.Body.Statements {
+						stmtReg, err := c.compileNode(stmt, hint)
+						if err != nil {
+							return err
+						}
+						if stmtReg != BadRegister && stmtReg != hint {
+							c.emitMove(hint, stmtReg, node.Token.Line)
+						}
 					}
+					return nil
+				})
+				if err != nil {
+					c.currentSymbolTable = previousSymbolTable
+					return BadRegister, err
 				}
 
 				// Restore the previous symbol table
@@ -449,15 +473,21 @@ func (c *Compiler) compileTryStatement(node *parser.TryStatement, hint Register)
 				}
 
 				// Compile catch body
-				for _, stmt := range node.CatchClause.Body.Statements {
-					stmtReg, err := c.compileNode(stmt, hint)
-					if err != nil {
-						c.currentSymbolTable = catchScopePrev
-						return BadRegister, err
-					}
-					if stmtReg != BadRegister && stmtReg != hint {
-						c.emitMove(hint, stmtReg, node.Token.Line)
+				err := c.withDisposeScope(node.CatchClause.Body.Statements, func() errors.PaseratiError {
+					for _, stmt := range node.CatchClause.Body.Statements {
+						stmtReg, err := c.compileNode(stmt, hint)
+						if err != nil {
+							return err
+						}
+						if stmtReg != BadRegister && stmtReg != hint {
+							c.emitMove(hint, stmtReg, node.Token.Line)
+						}
 					}
+					return nil
+				})
+				if err != nil {
+					c.currentSymbolTable = catchScopePrev
+					return BadRegister, err
 				}
 
 				// Restore scope
@@ -508,9 +538,14 @@ func (c *Compiler) compileThrowStatement(node *parser.ThrowStatement, hint Regis
 	return BadRegister, nil
 }
 
-// compileDebuggerStatement compiles a debugger statement (no-op at runtime)
-func (c *Compiler) compileDebuggerStatement(hint Register) (Register, errors.PaseratiError) {
-	// debugger is a no-op at runtime - we just load undefined into the hint register
+// compileDebuggerStatement compiles a debugger statement (no-op at runtime
+// unless compiled with debug info)
+func (c *Compiler) compileDebuggerStatement(node *parser.DebuggerStatement, hint Register) (Register, errors.PaseratiError) {
+	if c.debugInfo {
+		// Trap that pauses in an attached debugger
+		c.emitOpCode(vm.OpDebug, node.Token.Line)
+	}
+	// The statement's completion value is undefined
 	if hint == BadRegister {
 		hint = c.regAlloc.Alloc()
 		defer c.regAlloc.Free(hint)
@@ -541,7 +541,7 @@ func (c *Compiler) compileIndexExpression(node *parser.IndexExpression, hint Reg
 		}
 
 		// Use OpGetSuperComputed to get the property (handles getters correctly)
-		c.chunk.WriteOpCode(vm.OpGetSuperComputed, line)
+		c.emitOpCode(vm.OpGetSuperComputed, line)
 		c.chunk.EmitByte(byte(hint))
 		c.chunk.EmitByte(byte(indexReg))
 
@@ -766,7 +766,7 @@ func (c *Compiler) compileUpdateExpression(node *parser.UpdateExpression, hint R
 			// Step 1: Capture super base FIRST (before key evaluation)
 			baseReg := c.regAlloc.Alloc()
 			tempRegs = append(tempRegs, baseReg)
-			c.chunk.WriteOpCode(vm.OpLoadSuper, line)
+			c.emitOpCode(vm.OpLoadSuper, line)
 			c.chunk.EmitByte(byte(baseReg))
 
 			// Step 2: Compile the index expression
@@ -780,7 +780,7 @@ func (c *Compiler) compileUpdateExpression(node *parser.UpdateExpression, hint R
 			// Step 3: Read current value using OpGetSuperComputed
 			currentValueReg = c.regAlloc.Alloc()
 			tempRegs = append(tempRegs, currentValueReg)
-			c.chunk.WriteOpCode(vm.OpGetSuperComputed, line)
+			c.emitOpCode(vm.OpGetSuperComputed, line)
 			c.chunk.EmitByte(byte(currentValueReg))
 			c.chunk.EmitByte(byte(keyReg))
 
@@ -804,7 +804,7 @@ func (c *Compiler) compileUpdateExpression(node *parser.UpdateExpression, hint R
 					c.emitSubtract(numericValueReg, numericValueReg, constOneReg, line)
 				}
 				// Store back using captured super base
-				c.chunk.WriteOpCode(vm.OpSetSuperComputedWithBase, line)
+				c.emitOpCode(vm.OpSetSuperComputedWithBase, line)
 				c.chunk.EmitByte(byte(baseReg))
 				c.chunk.EmitByte(byte(keyReg))
 				c.chunk.EmitByte(byte(numericValueReg))
@@ -822,7 +822,7 @@ func (c *Compiler) compileUpdateExpression(node *parser.UpdateExpression, hint R
 					c.emitSubtract(numericValueReg, numericValueReg, constOneReg, line)
 				}
 				// Store back using captured super base
-				c.chunk.WriteOpCode(vm.OpSetSuperComputedWithBase, line)
+				c.emitOpCode(vm.OpSetSuperComputedWithBase, line)
 				c.chunk.EmitByte(byte(baseReg))
 				c.chunk.EmitByte(byte(keyReg))
 				c.chunk.EmitByte(byte(numericValueReg))
@@ -1908,7 +1908,7 @@ func (c *Compiler) compileCallExpression(node *parser.CallExpression, hint Regis
 		return c.compileReflectCall(node, hint)
 	}
 
-	// Check if this is a __setPrivateAccessor__, __setPrivateMethod__, or __copyPrivateMethod__ marker call (synthetic call from class compilation)
+	// Check if this is a __setPrivateAccessor__, __setPrivateMethod__, __copyPrivateMethod__ or __applyInitializers__ marker call (synthetic call from class compilation)
 	if memberExpr, ok := node.Function.(*parser.MemberExpression); ok {
 		if propIdent, ok := memberExpr.Property.(*parser.Identifier); ok {
 			if propIdent.Value == "__setPrivateAccessor__" {
@@ -1920,6 +1920,9 @@ func (c *Compiler) compileCallExpression(node *parser.CallExpression, hint Regis
 			if propIdent.Value == "__copyPrivateMethod__" {
 				return c.compilePrivateMethodCopy(node, memberExpr.Object, hint, &tempRegs)
 			}
+			if propIdent.Value == "__applyInitializers__" {
+				return c.compileApplyInitializers(node, memberExpr.Object, hint, &tempRegs)
+			}
 		}
 	}
 
@@ -1992,14 +1995,14 @@ func (c *Compiler) compileCallExpression(node *parser.CallExpression, hint Regis
 					c.inTailPosition = oldTailPos
 					return BadRegister, err
 				}
-				c.chunk.WriteOpCode(vm.OpGetSuperComputed, memberExpr.Token.Line)
+				c.emitOpCode(vm.OpGetSuperComputed, memberExpr.Token.Line)
 				c.chunk.EmitByte(byte(funcReg))
 				c.chunk.EmitByte(byte(propertyReg))
 			} else {
 				// Static property: super.method()
 				propertyName := c.extractPropertyName(memberExpr.Property)
 				nameConstIdx := c.chunk.AddConstant(vm.String(propertyName))
-				c.chunk.WriteOpCode(vm.OpGetSuper, memberExpr.Token.Line)
+				c.emitOpCode(vm.OpGetSuper, memberExpr.Token.Line)
 				c.chunk.EmitByte(byte(funcReg))
 				c.chunk.WriteUint16(nameConstIdx)
 			}
@@ -2056,7 +2059,7 @@ func (c *Compiler) compileCallExpression(node *parser.CallExpression, hint Regis
 			}
 
 			// Use OpGetSuperComputed to get the method
-			c.chunk.WriteOpCode(vm.OpGetSuperComputed, indexExpr.Token.Line)
+			c.emitOpCode(vm.OpGetSuperComputed, indexExpr.Token.Line)
 			c.chunk.EmitByte(byte(funcReg))
 			c.chunk.EmitByte(byte(propertyReg))
 
@@ -3366,7 +3369,7 @@ func (c *Compiler) compileSuperMemberExpression(node *parser.MemberExpression, h
 		}
 
 		// Use OpGetSuperComputed for super[expr]
-		c.chunk.WriteOpCode(vm.OpGetSuperComputed, node.Token.Line)
+		c.emitOpCode(vm.OpGetSuperComputed, node.Token.Line)
 		c.chunk.EmitByte(byte(hint))        // Destination register
 		c.chunk.EmitByte(byte(propertyReg)) // Key register
 
@@ -3377,7 +3380,7 @@ func (c *Compiler) compileSuperMemberExpression(node *parser.MemberExpression, h
 	propertyName := c.extractPropertyName(node.Property)
 	nameConstIdx := c.chunk.AddConstant(vm.String(propertyName))
 
-	c.chunk.WriteOpCode(vm.OpGetSuper, node.Token.Line)
+	c.emitOpCode(vm.OpGetSuper, node.Token.Line)
 	c.chunk.EmitByte(byte(hint))     // Destination register
 	c.chunk.WriteUint16(nameConstIdx) // Property name constant index
 
@@ -3739,7 +3742,7 @@ func (c *Compiler) compileOptionalCallExpression(node *parser.OptionalCallExpres
 			tempRegs = append(tempRegs, methodReg)
 			propertyName := c.extractPropertyName(memberExpr.Property)
 			nameConstIdx := c.chunk.AddConstant(vm.String(propertyName))
-			c.chunk.WriteOpCode(vm.OpGetSuper, memberExpr.Token.Line)
+			c.emitOpCode(vm.OpGetSuper, memberExpr.Token.Line)
 			c.chunk.EmitByte(byte(methodReg))
 			c.chunk.WriteUint16(nameConstIdx)
 
@@ -4170,7 +4173,7 @@ func (c *Compiler) compileDirectEval(node *parser.CallExpression, hint Register,
 		c.emitByte(byte(zeroReg))      // index
 
 		// Emit OpDirectEval with the first element
-		c.chunk.WriteOpCode(vm.OpDirectEval, line)
+		c.emitOpCode(vm.OpDirectEval, line)
 		c.chunk.EmitByte(byte(hint))
 		c.chunk.EmitByte(byte(codeReg))
 
@@ -4195,7 +4198,7 @@ func (c *Compiler) compileDirectEval(node *parser.CallExpression, hint Register,
 	}
 
 	// Emit OpDirectEval: result in hint, code string in codeReg
-	c.chunk.WriteOpCode(vm.OpDirectEval, line)
+	c.emitOpCode(vm.OpDirectEval, line)
 	c.chunk.EmitByte(byte(hint))
 	c.chunk.EmitByte(byte(codeReg))
 
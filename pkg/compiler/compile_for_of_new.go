@@ -415,7 +415,21 @@ func (c *Compiler) compileForOfStatementLabeled(node *parser.ForOfStatement, lab
 	// 14. Compile loop body
 	bodyReg := c.regAlloc.Alloc()
 	tempRegs = append(tempRegs, bodyReg)
-	bodyResultReg, err := c.compileNode(node.Body, bodyReg)
+	var bodyResultReg Register
+	compileBody := func() errors.PaseratiError {
+		bodyResultReg, err = c.compileNode(node.Body, bodyReg)
+		return err
+	}
+	if constStmt, ok := node.Variable.(*parser.ConstStatement); ok && constStmt.Using {
+		// for (using x of ...): each iteration's value is disposed of when the
+		// iteration ends
+		err = c.compileDisposeRegion(constStmt.Await, node.Token.Line, func() errors.PaseratiError {
+			c.emitAddDisposable(constStmt.Await, valueReg, node.Token.Line)
+			return compileBody()
+		})
+	} else {
+		err = compileBody()
+	}
 	if err != nil {
 		c.loopContextStack = c.loopContextStack[:len(c.loopContextStack)-1]
 		return BadRegister, err
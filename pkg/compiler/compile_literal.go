@@ -193,7 +193,7 @@ func (c *Compiler) compileArrowFunctionLiteral(node *parser.ArrowFunctionLiteral
 	functionChunk := funcCompiler.chunk
 
 	// Generate scope descriptor if this arrow function contains direct eval
-	if funcCompiler.hasDirectEval {
+	if funcCompiler.hasDirectEval || funcCompiler.debugInfo {
 		functionChunk.ScopeDesc = funcCompiler.generateScopeDescriptor()
 		debugPrintf("// [Compiler] Arrow function has direct eval, generated scope descriptor with %d locals\n",
 			len(functionChunk.ScopeDesc.LocalNames))
@@ -451,7 +451,7 @@ func (c *Compiler) compileArrowFunctionWithName(node *parser.ArrowFunctionLitera
 	functionChunk.NumSpillSlots = int(funcCompiler.nextSpillSlot) // Set spill slots needed
 
 	// Generate scope descriptor if this arrow function contains direct eval
-	if funcCompiler.hasDirectEval {
+	if funcCompiler.hasDirectEval || funcCompiler.debugInfo {
 		functionChunk.ScopeDesc = funcCompiler.generateScopeDescriptor()
 		debugPrintf("// [Compiler] Arrow function '%s' has direct eval, generated scope descriptor with %d locals\n",
 			nameHint, len(functionChunk.ScopeDesc.LocalNames))
@@ -1623,12 +1623,17 @@ func (c *Compiler) compileFunctionLiteralWithOptions(node *parser.FunctionLitera
 			functionCompiler.emitOpCode(vm.OpInitYield, node.Body.Token.Line)
 			debugPrintf("// [Generator] Emitted OpInitYield after %d desugared parameter declarations\n", desugarCount)
 
-			// Compile remaining statements (the actual body)
-			for i := desugarCount; i < len(blockBody.Statements); i++ {
-				stmtReg := functionCompiler.regAlloc.Alloc()
-				_, _ = functionCompiler.compileNode(blockBody.Statements[i], stmtReg)
-				functionCompiler.regAlloc.Free(stmtReg)
-			}
+			// Compile remaining statements (the actual body), in a dispose
+			// region if they declare resources, so that the generator's
+			// return() and throw() dispose of them too
+			_ = functionCompiler.withDisposeScope(remainingStmts, func() errors.PaseratiError {
+				for _, stmt := range remainingStmts {
+					stmtReg := functionCompiler.regAlloc.Alloc()
+					_, _ = functionCompiler.compileNode(stmt, stmtReg)
+					functionCompiler.regAlloc.Free(stmtReg)
+				}
+				return nil
+			})
 		} else {
 			// Non-block body (arrow function expression) - just emit OpInitYield first
 			functionCompiler.emitOpCode(vm.OpInitYield, node.Body.Token.Line)
@@ -1668,7 +1673,7 @@ func (c *Compiler) compileFunctionLiteralWithOptions(node *parser.FunctionLitera
 	functionChunk.NumSpillSlots = int(functionCompiler.nextSpillSlot) // Set spill slots needed
 
 	// Generate scope descriptor if this function contains direct eval
-	if functionCompiler.hasDirectEval {
+	if functionCompiler.hasDirectEval || functionCompiler.debugInfo {
 		functionChunk.ScopeDesc = functionCompiler.generateScopeDescriptor()
 		debugPrintf("// [Compiler] Function '%s' has direct eval, generated scope descriptor with %d locals\n",
 			determinedFuncName, len(functionChunk.ScopeDesc.LocalNames))
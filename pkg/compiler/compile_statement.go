@@ -669,6 +669,9 @@ func (c *Compiler) compileConstStatement(node *parser.ConstStatement, hint Regis
 	if node.Declare {
 		return BadRegister, nil
 	}
+	if node.Using {
+		return c.compileUsingDeclaration(node, hint)
+	}
 	// Process all constant declarations in the statement
 	for _, declarator := range node.Declarations {
 		// Set current declarator in legacy fields for backward compatibility
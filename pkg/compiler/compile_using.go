@@ -0,0 +1,179 @@
+package compiler
+
+import (
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// --- Explicit Resource Management ---
+//
+// A statement list declaring resources with `using` or `await using` is
+// compiled as if its statements were the try block of a try/catch/finally:
+//
+//	OpNewDisposeScope scope
+//	try {
+//	    ...statements; each using declaration adds its value to scope
+//	} catch (err) {
+//	    hasErr = true
+//	} finally {
+//	    OpDisposeResources scope err hasErr   ; rethrows err, or the disposals' errors
+//	}
+//
+// The finally part goes through the same FinallyContext and ExceptionTable
+// machinery as compileTryStatement, so return, break and continue leaving the
+// list dispose of its resources too. With await using, the disposals are
+// awaited (OpDisposeResourcesAsync, then OpAwait).
+
+// usingDeclarations returns the first of the using declarations stmts
+// declares resources with, if any, and whether any of them is an await using
+func usingDeclarations(stmts []parser.Statement) (first *parser.ConstStatement, await bool) {
+	for _, stmt := range stmts {
+		if s, ok := stmt.(*parser.ConstStatement); ok && s.Using {
+			if first == nil {
+				first = s
+			}
+			await = await || s.Await
+		}
+	}
+	return first, await
+}
+
+// withDisposeScope runs compile, which compiles the statement list stmts, in
+// a dispose region if the list declares resources
+func (c *Compiler) withDisposeScope(stmts []parser.Statement, compile func() errors.PaseratiError) errors.PaseratiError {
+	first, await := usingDeclarations(stmts)
+	if first == nil {
+		return compile()
+	}
+	return c.compileDisposeRegion(await, first.Token.Line, compile)
+}
+
+// compileDisposeRegion compiles body in a region disposing of the resources
+// added to its dispose scope, the innermost of c.disposeScopes while body is
+// compiled, however the region is left
+func (c *Compiler) compileDisposeRegion(await bool, line int, body func() errors.PaseratiError) errors.PaseratiError {
+	scopeReg := c.regAlloc.Alloc()
+	errReg := c.regAlloc.Alloc()
+	hasErrReg := c.regAlloc.Alloc()
+	defer func() {
+		c.regAlloc.Free(hasErrReg)
+		c.regAlloc.Free(errReg)
+		c.regAlloc.Free(scopeReg)
+	}()
+	c.emitOpCode(vm.OpNewDisposeScope, line)
+	c.emitByte(byte(scopeReg))
+	c.emitLoadUndefined(errReg, line)
+	c.emitLoadFalse(hasErrReg, line)
+
+	// Like a try block with a finally: break/continue/return go through the
+	// disposals, and there is no tail call out of the region
+	finallyCtx := &FinallyContext{
+		FinallyPC:                 -1,
+		JumpToFinallyPlaceholders: make([]int, 0),
+		LoopStackDepthAtCreation:  len(c.loopContextStack),
+	}
+	c.finallyContextStack = append(c.finallyContextStack, finallyCtx)
+	c.disposeScopes = append(c.disposeScopes, scopeReg)
+	c.tryFinallyDepth++
+	c.tryDepth++
+	prevInFinally := c.inFinallyBlock
+	c.inFinallyBlock = false
+
+	tryStart := len(c.chunk.Code)
+	err := body()
+
+	c.inFinallyBlock = prevInFinally
+	c.tryDepth--
+	c.tryFinallyDepth--
+	c.disposeScopes = c.disposeScopes[:len(c.disposeScopes)-1]
+	c.finallyContextStack = c.finallyContextStack[:len(c.finallyContextStack)-1]
+	if err != nil {
+		return err
+	}
+
+	normalExitJump := c.emitPlaceholderJump(vm.OpJump, 0, line)
+	tryEnd := len(c.chunk.Code)
+
+	// The catch part keeps the error the region is left with, in errReg, for
+	// the disposals to rethrow or suppress
+	catchPC := len(c.chunk.Code)
+	c.emitLoadTrue(hasErrReg, line)
+
+	finallyPC := len(c.chunk.Code)
+	c.patchJump(normalExitJump)
+	for _, placeholderPos := range finallyCtx.JumpToFinallyPlaceholders {
+		c.patchJumpToTarget(placeholderPos, finallyPC)
+	}
+	if await {
+		promiseReg := c.regAlloc.Alloc()
+		c.emitOpCode(vm.OpDisposeResourcesAsync, line)
+		c.emitByte(byte(promiseReg))
+		c.emitByte(byte(scopeReg))
+		c.emitByte(byte(errReg))
+		c.emitByte(byte(hasErrReg))
+		c.emitOpCode(vm.OpAwait, line)
+		c.emitByte(byte(promiseReg))
+		c.emitByte(byte(promiseReg))
+		c.regAlloc.Free(promiseReg)
+	} else {
+		c.emitOpCode(vm.OpDisposeResources, line)
+		c.emitByte(byte(scopeReg))
+		c.emitByte(byte(errReg))
+		c.emitByte(byte(hasErrReg))
+	}
+	c.emitHandlePendingAction(line)
+
+	c.chunk.ExceptionTable = append(c.chunk.ExceptionTable, vm.ExceptionHandler{
+		TryStart:   tryStart,
+		TryEnd:     tryEnd,
+		HandlerPC:  catchPC,
+		CatchReg:   int(errReg),
+		IsCatch:    true,
+		IsFinally:  false,
+		FinallyReg: -1,
+	}, vm.ExceptionHandler{
+		TryStart:   tryStart,
+		TryEnd:     finallyPC,
+		HandlerPC:  finallyPC,
+		CatchReg:   -1,
+		IsCatch:    false,
+		IsFinally:  true,
+		FinallyReg: -1,
+	})
+	return nil
+}
+
+// compileUsingDeclaration compiles a using or await using declaration: a
+// const declaration whose values are added, one after the other, to the
+// innermost dispose scope
+func (c *Compiler) compileUsingDeclaration(node *parser.ConstStatement, hint Register) (Register, errors.PaseratiError) {
+	if len(c.disposeScopes) == 0 {
+		return BadRegister, NewCompileError(node, "'using' declarations are only allowed in blocks, function bodies, modules and for-of heads")
+	}
+	for _, declarator := range node.Declarations {
+		decl := &parser.ConstStatement{Token: node.Token, Declarations: []*parser.VarDeclarator{declarator}}
+		if _, err := c.compileConstStatement(decl, hint); err != nil {
+			return BadRegister, err
+		}
+		valueReg := c.regAlloc.Alloc()
+		if _, err := c.compileNode(declarator.Name, valueReg); err != nil {
+			c.regAlloc.Free(valueReg)
+			return BadRegister, err
+		}
+		c.emitAddDisposable(node.Await, valueReg, declarator.Name.Token.Line)
+		c.regAlloc.Free(valueReg)
+	}
+	return BadRegister, nil
+}
+
+// emitAddDisposable adds the value in valueReg to the innermost dispose scope
+func (c *Compiler) emitAddDisposable(await bool, valueReg Register, line int) {
+	if await {
+		c.emitOpCode(vm.OpAddAsyncDisposable, line)
+	} else {
+		c.emitOpCode(vm.OpAddDisposable, line)
+	}
+	c.emitByte(byte(c.disposeScopes[len(c.disposeScopes)-1]))
+	c.emitByte(byte(valueReg))
+}
@@ -369,8 +369,9 @@ type Compiler struct {
 	globalCount int
 	// Unified heap allocator for coordinating global indices across modules
 	heapAlloc *HeapAlloc
-	// line tracking
-	line int
+	// Position of the node being compiled, attributed to the bytecode emitted for it
+	line   int
+	column int
 	// Anonymous class counter for generating unique names
 	anonymousClassCounter int
 
@@ -396,6 +397,10 @@ type Compiler struct {
 	withBlockDepth          int               // Total with blocks (inherited, for unresolved var lookup)
 	currentFuncWithDepth    int               // With blocks in current function only (NOT inherited, for local var lookup)
 
+	// Registers holding the dispose scopes of the statement lists being
+	// compiled that declare using resources, innermost last (see compile_using.go)
+	disposeScopes []Register
+
 	// --- Strict Mode Inheritance ---
 	inheritedStrictMode bool // Inherited strict mode from eval context
 
@@ -448,6 +453,9 @@ type Compiler struct {
 	// --- Script Mode for Function Constructor ---
 	forceScriptMode bool // True when compiling code from Function() constructor (import.meta not allowed)
 
+	// --- Debugger Support ---
+	debugInfo bool // True to give every function a scope descriptor and compile debugger statements to OpDebug
+
 	// --- Parameter Names Tracking ---
 	parameterNames map[string]bool // Set of parameter names for current function (for var hoisting)
 
@@ -473,9 +481,15 @@ type Compiler struct {
 	// Per ECMAScript, computed property keys must be evaluated at class definition time, not instantiation
 	computedFieldKeyVars map[int]string
 
+	// --- Auto-Accessors ---
+	// Maps property index to the getter, setter and decorator inits of accessor fields
+	autoAccessors map[int]*autoAccessor
+
 	// --- Decorator Support ---
 	// Pre-evaluated decorators for the current class being compiled
 	currentClassDecorators []*decoratorInfo
+	// The metadata object shared by the current class's decorators
+	currentClassMetadata Register
 }
 
 // NewCompiler creates a new *top-level* Compiler.
@@ -612,6 +626,13 @@ func (c *Compiler) SetForceScriptMode(force bool) {
 	c.forceScriptMode = force
 }
 
+// SetDebugInfo enables debug info for debuggers: every function gets a scope
+// descriptor mapping its registers to variable names, and debugger statements
+// compile to OpDebug traps instead of no-ops
+func (c *Compiler) SetDebugInfo(enabled bool) {
+	c.debugInfo = enabled
+}
+
 // syncImportsFromTypeChecker synchronizes import information from the type checker
 // This ensures that imports processed during type checking are available during compilation
 func (c *Compiler) syncImportsFromTypeChecker() {
@@ -704,6 +725,7 @@ func newFunctionCompiler(enclosingCompiler *Compiler) *Compiler {
 		privateBrandStack:       enclosingCompiler.privateBrandStack, // Inherited so nested can access outer class fields
 		// Inherit script mode from Function() constructor - import.meta not allowed in nested functions
 		forceScriptMode:         enclosingCompiler.forceScriptMode,
+		debugInfo:               enclosingCompiler.debugInfo,
 	}
 }
 
@@ -816,6 +838,7 @@ func (c *Compiler) Compile(node parser.Node) (*vm.Chunk, []errors.PaseratiError)
 	// Reset per-compilation state to avoid leaking state between eval calls
 	c.loopContextStack = nil
 	c.finallyContextStack = nil
+	c.disposeScopes = nil
 	c.errors = nil
 	c.inFinallyBlock = false
 	c.tryFinallyDepth = 0
@@ -1096,7 +1119,7 @@ func (c *Compiler) Compile(node parser.Node) (*vm.Chunk, []errors.PaseratiError)
 
 	// Generate scope descriptor for module/script-level code if it contains direct eval
 	// This is needed so that eval code can access local variables in the caller's scope
-	if c.hasDirectEval && c.enclosing == nil {
+	if (c.hasDirectEval || c.debugInfo) && c.enclosing == nil {
 		c.chunk.ScopeDesc = c.generateScopeDescriptor()
 		debugPrintf("// [Compiler] Module/script has direct eval, generated scope descriptor with %d locals\n",
 			len(c.chunk.ScopeDesc.LocalNames))
@@ -1145,8 +1168,12 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 		panic("Compiler internal error: typeChecker is nil during compileNode")
 	}
 
-	if c.line != parser.GetTokenFromNode(node).Line {
-		c.line = parser.GetTokenFromNode(node).Line
+	// Bytecode emitted for this node, but not its children, is attributed to
+	// its position. Nodes without one inherit their parent's.
+	if line, column := nodePosition(node); line > 0 {
+		outerLine, outerColumn := c.line, c.column
+		c.line, c.column = line, column
+		defer func() { c.line, c.column = outerLine, outerColumn }()
 		debugPrintf("// DEBUG compiling line %d (%s)\n", c.line, c.compilingFuncName)
 	}
 
@@ -1162,31 +1189,37 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 	case *parser.Program:
 		debugPrintf("// DEBUG Program: Starting statement loop.\n") // <<< ADDED
 		hasResult := false                                          // Track whether any statement produced a value
-		for i, stmt := range node.Statements {
-			debugPrintf("// DEBUG Program: Before compiling statement %d (%T).\n", i, stmt) // <<< ADDED
-			tlReg, err := c.compileNode(stmt, hint)
-			if err != nil {
-				debugPrintf("// DEBUG Program: Error compiling statement %d: %v\n", i, err) // <<< ADDED
-				return BadRegister, err                                                     // Propagate errors up
-			}
+		err := c.withDisposeScope(node.Statements, func() errors.PaseratiError {
+			for i, stmt := range node.Statements {
+				debugPrintf("// DEBUG Program: Before compiling statement %d (%T).\n", i, stmt) // <<< ADDED
+				tlReg, err := c.compileNode(stmt, hint)
+				if err != nil {
+					debugPrintf("// DEBUG Program: Error compiling statement %d: %v\n", i, err) // <<< ADDED
+					return err                                                                  // Propagate errors up
+				}
 
-			// <<< ADDED vvv
-			if c.enclosing == nil {
-				debugPrintf("// DEBUG Program: After compiling statement %d (%T). Result: R%d\n", i, stmt, tlReg)
-				// For top level, be conservative - don't free registers between statements
-				// The VM will handle cleanup when the program ends
-				// Track the most recent statement result to be the script's final result
-				if tlReg != BadRegister {
-					hint = tlReg
-					hasResult = true
+				// <<< ADDED vvv
+				if c.enclosing == nil {
+					debugPrintf("// DEBUG Program: After compiling statement %d (%T). Result: R%d\n", i, stmt, tlReg)
+					// For top level, be conservative - don't free registers between statements
+					// The VM will handle cleanup when the program ends
+					// Track the most recent statement result to be the script's final result
+					if tlReg != BadRegister {
+						hint = tlReg
+						hasResult = true
+					}
+				} else {
+					// Inside function body - be more aggressive about freeing registers
+					// But only free if we have more than a reasonable number allocated
+					// DISABLED: Focus on expression-level freeing instead
+					debugPrintf("// DEBUG Program: Inside function, but inter-statement freeing disabled\n")
 				}
-			} else {
-				// Inside function body - be more aggressive about freeing registers
-				// But only free if we have more than a reasonable number allocated
-				// DISABLED: Focus on expression-level freeing instead
-				debugPrintf("// DEBUG Program: Inside function, but inter-statement freeing disabled\n")
+				// <<< ADDED ^^^
 			}
-			// <<< ADDED ^^^
+			return nil
+		})
+		if err != nil {
+			return BadRegister, err
 		}
 		debugPrintf("// DEBUG Program: Finished statement loop. Final result: R%d, hasResult: %v\n", hint, hasResult) // <<< ADDED
 		// If no statement produced a value, the hint register may contain stale data
@@ -1609,18 +1642,23 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 		// Compile directly with hint so nested try-finally with break/continue can correctly
 		// propagate completion values
 		hasCompletionValue := false
-		for stmtIdx, stmt := range node.Statements {
-			_ = stmtIdx // Suppress unused warning
-			debugPrintf("// [BlockStatement] Compiling statement %d/%d: %T\n", stmtIdx, len(node.Statements), stmt)
-			resultReg, err := c.compileNode(stmt, hint)
-			if err != nil {
-				debugPrintf("// [BlockStatement] ERROR at statement %d: %v\n", stmtIdx, err)
-				return BadRegister, err
-			}
-			// If the statement produced a value, it's already in hint
-			if resultReg != BadRegister {
-				hasCompletionValue = true
+		err := c.withDisposeScope(node.Statements, func() errors.PaseratiError {
+			for stmtIdx, stmt := range node.Statements {
+				debugPrintf("// [BlockStatement] Compiling statement %d/%d: %T\n", stmtIdx, len(node.Statements), stmt)
+				resultReg, err := c.compileNode(stmt, hint)
+				if err != nil {
+					debugPrintf("// [BlockStatement] ERROR at statement %d: %v\n", stmtIdx, err)
+					return err
+				}
+				// If the statement produced a value, it's already in hint
+				if resultReg != BadRegister {
+					hasCompletionValue = true
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			return BadRegister, err
 		}
 
 		// Restore previous scope if we created an enclosed one
@@ -1652,6 +1690,10 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 
 		return BadRegister, nil
 
+	case *parser.AmbientModuleDeclaration, *parser.GlobalDeclaration, *parser.ExportAssignment:
+		// Ambient declarations only exist for type checking, ignore in compiler.
+		return BadRegister, nil
+
 	case *parser.ExpressionStatement:
 		debugPrintf("// DEBUG ExprStmt: Compiling expression %T.\n", node.Expression)
 
@@ -1810,8 +1852,8 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 		return c.compileThrowStatement(node, hint)
 
 	case *parser.DebuggerStatement:
-		// debugger statement is a no-op at runtime
-		return c.compileDebuggerStatement(hint)
+		// debugger statement is a no-op at runtime unless compiled with debug info
+		return c.compileDebuggerStatement(node, hint)
 
 	case *parser.WithStatement:
 		return c.compileWithStatement(node, hint)
@@ -1889,7 +1931,7 @@ func (c *Compiler) compileNode(node parser.Node, hint Register) (Register, error
 		// Super should only appear in member expressions or call expressions
 		// For now, keep the OpLoadSuper emission for edge cases
 		// TODO: Investigate if this code path is ever actually reached
-		c.chunk.WriteOpCode(vm.OpLoadSuper, node.Token.Line)
+		c.emitOpCode(vm.OpLoadSuper, node.Token.Line)
 		c.chunk.EmitByte(byte(hint))
 		return hint, nil
 
@@ -2492,6 +2534,11 @@ func (c *Compiler) compileShorthandMethod(node *parser.ShorthandMethod, nameHint
 	regSize := functionCompiler.regAlloc.MaxRegs()
 	functionChunk.NumSpillSlots = int(functionCompiler.nextSpillSlot) // Set spill slots needed
 
+	// Scope descriptor so debuggers can name the method's registers
+	if functionCompiler.debugInfo {
+		functionChunk.ScopeDesc = functionCompiler.generateScopeDescriptor()
+	}
+
 	// 9. Create the bytecode.Function object
 	var funcName string
 	if nameHint != "" {
@@ -4701,6 +4748,19 @@ func (c *Compiler) compileClassExpression(node *parser.ClassDeclaration, hint Re
 		return BadRegister, err
 	}
 
+	// Create the getters and setters of accessor fields
+	prevAutoAccessors := c.autoAccessors
+	defer func() { c.autoAccessors = prevAutoAccessors }()
+	if err := c.compileAutoAccessors(node, nil); err != nil {
+		if prevSymbolTable != nil {
+			c.currentSymbolTable = prevSymbolTable
+		}
+		if needToFreeSuperReg {
+			c.regAlloc.Free(superConstructorReg)
+		}
+		return BadRegister, err
+	}
+
 	// 1. Create constructor function
 	constructorReg, err := c.compileConstructor(node, BadRegister)
 	if err != nil {
@@ -3,13 +3,28 @@ package compiler
 import (
 	"fmt"
 
+	"github.com/nooga/paserati/pkg/parser"
 	"github.com/nooga/paserati/pkg/vm"
 )
 
 // --- Bytecode Emission Helpers ---
 
 func (c *Compiler) emitOpCode(op vm.OpCode, line int) {
-	c.chunk.WriteOpCode(op, line)
+	// The column is only known for the line of the node being compiled
+	column := 0
+	if line == c.line {
+		column = c.column
+	}
+	c.chunk.WriteOpCodeAt(op, line, column)
+}
+
+// nodePosition returns the line and column of a node's representative token,
+// or 0, 0 if it has none
+func nodePosition(node parser.Node) (int, int) {
+	if tok := parser.GetTokenFromNode(node); tok != nil {
+		return tok.Line, tok.Column
+	}
+	return 0, 0
 }
 
 func (c *Compiler) emitByte(b byte) {
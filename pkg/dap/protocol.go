@@ -0,0 +1,188 @@
+// Package dap implements a Debug Adapter Protocol server for Paserati, so
+// that editors such as VS Code can debug scripts. It maps DAP requests onto
+// the driver's Debugger; see docs/DEBUGGER_DESIGN.md for how the VM pauses.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+
+	"github.com/nooga/paserati/pkg/jsonrpc"
+)
+
+// Message is a protocol message as read from the wire: a request, a response
+// or an event. Only the fields of its Type are set.
+type Message struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"` // "request", "response" or "event"
+
+	// Requests and responses
+	Command   string          `json:"command,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+
+	// Responses
+	RequestSeq int    `json:"request_seq,omitempty"`
+	Success    bool   `json:"success,omitempty"`
+	Message    string `json:"message,omitempty"`
+
+	// Events
+	Event string `json:"event,omitempty"`
+
+	// Responses and events
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// request is an outgoing request, as sent by clients
+type request struct {
+	Seq       int    `json:"seq"`
+	Type      string `json:"type"`
+	Command   string `json:"command"`
+	Arguments any    `json:"arguments,omitempty"`
+}
+
+// response is an outgoing response. Success is always present on the wire.
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+// event is an outgoing event
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// ReadMessage reads one message, framed by a Content-Length header
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	var msg Message
+	if err := jsonrpc.ReadMessage(r, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Capabilities are the optional protocol features the server supports
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+// InitializeArguments are the arguments of the initialize request
+type InitializeArguments struct {
+	ClientID        string `json:"clientID,omitempty"`
+	AdapterID       string `json:"adapterID"`
+	LinesStartAt1   *bool  `json:"linesStartAt1,omitempty"`
+	ColumnsStartAt1 *bool  `json:"columnsStartAt1,omitempty"`
+}
+
+// LaunchArguments are the arguments of the launch request
+type LaunchArguments struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args,omitempty"`
+	StopOnEntry bool     `json:"stopOnEntry,omitempty"`
+	NoTypeCheck bool     `json:"noTypeCheck,omitempty"`
+}
+
+// Source identifies a script file
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// SourceBreakpoint is a breakpoint requested by setBreakpoints
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+// SetBreakpointsArguments are the arguments of the setBreakpoints request
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+// Breakpoint is a breakpoint as reported to the client
+type Breakpoint struct {
+	ID       int     `json:"id"`
+	Verified bool    `json:"verified"`
+	Line     int     `json:"line,omitempty"`
+	Source   *Source `json:"source,omitempty"`
+}
+
+// Thread is a thread of the debugged program; Paserati has one
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// StackTraceArguments are the arguments of the stackTrace request
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame,omitempty"`
+	Levels     int `json:"levels,omitempty"`
+}
+
+// StackFrame is a frame reported by stackTrace
+type StackFrame struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Source *Source `json:"source,omitempty"`
+	Line   int     `json:"line"`
+	Column int     `json:"column"`
+}
+
+// ScopesArguments are the arguments of the scopes request
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+// Scope is a group of variables of a frame
+type Scope struct {
+	Name               string `json:"name"`
+	PresentationHint   string `json:"presentationHint,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+// VariablesArguments are the arguments of the variables request
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+// Variable is a named value. A non-zero VariablesReference means it has
+// children, fetched with another variables request.
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// EvaluateArguments are the arguments of the evaluate request
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId,omitempty"`
+	Context    string `json:"context,omitempty"`
+}
+
+// StoppedEventBody is the body of the stopped event
+type StoppedEventBody struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	HitBreakpointIDs  []int  `json:"hitBreakpointIds,omitempty"`
+}
+
+// OutputEventBody is the body of the output event
+type OutputEventBody struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
@@ -0,0 +1,491 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/jsonrpc"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// threadID is the ID of the program's only thread
+const threadID = 1
+
+// Server serves one debug session over a connection. The client launches a
+// program with the launch request; the program starts once configuration is
+// done and runs under a driver.Debugger.
+type Server struct {
+	// NewSession creates the session a launched program runs in, given the
+	// program's path and arguments. It defaults to driver.NewPaserati.
+	NewSession func(program string, args []string) *driver.Paserati
+
+	r *bufio.Reader
+	w io.Writer
+
+	// mu serializes writes. Requests that resume the program hold it until
+	// their response is written, so it precedes the events they cause.
+	mu  sync.Mutex
+	seq int
+
+	lineBase   int // 0 when the client's lines start at 1, otherwise 1
+	columnBase int // Likewise for columns
+
+	p          *driver.Paserati
+	debugger   *driver.Debugger
+	program    string
+	source     string
+	launch     LaunchArguments
+	configured bool
+	started    bool
+	exited     chan struct{} // Closed when the program's events are drained
+
+	handles []variableHandle // Targets of variablesReference n at index n-1
+}
+
+// variableHandle is what a variablesReference expands: a scope of a frame,
+// or the properties of a value
+type variableHandle struct {
+	frame int
+	scope string
+	value vm.Value
+}
+
+// NewServer returns a server that talks to the client over rw
+func NewServer(rw io.ReadWriter) *Server {
+	return &Server{
+		NewSession: func(string, []string) *driver.Paserati { return driver.NewPaserati() },
+		r:          bufio.NewReader(rw),
+		w:          rw,
+	}
+}
+
+// Serve handles requests until the client disconnects or the connection
+// closes. A program still running is terminated.
+func (s *Server) Serve() error {
+	defer s.shutdown()
+	for {
+		msg, err := ReadMessage(s.r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Type != "request" {
+			continue
+		}
+		if msg.Command == "disconnect" {
+			s.shutdown()
+			s.respond(msg, nil, nil)
+			return nil
+		}
+		s.handle(msg)
+	}
+}
+
+// Output reports program output, such as console logging, to the client
+func (s *Server) Output(category string, text string) {
+	s.sendEvent("output", OutputEventBody{Category: category, Output: text})
+}
+
+// handle dispatches a request
+func (s *Server) handle(req *Message) {
+	var body any
+	var err error
+	switch req.Command {
+	case "initialize":
+		body, err = s.initialize(req)
+		s.respond(req, body, err)
+	case "launch":
+		// Configuration requests are invited once there is a program to
+		// configure
+		err = s.launchProgram(req)
+		s.respond(req, nil, err)
+		if err == nil {
+			s.sendEvent("initialized", nil)
+		}
+	case "configurationDone":
+		s.configured = true
+		s.mu.Lock()
+		err = s.start()
+		s.respondLocked(req, nil, err)
+		s.mu.Unlock()
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req)
+		s.respond(req, body, err)
+	case "threads":
+		s.respond(req, map[string]any{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil)
+	case "stackTrace":
+		body, err = s.stackTrace(req)
+		s.respond(req, body, err)
+	case "scopes":
+		body, err = s.scopes(req)
+		s.respond(req, body, err)
+	case "variables":
+		body, err = s.variables(req)
+		s.respond(req, body, err)
+	case "evaluate":
+		body, err = s.evaluate(req)
+		s.respond(req, body, err)
+	case "continue":
+		s.resume(req, (*driver.Debugger).Continue, map[string]any{"allThreadsContinued": true})
+	case "next":
+		s.resume(req, (*driver.Debugger).StepOver, nil)
+	case "stepIn":
+		s.resume(req, (*driver.Debugger).StepIn, nil)
+	case "stepOut":
+		s.resume(req, (*driver.Debugger).StepOut, nil)
+	case "terminate":
+		if s.debugger != nil {
+			s.debugger.Terminate()
+		}
+		s.respond(req, nil, nil)
+	default:
+		s.respond(req, nil, fmt.Errorf("%s is not supported", req.Command))
+	}
+}
+
+func (s *Server) initialize(req *Message) (any, error) {
+	var args InitializeArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	if args.LinesStartAt1 != nil && !*args.LinesStartAt1 {
+		s.lineBase = 1
+	}
+	if args.ColumnsStartAt1 != nil && !*args.ColumnsStartAt1 {
+		s.columnBase = 1
+	}
+	return Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsEvaluateForHovers:        true,
+		SupportsTerminateRequest:         true,
+	}, nil
+}
+
+// launchProgram prepares the program's session. It starts running once the
+// client is done configuring breakpoints.
+func (s *Server) launchProgram(req *Message) error {
+	if s.debugger != nil {
+		return fmt.Errorf("a program is already launched")
+	}
+	var args LaunchArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return err
+	}
+	if args.Program == "" {
+		return fmt.Errorf("launch: no program given")
+	}
+	source, err := os.ReadFile(args.Program)
+	if err != nil {
+		return fmt.Errorf("launch: %w", err)
+	}
+	s.launch = args
+	s.program = args.Program
+	s.source = string(source)
+	s.p = s.NewSession(args.Program, args.Args)
+	if args.NoTypeCheck {
+		s.p.SetSkipTypeCheck(true)
+	}
+	s.debugger = s.p.NewDebugger()
+	if s.configured {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.start()
+	}
+	return nil
+}
+
+// start runs the launched program once both launch and configurationDone
+// were received. It is called with mu held.
+func (s *Server) start() error {
+	if s.debugger == nil || !s.configured || s.started {
+		return nil
+	}
+	if err := s.debugger.Start(s.source, s.program, s.launch.StopOnEntry); err != nil {
+		return err
+	}
+	s.started = true
+	s.exited = make(chan struct{})
+	go s.forwardEvents()
+	return nil
+}
+
+// forwardEvents reports the debugger's events to the client
+func (s *Server) forwardEvents() {
+	defer close(s.exited)
+	for ev := range s.debugger.Events() {
+		switch ev.Kind {
+		case driver.DebugStopped:
+			body := StoppedEventBody{
+				Reason:            string(ev.Reason),
+				ThreadID:          threadID,
+				AllThreadsStopped: true,
+				HitBreakpointIDs:  ev.Breakpoints,
+			}
+			if ev.Reason == driver.StopDebuggerStatement {
+				body.Reason = "pause"
+				body.Description = "Paused on debugger statement"
+			}
+			s.sendEvent("stopped", body)
+		case driver.DebugBreakpointChanged:
+			s.sendEvent("breakpoint", map[string]any{"reason": "changed", "breakpoint": s.breakpoint(ev.Breakpoint)})
+		case driver.DebugExited:
+			exitCode := 0
+			if len(ev.Errors) > 0 {
+				exitCode = 1
+				for _, err := range ev.Errors {
+					s.Output("stderr", err.Error()+"\n")
+				}
+			}
+			s.sendEvent("exited", map[string]any{"exitCode": exitCode})
+			s.sendEvent("terminated", nil)
+		}
+	}
+}
+
+// shutdown terminates a running program, waits for it to exit and releases
+// its session
+func (s *Server) shutdown() {
+	if s.started {
+		s.debugger.Terminate()
+		<-s.exited
+	}
+	if s.p != nil {
+		s.p.Cleanup()
+		s.p = nil
+	}
+}
+
+func (s *Server) setBreakpoints(req *Message) (any, error) {
+	if s.debugger == nil {
+		return nil, fmt.Errorf("setBreakpoints: no program launched")
+	}
+	var args SetBreakpointsArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	lines := make([]int, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line + s.lineBase
+	}
+	result := make([]Breakpoint, 0, len(lines))
+	for _, bp := range s.debugger.SetBreakpoints(args.Source.Path, lines) {
+		result = append(result, s.breakpoint(bp))
+	}
+	return map[string]any{"breakpoints": result}, nil
+}
+
+// breakpoint converts a debugger breakpoint for the client
+func (s *Server) breakpoint(bp driver.Breakpoint) Breakpoint {
+	line := bp.Line
+	if bp.Verified {
+		line = bp.ResolvedLine
+	}
+	return Breakpoint{
+		ID:       bp.ID,
+		Verified: bp.Verified,
+		Line:     line - s.lineBase,
+		Source:   &Source{Name: filepath.Base(bp.Path), Path: bp.Path},
+	}
+}
+
+func (s *Server) stackTrace(req *Message) (any, error) {
+	var args StackTraceArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	frames, err := d.Stack()
+	if err != nil {
+		return nil, err
+	}
+	total := len(frames)
+	frames = frames[min(args.StartFrame, total):]
+	if args.Levels > 0 && args.Levels < len(frames) {
+		frames = frames[:args.Levels]
+	}
+	result := make([]StackFrame, len(frames))
+	for i, frame := range frames {
+		result[i] = StackFrame{
+			// Frame IDs are depths plus one, zero meaning no frame
+			ID:     frame.Index + 1,
+			Name:   frame.Function,
+			Line:   frame.Line - s.lineBase,
+			Column: max(frame.Column, 1) - s.columnBase,
+		}
+		if frame.Path != "" {
+			result[i].Source = &Source{Name: filepath.Base(frame.Path), Path: frame.Path}
+		}
+	}
+	return map[string]any{"stackFrames": result, "totalFrames": total}, nil
+}
+
+func (s *Server) scopes(req *Message) (any, error) {
+	var args ScopesArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	frame := max(args.FrameID-1, 0)
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	// Fetching the scopes checks the frame exists while the program is paused
+	scopes, err := d.Scopes(frame)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Scope, len(scopes))
+	for i, scope := range scopes {
+		hint := "locals"
+		if scope.Name == "Global" {
+			hint = ""
+		}
+		result[i] = Scope{
+			Name:               scope.Name,
+			PresentationHint:   hint,
+			VariablesReference: s.newHandle(variableHandle{frame: frame, scope: scope.Name}),
+		}
+	}
+	return map[string]any{"scopes": result}, nil
+}
+
+func (s *Server) variables(req *Message) (any, error) {
+	var args VariablesArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	if args.VariablesReference < 1 || args.VariablesReference > len(s.handles) {
+		return nil, fmt.Errorf("unknown variablesReference %d", args.VariablesReference)
+	}
+	handle := s.handles[args.VariablesReference-1]
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, err
+	}
+
+	var vars []driver.DebugVariable
+	if handle.scope != "" {
+		scopes, err := d.Scopes(handle.frame)
+		if err != nil {
+			return nil, err
+		}
+		for _, scope := range scopes {
+			if scope.Name == handle.scope {
+				vars = scope.Variables
+			}
+		}
+	} else if vars, err = d.Properties(handle.value); err != nil {
+		return nil, err
+	}
+	result := make([]Variable, len(vars))
+	for i, v := range vars {
+		result[i] = s.variable(v.Name, v.Value)
+	}
+	return map[string]any{"variables": result}, nil
+}
+
+func (s *Server) evaluate(req *Message) (any, error) {
+	var args EvaluateArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return nil, err
+	}
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	value, err := d.Evaluate(max(args.FrameID-1, 0), args.Expression)
+	var jsErr *driver.JSError
+	if stderrors.As(err, &jsErr) {
+		return nil, fmt.Errorf("%s", jsErr.Message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := s.variable("", value)
+	return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+}
+
+// variable describes a value for the client, giving objects and arrays a
+// handle to expand them with
+func (s *Server) variable(name string, value vm.Value) Variable {
+	v := Variable{Name: name, Value: value.Inspect(), Type: value.TypeName()}
+	if value.Type() == vm.TypeObject || value.Type() == vm.TypeArray {
+		v.VariablesReference = s.newHandle(variableHandle{value: value})
+	}
+	return v
+}
+
+func (s *Server) newHandle(handle variableHandle) int {
+	s.handles = append(s.handles, handle)
+	return len(s.handles)
+}
+
+// resume continues the paused program. Variable handles only live while the
+// program is paused.
+func (s *Server) resume(req *Message, step func(*driver.Debugger) error, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.pausedDebugger()
+	if err == nil {
+		err = step(d)
+	}
+	if err == nil {
+		s.handles = nil
+	}
+	s.respondLocked(req, body, err)
+}
+
+// pausedDebugger returns the debugger for requests that need a paused
+// program. Its methods report driver.ErrNotPaused when the program is not.
+func (s *Server) pausedDebugger() (*driver.Debugger, error) {
+	if !s.started {
+		return nil, fmt.Errorf("program not started")
+	}
+	return s.debugger, nil
+}
+
+func (s *Server) respond(req *Message, body any, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respondLocked(req, body, err)
+}
+
+func (s *Server) respondLocked(req *Message, body any, err error) {
+	resp := response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	s.seq++
+	resp.Seq = s.seq
+	jsonrpc.WriteMessage(s.w, resp)
+}
+
+func (s *Server) sendEvent(name string, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	jsonrpc.WriteMessage(s.w, event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+// decodeArguments decodes the arguments of a request into args
+func decodeArguments(req *Message, args any) error {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Arguments, args); err != nil {
+		return fmt.Errorf("%s: bad arguments: %w", req.Command, err)
+	}
+	return nil
+}
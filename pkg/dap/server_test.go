@@ -0,0 +1,349 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/jsonrpc"
+)
+
+const testProgram = `let total = 0;
+function add(a: number, b: number): number {
+  let sum = a + b;
+  return sum;
+}
+const point = { x: 1, y: [2, 3] };
+for (let i = 0; i < 3; i++) {
+  total = add(total, i);
+}
+console.log(total);
+`
+
+// testClient is a scripted DAP client talking to a Server over a pipe
+type testClient struct {
+	t        *testing.T
+	conn     net.Conn
+	seq      int
+	messages chan *Message
+	events   []*Message // Events received while waiting for a response
+	served   chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	c := &testClient{t: t, conn: clientConn, messages: make(chan *Message, 64), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewServer(serverConn).Serve()
+		serverConn.Close()
+	}()
+	go func() {
+		r := bufio.NewReader(clientConn)
+		for {
+			msg, err := ReadMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { clientConn.Close() })
+	return c
+}
+
+// send sends a request and returns its sequence number
+func (c *testClient) send(command string, arguments any) int {
+	c.t.Helper()
+	c.seq++
+	if err := jsonrpc.WriteMessage(c.conn, request{Seq: c.seq, Type: "request", Command: command, Arguments: arguments}); err != nil {
+		c.t.Fatalf("sending %s: %v", command, err)
+	}
+	return c.seq
+}
+
+// next returns the next message from the server
+func (c *testClient) next() *Message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatalf("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timed out waiting for the server")
+	}
+	return nil
+}
+
+// response waits for the next response, setting aside events that arrive
+// first
+func (c *testClient) response() *Message {
+	c.t.Helper()
+	for {
+		msg := c.next()
+		if msg.Type != "event" {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+// request sends a request and decodes the body of its successful response
+func (c *testClient) request(command string, arguments any, body any) {
+	c.t.Helper()
+	seq := c.send(command, arguments)
+	msg := c.response()
+	if msg.Type != "response" || msg.RequestSeq != seq || msg.Command != command {
+		c.t.Fatalf("expected the response to %s, got %+v", command, msg)
+	}
+	if !msg.Success {
+		c.t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(msg.Body, body); err != nil {
+			c.t.Fatalf("decoding %s response: %v", command, err)
+		}
+	}
+}
+
+// fail sends a request and returns the error message of its failed response
+func (c *testClient) fail(command string, arguments any) string {
+	c.t.Helper()
+	seq := c.send(command, arguments)
+	msg := c.response()
+	if msg.Type != "response" || msg.RequestSeq != seq || msg.Success {
+		c.t.Fatalf("expected %s to fail, got %+v", command, msg)
+	}
+	return msg.Message
+}
+
+// event waits for the named event, skipping others, and decodes its body
+func (c *testClient) event(name string, body any) {
+	c.t.Helper()
+	for {
+		var msg *Message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type == "event" && msg.Event == name {
+			if body != nil {
+				if err := json.Unmarshal(msg.Body, body); err != nil {
+					c.t.Fatalf("decoding %s event: %v", name, err)
+				}
+			}
+			return
+		}
+		if msg.Type == "response" {
+			c.t.Fatalf("unexpected response while waiting for %s: %+v", name, msg)
+		}
+	}
+}
+
+// stopped waits for a stopped event and returns the innermost frame
+func (c *testClient) stopped(reason string) StackFrame {
+	c.t.Helper()
+	var stop StoppedEventBody
+	c.event("stopped", &stop)
+	if stop.Reason != reason || stop.ThreadID != threadID {
+		c.t.Fatalf("expected a %s stop, got %+v", reason, stop)
+	}
+	var trace struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	if len(trace.StackFrames) == 0 {
+		c.t.Fatalf("empty stack trace")
+	}
+	return trace.StackFrames[0]
+}
+
+// variables fetches the variables of a reference as name/value pairs
+func (c *testClient) variables(ref int) (map[string]string, map[string]int) {
+	c.t.Helper()
+	var result struct {
+		Variables []Variable `json:"variables"`
+	}
+	c.request("variables", VariablesArguments{VariablesReference: ref}, &result)
+	values, refs := make(map[string]string), make(map[string]int)
+	for _, v := range result.Variables {
+		values[v.Name] = v.Value
+		refs[v.Name] = v.VariablesReference
+	}
+	return values, refs
+}
+
+// launch starts a session for the test program with breakpoints on lines
+func launch(t *testing.T, stopOnEntry bool, lines ...int) (*testClient, string) {
+	t.Helper()
+	program := filepath.Join(t.TempDir(), "program.ts")
+	if err := os.WriteFile(program, []byte(testProgram), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t)
+	var caps Capabilities
+	c.request("initialize", InitializeArguments{AdapterID: "paserati"}, &caps)
+	if !caps.SupportsConfigurationDoneRequest {
+		t.Fatalf("unexpected capabilities %+v", caps)
+	}
+	c.request("launch", LaunchArguments{Program: program, StopOnEntry: stopOnEntry}, nil)
+	c.event("initialized", nil)
+
+	breakpoints := make([]SourceBreakpoint, len(lines))
+	for i, line := range lines {
+		breakpoints[i] = SourceBreakpoint{Line: line}
+	}
+	var result struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", SetBreakpointsArguments{Source: Source{Path: program}, Breakpoints: breakpoints}, &result)
+	if len(result.Breakpoints) != len(lines) {
+		t.Fatalf("expected %d breakpoints, got %+v", len(lines), result.Breakpoints)
+	}
+	c.request("configurationDone", nil, nil)
+	return c, program
+}
+
+// finish continues the program to its end and disconnects
+func (c *testClient) finish(exitCode int) {
+	c.t.Helper()
+	c.request("continue", nil, nil)
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != exitCode {
+		c.t.Fatalf("expected exit code %d, got %d", exitCode, exited.ExitCode)
+	}
+	c.event("terminated", nil)
+	c.request("disconnect", nil, nil)
+	if err := <-c.served; err != nil {
+		c.t.Fatalf("Serve: %v", err)
+	}
+}
+
+func TestServerBreakpointsAndVariables(t *testing.T) {
+	c, program := launch(t, false, 4)
+
+	frame := c.stopped("breakpoint")
+	if frame.Name != "add" || frame.Line != 4 || frame.Source == nil || frame.Source.Path != program {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	var threads struct {
+		Threads []Thread `json:"threads"`
+	}
+	c.request("threads", nil, &threads)
+	if len(threads.Threads) != 1 || threads.Threads[0].ID != threadID {
+		t.Fatalf("unexpected threads %+v", threads)
+	}
+
+	var scopes struct {
+		Scopes []Scope `json:"scopes"`
+	}
+	c.request("scopes", ScopesArguments{FrameID: frame.ID}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Local" || scopes.Scopes[1].Name != "Global" {
+		t.Fatalf("unexpected scopes %+v", scopes)
+	}
+	locals, _ := c.variables(scopes.Scopes[0].VariablesReference)
+	if locals["a"] != "0" || locals["b"] != "0" || locals["sum"] != "0" {
+		t.Fatalf("unexpected locals %v", locals)
+	}
+
+	// Objects expand into their properties
+	globals, refs := c.variables(scopes.Scopes[1].VariablesReference)
+	if globals["total"] != "0" || refs["point"] == 0 {
+		t.Fatalf("unexpected globals %v %v", globals, refs)
+	}
+	props, propRefs := c.variables(refs["point"])
+	if props["x"] != "1" || propRefs["y"] == 0 {
+		t.Fatalf("unexpected properties %v", props)
+	}
+	elements, _ := c.variables(propRefs["y"])
+	if elements["0"] != "2" || elements["1"] != "3" || elements["length"] != "2" {
+		t.Fatalf("unexpected elements %v", elements)
+	}
+
+	// Evaluate in the paused frame and its caller
+	var result struct {
+		Result string `json:"result"`
+	}
+	c.request("evaluate", EvaluateArguments{Expression: "a + b + 40", FrameID: frame.ID}, &result)
+	if result.Result != "40" {
+		t.Fatalf("expected 40, got %q", result.Result)
+	}
+	c.request("evaluate", EvaluateArguments{Expression: "i + 1", FrameID: frame.ID + 1}, &result)
+	if result.Result != "1" {
+		t.Fatalf("expected 1 in the caller, got %q", result.Result)
+	}
+	if msg := c.fail("evaluate", EvaluateArguments{Expression: "nope", FrameID: frame.ID}); !strings.Contains(msg, "ReferenceError") {
+		t.Fatalf("expected a ReferenceError, got %q", msg)
+	}
+
+	c.request("continue", nil, nil)
+	if frame := c.stopped("breakpoint"); frame.Line != 4 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	c.request("setBreakpoints", SetBreakpointsArguments{Source: Source{Path: program}}, nil)
+	c.finish(0)
+}
+
+func TestServerStepping(t *testing.T) {
+	c, _ := launch(t, true)
+
+	c.stopped("entry")
+	steps := []struct {
+		command string
+		name    string
+		line    int
+	}{
+		{"next", "<script>", 1},
+		{"next", "<script>", 6},
+		{"next", "<script>", 7},
+		{"next", "<script>", 8},
+		{"stepIn", "add", 3},
+		{"stepOut", "<script>", 8},
+		{"next", "<script>", 7},
+	}
+	for _, step := range steps {
+		c.request(step.command, map[string]any{"threadId": threadID}, nil)
+		if frame := c.stopped("step"); frame.Name != step.name || frame.Line != step.line {
+			t.Fatalf("after %s: expected %s:%d, got %+v", step.command, step.name, step.line, frame)
+		}
+	}
+	c.finish(0)
+}
+
+func TestServerErrors(t *testing.T) {
+	c := newTestClient(t)
+	c.request("initialize", InitializeArguments{AdapterID: "paserati"}, nil)
+	if msg := c.fail("setBreakpoints", SetBreakpointsArguments{}); !strings.Contains(msg, "no program") {
+		t.Fatalf("unexpected error %q", msg)
+	}
+	if msg := c.fail("launch", LaunchArguments{Program: filepath.Join(t.TempDir(), "missing.ts")}); !strings.Contains(msg, "launch") {
+		t.Fatalf("unexpected error %q", msg)
+	}
+	c.fail("stackTrace", StackTraceArguments{ThreadID: threadID})
+	c.fail("continue", nil)
+	c.fail("pause", nil)
+	c.request("disconnect", nil, nil)
+	if err := <-c.served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	// Disconnecting from a paused program terminates it
+	c, _ = launch(t, true)
+	c.stopped("entry")
+	c.request("disconnect", nil, nil)
+	if err := <-c.served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
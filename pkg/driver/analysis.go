@@ -0,0 +1,95 @@
+package driver
+
+import (
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/source"
+)
+
+// Tools such as editors analyze modules as they are edited: type checked,
+// but neither compiled nor run. A module being edited can override its file
+// for the modules importing it, and modules whose files changed are dropped
+// from the session's cache so that only they and their importers are checked
+// again.
+
+// Analysis is a type checked module
+type Analysis struct {
+	Filename string
+	Source   *source.SourceFile
+	// Program is the module's syntax tree with the types the checker
+	// computed, and its imports' specifiers canonicalized (see
+	// modules.CanonicalizeImports). It is partial when the module has
+	// syntax errors, and then not checked.
+	Program *parser.Program
+	Errors  []errors.PaseratiError
+	Checker *checker.Checker // The checker of Program, nil if not checked
+}
+
+// Analyze parses and type checks content as the module at filename,
+// relative to the session's base directory unless absolute
+func (p *Paserati) Analyze(filename string, content string) *Analysis {
+	analysis := &Analysis{Filename: filename, Source: source.FromFile(filename, content)}
+	program, parseErrs := parser.NewParser(lexer.NewLexerWithSource(analysis.Source)).ParseProgram()
+	analysis.Program = program
+	if len(parseErrs) > 0 {
+		analysis.Errors = parseErrs
+		return analysis
+	}
+	analysis.Checker, analysis.Errors = p.checkModule(filename, program)
+	return analysis
+}
+
+// checkModule type checks program as the module at filename, canonicalizing
+// its imports
+func (p *Paserati) checkModule(filename string, program *parser.Program) (*checker.Checker, []errors.PaseratiError) {
+	modules.CanonicalizeImports(program, filename)
+	moduleChecker := p.newChecker()
+	moduleChecker.EnableModuleMode(filename, p.moduleLoader)
+	p.defineEmbeddedGlobals(moduleChecker)
+	p.configureChecker(moduleChecker)
+	p.configureDeclarations(moduleChecker)
+	return moduleChecker, moduleChecker.Check(program)
+}
+
+// newChecker returns a checker knowing the session's builtins
+func (p *Paserati) newChecker() *checker.Checker {
+	if p.initializers != nil {
+		return checker.NewCheckerWithInitializers(p.initializers)
+	}
+	return checker.NewChecker()
+}
+
+// OverrideModule makes the module at path, relative to the session's base
+// directory, have content instead of what its file holds, as a file being
+// edited does. Returns the specifiers of the modules that will be checked
+// again when next imported (see InvalidateModule).
+func (p *Paserati) OverrideModule(path string, content string) []string {
+	if p.overrides == nil {
+		p.overrides = modules.NewMemoryResolver("Overrides")
+		p.overrides.SetPriority(10) // Ahead of every resolver of files
+		p.moduleLoader.AddResolver(p.overrides)
+	}
+	p.overrides.AddModule(path, content)
+	return p.InvalidateModule(path)
+}
+
+// RestoreModule undoes OverrideModule, so that the module at path has what
+// its file holds again
+func (p *Paserati) RestoreModule(path string) []string {
+	if p.overrides == nil || p.overrides.GetModule(path) == nil {
+		return nil
+	}
+	p.overrides.RemoveModule(path)
+	return p.InvalidateModule(path)
+}
+
+// InvalidateModule drops the module at path, relative to the session's base
+// directory, and the modules importing it directly or not from the
+// session's cache, so that they are loaded and checked again when next
+// imported. Returns the specifiers of the modules dropped.
+func (p *Paserati) InvalidateModule(path string) []string {
+	return p.moduleLoader.Invalidate(path)
+}
@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeOverriddenModules(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"lib/b.ts": `export function twice(n: number): number { return n * 2; }`,
+		"a.ts":     `import { twice } from "./lib/b.ts"; export const x = twice(1);`,
+	})
+	main := `import { x } from "./a.ts"; const y: number = x;`
+
+	if analysis := p.Analyze("main.ts", main); len(analysis.Errors) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(analysis.Errors))
+	}
+
+	// Editing b.ts drops it and a.ts, which imports it, but not main.ts
+	dropped := p.OverrideModule("lib/b.ts", `export function twice(n: number): string { return "" + n; }`)
+	if strings.Join(dropped, ",") != "./a.ts,./lib/b.ts" {
+		t.Errorf("expected a.ts and b.ts to be dropped, got %q", dropped)
+	}
+	analysis := p.Analyze("main.ts", main)
+	if !strings.Contains(errorMessages(analysis.Errors), "cannot assign type 'string' to variable 'y'") {
+		t.Errorf("expected a.ts to be checked against the edited b.ts, got: %s", errorMessages(analysis.Errors))
+	}
+
+	p.RestoreModule("lib/b.ts")
+	if analysis := p.Analyze("main.ts", main); len(analysis.Errors) > 0 {
+		t.Errorf("expected b.ts's file after restoring it, got: %s", errorMessages(analysis.Errors))
+	}
+
+	analysis = p.Analyze("broken.ts", "let = ;")
+	if len(analysis.Errors) == 0 || analysis.Checker != nil || analysis.Program == nil {
+		t.Errorf("expected syntax errors and an unchecked program, got %+v", analysis)
+	}
+}
@@ -0,0 +1,224 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/aot"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/source"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// CompileBinaryModule parses, checks and compiles source as the module
+// moduleName and serializes the result in the .psm format (see package aot).
+// Only the module itself is compiled: its imports are resolved again when the
+// compiled module runs, from .psm files where those are up to date and from
+// source otherwise.
+func (p *Paserati) CompileBinaryModule(sourceCode string, moduleName string) ([]byte, []errors.PaseratiError) {
+	m, errs := p.compileModule(sourceCode, moduleName)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	data, err := aot.Encode(m)
+	if err != nil {
+		return nil, []errors.PaseratiError{aotError(err)}
+	}
+	return data, nil
+}
+
+// CompileAssembly compiles source as the module moduleName together with
+// every module it imports, directly or not, into an assembly (see package
+// aot). Native modules are left out, as every Paserati build provides them,
+// and so are modules that are only imported for their types. Modules loaded
+// with a dynamic import() are not followed.
+func (p *Paserati) CompileAssembly(sourceCode string, moduleName string) ([]byte, []errors.PaseratiError) {
+	entry, errs := p.compileModule(sourceCode, moduleName)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	assembly := &aot.Assembly{
+		Entry:   moduleName,
+		Modules: map[string]*aot.Module{moduleName: entry},
+		JSON:    make(map[string]string),
+	}
+	queue := entry.Imports
+	for len(queue) > 0 {
+		imp := queue[0]
+		queue = queue[1:]
+		if _, done := assembly.Modules[imp.Specifier]; done {
+			continue
+		}
+		if _, done := assembly.JSON[imp.Specifier]; done {
+			continue
+		}
+		if p.nativeResolver != nil && p.nativeResolver.CanResolve(imp.Specifier) {
+			continue
+		}
+
+		// Checking the entry point loaded every module it imports
+		record := p.moduleLoader.GetModule(imp.Specifier)
+		if record == nil {
+			return nil, []errors.PaseratiError{aotError(fmt.Errorf("module '%s' was not loaded", imp.Specifier))}
+		}
+		if err := record.GetError(); err != nil {
+			return nil, []errors.PaseratiError{aotError(fmt.Errorf("module '%s': %w", imp.Specifier, err))}
+		}
+		if record.IsJSON {
+			assembly.JSON[imp.Specifier] = record.GetSource()
+			continue
+		}
+		if record.CompiledChunk == nil {
+			return nil, []errors.PaseratiError{aotError(fmt.Errorf("module '%s' was not compiled", imp.Specifier))}
+		}
+
+		m := &aot.Module{
+			Path:       record.ResolvedPath,
+			SourceHash: aot.HashSource(record.GetSource()),
+			Chunk:      record.CompiledChunk,
+			Imports:    aotImports(record.RuntimeImports()),
+			Exports:    make(map[string]int, len(record.ExportIndices)),
+			Globals:    entry.Globals,
+		}
+		for name, index := range record.ExportIndices {
+			m.Exports[name] = int(index)
+		}
+		assembly.Modules[imp.Specifier] = m
+		queue = append(queue, m.Imports...)
+	}
+
+	data, err := aot.EncodeAssembly(assembly)
+	if err != nil {
+		return nil, []errors.PaseratiError{aotError(err)}
+	}
+	return data, nil
+}
+
+// compileModule parses, checks and compiles source as the module moduleName.
+// The module's global table names every heap slot of the session.
+func (p *Paserati) compileModule(sourceCode string, moduleName string) (*aot.Module, []errors.PaseratiError) {
+	program, parseErrs := parser.NewParser(lexer.NewLexerWithSource(source.NewEvalSource(sourceCode))).ParseProgram()
+	if len(parseErrs) > 0 {
+		return nil, parseErrs
+	}
+	modules.CanonicalizeImports(program, moduleName)
+	if err := p.preloadNativeModules(program); err != nil {
+		return nil, []errors.PaseratiError{err}
+	}
+
+	p.checker.EnableModuleMode(moduleName, p.moduleLoader)
+	p.compiler.EnableModuleMode(moduleName, p.moduleLoader)
+	p.compiler.SetIgnoreTypeErrors(p.ignoreTypeErrors)
+	p.compiler.SetSkipTypeCheck(p.skipTypeCheck)
+	chunk, compileErrs := p.compiler.Compile(program)
+	if len(compileErrs) > 0 {
+		return nil, compileErrs
+	}
+
+	m := &aot.Module{
+		Path:       moduleName,
+		SourceHash: aot.HashSource(sourceCode),
+		Chunk:      chunk,
+		Imports:    aotImports(modules.ExtractRuntimeImportSpecs(program)),
+		Exports:    p.compiler.GetExportGlobalIndices(),
+		Globals:    make(map[int]string),
+	}
+	for name, index := range p.compiler.GetHeapAlloc().GetNameToIndexMap() {
+		m.Globals[index] = name
+	}
+	return m, nil
+}
+
+func aotImports(specs []*modules.ImportSpec) []aot.Import {
+	imports := make([]aot.Import, len(specs))
+	for i, spec := range specs {
+		imports[i] = aot.Import{Specifier: spec.ModulePath, Type: spec.Attributes["type"]}
+	}
+	return imports
+}
+
+func aotError(err error) errors.PaseratiError {
+	return &errors.CompileError{
+		Position: errors.Position{Line: 0, Column: 0},
+		Msg:      err.Error(),
+		Cause:    err,
+	}
+}
+
+// RunBinaryModule runs a module compiled by CompileBinaryModule, skipping
+// parsing, type checking and compilation. options.ModuleName is ignored; the
+// module runs under the name it was compiled as.
+func (p *Paserati) RunBinaryModule(data []byte, options RunOptions) (vm.Value, []errors.PaseratiError) {
+	m, err := aot.Decode(data)
+	if err != nil {
+		return vm.Undefined, []errors.PaseratiError{aotError(err)}
+	}
+	return p.runCompiledModule(m, options)
+}
+
+// RunAssembly runs the entry module of an assembly built by CompileAssembly.
+// The modules it imports come from the assembly rather than the file system.
+// options.ModuleName is ignored.
+func (p *Paserati) RunAssembly(data []byte, options RunOptions) (vm.Value, []errors.PaseratiError) {
+	assembly, err := aot.DecodeAssembly(data)
+	if err != nil {
+		return vm.Undefined, []errors.PaseratiError{aotError(err)}
+	}
+	p.moduleLoader.AddResolver(aot.NewAssemblyResolver(assembly, p.compiler.GetHeapAlloc().GetOrAssignIndex))
+	return p.runCompiledModule(assembly.Modules[assembly.Entry], options)
+}
+
+// runCompiledModule links and runs a decoded module as the main program
+func (p *Paserati) runCompiledModule(m *aot.Module, options RunOptions) (vm.Value, []errors.PaseratiError) {
+	if err := m.Link(p.compiler.GetHeapAlloc().GetOrAssignIndex); err != nil {
+		return vm.Undefined, []errors.PaseratiError{aotError(err)}
+	}
+
+	// Native modules put their exports in the heap before the importing
+	// code runs, as preloadNativeModules does for source modules
+	for _, imp := range m.Imports {
+		if p.nativeResolver == nil || !p.nativeResolver.CanResolve(imp.Specifier) {
+			continue
+		}
+		moduleRecord, err := p.moduleLoader.LoadModule(imp.Specifier, ".")
+		if err != nil {
+			return vm.Undefined, []errors.PaseratiError{&errors.CompileError{
+				Position: errors.Position{Line: 0, Column: 0},
+				Msg:      fmt.Sprintf("Failed to preload native module '%s': %v", imp.Specifier, err),
+			}}
+		}
+		if concreteRecord, ok := moduleRecord.(*modules.ModuleRecord); ok {
+			p.registerNativeModuleExports(concreteRecord)
+		}
+	}
+
+	if options.Context != nil || options.Limits != (vm.Limits{}) {
+		ctx := options.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		p.vmInstance.SetLimits(ctx, options.Limits)
+		defer p.vmInstance.ClearLimits()
+	}
+
+	// The checker never saw the module's declarations, so code it evaluates
+	// can't be checked against them
+	p.uncheckedEval = true
+
+	heapAlloc := p.compiler.GetHeapAlloc()
+	p.vmInstance.SyncGlobalNames(heapAlloc.GetNameToIndexMap())
+	p.vmInstance.ResizeHeapForGlobals(heapAlloc.GetAllocatedSize())
+	p.vmInstance.SetCurrentModulePath(m.Path)
+
+	finalValue, runtimeErrs := p.vmInstance.Interpret(m.Chunk)
+	if len(runtimeErrs) == 0 {
+		runtimeErrs = p.runEventLoop()
+	} else {
+		p.vmInstance.DrainMicrotasks()
+	}
+	return finalValue, runtimeErrs
+}
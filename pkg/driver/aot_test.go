@@ -0,0 +1,162 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const aotLibSource = `
+export const greeting: string = "hello";
+export function add(a: number, b: number): number { return a + b; }
+export class Counter { n = 0; inc(): number { return ++this.n; } }
+`
+
+const aotMainSource = `
+import { greeting, add, Counter } from "./lib";
+enum Dir { Up, Down = "down" }
+function tag(s: TemplateStringsArray, ...v: any[]): string { return s.raw.join("|") + v.length; }
+const c = new Counter();
+c.inc();
+let caught = "";
+try { throw new Error("boom"); } catch (e) { caught = (e as Error).message; }
+const result = [greeting, add(2, 3), c.inc(), Dir.Down, tag` + "`a${1}\\n`" + `, 10n ** 20n, caught].join(",");
+result;
+`
+
+const aotMainResult = `hello,5,2,down,a|\n1,100000000000000000000,boom`
+
+// compileTo compiles source with a session rooted at dir and writes the .psm file
+func compileTo(t *testing.T, dir, name, source string) []byte {
+	t.Helper()
+	p := NewPaseratiWithBaseDir(dir)
+	defer p.Cleanup()
+	data, errs := p.CompileBinaryModule(source, name)
+	if len(errs) > 0 {
+		t.Fatalf("compiling %s: %v", name, errs)
+	}
+	if err := os.WriteFile(filepath.Join(dir, strings.TrimSuffix(name, ".ts")+".psm"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRunBinaryModule(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lib.ts"), []byte(aotLibSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data := compileTo(t, dir, "main.ts", aotMainSource)
+
+	// A fresh session with other globals allocated first
+	p := NewPaseratiWithBaseDir(dir)
+	defer p.Cleanup()
+	if err := p.Set("unrelated", 1); err != nil {
+		t.Fatal(err)
+	}
+	value, errs := p.RunBinaryModule(data, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if value.ToString() != aotMainResult {
+		t.Fatalf("expected %q, got %q", aotMainResult, value.ToString())
+	}
+
+	if _, errs := p.RunBinaryModule(data[:len(data)/2], RunOptions{}); len(errs) == 0 {
+		t.Fatalf("expected an error for truncated bytecode")
+	}
+}
+
+func TestCompiledModuleResolver(t *testing.T) {
+	dir := t.TempDir()
+	libPath := filepath.Join(dir, "lib.ts")
+	if err := os.WriteFile(libPath, []byte(aotLibSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	compileTo(t, dir, "lib.ts", aotLibSource)
+
+	run := func() (*Module, string) {
+		t.Helper()
+		p := NewPaseratiWithBaseDir(dir)
+		t.Cleanup(p.Cleanup)
+		value, errs := p.RunString(aotMainSource)
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		mod, err := p.Import("./lib")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mod, value.ToString()
+	}
+
+	// The compiled module is used next to its source
+	mod, result := run()
+	if result != aotMainResult || !strings.HasSuffix(mod.Path(), "lib.psm") {
+		t.Fatalf("expected lib.psm to run, got %s: %q", mod.Path(), result)
+	}
+
+	// A changed source makes the compiled module stale
+	if err := os.WriteFile(libPath, []byte(strings.Replace(aotLibSource, `"hello"`, `"howdy"`, 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mod, result = run()
+	if !strings.HasPrefix(result, "howdy,") || !strings.HasSuffix(mod.Path(), "lib.ts") {
+		t.Fatalf("expected the changed lib.ts to run, got %s: %q", mod.Path(), result)
+	}
+
+	// Without a source, the compiled module stands alone
+	if err := os.Remove(libPath); err != nil {
+		t.Fatal(err)
+	}
+	if mod, result = run(); result != aotMainResult || !strings.HasSuffix(mod.Path(), "lib.psm") {
+		t.Fatalf("expected lib.psm to run, got %s: %q", mod.Path(), result)
+	}
+}
+
+func TestRunAssembly(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib.ts":    aotLibSource,
+		"types.ts":  "export interface Shape { kind: string }",
+		"data.json": `{"name": "assembled"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mainSource := `import type { Shape } from "./types";
+import data from "./data.json" with { type: "json" };
+` + aotMainSource + `
+const shape: Shape = { kind: data.name };
+result + "," + shape.kind;
+`
+
+	p := NewPaseratiWithBaseDir(dir)
+	data, errs := p.CompileAssembly(mainSource, "main.ts")
+	p.Cleanup()
+	if len(errs) > 0 {
+		t.Fatalf("compiling: %v", errs)
+	}
+	if strings.Contains(string(data), "types.ts") {
+		t.Fatalf("the assembly includes a type-only import")
+	}
+
+	// The assembly runs without its sources
+	for name := range files {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p = NewPaseratiWithBaseDir(dir)
+	defer p.Cleanup()
+	value, errs := p.RunAssembly(data, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if want := aotMainResult + ",assembled"; value.ToString() != want {
+		t.Fatalf("expected %q, got %q", want, value.ToString())
+	}
+}
@@ -3,6 +3,7 @@ package driver
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -295,3 +296,147 @@ func TestGlobalFetchBlob(t *testing.T) {
 		t.Errorf("Expected 'blob_test_passed', got: %v", result.ToString())
 	}
 }
+
+// TestGlobalFetchStreamingBody tests reading response.body as the server
+// sends it, and sending a ReadableStream as a request body
+func TestGlobalFetchStreamingBody(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stream":
+			// Send the first chunk, then hold the response open until the
+			// script has read it
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("first;"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+				_, _ = w.Write([]byte("second"))
+			case <-r.Context().Done():
+			}
+		case "/release":
+			close(release)
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	p := NewPaserati()
+
+	tsCode := fmt.Sprintf(`
+		async function runTest() {
+			const response = await fetch("%[1]s/stream");
+			const reader = response.body.getReader();
+			const decoder = new TextDecoderStream();
+			const writer = decoder.writable.getWriter();
+			const text = decoder.readable.getReader();
+
+			// The first chunk arrives while the server is still sending
+			const first = await reader.read();
+			writer.write(first.value);
+			const firstText = (await text.read()).value;
+			await fetch("%[1]s/release");
+
+			let rest = "";
+			while (true) {
+				const { done, value } = await reader.read();
+				if (done) break;
+				writer.write(value);
+				rest += (await text.read()).value;
+			}
+
+			const echoed = await fetch("%[1]s/echo", {
+				method: "POST",
+				body: ReadableStream.from(["str", "eam"]).pipeThrough(new TextEncoderStream()),
+			});
+			return firstText + "|" + rest + "|" + (await echoed.text());
+		}
+
+		await runTest();
+	`, server.URL)
+
+	result, errs := p.RunCode(tsCode, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("Failed to run streaming body test: %v", errs[0])
+	}
+
+	if result.ToString() != "first;|second|stream" {
+		t.Errorf("Expected 'first;|second|stream', got: %v", result.ToString())
+	}
+}
+
+// TestGlobalFetchAbort tests aborting a fetch while the server is still
+// holding the response
+func TestGlobalFetchAbort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	p := NewPaserati()
+
+	tsCode := fmt.Sprintf(`
+		async function runTest() {
+			const controller = new AbortController();
+			const state = { fired: false };
+			controller.signal.addEventListener("abort", () => { state.fired = true; }, { once: true });
+			setTimeout(() => controller.abort("stop"), 10);
+			try {
+				await fetch("%s/slow", { signal: controller.signal });
+				return "not aborted";
+			} catch (e) {
+				return String(e) + "|" + state.fired;
+			}
+		}
+
+		await runTest();
+	`, server.URL)
+
+	result, errs := p.RunCode(tsCode, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("Failed to run abort test: %v", errs[0])
+	}
+
+	if result.ToString() != "AbortError: stop|true" {
+		t.Errorf("Expected 'AbortError: stop|true', got: %v", result.ToString())
+	}
+}
+
+// TestGlobalFetchURLSearchParams tests fetching a URL object with a
+// URLSearchParams body
+func TestGlobalFetchURLSearchParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s?%s|%s|%s", r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type"), body)
+	}))
+	defer server.Close()
+
+	p := NewPaserati()
+
+	tsCode := fmt.Sprintf(`
+		async function runTest() {
+			const url = new URL("/api/items", "%s");
+			url.searchParams.set("page", "2");
+			const response = await fetch(url, {
+				method: "POST",
+				body: new URLSearchParams({ name: "a b", tag: "x&y" }),
+			});
+			return await response.text();
+		}
+
+		await runTest();
+	`, server.URL)
+
+	result, errs := p.RunCode(tsCode, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("Failed to run URLSearchParams test: %v", errs[0])
+	}
+
+	expected := "/api/items?page=2|application/x-www-form-urlencoded;charset=UTF-8|name=a+b&tag=x%26y"
+	if result.ToString() != expected {
+		t.Errorf("Expected %q, got: %v", expected, result.ToString())
+	}
+}
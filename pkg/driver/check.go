@@ -0,0 +1,196 @@
+package driver
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/source"
+	"github.com/nooga/paserati/pkg/tsconfig"
+)
+
+// A project is checked without compiling or running any of it. Its files
+// are parsed in parallel on a modules.ParseWorkerPool, which also finds the
+// files they import, and then checked one after another in the session:
+// declaration files first, so that their ambient declarations are visible
+// to the modules.
+
+// CheckResult is the outcome of checking a project
+type CheckResult struct {
+	// Files are the files checked, relative to the session's base directory
+	// unless outside it: declaration files first, then the others, each
+	// sorted
+	Files []string
+	// Errors are the syntax and type errors found, in the order of Files and
+	// then of their positions, each reported once
+	Errors []errors.PaseratiError
+}
+
+// diagnosticKey identifies an error by where it is and what it says
+type diagnosticKey struct {
+	file         string
+	line, column int
+	message      string
+}
+
+// CheckFiles type checks the files at paths, relative to the session's base
+// directory unless absolute, and the TypeScript files they import, directly
+// or not, wherever the session would resolve the imports to: relative files,
+// packages in node_modules or the path mappings of its tsconfig.json. It
+// fails only when one of paths can't be read.
+func (p *Paserati) CheckFiles(paths []string) (*CheckResult, error) {
+	parsed, err := p.parseProject(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{}
+	for file := range parsed {
+		result.Files = append(result.Files, file)
+	}
+	sort.Slice(result.Files, func(i, j int) bool {
+		a, b := result.Files[i], result.Files[j]
+		if isDeclaration, other := strings.HasSuffix(a, ".d.ts"), strings.HasSuffix(b, ".d.ts"); isDeclaration != other {
+			return isDeclaration
+		}
+		return a < b
+	})
+	// An error can come up again, e.g. when a file listed is also checked
+	// for a file importing it; it's only reported the first time
+	reported := make(map[diagnosticKey]bool)
+	for _, file := range result.Files {
+		var errs []errors.PaseratiError
+		switch parse := parsed[file]; {
+		case parse.AST == nil:
+			errs = parse.SyntaxErrors
+		case strings.HasSuffix(file, ".d.ts"):
+			var declarations *checker.Declarations
+			if declarations, errs = p.checkDeclarationProgram(file, parse.AST); len(errs) == 0 {
+				p.addDeclarations(declarations)
+			}
+		default:
+			_, errs = p.checkModule(file, parse.AST)
+		}
+		sort.SliceStable(errs, func(i, j int) bool {
+			a, b := errs[i].Pos(), errs[j].Pos()
+			return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+		})
+		for _, err := range errs {
+			pos := err.Pos()
+			key := diagnosticKey{file: file, line: pos.Line, column: pos.Column, message: err.Message()}
+			if pos.Source != nil {
+				key.file = pos.Source.Path
+			}
+			if !reported[key] {
+				reported[key] = true
+				result.Errors = append(result.Errors, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseProject parses the files at paths and those they import on a worker
+// pool, returning the results by module path
+func (p *Paserati) parseProject(paths []string) (map[string]*modules.ParseResult, error) {
+	config := modules.DefaultLoaderConfig()
+	pool := modules.NewWorkerPool(config)
+	if err := pool.Start(context.Background(), config.NumWorkers); err != nil {
+		return nil, err
+	}
+	defer pool.Shutdown(context.Background())
+
+	queued := make(map[string]bool)
+	var queue []*modules.ParseJob
+	enqueue := func(modulePath string, content string) {
+		queued[modulePath] = true
+		queue = append(queue, &modules.ParseJob{
+			ModulePath: modulePath,
+			Source:     source.FromFile(modulePath, content),
+			Timestamp:  time.Now(),
+		})
+	}
+	for _, path := range paths {
+		modulePath := p.modulePath(path)
+		if queued[modulePath] {
+			continue
+		}
+		content, err := os.ReadFile(p.projectPath(modulePath))
+		if err != nil {
+			return nil, err
+		}
+		enqueue(modulePath, string(content))
+	}
+
+	// Imported files are found as results come in. No more jobs are in
+	// flight than the result buffer holds, so that workers never wait on
+	// results while we wait to submit.
+	resolve := p.projectResolver()
+	parsed := make(map[string]*modules.ParseResult)
+	for inFlight := 0; len(queue) > 0 || inFlight > 0; inFlight-- {
+		for ; len(queue) > 0 && inFlight < config.ResultBufferSize; inFlight++ {
+			if err := pool.Submit(queue[0]); err != nil {
+				return nil, err
+			}
+			queue = queue[1:]
+		}
+		result := <-pool.Results()
+		parsed[result.ModulePath] = result
+		if result.AST == nil {
+			continue
+		}
+		for _, spec := range result.ImportSpecs {
+			// Imports that don't resolve are reported by the importer's checker
+			resolved, ok := resolve(spec.ModulePath, result.ModulePath)
+			if !ok {
+				continue
+			}
+			if !queued[resolved.ResolvedPath] && tsconfig.IsSourceFile(resolved.ResolvedPath) {
+				if content, err := io.ReadAll(resolved.Source); err == nil {
+					enqueue(resolved.ResolvedPath, string(content))
+				}
+			}
+			resolved.Source.Close()
+		}
+	}
+	return parsed, nil
+}
+
+// projectResolver returns a function resolving the imports of the project's
+// files to the files the session would load: packages and path mappings
+// through the session's NodeResolver, relative and absolute specifiers
+// through a FileSystemResolver
+func (p *Paserati) projectResolver() func(specifier, fromPath string) (*modules.ResolvedModule, bool) {
+	resolvers := []modules.ModuleResolver{
+		p.nodeResolver,
+		modules.NewFileSystemResolver(os.DirFS(p.baseDir), p.baseDir),
+	}
+	return func(specifier, fromPath string) (*modules.ResolvedModule, bool) {
+		for _, resolver := range resolvers {
+			if resolver.CanResolve(specifier) {
+				resolved, err := resolver.Resolve(specifier, fromPath)
+				return resolved, err == nil
+			}
+		}
+		return nil, false
+	}
+}
+
+// modulePath returns the module path of the file at path: slash-separated
+// and relative to the session's base directory, unless outside it
+func (p *Paserati) modulePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	if rel, ok := relativePath(p.baseDir, path); ok {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,112 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nooga/paserati/pkg/builtins"
+)
+
+func TestCheckFiles(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"globals.d.ts": `declare const appName: string;`,
+		"main.ts": `import { twice } from "./lib/util";
+const n: number = twice(2);
+const name: string = appName;
+globalThis.ran = true;`,
+		"lib/util.ts": `import { helper } from "./helper.ts";
+export function twice(n: number): number { return helper(n) * 2; }
+const bad: string = 1;`,
+		"lib/helper.ts": `export function helper(n: number): number { return n; }`,
+		"broken.ts":     "let = ;\nconst x: number = 1;",
+	})
+
+	result, err := p.CheckFiles([]string{"main.ts", "broken.ts", "globals.d.ts", "./main.ts"})
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+	if files := strings.Join(result.Files, " "); files != "globals.d.ts broken.ts lib/helper.ts lib/util.ts main.ts" {
+		t.Errorf("expected the files and those they import, got %s", files)
+	}
+	var found []string
+	for _, err := range result.Errors {
+		pos := err.Pos()
+		found = append(found, fmt.Sprintf("%s:%d:%d %s", pos.Source.Path, pos.Line, pos.Column, err.Kind()))
+	}
+	if len(found) < 2 || !strings.HasPrefix(found[0], "broken.ts:1:") || found[len(found)-1] != "lib/util.ts:3:21 Type" {
+		t.Errorf("expected syntax errors in broken.ts and a type error in lib/util.ts, got %v", found)
+	}
+
+	if _, err := p.CheckFiles([]string{"missing.ts"}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestCheckFilesResolvesLikeSession(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"baseUrl": ".", "paths": {"@lib/*": ["src/lib/*"]}}}`,
+		"src/main.ts": `import { value } from "@lib/value";
+import { greet } from "greeter";
+const n: number = value + greet().length;`,
+		"src/lib/value.ts":                  "export const value = 1;\nconst bad: string = 1;",
+		"node_modules/greeter/package.json": `{"name": "greeter", "main": "index.ts"}`,
+		"node_modules/greeter/index.ts":     "export function greet(): string { return \"hi\"; }\nconst bad: number = \"\";",
+	})
+
+	result, err := p.CheckFiles([]string{"src/main.ts"})
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+	if files := strings.Join(result.Files, " "); files != "node_modules/greeter/index.ts src/lib/value.ts src/main.ts" {
+		t.Errorf("expected the files imported through paths and node_modules, got %s", files)
+	}
+	var found []string
+	for _, err := range result.Errors {
+		pos := err.Pos()
+		found = append(found, fmt.Sprintf("%s:%d", pos.Source.Path, pos.Line))
+	}
+	if got := strings.Join(found, " "); got != "node_modules/greeter/index.ts:2 src/lib/value.ts:2" {
+		t.Errorf("expected the type errors in the imported files, got %s", got)
+	}
+}
+
+func TestCheckFilesReportsErrorsOnce(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"paths": {"@lib/*": ["./lib/*"]}}}`,
+		"main.ts": `import { one } from "./lib/util";
+import { one as again } from "@lib/util";
+const n: string = one + again;`,
+		"other.ts":    `import { one } from "./lib/util.ts";`,
+		"lib/util.ts": "export const one = 1;\nconst a: string = 1;\nconst b: number = \"\";",
+	})
+
+	absolute := filepath.Join(p.baseDir, "lib", "util.ts")
+	result, err := p.CheckFiles([]string{"main.ts", "lib/util.ts", "other.ts", "./lib/util.ts", absolute})
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+	if len(result.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %d:\n%s", len(result.Errors), errorMessages(result.Errors))
+	}
+}
+
+func TestCheckFilesWithSessionBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.ts"), []byte("const args: string[] = process.argv;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	initializers := append(builtins.GetStandardInitializers(), NewProcessInitializer([]string{"paserati"}))
+	p := NewPaseratiWithInitializersAndBaseDir(initializers, dir)
+	defer p.Cleanup()
+
+	result, err := p.CheckFiles([]string{"main.ts"})
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("expected the session's builtins to be declared, got %s", errorMessages(result.Errors))
+	}
+}
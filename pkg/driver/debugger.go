@@ -0,0 +1,751 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"unicode"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// This file implements the Go-level debugger. Breakpoints and steps are
+// OpDebug traps planted over instructions (see docs/DEBUGGER_DESIGN.md): the
+// VM reports a trap to the debugger's hook on the script's goroutine, which
+// blocks there while the program is paused and serves inspection requests.
+//
+//	d := p.NewDebugger()
+//	d.SetBreakpoint("app.ts", 12)
+//	d.Start(source, "app.ts", false)
+//	for ev := range d.Events() {
+//		if ev.Kind == driver.DebugStopped {
+//			frames, _ := d.Stack()
+//			value, _ := d.Evaluate(0, "total * 2")
+//			d.StepOver()
+//		}
+//	}
+//
+// Stepping plants temporary traps on every instruction of the chunks execution
+// may reach and stops at the first one on a new line, so code runs at full
+// speed whenever no breakpoint or step is pending.
+
+// ErrNotPaused is returned by Debugger methods that need a paused program
+var ErrNotPaused = stderrors.New("program is not paused")
+
+// StopReason tells why the program paused
+type StopReason string
+
+const (
+	StopEntry             StopReason = "entry"      // Before the first instruction, see Start
+	StopBreakpoint        StopReason = "breakpoint" // At a breakpoint
+	StopStep              StopReason = "step"       // After a step completed
+	StopDebuggerStatement StopReason = "debugger"   // At a debugger statement
+)
+
+// DebugEventKind identifies a DebugEvent
+type DebugEventKind int
+
+const (
+	DebugStopped           DebugEventKind = iota // The program paused
+	DebugBreakpointChanged                       // A breakpoint was verified once its file loaded
+	DebugExited                                  // The program finished; no further events follow
+)
+
+// DebugEvent reports a change in the state of a debugged program
+type DebugEvent struct {
+	Kind        DebugEventKind
+	Reason      StopReason             // Why the program paused (DebugStopped)
+	Breakpoints []int                  // IDs of the breakpoints that were hit (DebugStopped)
+	Breakpoint  Breakpoint             // The updated breakpoint (DebugBreakpointChanged)
+	Value       vm.Value               // The program's completion value (DebugExited)
+	Errors      []errors.PaseratiError // Errors the program failed with (DebugExited)
+}
+
+// Breakpoint is a source line breakpoint
+type Breakpoint struct {
+	ID   int
+	Path string // File as given to SetBreakpoint
+	Line int    // Requested line
+	// Verified is set once the breakpoint resolved to code, at ResolvedLine:
+	// the requested line or, when it has no code, the next line that does
+	Verified     bool
+	ResolvedLine int
+}
+
+// DebugFrame is an active call frame of the paused program
+type DebugFrame struct {
+	Index    int // Depth of the frame, 0 being the innermost
+	Function string
+	Path     string // File the function was loaded from, if known
+	Line     int
+	Column   int // 0 if not known
+}
+
+// DebugScope is a named group of variables visible from a frame
+type DebugScope struct {
+	Name      string // "Local" or "Global"
+	Variables []DebugVariable
+}
+
+// DebugVariable is a named value in a DebugScope
+type DebugVariable struct {
+	Name  string
+	Value vm.Value
+}
+
+type stepMode int
+
+const (
+	stepNone stepMode = iota
+	stepIn
+	stepOver
+	stepOut
+)
+
+// trapSite is an instruction a trap is planted on
+type trapSite struct {
+	chunk *vm.Chunk
+	ip    int
+}
+
+// breakpointState is a breakpoint and the instructions it resolved to
+type breakpointState struct {
+	Breakpoint
+	sites []trapSite
+}
+
+// debugCommand is sent to the paused program: either work to run on its
+// goroutine, or a request to resume in the given step mode
+type debugCommand struct {
+	run  func()
+	done chan struct{}
+	step stepMode
+}
+
+// Debugger runs a script under debugger control. The script runs on its own
+// goroutine, started by Start; Debugger methods may be called from another,
+// single controlling goroutine. Methods that inspect the program require it to
+// be paused and execute on the script's goroutine.
+type Debugger struct {
+	p      *Paserati
+	vm     *vm.VM
+	events chan DebugEvent
+
+	commands chan debugCommand
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu          sync.Mutex
+	running     bool // Between Start and the DebugExited event
+	paused      bool
+	breakpoints []*breakpointState
+	nextID      int
+	dirty       bool // Breakpoints changed since they were last planted
+
+	// State below is only touched on the script's goroutine, or while the
+	// program is not running
+	stopOnEntry bool
+	evaluating  bool
+	chunkPaths  map[*vm.Chunk]string // Loaded chunks and the normalized paths of their files
+	offsets     map[*vm.Chunk][]int
+	userSites   map[trapSite][]int // Planted breakpoint sites and their breakpoint IDs
+	stepSites   map[trapSite]bool  // Planted step traps
+	step        stepMode
+	stepDepth   int
+	stepChunk   *vm.Chunk
+	stepLine    int
+	globalBase  int // Heap size before the program ran; later globals are the program's
+}
+
+// NewDebugger returns a debugger for this session. Code it runs is compiled
+// with debug info, see SetDebugInfo.
+func (p *Paserati) NewDebugger() *Debugger {
+	return &Debugger{
+		p:          p,
+		vm:         p.vmInstance,
+		events:     make(chan DebugEvent, 16),
+		commands:   make(chan debugCommand),
+		chunkPaths: make(map[*vm.Chunk]string),
+		offsets:    make(map[*vm.Chunk][]int),
+		userSites:  make(map[trapSite][]int),
+		stepSites:  make(map[trapSite]bool),
+	}
+}
+
+// Events returns the channel the debugger reports stops and the program's
+// exit on. It is closed after the DebugExited event; the controller must keep
+// reading until then.
+func (d *Debugger) Events() <-chan DebugEvent {
+	return d.events
+}
+
+// Start runs source, loaded from path, on a new goroutine. With stopOnEntry the
+// program pauses before its first instruction. A debugger runs one program.
+func (d *Debugger) Start(source string, path string, stopOnEntry bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running || d.cancel != nil {
+		return fmt.Errorf("debugger already started")
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.running = true
+	d.stopOnEntry = stopOnEntry
+	d.globalBase = d.vm.GetHeap().Size()
+
+	d.p.SetDebugInfo(true)
+	d.vm.SetDebugHook(debugHook{d})
+	go func() {
+		value, errs := d.p.RunCode(source, RunOptions{ModuleName: path, Context: d.ctx})
+		d.vm.SetDebugHook(nil)
+		// Leave no traps behind for code the session runs later
+		d.clearStepTraps()
+		for site := range d.userSites {
+			d.vm.ClearBreakpoint(site.chunk, site.ip)
+		}
+		clear(d.userSites)
+		d.mu.Lock()
+		d.running = false
+		d.mu.Unlock()
+		d.events <- DebugEvent{Kind: DebugExited, Value: value, Errors: errs}
+		close(d.events)
+	}()
+	return nil
+}
+
+// Terminate stops the program, which exits with a termination error
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	// A paused program notices the cancellation and resumes on its own
+	cancel()
+}
+
+// Continue resumes the paused program until the next breakpoint
+func (d *Debugger) Continue() error { return d.resume(stepNone) }
+
+// StepOver resumes the paused program until it reaches another line of the
+// current function, or returns from it
+func (d *Debugger) StepOver() error { return d.resume(stepOver) }
+
+// StepIn resumes the paused program until it reaches another line, including
+// one in a called function
+func (d *Debugger) StepIn() error { return d.resume(stepIn) }
+
+// StepOut resumes the paused program until the current function returns
+func (d *Debugger) StepOut() error { return d.resume(stepOut) }
+
+func (d *Debugger) resume(mode stepMode) error {
+	d.mu.Lock()
+	paused := d.paused
+	d.mu.Unlock()
+	if !paused {
+		return ErrNotPaused
+	}
+	select {
+	case d.commands <- debugCommand{step: mode}:
+		return nil
+	case <-d.ctx.Done():
+		return ErrNotPaused
+	}
+}
+
+// onPaused runs fn on the script's goroutine while the program is paused
+func (d *Debugger) onPaused(fn func()) error {
+	d.mu.Lock()
+	paused := d.paused
+	d.mu.Unlock()
+	if !paused {
+		return ErrNotPaused
+	}
+	done := make(chan struct{})
+	select {
+	case d.commands <- debugCommand{run: fn, done: done}:
+		<-done
+		return nil
+	case <-d.ctx.Done():
+		return ErrNotPaused
+	}
+}
+
+// SetBreakpoint adds a breakpoint on a source line. It is verified right away
+// when the file is loaded and the program paused; otherwise once the file
+// loads, or the program next stops, reported by a DebugBreakpointChanged event.
+func (d *Debugger) SetBreakpoint(path string, line int) Breakpoint {
+	d.mu.Lock()
+	d.nextID++
+	bp := &breakpointState{Breakpoint: Breakpoint{ID: d.nextID, Path: path, Line: line}}
+	d.breakpoints = append(d.breakpoints, bp)
+	d.dirty = true
+	d.mu.Unlock()
+
+	d.onPaused(func() { d.plantBreakpoints() })
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return bp.Breakpoint
+}
+
+// SetBreakpoints replaces the breakpoints of a file with breakpoints on the
+// given lines, returning them in the same order
+func (d *Debugger) SetBreakpoints(path string, lines []int) []Breakpoint {
+	d.mu.Lock()
+	file := debugPath(path)
+	kept := d.breakpoints[:0]
+	for _, bp := range d.breakpoints {
+		if debugPath(bp.Path) != file {
+			kept = append(kept, bp)
+		}
+	}
+	added := make([]*breakpointState, len(lines))
+	for i, line := range lines {
+		d.nextID++
+		added[i] = &breakpointState{Breakpoint: Breakpoint{ID: d.nextID, Path: path, Line: line}}
+		kept = append(kept, added[i])
+	}
+	d.breakpoints = kept
+	d.dirty = true
+	d.mu.Unlock()
+
+	d.onPaused(func() { d.plantBreakpoints() })
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]Breakpoint, len(added))
+	for i, bp := range added {
+		result[i] = bp.Breakpoint
+	}
+	return result
+}
+
+// ClearBreakpoint removes the breakpoint with the given ID
+func (d *Debugger) ClearBreakpoint(id int) error {
+	d.mu.Lock()
+	found := false
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			found = true
+			break
+		}
+	}
+	d.dirty = d.dirty || found
+	d.mu.Unlock()
+	if !found {
+		return fmt.Errorf("no breakpoint %d", id)
+	}
+	d.onPaused(func() { d.plantBreakpoints() })
+	return nil
+}
+
+// Breakpoints returns the breakpoints, in the order they were set
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]Breakpoint, len(d.breakpoints))
+	for i, bp := range d.breakpoints {
+		result[i] = bp.Breakpoint
+	}
+	return result
+}
+
+// Stack returns the frames of the paused program, innermost first
+func (d *Debugger) Stack() ([]DebugFrame, error) {
+	var frames []DebugFrame
+	err := d.onPaused(func() {
+		for i, frame := range d.vm.DebugFrames() {
+			frames = append(frames, DebugFrame{
+				Index:    i,
+				Function: frame.Function.Name,
+				Path:     d.chunkPaths[frame.Function.Chunk],
+				Line:     frame.Line(),
+				Column:   frame.Column(),
+			})
+		}
+	})
+	return frames, err
+}
+
+// Scopes returns the variables visible from a frame of the paused program:
+// the frame's locals, named from its scope descriptor, and the program's
+// top-level declarations
+func (d *Debugger) Scopes(frame int) ([]DebugScope, error) {
+	var scopes []DebugScope
+	var scopeErr error
+	err := d.onPaused(func() {
+		frames := d.vm.DebugFrames()
+		if frame < 0 || frame >= len(frames) {
+			scopeErr = fmt.Errorf("no frame %d", frame)
+			return
+		}
+		scopes = []DebugScope{
+			{Name: "Local", Variables: frameLocals(frames[frame])},
+			{Name: "Global", Variables: d.programGlobals()},
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scopes, scopeErr
+}
+
+// Evaluate evaluates an expression in a frame of the paused program, with the
+// frame's locals in scope. Breakpoints are not hit during evaluation. A thrown
+// exception is returned as a *JSError.
+func (d *Debugger) Evaluate(frame int, expression string) (vm.Value, error) {
+	var result vm.Value
+	var evalErr error
+	err := d.onPaused(func() {
+		// Debugger expressions are not type checked against the paused frame
+		savedIgnore := d.p.ignoreTypeErrors
+		d.p.SetIgnoreTypeErrors(true)
+		d.evaluating = true
+		result, evalErr = d.vm.EvalInFrame(frame, expression)
+		d.evaluating = false
+		d.p.SetIgnoreTypeErrors(savedIgnore)
+		evalErr = d.p.wrapError(evalErr)
+	})
+	if err != nil {
+		return vm.Undefined, err
+	}
+	return result, evalErr
+}
+
+// Properties returns the own data properties of an object or the elements of
+// an array, for expanding a variable of the paused program. Getters are not
+// run. Other values have no properties.
+func (d *Debugger) Properties(value vm.Value) ([]DebugVariable, error) {
+	var props []DebugVariable
+	err := d.onPaused(func() {
+		switch value.Type() {
+		case vm.TypeArray:
+			arr := value.AsArray()
+			for i := 0; i < arr.Length(); i++ {
+				props = append(props, DebugVariable{Name: strconv.Itoa(i), Value: arr.Get(i)})
+			}
+			props = append(props, DebugVariable{Name: "length", Value: vm.NumberValue(float64(arr.Length()))})
+		case vm.TypeObject:
+			obj := value.AsPlainObject()
+			for _, key := range obj.OwnKeys() {
+				if v, ok := obj.GetOwn(key); ok {
+					props = append(props, DebugVariable{Name: key, Value: v})
+				}
+			}
+		}
+	})
+	return props, err
+}
+
+// frameLocals returns the named registers of a frame
+func frameLocals(frame vm.DebugFrame) []DebugVariable {
+	desc := frame.Function.Chunk.ScopeDesc
+	if desc == nil {
+		return nil
+	}
+	var locals []DebugVariable
+	for reg, name := range desc.LocalNames {
+		if reg >= len(frame.Registers) || !isDebugName(name) {
+			continue
+		}
+		value := frame.Registers[reg]
+		if value.Type() == vm.TypeUninitialized {
+			continue // Not yet declared, or in a scope not entered
+		}
+		locals = append(locals, DebugVariable{Name: name, Value: value})
+	}
+	return locals
+}
+
+// programGlobals returns the global variables declared by the program
+func (d *Debugger) programGlobals() []DebugVariable {
+	heap := d.vm.GetHeap()
+	var globals []DebugVariable
+	for i := d.globalBase; i < heap.Size(); i++ {
+		name := heap.GetNameByIndex(i)
+		value, ok := heap.Get(i)
+		if !ok || !isDebugName(name) || value.Type() == vm.TypeUninitialized {
+			continue
+		}
+		globals = append(globals, DebugVariable{Name: name, Value: value})
+	}
+	return globals
+}
+
+// isDebugName reports whether a register or global name is a source-level
+// identifier rather than a compiler temporary
+func isDebugName(name string) bool {
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// debugPath normalizes a file path for matching breakpoints to loaded files
+func debugPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// debugHook receives the VM's notifications on the script's goroutine
+type debugHook struct{ d *Debugger }
+
+func (h debugHook) EnterChunk(chunk *vm.Chunk, path string) {
+	d := h.d
+	if d.evaluating || d.ctx.Err() != nil {
+		return
+	}
+	d.indexChunk(chunk, debugPath(path))
+	d.mu.Lock()
+	d.dirty = true
+	d.mu.Unlock()
+	changed := d.plantBreakpoints()
+	for _, bp := range changed {
+		d.emit(DebugEvent{Kind: DebugBreakpointChanged, Breakpoint: bp})
+	}
+	if d.step == stepIn {
+		d.plantStepTraps(chunk)
+	}
+	if d.stopOnEntry {
+		d.stopOnEntry = false
+		d.pause(StopEntry, nil)
+	}
+}
+
+func (h debugHook) Trap(chunk *vm.Chunk, ip int) {
+	d := h.d
+	if d.evaluating || d.ctx.Err() != nil {
+		return
+	}
+	d.mu.Lock()
+	dirty := d.dirty
+	d.mu.Unlock()
+	if dirty {
+		for _, bp := range d.plantBreakpoints() {
+			d.emit(DebugEvent{Kind: DebugBreakpointChanged, Breakpoint: bp})
+		}
+	}
+
+	site := trapSite{chunk, ip}
+	switch {
+	case chunk.OriginalOpCode(ip) == vm.OpDebug:
+		d.pause(StopDebuggerStatement, nil)
+	case len(d.userSites[site]) > 0:
+		d.pause(StopBreakpoint, d.userSites[site])
+	case d.stepSites[site] && d.stepDone(chunk, ip):
+		d.pause(StopStep, nil)
+	}
+}
+
+// indexChunk records the file of a chunk and of the functions nested in it
+func (d *Debugger) indexChunk(chunk *vm.Chunk, path string) {
+	if chunk == nil {
+		return
+	}
+	if _, ok := d.chunkPaths[chunk]; ok {
+		return
+	}
+	d.chunkPaths[chunk] = path
+	for _, constant := range chunk.Constants {
+		if constant.Type() == vm.TypeFunction {
+			d.indexChunk(constant.AsFunction().Chunk, path)
+		}
+	}
+}
+
+// instructionOffsets returns the cached instruction offsets of a chunk
+func (d *Debugger) instructionOffsets(chunk *vm.Chunk) []int {
+	offsets, ok := d.offsets[chunk]
+	if !ok {
+		offsets = chunk.InstructionOffsets()
+		d.offsets[chunk] = offsets
+	}
+	return offsets
+}
+
+// pause blocks the script's goroutine, serving commands until one resumes it
+func (d *Debugger) pause(reason StopReason, hits []int) {
+	d.clearStepTraps()
+	d.step = stepNone
+	d.mu.Lock()
+	d.paused = true
+	d.mu.Unlock()
+	d.emit(DebugEvent{Kind: DebugStopped, Reason: reason, Breakpoints: append([]int(nil), hits...)})
+
+	for {
+		select {
+		case cmd := <-d.commands:
+			if cmd.run != nil {
+				cmd.run()
+				close(cmd.done)
+				continue
+			}
+			d.mu.Lock()
+			d.paused = false
+			d.mu.Unlock()
+			d.beginStep(cmd.step)
+			return
+		case <-d.ctx.Done():
+			d.mu.Lock()
+			d.paused = false
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+// emit reports an event from the running program. Events are dropped once the
+// program is terminated, so a controller that stopped reading does not block it.
+func (d *Debugger) emit(event DebugEvent) {
+	select {
+	case d.events <- event:
+	case <-d.ctx.Done():
+	}
+}
+
+// beginStep records where a step starts and plants its traps
+func (d *Debugger) beginStep(mode stepMode) {
+	frames := d.vm.DebugFrames()
+	if mode == stepNone || len(frames) == 0 {
+		return
+	}
+	d.step = mode
+	d.stepDepth = len(frames)
+	d.stepChunk = frames[0].Function.Chunk
+	d.stepLine = frames[0].Line()
+
+	// Callers are reached by returning; anything else only by calling in
+	start := 0
+	if mode == stepOut {
+		start = 1
+	}
+	for _, frame := range frames[start:] {
+		d.plantStepTraps(frame.Function.Chunk)
+	}
+	if mode == stepIn {
+		for chunk := range d.chunkPaths {
+			d.plantStepTraps(chunk)
+		}
+	}
+}
+
+// stepDone reports whether execution reaching ip completes the current step
+func (d *Debugger) stepDone(chunk *vm.Chunk, ip int) bool {
+	if chunk.GetLine(ip) <= 0 {
+		// Compiler-generated code with no source position is never a stop
+		return false
+	}
+	depth := len(d.vm.DebugFrames())
+	newLine := chunk != d.stepChunk || chunk.GetLine(ip) != d.stepLine
+	switch d.step {
+	case stepIn:
+		return depth != d.stepDepth || newLine
+	case stepOver:
+		return depth < d.stepDepth || (depth == d.stepDepth && newLine)
+	case stepOut:
+		return depth < d.stepDepth
+	}
+	return false
+}
+
+// plantStepTraps plants a step trap on every instruction of a chunk
+func (d *Debugger) plantStepTraps(chunk *vm.Chunk) {
+	for _, ip := range d.instructionOffsets(chunk) {
+		site := trapSite{chunk, ip}
+		if d.stepSites[site] {
+			continue
+		}
+		d.stepSites[site] = true
+		d.vm.SetBreakpoint(chunk, ip)
+	}
+}
+
+// clearStepTraps removes the step traps, keeping breakpoints planted
+func (d *Debugger) clearStepTraps() {
+	for site := range d.stepSites {
+		if _, ok := d.userSites[site]; !ok {
+			d.vm.ClearBreakpoint(site.chunk, site.ip)
+		}
+	}
+	clear(d.stepSites)
+}
+
+// plantBreakpoints resolves the breakpoints against the loaded chunks and
+// replants their traps. It returns the breakpoints whose resolution changed.
+func (d *Debugger) plantBreakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return nil
+	}
+	d.dirty = false
+
+	sites := make(map[trapSite][]int)
+	var changed []Breakpoint
+	for _, bp := range d.breakpoints {
+		before := bp.Breakpoint
+		bp.sites, bp.ResolvedLine = d.resolveLine(debugPath(bp.Path), bp.Line)
+		bp.Verified = len(bp.sites) > 0
+		for _, site := range bp.sites {
+			sites[site] = append(sites[site], bp.ID)
+		}
+		if bp.Breakpoint != before {
+			changed = append(changed, bp.Breakpoint)
+		}
+	}
+	for site := range d.userSites {
+		if _, keep := sites[site]; !keep && !d.stepSites[site] {
+			d.vm.ClearBreakpoint(site.chunk, site.ip)
+		}
+	}
+	for site := range sites {
+		d.vm.SetBreakpoint(site.chunk, site.ip)
+	}
+	d.userSites = sites
+	return changed
+}
+
+// resolveLine finds the instructions a breakpoint on a line of a file stops
+// at: the first instruction of that line, or of the next line with code, in
+// each function of the file
+func (d *Debugger) resolveLine(path string, line int) ([]trapSite, int) {
+	var chunks []*vm.Chunk
+	for chunk, chunkPath := range d.chunkPaths {
+		if chunkPath == path {
+			chunks = append(chunks, chunk)
+		}
+	}
+	line = max(line, 1)
+	target := 0
+	for _, chunk := range chunks {
+		for _, ip := range d.instructionOffsets(chunk) {
+			if l := chunk.GetLine(ip); l >= line && (target == 0 || l < target) {
+				target = l
+			}
+		}
+	}
+	if target == 0 {
+		return nil, 0
+	}
+	var sites []trapSite
+	for _, chunk := range chunks {
+		for _, ip := range d.instructionOffsets(chunk) {
+			if chunk.GetLine(ip) == target {
+				sites = append(sites, trapSite{chunk, ip})
+				break
+			}
+		}
+	}
+	return sites, target
+}
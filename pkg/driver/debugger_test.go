@@ -0,0 +1,291 @@
+package driver
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+const debugScript = `let total = 0;
+function add(a: number, b: number): number {
+  let sum = a + b;
+  return sum;
+}
+
+for (let i = 0; i < 3; i++) {
+  total = add(total, i);
+}
+debugger;
+total;
+`
+
+const debugPathName = "/tmp/debug_script.ts"
+
+// nextStop returns the next stop event, failing the test if the program exits
+// or stalls first
+func nextStop(t *testing.T, d *Debugger) DebugEvent {
+	t.Helper()
+	for {
+		select {
+		case ev, ok := <-d.Events():
+			if !ok || ev.Kind == DebugExited {
+				t.Fatalf("program exited before stopping: %v", ev.Errors)
+			}
+			if ev.Kind == DebugStopped {
+				return ev
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the program to stop")
+		}
+	}
+}
+
+// waitExit drains events until the program exits and returns the exit event
+func waitExit(t *testing.T, d *Debugger) DebugEvent {
+	t.Helper()
+	for {
+		select {
+		case ev, ok := <-d.Events():
+			if !ok {
+				t.Fatalf("events closed without an exit event")
+			}
+			if ev.Kind == DebugStopped {
+				t.Fatalf("unexpected stop: %+v", ev)
+			}
+			if ev.Kind == DebugExited {
+				return ev
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the program to exit")
+		}
+	}
+}
+
+// expectLocation checks the innermost frame of the paused program
+func expectLocation(t *testing.T, d *Debugger, function string, line int) []DebugFrame {
+	t.Helper()
+	frames, err := d.Stack()
+	if err != nil {
+		t.Fatalf("Stack: %v", err)
+	}
+	if len(frames) == 0 || frames[0].Function != function || frames[0].Line != line {
+		t.Fatalf("expected to be paused in %s at line %d, got %+v", function, line, frames)
+	}
+	return frames
+}
+
+// scopeValues returns the variables of a scope as inspected strings
+func scopeValues(t *testing.T, d *Debugger, frame int, scope string) map[string]string {
+	t.Helper()
+	scopes, err := d.Scopes(frame)
+	if err != nil {
+		t.Fatalf("Scopes: %v", err)
+	}
+	values := make(map[string]string)
+	for _, s := range scopes {
+		if s.Name == scope {
+			for _, v := range s.Variables {
+				values[v.Name] = v.Value.Inspect()
+			}
+		}
+	}
+	return values
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	d := p.NewDebugger()
+	bp := d.SetBreakpoint(debugPathName, 4)
+	if err := d.Start(debugScript, debugPathName, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		ev := nextStop(t, d)
+		if ev.Reason != StopBreakpoint || len(ev.Breakpoints) != 1 || ev.Breakpoints[0] != bp.ID {
+			t.Fatalf("expected a stop at breakpoint %d, got %+v", bp.ID, ev)
+		}
+		frames := expectLocation(t, d, "add", 4)
+		if len(frames) != 2 || frames[1].Function != "<script>" || frames[1].Line != 8 || frames[1].Path != debugPathName {
+			t.Fatalf("unexpected stack %+v", frames)
+		}
+		locals := scopeValues(t, d, 0, "Local")
+		if locals["a"] != []string{"0", "0", "1"}[i] || locals["b"] != []string{"0", "1", "2"}[i] {
+			t.Fatalf("iteration %d: unexpected locals %v", i, locals)
+		}
+		if globals := scopeValues(t, d, 0, "Global"); globals["total"] != locals["a"] {
+			t.Fatalf("iteration %d: unexpected globals %v", i, globals)
+		}
+		if err := d.Continue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ev := nextStop(t, d)
+	if ev.Reason != StopDebuggerStatement {
+		t.Fatalf("expected the debugger statement, got %+v", ev)
+	}
+	expectLocation(t, d, "<script>", 10)
+	if err := d.ClearBreakpoint(bp.ID); err != nil {
+		t.Fatal(err)
+	}
+	d.Continue()
+	exit := waitExit(t, d)
+	if len(exit.Errors) > 0 || exit.Value.ToFloat() != 3 {
+		t.Fatalf("expected the program to complete with 3, got %s %v", exit.Value.Inspect(), exit.Errors)
+	}
+
+	// No trap survives the session
+	value, errs := p.RunCode("add(20, 22);", RunOptions{})
+	if len(errs) > 0 || value.ToFloat() != 42 {
+		t.Fatalf("expected 42 after the session, got %s %v", value.Inspect(), errs)
+	}
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	d := p.NewDebugger()
+	d.SetBreakpoint(debugPathName, 8)
+	d.Start(debugScript, debugPathName, false)
+
+	nextStop(t, d)
+	expectLocation(t, d, "<script>", 8)
+
+	steps := []struct {
+		step     func() error
+		function string
+		line     int
+	}{
+		{d.StepIn, "add", 3},
+		{d.StepOver, "add", 4},
+		{d.StepOut, "<script>", 8},
+		{d.StepOver, "<script>", 7},
+		{d.StepOver, "<script>", 8},
+		{d.StepOver, "<script>", 7},
+	}
+	for _, s := range steps {
+		if err := s.step(); err != nil {
+			t.Fatal(err)
+		}
+		ev := nextStop(t, d)
+		if ev.Reason != StopStep && ev.Reason != StopBreakpoint {
+			t.Fatalf("unexpected stop %+v", ev)
+		}
+		expectLocation(t, d, s.function, s.line)
+	}
+
+	d.SetBreakpoints(debugPathName, nil)
+	if bps := d.Breakpoints(); len(bps) != 0 {
+		t.Fatalf("breakpoints not cleared: %v", bps)
+	}
+	d.Continue()
+	if ev := nextStop(t, d); ev.Reason != StopDebuggerStatement {
+		t.Fatalf("expected the debugger statement, got %+v", ev)
+	}
+	d.Continue()
+	waitExit(t, d)
+}
+
+func TestDebuggerStopOnEntry(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	d := p.NewDebugger()
+	d.Start(debugScript, debugPathName, true)
+
+	if ev := nextStop(t, d); ev.Reason != StopEntry {
+		t.Fatalf("expected an entry stop, got %+v", ev)
+	}
+	// Breakpoints set while paused are verified right away; one on a line
+	// without code moves to the next line that has some
+	bp := d.SetBreakpoint(debugPathName, 6)
+	if !bp.Verified || bp.ResolvedLine != 7 {
+		t.Fatalf("expected the breakpoint to resolve to line 7, got %+v", bp)
+	}
+	d.Continue()
+	nextStop(t, d)
+	expectLocation(t, d, "<script>", 7)
+
+	if err := d.ClearBreakpoint(bp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ClearBreakpoint(bp.ID); err == nil {
+		t.Fatalf("expected an error clearing a removed breakpoint")
+	}
+	d.Continue()
+	nextStop(t, d) // debugger statement
+	if err := d.Continue(); err != nil {
+		t.Fatal(err)
+	}
+	waitExit(t, d)
+	if err := d.Continue(); err != ErrNotPaused {
+		t.Fatalf("expected ErrNotPaused after exit, got %v", err)
+	}
+}
+
+func TestDebuggerEvaluate(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	d := p.NewDebugger()
+	d.SetBreakpoint(debugPathName, 4)
+	d.Start(debugScript, debugPathName, false)
+	nextStop(t, d)
+	d.Continue()
+	nextStop(t, d) // add(0, 1)
+
+	value, err := d.Evaluate(0, "sum * 10 + a")
+	if err != nil || value.ToFloat() != 10 {
+		t.Fatalf("expected 10, got %s %v", value.Inspect(), err)
+	}
+	value, err = d.Evaluate(1, "total + i")
+	if err != nil || value.ToFloat() != 1 {
+		t.Fatalf("expected 1 in the caller's frame, got %s %v", value.Inspect(), err)
+	}
+
+	// A thrown exception is reported without disturbing the paused program
+	_, err = d.Evaluate(0, "missing + 1")
+	var jsErr *JSError
+	if err == nil || !stderrors.As(err, &jsErr) || !strings.HasPrefix(jsErr.Message, "ReferenceError: missing") {
+		t.Fatalf("expected a ReferenceError, got %v", err)
+	}
+	if _, err := d.Evaluate(5, "1"); err == nil {
+		t.Fatalf("expected an error for a missing frame")
+	}
+
+	// Evaluation can change the program's state
+	if _, err := d.Evaluate(0, "sum = 100"); err != nil {
+		t.Fatal(err)
+	}
+	d.SetBreakpoints(debugPathName, nil)
+	d.Continue()
+	nextStop(t, d) // debugger statement
+	d.Continue()
+	exit := waitExit(t, d)
+	if len(exit.Errors) > 0 || exit.Value.ToFloat() != 102 {
+		t.Fatalf("expected 102, got %s %v", exit.Value.Inspect(), exit.Errors)
+	}
+}
+
+func TestDebuggerTerminate(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	d := p.NewDebugger()
+	d.Start("let n = 0;\nwhile (true) {\n  n++;\n}\n", debugPathName, true)
+	nextStop(t, d)
+	d.StepOver()
+	nextStop(t, d)
+	d.Terminate()
+
+	exit := waitExit(t, d)
+	if len(exit.Errors) != 1 {
+		t.Fatalf("expected a termination error, got %v", exit.Errors)
+	}
+	var term *vm.TerminationError
+	if !stderrors.As(ErrorList(exit.Errors), &term) || term.Reason != vm.TerminationCancelled {
+		t.Fatalf("expected a cancellation, got %v", exit.Errors)
+	}
+}
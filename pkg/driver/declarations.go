@@ -0,0 +1,151 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/source"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// Ambient declarations made anywhere in a session - by declaration files
+// loaded through LoadDeclarations or reference directives, and by the
+// `declare global` and `declare module` blocks of checked modules - are
+// shared by all of its checkers. Declaration files only describe values
+// that exist elsewhere, such as the host objects an embedder sets from Go
+// or a native module's exports, so they are checked but never compiled.
+
+// LoadDeclarations loads the declaration file at path, relative to the
+// session's base directory unless absolute, and makes its ambient
+// declarations visible to the code the session checks
+func (p *Paserati) LoadDeclarations(path string) error {
+	content, err := os.ReadFile(p.projectPath(path))
+	if err != nil {
+		return err
+	}
+	return p.AddDeclarations(path, string(content))
+}
+
+// AddDeclarations checks source, the contents of the declaration file
+// filename, and makes its ambient declarations visible to the code the
+// session checks. Errors in the file are returned.
+func (p *Paserati) AddDeclarations(filename string, source string) error {
+	declarations, errs := p.checkDeclarations(filename, source)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	p.addDeclarations(declarations)
+	return nil
+}
+
+// configureDeclarations makes a checker see the session's ambient
+// declarations, add the ones it checks to them and load the files its
+// reference directives name
+func (p *Paserati) configureDeclarations(c *checker.Checker) {
+	if p.declarations != nil {
+		c.InstallDeclarations(p.declarations)
+	}
+	c.SetDeclarationsHandler(p.addDeclarations)
+	c.SetReferenceResolver(p.resolveReference)
+}
+
+// addDeclarations adds ambient declarations to the session
+func (p *Paserati) addDeclarations(declarations *checker.Declarations) {
+	if p.declarations == nil {
+		p.declarations = checker.NewDeclarations()
+	}
+	p.declarations.Merge(declarations)
+	p.checker.InstallDeclarations(declarations)
+}
+
+// declaredGlobal returns the type ambient declarations give the global name
+func (p *Paserati) declaredGlobal(name string) (types.Type, bool) {
+	if p.declarations == nil {
+		return nil, false
+	}
+	typ, ok := p.declarations.Values[name]
+	return typ, ok
+}
+
+// resolveReference loads the declaration file a `/// <reference path="..." />`
+// or `/// <reference types="..." />` directive of the file at fromPath names
+func (p *Paserati) resolveReference(directive parser.ReferenceDirective, fromPath string) (*checker.Declarations, error) {
+	var path string
+	switch directive.Kind {
+	case "path":
+		path = directive.Value
+		if !filepath.IsAbs(path) {
+			path = pathpkg.Join(pathpkg.Dir(filepath.ToSlash(fromPath)), path)
+		}
+	case "types":
+		resolved, err := p.nodeResolver.ResolveTypes(directive.Value, fromPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find type definition file for '%s'", directive.Value)
+		}
+		path = resolved
+	default:
+		return checker.NewDeclarations(), nil // Other directives don't load declarations
+	}
+
+	if declarations, ok := p.referenced[path]; ok {
+		return declarations, nil
+	}
+	content, err := os.ReadFile(p.projectPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find referenced file '%s'", directive.Value)
+	}
+	if p.referenced == nil {
+		p.referenced = make(map[string]*checker.Declarations)
+	}
+	p.referenced[path] = checker.NewDeclarations() // Files may reference each other
+	declarations, errs := p.checkDeclarations(path, string(content))
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	p.referenced[path] = declarations
+	p.addDeclarations(declarations)
+	return declarations, nil
+}
+
+// checkDeclarations parses and checks a declaration file, returning the
+// ambient declarations it makes
+func (p *Paserati) checkDeclarations(filename string, content string) (*checker.Declarations, []errors.PaseratiError) {
+	sourceFile := source.FromFile(filename, content)
+	program, parseErrs := parser.NewParser(lexer.NewLexerWithSource(sourceFile)).ParseProgram()
+	if len(parseErrs) > 0 {
+		return nil, parseErrs
+	}
+	return p.checkDeclarationProgram(filename, program)
+}
+
+// checkDeclarationProgram checks program as the declaration file filename,
+// returning the ambient declarations it makes
+func (p *Paserati) checkDeclarationProgram(filename string, program *parser.Program) (*checker.Declarations, []errors.PaseratiError) {
+	declarationChecker := p.newChecker()
+	declarationChecker.EnableModuleMode(filename, p.moduleLoader)
+	p.defineEmbeddedGlobals(declarationChecker)
+	p.configureChecker(declarationChecker)
+	if p.declarations != nil {
+		declarationChecker.InstallDeclarations(p.declarations)
+	}
+	declarationChecker.SetReferenceResolver(p.resolveReference)
+	if errs := declarationChecker.Check(program); len(errs) > 0 {
+		return nil, errs
+	}
+	return declarationChecker.Declarations(), nil
+}
+
+// projectPath returns the file system path of path, which is relative to
+// the session's base directory unless absolute
+func (p *Paserati) projectPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.baseDir, filepath.FromSlash(path))
+}
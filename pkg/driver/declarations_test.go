@@ -0,0 +1,162 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadDeclarations(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"host.d.ts": `
+			interface Settings { verbose: boolean; }
+			declare const settings: Settings;
+			declare function greet(name: string): string;
+			declare function greet(name: string, times: number): string;
+		`,
+	})
+	if err := p.LoadDeclarations("host.d.ts"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("settings", map[string]any{"verbose": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("greet", func(name string) string { return "hello, " + name }); err != nil {
+		t.Fatal(err)
+	}
+
+	value, errs := p.RunString(`
+		const s: Settings = settings;
+		s.verbose ? greet("world") : greet("nobody", 2);
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if value.ToString() != "hello, world" {
+		t.Errorf("expected 'hello, world', got %s", value.Inspect())
+	}
+
+	_, errs = p.RunString(`const n: number = greet("world");`)
+	if !strings.Contains(errorMessages(errs), "cannot assign type 'string' to variable 'n' of type 'number'") {
+		t.Errorf("expected greet's declared return type to be checked, got: %s", errorMessages(errs))
+	}
+}
+
+func TestDeclaredClassAccessors(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"box.d.ts": `
+			declare class Box {
+				get size(): number;
+				set size(value: number);
+				static get count(): number;
+			}
+		`,
+	})
+	if err := p.LoadDeclarations("box.d.ts"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, errs := p.RunString(`function area(b: Box): number { b.size = 2; return b.size * Box.count; }`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	_, errs = p.RunString(`function label(b: Box): string { return b.size; }`)
+	if !strings.Contains(errorMessages(errs), "cannot return value of type number") {
+		t.Errorf("expected the getter's declared type to be checked, got: %s", errorMessages(errs))
+	}
+}
+
+func TestDeclarationsTypeJavaScript(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"lib/format.js":                   `export function format(n) { return "#" + n; }`,
+		"lib/format.d.ts":                 `export declare function format(n: number): string;`,
+		"node_modules/typed/package.json": `{"main": "main.js", "types": "types/main.d.ts"}`,
+		"node_modules/typed/main.js":      `export const answer = 42;`,
+		"node_modules/typed/types/main.d.ts": `
+			export interface Answer { value: number; }
+			export declare const answer: number;
+		`,
+		"node_modules/untyped/index.js":             `export default function twice(s) { return s + s; }`,
+		"node_modules/@types/untyped/index.d.ts":    `declare function twice(s: string): string; export = twice;`,
+		"node_modules/@scope/pkg/index.js":          `export const scoped = "yes";`,
+		"node_modules/@types/scope__pkg/index.d.ts": `export declare const scoped: string;`,
+	})
+
+	value, errs := p.RunString(`
+		import { format } from "./lib/format.js";
+		import { answer, type Answer } from "typed";
+		import twice from "untyped";
+		import { scoped } from "@scope/pkg";
+		const a: Answer = { value: answer };
+		twice(format(a.value)) + scoped;
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if value.ToString() != "#42#42yes" {
+		t.Errorf("expected '#42#42yes', got %s", value.Inspect())
+	}
+
+	_, errs = p.RunString(`
+		import { format } from "./lib/format.js";
+		import twice from "untyped";
+		format("x");
+		const n: number = twice("x");
+	`)
+	messages := errorMessages(errs)
+	for _, expected := range []string{"to parameter of type 'number'", "cannot assign type 'string' to variable 'n' of type 'number'"} {
+		if !strings.Contains(messages, expected) {
+			t.Errorf("expected %q in:\n%s", expected, messages)
+		}
+	}
+}
+
+func TestAmbientModulesAndGlobals(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"types/host.d.ts": `
+			/// <reference path="./clock.d.ts" />
+			declare module "math-host" {
+				export interface Pair { quotient: number; remainder: number; }
+				export function divmod(a: number, b: number): Pair;
+			}
+		`,
+		"types/clock.d.ts": `declare function now(): number;`,
+		"setup.ts": `
+			declare global {
+				var appName: string;
+			}
+			export {};
+		`,
+	})
+	p.DeclareModule("math-host", func(m *ModuleBuilder) {
+		m.Function("divmod", func(a, b float64) map[string]float64 {
+			return map[string]float64{"quotient": float64(int(a) / int(b)), "remainder": float64(int(a) % int(b))}
+		})
+	})
+	if err := p.Set("now", func() float64 { return 7 }); err != nil {
+		t.Fatal(err)
+	}
+
+	value, errs := p.RunString(`
+		/// <reference path="./types/host.d.ts" />
+		import { divmod, type Pair } from "math-host";
+		import "./setup.ts";
+		const r: Pair = divmod(now(), 2);
+		r.quotient * 10 + r.remainder;
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if value.ToFloat() != 31 {
+		t.Errorf("expected 31, got %s", value.Inspect())
+	}
+
+	_, errs = p.RunString(`
+		import { divmod } from "math-host";
+		const s: string = divmod(1, 2).quotient;
+		const n: number = appName;
+	`)
+	messages := errorMessages(errs)
+	if !strings.Contains(messages, "variable 's'") || !strings.Contains(messages, "variable 'n'") {
+		t.Errorf("expected the ambient module and global types to be checked, got:\n%s", messages)
+	}
+}
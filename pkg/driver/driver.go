@@ -1,11 +1,14 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/nooga/paserati/pkg/aot"
 	"github.com/nooga/paserati/pkg/builtins"
 	"github.com/nooga/paserati/pkg/checker"
 	"github.com/nooga/paserati/pkg/compiler"
@@ -14,6 +17,8 @@ import (
 	"github.com/nooga/paserati/pkg/modules"
 	"github.com/nooga/paserati/pkg/parser"
 	"github.com/nooga/paserati/pkg/source"
+	"github.com/nooga/paserati/pkg/tsconfig"
+	"github.com/nooga/paserati/pkg/types"
 	"github.com/nooga/paserati/pkg/vm"
 )
 
@@ -57,6 +62,30 @@ type Paserati struct {
 	nativeResolver   *NativeModuleResolver // *NativeModuleResolver - defined in native_module.go to avoid import cycles
 	ignoreTypeErrors bool                  // When true, type checking errors are ignored and compilation continues
 	skipTypeCheck    bool                  // When true, type checker is not run at all (for pure JS mode)
+	debugInfo        bool                  // When true, code is compiled with debug info for the debugger
+	uncheckedEval    bool                  // When true, eval and Function code is not type checked (see RunBinaryModule)
+	strictNullChecks bool                  // When true, null and undefined are distinct types (see SetStrictNullChecks)
+
+	// Builtins the session was made with, nil for the standard ones (see
+	// newChecker)
+	initializers []builtins.BuiltinInitializer
+
+	// Project state (see tsconfig.go)
+	baseDir      string                // Directory modules are resolved in
+	nodeResolver *modules.NodeResolver // Resolver of packages and tsconfig.json path mappings
+	config       *tsconfig.Config      // The project's tsconfig.json, nil without one
+
+	// Sources of modules being edited, ahead of their files (see analysis.go)
+	overrides *modules.MemoryResolver
+
+	// Ambient declarations (see declarations.go)
+	declarations *checker.Declarations            // Declarations shared by the session's checkers, nil if none
+	referenced   map[string]*checker.Declarations // Declaration files loaded by reference directives, by path
+
+	// Embedding API state (see embed.go)
+	valueConverter *ValueConverter       // Converter used by Set/Get/Function calls
+	embedGlobals   map[string]types.Type // Types of globals set from Go, declared in module checkers too
+	embedModules   map[string]*Module    // Modules imported through Import, keyed by specifier
 }
 
 // SetIgnoreTypeErrors sets whether type checking errors should be ignored
@@ -78,6 +107,14 @@ func (p *Paserati) SetSkipTypeCheck(skip bool) {
 	}
 }
 
+// SetDebugInfo sets whether code, including imported modules, is compiled
+// with the debug info a Debugger needs to name variables and stop at
+// debugger statements
+func (p *Paserati) SetDebugInfo(enabled bool) {
+	p.debugInfo = enabled
+	p.compiler.SetDebugInfo(enabled)
+}
+
 // SetSkipStrictPropertyInit controls whether TS2564 is emitted. Default false
 // (emit). Used by paserati-testtsc to opt out per-file based on TS directives.
 func (p *Paserati) SetSkipStrictPropertyInit(skip bool) {
@@ -90,6 +127,15 @@ func (p *Paserati) SetNoImplicitOverride(enabled bool) {
 	p.checker.SetNoImplicitOverride(enabled)
 }
 
+// SetStrictNullChecks controls whether null and undefined are checked as
+// distinct types, like tsc's --strictNullChecks. It applies to the session's
+// checker and those of modules loaded afterwards, and overrides the
+// project's tsconfig.json.
+func (p *Paserati) SetStrictNullChecks(enabled bool) {
+	p.strictNullChecks = enabled
+	p.checker.SetStrictNullChecks(enabled)
+}
+
 // SetAllowTopLevelReturn controls whether script/eval style top-level returns
 // are accepted by the type checker.
 func (p *Paserati) SetAllowTopLevelReturn(allow bool) {
@@ -157,6 +203,14 @@ func NewPaseratiWithInitializersAndBaseDir(customInitializers []builtins.Builtin
 	// Create unified heap allocator for coordinating global indices
 	heapAlloc := compiler.NewHeapAlloc()
 
+	// Prefer up-to-date compiled .psm files over their sources
+	moduleLoader.AddResolver(aot.NewResolver(os.DirFS(baseDir), baseDir, heapAlloc.GetOrAssignIndex))
+
+	// Bare specifiers name packages in node_modules, or files through the
+	// path mappings of the project's tsconfig.json
+	nodeResolver := modules.NewNodeResolver(os.DirFS(baseDir), baseDir)
+	moduleLoader.AddResolver(nodeResolver)
+
 	// Create checker and compiler with custom initializers
 	typeChecker := checker.NewCheckerWithInitializers(customInitializers)
 	comp := compiler.NewCompiler()
@@ -171,6 +225,9 @@ func NewPaseratiWithInitializersAndBaseDir(customInitializers []builtins.Builtin
 		compiler:     comp,
 		moduleLoader: moduleLoader,
 		heapAlloc:    heapAlloc,
+		baseDir:      baseDir,
+		nodeResolver: nodeResolver,
+		initializers: customInitializers,
 	}
 
 	// Wire the module loader into the VM
@@ -198,6 +255,12 @@ func NewPaseratiWithInitializersAndBaseDir(customInitializers []builtins.Builtin
 		newChecker := checker.NewCheckerWithInitializers(customInitializers)
 		// Enable module mode so the checker can resolve imports
 		newChecker.EnableModuleMode("", moduleLoader)
+		// Declare globals set from Go so module code can refer to them
+		paserati.defineEmbeddedGlobals(newChecker)
+		// Check modules with the options of the project's tsconfig.json
+		paserati.configureChecker(newChecker)
+		// Check modules against the session's ambient declarations
+		paserati.configureDeclarations(newChecker)
 		debugPrintf("// [Driver] Created new checker for module: %p\n", newChecker)
 		return newChecker
 	})
@@ -211,6 +274,7 @@ func NewPaseratiWithInitializersAndBaseDir(customInitializers []builtins.Builtin
 		// CRITICAL: Give module compiler the SAME heap allocator instance
 		// This ensures all compilers coordinate on the exact same global indices
 		newCompiler.SetHeapAlloc(paserati.heapAlloc)
+		newCompiler.SetDebugInfo(paserati.debugInfo)
 
 		// Return a wrapper that adapts the return type to interface{}
 		return &compilerAdapter{newCompiler}
@@ -218,6 +282,7 @@ func NewPaseratiWithInitializersAndBaseDir(customInitializers []builtins.Builtin
 
 	// Enable module mode for the main checker by default for consistent type checking
 	typeChecker.EnableModuleMode("", moduleLoader)
+	paserati.configureDeclarations(typeChecker)
 
 	// Install built-in Paserati modules
 	installBuiltinModules(paserati)
@@ -241,6 +306,14 @@ func NewPaseratiWithBaseDir(baseDir string) *Paserati {
 	// Create unified heap allocator for coordinating global indices
 	heapAlloc := compiler.NewHeapAlloc()
 
+	// Prefer up-to-date compiled .psm files over their sources
+	moduleLoader.AddResolver(aot.NewResolver(os.DirFS(baseDir), baseDir, heapAlloc.GetOrAssignIndex))
+
+	// Bare specifiers name packages in node_modules, or files through the
+	// path mappings of the project's tsconfig.json
+	nodeResolver := modules.NewNodeResolver(os.DirFS(baseDir), baseDir)
+	moduleLoader.AddResolver(nodeResolver)
+
 	// Create checker and compiler
 	typeChecker := checker.NewChecker()
 	comp := compiler.NewCompiler()
@@ -255,6 +328,8 @@ func NewPaseratiWithBaseDir(baseDir string) *Paserati {
 		compiler:     comp,
 		moduleLoader: moduleLoader,
 		heapAlloc:    heapAlloc,
+		baseDir:      baseDir,
+		nodeResolver: nodeResolver,
 	}
 
 	// Wire the module loader into the VM
@@ -282,6 +357,12 @@ func NewPaseratiWithBaseDir(baseDir string) *Paserati {
 		newChecker := checker.NewChecker()
 		// Enable module mode so the checker can resolve imports
 		newChecker.EnableModuleMode("", moduleLoader)
+		// Declare globals set from Go so module code can refer to them
+		paserati.defineEmbeddedGlobals(newChecker)
+		// Check modules with the options of the project's tsconfig.json
+		paserati.configureChecker(newChecker)
+		// Check modules against the session's ambient declarations
+		paserati.configureDeclarations(newChecker)
 		debugPrintf("// [Driver] Created new checker for module: %p\n", newChecker)
 		return newChecker
 	})
@@ -295,6 +376,7 @@ func NewPaseratiWithBaseDir(baseDir string) *Paserati {
 		// CRITICAL: Give module compiler the SAME heap allocator instance
 		// This ensures all compilers coordinate on the exact same global indices
 		newCompiler.SetHeapAlloc(paserati.heapAlloc)
+		newCompiler.SetDebugInfo(paserati.debugInfo)
 
 		// Return a wrapper that adapts the return type to interface{}
 		return &compilerAdapter{newCompiler}
@@ -302,6 +384,7 @@ func NewPaseratiWithBaseDir(baseDir string) *Paserati {
 
 	// Enable module mode for the main checker by default for consistent type checking
 	typeChecker.EnableModuleMode("", moduleLoader)
+	paserati.configureDeclarations(typeChecker)
 
 	// Install built-in Paserati modules
 	installBuiltinModules(paserati)
@@ -326,7 +409,7 @@ func (p *Paserati) CompileProgram(program *parser.Program) (*vm.Chunk, []errors.
 func (p *Paserati) CompileProgramWithStrictMode(program *parser.Program, strict bool) (*vm.Chunk, []errors.PaseratiError) {
 	// Honor session settings to ignore/skip type errors (used for Test262)
 	p.compiler.SetIgnoreTypeErrors(p.ignoreTypeErrors)
-	p.compiler.SetSkipTypeCheck(p.skipTypeCheck)
+	p.compiler.SetSkipTypeCheck(p.skipTypeCheck || p.uncheckedEval)
 	// Set strict mode before compilation
 	if strict {
 		p.compiler.SetStrictMode(true)
@@ -343,7 +426,7 @@ func (p *Paserati) CompileProgramWithStrictMode(program *parser.Program, strict
 func (p *Paserati) CompileProgramAsScript(program *parser.Program) (*vm.Chunk, []errors.PaseratiError) {
 	// Honor session settings to ignore/skip type errors (used for Test262)
 	p.compiler.SetIgnoreTypeErrors(p.ignoreTypeErrors)
-	p.compiler.SetSkipTypeCheck(p.skipTypeCheck)
+	p.compiler.SetSkipTypeCheck(p.skipTypeCheck || p.uncheckedEval)
 	// Force script mode to disallow import.meta
 	p.compiler.SetForceScriptMode(true)
 	chunk, errs := p.compiler.Compile(program)
@@ -395,8 +478,9 @@ func (p *Paserati) EvalCode(code string, inheritStrict bool) (vm.Value, []error)
 	}
 
 	// Only sync global names for non-strict eval
-	// In strict mode, eval creates its own variable environment and declarations stay local
-	if !chunk.IsStrict {
+	// In strict mode, eval creates its own variable environment and declarations stay local.
+	// Debug sessions sync anyway so ReferenceErrors from evaluated code name the variable.
+	if !chunk.IsStrict || p.debugInfo {
 		p.SyncGlobalNamesFromCompiler()
 	}
 
@@ -515,8 +599,9 @@ func (p *Paserati) DirectEvalCode(code string, inheritStrict bool, scopeDesc *vm
 	}
 
 	// Only sync global names for non-strict eval
-	// In strict mode, eval creates its own variable environment and declarations stay local
-	if !chunk.IsStrict {
+	// In strict mode, eval creates its own variable environment and declarations stay local.
+	// Debug sessions sync anyway so ReferenceErrors from evaluated code name the variable.
+	if !chunk.IsStrict || p.debugInfo {
 		p.SyncGlobalNamesFromCompiler()
 	}
 
@@ -877,8 +962,11 @@ func (p *Paserati) RunModule(filename string) bool {
 	// Set the module path in the VM so import.meta.url works correctly
 	p.vmInstance.SetCurrentModulePath(moduleRecord.ResolvedPath)
 
-	// Execute the module
+	// Execute the module and run its event loop to completion
 	finalValue, runtimeErrs := p.vmInstance.Interpret(chunk)
+	if len(runtimeErrs) == 0 {
+		runtimeErrs = p.runEventLoop()
+	}
 	if len(runtimeErrs) > 0 {
 		// Get source code for error display
 		sourceCode := ""
@@ -1007,8 +1095,11 @@ func (p *Paserati) RunModuleWithValue(filename string) (vm.Value, []errors.Paser
 	// Set the module path in the VM so import.meta.url works correctly
 	p.vmInstance.SetCurrentModulePath(moduleRecord.ResolvedPath)
 
-	// Execute the module and return the final value
+	// Execute the module, run its event loop and return the final value
 	finalValue, runtimeErrs := p.vmInstance.Interpret(chunk)
+	if len(runtimeErrs) == 0 {
+		runtimeErrs = p.runEventLoop()
+	}
 
 	// After successful execution, collect exported values from the compiler
 	if p.compiler.IsModuleMode() {
@@ -1051,6 +1142,8 @@ func containsImports(program *parser.Program) bool {
 // runAsModule runs code as a module with the given module name
 // This is the unified path for all module execution
 func (p *Paserati) runAsModule(sourceCode string, program *parser.Program, moduleName string) (vm.Value, []errors.PaseratiError) {
+	modules.CanonicalizeImports(program, moduleName)
+
 	// Preload all native modules that might be imported
 	// This ensures their exports are registered with HeapAlloc before compilation
 	if err := p.preloadNativeModules(program); err != nil {
@@ -1094,12 +1187,35 @@ func (p *Paserati) runAsModule(sourceCode string, program *parser.Program, modul
 	// Execute the chunk
 	finalValue, runtimeErrs := p.vmInstance.Interpret(chunk)
 
-	// Drain microtasks for async operations (Promises, etc.)
-	p.vmInstance.DrainMicrotasks()
+	// Drain microtasks for async operations (Promises, etc.), then keep
+	// running timers and external operations until the event loop is empty
+	if len(runtimeErrs) == 0 {
+		runtimeErrs = p.runEventLoop()
+	} else {
+		p.vmInstance.DrainMicrotasks()
+	}
 
 	return finalValue, runtimeErrs
 }
 
+// runEventLoop runs the VM's event loop until no microtasks, timers or
+// external operations remain. An uncaught exception in a timer callback is
+// reported as a runtime error.
+func (p *Paserati) runEventLoop() []errors.PaseratiError {
+	if err := p.vmInstance.RunEventLoop(); err != nil {
+		runtimeErr := &errors.RuntimeError{
+			Position: errors.Position{Line: 0, Column: 0},
+			Msg:      err.Error(),
+			Cause:    err,
+		}
+		if _, ok := err.(*vm.TerminationError); ok {
+			runtimeErr.ErrorCode = errors.PS4003
+		}
+		return []errors.PaseratiError{runtimeErr}
+	}
+	return nil
+}
+
 // runAsTemporaryModule runs code with imports as a temporary module
 // DEPRECATED: Use runAsModule instead
 func (p *Paserati) runAsTemporaryModule(sourceCode string, program *parser.Program) (vm.Value, []errors.PaseratiError) {
@@ -1127,13 +1243,46 @@ func EmitJavaScript(sourceCode string) (string, []errors.PaseratiError) {
 // EmitJavaScriptFile reads a TypeScript file and emits equivalent JavaScript code.
 // It returns the JavaScript code as a string or an error list.
 func EmitJavaScriptFile(filename string) (string, []errors.PaseratiError) {
+	program, errs := parseFileForEmit(filename)
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	// Create JavaScript emitter and emit JS code
+	emitter := parser.NewJSEmitter()
+	jsCode := emitter.Emit(program)
+
+	return jsCode, nil
+}
+
+// EmitJavaScriptFileWithSourceMap is EmitJavaScriptFile that also returns a
+// source map from the JavaScript code, to be written as outputFilename, back
+// to the TypeScript file.
+func EmitJavaScriptFileWithSourceMap(filename string, outputFilename string) (string, *parser.SourceMap, []errors.PaseratiError) {
+	program, errs := parseFileForEmit(filename)
+	if len(errs) > 0 {
+		return "", nil, errs
+	}
+
+	emitter := parser.NewJSEmitter()
+	jsCode, sourceMap := emitter.EmitWithSourceMap(program, filepath.Base(outputFilename))
+	// Sources are resolved relative to the map, which sits next to the output
+	if rel, err := filepath.Rel(filepath.Dir(outputFilename), filename); err == nil {
+		sourceMap.Sources[0] = filepath.ToSlash(rel)
+	}
+
+	return jsCode, sourceMap, nil
+}
+
+// parseFileForEmit reads and parses a TypeScript file for the JavaScript emitter
+func parseFileForEmit(filename string) (*parser.Program, []errors.PaseratiError) {
 	sourceBytes, err := ioutil.ReadFile(filename)
 	if err != nil {
 		readErr := &errors.CompileError{
 			Position: errors.Position{Line: 0, Column: 0},
 			Msg:      fmt.Sprintf("Failed to read file '%s': %s", filename, err.Error()),
 		}
-		return "", []errors.PaseratiError{readErr}
+		return nil, []errors.PaseratiError{readErr}
 	}
 	sourceCode := string(sourceBytes)
 	sourceFile := source.FromFile(filename, sourceCode)
@@ -1141,20 +1290,26 @@ func EmitJavaScriptFile(filename string) (string, []errors.PaseratiError) {
 	p := parser.NewParser(l)
 	program, parseErrs := p.ParseProgram()
 	if len(parseErrs) > 0 {
-		return "", parseErrs
+		return nil, parseErrs
 	}
-
-	// Create JavaScript emitter and emit JS code
-	emitter := parser.NewJSEmitter()
-	jsCode := emitter.Emit(program)
-
-	return jsCode, nil
+	return program, nil
 }
 
 // WriteJavaScriptFile reads a TypeScript file, converts it to JavaScript,
 // and writes the output to a file with a .js extension.
 // Returns true if successful, false otherwise.
 func WriteJavaScriptFile(inputFilename string, outputFilename string) bool {
+	return writeJavaScriptFile(inputFilename, outputFilename, false)
+}
+
+// WriteJavaScriptFileWithSourceMap is WriteJavaScriptFile that also writes
+// a source map next to the output, as the output file name plus ".map", and
+// links it from the output with a sourceMappingURL comment.
+func WriteJavaScriptFileWithSourceMap(inputFilename string, outputFilename string) bool {
+	return writeJavaScriptFile(inputFilename, outputFilename, true)
+}
+
+func writeJavaScriptFile(inputFilename string, outputFilename string, withSourceMap bool) bool {
 	if outputFilename == "" {
 		// Default to replacing .ts with .js
 		outputFilename = inputFilename
@@ -1165,13 +1320,32 @@ func WriteJavaScriptFile(inputFilename string, outputFilename string) bool {
 		}
 	}
 
-	jsCode, errs := EmitJavaScriptFile(inputFilename)
+	var jsCode string
+	var sourceMap *parser.SourceMap
+	var errs []errors.PaseratiError
+	if withSourceMap {
+		jsCode, sourceMap, errs = EmitJavaScriptFileWithSourceMap(inputFilename, outputFilename)
+	} else {
+		jsCode, errs = EmitJavaScriptFile(inputFilename)
+	}
 	if len(errs) > 0 {
 		// Print errors
 		errors.DisplayErrors(errs)
 		return false
 	}
 
+	if sourceMap != nil {
+		mapFilename := outputFilename + ".map"
+		if err := ioutil.WriteFile(mapFilename, sourceMap.JSON(), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing source map: %s\n", err)
+			return false
+		}
+		if !strings.HasSuffix(jsCode, "\n") {
+			jsCode += "\n"
+		}
+		jsCode += "//# sourceMappingURL=" + filepath.Base(mapFilename) + "\n"
+	}
+
 	// Write JavaScript code to the output file
 	err := ioutil.WriteFile(outputFilename, []byte(jsCode), 0644)
 	if err != nil {
@@ -1183,7 +1357,7 @@ func WriteJavaScriptFile(inputFilename string, outputFilename string) bool {
 	return true
 }
 
-// RunOptions configures optional debugging output
+// RunOptions configures optional debugging output and resource limits
 type RunOptions struct {
 	ShowTokens     bool
 	ShowAST        bool
@@ -1191,6 +1365,13 @@ type RunOptions struct {
 	ShowCacheStats bool   // Show inline cache statistics
 	ModuleName     string // Module name to use (defaults to "__code_module__" if empty)
 	DisasmFilter   string // Filter string for disassembly (empty = all)
+
+	// Limits bounds the instructions, wall-clock time, call depth and
+	// allocations of the run, including its event loop. Context, if set, adds
+	// its deadline and cancellation. Exceeding either stops the run with a
+	// runtime error wrapping a *vm.TerminationError, which scripts cannot catch.
+	Limits  vm.Limits
+	Context context.Context
 }
 
 // RunCode runs source code with the given Paserati session and options.
@@ -1210,6 +1391,15 @@ func (p *Paserati) RunCode(sourceCode string, options RunOptions) (vm.Value, []e
 		moduleName = "__code_module__"
 	}
 
+	if options.Context != nil || options.Limits != (vm.Limits{}) {
+		ctx := options.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		p.vmInstance.SetLimits(ctx, options.Limits)
+		defer p.vmInstance.ClearLimits()
+	}
+
 	// Run in module mode
 	value, errs := p.runAsModule(sourceCode, program, moduleName)
 
@@ -1289,6 +1479,10 @@ func initializeBuiltinsWithCustom(paserati *Paserati, initializers []builtins.Bu
 			}
 			return nil
 		},
+		GetGlobal: func(name string) (vm.Value, bool) {
+			value, ok := globalVariables[name]
+			return value, ok
+		},
 	}
 
 	// Initialize all builtins runtime values ONCE
@@ -1365,6 +1559,7 @@ func (p *Paserati) InitializeRealmBuiltins(realm *vm.Realm, initializers []built
 				}
 				return nil
 			},
+			GetGlobal: realm.GetGlobal,
 		}
 
 		// Initialize builtins in the new realm
@@ -1520,8 +1715,8 @@ func (p *Paserati) preloadNativeModules(program *parser.Program) errors.Paserati
 func installBuiltinModules(p *Paserati) {
 	// Note: fetch and Headers are now global builtins (defined in pkg/builtins/fetch_init.go)
 	// The paserati/http module is deprecated - use global fetch instead
+	// Likewise Web Crypto is the global crypto (defined in pkg/builtins/crypto_init.go)
 
 	// Add more modules here as we create them
 	// p.DeclareModule("paserati/fs", fsModule)
-	// p.DeclareModule("paserati/crypto", cryptoModule)
 }
@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/source"
+)
+
+// EmitDeclarations type checks the module at filename, relative to the
+// session's base directory unless absolute, and returns its declaration
+// file: the declarations of everything it exports, with the types the
+// checker inferred where the source has no annotations.
+func (p *Paserati) EmitDeclarations(filename string) (string, []errors.PaseratiError) {
+	content, err := os.ReadFile(p.projectPath(filename))
+	if err != nil {
+		return "", []errors.PaseratiError{&errors.CompileError{
+			Msg: fmt.Sprintf("Failed to read file '%s': %s", filename, err.Error()),
+		}}
+	}
+	sourceFile := source.FromFile(filename, string(content))
+	program, parseErrs := parser.NewParser(lexer.NewLexerWithSource(sourceFile)).ParseProgram()
+	if len(parseErrs) > 0 {
+		return "", parseErrs
+	}
+
+	// Imports are checked with canonical specifiers but declared as written
+	specifiers := make(map[*parser.StringLiteral]string)
+	for _, stmt := range program.Statements {
+		switch node := stmt.(type) {
+		case *parser.ImportDeclaration:
+			specifiers[node.Source] = node.Source.Value
+		case *parser.ExportNamedDeclaration:
+			if node.Source != nil {
+				specifiers[node.Source] = node.Source.Value
+			}
+		case *parser.ExportAllDeclaration:
+			specifiers[node.Source] = node.Source.Value
+		}
+	}
+	declarationChecker, errs := p.checkModule(filename, program)
+	if len(errs) > 0 {
+		return "", errs
+	}
+	for literal, specifier := range specifiers {
+		literal.Value = specifier
+	}
+	return declarationChecker.EmitDeclarations(program), nil
+}
+
+// WriteDeclarationFile writes the declaration file of the module at
+// inputFilename to outputFilename, by default the input file name with .ts
+// replaced by .d.ts. Errors are displayed. Returns true if successful.
+func (p *Paserati) WriteDeclarationFile(inputFilename string, outputFilename string) bool {
+	if outputFilename == "" {
+		outputFilename = strings.TrimSuffix(inputFilename, ".ts") + ".d.ts"
+	}
+
+	declarations, errs := p.EmitDeclarations(inputFilename)
+	if len(errs) > 0 {
+		errors.DisplayErrors(errs)
+		return false
+	}
+
+	if err := os.WriteFile(outputFilename, []byte(declarations), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing declaration file: %s\n", err)
+		return false
+	}
+
+	fmt.Printf("Declarations written to %s\n", outputFilename)
+	return true
+}
@@ -0,0 +1,131 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmitDeclarations(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"shapes.ts": `export interface Named { name: string; }`,
+		"lib.ts": `
+			import { type Named } from "./shapes.ts";
+			interface Hidden { secret: number; }
+			export type ID = string | number;
+			export interface Point extends Named { readonly tag: "p"; move(dx: number, dy: number): Point; }
+			export interface Box<T> { value: T; map<U>(f: (v: T) => U): Box<U>; }
+			export const version = "1.0";
+			export const origin = { x: 0, y: 0 };
+			export function area(w: number, h: number) { return w * h; }
+			export async function later(n: number) { return n; }
+			export function hide(): Hidden { return { secret: 1 }; }
+			export class Counter<T> implements Named {
+				name = "c";
+				#count = 0;
+				private secret = 1;
+				readonly id: ID = 1;
+				constructor(public label: string) {}
+				increment() { return ++this.#count; }
+				wrap(x: T): T[] { return [x]; }
+			}
+			export class Sub extends Counter<string> {}
+			export enum Color { Red, Green = 5, Blue }
+			export namespace Geo { export const pi = 3.14; export function circle(r: number) { return pi * r * r; } }
+			export default function (q: number) { return q > 1; }
+			export { area as size };
+		`,
+	})
+
+	declarations, errs := p.EmitDeclarations("lib.ts")
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	for _, expected := range []string{
+		`import { type Named } from "./shapes.ts";`,
+		"interface Hidden {\n    secret: number;\n}",
+		"export type ID = number | string;",
+		"export interface Point extends Named {\n    readonly tag: \"p\";\n    move(dx: number, dy: number): Point;\n}",
+		"    map<U>(f: (v: T) => U): Box<U>;",
+		`export declare const version: "1.0";`,
+		"export declare const origin: {\n    x: number;\n    y: number;\n};",
+		"export declare function area(w: number, h: number): number;",
+		"export declare function later(n: number): Promise<number>;",
+		"export declare function hide(): Hidden;",
+		"export declare class Counter<T> implements Named {\n    #private;\n    name: string;\n    private secret;\n    readonly id: ID;\n    label: string;\n    constructor(label: string);\n    increment(): number;\n    wrap(x: T): T[];\n}",
+		"export declare class Sub extends Counter<string> {",
+		"export declare enum Color {\n    Red = 0,\n    Green = 5,\n    Blue = 6\n}",
+		"export declare namespace Geo {\n    const pi: 3.14;\n    function circle(r: number): number;\n}",
+		"declare function _default(q: number): boolean;\nexport default _default;",
+		"export { area as size };",
+		"export {};",
+	} {
+		if !strings.Contains(declarations, expected) {
+			t.Errorf("expected %q in:\n%s", expected, declarations)
+		}
+	}
+
+	// The declarations are a module other code type checks against
+	if err := p.AddDeclarations("lib.d.ts", declarations); err != nil {
+		t.Fatalf("emitted declarations don't check: %s\n%s", err, declarations)
+	}
+}
+
+func TestEmittedDeclarationsRoundTrip(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"shape.ts": `
+			export class Shape {
+				constructor(protected tag?: string) {}
+				get size(): number { return 1; }
+				set size(value: number) {}
+				static get count(): number { return 0; }
+				describe() { return { sides: 4, round: false, name: "shape" }; }
+			}
+		`,
+		"shape.js": `
+			export class Shape {
+				constructor(tag) { this.tag = tag; }
+				get size() { return 2; }
+				set size(value) {}
+				static get count() { return 3; }
+				describe() { return { sides: 4, round: false, name: "shape" }; }
+			}
+		`,
+	})
+
+	declarations, errs := p.EmitDeclarations("shape.ts")
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	for _, expected := range []string{
+		"    protected tag?: string;\n",
+		"    get size(): number;\n    set size(value: number);\n    static get count(): number;\n",
+		"    describe(): {\n        name: string;\n        round: boolean;\n        sides: number;\n    };\n",
+	} {
+		if !strings.Contains(declarations, expected) {
+			t.Errorf("expected %q in:\n%s", expected, declarations)
+		}
+	}
+
+	// Code importing the JavaScript module is checked against them
+	if err := os.WriteFile(filepath.Join(p.baseDir, "shape.d.ts"), []byte(declarations), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	value, errs := p.RunString(`
+		import { Shape } from "./shape.js";
+		const s = new Shape();
+		const sides: number = s.describe().sides;
+		s.size * sides + Shape.count;
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("emitted declarations don't check: %s\n%s", errorMessages(errs), declarations)
+	}
+	if value.ToFloat() != 11 {
+		t.Errorf("expected 11, got %s", value.Inspect())
+	}
+	_, errs = p.RunString(`import { Shape } from "./shape.js"; const n: string = new Shape().size;`)
+	if !strings.Contains(errorMessages(errs), "'number'") {
+		t.Errorf("expected the declared accessor type to be checked, got: %s", errorMessages(errs))
+	}
+}
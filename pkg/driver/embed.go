@@ -0,0 +1,483 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// This file implements the embedding API: reading and writing globals from Go,
+// importing modules and calling their exports, and awaiting promises.
+//
+// Values cross the boundary through ValueConverter. Go values passed in are
+// converted with ConvertToVM; script values handed back are converted with
+// ConvertFromVM, with JS functions wrapped as *Function handles. All methods
+// must be called from the goroutine that owns the Paserati instance.
+
+// JSError is returned when a script throws an exception that reaches Go,
+// either from a call, a rejected promise or a module's top-level code
+type JSError struct {
+	Value   vm.Value // The thrown value
+	Message string   // Display form of the thrown value, e.g. "TypeError: x is not a function"
+	Stack   string   // The Error object's stack property, if any
+}
+
+func (e *JSError) Error() string {
+	return "Uncaught exception: " + e.Message
+}
+
+// newJSError wraps a thrown value, capturing its message and stack trace
+func (p *Paserati) newJSError(thrown vm.Value) *JSError {
+	jsErr := &JSError{Value: thrown, Message: p.vmInstance.FormatException(thrown)}
+	if stack, ok := getOwn(thrown, "stack"); ok && stack.Type() != vm.TypeUndefined {
+		jsErr.Stack = stack.ToString()
+	}
+	return jsErr
+}
+
+// wrapError converts errors escaping the VM into *JSError where they carry a
+// thrown value, and leaves everything else untouched
+func (p *Paserati) wrapError(err error) error {
+	var exc vm.ExceptionError
+	if stderrors.As(err, &exc) {
+		return p.newJSError(exc.GetExceptionValue())
+	}
+	return err
+}
+
+// ErrorList holds the diagnostics of a failed compilation or run
+type ErrorList []errors.PaseratiError
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap exposes the individual diagnostics to errors.Is and errors.As, for
+// instance to detect a *vm.TerminationError
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
+
+// listError turns diagnostics into a Go error. An uncaught exception is
+// reported as its *JSError so callers can inspect the thrown value.
+func (p *Paserati) listError(errs []errors.PaseratiError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, err := range errs {
+		var exc vm.ExceptionError
+		if stderrors.As(err, &exc) {
+			return p.newJSError(exc.GetExceptionValue())
+		}
+	}
+	return ErrorList(errs)
+}
+
+// converter returns the session's value converter, wrapping JS functions as
+// *Function handles
+func (p *Paserati) converter() *ValueConverter {
+	if p.valueConverter == nil {
+		p.valueConverter = NewValueConverter(p.vmInstance)
+		p.valueConverter.wrapCallable = func(fn vm.Value) interface{} {
+			return &Function{p: p, value: fn}
+		}
+	}
+	return p.valueConverter
+}
+
+// ToValue converts a Go value to a script value
+func (p *Paserati) ToValue(goValue interface{}) vm.Value {
+	return p.converter().ConvertToVM(goValue)
+}
+
+// Export converts a script value to a plain Go value (see ValueConverter.ConvertFromVM).
+// JS functions are returned as *Function handles.
+func (p *Paserati) Export(value vm.Value) interface{} {
+	return p.converter().ConvertFromVM(value)
+}
+
+// ExportTo converts a script value into the Go variable pointed to by target
+func (p *Paserati) ExportTo(value vm.Value, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("ExportTo: target must be a non-nil pointer, got %T", target)
+	}
+	converted, err := p.converter().ConvertTo(value, ptr.Type().Elem())
+	if err != nil {
+		return err
+	}
+	ptr.Elem().Set(converted)
+	return nil
+}
+
+// --- Globals ---
+
+// Set defines or overwrites a global variable. The Go value is converted to a
+// script value and its TypeScript type is inferred for code compiled afterwards,
+// unless a declaration file loaded into the session declares the global.
+func (p *Paserati) Set(name string, goValue interface{}) error {
+	value := p.ToValue(goValue)
+	var typ types.Type = types.Any
+	if declared, ok := p.declaredGlobal(name); ok {
+		typ = declared
+	} else if _, isValue := goValue.(vm.Value); !isValue && goValue != nil {
+		typ = NewTypeGenerator().GenerateType(goValue)
+	}
+	return p.SetValue(name, value, typ)
+}
+
+// SetValue defines or overwrites a global variable with a script value and an
+// explicit type. A nil type is treated as any.
+func (p *Paserati) SetValue(name string, value vm.Value, typ types.Type) error {
+	if typ == nil {
+		typ = types.Any
+	}
+	if p.embedGlobals == nil {
+		p.embedGlobals = make(map[string]types.Type)
+	}
+	p.embedGlobals[name] = typ
+	p.checker.DefineGlobal(name, typ)
+
+	heapAlloc := p.compiler.GetHeapAlloc()
+	index := heapAlloc.GetOrAssignIndex(name)
+	p.vmInstance.SyncGlobalNames(heapAlloc.GetNameToIndexMap())
+	p.vmInstance.ResizeHeapForGlobals(heapAlloc.GetAllocatedSize())
+	if err := p.vmInstance.GetHeap().Set(index, value); err != nil {
+		return fmt.Errorf("failed to set global '%s': %w", name, err)
+	}
+	if p.vmInstance.GlobalObject != nil {
+		p.vmInstance.GlobalObject.SetOwn(name, value)
+	}
+	return nil
+}
+
+// Get returns a global variable converted to a Go value (see Export).
+// The second result is false if the global does not exist.
+func (p *Paserati) Get(name string) (interface{}, bool) {
+	value, ok := p.GetValue(name)
+	if !ok {
+		return nil, false
+	}
+	return p.Export(value), true
+}
+
+// GetValue returns a global variable as a script value. Both top-level
+// bindings and properties assigned to globalThis are found.
+func (p *Paserati) GetValue(name string) (vm.Value, bool) {
+	if index, ok := p.compiler.GetHeapAlloc().GetIndex(name); ok {
+		if value, ok := p.vmInstance.GetGlobalByIndex(index); ok {
+			return value, true
+		}
+	}
+	if p.vmInstance.GlobalObject != nil {
+		if value, ok := p.vmInstance.GlobalObject.GetOwn(name); ok {
+			return value, true
+		}
+	}
+	return vm.Undefined, false
+}
+
+// defineEmbeddedGlobals declares globals set from Go in a module checker, so
+// that files loaded through the module system can refer to them
+func (p *Paserati) defineEmbeddedGlobals(c *checker.Checker) {
+	for name, typ := range p.embedGlobals {
+		c.DefineGlobal(name, typ)
+	}
+}
+
+// --- Functions ---
+
+// Function is a handle to a callable script value
+type Function struct {
+	p     *Paserati
+	value vm.Value
+	name  string
+}
+
+// Function returns a handle to the callable global with the given name
+func (p *Paserati) Function(name string) (*Function, error) {
+	value, ok := p.GetValue(name)
+	if !ok {
+		return nil, fmt.Errorf("global '%s' is not defined", name)
+	}
+	return p.newFunction(name, value)
+}
+
+func (p *Paserati) newFunction(name string, value vm.Value) (*Function, error) {
+	if !value.IsCallable() {
+		return nil, fmt.Errorf("'%s' is not a function (got %s)", name, value.TypeName())
+	}
+	return &Function{p: p, value: value, name: name}, nil
+}
+
+// VMValue returns the underlying script function
+func (f *Function) VMValue() vm.Value {
+	return f.value
+}
+
+// Call invokes the function with this=undefined. Arguments are converted with
+// ToValue. A thrown exception is returned as *JSError. The result is returned
+// as-is, so async functions yield a promise that can be passed to Await.
+func (f *Function) Call(args ...interface{}) (vm.Value, error) {
+	return f.CallWithThis(vm.Undefined, args...)
+}
+
+// CallWithThis invokes the function with an explicit this value
+func (f *Function) CallWithThis(this vm.Value, args ...interface{}) (vm.Value, error) {
+	vmArgs := make([]vm.Value, len(args))
+	for i, arg := range args {
+		vmArgs[i] = f.p.ToValue(arg)
+	}
+	result, err := f.p.vmInstance.Call(f.value, this, vmArgs)
+	if err != nil {
+		return vm.Undefined, f.p.wrapError(err)
+	}
+	return result, nil
+}
+
+// CallContext invokes the function and, if it returns a promise, waits for the
+// promise to settle while running the event loop (see Await)
+func (f *Function) CallContext(ctx context.Context, args ...interface{}) (vm.Value, error) {
+	result, err := f.Call(args...)
+	if err != nil {
+		return vm.Undefined, err
+	}
+	return f.p.Await(ctx, result)
+}
+
+// Bind stores a typed Go function in the variable pointed to by fnPtr that
+// calls this script function. The Go signature must return error as its last
+// result and may return one value before it. If its first parameter is a
+// context.Context, a returned promise is awaited under that context; otherwise
+// promises are awaited with context.Background().
+//
+//	var add func(a, b int) (int, error)
+//	err := mod.Bind("add", &add)
+func (f *Function) Bind(fnPtr interface{}) error {
+	ptr := reflect.ValueOf(fnPtr)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Func {
+		return fmt.Errorf("Bind: expected a pointer to a function variable, got %T", fnPtr)
+	}
+	fnType := ptr.Elem().Type()
+	numOut := fnType.NumOut()
+	if numOut == 0 || numOut > 2 || fnType.Out(numOut-1) != errorType {
+		return fmt.Errorf("Bind: %s must return error as its last result", fnType)
+	}
+	hasCtx := fnType.NumIn() > 0 && fnType.In(0) == contextType
+
+	fn := reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		ctx := context.Background()
+		if hasCtx {
+			if c, ok := in[0].Interface().(context.Context); ok && c != nil {
+				ctx = c
+			}
+			in = in[1:]
+		}
+		if fnType.IsVariadic() && len(in) > 0 {
+			last := in[len(in)-1]
+			in = in[:len(in)-1]
+			for i := 0; i < last.Len(); i++ {
+				in = append(in, last.Index(i))
+			}
+		}
+		args := make([]interface{}, len(in))
+		for i, arg := range in {
+			args[i] = arg.Interface()
+		}
+
+		out := make([]reflect.Value, numOut)
+		if numOut == 2 {
+			out[0] = reflect.Zero(fnType.Out(0))
+		}
+		fail := func(err error) []reflect.Value {
+			out[numOut-1] = reflect.ValueOf(&err).Elem()
+			return out
+		}
+
+		result, err := f.CallContext(ctx, args...)
+		if err != nil {
+			return fail(err)
+		}
+		if numOut == 2 {
+			converted, err := f.p.converter().ConvertTo(result, fnType.Out(0))
+			if err != nil {
+				return fail(fmt.Errorf("%s: result: %w", f.displayName(), err))
+			}
+			out[0] = converted
+		}
+		out[numOut-1] = reflect.Zero(errorType)
+		return out
+	})
+	ptr.Elem().Set(fn)
+	return nil
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+func (f *Function) displayName() string {
+	if f.name != "" {
+		return f.name
+	}
+	return "function"
+}
+
+// --- Modules ---
+
+// Module is a handle to an executed module's exports
+type Module struct {
+	p      *Paserati
+	record *modules.ModuleRecord
+}
+
+// Import loads and runs the module at specifier (resolved against the session's
+// base directory) and returns a handle to its exports. Each module runs at
+// most once per session; later imports return the same handle.
+func (p *Paserati) Import(specifier string) (*Module, error) {
+	if mod, ok := p.embedModules[specifier]; ok {
+		return mod, nil
+	}
+
+	_, compileErrs, runtimeErrs := p.RunModuleWithValue(specifier)
+	if err := p.listError(compileErrs); err != nil {
+		return nil, err
+	}
+	if err := p.listError(runtimeErrs); err != nil {
+		return nil, err
+	}
+
+	recordInterface, err := p.moduleLoader.LoadModule(specifier, ".")
+	if err != nil {
+		return nil, err
+	}
+	record, ok := recordInterface.(*modules.ModuleRecord)
+	if !ok {
+		return nil, fmt.Errorf("module '%s' has invalid type", specifier)
+	}
+
+	mod := &Module{p: p, record: record}
+	if p.embedModules == nil {
+		p.embedModules = make(map[string]*Module)
+	}
+	p.embedModules[specifier] = mod
+	return mod, nil
+}
+
+// Path returns the resolved path of the module
+func (m *Module) Path() string {
+	return m.record.ResolvedPath
+}
+
+// Exports returns the sorted names of the module's runtime exports
+func (m *Module) Exports() []string {
+	names := make([]string, 0, len(m.record.ExportIndices)+len(m.record.ExportValues))
+	seen := make(map[string]bool)
+	for name := range m.record.ExportIndices {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for name := range m.record.ExportValues {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetValue returns the current value of an export. Exports are read from the
+// global heap so the latest value of a live binding is returned.
+func (m *Module) GetValue(name string) (vm.Value, bool) {
+	if index, ok := m.record.ExportIndices[name]; ok {
+		if value, ok := m.p.vmInstance.GetGlobalByIndex(int(index)); ok {
+			return value, true
+		}
+	}
+	value, ok := m.record.ExportValues[name]
+	return value, ok
+}
+
+// Get returns an export converted to a Go value (see Paserati.Export)
+func (m *Module) Get(name string) (interface{}, bool) {
+	value, ok := m.GetValue(name)
+	if !ok {
+		return nil, false
+	}
+	return m.p.Export(value), true
+}
+
+// Function returns a handle to an exported function
+func (m *Module) Function(name string) (*Function, error) {
+	value, ok := m.GetValue(name)
+	if !ok {
+		return nil, fmt.Errorf("module '%s' has no export '%s'", m.Path(), name)
+	}
+	return m.p.newFunction(name, value)
+}
+
+// Bind looks up an exported function and binds it to a typed Go function
+// variable (see Function.Bind)
+func (m *Module) Bind(name string, fnPtr interface{}) error {
+	fn, err := m.Function(name)
+	if err != nil {
+		return err
+	}
+	return fn.Bind(fnPtr)
+}
+
+// --- Promises ---
+
+// Await waits for a promise to settle, running microtasks, timers and external
+// operations in the meantime. Non-promise values are returned unchanged.
+// A rejection is returned as *JSError. If ctx is done first, ctx.Err() is
+// returned and the promise is left pending.
+func (p *Paserati) Await(ctx context.Context, value vm.Value) (vm.Value, error) {
+	if value.Type() != vm.TypePromise {
+		return value, nil
+	}
+	promise := value.AsPromise()
+	rt := p.vmInstance.GetAsyncRuntime()
+
+	// Wake a blocked event loop when ctx is done so cancellation is noticed
+	stop := context.AfterFunc(ctx, func() {
+		rt.BeginExternalOp()
+		rt.EndExternalOp()
+	})
+	defer stop()
+
+	for {
+		p.vmInstance.DrainMicrotasks()
+		switch promise.GetState() {
+		case vm.PromiseFulfilled:
+			return promise.GetResult(), nil
+		case vm.PromiseRejected:
+			return vm.Undefined, p.newJSError(promise.GetResult())
+		}
+		if err := ctx.Err(); err != nil {
+			return vm.Undefined, err
+		}
+		progressed, err := p.vmInstance.RunEventLoopTurn()
+		if err != nil {
+			return vm.Undefined, p.wrapError(err)
+		}
+		if !progressed && promise.GetState() == vm.PromisePending {
+			return vm.Undefined, fmt.Errorf("promise can never settle: no pending timers or external operations")
+		}
+	}
+}
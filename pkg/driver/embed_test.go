@@ -0,0 +1,373 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// writeModules writes the given files into a temp dir and returns a session rooted there
+func writeModules(t *testing.T, files map[string]string) *Paserati {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := NewPaseratiWithBaseDir(dir)
+	t.Cleanup(p.Cleanup)
+	return p
+}
+
+func TestEmbedSetAndGetGlobals(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	type config struct {
+		Name  string   `json:"name"`
+		Ports []int    `json:"ports"`
+		Tags  []string `json:"tags"`
+	}
+
+	if err := p.Set("limit", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("settings", map[string]interface{}{"verbose": true, "level": "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("double", func(x float64) float64 { return x * 2 }); err != nil {
+		t.Fatal(err)
+	}
+
+	value, errs := p.RunString(`
+		const total: number = double(limit) + 1;
+		const summary = { name: settings.level, ports: [80, 443], tags: ["a", "b"] };
+		total;
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if value.ToFloat() != 7 {
+		t.Fatalf("expected 7, got %s", value.Inspect())
+	}
+
+	total, ok := p.Get("total")
+	if !ok || total != 7.0 {
+		t.Fatalf("expected total=7, got %v (found=%v)", total, ok)
+	}
+
+	summaryValue, ok := p.GetValue("summary")
+	if !ok {
+		t.Fatal("summary not found")
+	}
+	var cfg config
+	if err := p.ExportTo(summaryValue, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := config{Name: "debug", Ports: []int{80, 443}, Tags: []string{"a", "b"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("expected %+v, got %+v", want, cfg)
+	}
+
+	if _, ok := p.Get("doesNotExist"); ok {
+		t.Fatal("expected missing global to be reported as not found")
+	}
+}
+
+func TestEmbedGlobalsVisibleToModules(t *testing.T) {
+	p := writeModules(t, map[string]string{
+		"main.ts": `export const greeting: string = prefix + ", world";`,
+	})
+	if err := p.Set("prefix", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := p.Import("./main.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mod.Get("greeting"); got != "hello, world" {
+		t.Fatalf("expected 'hello, world', got %v", got)
+	}
+}
+
+func TestEmbedModuleExports(t *testing.T) {
+	p := writeModules(t, map[string]string{
+		"math.ts": `
+			export function add(a: number, b: number): number { return a + b; }
+			export function join(sep: string, ...parts: string[]): string { return parts.join(sep); }
+			let hits = 0;
+			export function bump(): number { return ++hits; }
+			export const version = "1.0";
+		`,
+	})
+
+	mod, err := p.Import("./math.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mod.Exports(); !reflect.DeepEqual(got, []string{"add", "bump", "join", "version"}) {
+		t.Fatalf("unexpected exports: %v", got)
+	}
+
+	// Untyped handle
+	add, err := mod.Function("add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := add.Call(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ToFloat() != 5 {
+		t.Fatalf("expected 5, got %s", result.Inspect())
+	}
+
+	// Typed handles
+	var typedAdd func(a, b int) (int, error)
+	if err := mod.Bind("add", &typedAdd); err != nil {
+		t.Fatal(err)
+	}
+	if sum, err := typedAdd(40, 2); err != nil || sum != 42 {
+		t.Fatalf("expected 42, got %d (%v)", sum, err)
+	}
+
+	var join func(sep string, parts ...string) (string, error)
+	if err := mod.Bind("join", &join); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := join("-", "a", "b", "c"); err != nil || s != "a-b-c" {
+		t.Fatalf("expected a-b-c, got %q (%v)", s, err)
+	}
+
+	// State persists across calls into the module
+	var bump func() (float64, error)
+	if err := mod.Bind("bump", &bump); err != nil {
+		t.Fatal(err)
+	}
+	var hits float64
+	for i := 0; i < 3; i++ {
+		if hits, err = bump(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits != 3 {
+		t.Fatalf("expected 3 hits, got %v", hits)
+	}
+	if got, _ := mod.Get("version"); got != "1.0" {
+		t.Fatalf("expected version 1.0, got %v", got)
+	}
+
+	// Importing again returns the same handle without re-running the module
+	again, err := p.Import("./math.ts")
+	if err != nil || again != mod {
+		t.Fatalf("expected cached module handle, got %v (%v)", again, err)
+	}
+
+	if _, err := mod.Function("version"); err == nil {
+		t.Fatal("expected error for non-function export")
+	}
+	var bad func(a, b int) int
+	if err := mod.Bind("add", &bad); err == nil {
+		t.Fatal("expected error for signature without error result")
+	}
+}
+
+func TestEmbedExceptionsAsJSError(t *testing.T) {
+	p := writeModules(t, map[string]string{
+		"fail.ts": `
+			export function fail(msg: string): never { throw new RangeError(msg); }
+			export function throwValue(): void { throw 42; }
+		`,
+		"broken.ts": `throw new TypeError("boom at load");`,
+	})
+
+	mod, err := p.Import("./fail.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fail, _ := mod.Function("fail")
+	_, err = fail.Call("out of range")
+	var jsErr *JSError
+	if !stderrors.As(err, &jsErr) {
+		t.Fatalf("expected *JSError, got %T: %v", err, err)
+	}
+	if jsErr.Message != "RangeError: out of range" {
+		t.Fatalf("unexpected message: %q", jsErr.Message)
+	}
+	if !strings.Contains(jsErr.Stack, "fail") {
+		t.Fatalf("expected stack to mention 'fail', got %q", jsErr.Stack)
+	}
+	if msg, _ := getOwn(jsErr.Value, "message"); msg.ToString() != "out of range" {
+		t.Fatalf("expected thrown value to be the RangeError, got %s", jsErr.Value.Inspect())
+	}
+
+	throwValue, _ := mod.Function("throwValue")
+	_, err = throwValue.Call()
+	if !stderrors.As(err, &jsErr) || jsErr.Value.ToFloat() != 42 {
+		t.Fatalf("expected thrown 42, got %v", err)
+	}
+
+	// Exceptions in a module's top-level code surface the same way
+	_, err = p.Import("./broken.ts")
+	if !stderrors.As(err, &jsErr) || jsErr.Message != "TypeError: boom at load" {
+		t.Fatalf("expected TypeError from module body, got %T: %v", err, err)
+	}
+}
+
+func TestEmbedAwaitPromises(t *testing.T) {
+	p := writeModules(t, map[string]string{
+		"async.ts": `
+			export async function delayed(x: number): Promise<number> {
+				await new Promise((resolve) => setTimeout(resolve, 5));
+				return x * 2;
+			}
+			export async function rejects(): Promise<number> {
+				throw new Error("nope");
+			}
+			export function forever(): Promise<number> {
+				return new Promise(() => {});
+			}
+			export function slow(): Promise<number> {
+				return new Promise((resolve) => setTimeout(() => resolve(1), 10000));
+			}
+		`,
+	})
+	mod, err := p.Import("./async.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delayed, _ := mod.Function("delayed")
+	promise, err := delayed.Call(21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promise.Type() != vm.TypePromise {
+		t.Fatalf("expected a promise, got %s", promise.TypeName())
+	}
+	value, err := p.Await(context.Background(), promise)
+	if err != nil || value.ToFloat() != 42 {
+		t.Fatalf("expected 42, got %s (%v)", value.Inspect(), err)
+	}
+
+	var typedDelayed func(ctx context.Context, x int) (int, error)
+	if err := mod.Bind("delayed", &typedDelayed); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := typedDelayed(context.Background(), 5); err != nil || n != 10 {
+		t.Fatalf("expected 10, got %d (%v)", n, err)
+	}
+
+	rejects, _ := mod.Function("rejects")
+	_, err = rejects.CallContext(context.Background())
+	var jsErr *JSError
+	if !stderrors.As(err, &jsErr) || jsErr.Message != "Error: nope" {
+		t.Fatalf("expected rejection as JSError, got %T: %v", err, err)
+	}
+
+	forever, _ := mod.Function("forever")
+	if _, err := forever.CallContext(context.Background()); err == nil {
+		t.Fatal("expected error for a promise that can never settle")
+	}
+
+	slow, _ := mod.Function("slow")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = slow.CallContext(ctx)
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("cancellation took too long: %v", elapsed)
+	}
+
+	// Non-promise values pass straight through
+	if v, err := p.Await(context.Background(), vm.NumberValue(1)); err != nil || v.ToFloat() != 1 {
+		t.Fatalf("expected passthrough, got %s (%v)", v.Inspect(), err)
+	}
+}
+
+func TestEmbedFunctionValuesRoundTrip(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	_, errs := p.RunString(`
+		function makeAdder(n: number) { return (x: number) => x + n; }
+		const handlers = { inc: (x: number) => x + 1 };
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	makeAdder, err := p.Function("makeAdder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adderValue, err := makeAdder.Call(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adder, ok := p.Export(adderValue).(*Function)
+	if !ok {
+		t.Fatalf("expected *Function, got %T", p.Export(adderValue))
+	}
+	if v, err := adder.Call(5); err != nil || v.ToFloat() != 15 {
+		t.Fatalf("expected 15, got %s (%v)", v.Inspect(), err)
+	}
+
+	handlers, _ := p.Get("handlers")
+	inc, ok := handlers.(map[string]interface{})["inc"].(*Function)
+	if !ok {
+		t.Fatalf("expected nested *Function, got %T", handlers.(map[string]interface{})["inc"])
+	}
+	if v, _ := inc.Call(1); v.ToFloat() != 2 {
+		t.Fatalf("expected 2, got %s", v.Inspect())
+	}
+
+	// Function handles passed back in are unwrapped
+	if err := p.Set("inc", inc); err != nil {
+		t.Fatal(err)
+	}
+	value, errs := p.RunString(`inc(41);`)
+	if len(errs) > 0 || value.ToFloat() != 42 {
+		t.Fatalf("expected 42, got %s (%v)", value.Inspect(), errs)
+	}
+}
+
+// TestSessionRecoversAfterUncaughtException checks that a session keeps
+// working after a run or a Go-initiated call ends with an uncaught exception
+func TestSessionRecoversAfterUncaughtException(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	if _, errs := p.RunString(`let count = 0; function inc(): number { return ++count; }`); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	_, errs := p.RunString(`throw new Error("first");`)
+	if err := p.listError(errs); err == nil || !strings.Contains(err.Error(), "Error: first") {
+		t.Fatalf("expected uncaught Error, got %v", err)
+	}
+
+	inc, err := p.Function("inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := inc.Call(); err != nil || v.ToFloat() != 1 {
+		t.Fatalf("expected 1, got %s (%v)", v.Inspect(), err)
+	}
+
+	value, errs := p.RunString(`inc();`)
+	if len(errs) > 0 || value.ToFloat() != 2 {
+		t.Fatalf("expected 2, got %s (%v)", value.Inspect(), errs)
+	}
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// A module can be replaced in a running session without starting over: its
+// new version is checked and compiled, and run in place of the old one,
+// whose state the rest of the program keeps. Imported names read the
+// exporting module's globals on every use, so the modules importing it see
+// the new version's exports as live bindings without running again. The
+// versions can hand over through import.meta.hot (see vm.ReloadModule).
+
+// HotReload is the outcome of ReloadModule
+type HotReload struct {
+	// Errors are the syntax, type and compile errors of the new version, and
+	// the type errors it causes in the modules importing it. The running
+	// version is kept when there are any.
+	Errors []errors.PaseratiError
+	// Reloaded is whether the new version ran in place of the old one. A
+	// module that hasn't run yet runs its new version when first imported.
+	Reloaded bool
+	// Accepted is whether the old version accepted being replaced, with
+	// import.meta.hot.accept
+	Accepted bool
+}
+
+// ReloadModule replaces the module at path, relative to the session's base
+// directory unless absolute, with what its file holds now. The modules
+// importing it are checked against the new version, but not compiled nor
+// run again. The old version's import.meta.hot.dispose callbacks run before
+// the new version, and its accept callbacks after, with the new version's
+// namespace. Fails if the module isn't loaded, or if the new version or a
+// callback throws; the new version may then have run in part. Like the
+// session's other methods, it must not be called while the session runs
+// code.
+func (p *Paserati) ReloadModule(path string) (*HotReload, error) {
+	replaced, record, err := p.moduleLoader.Reload(p.modulePath(path))
+	if err != nil {
+		return nil, err
+	}
+	reload := &HotReload{}
+	if record.Error != nil {
+		reload.Errors = record.Diagnostics
+		if len(reload.Errors) == 0 {
+			reload.Errors = []errors.PaseratiError{&errors.CompileError{
+				Msg: fmt.Sprintf("Module error in '%s': %s", record.ResolvedPath, record.Error.Error()),
+			}}
+		}
+		return reload, nil
+	}
+
+	p.vmInstance.SyncGlobalNames(p.heapAlloc.GetNameToIndexMap())
+	p.vmInstance.ResizeHeapForGlobals(p.heapAlloc.GetAllocatedSize())
+	for _, old := range replaced {
+		if old.CompiledChunk == nil {
+			continue
+		}
+		reloaded, accepted, errs := p.vmInstance.ReloadModule(old.CompiledChunk)
+		reload.Reloaded = reload.Reloaded || reloaded
+		reload.Accepted = reload.Accepted || accepted
+		if len(errs) > 0 {
+			return reload, p.listError(errs)
+		}
+	}
+	return reload, nil
+}
@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadModule(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"main.ts": `import { rule, version } from "./rules/limit";
+import * as limits from "./rules/limit";
+export function evaluate(n: number): string {
+  return rule(n) + " v" + version + " v" + limits.version;
+}`,
+		"rules/limit.ts": `export const version = 1;
+let calls: number = import.meta.hot?.data.calls ?? 0;
+export function rule(n: number): string { calls++; return (n > 10 ? "deny" : "allow") + " " + calls; }
+import.meta.hot?.dispose((data: any) => { data.calls = calls; });
+import.meta.hot?.accept((next: any) => { globalThis.acceptedVersion = next.version; });`,
+	})
+	if _, compileErrs, runErrs := p.RunModuleWithValue("./main.ts"); len(compileErrs)+len(runErrs) > 0 {
+		t.Fatalf("failed to run main.ts: %s%s", errorMessages(compileErrs), errorMessages(runErrs))
+	}
+	evaluate, err := p.Function("evaluate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(n int) string {
+		t.Helper()
+		result, err := evaluate.Call(n)
+		if err != nil {
+			t.Fatalf("evaluate failed: %v", err)
+		}
+		return result.ToString()
+	}
+	reload := func(content string) (*HotReload, error) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(p.baseDir, "rules", "limit.ts"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p.ReloadModule("rules/limit.ts")
+	}
+
+	if result := call(5); result != "allow 1 v1 v1" {
+		t.Fatalf("unexpected first result %q", result)
+	}
+
+	// The importer sees the new exports, and the state handed over
+	result, err := reload(`export const version = 2;
+let calls: number = import.meta.hot?.data.calls ?? 0;
+export function rule(n: number): string { calls++; return (n > 3 ? "deny" : "allow") + " " + calls; }`)
+	if err != nil || len(result.Errors) > 0 || !result.Reloaded || !result.Accepted {
+		t.Fatalf("expected an accepted reload, got %+v, %v", result, err)
+	}
+	if result := call(5); result != "deny 2 v2 v2" {
+		t.Errorf("expected the new rule with the old state, got %q", result)
+	}
+	if accepted, _ := p.Get("acceptedVersion"); accepted != 2.0 {
+		t.Errorf("expected the accept callback to get the new version, got %v", accepted)
+	}
+
+	// Versions that fail to check, themselves or their importers, are not run
+	result, err = reload(`export const version = 3;
+export function rule(n: string): string { return "deny"; }`)
+	if err != nil || len(result.Errors) == 0 || result.Errors[0].Kind() != "Type" || result.Reloaded {
+		t.Fatalf("expected a type error in main.ts, got %+v, %v", result, err)
+	}
+	if pos := result.Errors[0].Pos(); pos.Source == nil || pos.Source.Path != "main.ts" {
+		t.Errorf("expected the type error in main.ts, got %v", result.Errors[0])
+	}
+	result, err = reload(`export const version = ;`)
+	if err != nil || len(result.Errors) == 0 || result.Errors[0].Kind() != "Syntax" {
+		t.Fatalf("expected a syntax error, got %+v, %v", result, err)
+	}
+	if result := call(1); result != "allow 3 v2 v2" {
+		t.Errorf("expected the running version to be kept, got %q", result)
+	}
+
+	// A version that throws is reported
+	if _, err = reload(`export const version = 4;
+export function rule(n: number): string { return "allow"; }
+throw new Error("bad rule");`); err == nil {
+		t.Error("expected the error thrown by the new version")
+	}
+
+	if _, err := p.ReloadModule("rules/missing.ts"); err == nil {
+		t.Error("expected an error for a module that isn't loaded")
+	}
+}
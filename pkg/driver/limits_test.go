@@ -0,0 +1,162 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// runLimited runs source under the given options and returns the termination
+// that stopped it, failing the test if the run was not terminated
+func runLimited(t *testing.T, p *Paserati, source string, options RunOptions) *vm.TerminationError {
+	t.Helper()
+	start := time.Now()
+	_, errs := p.RunCode(source, options)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run took %v before stopping", elapsed)
+	}
+	var term *vm.TerminationError
+	if !stderrors.As(ErrorList(errs), &term) {
+		t.Fatalf("expected a termination, got %v", errs)
+	}
+	if len(errs) != 1 || errs[0].Code() != errors.PS4003 {
+		t.Fatalf("expected a single PS4003 error, got %v", errs)
+	}
+	return term
+}
+
+// expectUsable checks that the session still runs code after a termination
+func expectUsable(t *testing.T, p *Paserati) {
+	t.Helper()
+	value, errs := p.RunCode("21 * 2", RunOptions{})
+	if len(errs) > 0 || value.ToFloat() != 42 {
+		t.Fatalf("session unusable after termination: %s %v", value.Inspect(), errs)
+	}
+}
+
+func TestLimitsRunawayLoops(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{"while", "while (true) {}"},
+		{"try-catch", "while (true) { try { while (true) {} } catch (e) {} finally { continue; } }"},
+		{"callback", "try { [1, 2, 3].map((x) => { while (x > 0) {} return x; }); } catch (e) {}"},
+		{"timer", "setTimeout(() => { while (true) {} }, 1);"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPaserati()
+			defer p.Cleanup()
+			term := runLimited(t, p, tc.source, RunOptions{Limits: vm.Limits{MaxInstructions: 50_000}})
+			if term.Reason != vm.TerminationInstructionLimit || term.Limit != 50_000 {
+				t.Fatalf("unexpected termination: %v", term)
+			}
+			expectUsable(t, p)
+		})
+	}
+}
+
+func TestLimitsDeadline(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	term := runLimited(t, p, "let n = 0; while (true) { n++; }", RunOptions{
+		Limits: vm.Limits{Deadline: time.Now().Add(50 * time.Millisecond)},
+	})
+	if term.Reason != vm.TerminationDeadline {
+		t.Fatalf("unexpected termination: %v", term)
+	}
+	expectUsable(t, p)
+
+	// A context deadline also interrupts an event loop waiting on a far-off timer
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	q := NewPaserati()
+	defer q.Cleanup()
+	term = runLimited(t, q, "setTimeout(() => {}, 60_000);", RunOptions{Context: ctx})
+	if term.Reason != vm.TerminationDeadline {
+		t.Fatalf("unexpected termination: %v", term)
+	}
+
+	// Cancelling the context stops the run too
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	r := NewPaserati()
+	defer r.Cleanup()
+	term = runLimited(t, r, "for (;;) {}", RunOptions{Context: ctx})
+	if term.Reason != vm.TerminationCancelled {
+		t.Fatalf("unexpected termination: %v", term)
+	}
+}
+
+func TestLimitsRunawayRecursion(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	term := runLimited(t, p, `
+		function down(n: number): number { return 1 + down(n + 1); }
+		try { down(0); } catch (e) { "caught"; }
+	`, RunOptions{Limits: vm.Limits{MaxCallDepth: 64}})
+	if term.Reason != vm.TerminationCallDepth || term.Limit != 64 {
+		t.Fatalf("unexpected termination: %v", term)
+	}
+	expectUsable(t, p)
+
+	// Recursion within the limit is unaffected
+	value, errs := p.RunCode(`
+		function fact(n: number): number { return n <= 1 ? 1 : n * fact(n - 1); }
+		fact(10);
+	`, RunOptions{Limits: vm.Limits{MaxCallDepth: 64}})
+	if len(errs) > 0 || value.ToFloat() != 3628800 {
+		t.Fatalf("expected 3628800, got %s %v", value.Inspect(), errs)
+	}
+}
+
+func TestLimitsAllocationBombs(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{"string doubling", `let s = "x"; while (true) { s = s + s; }`},
+		{"template doubling", "let s = `ab`; for (;;) { s = `${s}${s}`; }"},
+		{"caught doubling", `let s = "x"; for (;;) { try { s += s; } catch (e) {} }`},
+		{"repeat", `try { "abc".repeat(1e9); } catch (e) {}`},
+		{"array growth", `const xs: number[] = []; for (let i = 0; ; i++) { xs.push(i); }`},
+		{"object churn", `const keep: any[] = []; for (;;) { keep[keep.length] = { a: 1 }; }`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPaserati()
+			defer p.Cleanup()
+			term := runLimited(t, p, tc.source, RunOptions{Limits: vm.Limits{MaxAllocation: 1 << 20}})
+			if term.Reason != vm.TerminationAllocationLimit {
+				t.Fatalf("unexpected termination: %v", term)
+			}
+			expectUsable(t, p)
+		})
+	}
+}
+
+func TestLimitsDoNotAffectNormalRuns(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	value, errs := p.RunCode(`
+		let parts: string[] = [];
+		for (let i = 0; i < 100; i++) { parts.push("n" + i); }
+		parts.join(",").length;
+	`, RunOptions{Limits: vm.Limits{
+		MaxInstructions: 1_000_000,
+		MaxCallDepth:    128,
+		MaxAllocation:   1 << 20,
+		Deadline:        time.Now().Add(time.Minute),
+	}})
+	if len(errs) > 0 || value.ToFloat() != 389 {
+		t.Fatalf("expected 389, got %s %v", value.Inspect(), errs)
+	}
+}
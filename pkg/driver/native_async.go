@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/nooga/paserati/pkg/types"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// AsyncFunction adds a function that runs on its own goroutine and returns a
+// promise, so that slow Go code like I/O doesn't block the VM.
+//
+// fn may take a context.Context as its first parameter, which is cancelled
+// when the VM is, and may return a value, an error, or both as (T, error).
+// The promise is fulfilled with the value or rejected with an Error carrying
+// the error's message. fn must not touch the VM: its arguments are converted
+// before it starts and its result is converted on the VM's thread.
+func (m *ModuleBuilder) AsyncFunction(name string, fn interface{}) *ModuleBuilder {
+	m.exports[name] = goAsyncFunctionToTSType(fn)
+	m.values[name] = goAsyncFunctionToVM(m.vm, fn)
+	return m
+}
+
+// AsyncFunction adds an async function to the namespace (see ModuleBuilder.AsyncFunction)
+func (ns *NamespaceBuilder) AsyncFunction(name string, fn interface{}) *NamespaceBuilder {
+	ns.exports[name] = goAsyncFunctionToTSType(fn)
+	ns.values[name] = goAsyncFunctionToVM(ns.vm, fn)
+	return ns
+}
+
+// asyncSignature describes the Go function behind an async native function
+type asyncSignature struct {
+	takesContext bool // The first parameter is a context.Context
+	hasValue     bool // The first result is the value to fulfill with
+	hasError     bool // The last result is an error
+}
+
+func newAsyncSignature(fnType reflect.Type) asyncSignature {
+	sig := asyncSignature{takesContext: fnType.NumIn() > 0 && fnType.In(0) == contextType}
+	switch fnType.NumOut() {
+	case 1:
+		sig.hasError = fnType.Out(0) == errorType
+		sig.hasValue = !sig.hasError
+	case 2:
+		sig.hasValue = true
+		sig.hasError = fnType.Out(1) == errorType
+	}
+	return sig
+}
+
+// goAsyncFunctionToTSType returns the type of an async native function: its
+// parameters, less any context, returning a promise of its value
+func goAsyncFunctionToTSType(fn interface{}) types.Type {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return types.Any
+	}
+	sig := newAsyncSignature(fnType)
+
+	var params []types.Type
+	first := 0
+	if sig.takesContext {
+		first = 1
+	}
+	for i := first; i < fnType.NumIn(); i++ {
+		params = append(params, goTypeToTSType(fnType.In(i)))
+	}
+
+	var valueType types.Type = types.Void
+	if sig.hasValue {
+		valueType = goTypeToTSType(fnType.Out(0))
+	}
+	if types.PromiseGeneric == nil {
+		return types.NewSimpleFunction(params, types.Any)
+	}
+	return types.NewSimpleFunction(params, types.NewInstantiatedType(types.PromiseGeneric, []types.Type{valueType}))
+}
+
+// goAsyncFunctionToVM wraps fn in a native function that starts fn on a
+// goroutine and returns a pending promise. The goroutine counts as an
+// external operation of the VM's async runtime, keeping the event loop alive
+// until it settles the promise through a microtask.
+func goAsyncFunctionToVM(vmInstance *vm.VM, fn interface{}) vm.Value {
+	fnValue := reflect.ValueOf(fn)
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return vm.Undefined
+	}
+	sig := newAsyncSignature(fnType)
+
+	first := 0
+	if sig.takesContext {
+		first = 1
+	}
+	minArgs := fnType.NumIn() - first
+	if fnType.IsVariadic() {
+		minArgs--
+	}
+
+	return vm.NewNativeFunction(minArgs, fnType.IsVariadic(), "native_async_function", func(args []vm.Value) (vm.Value, error) {
+		goArgs := make([]reflect.Value, 0, fnType.NumIn())
+		if sig.takesContext {
+			goArgs = append(goArgs, reflect.ValueOf(vmInstance.Context()))
+		}
+		goArgs = append(goArgs, convertNativeArgs(fnType, first, args)...)
+
+		promise := vmInstance.NewPendingPromise()
+		promiseObj := promise.AsPromise()
+		rt := vmInstance.GetAsyncRuntime()
+		rt.BeginExternalOp()
+
+		go func() {
+			var results []reflect.Value
+			var failure error
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						failure = fmt.Errorf("panic: %v", r)
+					}
+				}()
+				results = fnValue.Call(goArgs)
+			}()
+			if failure == nil && sig.hasError {
+				if errVal := results[len(results)-1]; !errVal.IsNil() {
+					failure = errVal.Interface().(error)
+				}
+			}
+
+			// Values may only be created and promises settled on the VM's thread
+			rt.ScheduleMicrotask(func() {
+				if failure != nil {
+					vmInstance.RejectPromise(promiseObj, goErrorToVM(vmInstance, failure))
+				} else if sig.hasValue {
+					vmInstance.ResolvePromise(promiseObj, reflectValueToVM(results[0]))
+				} else {
+					vmInstance.ResolvePromise(promiseObj, vm.Undefined)
+				}
+			})
+			rt.EndExternalOp()
+		}()
+
+		return promise, nil
+	})
+}
+
+// convertNativeArgs converts args to the parameters of fnType from first on,
+// passing zero values for missing arguments and spreading extra ones over a
+// variadic parameter
+func convertNativeArgs(fnType reflect.Type, first int, args []vm.Value) []reflect.Value {
+	fixed := fnType.NumIn()
+	if fnType.IsVariadic() {
+		fixed--
+	}
+	var goArgs []reflect.Value
+	for i := first; i < fixed; i++ {
+		if j := i - first; j < len(args) {
+			goArgs = append(goArgs, vmValueToReflectValue(args[j], fnType.In(i)))
+		} else {
+			goArgs = append(goArgs, reflect.Zero(fnType.In(i)))
+		}
+	}
+	if fnType.IsVariadic() {
+		elemType := fnType.In(fixed).Elem()
+		for j := fixed - first; j < len(args); j++ {
+			goArgs = append(goArgs, vmValueToReflectValue(args[j], elemType))
+		}
+	}
+	return goArgs
+}
+
+// goErrorToVM converts an error returned by Go code into a value to reject
+// with: the thrown value for errors carrying one, an Error otherwise
+func goErrorToVM(vmInstance *vm.VM, err error) vm.Value {
+	if exc, ok := err.(vm.ExceptionError); ok {
+		return exc.GetExceptionValue()
+	}
+	if jsErr, ok := err.(*JSError); ok {
+		return jsErr.Value
+	}
+	if errorCtor, ok := vmInstance.GetGlobal("Error"); ok {
+		if errorValue, callErr := vmInstance.Call(errorCtor, vm.Undefined, []vm.Value{vm.NewString(err.Error())}); callErr == nil {
+			return errorValue
+		}
+	}
+	return vm.NewString(err.Error())
+}
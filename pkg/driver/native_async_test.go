@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestNativeAsyncFunction(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	release := make(chan struct{})
+	p.DeclareModule("async-utils", func(m *ModuleBuilder) {
+		m.AsyncFunction("slowDouble", func(x float64) float64 {
+			<-release // Only released once the script has moved on
+			return x * 2
+		})
+		m.AsyncFunction("fail", func(ctx context.Context, message string) (string, error) {
+			return "", stderrors.New(message)
+		})
+		m.AsyncFunction("tick", func() {
+			close(release)
+		})
+	})
+
+	value, errs := p.RunString(`
+		import { slowDouble, fail, tick } from "async-utils";
+		const log: string[] = [];
+		const doubled: Promise<number> = slowDouble(21);
+		doubled.then(v => { log.push("double " + v); });
+		log.push("sync");
+		async function run() {
+			await tick();
+			await doubled;
+			try {
+				await fail("boom");
+			} catch (e) {
+				log.push(e instanceof Error ? e.message : "not an Error");
+			}
+		}
+		await run();
+		log.join(",");
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if result := value.ToString(); result != "sync,double 42,boom" {
+		t.Errorf("expected sync,double 42,boom, got %s", result)
+	}
+}
+
+func TestNativeAsyncFunctionCancel(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	p.DeclareModule("hang", func(m *ModuleBuilder) {
+		m.AsyncFunction("hang", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			stopped <- ctx.Err()
+			return ctx.Err()
+		})
+	})
+
+	go func() {
+		<-started
+		p.CancelVM()
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.RunString(`import { hang } from "hang"; await hang();`)
+	}()
+
+	select {
+	case err := <-stopped:
+		if !stderrors.Is(err, context.Canceled) {
+			t.Errorf("expected the context to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context was not cancelled with the VM")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run did not stop after cancellation")
+	}
+}
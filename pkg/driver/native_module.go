@@ -53,6 +53,9 @@ type NativeModule struct {
 // ValueConverter handles conversion between Go values and VM values
 type ValueConverter struct {
 	vm *vm.VM
+
+	// wrapCallable, when set, converts JS functions returned by ConvertFromVM
+	wrapCallable func(vm.Value) interface{}
 }
 
 // TypeGenerator uses reflection to generate TypeScript types from Go types
@@ -102,12 +105,6 @@ func (m *ModuleBuilder) Function(name string, fn interface{}) *ModuleBuilder {
 	return m
 }
 
-// AsyncFunction adds an async function to the module (TODO: implement Promise wrapping)
-func (m *ModuleBuilder) AsyncFunction(name string, fn interface{}) *ModuleBuilder {
-	// For now, just treat as regular function
-	return m.Function(name, fn)
-}
-
 // Constructor adds a constructor function to the module
 func (m *ModuleBuilder) Constructor(name string, fn interface{}) *ModuleBuilder {
 	return m.Function(name, fn)
@@ -861,8 +858,15 @@ func NewValueConverter(vm *vm.VM) *ValueConverter {
 	return &ValueConverter{vm: vm}
 }
 
+// ConvertToVM converts a Go value to a VM value. Strings, numbers, booleans,
+// maps, slices, structs and functions are converted recursively; vm.Value and
+// anything exposing a VMValue method are passed through unchanged.
 func (vc *ValueConverter) ConvertToVM(goValue interface{}) vm.Value {
 	switch v := goValue.(type) {
+	case vm.Value:
+		return v
+	case interface{ VMValue() vm.Value }:
+		return v.VMValue()
 	case string:
 		return vm.NewString(v)
 	case int:
@@ -882,33 +886,97 @@ func (vc *ValueConverter) ConvertToVM(goValue interface{}) vm.Value {
 		if reflect.TypeOf(goValue).Kind() == reflect.Func {
 			return vc.wrapGoFunction(goValue)
 		}
+		return vc.convertReflectValueToVM(reflect.ValueOf(goValue))
+	}
+}
 
-		// For now, return undefined for unknown types
-		return vm.Undefined
+// ConvertFromVM converts a VM value to a plain Go value: nil, bool, float64,
+// string, []interface{} or map[string]interface{}. Callable values are passed
+// to the wrapCallable hook when one is set; anything else that has no natural
+// Go representation is returned as the vm.Value itself.
+func (vc *ValueConverter) ConvertFromVM(vmVal vm.Value) interface{} {
+	switch vmVal.Type() {
+	case vm.TypeUndefined, vm.TypeNull:
+		return nil
+	case vm.TypeBoolean:
+		return vmVal.AsBoolean()
+	case vm.TypeFloatNumber, vm.TypeIntegerNumber:
+		return vmVal.ToFloat()
+	case vm.TypeString:
+		return vmVal.ToString()
+	case vm.TypeArray:
+		arr := vmVal.AsArray()
+		result := make([]interface{}, arr.Length())
+		for i := range result {
+			result[i] = vc.ConvertFromVM(arr.Get(i))
+		}
+		return result
+	case vm.TypeObject, vm.TypeDictObject:
+		result := make(map[string]interface{})
+		for _, key := range ownKeys(vmVal) {
+			if val, ok := getOwn(vmVal, key); ok {
+				result[key] = vc.ConvertFromVM(val)
+			}
+		}
+		return result
 	}
+	if vmVal.IsCallable() && vc.wrapCallable != nil {
+		return vc.wrapCallable(vmVal)
+	}
+	return vmVal
+}
+
+// ConvertTo converts a VM value to a Go value of the given type
+func (vc *ValueConverter) ConvertTo(vmVal vm.Value, targetType reflect.Type) (reflect.Value, error) {
+	return vc.convertVMValueToReflectValue(vmVal, targetType)
 }
 
 func (vc *ValueConverter) wrapGoFunction(fn interface{}) vm.Value {
 	fnValue := reflect.ValueOf(fn)
 	fnType := reflect.TypeOf(fn)
 
-	return vm.NewNativeFunction(fnType.NumIn(), fnType.IsVariadic(), "native_function", func(args []vm.Value) (vm.Value, error) {
-		// Convert VM values to Go values for input
-		goArgs := make([]reflect.Value, len(args))
-		for i, arg := range args {
-			if i < fnType.NumIn() {
-				goArgs[i] = vc.convertVMValueToReflectValue(arg, fnType.In(i))
+	// Variadic functions collect trailing arguments into their last parameter
+	fixedArgs := fnType.NumIn()
+	if fnType.IsVariadic() {
+		fixedArgs--
+	}
+
+	return vm.NewNativeFunction(fixedArgs, fnType.IsVariadic(), "native_function", func(args []vm.Value) (vm.Value, error) {
+		// Convert VM values to Go values for input, using undefined for missing arguments
+		goArgs := make([]reflect.Value, 0, fnType.NumIn())
+		for i := 0; i < fixedArgs; i++ {
+			arg := vm.Undefined
+			if i < len(args) {
+				arg = args[i]
 			}
+			goArg, err := vc.convertVMValueToReflectValue(arg, fnType.In(i))
+			if err != nil {
+				return vm.Undefined, vc.vm.NewTypeError(fmt.Sprintf("argument %d: %s", i+1, err.Error()))
+			}
+			goArgs = append(goArgs, goArg)
 		}
-
-		// Add missing arguments as zero values if function expects more
-		for i := len(args); i < fnType.NumIn(); i++ {
-			goArgs = append(goArgs, reflect.Zero(fnType.In(i)))
+		if fnType.IsVariadic() {
+			elemType := fnType.In(fixedArgs).Elem()
+			for i := fixedArgs; i < len(args); i++ {
+				goArg, err := vc.convertVMValueToReflectValue(args[i], elemType)
+				if err != nil {
+					return vm.Undefined, vc.vm.NewTypeError(fmt.Sprintf("argument %d: %s", i+1, err.Error()))
+				}
+				goArgs = append(goArgs, goArg)
+			}
 		}
 
 		// Call the Go function
 		results := fnValue.Call(goArgs)
 
+		// A trailing non-nil error is thrown into the script
+		if n := len(results); n > 0 && fnType.Out(n-1) == errorType {
+			if errVal := results[n-1]; !errVal.IsNil() {
+				return vm.Undefined, errVal.Interface().(error)
+			}
+			results = results[:n-1]
+		}
+
 		// Convert result back to VM value
 		if len(results) > 0 {
 			return vc.convertReflectValueToVM(results[0]), nil
@@ -918,42 +986,114 @@ func (vc *ValueConverter) wrapGoFunction(fn interface{}) vm.Value {
 	})
 }
 
-func (vc *ValueConverter) convertVMValueToReflectValue(vmVal vm.Value, targetType reflect.Type) reflect.Value {
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	vmValueType = reflect.TypeOf(vm.Value{})
+)
+
+func (vc *ValueConverter) convertVMValueToReflectValue(vmVal vm.Value, targetType reflect.Type) (reflect.Value, error) {
+	if targetType == vmValueType {
+		return reflect.ValueOf(vmVal), nil
+	}
+
+	// null and undefined become the zero value of any type
+	if vmVal.Type() == vm.TypeUndefined || vmVal.Type() == vm.TypeNull {
+		return reflect.Zero(targetType), nil
+	}
+
+	result := reflect.New(targetType).Elem()
 	switch targetType.Kind() {
+	case reflect.Interface:
+		goValue := vc.ConvertFromVM(vmVal)
+		if goValue == nil {
+			return result, nil
+		}
+		rv := reflect.ValueOf(goValue)
+		if !rv.Type().AssignableTo(targetType) {
+			return result, fmt.Errorf("cannot convert %s to %s", vmVal.TypeName(), targetType)
+		}
+		result.Set(rv)
 	case reflect.String:
-		if vmVal.IsString() {
-			return reflect.ValueOf(vmVal.AsString())
+		result.SetString(vmVal.ToString())
+	case reflect.Bool:
+		result.SetBool(vmVal.IsTruthy())
+	case reflect.Float64, reflect.Float32:
+		result.SetFloat(vmVal.ToFloat())
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		result.SetInt(int64(vmVal.ToFloat()))
+	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		result.SetUint(uint64(vmVal.ToFloat()))
+	case reflect.Slice:
+		if vmVal.Type() != vm.TypeArray {
+			return result, fmt.Errorf("cannot convert %s to %s", vmVal.TypeName(), targetType)
 		}
-		return reflect.ValueOf(vmVal.ToString())
-	case reflect.Float64:
-		if vmVal.IsNumber() {
-			return reflect.ValueOf(vmVal.AsFloat())
+		arr := vmVal.AsArray()
+		slice := reflect.MakeSlice(targetType, arr.Length(), arr.Length())
+		for i := 0; i < arr.Length(); i++ {
+			elem, err := vc.convertVMValueToReflectValue(arr.Get(i), targetType.Elem())
+			if err != nil {
+				return result, fmt.Errorf("index %d: %w", i, err)
+			}
+			slice.Index(i).Set(elem)
 		}
-		return reflect.ValueOf(0.0)
-	case reflect.Float32:
-		if vmVal.IsNumber() {
-			return reflect.ValueOf(float32(vmVal.AsFloat()))
+		result.Set(slice)
+	case reflect.Map:
+		if targetType.Key().Kind() != reflect.String || (vmVal.Type() != vm.TypeObject && vmVal.Type() != vm.TypeDictObject) {
+			return result, fmt.Errorf("cannot convert %s to %s", vmVal.TypeName(), targetType)
 		}
-		return reflect.ValueOf(float32(0.0))
-	case reflect.Int, reflect.Int64:
-		if vmVal.IsNumber() {
-			return reflect.ValueOf(int64(vmVal.AsFloat()))
+		m := reflect.MakeMap(targetType)
+		for _, key := range ownKeys(vmVal) {
+			val, _ := getOwn(vmVal, key)
+			elem, err := vc.convertVMValueToReflectValue(val, targetType.Elem())
+			if err != nil {
+				return result, fmt.Errorf("property %q: %w", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(targetType.Key()), elem)
 		}
-		return reflect.ValueOf(int64(0))
-	case reflect.Bool:
-		if vmVal.IsBoolean() {
-			return reflect.ValueOf(vmVal.AsBoolean())
+		result.Set(m)
+	case reflect.Struct:
+		if vmVal.Type() != vm.TypeObject && vmVal.Type() != vm.TypeDictObject {
+			return result, fmt.Errorf("cannot convert %s to %s", vmVal.TypeName(), targetType)
 		}
-		return reflect.ValueOf(false)
+		// Fields are matched by JSON tag or field name, as in ModuleBuilder
+		mb := &ModuleBuilder{}
+		for i := 0; i < targetType.NumField(); i++ {
+			field := targetType.Field(i)
+			propName := mb.getJSONPropertyName(field)
+			if !field.IsExported() || propName == "" {
+				continue
+			}
+			val, ok := getOwn(vmVal, propName)
+			if !ok {
+				continue
+			}
+			elem, err := vc.convertVMValueToReflectValue(val, field.Type)
+			if err != nil {
+				return result, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			result.Field(i).Set(elem)
+		}
+	case reflect.Ptr:
+		elem, err := vc.convertVMValueToReflectValue(vmVal, targetType.Elem())
+		if err != nil {
+			return result, err
+		}
+		ptr := reflect.New(targetType.Elem())
+		ptr.Elem().Set(elem)
+		result.Set(ptr)
 	default:
-		return reflect.Zero(targetType)
+		return result, fmt.Errorf("cannot convert %s to %s", vmVal.TypeName(), targetType)
 	}
+	return result, nil
 }
 
 func (vc *ValueConverter) convertReflectValueToVM(reflectVal reflect.Value) vm.Value {
 	if !reflectVal.IsValid() {
 		return vm.Undefined
 	}
+	if reflectVal.Type() == vmValueType {
+		return reflectVal.Interface().(vm.Value)
+	}
 
 	switch reflectVal.Kind() {
 	case reflect.String:
@@ -966,6 +1106,16 @@ func (vc *ValueConverter) convertReflectValueToVM(reflectVal reflect.Value) vm.V
 		return vm.NumberValue(float64(reflectVal.Uint()))
 	case reflect.Bool:
 		return vm.BooleanValue(reflectVal.Bool())
+	case reflect.Interface:
+		if reflectVal.IsNil() {
+			return vm.Null
+		}
+		return vc.ConvertToVM(reflectVal.Interface())
+	case reflect.Func:
+		if reflectVal.IsNil() {
+			return vm.Null
+		}
+		return vc.wrapGoFunction(reflectVal.Interface())
 	case reflect.Map:
 		// Convert Go map to VM object
 		obj := vm.NewObject(vm.Undefined)
@@ -976,11 +1126,54 @@ func (vc *ValueConverter) convertReflectValueToVM(reflectVal reflect.Value) vm.V
 			objPtr.SetOwn(keyStr, valVM)
 		}
 		return obj
+	case reflect.Slice, reflect.Array:
+		if reflectVal.Kind() == reflect.Slice && reflectVal.IsNil() {
+			return vm.Null
+		}
+		if reflectVal.Type().Elem().Kind() == reflect.Uint8 && reflectVal.Kind() == reflect.Slice {
+			// []byte becomes a Uint8Array
+			return reflectValueToVM(reflectVal)
+		}
+		arr := vm.NewArray()
+		arrayObj := arr.AsArray()
+		for i := 0; i < reflectVal.Len(); i++ {
+			arrayObj.Set(i, vc.convertReflectValueToVM(reflectVal.Index(i)))
+		}
+		return arr
+	case reflect.Struct:
+		// Bind through a pointer so methods with pointer receivers are exposed
+		ptr := reflect.New(reflectVal.Type())
+		ptr.Elem().Set(reflectVal)
+		return reflectValueToVM(ptr)
+	case reflect.Ptr:
+		return reflectValueToVM(reflectVal)
 	default:
 		return vm.Undefined
 	}
 }
 
+// ownKeys returns the own enumerable keys of a plain or dictionary object
+func ownKeys(obj vm.Value) []string {
+	switch obj.Type() {
+	case vm.TypeObject:
+		return obj.AsPlainObject().OwnKeys()
+	case vm.TypeDictObject:
+		return obj.AsDictObject().OwnKeys()
+	}
+	return nil
+}
+
+// getOwn reads an own property of a plain or dictionary object
+func getOwn(obj vm.Value, key string) (vm.Value, bool) {
+	switch obj.Type() {
+	case vm.TypeObject:
+		return obj.AsPlainObject().GetOwn(key)
+	case vm.TypeDictObject:
+		return obj.AsDictObject().GetOwn(key)
+	}
+	return vm.Undefined, false
+}
+
 // TypeGenerator methods
 
 func NewTypeGenerator() *TypeGenerator {
@@ -1017,6 +1210,8 @@ func (tg *TypeGenerator) GenerateType(goValue interface{}) types.Type {
 		result = tg.generateFunctionType(t)
 	case reflect.Map:
 		result = mapTypeToObjectType(t, tg.mapGoTypeToTS(t.Elem()))
+	case reflect.Slice:
+		result = goTypeToTSType(t)
 	default:
 		result = types.Any
 	}
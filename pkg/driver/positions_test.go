@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStackTraceColumns(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	// Two calls on one line are told apart by column
+	value, errs := p.RunCode(`function where(): string { return new Error("x").stack; }
+const stacks = [where(), where()];
+stacks.join("|");`, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	stacks := strings.Split(value.ToString(), "|")
+	if len(stacks) != 2 {
+		t.Fatalf("unexpected result %q", value.ToString())
+	}
+	for i, want := range []string{":2:22)", ":2:31)"} {
+		if !strings.Contains(stacks[i], ":1:35)") || !strings.Contains(stacks[i], want) {
+			t.Errorf("stack %d = %q, want frames at 1:35 and %s", i, stacks[i], want)
+		}
+	}
+}
+
+func TestRuntimeErrorColumn(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	p.SetSkipTypeCheck(true)
+
+	_, errs := p.RunCode("let o: any = null;\nlet y = 1; let z = o.foo;\n", RunOptions{})
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	if pos := errs[0].Pos(); pos.Line != 2 || pos.Column != 21 {
+		t.Errorf("error at %d:%d, want 2:21", pos.Line, pos.Column)
+	}
+	if !strings.Contains(errs[0].Error(), ":2:21") {
+		t.Errorf("stack in %q lacks the column", errs[0].Error())
+	}
+}
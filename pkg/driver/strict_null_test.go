@@ -0,0 +1,88 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictNullChecks(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	p.SetStrictNullChecks(true)
+
+	_, errs := p.RunString(`
+		let s: string = null;
+		function len(x: string | null): number { return x.length; }
+		function first(p?: string): string { return p.toUpperCase(); }
+		let f: (() => number) | undefined;
+		f();
+		const total: number = [1, 2].find(n => n > 1);
+	`)
+	messages := errorMessages(errs)
+	for _, expected := range []string{
+		"cannot assign type 'null' to variable 's' of type 'string'",
+		"'x' is possibly 'null'.",
+		"'p' is possibly 'undefined'.",
+		"Cannot invoke an object which is possibly 'undefined'.",
+		"cannot assign type 'number | undefined' to variable 'total' of type 'number'",
+	} {
+		if !strings.Contains(messages, expected) {
+			t.Errorf("expected %q in:\n%s", expected, messages)
+		}
+	}
+}
+
+func TestStrictNullChecksNarrowing(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+	p.SetStrictNullChecks(true)
+
+	value, errs := p.RunString(`
+		interface Config { name?: string; size: number | null }
+		function describe(c: Config, fallback?: string): string {
+			const name = c.name ? c.name.toUpperCase() : "anonymous";
+			const size = c.size !== null ? c.size.toFixed(0) : "?";
+			if (fallback === undefined) {
+				return name + ":" + size;
+			}
+			return fallback.length + name;
+		}
+		const m = new Map<string, number>();
+		m.set("a", 1);
+		const a = m.get("a");
+		const found = [1, 2, 3].find(n => n > 2)!;
+		const label = typeof a === "number" ? a.toFixed(0) : "";
+		describe({ size: 2 }) + "," + describe({ name: "x", size: null }, "ab") + "," + label + (found + 1);
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if result := value.ToString(); result != "anonymous:2,2X,14" {
+		t.Errorf("expected anonymous:2,2X,14, got %s", result)
+	}
+}
+
+func TestStrictNullChecksConfig(t *testing.T) {
+	const source = `function len(x: string | null): number { return x.length; } len("abc");`
+
+	loose := newProject(t, map[string]string{"tsconfig.json": `{"compilerOptions": {}}`})
+	if _, errs := loose.RunString(source); strings.Contains(errorMessages(errs), "possibly") {
+		t.Errorf("unexpected strict null check without the option:\n%s", errorMessages(errs))
+	}
+
+	for _, config := range []string{
+		`{"compilerOptions": {"strictNullChecks": true}}`,
+		`{"compilerOptions": {"strict": true}}`,
+	} {
+		p := newProject(t, map[string]string{"tsconfig.json": config})
+		_, errs := p.RunString(source)
+		if !strings.Contains(errorMessages(errs), "'x' is possibly 'null'.") {
+			t.Errorf("expected a strict null check with %s, got:\n%s", config, errorMessages(errs))
+		}
+	}
+
+	p := newProject(t, map[string]string{"tsconfig.json": `{"compilerOptions": {"strict": true, "strictNullChecks": false}}`})
+	if _, errs := p.RunString(source); strings.Contains(errorMessages(errs), "possibly") {
+		t.Errorf("strictNullChecks: false should override strict:\n%s", errorMessages(errs))
+	}
+}
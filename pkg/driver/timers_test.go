@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/runtime"
+)
+
+// TestTimersWithManualClock drives setTimeout/setInterval with virtual time
+func TestTimersWithManualClock(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	clock := runtime.NewManualClock(time.Unix(0, 0))
+	p.GetVM().SetAsyncRuntime(runtime.NewDefaultAsyncRuntimeWithClock(clock))
+
+	source := `
+		const log: string[] = [];
+		setTimeout(() => {
+			log.push("timeout");
+			Promise.resolve(0).then(() => log.push("micro"));
+		}, 100);
+		let ticks = 0;
+		const id = setInterval(() => {
+			ticks++;
+			log.push("tick" + ticks);
+			if (ticks === 2) clearInterval(id);
+		}, 40);
+		log;
+	`
+	program, parseErrs := parser.NewParser(lexer.NewLexer(source)).ParseProgram()
+	if len(parseErrs) > 0 {
+		t.Fatalf("parse errors: %v", parseErrs)
+	}
+	chunk, compileErrs := p.CompileProgram(program)
+	if len(compileErrs) > 0 {
+		t.Fatalf("compile errors: %v", compileErrs)
+	}
+	result, runtimeErrs := p.InterpretChunk(chunk)
+	if len(runtimeErrs) > 0 {
+		t.Fatalf("runtime errors: %v", runtimeErrs)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    string
+	}{
+		{39 * time.Millisecond, "[]"},
+		{1 * time.Millisecond, `["tick1"]`},
+		{40 * time.Millisecond, `["tick1", "tick2"]`},
+		{19 * time.Millisecond, `["tick1", "tick2"]`},
+		{1 * time.Millisecond, `["tick1", "tick2", "timeout", "micro"]`},
+		{time.Second, `["tick1", "tick2", "timeout", "micro"]`},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if err := p.GetVM().RunDueTimers(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if got := result.Inspect(); got != step.want {
+			t.Fatalf("step %d (+%v): expected %s, got %s", i, step.advance, step.want, got)
+		}
+	}
+
+	if err := p.GetVM().RunEventLoop(); err != nil {
+		t.Fatalf("expected empty event loop to finish cleanly, got %v", err)
+	}
+}
+
+// TestRunCodeWaitsForTimers checks that the driver keeps running until all
+// timers have fired
+func TestRunCodeWaitsForTimers(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	fired := false
+	p.DeclareModule("probe", func(m *ModuleBuilder) {
+		m.Function("mark", func() { fired = true })
+	})
+
+	_, errs := p.RunCode(`
+		import { mark } from "probe";
+		setTimeout(() => setTimeout(mark, 2), 2);
+	`, RunOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !fired {
+		t.Fatal("expected nested timer to fire before RunCode returned")
+	}
+}
+
+// TestTimerExceptionReported checks uncaught timer exceptions surface as runtime errors
+func TestTimerExceptionReported(t *testing.T) {
+	p := NewPaserati()
+	defer p.Cleanup()
+
+	_, errs := p.RunCode(`setTimeout(() => { throw new TypeError("late"); }, 1);`, RunOptions{})
+	if len(errs) != 1 {
+		t.Fatalf("expected one runtime error, got %v", errs)
+	}
+	if got := errs[0].Error(); !strings.Contains(got, "Uncaught exception: TypeError: late") {
+		t.Fatalf("unexpected error message: %s", got)
+	}
+}
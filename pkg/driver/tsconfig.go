@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/tsconfig"
+)
+
+// Compiler options of a tsconfig.json, by how paserati treats them. Options
+// in none of these sets are reported as unknown.
+var (
+	// honoredOptions map onto checker, compiler or module resolution settings
+	honoredOptions = map[string]bool{
+		"strict": true, "noImplicitAny": true, "strictNullChecks": true, "strictPropertyInitialization": true,
+		"alwaysStrict": true, "useUnknownInCatchVariables": true, "noImplicitOverride": true,
+		"noUnusedLocals": true, "noUnusedParameters": true, "baseUrl": true, "paths": true,
+	}
+	// unsupportedOptions change checking or semantics in ways paserati doesn't
+	// implement; they are reported unless turned off. Code always runs with
+	// the latest semantics and is checked against paserati's own builtins,
+	// whatever the target and lib.
+	unsupportedOptions = map[string]bool{
+		"target": true, "lib": true,
+		"strictFunctionTypes": true, "strictBindCallApply": true,
+		"strictBuiltinIteratorReturn": true, "noImplicitThis": true, "noImplicitReturns": true,
+		"noFallthroughCasesInSwitch": true, "noUncheckedIndexedAccess": true,
+		"exactOptionalPropertyTypes": true, "noPropertyAccessFromIndexSignature": true,
+		"experimentalDecorators": true, "emitDecoratorMetadata": true, "jsx": true,
+	}
+	// inertOptions only concern emitting files, tsc's own module resolution
+	// or project layout, and have nothing to do when running code
+	inertOptions = map[string]bool{
+		"module": true, "moduleResolution": true, "moduleDetection": true, "moduleSuffixes": true,
+		"resolveJsonModule": true, "resolvePackageJsonExports": true, "resolvePackageJsonImports": true,
+		"customConditions": true, "allowImportingTsExtensions": true, "rewriteRelativeImportExtensions": true,
+		"esModuleInterop": true, "allowSyntheticDefaultImports": true, "isolatedModules": true,
+		"verbatimModuleSyntax": true, "preserveConstEnums": true, "importHelpers": true,
+		"downlevelIteration": true, "useDefineForClassFields": true, "allowJs": true, "checkJs": true,
+		"skipLibCheck": true, "skipDefaultLibCheck": true, "forceConsistentCasingInFileNames": true,
+		"types": true, "typeRoots": true, "rootDir": true, "rootDirs": true,
+		"outDir": true, "outFile": true, "declaration": true, "declarationDir": true, "declarationMap": true,
+		"emitDeclarationOnly": true, "sourceMap": true, "inlineSourceMap": true, "inlineSources": true,
+		"sourceRoot": true, "mapRoot": true, "noEmit": true, "noEmitOnError": true, "removeComments": true,
+		"newLine": true, "incremental": true, "composite": true, "tsBuildInfoFile": true,
+		"pretty": true, "noErrorTruncation": true, "allowUnreachableCode": true, "allowUnusedLabels": true,
+	}
+)
+
+// LoadConfig reads the tsconfig.json at path, with the configs it extends,
+// and applies its compiler options to the session: checker flags, strict
+// mode for JavaScript and path mappings for module resolution. Checker
+// options the config doesn't set keep their current values. Options that
+// paserati can't honor are reported as warnings on stderr.
+func (p *Paserati) LoadConfig(path string) error {
+	config, err := tsconfig.Load(path)
+	if err != nil {
+		return err
+	}
+	p.config = config
+	if options := &config.CompilerOptions; options.StrictNullChecks != nil || options.Strict != nil {
+		p.strictNullChecks = options.StrictFlag(options.StrictNullChecks)
+	}
+	p.configureChecker(p.checker)
+	if options := &config.CompilerOptions; options.AlwaysStrict != nil && *options.AlwaysStrict {
+		p.compiler.SetStrictMode(true) // Type-checked code is always strict
+	}
+	warnings := configWarnings(config)
+	warnings = append(warnings, p.configurePaths(config)...)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", config.Path, warning)
+	}
+	return nil
+}
+
+// Config returns the tsconfig.json the session uses, or nil if it has none
+func (p *Paserati) Config() *tsconfig.Config {
+	return p.config
+}
+
+// LoadProjectConfig applies the tsconfig.json found in dir or its parents,
+// the way tsc finds a project, and returns its path. Without one, it
+// returns "" and leaves the session as it is.
+func (p *Paserati) LoadProjectConfig(dir string) (string, error) {
+	path := tsconfig.Find(dir)
+	if path == "" {
+		return "", nil
+	}
+	return path, p.LoadConfig(path)
+}
+
+// configureChecker applies the session's checker options and the compiler
+// options of its config to a checker
+func (p *Paserati) configureChecker(c *checker.Checker) {
+	c.SetStrictNullChecks(p.strictNullChecks)
+	if p.config == nil {
+		return
+	}
+	options := &p.config.CompilerOptions
+	c.SetNoImplicitAny(options.StrictFlag(options.NoImplicitAny))
+	c.SetUseUnknownInCatchVariables(options.StrictFlag(options.UseUnknownInCatchVariables))
+	if options.StrictPropertyInitialization != nil || options.Strict != nil {
+		// Unlike tsc, the checker reports uninitialized properties by default
+		c.SetSkipStrictPropertyInit(!options.StrictFlag(options.StrictPropertyInitialization))
+	}
+	if options.NoImplicitOverride != nil {
+		c.SetNoImplicitOverride(*options.NoImplicitOverride)
+	}
+	if options.NoUnusedLocals != nil {
+		c.SetNoUnusedLocals(*options.NoUnusedLocals)
+	}
+	if options.NoUnusedParameters != nil {
+		c.SetNoUnusedParameters(*options.NoUnusedParameters)
+	}
+}
+
+// configurePaths hands the "baseUrl" and "paths" of config to the package
+// resolver, which resolves within the session's base directory
+func (p *Paserati) configurePaths(config *tsconfig.Config) []string {
+	options := &config.CompilerOptions
+	var warnings []string
+	p.nodeResolver.SetBaseURL("")
+	p.nodeResolver.SetPaths(nil, "")
+	if options.BaseURL != "" {
+		if dir, ok := relativePath(p.baseDir, options.BaseURL); ok {
+			p.nodeResolver.SetBaseURL(dir)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("baseUrl %s is outside of %s and is ignored", options.BaseURL, p.baseDir))
+		}
+	}
+	if len(options.Paths) > 0 {
+		if dir, ok := relativePath(p.baseDir, options.PathsBase); ok {
+			p.nodeResolver.SetPaths(options.Paths, dir)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("paths relative to %s, outside of %s, are ignored", options.PathsBase, p.baseDir))
+		}
+	}
+	return warnings
+}
+
+// relativePath returns target as a slash-separated path relative to base,
+// and false if it is outside of base
+func relativePath(base, target string) (string, bool) {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+// configWarnings returns the warnings for the compiler options of config
+// that paserati does not honor
+func configWarnings(config *tsconfig.Config) []string {
+	options := &config.CompilerOptions
+	var warnings []string
+	for _, name := range options.Names() {
+		switch {
+		case honoredOptions[name], inertOptions[name]:
+		case unsupportedOptions[name]:
+			if string(options.Raw(name)) != "false" {
+				warnings = append(warnings, fmt.Sprintf("compiler option %q is not supported and is ignored", name))
+			}
+		default:
+			warnings = append(warnings, fmt.Sprintf("unknown compiler option %q", name))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/tsconfig"
+)
+
+// newProject writes files to a temporary directory and opens a session
+// there, with the directory's tsconfig.json if it has one
+func newProject(t *testing.T, files map[string]string) *Paserati {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := NewPaseratiWithBaseDir(dir)
+	t.Cleanup(p.Cleanup)
+	if _, ok := files[tsconfig.FileName]; ok {
+		if err := p.LoadConfig(filepath.Join(dir, tsconfig.FileName)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+// errorMessages returns the messages of errs, one per line
+func errorMessages(errs []errors.PaseratiError) string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Message())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func TestConfigCheckerOptions(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"tsconfig.base.json": `{"compilerOptions": {"strict": true}}`,
+		"tsconfig.json": `{
+			"extends": "./tsconfig.base.json",
+			"compilerOptions": {"noUnusedLocals": true, "noUnusedParameters": true},
+		}`,
+	})
+	if p.Config() == nil {
+		t.Fatal("expected the project's tsconfig.json to be loaded")
+	}
+
+	_, errs := p.RunString(`
+		function scale(value, _unused: number, factor: number) {
+			const unread = 1;
+			return [1, 2].map(n => n * value);
+		}
+		const area = (w, h: number) => h;
+		try { scale(1, 2, 3); area(1, 2); } catch (e) { e.message; }
+	`)
+	messages := errorMessages(errs)
+	for _, expected := range []string{
+		"Parameter 'value' implicitly has an 'any' type.",
+		"Parameter 'w' implicitly has an 'any' type.",
+		"'factor' is declared but its value is never read.",
+		"'unread' is declared but its value is never read.",
+		"'w' is declared but its value is never read.",
+		"property access is not supported on type unknown",
+	} {
+		if !strings.Contains(messages, expected) {
+			t.Errorf("expected %q in:\n%s", expected, messages)
+		}
+	}
+	for _, unexpected := range []string{"'n'", "'_unused'", "'scale'", "'h'"} {
+		if strings.Contains(messages, unexpected) {
+			t.Errorf("unexpected report of %s in:\n%s", unexpected, messages)
+		}
+	}
+}
+
+func TestProjectConfigIsExplicit(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, tsconfig.FileName)
+	if err := os.WriteFile(config, []byte(`{"compilerOptions": {"strict": true}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPaseratiWithBaseDir(src)
+	defer p.Cleanup()
+	if p.Config() != nil {
+		t.Fatal("expected a new session not to look for a tsconfig.json")
+	}
+	path, err := p.LoadProjectConfig(src)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if path != config || p.Config() == nil || p.Config().Path != config {
+		t.Errorf("expected %s to be loaded, got %q", config, path)
+	}
+}
+
+func TestConfigPaths(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"tsconfig.json":    `{"compilerOptions": {"baseUrl": "src", "paths": {"@lib/*": ["lib/*"]}}}`,
+		"src/lib/value.ts": `export const value = 20;`,
+		"src/shared.ts":    `export const shared = 22;`,
+	})
+
+	value, errs := p.RunString(`
+		import { value } from "@lib/value";
+		import { shared } from "shared";
+		value + shared;
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(errs))
+	}
+	if value.ToFloat() != 42 {
+		t.Errorf("expected 42, got %s", value.Inspect())
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	config, err := tsconfig.Parse([]byte(`{"compilerOptions": {
+		"strict": true,
+		"module": "nodenext",
+		"strictFunctionTypes": false,
+		"noImplicitReturns": true,
+		"frobnicate": true,
+		"target": "ES5",
+		"lib": ["ES2022", "dom.iterable", "es1999"]
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`unknown compiler option "frobnicate"`,
+		`compiler option "lib" is not supported and is ignored`,
+		`compiler option "noImplicitReturns" is not supported and is ignored`,
+		`compiler option "target" is not supported and is ignored`,
+	}
+	warnings := configWarnings(config)
+	if strings.Join(warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
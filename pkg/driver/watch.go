@@ -0,0 +1,304 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nooga/paserati/pkg/errors"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/vm"
+)
+
+// A watched script runs again whenever one of the files it is made of
+// changes. The files are polled, which needs no support from the platform
+// and costs little for the few hundred files of a project. Only the modules
+// whose files changed and the modules importing them, directly or not, are
+// dropped from the session's cache; the others are neither parsed, checked
+// nor compiled again, only run again.
+
+// DefaultWatchInterval is how often Watch polls the files of the script by
+// default
+const DefaultWatchInterval = 200 * time.Millisecond
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// Interval is how often the files of the script are polled for changes,
+	// DefaultWatchInterval if zero
+	Interval time.Duration
+	// OnCycle, if set, is called once the script is loaded, before it runs
+	OnCycle func(cycle *WatchCycle)
+	// OnRun, if set, is called once the script and its event loop are done
+	// or interrupted by a change
+	OnRun func(run *WatchRun)
+}
+
+// WatchCycle is a load of a watched script
+type WatchCycle struct {
+	// Changed are the files that changed since the previous cycle, relative
+	// to the session's base directory; none on the first cycle
+	Changed []string
+	// Rebuilt are the modules parsed, checked and compiled in this cycle
+	Rebuilt []string
+	// Cached is the number of modules kept from the previous cycle
+	Cached int
+	// Errors are the syntax, type and compile errors of the script's
+	// modules, in the order they were loaded. The script runs only if there
+	// are none.
+	Errors   []errors.PaseratiError
+	Duration time.Duration
+}
+
+// WatchRun is a run of a watched script
+type WatchRun struct {
+	// Errors are the runtime errors that stopped the script, if any
+	Errors []errors.PaseratiError
+	// Interrupted is whether the script was stopped by a change before it
+	// was done
+	Interrupted bool
+	Duration    time.Duration
+}
+
+// fileStamp identifies a version of a file; it is zero if the file is
+// missing
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch runs the script at filename, relative to the session's base
+// directory unless absolute, and runs it again each time one of its files
+// changes, until ctx is done. A run still going when a file changes is
+// stopped first. Watch fails only if the script can't be read at first.
+func (p *Paserati) Watch(ctx context.Context, filename string, options WatchOptions) error {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	entry := p.modulePath(filename)
+	if _, err := os.Stat(p.projectPath(entry)); err != nil {
+		return err
+	}
+	specifier := entry
+	if !modules.IsRelativeSpecifier(specifier) && !filepath.IsAbs(specifier) {
+		specifier = "./" + specifier
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	stamps := make(map[string]fileStamp)
+	var changed []string
+	for {
+		cycle, record := p.loadWatched(specifier, changed)
+		p.watchFiles(stamps, entry, specifier)
+		if options.OnCycle != nil {
+			options.OnCycle(cycle)
+		}
+
+		// The script runs on its own goroutine, so that files are polled
+		// while it runs; the VM is left alone until it is done
+		var done chan *WatchRun
+		cancelRun := func() {}
+		if len(cycle.Errors) == 0 {
+			var runCtx context.Context
+			runCtx, cancelRun = context.WithCancel(ctx)
+			done = make(chan *WatchRun, 1)
+			go func() { done <- p.runWatched(runCtx, record) }()
+		}
+		finish := func(run *WatchRun) {
+			cancelRun()
+			if options.OnRun != nil {
+				options.OnRun(run)
+			}
+		}
+
+		for changed = nil; changed == nil; {
+			select {
+			case <-ctx.Done():
+				if done != nil {
+					cancelRun()
+					<-done
+				}
+				return nil
+			case run := <-done:
+				done = nil
+				finish(run)
+			case <-ticker.C:
+				changed = p.pollFiles(stamps)
+			}
+		}
+		if done != nil {
+			cancelRun()
+			finish(<-done)
+		}
+
+		// Editors often save a file in several writes: wait for the files to
+		// settle before loading them
+		for settled := false; !settled; {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				more := p.pollFiles(stamps)
+				changed = append(changed, more...)
+				settled = len(more) == 0
+			}
+		}
+		sort.Strings(changed)
+		changed = slices.Compact(changed)
+
+		p.vmInstance.ResetModules()
+		p.vmInstance.GetAsyncRuntime().Reset()
+	}
+}
+
+// loadWatched loads the module at specifier, after dropping the modules
+// at the changed paths and those importing them from the session's cache,
+// returning the cycle and the module's record, nil if it failed to load
+func (p *Paserati) loadWatched(specifier string, changed []string) (*WatchCycle, *modules.ModuleRecord) {
+	start := time.Now()
+	cycle := &WatchCycle{Changed: changed}
+	for _, path := range changed {
+		p.InvalidateModule(path)
+	}
+	defer func() { cycle.Duration = time.Since(start) }()
+
+	loaded, err := p.moduleLoader.LoadModule(specifier, ".")
+	record, _ := loaded.(*modules.ModuleRecord)
+	if err != nil || record == nil {
+		msg := fmt.Sprintf("Module '%s' was not loaded", specifier)
+		if err != nil {
+			msg = fmt.Sprintf("Failed to load module '%s': %s", specifier, err.Error())
+		}
+		cycle.Errors = append(cycle.Errors, &errors.CompileError{Msg: msg})
+		return cycle, nil
+	}
+
+	for _, module := range p.moduleGraph(specifier) {
+		if module.IsNativeModule() {
+			continue
+		}
+		if module.LoadTime.Before(start) {
+			cycle.Cached++
+		} else {
+			cycle.Rebuilt = append(cycle.Rebuilt, module.ResolvedPath)
+		}
+		switch {
+		case len(module.Diagnostics) > 0:
+			errs := append([]errors.PaseratiError(nil), module.Diagnostics...)
+			sort.SliceStable(errs, func(i, j int) bool {
+				a, b := errs[i].Pos(), errs[j].Pos()
+				return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+			})
+			cycle.Errors = append(cycle.Errors, errs...)
+		case module.Error != nil:
+			cycle.Errors = append(cycle.Errors, &errors.CompileError{
+				Msg: fmt.Sprintf("Module error in '%s': %s", module.ResolvedPath, module.Error.Error()),
+			})
+		}
+	}
+	if len(cycle.Errors) == 0 && record.CompiledChunk == nil {
+		cycle.Errors = append(cycle.Errors, &errors.CompileError{
+			Msg: fmt.Sprintf("Module '%s' was not compiled", specifier),
+		})
+	}
+	return cycle, record
+}
+
+// runWatched runs the compiled module of record and its event loop until
+// they are done or ctx is
+func (p *Paserati) runWatched(ctx context.Context, record *modules.ModuleRecord) *WatchRun {
+	start := time.Now()
+	p.vmInstance.SetLimits(ctx, vm.Limits{})
+	defer p.vmInstance.ClearLimits()
+
+	p.vmInstance.SyncGlobalNames(p.heapAlloc.GetNameToIndexMap())
+	p.vmInstance.ResizeHeapForGlobals(p.heapAlloc.GetAllocatedSize())
+	p.vmInstance.SetCurrentModulePath(record.ResolvedPath)
+	_, errs := p.vmInstance.Interpret(record.CompiledChunk)
+	if len(errs) == 0 {
+		errs = p.runEventLoop()
+	}
+
+	run := &WatchRun{Interrupted: ctx.Err() != nil, Duration: time.Since(start)}
+	if !run.Interrupted {
+		run.Errors = errs
+	}
+	return run
+}
+
+// moduleGraph returns the records of the module at specifier and of those
+// it imports, directly or not, that the session has loaded
+func (p *Paserati) moduleGraph(specifier string) []*modules.ModuleRecord {
+	var graph []*modules.ModuleRecord
+	seen := make(map[string]bool)
+	for queue := []string{specifier}; len(queue) > 0; queue = queue[1:] {
+		if seen[queue[0]] {
+			continue
+		}
+		seen[queue[0]] = true
+		record := p.moduleLoader.GetModule(queue[0])
+		if record == nil {
+			continue
+		}
+		graph = append(graph, record)
+		queue = append(queue, record.Dependencies...)
+	}
+	return graph
+}
+
+// watchFiles makes stamps hold the files of the module at specifier and of
+// those it imports, keeping the stamps of the files already there so that
+// changes made while loading are still seen. The entry file is always
+// watched, even while missing.
+func (p *Paserati) watchFiles(stamps map[string]fileStamp, entry string, specifier string) {
+	files := map[string]bool{entry: true}
+	for _, record := range p.moduleGraph(specifier) {
+		if record.IsNativeModule() {
+			continue
+		}
+		for _, path := range []string{record.ResolvedPath, record.Typings} {
+			if path == "" {
+				continue
+			}
+			if _, err := os.Stat(p.projectPath(path)); err == nil {
+				files[path] = true
+			}
+		}
+	}
+	for path := range stamps {
+		if !files[path] {
+			delete(stamps, path)
+		}
+	}
+	for path := range files {
+		if _, ok := stamps[path]; !ok {
+			stamps[path] = statFile(p.projectPath(path))
+		}
+	}
+}
+
+// pollFiles updates stamps, returning the files that changed since
+func (p *Paserati) pollFiles(stamps map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range stamps {
+		if now := statFile(p.projectPath(path)); now != stamp {
+			stamps[path] = now
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// statFile returns the stamp of the file at path
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
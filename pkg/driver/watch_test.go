@@ -0,0 +1,124 @@
+package driver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	p := newProject(t, map[string]string{
+		"main.ts": `import { greeting } from "./lib/greeting";
+import { answer } from "./lib/answer";
+let runs = 0;
+runs++;
+setTimeout(() => report(greeting + " " + answer + " " + runs), 0);`,
+		"lib/greeting.ts": `export const greeting = "hello";`,
+		"lib/answer.ts":   `export const answer: number = 42;`,
+	})
+	reports := make(chan string, 10)
+	if err := p.Set("report", func(s string) { reports <- s }); err != nil {
+		t.Fatal(err)
+	}
+	writes := 0
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(p.baseDir, filepath.FromSlash(name))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure the change is seen even where modification times are coarse
+		writes++
+		later := time.Now().Add(time.Duration(writes) * time.Second)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cycles := make(chan *WatchCycle, 10)
+	runs := make(chan *WatchRun, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- p.Watch(ctx, "main.ts", WatchOptions{
+			Interval: 10 * time.Millisecond,
+			OnCycle:  func(cycle *WatchCycle) { cycles <- cycle },
+			OnRun:    func(run *WatchRun) { runs <- run },
+		})
+	}()
+	next := func() (*WatchCycle, string) {
+		t.Helper()
+		var cycle *WatchCycle
+		select {
+		case cycle = <-cycles:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a cycle")
+		}
+		if len(cycle.Errors) > 0 {
+			return cycle, ""
+		}
+		select {
+		case report := <-reports:
+			return cycle, report
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the script to run")
+		}
+		return nil, ""
+	}
+
+	cycle, report := next()
+	if report != "hello 42 1" || len(cycle.Rebuilt) != 3 || cycle.Cached != 0 {
+		t.Errorf("first cycle: expected 3 modules rebuilt and \"hello 42 1\", got %v and %q", cycle.Rebuilt, report)
+	}
+
+	write("lib/greeting.ts", `export const greeting = "hi";`)
+	cycle, report = next()
+	if report != "hi 42 1" {
+		t.Errorf("expected the script to run again from scratch, got %q", report)
+	}
+	if strings.Join(cycle.Changed, " ") != "lib/greeting.ts" || len(cycle.Rebuilt) != 2 || cycle.Cached != 1 {
+		t.Errorf("expected only lib/greeting.ts and main.ts rebuilt, got changed %v, rebuilt %v, %d cached", cycle.Changed, cycle.Rebuilt, cycle.Cached)
+	}
+
+	write("lib/answer.ts", `export const answer: number = "no";`)
+	cycle, _ = next()
+	if len(cycle.Errors) != 1 || cycle.Errors[0].Kind() != "Type" || cycle.Errors[0].Pos().Line != 1 {
+		t.Errorf("expected a type error in lib/answer.ts, got %v", cycle.Errors)
+	}
+
+	write("lib/answer.ts", `export const answer: number = 7;`)
+	if _, report = next(); report != "hi 7 1" {
+		t.Errorf("expected the script to run once fixed, got %q", report)
+	}
+
+	// A script that never finishes is stopped before it runs again
+	write("main.ts", `setInterval(() => {}, 1000);
+report("serving");`)
+	if _, report = next(); report != "serving" {
+		t.Errorf("expected the new script to run, got %q", report)
+	}
+	write("main.ts", `setInterval(() => {}, 1000);
+report("serving again");`)
+	if _, report = next(); report != "serving again" {
+		t.Errorf("expected the script to run again, got %q", report)
+	}
+	var interrupted bool
+	for len(runs) > 0 {
+		run := <-runs
+		interrupted = interrupted || run.Interrupted
+	}
+	if !interrupted {
+		t.Error("expected the first run of the server to be interrupted")
+	}
+
+	cancel()
+	if err := <-stopped; err != nil {
+		t.Errorf("Watch failed: %v", err)
+	}
+	if err := p.Watch(context.Background(), "missing.ts", WatchOptions{}); err == nil {
+		t.Error("expected an error for a missing script")
+	}
+}
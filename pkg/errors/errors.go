@@ -38,6 +38,7 @@ const (
 	// Runtime Error Codes (PS4xxx)
 	PS4001 = "PS4001" // Runtime exception
 	PS4002 = "PS4002" // Reference error
+	PS4003 = "PS4003" // Execution terminated (resource limit or cancellation)
 )
 
 // PaseratiError is the interface implemented by all Paserati errors.
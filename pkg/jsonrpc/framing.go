@@ -0,0 +1,51 @@
+// Package jsonrpc frames JSON messages with a Content-Length header, the
+// base protocol shared by the language server (see package lsp) and the
+// debug adapter (see package dap).
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ReadMessage reads one message, framed by a Content-Length header, and
+// decodes it into msg. It returns io.EOF when r ends before a header.
+func ReadMessage(r *bufio.Reader, msg any) error {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("jsonrpc: reading header: %w", err)
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return fmt.Errorf("jsonrpc: bad Content-Length %q", header.Get("Content-Length"))
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return fmt.Errorf("jsonrpc: reading content: %w", err)
+	}
+	if err := json.Unmarshal(content, msg); err != nil {
+		return fmt.Errorf("jsonrpc: decoding message: %w", err)
+	}
+	return nil
+}
+
+// WriteMessage encodes msg as JSON and writes it with its Content-Length header
+func WriteMessage(w io.Writer, msg any) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,48 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	for _, text := range []string{"ünïcode", "second"} {
+		if err := WriteMessage(&buf, map[string]string{"text": text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.HasPrefix(buf.String(), "Content-Length: 20\r\n\r\n{") {
+		t.Errorf("expected the length in bytes in the header, got %q", buf.String())
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, expected := range []string{"ünïcode", "second"} {
+		var msg struct{ Text string }
+		if err := ReadMessage(r, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Text != expected {
+			t.Errorf("expected %q, got %q", expected, msg.Text)
+		}
+	}
+	if err := ReadMessage(r, &struct{}{}); err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	for _, input := range []string{
+		"Content-Type: application/json\r\n\r\n{}",
+		"Content-Length: -1\r\n\r\n{}",
+		"Content-Length: 10\r\n\r\n{}",
+		"Content-Length: 2\r\n\r\n{]",
+	} {
+		if err := ReadMessage(bufio.NewReader(strings.NewReader(input)), &struct{}{}); err == nil || err == io.EOF {
+			t.Errorf("expected an error reading %q, got %v", input, err)
+		}
+	}
+}
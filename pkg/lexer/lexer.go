@@ -438,6 +438,8 @@ func (l *Lexer) SetPosition(pos int) {
 	l.readPosition = pos + 1
 	l.ch = l.input[l.position]
 	// NOTE: Line number is NOT recalculated here. Backtracking assumes it's okay.
+	// The column is, as it is cheap to find from the start of the line.
+	l.column = pos - strings.LastIndexAny(l.input[:pos], "\n\r")
 
 	// DEPRECATED: This method resets template state which breaks backtracking.
 	// Use SaveState/RestoreState instead for proper template literal handling.
@@ -467,7 +469,7 @@ func NewLexerWithSource(sourceFile *source.SourceFile) *Lexer {
 		source:    sourceFile,
 		input:     sourceFile.Content,
 		line:      1,
-		column:    1,
+		column:    0,       // readChar moves to column 1
 		prevToken: ILLEGAL, // Initialize to ILLEGAL to allow regex at start of input
 	} // Start at line 1, column 1
 	l.readChar() // Initialize l.ch, l.position, l.readPosition, and potentially update line/column if input starts with newline
@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/driver"
+)
+
+// document is a module's text, open in the client or read from disk, and
+// its analysis
+type document struct {
+	uri      string
+	path     string // Module path, relative to the root unless outside it
+	version  int
+	text     string
+	lines    []int // Byte offsets of the starts of lines
+	analysis *driver.Analysis
+}
+
+func newDocument(uri, path, text string, version int) *document {
+	doc := &document{uri: uri, path: path, version: version}
+	doc.setText(text)
+	return doc
+}
+
+func (d *document) setText(text string) {
+	d.text = text
+	d.lines = append(d.lines[:0], 0)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+}
+
+// applyChange applies a content change; one without a range replaces the
+// whole text
+func (d *document) applyChange(change TextDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.setText(change.Text)
+		return
+	}
+	start, end := d.offset(change.Range.Start), d.offset(change.Range.End)
+	if end < start {
+		start, end = end, start
+	}
+	d.setText(d.text[:start] + change.Text + d.text[end:])
+}
+
+// offset converts a position to a byte offset, clamped to the text
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[pos.Line]
+	for units := 0; units < pos.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// position converts a byte offset to a position
+func (d *document) position(offset int) Position {
+	offset = max(0, min(offset, len(d.text)))
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	character := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += utf16.RuneLen(r)
+	}
+	return Position{Line: line, Character: character}
+}
+
+// span converts a span of byte offsets to a range
+func (d *document) span(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// lineColumn converts a 1-based line and a 1-based column, as errors report
+// them, to a byte offset. The lexer counts columns in bytes.
+func (d *document) lineColumn(line, column int) int {
+	if line < 1 || line > len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[line-1]
+	end := len(d.text)
+	if line < len(d.lines) {
+		end = d.lines[line] - 1
+	}
+	return min(offset+max(column-1, 0), end)
+}
+
+// uriToPath returns the file path of a file URI
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathToURI returns the file URI of an absolute file path
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
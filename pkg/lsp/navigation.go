@@ -0,0 +1,709 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nooga/paserati/pkg/checker"
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/modules"
+	"github.com/nooga/paserati/pkg/parser"
+	"github.com/nooga/paserati/pkg/source"
+	"github.com/nooga/paserati/pkg/types"
+)
+
+// completionPlaceholder stands in for the member being completed, so that
+// the member expression parses and its object gets checked
+const completionPlaceholder = "__paserati_completion__"
+
+// maxReexports bounds how many re-exports a declaration is followed through
+const maxReexports = 16
+
+func (s *Server) hover(msg *Message) (any, error) {
+	doc, path, err := s.identifierAt(msg)
+	if err != nil || path == nil {
+		return nil, err
+	}
+	ident := path[len(path)-1].(*parser.Identifier)
+
+	var text string
+	if member := memberOf(path); member != nil {
+		if t := member.GetComputedType(); t != nil {
+			text = "(property) " + ident.Value + ": " + t.String()
+		}
+	} else if t := ident.GetComputedType(); t != nil {
+		text = ident.Value + ": " + t.String()
+	} else if binding := bindingOf(doc.analysis.Program, ident); binding != nil {
+		text = bindingText(binding)
+	}
+	if text == "" {
+		return nil, nil
+	}
+	r := doc.span(ident.Token.StartPos, ident.Token.EndPos)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: "```typescript\n" + text + "\n```"}, Range: &r}, nil
+}
+
+// bindingText describes a binding whose identifier has no type of its own:
+// declarations of types, and of values typed through other identifiers
+func bindingText(binding *checker.Binding) string {
+	if binding.Type && binding.Node != nil {
+		return binding.Node.String()
+	}
+	for _, ident := range append([]*parser.Identifier{binding.Name}, binding.References...) {
+		if t := ident.GetComputedType(); t != nil {
+			return binding.Name.Value + ": " + t.String()
+		}
+	}
+	if expr, ok := binding.Node.(parser.Expression); ok && expr.GetComputedType() != nil {
+		return binding.Name.Value + ": " + expr.GetComputedType().String()
+	}
+	return ""
+}
+
+func (s *Server) definition(msg *Message) (any, error) {
+	doc, path, err := s.identifierAt(msg)
+	if err != nil || path == nil || memberOf(path) != nil {
+		return nil, err
+	}
+	nav := s.navigator()
+	target := nav.declaration(doc, path[len(path)-1].(*parser.Identifier), 0)
+	if target == nil {
+		return nil, nil
+	}
+	return []Location{target.location()}, nil
+}
+
+func (s *Server) references(msg *Message) (any, error) {
+	var params ReferenceParams
+	if err := decodeParams(msg, &params); err != nil {
+		return nil, err
+	}
+	doc, path, err := s.identifierAtPosition(params.TextDocumentPositionParams)
+	if err != nil || path == nil || memberOf(path) != nil {
+		return nil, err
+	}
+	nav := s.navigator()
+	target := nav.declaration(doc, path[len(path)-1].(*parser.Identifier), 0)
+	if target == nil || target.ident == nil {
+		return nil, nil
+	}
+
+	// The declaration's own module, and the open modules importing it
+	docs := s.openDocuments()
+	if !nav.isOpen(target.doc) {
+		docs = append(docs, target.doc)
+	}
+	locations := []Location{}
+	for _, d := range docs {
+		if d.analysis == nil || d.analysis.Program == nil {
+			continue
+		}
+		for _, binding := range checker.Bindings(d.analysis.Program) {
+			if !target.same(nav.bindingDeclaration(d, binding, 0)) {
+				continue
+			}
+			isDeclaration := target.same(&declaration{doc: d, ident: binding.Name})
+			if params.Context.IncludeDeclaration || !isDeclaration {
+				locations = append(locations, (&declaration{doc: d, ident: binding.Name}).location())
+			}
+			for _, ref := range binding.References {
+				locations = append(locations, (&declaration{doc: d, ident: ref}).location())
+			}
+		}
+	}
+	return locations, nil
+}
+
+func (s *Server) documentSymbol(msg *Message) (any, error) {
+	var params DocumentSymbolParams
+	if err := decodeParams(msg, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if doc.analysis.Program == nil {
+		return []DocumentSymbol{}, nil
+	}
+	return symbols(doc, doc.analysis.Program.Statements, len(doc.text)), nil
+}
+
+// completion lists the members of the object of the member access being
+// typed, checking the document with the partial member name replaced
+func (s *Server) completion(msg *Message) (any, error) {
+	var params TextDocumentPositionParams
+	if err := decodeParams(msg, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	list := CompletionList{Items: []CompletionItem{}}
+
+	offset := doc.offset(params.Position)
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(doc.text[:start])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		start -= size
+	}
+	if start == 0 || doc.text[start-1] != '.' {
+		return list, nil
+	}
+
+	analysis := s.p.Analyze(doc.path, doc.text[:start]+completionPlaceholder+doc.text[offset:])
+	if analysis.Checker == nil {
+		return list, nil
+	}
+	path := checker.NodePath(analysis.Program, start)
+	if path == nil {
+		return list, nil
+	}
+	var object parser.Expression
+	switch node := memberOf(path).(type) {
+	case *parser.MemberExpression:
+		object = node.Object
+	case *parser.OptionalChainingExpression:
+		object = node.Object
+	}
+	if object == nil || object.GetComputedType() == nil {
+		return list, nil
+	}
+	for name, memberType := range analysis.Checker.Members(object.GetComputedType()) {
+		item := CompletionItem{Label: name, Kind: CompletionProperty, Detail: memberType.String()}
+		if isCallable(memberType) {
+			item.Kind = CompletionMethod
+		}
+		list.Items = append(list.Items, item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Label < list.Items[j].Label })
+	return list, nil
+}
+
+// identifierAt returns the document of a request about a position, and
+// the identifier there with the nodes enclosing it
+func (s *Server) identifierAt(msg *Message) (*document, []parser.Node, error) {
+	var params TextDocumentPositionParams
+	if err := decodeParams(msg, &params); err != nil {
+		return nil, nil, err
+	}
+	return s.identifierAtPosition(params)
+}
+
+func (s *Server) identifierAtPosition(params TextDocumentPositionParams) (*document, []parser.Node, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc.analysis.Program == nil {
+		return doc, nil, nil
+	}
+	return doc, checker.NodePath(doc.analysis.Program, doc.offset(params.Position)), nil
+}
+
+// memberOf returns the member access whose property is the identifier at
+// the end of path, or nil
+func memberOf(path []parser.Node) parser.Expression {
+	if len(path) < 2 {
+		return nil
+	}
+	ident := path[len(path)-1]
+	switch parent := path[len(path)-2].(type) {
+	case *parser.MemberExpression:
+		if parent.Property == ident {
+			return parent
+		}
+	case *parser.OptionalChainingExpression:
+		if parent.Property == ident {
+			return parent
+		}
+	}
+	return nil
+}
+
+// bindingOf returns the binding ident declares or refers to, or nil
+func bindingOf(program *parser.Program, ident *parser.Identifier) *checker.Binding {
+	for _, binding := range checker.Bindings(program) {
+		if binding.Name == ident {
+			return binding
+		}
+		for _, ref := range binding.References {
+			if ref == ident {
+				return binding
+			}
+		}
+	}
+	return nil
+}
+
+func isCallable(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.ObjectType:
+		return t.IsCallable()
+	case *types.GenericType:
+		return isCallable(t.Body)
+	}
+	return false
+}
+
+// declaration is where a name is declared: an identifier of a module, or
+// the module itself for namespace imports
+type declaration struct {
+	doc   *document
+	ident *parser.Identifier
+}
+
+func (d *declaration) location() Location {
+	if d.ident == nil {
+		return Location{URI: d.doc.uri}
+	}
+	return Location{URI: d.doc.uri, Range: d.doc.span(d.ident.Token.StartPos, d.ident.Token.EndPos)}
+}
+
+// same reports whether d and other are the same declaration. Modules that
+// are not open are parsed anew, so declarations are compared by position.
+func (d *declaration) same(other *declaration) bool {
+	if d == nil || other == nil || d.doc.path != other.doc.path {
+		return false
+	}
+	if d.ident == nil || other.ident == nil {
+		return d.ident == other.ident
+	}
+	return d.ident.Token.StartPos == other.ident.Token.StartPos
+}
+
+// navigator follows names across modules for one request, parsing the
+// modules that are not open once
+type navigator struct {
+	s      *Server
+	parsed map[string]*document
+}
+
+func (s *Server) navigator() *navigator {
+	return &navigator{s: s, parsed: make(map[string]*document)}
+}
+
+func (n *navigator) isOpen(doc *document) bool {
+	return n.s.docs[doc.uri] == doc
+}
+
+// declaration returns the declaration ident refers to, following imports
+// to the modules declaring what they import. depth counts the re-exports
+// followed.
+func (n *navigator) declaration(doc *document, ident *parser.Identifier, depth int) *declaration {
+	binding := bindingOf(doc.analysis.Program, ident)
+	if binding == nil {
+		return nil
+	}
+	return n.bindingDeclaration(doc, binding, depth)
+}
+
+// bindingDeclaration returns the declaration of a binding of doc
+func (n *navigator) bindingDeclaration(doc *document, binding *checker.Binding, depth int) *declaration {
+	imp, ok := binding.Node.(*parser.ImportDeclaration)
+	if !ok {
+		return &declaration{doc: doc, ident: binding.Name}
+	}
+	target := n.module(imp.Source.Value)
+	if target == nil {
+		return nil
+	}
+	for _, spec := range imp.Specifiers {
+		switch spec := spec.(type) {
+		case *parser.ImportDefaultSpecifier:
+			if spec.Local == binding.Name {
+				return n.exported(target, "default", depth+1)
+			}
+		case *parser.ImportNamedSpecifier:
+			if spec.Local == binding.Name {
+				return n.exported(target, spec.Imported.Value, depth+1)
+			}
+		case *parser.ImportNamespaceSpecifier:
+			if spec.Local == binding.Name {
+				return &declaration{doc: target}
+			}
+		}
+	}
+	return nil
+}
+
+// exported returns the declaration of what doc exports as name
+func (n *navigator) exported(doc *document, name string, depth int) *declaration {
+	if doc == nil || depth > maxReexports {
+		return nil
+	}
+	for _, stmt := range doc.analysis.Program.Statements {
+		switch node := stmt.(type) {
+		case *parser.ExportNamedDeclaration:
+			if node.Declaration != nil {
+				for _, ident := range declaredNames(node.Declaration) {
+					if ident.Value == name {
+						return &declaration{doc: doc, ident: ident}
+					}
+				}
+				continue
+			}
+			for _, spec := range node.Specifiers {
+				spec, ok := spec.(*parser.ExportNamedSpecifier)
+				if !ok || exportName(spec.Exported) != name {
+					continue
+				}
+				local := exportName(spec.Local)
+				if node.Source != nil {
+					return n.exported(n.module(node.Source.Value), local, depth+1)
+				}
+				if ident := topLevelName(doc.analysis.Program, local); ident != nil {
+					return n.declaration(doc, ident, depth)
+				}
+			}
+		case *parser.ExportDefaultDeclaration:
+			if name != "default" {
+				continue
+			}
+			switch decl := node.Declaration.(type) {
+			case *parser.FunctionLiteral:
+				if decl.Name != nil {
+					return &declaration{doc: doc, ident: decl.Name}
+				}
+			case *parser.ClassExpression:
+				if decl.Name != nil {
+					return &declaration{doc: doc, ident: decl.Name}
+				}
+			case *parser.Identifier:
+				if ident := topLevelName(doc.analysis.Program, decl.Value); ident != nil {
+					return n.declaration(doc, ident, depth)
+				}
+			}
+			return &declaration{doc: doc}
+		case *parser.ExportAllDeclaration:
+			if node.Exported == nil && name != "default" {
+				if decl := n.exported(n.module(node.Source.Value), name, depth+1); decl != nil {
+					return decl
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// module returns the module a canonical relative specifier refers to: an
+// open document, or a file parsed for the request. Returns nil for packages
+// and modules that are not found.
+func (n *navigator) module(specifier string) *document {
+	if !modules.IsRelativeSpecifier(specifier) {
+		return nil
+	}
+	path := strings.TrimPrefix(specifier, "./")
+	candidates := []string{path, path + ".ts", path + ".tsx", path + ".d.ts", path + "/index.ts"}
+	if strings.HasSuffix(path, ".js") {
+		candidates = append(candidates, strings.TrimSuffix(path, ".js")+".ts")
+	}
+	for _, candidate := range candidates {
+		for _, doc := range n.s.docs {
+			if doc.path == candidate {
+				return doc
+			}
+		}
+		if doc, ok := n.parsed[candidate]; ok {
+			return doc
+		}
+		filename := filepath.Join(n.s.root, filepath.FromSlash(candidate))
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			continue
+		}
+		doc := newDocument(pathToURI(filename), candidate, string(content), 0)
+		sourceFile := source.FromFile(candidate, doc.text)
+		program, _ := parser.NewParser(lexer.NewLexerWithSource(sourceFile)).ParseProgram()
+		modules.CanonicalizeImports(program, candidate)
+		doc.analysis = &driver.Analysis{Filename: candidate, Source: sourceFile, Program: program}
+		n.parsed[candidate] = doc
+		return doc
+	}
+	return nil
+}
+
+// exportName returns the name of an export specifier's identifier or string
+func exportName(expr parser.Expression) string {
+	switch expr := expr.(type) {
+	case *parser.Identifier:
+		return expr.Value
+	case *parser.StringLiteral:
+		return expr.Value
+	}
+	return ""
+}
+
+// topLevelName returns the identifier declaring or importing name at the
+// top level of program
+func topLevelName(program *parser.Program, name string) *parser.Identifier {
+	for _, stmt := range program.Statements {
+		if imp, ok := stmt.(*parser.ImportDeclaration); ok {
+			for _, spec := range imp.Specifiers {
+				var local *parser.Identifier
+				switch spec := spec.(type) {
+				case *parser.ImportDefaultSpecifier:
+					local = spec.Local
+				case *parser.ImportNamedSpecifier:
+					local = spec.Local
+				case *parser.ImportNamespaceSpecifier:
+					local = spec.Local
+				}
+				if local != nil && local.Value == name {
+					return local
+				}
+			}
+			continue
+		}
+		if export, ok := stmt.(*parser.ExportNamedDeclaration); ok && export.Declaration != nil {
+			stmt = export.Declaration
+		}
+		for _, ident := range declaredNames(stmt) {
+			if ident.Value == name {
+				return ident
+			}
+		}
+	}
+	return nil
+}
+
+// declaredNames returns the identifiers a declaration statement declares
+func declaredNames(stmt parser.Statement) []*parser.Identifier {
+	var names []*parser.Identifier
+	switch node := stmt.(type) {
+	case *parser.LetStatement:
+		for _, decl := range node.Declarations {
+			names = append(names, decl.Name)
+		}
+	case *parser.ConstStatement:
+		for _, decl := range node.Declarations {
+			names = append(names, decl.Name)
+		}
+	case *parser.VarStatement:
+		for _, decl := range node.Declarations {
+			names = append(names, decl.Name)
+		}
+	case *parser.ExpressionStatement:
+		switch expr := node.Expression.(type) {
+		case *parser.FunctionLiteral:
+			names = append(names, expr.Name)
+		case *parser.FunctionSignature:
+			names = append(names, expr.Name)
+		case *parser.EnumDeclaration:
+			names = append(names, expr.Name)
+		}
+	case *parser.ClassDeclaration:
+		names = append(names, node.Name)
+	case *parser.InterfaceDeclaration:
+		names = append(names, node.Name)
+	case *parser.TypeAliasStatement:
+		names = append(names, node.Name)
+	case *parser.NamespaceDeclaration:
+		names = append(names, node.Name)
+	}
+	declared := names[:0]
+	for _, name := range names {
+		if name != nil {
+			declared = append(declared, name)
+		}
+	}
+	return declared
+}
+
+// symbols returns the symbols of the declarations among stmts. Each spans
+// from its statement to the next one, the last one to end.
+func symbols(doc *document, stmts []parser.Statement, end int) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	for i, stmt := range stmts {
+		start, stmtEnd := nodeStart(stmt), end
+		if i+1 < len(stmts) {
+			if next := nodeStart(stmts[i+1]); next > start {
+				stmtEnd = next
+			}
+		}
+		if export, ok := stmt.(*parser.ExportNamedDeclaration); ok && export.Declaration != nil {
+			stmt = export.Declaration
+		}
+		if export, ok := stmt.(*parser.ExportDefaultDeclaration); ok {
+			stmt = &parser.ExpressionStatement{Expression: export.Declaration}
+		}
+		result = append(result, statementSymbols(doc, stmt, start, stmtEnd)...)
+	}
+	return result
+}
+
+func statementSymbols(doc *document, stmt parser.Statement, start, end int) []DocumentSymbol {
+	var result []DocumentSymbol
+	declarators := func(decls []*parser.VarDeclarator, kind int) {
+		for _, decl := range decls {
+			if decl.Name == nil {
+				continue
+			}
+			t := decl.ComputedType
+			if t == nil {
+				t = decl.Name.GetComputedType()
+			}
+			result = append(result, symbol(doc, decl.Name, kind, t, start, end))
+		}
+	}
+	switch node := stmt.(type) {
+	case *parser.LetStatement:
+		declarators(node.Declarations, SymbolVariable)
+	case *parser.VarStatement:
+		declarators(node.Declarations, SymbolVariable)
+	case *parser.ConstStatement:
+		declarators(node.Declarations, SymbolConstant)
+	case *parser.ExpressionStatement:
+		switch expr := node.Expression.(type) {
+		case *parser.FunctionLiteral:
+			if expr.Name != nil {
+				result = append(result, symbol(doc, expr.Name, SymbolFunction, expr.GetComputedType(), start, end))
+			}
+		case *parser.FunctionSignature:
+			if expr.Name != nil {
+				result = append(result, symbol(doc, expr.Name, SymbolFunction, expr.GetComputedType(), start, end))
+			}
+		case *parser.EnumDeclaration:
+			sym := symbol(doc, expr.Name, SymbolEnum, nil, start, end)
+			for _, member := range expr.Members {
+				if member != nil && member.Name != nil {
+					sym.Children = append(sym.Children, symbol(doc, member.Name, SymbolEnumMember, nil, member.Name.Token.StartPos, member.Name.Token.EndPos))
+				}
+			}
+			result = append(result, sym)
+		case *parser.ClassExpression:
+			if expr.Name != nil {
+				sym := symbol(doc, expr.Name, SymbolClass, nil, start, end)
+				sym.Children = classMembers(doc, expr.Body)
+				result = append(result, sym)
+			}
+		}
+	case *parser.ClassDeclaration:
+		sym := symbol(doc, node.Name, SymbolClass, nil, start, end)
+		sym.Children = classMembers(doc, node.Body)
+		result = append(result, sym)
+	case *parser.InterfaceDeclaration:
+		sym := symbol(doc, node.Name, SymbolInterface, nil, start, end)
+		for _, prop := range node.Properties {
+			if prop.Name == nil {
+				continue
+			}
+			kind := SymbolProperty
+			if prop.IsMethod {
+				kind = SymbolMethod
+			}
+			sym.Children = append(sym.Children, symbol(doc, prop.Name, kind, nil, prop.Name.Token.StartPos, prop.Name.Token.EndPos))
+		}
+		result = append(result, sym)
+	case *parser.TypeAliasStatement:
+		result = append(result, symbol(doc, node.Name, SymbolTypeParameter, nil, start, end))
+	case *parser.NamespaceDeclaration:
+		sym := symbol(doc, node.Name, SymbolNamespace, nil, start, end)
+		if node.Body != nil {
+			sym.Children = symbols(doc, node.Body.Statements, end)
+		}
+		result = append(result, sym)
+	}
+	return result
+}
+
+// classMembers returns the symbols of the methods and properties of a class
+func classMembers(doc *document, body *parser.ClassBody) []DocumentSymbol {
+	if body == nil {
+		return nil
+	}
+	var members []DocumentSymbol
+	for _, method := range body.Methods {
+		ident, ok := method.Key.(*parser.Identifier)
+		if !ok {
+			continue
+		}
+		kind := SymbolMethod
+		if method.Kind == "constructor" {
+			kind = SymbolConstructor
+		}
+		var t types.Type
+		if method.Value != nil {
+			t = method.Value.GetComputedType()
+		}
+		members = append(members, symbol(doc, ident, kind, t, method.Token.StartPos, ident.Token.EndPos))
+	}
+	for _, prop := range body.Properties {
+		if ident, ok := prop.Key.(*parser.Identifier); ok {
+			members = append(members, symbol(doc, ident, SymbolProperty, nil, prop.Token.StartPos, ident.Token.EndPos))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i].SelectionRange.Start, members[j].SelectionRange.Start
+		return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+	})
+	return members
+}
+
+// symbol returns the symbol of a declaration spanning start to end, widened
+// to cover its name
+func symbol(doc *document, name *parser.Identifier, kind int, t types.Type, start, end int) DocumentSymbol {
+	if start < 0 || start > name.Token.StartPos {
+		start = name.Token.StartPos
+	}
+	end = max(end, name.Token.EndPos)
+	sym := DocumentSymbol{
+		Name:           name.Value,
+		Kind:           kind,
+		Range:          doc.span(start, end),
+		SelectionRange: doc.span(name.Token.StartPos, name.Token.EndPos),
+	}
+	if t != nil {
+		sym.Detail = t.String()
+	}
+	return sym
+}
+
+// nodeStart returns the byte offset a statement starts at, or -1 if unknown.
+// Expressions start with their leftmost operand.
+func nodeStart(node parser.Node) int {
+	var token *lexer.Token
+	switch node := node.(type) {
+	case *parser.ExportNamedDeclaration:
+		token = node.Token
+	case *parser.ExportDefaultDeclaration:
+		token = node.Token
+	case *parser.ExportAllDeclaration:
+		token = node.Token
+	case *parser.ImportDeclaration:
+		token = node.Token
+	case *parser.ClassDeclaration:
+		token = node.Token
+	case *parser.NamespaceDeclaration:
+		token = node.Token
+	case *parser.ExpressionStatement:
+		return nodeStart(node.Expression)
+	case *parser.CallExpression:
+		return nodeStart(node.Function)
+	case *parser.MemberExpression:
+		return nodeStart(node.Object)
+	case *parser.IndexExpression:
+		return nodeStart(node.Left)
+	case *parser.InfixExpression:
+		return nodeStart(node.Left)
+	case *parser.AssignmentExpression:
+		return nodeStart(node.Left)
+	case *parser.TernaryExpression:
+		return nodeStart(node.Condition)
+	default:
+		token = parser.GetTokenFromNode(node)
+	}
+	if token == nil || token.EndPos == 0 { // Nodes without a token get a zero one
+		return -1
+	}
+	return token.StartPos
+}
@@ -0,0 +1,309 @@
+// Package lsp implements a Language Server Protocol server for Paserati, so
+// that editors get diagnostics, hover types, navigation and completion while
+// editing. Documents are type checked by the driver as they change; see
+// driver.Analyze.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+
+	"github.com/nooga/paserati/pkg/jsonrpc"
+)
+
+// Message is a JSON-RPC message as read from the wire: a request, a
+// notification or a response. Requests have an ID and a Method,
+// notifications only a Method and responses only an ID.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+
+	// Responses
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// ResponseError is the error of a failed request
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// Error codes
+const (
+	CodeInvalidRequest       = -32600
+	CodeMethodNotFound       = -32601
+	CodeInvalidParams        = -32602
+	CodeInternalError        = -32603
+	CodeServerNotInitialized = -32002
+)
+
+// request is an outgoing request, as sent by clients
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// response is an outgoing successful response. Result is always present on
+// the wire, null for requests without one.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// errorResponse is an outgoing failed response
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *ResponseError  `json:"error"`
+}
+
+// notification is an outgoing notification
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// ReadMessage reads one message, framed by a Content-Length header
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	var msg Message
+	if err := jsonrpc.ReadMessage(r, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Position is a position in a document: a 0-based line, and a 0-based
+// character offset in UTF-16 code units within the line
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document, its End exclusive
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a span of a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// InitializeParams are the params of the initialize request
+type InitializeParams struct {
+	ProcessID        *int              `json:"processId"`
+	RootURI          string            `json:"rootUri,omitempty"`
+	RootPath         string            `json:"rootPath,omitempty"`
+	WorkspaceFolders []WorkspaceFolder `json:"workspaceFolders,omitempty"`
+}
+
+// WorkspaceFolder is a folder open in the editor
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// InitializeResult is the result of the initialize request
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+// ServerInfo identifies the server
+type ServerInfo struct {
+	Name string `json:"name"`
+}
+
+// ServerCapabilities are the protocol features the server supports
+type ServerCapabilities struct {
+	TextDocumentSync       TextDocumentSyncOptions `json:"textDocumentSync"`
+	HoverProvider          bool                    `json:"hoverProvider"`
+	DefinitionProvider     bool                    `json:"definitionProvider"`
+	ReferencesProvider     bool                    `json:"referencesProvider"`
+	DocumentSymbolProvider bool                    `json:"documentSymbolProvider"`
+	CompletionProvider     *CompletionOptions      `json:"completionProvider,omitempty"`
+}
+
+// Text document sync kinds: how clients send changes to documents
+const (
+	SyncFull        = 1
+	SyncIncremental = 2
+)
+
+// TextDocumentSyncOptions say which document notifications the server wants
+type TextDocumentSyncOptions struct {
+	OpenClose bool `json:"openClose"`
+	Change    int  `json:"change"`
+}
+
+// CompletionOptions configure completion
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+// TextDocumentItem is a document opened by the client
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentIdentifier identifies a document
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a version of a document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// DidOpenTextDocumentParams are the params of textDocument/didOpen
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// TextDocumentContentChangeEvent is a change to a document: Text replaces
+// Range, or the whole document without one
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// DidChangeTextDocumentParams are the params of textDocument/didChange
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidCloseTextDocumentParams are the params of textDocument/didClose
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// FileEvent is a change to a file on disk
+type FileEvent struct {
+	URI  string `json:"uri"`
+	Type int    `json:"type"` // 1 created, 2 changed, 3 deleted
+}
+
+// DidChangeWatchedFilesParams are the params of
+// workspace/didChangeWatchedFiles
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+// SeverityError is the severity of errors
+const SeverityError = 1
+
+// Diagnostic is a problem found in a document
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of
+// textDocument/publishDiagnostics
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// TextDocumentPositionParams are the params of requests about a position:
+// hover, definition and completion
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// ReferenceParams are the params of textDocument/references
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
+}
+
+// ReferenceContext says whether references include the declaration
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+// DocumentSymbolParams are the params of textDocument/documentSymbol
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// MarkupContent is formatted text
+type MarkupContent struct {
+	Kind  string `json:"kind"` // "plaintext" or "markdown"
+	Value string `json:"value"`
+}
+
+// Hover is the result of textDocument/hover
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// Symbol kinds
+const (
+	SymbolNamespace     = 3
+	SymbolClass         = 5
+	SymbolMethod        = 6
+	SymbolProperty      = 7
+	SymbolConstructor   = 9
+	SymbolEnum          = 10
+	SymbolInterface     = 11
+	SymbolFunction      = 12
+	SymbolVariable      = 13
+	SymbolConstant      = 14
+	SymbolEnumMember    = 22
+	SymbolTypeParameter = 26
+)
+
+// DocumentSymbol is a declaration of a document, with the declarations
+// nested in it. SelectionRange is its name and Range all of it.
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// Completion item kinds
+const (
+	CompletionMethod   = 2
+	CompletionProperty = 10
+)
+
+// CompletionList is the result of textDocument/completion
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// CompletionItem is a completion proposal
+type CompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
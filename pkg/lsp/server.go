@@ -0,0 +1,382 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nooga/paserati/pkg/driver"
+	"github.com/nooga/paserati/pkg/jsonrpc"
+	"github.com/nooga/paserati/pkg/parser"
+)
+
+// Server serves one client over a connection. The documents the client
+// opens override their files, so modules importing them see what is being
+// edited. Whenever a document changes, it and the open documents importing
+// it, directly or not, are checked again and their diagnostics published;
+// modules that didn't change stay cached in the session.
+type Server struct {
+	// NewSession creates the session documents are checked in, given the
+	// workspace root. It defaults to newSession.
+	NewSession func(root string) *driver.Paserati
+
+	r  *bufio.Reader
+	w  io.Writer
+	mu sync.Mutex // Serializes writes
+
+	root     string
+	p        *driver.Paserati
+	docs     map[string]*document // Open documents by URI
+	shutdown bool
+}
+
+// NewServer returns a server that talks to the client over rw
+func NewServer(rw io.ReadWriter) *Server {
+	return &Server{
+		NewSession: newSession,
+		r:          bufio.NewReader(rw),
+		w:          rw,
+		docs:       make(map[string]*document),
+	}
+}
+
+// newSession opens a session in the workspace root with the options of the
+// project's tsconfig.json, like the one "paserati check" would use
+func newSession(root string) *driver.Paserati {
+	p := driver.NewPaseratiWithBaseDir(root)
+	if path, err := p.LoadProjectConfig(root); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", path, err)
+	}
+	return p
+}
+
+// Serve handles messages until the client exits or the connection closes
+func (s *Server) Serve() error {
+	defer func() {
+		if s.p != nil {
+			s.p.Cleanup()
+		}
+	}()
+	for {
+		msg, err := ReadMessage(s.r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "" {
+			continue // A response; the server sends no requests
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		s.handle(msg)
+	}
+}
+
+// handle dispatches a request or a notification. Notifications that fail
+// have no one to tell and are dropped.
+func (s *Server) handle(msg *Message) {
+	isRequest := len(msg.ID) > 0
+	if s.p == nil && msg.Method != "initialize" {
+		if isRequest {
+			s.respond(msg, nil, &ResponseError{Code: CodeServerNotInitialized, Message: "server not initialized"})
+		}
+		return
+	}
+	if s.shutdown && isRequest {
+		s.respond(msg, nil, &ResponseError{Code: CodeInvalidRequest, Message: "server is shut down"})
+		return
+	}
+
+	var result any
+	var err error
+	switch msg.Method {
+	case "initialize":
+		result, err = s.initialize(msg)
+	case "initialized":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		err = s.didOpen(msg)
+	case "textDocument/didChange":
+		err = s.didChange(msg)
+	case "textDocument/didClose":
+		err = s.didClose(msg)
+	case "textDocument/didSave":
+		// Documents are checked as they change, saved or not
+	case "workspace/didChangeWatchedFiles":
+		err = s.didChangeWatchedFiles(msg)
+	case "textDocument/hover":
+		result, err = s.hover(msg)
+	case "textDocument/definition":
+		result, err = s.definition(msg)
+	case "textDocument/references":
+		result, err = s.references(msg)
+	case "textDocument/documentSymbol":
+		result, err = s.documentSymbol(msg)
+	case "textDocument/completion":
+		result, err = s.completion(msg)
+	default:
+		err = &ResponseError{Code: CodeMethodNotFound, Message: fmt.Sprintf("unsupported method %s", msg.Method)}
+	}
+	if isRequest {
+		s.respond(msg, result, err)
+	}
+}
+
+func (s *Server) initialize(msg *Message) (any, error) {
+	if s.p != nil {
+		return nil, &ResponseError{Code: CodeInvalidRequest, Message: "server already initialized"}
+	}
+	var params InitializeParams
+	if err := decodeParams(msg, &params); err != nil {
+		return nil, err
+	}
+	root := params.RootPath
+	if len(params.WorkspaceFolders) > 0 {
+		root = uriToPath(params.WorkspaceFolders[0].URI)
+	}
+	if params.RootURI != "" {
+		root = uriToPath(params.RootURI)
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	s.root = root
+	s.p = s.NewSession(root)
+
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:       TextDocumentSyncOptions{OpenClose: true, Change: SyncIncremental},
+			HoverProvider:          true,
+			DefinitionProvider:     true,
+			ReferencesProvider:     true,
+			DocumentSymbolProvider: true,
+			CompletionProvider:     &CompletionOptions{TriggerCharacters: []string{"."}},
+		},
+		ServerInfo: &ServerInfo{Name: "paserati"},
+	}, nil
+}
+
+func (s *Server) didOpen(msg *Message) error {
+	var params DidOpenTextDocumentParams
+	if err := decodeParams(msg, &params); err != nil {
+		return err
+	}
+	item := params.TextDocument
+	doc := newDocument(item.URI, s.modulePath(item.URI), item.Text, item.Version)
+	s.docs[item.URI] = doc
+	s.edited(doc)
+	return nil
+}
+
+func (s *Server) didChange(msg *Message) error {
+	var params DidChangeTextDocumentParams
+	if err := decodeParams(msg, &params); err != nil {
+		return err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	for _, change := range params.ContentChanges {
+		doc.applyChange(change)
+	}
+	doc.version = params.TextDocument.Version
+	s.edited(doc)
+	return nil
+}
+
+func (s *Server) didClose(msg *Message) error {
+	var params DidCloseTextDocumentParams
+	if err := decodeParams(msg, &params); err != nil {
+		return err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	delete(s.docs, doc.uri)
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: doc.uri, Diagnostics: []Diagnostic{}})
+	if !filepath.IsAbs(doc.path) {
+		s.checkImporters(doc.path, s.p.RestoreModule(doc.path))
+	}
+	return nil
+}
+
+// didChangeWatchedFiles drops modules whose files changed on disk. Open
+// documents keep overriding their files.
+func (s *Server) didChangeWatchedFiles(msg *Message) error {
+	var params DidChangeWatchedFilesParams
+	if err := decodeParams(msg, &params); err != nil {
+		return err
+	}
+	for _, change := range params.Changes {
+		if _, open := s.docs[change.URI]; open {
+			continue
+		}
+		path := s.modulePath(change.URI)
+		s.checkImporters(path, s.p.InvalidateModule(path))
+	}
+	return nil
+}
+
+// edited checks doc, whose text changed, and the open documents importing it
+func (s *Server) edited(doc *document) {
+	var dropped []string
+	if !filepath.IsAbs(doc.path) {
+		dropped = s.p.OverrideModule(doc.path, doc.text)
+	}
+	s.check(doc)
+	s.checkImporters(doc.path, dropped)
+}
+
+// checkImporters checks the open documents importing the module at path or
+// one of the modules dropped from the session's cache
+func (s *Server) checkImporters(path string, dropped []string) {
+	stale := map[string]bool{"./" + path: true, "./" + strings.TrimSuffix(path, pathExt(path)): true}
+	for _, specifier := range dropped {
+		stale[specifier] = true
+	}
+	for _, doc := range s.openDocuments() {
+		if doc.path == path || doc.analysis == nil {
+			continue
+		}
+		for _, specifier := range importSpecifiers(doc.analysis.Program) {
+			if stale[specifier] {
+				s.check(doc)
+				break
+			}
+		}
+	}
+}
+
+// check analyzes doc and publishes its diagnostics
+func (s *Server) check(doc *document) {
+	doc.analysis = s.p.Analyze(doc.path, doc.text)
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+// diagnostics converts the errors found in doc itself to diagnostics
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, err := range doc.analysis.Errors {
+		pos := err.Pos()
+		if pos.Source != nil && pos.Source != doc.analysis.Source && pos.Source.Path != doc.analysis.Source.Path {
+			continue
+		}
+		start := doc.lineColumn(pos.Line, pos.Column)
+		end := start + max(pos.EndPos-pos.StartPos, 0)
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    doc.span(start, end),
+			Severity: SeverityError,
+			Source:   "paserati",
+			Message:  err.Message(),
+		})
+	}
+	return diagnostics
+}
+
+// document returns an open document
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("document %s is not open", uri)}
+	}
+	return doc, nil
+}
+
+// openDocuments returns the open documents in a stable order
+func (s *Server) openDocuments() []*document {
+	docs := make([]*document, 0, len(s.docs))
+	for _, doc := range s.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].uri < docs[j].uri })
+	return docs
+}
+
+// modulePath returns the module path of a document, relative to the root
+// unless it is outside the root
+func (s *Server) modulePath(uri string) string {
+	path := uriToPath(uri)
+	if rel, err := filepath.Rel(s.root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// importSpecifiers returns the canonical specifiers program imports and
+// re-exports from
+func importSpecifiers(program *parser.Program) []string {
+	var specifiers []string
+	for _, stmt := range program.Statements {
+		switch node := stmt.(type) {
+		case *parser.ImportDeclaration:
+			specifiers = append(specifiers, node.Source.Value)
+		case *parser.ExportNamedDeclaration:
+			if node.Source != nil {
+				specifiers = append(specifiers, node.Source.Value)
+			}
+		case *parser.ExportAllDeclaration:
+			specifiers = append(specifiers, node.Source.Value)
+		}
+	}
+	return specifiers
+}
+
+// pathExt returns the extension of a module path, such as ".ts"
+func pathExt(path string) string {
+	if strings.HasSuffix(path, ".d.ts") {
+		return ".d.ts"
+	}
+	return filepath.Ext(path)
+}
+
+func (s *Server) respond(msg *Message, result any, err error) {
+	if err == nil {
+		s.send(response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		return
+	}
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		respErr = &ResponseError{Code: CodeInternalError, Message: err.Error()}
+	}
+	s.send(errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: respErr})
+}
+
+func (s *Server) notify(method string, params any) {
+	s.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) send(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jsonrpc.WriteMessage(s.w, msg)
+}
+
+// decodeParams decodes the params of a message into params
+func decodeParams(msg *Message, params any) error {
+	if len(msg.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("%s: bad params: %s", msg.Method, err)}
+	}
+	return nil
+}
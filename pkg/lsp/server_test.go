@@ -0,0 +1,301 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nooga/paserati/pkg/jsonrpc"
+)
+
+const testShapes = `export interface Point { x: number; y: number; }
+export function origin(): Point { return { x: 0, y: 0 }; }
+export const label = "shapes";
+`
+
+const testMain = `import { origin, label, type Point } from "./lib/shapes.ts";
+const p: Point = origin();
+const n: number = p.x;
+console.log(n, p.y, label);
+`
+
+// testClient is a scripted LSP client talking to a Server over a pipe
+type testClient struct {
+	t             *testing.T
+	conn          net.Conn
+	id            int
+	messages      chan *Message
+	notifications []*Message // Notifications received while waiting for a response
+	served        chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	c := &testClient{t: t, conn: clientConn, messages: make(chan *Message, 64), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewServer(serverConn).Serve()
+		serverConn.Close()
+	}()
+	go func() {
+		r := bufio.NewReader(clientConn)
+		for {
+			msg, err := ReadMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { clientConn.Close() })
+	return c
+}
+
+// next returns the next message from the server
+func (c *testClient) next() *Message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatalf("connection closed")
+		}
+		return msg
+	case <-time.After(10 * time.Second):
+		c.t.Fatalf("timed out waiting for the server")
+	}
+	return nil
+}
+
+// request sends a request and decodes the result of its response
+func (c *testClient) request(method string, params any, result any) {
+	c.t.Helper()
+	c.id++
+	if err := jsonrpc.WriteMessage(c.conn, request{JSONRPC: "2.0", ID: c.id, Method: method, Params: params}); err != nil {
+		c.t.Fatalf("sending %s: %v", method, err)
+	}
+	for {
+		msg := c.next()
+		if msg.Method != "" {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if string(msg.ID) != strconv.Itoa(c.id) {
+			c.t.Fatalf("expected the response to %s, got %+v", method, msg)
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s failed: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("decoding %s result: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+// notify sends a notification
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+	if err := jsonrpc.WriteMessage(c.conn, notification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		c.t.Fatalf("sending %s: %v", method, err)
+	}
+}
+
+// diagnostics waits for the next diagnostics published for uri
+func (c *testClient) diagnostics(uri string) []Diagnostic {
+	c.t.Helper()
+	for {
+		var msg *Message
+		if len(c.notifications) > 0 {
+			msg, c.notifications = c.notifications[0], c.notifications[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatalf("decoding diagnostics: %v", err)
+		}
+		if params.URI == uri {
+			return params.Diagnostics
+		}
+	}
+}
+
+// at returns the params of a request about the position of the nth
+// occurrence of marker in text, offset by delta characters
+func at(uri, text, marker string, nth, delta int) TextDocumentPositionParams {
+	offset := -1
+	for i := 0; i < nth; i++ {
+		offset += 1 + strings.Index(text[offset+1:], marker)
+	}
+	doc := newDocument(uri, "", text, 0)
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: doc.position(offset + delta)}
+}
+
+func TestLanguageServer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	shapesPath := filepath.Join(dir, "lib", "shapes.ts")
+	if err := os.WriteFile(shapesPath, []byte(testShapes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mainURI, shapesURI := pathToURI(filepath.Join(dir, "main.ts")), pathToURI(shapesPath)
+
+	c := newTestClient(t)
+	var initialized InitializeResult
+	c.request("initialize", InitializeParams{RootURI: pathToURI(dir)}, &initialized)
+	if initialized.Capabilities.TextDocumentSync.Change != SyncIncremental || !initialized.Capabilities.HoverProvider {
+		t.Errorf("unexpected capabilities %+v", initialized.Capabilities)
+	}
+	c.notify("initialized", struct{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: mainURI, LanguageID: "typescript", Version: 1, Text: testMain}})
+	if diagnostics := c.diagnostics(mainURI); len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %+v", diagnostics)
+	}
+
+	// Hover
+	for _, tc := range []struct {
+		params   TextDocumentPositionParams
+		expected string
+	}{
+		{at(mainURI, testMain, "n:", 1, 0), "n: number"},
+		{at(mainURI, testMain, "p.x", 1, 2), "(property) x: number"},
+		{at(mainURI, testMain, "origin", 2, 3), "origin: () =>"},
+	} {
+		var hover Hover
+		c.request("textDocument/hover", tc.params, &hover)
+		if !strings.Contains(hover.Contents.Value, tc.expected) {
+			t.Errorf("expected hover %q, got %q", tc.expected, hover.Contents.Value)
+		}
+	}
+
+	// Definition follows imports into the module exporting the name
+	var locations []Location
+	c.request("textDocument/definition", at(mainURI, testMain, "origin", 2, 0), &locations)
+	expected := Location{URI: shapesURI, Range: Range{Start: Position{Line: 1, Character: 16}, End: Position{Line: 1, Character: 22}}}
+	if len(locations) != 1 || locations[0] != expected {
+		t.Errorf("expected the definition %+v, got %+v", expected, locations)
+	}
+
+	// References
+	c.request("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: at(mainURI, testMain, "p:", 1, 0),
+		Context:                    ReferenceContext{IncludeDeclaration: true},
+	}, &locations)
+	if len(locations) != 3 || locations[0].Range.Start != (Position{Line: 1, Character: 6}) {
+		t.Errorf("expected the declaration and 2 references of p, got %+v", locations)
+	}
+
+	// Document symbols
+	var symbols []DocumentSymbol
+	c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: mainURI}}, &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "p" || symbols[1].Name != "n" {
+		t.Fatalf("expected constants p and n, got %+v", symbols)
+	}
+	if n := symbols[1]; n.Kind != SymbolConstant || n.Detail != "number" || n.Range.End != (Position{Line: 3, Character: 0}) {
+		t.Errorf("expected n to be a number constant spanning its statement, got %+v", n)
+	}
+
+	// Completion of a member being typed, sent as an incremental change
+	end := Position{Line: 4, Character: 0}
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: mainURI, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Range: &Range{Start: end, End: end}, Text: "label.to"}},
+	})
+	if diagnostics := c.diagnostics(mainURI); len(diagnostics) == 0 {
+		t.Errorf("expected diagnostics for the unknown member")
+	}
+	var completions CompletionList
+	c.request("textDocument/completion", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: mainURI}, Position: Position{Line: 4, Character: 8}}, &completions)
+	labels := make(map[string]CompletionItem)
+	for _, item := range completions.Items {
+		labels[item.Label] = item
+	}
+	if labels["toUpperCase"].Kind != CompletionMethod || labels["length"].Kind != CompletionProperty {
+		t.Errorf("expected string members, got %+v", completions.Items)
+	}
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: mainURI, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: testMain + "p."}},
+	})
+	c.diagnostics(mainURI)
+	c.request("textDocument/completion", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: mainURI}, Position: Position{Line: 4, Character: 2}}, &completions)
+	if len(completions.Items) != 2 || completions.Items[0].Label != "x" || completions.Items[1].Label != "y" {
+		t.Errorf("expected the members of Point, got %+v", completions.Items)
+	}
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: mainURI, Version: 4},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: testMain}},
+	})
+	if diagnostics := c.diagnostics(mainURI); len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %+v", diagnostics)
+	}
+
+	// Editing an imported module checks its importers again
+	edited := strings.Replace(testShapes, "x: number", "x: string", 1)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: shapesURI, LanguageID: "typescript", Version: 1, Text: edited}})
+	if diagnostics := c.diagnostics(shapesURI); len(diagnostics) == 0 || !strings.Contains(diagnostics[0].Message, "string") {
+		t.Errorf("expected the edited module's error, got %+v", diagnostics)
+	}
+	diagnostics := c.diagnostics(mainURI)
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "variable 'n'") || diagnostics[0].Range.Start.Line != 2 {
+		t.Errorf("expected main.ts to be checked against the edited module, got %+v", diagnostics)
+	}
+
+	// References across the open modules
+	c.request("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: at(shapesURI, edited, "origin", 1, 0),
+		Context:                    ReferenceContext{IncludeDeclaration: false},
+	}, &locations)
+	if len(locations) != 2 || locations[0].URI != mainURI || locations[1].URI != mainURI {
+		t.Errorf("expected the import and call of origin in main.ts, got %+v", locations)
+	}
+
+	// Closing it restores the file
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: shapesURI}})
+	if diagnostics := c.diagnostics(shapesURI); len(diagnostics) != 0 {
+		t.Errorf("expected the closed module's diagnostics to be cleared, got %+v", diagnostics)
+	}
+	if diagnostics := c.diagnostics(mainURI); len(diagnostics) != 0 {
+		t.Errorf("expected main.ts to be checked against the file again, got %+v", diagnostics)
+	}
+
+	c.request("shutdown", nil, nil)
+	c.notify("exit", nil)
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the server didn't exit")
+	}
+}
+
+func TestDocumentPositions(t *testing.T) {
+	doc := newDocument("file:///x.ts", "x.ts", "const é = \"ü\";\nlet 𝒳 = 1;\n", 1)
+	// Errors count columns in bytes, positions in UTF-16 code units
+	if pos := doc.position(doc.lineColumn(1, 12)); pos != (Position{Line: 0, Character: 10}) {
+		t.Errorf("expected the string literal at 0:10, got %+v", pos)
+	}
+	if pos := doc.position(doc.lineColumn(2, 12)); pos != (Position{Line: 1, Character: 9}) {
+		t.Errorf("expected the number at 1:9, got %+v", pos)
+	}
+	if offset := doc.lineColumn(1, 100); offset != strings.Index(doc.text, "\n") {
+		t.Errorf("expected columns past the end of a line to be clamped to it, got %d", offset)
+	}
+}
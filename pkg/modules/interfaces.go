@@ -39,9 +39,20 @@ type ModuleResolver interface {
 	Priority() int
 }
 
+// TypesResolver is implemented by resolvers that can find the declaration
+// file typing a JavaScript module, such as the @types package of an npm
+// package
+type TypesResolver interface {
+	// ResolveTypes returns the path of the declaration file for specifier
+	ResolveTypes(specifier string, fromPath string) (string, error)
+}
+
 // ModuleLoader is the main interface for loading modules
 type ModuleLoader interface {
-	// LoadModule loads a module and all its dependencies
+	// LoadModule loads a module and all its dependencies. Relative
+	// specifiers are relative to the root, as canonical ones are (see
+	// CanonicalSpecifier); fromPath is the importing module, from which bare
+	// specifiers are looked up.
 	LoadModule(specifier string, fromPath string) (vm.ModuleRecord, error)
 
 	// LoadModuleParallel loads a module using parallel processing
@@ -65,6 +76,15 @@ type ModuleLoader interface {
 	// ClearCache clears the module cache
 	ClearCache()
 
+	// Invalidate drops a module and the modules importing it from the
+	// cache, returning their specifiers
+	Invalidate(path string) []string
+
+	// Reload loads a module again in place of the cached versions, keeping
+	// the modules importing it, unless the new version fails to load or
+	// check. Returns the versions replaced and the new one.
+	Reload(path string) ([]*ModuleRecord, *ModuleRecord, error)
+
 	// GetStats returns loader statistics
 	GetStats() LoaderStats
 
@@ -92,6 +112,10 @@ type ModuleRegistry interface {
 	// Remove removes a module from the cache
 	Remove(specifier string)
 
+	// GetDependents returns the specifiers of the cached modules importing
+	// the module specifier names
+	GetDependents(specifier string) []string
+
 	// Clear clears all cached modules
 	Clear()
 
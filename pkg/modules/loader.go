@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
+	pathpkg "path"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/nooga/paserati/pkg/errors"
 	"github.com/nooga/paserati/pkg/lexer"
 	"github.com/nooga/paserati/pkg/parser"
 	"github.com/nooga/paserati/pkg/source"
@@ -111,7 +115,7 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 	}
 
 	// Resolve the module
-	resolved, err := ml.resolveModule(specifier, fromPath)
+	resolved, err := ml.resolveModule(specifier, resolveFrom(specifier, fromPath))
 	if err != nil {
 		return nil, err
 	}
@@ -137,10 +141,22 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 		return record, nil // Return record with error, don't fail completely
 	}
 
+	// JavaScript is typed by its declaration file, when it has one
+	if !record.precompiled && !record.isNative && ml.checkerFactory != nil && !ml.config.SkipTypeCheck {
+		record.Typings = ml.findTypings(specifier, fromPath, resolved)
+	}
+
 	// Extract and load dependencies before type checking
 	debugPrintf("// [ModuleLoader] About to extract imports for: %s (AST=%v)\n", specifier, record.AST != nil)
 	importSpecs := extractImportSpecs(record.AST)
+	if record.precompiled {
+		importSpecs = record.compiledImports
+	}
 	debugPrintf("// [ModuleLoader] Found %d import specs in %s\n", len(importSpecs), record.ResolvedPath)
+	record.Dependencies = make([]string, len(importSpecs))
+	for i, importSpec := range importSpecs {
+		record.Dependencies[i] = importSpec.ModulePath
+	}
 
 	// Load dependencies recursively
 	for _, importSpec := range importSpecs {
@@ -172,10 +188,17 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 
 	debugPrintf("// [ModuleLoader] Finished loading dependencies for: %s\n", specifier)
 
+	// Precompiled modules are ready to run once their dependencies are loaded
+	if record.precompiled {
+		record.State = ModuleCompiled
+		record.CompleteTime = time.Now()
+		return record, nil
+	}
+
 	// Add type checking and compilation to sequential loading
 	debugPrintf("// [ModuleLoader] Sequential loading checkerFactory: %v, compilerFactory: %v\n",
 		ml.checkerFactory != nil, ml.compilerFactory != nil)
-	if ml.checkerFactory != nil && !ml.config.SkipTypeCheck {
+	if ml.checkerFactory != nil && !ml.config.SkipTypeCheck && record.Typings == "" {
 		// Type check the module
 		record.State = ModuleChecking
 		record.CheckTime = time.Now()
@@ -204,6 +227,7 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 		// but store the error for later reporting
 		if len(checkErrors) > 0 && !ml.config.IgnoreTypeErrors {
 			record.Error = fmt.Errorf("type checking failed: %s", checkErrors[0].Error())
+			record.Diagnostics = checkErrors
 			record.State = ModuleError
 			debugPrintf("// [ModuleLoader] Type check error in %s (exports still extracted): %s\n", specifier, checkErrors[0].Error())
 			return record, nil
@@ -219,10 +243,11 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 			moduleCompiler.EnableModuleMode(record.ResolvedPath, ml)
 			moduleCompiler.SetChecker(moduleChecker)
 
-			chunk, compileErrors := moduleCompiler.Compile(record.AST)
+			chunk, compileErrors := moduleCompiler.Compile(compiledProgram(record))
 			if len(compileErrors) > 0 {
 				debugPrintf("// [ModuleLoader] Compilation error: %s\n", compileErrors[0].Error())
 				record.Error = fmt.Errorf("compilation failed: %s", compileErrors[0].Error())
+				record.Diagnostics = compileErrors
 				record.State = ModuleError
 				return record, nil
 			}
@@ -254,8 +279,12 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 			debugPrintf("// [ModuleLoader] Stored %d export indices for module: %s\n", len(exportIndices), record.ResolvedPath)
 		}
 		record.State = ModuleCompiled
-	} else if ml.config.SkipTypeCheck && ml.compilerFactory != nil {
-		// Skip type checking but still compile (pure JS mode)
+	} else if (ml.config.SkipTypeCheck || record.Typings != "") && ml.compilerFactory != nil {
+		// Skip type checking but still compile (pure JS mode, or JavaScript
+		// whose types come from its declaration file)
+		if record.Typings != "" {
+			ml.loadTypings(record)
+		}
 		debugPrintf("// [ModuleLoader] Skipping type check, starting compilation for module: %s\n", record.ResolvedPath)
 		record.State = ModuleCompiling
 		record.CompileTime = time.Now()
@@ -264,10 +293,11 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 		moduleCompiler.EnableModuleMode(record.ResolvedPath, ml)
 		moduleCompiler.SetSkipTypeCheck(true)
 
-		chunk, compileErrors := moduleCompiler.Compile(record.AST)
+		chunk, compileErrors := moduleCompiler.Compile(compiledProgram(record))
 		if len(compileErrors) > 0 {
 			debugPrintf("// [ModuleLoader] Compilation error: %s\n", compileErrors[0].Error())
 			record.Error = fmt.Errorf("compilation failed: %s", compileErrors[0].Error())
+			record.Diagnostics = compileErrors
 			record.State = ModuleError
 			return record, nil
 		}
@@ -307,6 +337,68 @@ func (ml *moduleLoader) loadModuleSequential(specifier string, fromPath string)
 	return record, nil
 }
 
+// compiledProgram returns the program a module compiles to. Declaration
+// files only have types, so they compile to a module without code.
+func compiledProgram(record *ModuleRecord) *parser.Program {
+	if parser.IsDeclarationFile(record.ResolvedPath) {
+		return &parser.Program{Source: record.AST.Source}
+	}
+	return record.AST
+}
+
+// declarationExtensions maps JavaScript extensions to the extensions of the
+// declaration files typing them
+var declarationExtensions = map[string]string{".js": ".d.ts", ".jsx": ".d.ts", ".mjs": ".d.mts", ".cjs": ".d.cts"}
+
+// findTypings returns the specifier of the declaration file typing the
+// JavaScript module specifier resolved to: the declaration file next to it
+// or, for a package, the one its package.json or @types package names.
+// It returns "" for TypeScript and for JavaScript without typings.
+func (ml *moduleLoader) findTypings(specifier string, fromPath string, resolved *ResolvedModule) string {
+	ext := pathpkg.Ext(resolved.ResolvedPath)
+	declarationExt, ok := declarationExtensions[ext]
+	if !ok {
+		return ""
+	}
+	sibling := strings.TrimSuffix(resolved.ResolvedPath, ext) + declarationExt
+	if resolved.FS != nil {
+		if info, err := fs.Stat(resolved.FS, sibling); err == nil && !info.IsDir() {
+			return pathSpecifier(sibling)
+		}
+	}
+	for _, resolver := range ml.resolvers {
+		typesResolver, ok := resolver.(TypesResolver)
+		if !ok || !resolver.CanResolve(specifier) {
+			continue
+		}
+		if typings, err := typesResolver.ResolveTypes(specifier, resolveFrom(specifier, fromPath)); err == nil {
+			return pathSpecifier(typings)
+		}
+	}
+	return ""
+}
+
+// pathSpecifier returns the canonical specifier of the file at path
+func pathSpecifier(path string) string {
+	if pathpkg.IsAbs(path) {
+		return path
+	}
+	return CanonicalSpecifier("./"+path, "")
+}
+
+// loadTypings loads the declaration file typing a JavaScript module and
+// takes the module's exported types from it
+func (ml *moduleLoader) loadTypings(record *ModuleRecord) {
+	loaded, err := ml.loadModuleSequential(record.Typings, "")
+	if err != nil {
+		debugPrintf("// [ModuleLoader] Failed to load typings %s of %s: %v\n", record.Typings, record.ResolvedPath, err)
+		return
+	}
+	if typings, ok := loaded.(*ModuleRecord); ok && typings.Exports != nil {
+		record.Exports = typings.Exports
+	}
+}
+
 // parseModuleSequential parses a single module synchronously
 // loadJSONModule loads a JSON file as a module with a default export
 func (ml *moduleLoader) loadJSONModule(specifier string, fromPath string) (*ModuleRecord, error) {
@@ -320,7 +412,7 @@ func (ml *moduleLoader) loadJSONModule(specifier string, fromPath string) (*Modu
 	}
 
 	// Resolve the module
-	resolved, err := ml.resolveModule(specifier, fromPath)
+	resolved, err := ml.resolveModule(specifier, resolveFrom(specifier, fromPath))
 	if err != nil {
 		return nil, err
 	}
@@ -380,6 +472,13 @@ func (ml *moduleLoader) parseModuleSequential(record *ModuleRecord, resolved *Re
 		return ml.handleNativeModuleSource(record, nativeModule)
 	}
 
+	// Precompiled modules carry their bytecode instead of source
+	if compiled, ok := resolved.Source.(CompiledModuleSource); ok {
+		debugPrintf("// [ModuleLoader] Detected precompiled module: %s\n", record.ResolvedPath)
+		defer compiled.Close()
+		return ml.handleCompiledModuleSource(record, compiled)
+	}
+
 	// Read the source content
 	defer resolved.Source.Close()
 
@@ -411,10 +510,12 @@ func (ml *moduleLoader) parseModuleSequential(record *ModuleRecord, resolved *Re
 
 	program, parseErrs := parserInstance.ParseProgram()
 	if len(parseErrs) > 0 {
+		record.Diagnostics = parseErrs
 		return fmt.Errorf("parsing failed: %s", parseErrs[0].Error())
 	}
 
 	// Store the parsed AST and source
+	CanonicalizeImports(program, record.ResolvedPath)
 	record.AST = program
 	record.Source = sourceFile
 
@@ -514,6 +615,7 @@ func (ml *moduleLoader) loadModuleParallelImpl(specifier string, fromPath string
 // resolveModule resolves a module specifier using the resolver chain
 func (ml *moduleLoader) resolveModule(specifier string, fromPath string) (*ResolvedModule, error) {
 	debugPrintf("// [ModuleLoader] resolveModule: %s from %s\n", specifier, fromPath)
+	var lastErr error
 	for _, resolver := range ml.resolvers {
 		if resolver.CanResolve(specifier) {
 			debugPrintf("// [ModuleLoader] Trying resolver: %T\n", resolver)
@@ -524,9 +626,15 @@ func (ml *moduleLoader) resolveModule(specifier string, fromPath string) (*Resol
 			}
 			debugPrintf("// [ModuleLoader] Resolver failed: %v\n", err)
 			// Continue to next resolver if this one fails
+			lastErr = err
 		}
 	}
 
+	if lastErr != nil {
+		// Say why the last resolver that tried failed, e.g. that a package
+		// does not export a subpath
+		return nil, fmt.Errorf("no resolver could handle specifier: %w", lastErr)
+	}
 	return nil, fmt.Errorf("no resolver could handle specifier: %s", specifier)
 }
 
@@ -719,6 +827,115 @@ func (ml *moduleLoader) ClearCache() {
 	}
 }
 
+// Invalidate drops the module at path, a specifier or a resolved path, and
+// the modules importing it directly or not from the cache, so that they are
+// loaded again. Returns the specifiers of the modules dropped.
+func (ml *moduleLoader) Invalidate(path string) []string {
+	target := strings.TrimPrefix(path, "./")
+	var queue []string
+	for _, specifier := range ml.registry.List() {
+		record := ml.registry.Get(specifier)
+		if specifier == path || (record != nil && strings.TrimPrefix(record.ResolvedPath, "./") == target) {
+			queue = append(queue, specifier)
+		}
+	}
+
+	dropped := make(map[string]bool)
+	for len(queue) > 0 {
+		specifier := queue[0]
+		queue = queue[1:]
+		if dropped[specifier] {
+			continue
+		}
+		dropped[specifier] = true
+		queue = append(queue, ml.registry.GetDependents(specifier)...)
+	}
+	specifiers := make([]string, 0, len(dropped))
+	for specifier := range dropped {
+		ml.registry.Remove(specifier)
+		specifiers = append(specifiers, specifier)
+	}
+	sort.Strings(specifiers)
+	return specifiers
+}
+
+// Reload loads the module at path, a specifier or a resolved path, again in
+// place of the versions in the cache, keeping the modules importing it. The
+// modules importing it directly are type checked again against the new
+// version. If the new version fails to load or check, or makes one of them
+// fail to, the cached versions are kept and the new one, returned, has the
+// error. Returns the versions replaced, and an error if there are none.
+func (ml *moduleLoader) Reload(path string) ([]*ModuleRecord, *ModuleRecord, error) {
+	target := strings.TrimPrefix(path, "./")
+	cached := make(map[string]*ModuleRecord)
+	var specifiers []string
+	for _, specifier := range ml.registry.List() {
+		record := ml.registry.Get(specifier)
+		if record != nil && (specifier == path || strings.TrimPrefix(record.ResolvedPath, "./") == target) {
+			cached[specifier] = record
+			specifiers = append(specifiers, specifier)
+		}
+	}
+	if len(specifiers) == 0 {
+		return nil, nil, fmt.Errorf("module %s is not loaded", path)
+	}
+	sort.Strings(specifiers)
+
+	var importers []string
+	replaced := make([]*ModuleRecord, 0, len(specifiers))
+	for _, specifier := range specifiers {
+		importers = append(importers, ml.registry.GetDependents(specifier)...)
+		if !slices.Contains(replaced, cached[specifier]) {
+			replaced = append(replaced, cached[specifier])
+		}
+		ml.registry.Remove(specifier)
+	}
+	loaded, err := ml.loadModuleSequential(specifiers[0], ".")
+	record, _ := loaded.(*ModuleRecord)
+	if err != nil || record == nil {
+		record = &ModuleRecord{Specifier: specifiers[0], ResolvedPath: replaced[0].ResolvedPath, State: ModuleError, Error: err}
+		if err == nil {
+			record.Error = fmt.Errorf("module %s was not loaded", specifiers[0])
+		}
+	}
+
+	if record.Error == nil && ml.checkerFactory != nil && !ml.config.SkipTypeCheck && !ml.config.IgnoreTypeErrors {
+		var diagnostics []errors.PaseratiError
+		checked := make(map[*ModuleRecord]bool)
+		for _, specifier := range importers {
+			importer := ml.registry.Get(specifier)
+			if importer == nil || checked[importer] || importer.Source == nil || importer.Typings != "" || importer.isNative || importer.precompiled {
+				continue
+			}
+			checked[importer] = true
+			program, parseErrs := parser.NewParser(lexer.NewLexerWithSource(importer.Source)).ParseProgram()
+			if len(parseErrs) > 0 {
+				continue
+			}
+			CanonicalizeImports(program, importer.ResolvedPath)
+			importerChecker := ml.checkerFactory()
+			importerChecker.EnableModuleMode(importer.ResolvedPath, ml)
+			diagnostics = append(diagnostics, importerChecker.Check(program)...)
+		}
+		if len(diagnostics) > 0 {
+			record.Error = fmt.Errorf("type checking importers failed: %s", diagnostics[0].Error())
+			record.Diagnostics = diagnostics
+			record.State = ModuleError
+		}
+	}
+
+	if record.Error != nil {
+		for specifier, cachedRecord := range cached {
+			ml.registry.Set(specifier, cachedRecord)
+		}
+		return replaced, record, nil
+	}
+	for _, specifier := range specifiers[1:] {
+		ml.registry.Set(specifier, record)
+	}
+	return replaced, record, nil
+}
+
 // GetStats returns loader statistics
 func (ml *moduleLoader) GetStats() LoaderStats {
 	stats := LoaderStats{
@@ -789,6 +1006,7 @@ func (ml *moduleLoader) performDependencyOrderedTypeChecking(entryPoint string)
 			if len(errors) > 0 && !ml.config.IgnoreTypeErrors {
 				// Store the first error (can be enhanced to store all errors)
 				record.Error = fmt.Errorf("type checking failed: %s", errors[0].Error())
+				record.Diagnostics = errors
 				record.State = ModuleError
 				continue
 			}
@@ -821,9 +1039,10 @@ func (ml *moduleLoader) performDependencyOrderedTypeChecking(entryPoint string)
 			}
 
 			// Compile the module to bytecode
-			chunk, compileErrors := moduleCompiler.Compile(record.AST)
+			chunk, compileErrors := moduleCompiler.Compile(compiledProgram(record))
 			if len(compileErrors) > 0 {
 				record.Error = fmt.Errorf("compilation failed: %s", compileErrors[0].Error())
+				record.Diagnostics = compileErrors
 				record.State = ModuleError
 				continue
 			}
@@ -942,6 +1161,43 @@ func (ml *moduleLoader) handleNativeModuleSource(record *ModuleRecord, nativeMod
 	return nil
 }
 
+// CompiledModuleSource is implemented by the Source of a resolved module that
+// was compiled ahead of time. Its chunk must already use the loader's heap
+// layout.
+type CompiledModuleSource interface {
+	io.ReadCloser
+	CompiledChunk() *vm.Chunk
+	ExportIndices() map[string]uint16
+	ImportSpecs() []*ImportSpec
+}
+
+// handleCompiledModuleSource populates the module record from precompiled
+// bytecode. Only export names are known, so their types are any.
+func (ml *moduleLoader) handleCompiledModuleSource(record *ModuleRecord, compiled CompiledModuleSource) error {
+	chunk := compiled.CompiledChunk()
+	if chunk == nil {
+		return fmt.Errorf("precompiled module %s has no bytecode", record.ResolvedPath)
+	}
+	record.CompiledChunk = chunk
+	record.ExportIndices = compiled.ExportIndices()
+	record.Exports = make(map[string]types.Type, len(record.ExportIndices))
+	for name := range record.ExportIndices {
+		record.Exports[name] = types.Any
+	}
+	record.compiledImports = compiled.ImportSpecs()
+	record.precompiled = true
+
+	record.AST = &parser.Program{
+		Statements: []parser.Statement{},
+	}
+	record.Source = &source.SourceFile{
+		Name:    record.ResolvedPath,
+		Path:    record.ResolvedPath,
+		Content: "// Precompiled module",
+	}
+	return nil
+}
+
 // Helper function for max
 func max(a, b int) int {
 	if a > b {
@@ -98,6 +98,7 @@ func (r *registry) SetParsed(specifier string, result *ParseResult) {
 	record.ParseDuration = result.ParseDuration
 	record.WorkerID = result.WorkerID
 	record.Error = result.Error
+	record.Diagnostics = result.SyntaxErrors
 	
 	if result.Error == nil {
 		record.State = ModuleParsed
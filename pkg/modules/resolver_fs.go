@@ -38,7 +38,7 @@ func NewFileSystemResolver(filesystem fs.FS, baseDir string) *FileSystemResolver
 		fs:         moduleFS,
 		priority:   100, // Lower priority than specialized resolvers
 		extensions: []string{".ts", ".tsx", ".js", ".jsx", ".d.ts", ".json"},
-		indexFiles: []string{"index.ts", "index.tsx", "index.js", "index.jsx"},
+		indexFiles: []string{"index.ts", "index.tsx", "index.d.ts", "index.js", "index.jsx"},
 		baseDir:    baseDir,
 	}
 }
@@ -55,7 +55,7 @@ func NewOSFileSystemResolver(baseDir string) *FileSystemResolver {
 		fs:         &osFS{baseDir: absBaseDir},
 		priority:   100,
 		extensions: []string{".ts", ".tsx", ".js", ".jsx", ".d.ts", ".json"},
-		indexFiles: []string{"index.ts", "index.tsx", "index.js", "index.jsx"},
+		indexFiles: []string{"index.ts", "index.tsx", "index.d.ts", "index.js", "index.jsx"},
 		baseDir:    absBaseDir,
 	}
 }
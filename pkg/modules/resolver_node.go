@@ -0,0 +1,532 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nooga/paserati/pkg/parser"
+)
+
+// NodeResolver resolves bare specifiers the way Node's ESM resolver does.
+// A package is looked up in the node_modules directories from the importing
+// module up to the root and entered through the "exports" of its
+// package.json, matched against the resolver's conditions, or through its
+// "module" and "main" fields and index files when it has no "exports".
+// Specifiers starting with "#" resolve through the "imports" of the
+// importing module's package.json. tsconfig.json "paths" and "baseUrl"
+// mappings, when set, are tried ahead of node_modules, as tsc does.
+type NodeResolver struct {
+	name       string              // Human-readable name
+	fs         ModuleFS            // File system to resolve from
+	files      *FileSystemResolver // Finds files by extension and index file
+	priority   int                 // Resolution priority
+	conditions map[string]bool     // Export conditions besides "default"
+
+	// tsconfig.json mappings
+	baseURL  string              // Directory bare specifiers are tried in, "" if none
+	paths    map[string][]string // Patterns and their substitutions
+	pathsDir string              // Directory substitutions are relative to
+
+	mutex     sync.Mutex
+	manifests map[string]*packageJSON // Parsed package.json files by directory, nil if none
+}
+
+// NewNodeResolver creates a resolver for the packages installed in the
+// node_modules directories of filesystem
+func NewNodeResolver(filesystem fs.FS, baseDir string) *NodeResolver {
+	files := NewFileSystemResolver(filesystem, baseDir)
+	return &NodeResolver{
+		name:       "Node",
+		fs:         files.fs,
+		files:      files,
+		priority:   95, // Ahead of the file system resolver, whose specifiers it leaves alone
+		conditions: map[string]bool{"import": true},
+		manifests:  make(map[string]*packageJSON),
+	}
+}
+
+// Name returns the resolver name
+func (r *NodeResolver) Name() string {
+	return r.name
+}
+
+// CanResolve returns true for bare specifiers and package imports
+func (r *NodeResolver) CanResolve(specifier string) bool {
+	return specifier != "" &&
+		!IsRelativeSpecifier(specifier) &&
+		!strings.HasPrefix(specifier, "/") &&
+		!filepath.IsAbs(specifier) &&
+		!strings.Contains(specifier, "://")
+}
+
+// Priority returns the resolver priority
+func (r *NodeResolver) Priority() int {
+	return r.priority
+}
+
+// SetPriority sets the resolver priority
+func (r *NodeResolver) SetPriority(priority int) {
+	r.priority = priority
+}
+
+// SetConditions sets the conditions "exports" and "imports" are matched
+// against, in place of "import". "default" always matches. With "types",
+// packages without "exports" are entered through their "types" field.
+func (r *NodeResolver) SetConditions(conditions ...string) {
+	r.conditions = make(map[string]bool, len(conditions))
+	for _, condition := range conditions {
+		r.conditions[condition] = true
+	}
+}
+
+// SetBaseURL makes bare specifiers resolve to files in dir before packages
+// are looked up, as tsconfig.json's "baseUrl" does
+func (r *NodeResolver) SetBaseURL(dir string) {
+	r.baseURL = dir
+}
+
+// SetPaths sets the path mappings of tsconfig.json's "paths": patterns with
+// at most one "*", each mapped to substitutions relative to dir
+func (r *NodeResolver) SetPaths(paths map[string][]string, dir string) {
+	r.paths = paths
+	r.pathsDir = dir
+}
+
+// Resolve resolves a module specifier to a concrete module
+func (r *NodeResolver) Resolve(specifier string, fromPath string) (*ResolvedModule, error) {
+	resolvedPath, err := r.resolvePath(specifier, fromPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", specifier, err)
+	}
+
+	source, err := r.fs.Open(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", resolvedPath, err)
+	}
+
+	return &ResolvedModule{
+		Specifier:    specifier,
+		ResolvedPath: resolvedPath,
+		Source:       source,
+		FS:           r.fs,
+		Resolver:     r.name,
+	}, nil
+}
+
+// ResolveTypes returns the path of the declaration file typing the module
+// specifier names: the one the package's "exports" or "types" point to, or
+// else the one of its @types package
+func (r *NodeResolver) ResolveTypes(specifier string, fromPath string) (string, error) {
+	types := r.withConditions("types")
+	if resolved, err := types.resolvePath(specifier, fromPath); err == nil && parser.IsDeclarationFile(resolved) {
+		return resolved, nil
+	}
+	name, subpath, err := parsePackageSpecifier(specifier)
+	if err != nil {
+		return "", err
+	}
+	// @types/scope__name types @scope/name
+	typesName := "@types/" + strings.Replace(strings.TrimPrefix(name, "@"), "/", "__", 1)
+	if resolved, err := types.resolvePackage(typesName+strings.TrimPrefix(subpath, "."), fromPath); err == nil && parser.IsDeclarationFile(resolved) {
+		return resolved, nil
+	}
+	return "", fmt.Errorf("no declaration file for %s", specifier)
+}
+
+// withConditions returns a resolver like r that also matches conditions
+func (r *NodeResolver) withConditions(conditions ...string) *NodeResolver {
+	clone := &NodeResolver{
+		name:       r.name,
+		fs:         r.fs,
+		files:      r.files,
+		priority:   r.priority,
+		conditions: make(map[string]bool, len(r.conditions)+len(conditions)),
+		baseURL:    r.baseURL,
+		paths:      r.paths,
+		pathsDir:   r.pathsDir,
+		manifests:  make(map[string]*packageJSON),
+	}
+	for condition := range r.conditions {
+		clone.conditions[condition] = true
+	}
+	for _, condition := range conditions {
+		clone.conditions[condition] = true
+	}
+	return clone
+}
+
+// resolvePath returns the path of the file specifier names
+func (r *NodeResolver) resolvePath(specifier string, fromPath string) (string, error) {
+	if strings.HasPrefix(specifier, "#") {
+		return r.resolvePackageImports(specifier, fromPath)
+	}
+	if resolved, ok := r.resolveMapped(specifier); ok {
+		return resolved, nil
+	}
+	return r.resolvePackage(specifier, fromPath)
+}
+
+// resolveMapped resolves specifier through the tsconfig.json mappings
+func (r *NodeResolver) resolveMapped(specifier string) (string, bool) {
+	if targets, match, ok := matchPathPattern(r.paths, specifier); ok {
+		for _, target := range targets {
+			target = strings.Replace(target, "*", match, 1)
+			if resolved, err := r.files.tryResolve(pathpkg.Join(r.pathsDir, target)); err == nil {
+				return resolved, true
+			}
+		}
+	}
+	if r.baseURL != "" {
+		if resolved, err := r.files.tryResolve(pathpkg.Join(r.baseURL, specifier)); err == nil {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// matchPathPattern finds the "paths" pattern matching specifier: an exact
+// one, or else the one with the longest prefix before its "*". It returns
+// the pattern's substitutions and the text "*" matched.
+func matchPathPattern(paths map[string][]string, specifier string) ([]string, string, bool) {
+	if targets, ok := paths[specifier]; ok && !strings.Contains(specifier, "*") {
+		return targets, "", true
+	}
+	best, match := "", ""
+	for pattern := range paths {
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if !ok || len(specifier) < len(prefix)+len(suffix) ||
+			!strings.HasPrefix(specifier, prefix) || !strings.HasSuffix(specifier, suffix) {
+			continue
+		}
+		if best == "" || len(prefix) > strings.Index(best, "*") {
+			best, match = pattern, specifier[len(prefix):len(specifier)-len(suffix)]
+		}
+	}
+	if best == "" {
+		return nil, "", false
+	}
+	return paths[best], match, true
+}
+
+// resolvePackage resolves a package name, optionally followed by a subpath,
+// from the node_modules directories visible to fromPath
+func (r *NodeResolver) resolvePackage(specifier string, fromPath string) (string, error) {
+	name, subpath, err := parsePackageSpecifier(specifier)
+	if err != nil {
+		return "", err
+	}
+	for dir := startDir(fromPath); ; dir = pathpkg.Dir(dir) {
+		if pathpkg.Base(dir) != "node_modules" {
+			packageDir := pathpkg.Join(dir, "node_modules", name)
+			if r.isDir(packageDir) {
+				return r.resolvePackageEntry(packageDir, name, subpath)
+			}
+		}
+		if dir == "." {
+			break
+		}
+	}
+	return "", fmt.Errorf("package %s not found in node_modules", name)
+}
+
+// resolvePackageEntry resolves subpath ("." for the package itself) within
+// the package installed at packageDir
+func (r *NodeResolver) resolvePackageEntry(packageDir string, name string, subpath string) (string, error) {
+	manifest := r.manifest(packageDir)
+	if manifest != nil && manifest.exports != nil {
+		resolved, err := r.resolveExports(manifest, subpath)
+		if errors.Is(err, errNoTarget) {
+			return "", fmt.Errorf("package path %s is not exported from package %s", subpath, name)
+		}
+		return resolved, err
+	}
+
+	if subpath != "." {
+		return r.files.tryResolve(pathpkg.Join(packageDir, subpath))
+	}
+	if manifest != nil {
+		var entries []string
+		if r.conditions["types"] {
+			entries = append(entries, manifest.types, manifest.typings)
+		}
+		for _, entry := range append(entries, manifest.module, manifest.main) {
+			if entry == "" {
+				continue
+			}
+			if resolved, err := r.files.tryResolve(pathpkg.Join(packageDir, entry)); err == nil {
+				return resolved, nil
+			}
+		}
+	}
+	return r.files.tryResolve(packageDir)
+}
+
+// resolveExports resolves subpath through a package's "exports"
+func (r *NodeResolver) resolveExports(manifest *packageJSON, subpath string) (string, error) {
+	exports, ok := manifest.exports.(*jsonObject)
+	if !ok || !exports.hasSubpathKeys() {
+		// A target or conditions for the package itself
+		if subpath != "." {
+			return "", errNoTarget
+		}
+		return r.resolveTarget(manifest.dir, manifest.exports, "", false, false)
+	}
+	return r.resolveImportsExports(manifest.dir, exports, subpath, false)
+}
+
+// resolvePackageImports resolves a "#" specifier through the "imports" of
+// the package.json nearest to fromPath
+func (r *NodeResolver) resolvePackageImports(specifier string, fromPath string) (string, error) {
+	if specifier == "#" || strings.HasPrefix(specifier, "#/") {
+		return "", fmt.Errorf("invalid package import specifier %s", specifier)
+	}
+	for dir := startDir(fromPath); ; dir = pathpkg.Dir(dir) {
+		if pathpkg.Base(dir) == "node_modules" {
+			break
+		}
+		if manifest := r.manifest(dir); manifest != nil {
+			imports, ok := manifest.imports.(*jsonObject)
+			if !ok {
+				break
+			}
+			resolved, err := r.resolveImportsExports(dir, imports, specifier, true)
+			if errors.Is(err, errNoTarget) {
+				break
+			}
+			return resolved, err
+		}
+		if dir == "." {
+			break
+		}
+	}
+	return "", fmt.Errorf("package import %s is not defined", specifier)
+}
+
+// errNoTarget reports that "exports" or "imports" has no target for a
+// subpath under the resolver's conditions
+var errNoTarget = errors.New("no matching target")
+
+// resolveImportsExports resolves key through an "exports" or "imports"
+// object whose keys are subpaths or subpath patterns
+func (r *NodeResolver) resolveImportsExports(packageDir string, object *jsonObject, key string, isImports bool) (string, error) {
+	if target, ok := object.values[key]; ok && !strings.Contains(key, "*") {
+		return r.resolveTarget(packageDir, target, "", false, isImports)
+	}
+
+	// The pattern with the longest prefix wins, then the longest pattern
+	best, match := "", ""
+	for _, pattern := range object.keys {
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if !ok || strings.Contains(suffix, "*") || key == prefix || len(key) < len(prefix)+len(suffix) ||
+			!strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		bestPrefix := strings.Index(best, "*")
+		if best == "" || len(prefix) > bestPrefix || (len(prefix) == bestPrefix && len(pattern) > len(best)) {
+			best, match = pattern, key[len(prefix):len(key)-len(suffix)]
+		}
+	}
+	if best == "" {
+		return "", errNoTarget
+	}
+	return r.resolveTarget(packageDir, object.values[best], match, true, isImports)
+}
+
+// resolveTarget resolves an "exports" or "imports" target: a path within
+// the package, an array of fallbacks, or conditions mapped to targets
+func (r *NodeResolver) resolveTarget(packageDir string, target any, match string, isPattern bool, isImports bool) (string, error) {
+	switch target := target.(type) {
+	case string:
+		if isPattern {
+			target = strings.ReplaceAll(target, "*", match)
+		}
+		if !strings.HasPrefix(target, "./") {
+			if isImports && !strings.HasPrefix(target, "../") && !strings.HasPrefix(target, "/") && !strings.Contains(target, "://") {
+				// Package imports may map to other packages
+				return r.resolvePackage(target, pathpkg.Join(packageDir, "package.json"))
+			}
+			return "", fmt.Errorf("invalid package target %q in %s", target, packageDir)
+		}
+		for _, segment := range strings.Split(target[2:], "/") {
+			if segment == "." || segment == ".." || strings.EqualFold(segment, "node_modules") {
+				return "", fmt.Errorf("invalid package target %q in %s", target, packageDir)
+			}
+		}
+		return r.files.tryResolve(pathpkg.Join(packageDir, target))
+
+	case []any:
+		err := errNoTarget
+		for _, fallback := range target {
+			var resolved string
+			if resolved, err = r.resolveTarget(packageDir, fallback, match, isPattern, isImports); err == nil {
+				return resolved, nil
+			}
+		}
+		return "", err
+
+	case *jsonObject:
+		for _, condition := range target.keys {
+			if condition != "default" && !r.conditions[condition] {
+				continue
+			}
+			resolved, err := r.resolveTarget(packageDir, target.values[condition], match, isPattern, isImports)
+			if errors.Is(err, errNoTarget) {
+				continue
+			}
+			return resolved, err
+		}
+		return "", errNoTarget
+	}
+	// null excludes the subpath
+	return "", errNoTarget
+}
+
+// parsePackageSpecifier splits a bare specifier into a package name, which
+// is scoped if it starts with "@", and a subpath, "." for the package itself
+func parsePackageSpecifier(specifier string) (name string, subpath string, err error) {
+	segments := strings.SplitN(specifier, "/", 3)
+	count := 1
+	if strings.HasPrefix(specifier, "@") {
+		if len(segments) < 2 || segments[1] == "" {
+			return "", "", fmt.Errorf("invalid package name %s", specifier)
+		}
+		count = 2
+	}
+	name = strings.Join(segments[:min(count, len(segments))], "/")
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, "\\%") {
+		return "", "", fmt.Errorf("invalid package name %s", specifier)
+	}
+	return name, "." + specifier[len(name):], nil
+}
+
+// startDir returns the directory lookups for the module at fromPath start in
+func startDir(fromPath string) string {
+	dir := "."
+	if fromPath != "" {
+		dir = pathpkg.Dir(fromPath)
+	}
+	if pathpkg.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "."
+	}
+	return dir
+}
+
+// isDir checks if a path exists and is a directory
+func (r *NodeResolver) isDir(path string) bool {
+	info, err := fs.Stat(r.fs, path)
+	return err == nil && info.IsDir()
+}
+
+// packageJSON holds the fields of a package.json that resolution uses
+type packageJSON struct {
+	dir     string
+	main    string
+	module  string
+	types   string
+	typings string
+	exports any // Decoded by decodeOrderedJSON, nil if absent
+	imports any
+}
+
+// manifest returns the package.json in dir, or nil if there is none or it
+// is not valid JSON
+func (r *NodeResolver) manifest(dir string) *packageJSON {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if manifest, ok := r.manifests[dir]; ok {
+		return manifest
+	}
+	var manifest *packageJSON
+	if data, err := r.fs.ReadFile(pathpkg.Join(dir, "package.json")); err == nil {
+		manifest = parsePackageJSON(dir, data)
+	}
+	r.manifests[dir] = manifest
+	return manifest
+}
+
+// parsePackageJSON parses a package.json, returning nil if it is invalid
+func parsePackageJSON(dir string, data []byte) *packageJSON {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoded, err := decodeOrderedJSON(decoder)
+	if err != nil {
+		return nil
+	}
+	object, ok := decoded.(*jsonObject)
+	if !ok {
+		return nil
+	}
+	field := func(key string) string {
+		value, _ := object.values[key].(string)
+		return value
+	}
+	return &packageJSON{
+		dir:     dir,
+		main:    field("main"),
+		module:  field("module"),
+		types:   field("types"),
+		typings: field("typings"),
+		exports: object.values["exports"],
+		imports: object.values["imports"],
+	}
+}
+
+// jsonObject is a JSON object that keeps its keys in document order, the
+// order in which conditions are matched
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// hasSubpathKeys reports whether the object maps subpaths, rather than
+// conditions, to targets
+func (o *jsonObject) hasSubpathKeys() bool {
+	return len(o.keys) > 0 && strings.HasPrefix(o.keys[0], ".")
+}
+
+// decodeOrderedJSON decodes the next JSON value, as encoding/json does into
+// an any, except that objects are decoded to *jsonObject
+func decodeOrderedJSON(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{values: make(map[string]any)}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string) // Object keys are always strings
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := object.values[key]; !exists {
+				object.keys = append(object.keys, key)
+			}
+			object.values[key] = value
+		}
+		_, err = decoder.Token() // '}'
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token() // ']'
+		return array, err
+	}
+	return token, nil
+}
@@ -0,0 +1,192 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func nodeTestFS() fstest.MapFS {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+	return fstest.MapFS{
+		"node_modules/fmt/package.json": file(`{
+			"name": "fmt",
+			"exports": {
+				".": {"types": "./dist/index.d.ts", "import": "./dist/index.js", "require": "./dist/index.cjs"},
+				"./sub/*": "./dist/sub/*.js",
+				"./sub/private/*": null,
+				"./feature": ["./missing.js", "./dist/feature.js"]
+			},
+			"imports": {"#util": "./dist/util.js", "#dep": "dep"}
+		}`),
+		"node_modules/fmt/dist/index.js":          file(`export const x = 1;`),
+		"node_modules/fmt/dist/index.d.ts":        file(`export declare const x: number;`),
+		"node_modules/fmt/dist/index.cjs":         file(`module.exports = {};`),
+		"node_modules/fmt/dist/sub/deep.js":       file(`export const deep = 1;`),
+		"node_modules/fmt/dist/sub/private/x.js":  file(`export const x = 1;`),
+		"node_modules/fmt/dist/feature.js":        file(`export const feature = 1;`),
+		"node_modules/fmt/dist/util.js":           file(`export const util = 1;`),
+		"node_modules/dep/index.ts":               file(`export const dep = 1;`),
+		"node_modules/sugar/package.json":         file(`{"exports": {"import": "./esm.js", "default": "./cjs.js"}}`),
+		"node_modules/sugar/esm.js":               file(`export const esm = 1;`),
+		"node_modules/sugar/cjs.js":               file(`module.exports = {};`),
+		"node_modules/@scope/legacy/package.json": file(`{"module": "./esm.ts", "main": "./main.js"}`),
+		"node_modules/@scope/legacy/esm.ts":       file(`export const esm = 1;`),
+		"node_modules/@scope/legacy/main.js":      file(`module.exports = {};`),
+		"node_modules/@scope/legacy/lib/util.ts":  file(`export const util = 1;`),
+		"packages/app/node_modules/dep/index.ts":  file(`export const nested = 1;`),
+		"packages/app/main.ts":                    file(`import { dep } from "dep";`),
+		"src/lib/value.ts":                        file(`export const value = 1;`),
+		"src/shared.ts":                           file(`export const shared = 1;`),
+	}
+}
+
+func TestNodeResolverCanResolve(t *testing.T) {
+	resolver := NewNodeResolver(fstest.MapFS{}, "")
+
+	tests := []struct {
+		specifier  string
+		canResolve bool
+	}{
+		{"date-fns", true},
+		{"@scoped/module/sub", true},
+		{"#internal", true},
+		{"./relative.ts", false},
+		{"../parent.ts", false},
+		{"/absolute.ts", false},
+		{"https://example.com/mod.js", false},
+	}
+
+	for _, test := range tests {
+		if result := resolver.CanResolve(test.specifier); result != test.canResolve {
+			t.Errorf("CanResolve('%s') = %v, expected %v", test.specifier, result, test.canResolve)
+		}
+	}
+}
+
+func TestNodeResolverResolve(t *testing.T) {
+	resolver := NewNodeResolver(nodeTestFS(), "")
+
+	tests := []struct {
+		specifier string
+		fromPath  string
+		expected  string
+	}{
+		{"fmt", "main.ts", "node_modules/fmt/dist/index.js"},
+		{"fmt/sub/deep", "main.ts", "node_modules/fmt/dist/sub/deep.js"},
+		{"fmt/feature", "main.ts", "node_modules/fmt/dist/feature.js"},
+		{"sugar", "main.ts", "node_modules/sugar/esm.js"},
+		{"@scope/legacy", "main.ts", "node_modules/@scope/legacy/esm.ts"},
+		{"@scope/legacy/lib/util", "main.ts", "node_modules/@scope/legacy/lib/util.ts"},
+		{"dep", "main.ts", "node_modules/dep/index.ts"},
+		{"dep", "packages/app/main.ts", "packages/app/node_modules/dep/index.ts"},
+		{"#util", "node_modules/fmt/dist/index.js", "node_modules/fmt/dist/util.js"},
+		{"#dep", "node_modules/fmt/dist/index.js", "node_modules/dep/index.ts"},
+	}
+
+	for _, test := range tests {
+		resolved, err := resolver.Resolve(test.specifier, test.fromPath)
+		if err != nil {
+			t.Errorf("Resolve(%q, %q) failed: %v", test.specifier, test.fromPath, err)
+			continue
+		}
+		resolved.Source.Close()
+		if resolved.ResolvedPath != test.expected {
+			t.Errorf("Resolve(%q, %q) = %s, expected %s", test.specifier, test.fromPath, resolved.ResolvedPath, test.expected)
+		}
+	}
+}
+
+func TestNodeResolverErrors(t *testing.T) {
+	resolver := NewNodeResolver(nodeTestFS(), "")
+
+	tests := []struct {
+		specifier string
+		fromPath  string
+		message   string
+	}{
+		{"missing", "main.ts", "package missing not found"},
+		{"fmt/dist/index.js", "main.ts", "package path ./dist/index.js is not exported from package fmt"},
+		{"fmt/sub/private/x", "main.ts", "package path ./sub/private/x is not exported from package fmt"},
+		{"#util", "main.ts", "package import #util is not defined"},
+		{"#missing", "node_modules/fmt/dist/index.js", "package import #missing is not defined"},
+	}
+
+	for _, test := range tests {
+		_, err := resolver.Resolve(test.specifier, test.fromPath)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Resolve(%q, %q) error = %v, expected %q", test.specifier, test.fromPath, err, test.message)
+		}
+	}
+}
+
+func TestNodeResolverConditions(t *testing.T) {
+	resolver := NewNodeResolver(nodeTestFS(), "")
+	resolver.SetConditions("types", "import")
+
+	resolved, err := resolver.Resolve("fmt", "main.ts")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	resolved.Source.Close()
+	if resolved.ResolvedPath != "node_modules/fmt/dist/index.d.ts" {
+		t.Errorf("expected the types condition to win, got %s", resolved.ResolvedPath)
+	}
+}
+
+func TestNodeResolverPaths(t *testing.T) {
+	resolver := NewNodeResolver(nodeTestFS(), "")
+	resolver.SetBaseURL("src")
+	resolver.SetPaths(map[string][]string{
+		"@lib/*":       {"missing/*", "lib/*"},
+		"@lib/special": {"shared.ts"},
+		"@*":           {"never/*"},
+	}, "src")
+
+	tests := []struct {
+		specifier string
+		expected  string
+	}{
+		{"@lib/value", "src/lib/value.ts"},
+		{"@lib/special", "src/shared.ts"},
+		{"shared", "src/shared.ts"},               // baseUrl
+		{"fmt", "node_modules/fmt/dist/index.js"}, // Falls through to node_modules
+	}
+
+	for _, test := range tests {
+		resolved, err := resolver.Resolve(test.specifier, "main.ts")
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", test.specifier, err)
+			continue
+		}
+		resolved.Source.Close()
+		if resolved.ResolvedPath != test.expected {
+			t.Errorf("Resolve(%q) = %s, expected %s", test.specifier, resolved.ResolvedPath, test.expected)
+		}
+	}
+}
+
+func TestCanonicalSpecifier(t *testing.T) {
+	tests := []struct {
+		specifier string
+		fromPath  string
+		expected  string
+	}{
+		{"./b.ts", "app.ts", "./b.ts"},
+		{"./b.ts", "", "./b.ts"},
+		{"./b.ts", "lib/a.ts", "./lib/b.ts"},
+		{"../b.ts", "lib/a.ts", "./b.ts"},
+		{"./x/../y.ts", "lib/a.ts", "./lib/y.ts"},
+		{"../../b.ts", "lib/a.ts", "../b.ts"},
+		{"date-fns", "lib/a.ts", "date-fns"},
+		{"#util", "lib/a.ts", "#util"},
+	}
+
+	for _, test := range tests {
+		if result := CanonicalSpecifier(test.specifier, test.fromPath); result != test.expected {
+			t.Errorf("CanonicalSpecifier(%q, %q) = %q, expected %q", test.specifier, test.fromPath, result, test.expected)
+		}
+	}
+}
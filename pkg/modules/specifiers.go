@@ -0,0 +1,69 @@
+package modules
+
+import (
+	pathpkg "path"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/parser"
+)
+
+// Modules are identified by specifier in the registry, in compiled code and
+// at run time, so that a relative specifier names the same module wherever
+// it appears, relative imports are canonicalized: made relative to the root
+// of the module tree rather than to the importing module. From "lib/a.ts",
+// "./b.ts" becomes "./lib/b.ts", the specifier "app.ts" would use for the
+// same file. Bare specifiers ("date-fns") are left alone.
+
+// IsRelativeSpecifier reports whether specifier is a relative path
+func IsRelativeSpecifier(specifier string) bool {
+	return strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../")
+}
+
+// CanonicalSpecifier returns the canonical form of specifier as imported by
+// the module at fromPath
+func CanonicalSpecifier(specifier string, fromPath string) string {
+	if !IsRelativeSpecifier(specifier) {
+		return specifier
+	}
+	dir := "."
+	if fromPath != "" {
+		dir = pathpkg.Dir(fromPath)
+	}
+	target := pathpkg.Join(dir, specifier)
+	if pathpkg.IsAbs(target) || target == ".." || strings.HasPrefix(target, "../") {
+		return target
+	}
+	return "./" + target
+}
+
+// CanonicalizeImports rewrites the specifiers of program's static imports
+// and re-exports to their canonical form, for the module at modulePath
+func CanonicalizeImports(program *parser.Program, modulePath string) {
+	if program == nil {
+		return
+	}
+	for _, stmt := range program.Statements {
+		var source *parser.StringLiteral
+		switch node := stmt.(type) {
+		case *parser.ImportDeclaration:
+			source = node.Source
+		case *parser.ExportNamedDeclaration:
+			source = node.Source
+		case *parser.ExportAllDeclaration:
+			source = node.Source
+		}
+		if source != nil {
+			source.Value = CanonicalSpecifier(source.Value, modulePath)
+		}
+	}
+}
+
+// resolveFrom returns the path specifier is resolved from when imported by
+// the module at fromPath. Canonical relative specifiers are relative to the
+// root; bare ones are looked up from the importer, as packages are.
+func resolveFrom(specifier string, fromPath string) string {
+	if IsRelativeSpecifier(specifier) {
+		return "."
+	}
+	return fromPath
+}
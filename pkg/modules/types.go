@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/nooga/paserati/pkg/errors"
 	"github.com/nooga/paserati/pkg/parser"
 	"github.com/nooga/paserati/pkg/source"
 	"github.com/nooga/paserati/pkg/types"
@@ -76,6 +77,7 @@ type ModuleRecord struct {
 	ExportValues  map[string]vm.Value   // Exported runtime values
 	ExportIndices map[string]uint16     // Export name to global heap index mapping (for dynamic import)
 	Namespace     vm.Value              // Module namespace object
+	Typings       string                // Declaration file typing a JavaScript module, "" if none
 
 	// Compilation results
 	CompiledChunk *vm.Chunk // Compiled bytecode chunk for execution
@@ -88,12 +90,17 @@ type ModuleRecord struct {
 	nativeModule NativeModuleInterface // Native module interface for lazy initialization
 	isNative     bool                  // Flag to indicate this is a native module
 
+	// Precompiled module support
+	precompiled     bool          // Loaded from compiled bytecode; skips checking and compilation
+	compiledImports []*ImportSpec // Static imports of a precompiled module
+
 	// JSON module support
 	IsJSON   bool     // Flag to indicate this is a JSON module
 	JSONData vm.Value // Parsed JSON data (for JSON modules)
 
 	// Error handling
-	Error error // Loading/parsing/checking error
+	Error       error                  // Loading/parsing/checking error
+	Diagnostics []errors.PaseratiError // The syntax, type or compile errors behind Error, if any
 
 	// Timing information
 	LoadTime     time.Time // When module loading started
@@ -175,14 +182,15 @@ type ParseJob struct {
 
 // ParseResult represents the result of parsing a module
 type ParseResult struct {
-	ModulePath    string          // Module path that was parsed
-	AST           *parser.Program // Parsed AST
-	ImportSpecs   []*ImportSpec   // Discovered imports
-	ExportSpecs   []*ExportSpec   // Discovered exports
-	ParseDuration time.Duration   // Time taken to parse
-	WorkerID      int             // ID of worker that parsed this
-	Error         error           // Parse error (if any)
-	Timestamp     time.Time       // When parsing completed
+	ModulePath    string                 // Module path that was parsed
+	AST           *parser.Program        // Parsed AST
+	ImportSpecs   []*ImportSpec          // Discovered imports
+	ExportSpecs   []*ExportSpec          // Discovered exports
+	ParseDuration time.Duration          // Time taken to parse
+	WorkerID      int                    // ID of worker that parsed this
+	Error         error                  // Parse error (if any)
+	SyntaxErrors  []errors.PaseratiError // All syntax errors, when Error is set
+	Timestamp     time.Time              // When parsing completed
 }
 
 // LoaderConfig configures module loader behavior
@@ -296,6 +304,17 @@ func (mr *ModuleRecord) GetCompiledChunk() *vm.Chunk {
 	return mr.CompiledChunk
 }
 
+// RuntimeImports returns the static imports evaluated when the module runs
+func (mr *ModuleRecord) RuntimeImports() []*ImportSpec {
+	if mr.precompiled {
+		return mr.compiledImports
+	}
+	if mr.AST == nil {
+		return nil
+	}
+	return ExtractRuntimeImportSpecs(mr.AST)
+}
+
 // GetExportNames returns the names of all exports from this module
 func (mr *ModuleRecord) GetExportNames() []string {
 	names := make([]string, 0, len(mr.Exports))
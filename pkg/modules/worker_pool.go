@@ -288,6 +288,7 @@ func (w *parseWorker) processJob(job *ParseJob) *ParseResult {
 	if len(parseErrs) > 0 {
 		// Take the first error
 		result.Error = fmt.Errorf("parsing failed: %s", parseErrs[0].Error())
+		result.SyntaxErrors = parseErrs
 		result.ParseDuration = time.Since(startTime)
 		return result
 	}
@@ -303,6 +304,35 @@ func (w *parseWorker) processJob(job *ParseJob) *ParseResult {
 	return result
 }
 
+// ExtractImportSpecs returns the modules a program imports statically, in
+// source order, including those it re-exports from
+func ExtractImportSpecs(program *parser.Program) []*ImportSpec {
+	return extractImportSpecs(program)
+}
+
+// ExtractRuntimeImportSpecs is ExtractImportSpecs without type-only imports
+// and re-exports, which compiled code never evaluates
+func ExtractRuntimeImportSpecs(program *parser.Program) []*ImportSpec {
+	var specs []*ImportSpec
+	for _, stmt := range program.Statements {
+		switch node := stmt.(type) {
+		case *parser.ImportDeclaration:
+			if node.Source != nil && !node.IsTypeOnly {
+				specs = append(specs, &ImportSpec{ModulePath: node.Source.Value, Attributes: node.Attributes})
+			}
+		case *parser.ExportNamedDeclaration:
+			if node.Source != nil && !node.IsTypeOnly {
+				specs = append(specs, &ImportSpec{ModulePath: node.Source.Value})
+			}
+		case *parser.ExportAllDeclaration:
+			if node.Source != nil {
+				specs = append(specs, &ImportSpec{ModulePath: node.Source.Value})
+			}
+		}
+	}
+	return specs
+}
+
 // extractImportSpecs extracts import specifications from the AST
 // This includes both import statements and re-export statements with 'from' clauses
 func extractImportSpecs(program *parser.Program) []*ImportSpec {
@@ -164,10 +164,14 @@ func (vs *VarStatement) String() string {
 // ConstStatement represents a `const` variable declaration.
 // const <Name> : <TypeAnnotation> = <Value>;
 // Note: Structurally identical to LetStatement for now, but semantically different.
+// `using` and `await using` declarations are ConstStatements too: they bind
+// like const, and dispose of their values when their scope is left.
 type ConstStatement struct {
-	Token        *lexer.Token     // The lexer.CONST token
+	Token        *lexer.Token     // The lexer.CONST token, or the 'using' or 'await' token
 	Declarations []*VarDeclarator // List of variable declarations
 	Declare      bool             // True for ambient declarations (declare const)
+	Using        bool             // True for using and await using declarations
+	Await        bool             // True for await using declarations
 	// Legacy fields for backward compatibility (first declaration)
 	Name           *Identifier // The variable name
 	TypeAnnotation Expression  // Parsed type node
@@ -179,7 +183,11 @@ func (cs *ConstStatement) statementNode()       {}
 func (cs *ConstStatement) TokenLiteral() string { return cs.Token.Literal }
 func (cs *ConstStatement) String() string {
 	var out bytes.Buffer
-	out.WriteString(cs.TokenLiteral() + " ")
+	if cs.Await {
+		out.WriteString("await using ")
+	} else {
+		out.WriteString(cs.TokenLiteral() + " ")
+	}
 	out.WriteString(cs.Name.String())
 	if cs.TypeAnnotation != nil {
 		out.WriteString(": ")
@@ -2163,6 +2171,25 @@ func (ead *ExportAllDeclaration) String() string {
 	return out.String()
 }
 
+// ExportAssignment represents `export = expression;` in a declaration file or
+// ambient module, which makes the expression the module's whole export
+type ExportAssignment struct {
+	Token      *lexer.Token // The 'export' token
+	Expression Expression
+}
+
+func (ea *ExportAssignment) statementNode()       {}
+func (ea *ExportAssignment) TokenLiteral() string { return ea.Token.Literal }
+func (ea *ExportAssignment) String() string {
+	var out bytes.Buffer
+	out.WriteString("export = ")
+	if ea.Expression != nil {
+		out.WriteString(ea.Expression.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
 // ExportSpecifier represents individual export specifiers in export { ... }
 type ExportSpecifier interface {
 	Node
@@ -2197,6 +2224,7 @@ type FunctionTypeExpression struct {
 	Token          *lexer.Token     // The '(' token starting the parameter list
 	TypeParameters []*TypeParameter // Generic type parameters (e.g., <T, U>)
 	Parameters     []Expression     // Slice of Expression nodes representing parameter types
+	ParameterNames []string         // Names of the parameters, "" where only a type is given
 	OptionalParams []bool           // Tracks optional parameters in method/call signatures
 	RestParameter  Expression       // Optional rest parameter type (e.g., ...args: string[])
 	ReturnType     Expression       // Expression node for the return type
@@ -2818,6 +2846,7 @@ type InterfaceProperty struct {
 	Type                   Expression  // Type annotation (for properties) or function type (for methods)
 	IsMethod               bool        // Whether this is a method signature
 	Optional               bool        // Whether the property is optional (Name?)
+	Readonly               bool        // Whether the property is readonly
 	IsConstructorSignature bool        // Whether this is a constructor signature (new (): T)
 	IsComputedProperty     bool        // Whether this is a computed property name [expr]:
 
@@ -2830,6 +2859,9 @@ type InterfaceProperty struct {
 
 func (ip *InterfaceProperty) String() string {
 	var out bytes.Buffer
+	if ip.Readonly {
+		out.WriteString("readonly ")
+	}
 	if ip.IsConstructorSignature {
 		out.WriteString("new ")
 		out.WriteString(ip.Type.String()) // This should be a function type for the constructor
@@ -2876,6 +2908,7 @@ type ConstructorTypeExpression struct {
 	Token          *lexer.Token     // The 'new' token
 	TypeParameters []*TypeParameter // Optional type parameters: new<T>(...)
 	Parameters     []Expression     // Parameter types for the constructor
+	ParameterNames []string         // Names of the parameters, "" where only a type is given
 	RestParameter  Expression       // Rest parameter type for variadic constructors
 	ReturnType     Expression       // The constructed type (T in `new (): T`)
 }
@@ -3621,6 +3654,7 @@ type PropertyDefinition struct {
 	IsProtected        bool         // For private access modifier
 	IsOverride         bool         // For override keyword
 	IsDeclare          bool         // For declare keyword
+	IsAccessor         bool         // For auto-accessors (accessor keyword): a getter and setter over private storage
 	Decorators         []*Decorator // Decorators applied to this property
 }
 
@@ -3646,6 +3680,9 @@ func (pd *PropertyDefinition) String() string {
 	if pd.IsStatic {
 		out.WriteString("static ")
 	}
+	if pd.IsAccessor {
+		out.WriteString("accessor ")
+	}
 	if pd.Key != nil {
 		out.WriteString(pd.Key.String())
 	}
@@ -3806,6 +3843,54 @@ func (n *NamespaceDeclaration) String() string {
 	return out.String()
 }
 
+// AmbientModuleDeclaration represents a declaration of a module's types:
+//
+//	declare module "name" { ... }
+//	declare module "name";
+//
+// The body holds the module's exports, as a declaration file would. Without
+// a body (the shorthand form) everything imported from the module is any.
+type AmbientModuleDeclaration struct {
+	Token *lexer.Token    // The 'module' token
+	Name  *StringLiteral  // The module specifier, which may contain a "*" wildcard
+	Body  *BlockStatement // nil for the shorthand form
+}
+
+func (m *AmbientModuleDeclaration) statementNode()       {}
+func (m *AmbientModuleDeclaration) TokenLiteral() string { return m.Token.Literal }
+func (m *AmbientModuleDeclaration) String() string {
+	var out bytes.Buffer
+	out.WriteString("declare module ")
+	if m.Name != nil {
+		out.WriteString(m.Name.String())
+	}
+	if m.Body == nil {
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString(" ")
+	out.WriteString(m.Body.String())
+	return out.String()
+}
+
+// GlobalDeclaration represents `declare global { ... }`, which adds the
+// declarations in its body to the global scope from within a module
+type GlobalDeclaration struct {
+	Token *lexer.Token // The 'global' token
+	Body  *BlockStatement
+}
+
+func (g *GlobalDeclaration) statementNode()       {}
+func (g *GlobalDeclaration) TokenLiteral() string { return g.Token.Literal }
+func (g *GlobalDeclaration) String() string {
+	var out bytes.Buffer
+	out.WriteString("declare global ")
+	if g.Body != nil {
+		out.WriteString(g.Body.String())
+	}
+	return out.String()
+}
+
 // dumpNode prints a structured representation of an AST node
 func dumpNode(node Node, indent string) {
 	if node == nil {
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/source"
+)
+
+func TestParseReferenceDirectives(t *testing.T) {
+	input := `#!/usr/bin/env paserati
+/* leading comment */
+/// <reference path="./globals.d.ts" />
+/// <reference types='node' />
+/// <reference lib="es2020" />
+let x = 1;
+/// <reference path="./ignored.d.ts" />
+`
+	directives := ParseReferenceDirectives(input)
+	expected := []ReferenceDirective{
+		{Kind: "path", Value: "./globals.d.ts", Line: 3},
+		{Kind: "types", Value: "node", Line: 4},
+		{Kind: "lib", Value: "es2020", Line: 5},
+	}
+	if len(directives) != len(expected) {
+		t.Fatalf("expected %d directives, got %+v", len(expected), directives)
+	}
+	for i, directive := range directives {
+		if directive != expected[i] {
+			t.Errorf("directive %d: expected %+v, got %+v", i, expected[i], directive)
+		}
+	}
+}
+
+func TestParseDeclarationFile(t *testing.T) {
+	input := `
+declare module "host" {
+	export function now(): number
+	export class Clock {
+		get time(): number
+		tick(): void
+	}
+	const version: string
+	export default version
+}
+declare module "shorthand";
+declare global {
+	interface Window { host: string }
+}
+declare namespace util { function id<T>(x: T): T }
+declare function f(a: number): string
+declare function f(a: string): string
+export = f;
+export as namespace F;
+`
+	sourceFile := source.NewSourceFile("host.d.ts", "host.d.ts", input)
+	program, errs := NewParser(lexer.NewLexerWithSource(sourceFile)).ParseProgram()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+
+	var modules, globals, assignments int
+	for _, stmt := range program.Statements {
+		switch node := stmt.(type) {
+		case *AmbientModuleDeclaration:
+			modules++
+			if node.Name.Value == "shorthand" && node.Body != nil {
+				t.Errorf("expected the shorthand module to have no body")
+			}
+		case *GlobalDeclaration:
+			globals++
+		case *ExportAssignment:
+			assignments++
+		}
+	}
+	if modules != 2 || globals != 1 || assignments != 1 {
+		t.Errorf("expected 2 ambient modules, 1 global block and 1 export assignment, got %d, %d and %d", modules, globals, assignments)
+	}
+}
+
+func TestParseInterfaceMemberNames(t *testing.T) {
+	input := `interface Shape {
+	readonly kind: "shape";
+	readonly: boolean;
+	move(dx: number, dy: number): void;
+	compare: (other: Shape, ...rest: Shape[]) => number;
+}`
+	program, errs := NewParser(lexer.NewLexer(input)).ParseProgram()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	decl, ok := program.Statements[0].(*InterfaceDeclaration)
+	if !ok || len(decl.Properties) != 4 {
+		t.Fatalf("expected an interface with 4 members, got %s", program.String())
+	}
+
+	kind, readonly := decl.Properties[0], decl.Properties[1]
+	if kind.Name.Value != "kind" || !kind.Readonly {
+		t.Errorf("expected readonly member 'kind', got %s", kind.String())
+	}
+	if readonly.Name.Value != "readonly" || readonly.Readonly {
+		t.Errorf("expected a member named 'readonly', got %s", readonly.String())
+	}
+
+	expected := [][]string{{"dx", "dy"}, {"other"}}
+	for i, prop := range decl.Properties[2:] {
+		funcType, ok := prop.Type.(*FunctionTypeExpression)
+		if !ok {
+			t.Fatalf("expected a function type for %s, got %T", prop.Name.Value, prop.Type)
+		}
+		if strings.Join(funcType.ParameterNames, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("expected parameter names %q for %s, got %q", expected[i], prop.Name.Value, funcType.ParameterNames)
+		}
+	}
+}
+
+func TestParseUsingDeclarations(t *testing.T) {
+	input := `using a = open(), b = open();
+async function f() {
+	await using c = open();
+	for (using d of items) {}
+}
+using(x);
+let using = 1;
+using = using + 1;`
+	program, errs := NewParser(lexer.NewLexer(input)).ParseProgram()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	if len(program.Statements) != 5 {
+		t.Fatalf("expected 5 statements, got %s", program.String())
+	}
+
+	decl, ok := program.Statements[0].(*ConstStatement)
+	if !ok || !decl.Using || decl.Await || len(decl.Declarations) != 2 {
+		t.Fatalf("expected a using declaration of 2 names, got %s", program.Statements[0].String())
+	}
+	body := program.Statements[1].(*ExpressionStatement).Expression.(*FunctionLiteral).Body.Statements
+	if decl, ok := body[0].(*ConstStatement); !ok || !decl.Using || !decl.Await {
+		t.Errorf("expected an await using declaration, got %s", body[0].String())
+	}
+	forOf, ok := body[1].(*ForOfStatement)
+	if !ok {
+		t.Fatalf("expected a for-of statement, got %s", body[1].String())
+	}
+	if decl, ok := forOf.Variable.(*ConstStatement); !ok || !decl.Using || decl.Name.Value != "d" {
+		t.Errorf("expected a using declaration in the for-of head, got %s", forOf.Variable.String())
+	}
+
+	// `using` is still an identifier everywhere else
+	for _, stmt := range program.Statements[2:] {
+		if decl, ok := stmt.(*ConstStatement); ok && decl.Using {
+			t.Errorf("expected %s not to be a using declaration", stmt.String())
+		}
+	}
+
+	for _, input := range []string{
+		"function f() { await using x = open(); }",
+		"switch (x) { case 1: using y = open(); }",
+		"for (using i = 0; i < 1; i++) {}",
+	} {
+		if _, errs := NewParser(lexer.NewLexer(input)).ParseProgram(); len(errs) == 0 {
+			t.Errorf("expected a parse error for %q", input)
+		}
+	}
+}
+
+func TestParseAccessorFields(t *testing.T) {
+	input := `class C {
+	accessor x = 1;
+	static accessor #y: number;
+	@dec accessor [k] = 2;
+	accessor = 3;
+	accessor() {}
+}`
+	program, errs := NewParser(lexer.NewLexer(input)).ParseProgram()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	class, ok := program.Statements[0].(*ClassDeclaration)
+	if !ok {
+		t.Fatalf("expected a class declaration, got %s", program.Statements[0].String())
+	}
+	props := class.Body.Properties
+	if len(props) != 4 {
+		t.Fatalf("expected 4 properties, got %d", len(props))
+	}
+	for i, want := range []bool{true, true, true, false} {
+		if props[i].IsAccessor != want {
+			t.Errorf("expected property %s to have IsAccessor %v", props[i].String(), want)
+		}
+	}
+	if !props[1].IsStatic || len(props[2].Decorators) != 1 {
+		t.Errorf("expected the modifiers and decorators to be kept, got %s", class.String())
+	}
+
+	// `accessor` is a method name here, not a modifier
+	if len(class.Body.Methods) != 1 {
+		t.Errorf("expected a method named accessor, got %s", class.String())
+	}
+
+	for _, input := range []string{
+		"class C { accessor m() {} }",
+		"class C { accessor x?: number; }",
+		"class C { declare accessor x: number; }",
+	} {
+		if _, errs := NewParser(lexer.NewLexer(input)).ParseProgram(); len(errs) == 0 {
+			t.Errorf("expected a parse error for %q", input)
+		}
+	}
+}
@@ -4,12 +4,23 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+
+	"github.com/nooga/paserati/pkg/lexer"
 )
 
 // JSEmitter is responsible for transforming AST nodes into JavaScript code
 type JSEmitter struct {
 	indentLevel int
 	buffer      bytes.Buffer
+
+	// Source map state: the token of the node about to be written, the
+	// mappings recorded so far and the generated line scanned up to
+	content   string
+	pending   *lexer.Token
+	mappings  []sourceMapping
+	scanned   int
+	line      int
+	lineStart int
 }
 
 // NewJSEmitter creates a new JavaScript emitter
@@ -23,6 +34,12 @@ func NewJSEmitter() *JSEmitter {
 func (e *JSEmitter) Emit(program *Program) string {
 	e.buffer.Reset()
 	e.indentLevel = 0
+	e.content = ""
+	if program.Source != nil {
+		e.content = program.Source.Content
+	}
+	e.pending, e.mappings = nil, nil
+	e.scanned, e.line, e.lineStart = 0, 0, 0
 
 	for _, stmt := range program.Statements {
 		e.emitStatement(stmt)
@@ -31,6 +48,26 @@ func (e *JSEmitter) Emit(program *Program) string {
 	return e.buffer.String()
 }
 
+// EmitWithSourceMap converts a program AST to JavaScript code and a source
+// map from it back to the program's source. file names the generated file
+// in the map.
+func (e *JSEmitter) EmitWithSourceMap(program *Program, file string) (string, *SourceMap) {
+	code := e.Emit(program)
+	sourceMap := &SourceMap{
+		Version:  3,
+		File:     file,
+		Names:    []string{},
+		Mappings: encodeMappings(e.mappings),
+	}
+	if program.Source != nil {
+		sourceMap.Sources = []string{program.Source.DisplayPath()}
+		sourceMap.SourcesContent = []string{program.Source.Content}
+	} else {
+		sourceMap.Sources = []string{""}
+	}
+	return code, sourceMap
+}
+
 // Helper methods
 
 func (e *JSEmitter) indent() {
@@ -51,17 +88,52 @@ func (e *JSEmitter) writeIndent() {
 
 func (e *JSEmitter) writeLine(format string, args ...interface{}) {
 	e.writeIndent()
+	e.flushMark()
 	fmt.Fprintf(&e.buffer, format, args...)
 	e.buffer.WriteString("\n")
 }
 
 func (e *JSEmitter) write(format string, args ...interface{}) {
+	e.flushMark()
 	fmt.Fprintf(&e.buffer, format, args...)
 }
 
+// mark maps the next output to node's position in the source. Of nodes whose
+// output starts at the same place, the innermost is mapped.
+func (e *JSEmitter) mark(node Node) {
+	if tok := GetTokenFromNode(node); tok != nil && tok.Line > 0 {
+		e.pending = tok
+	}
+}
+
+// flushMark records a mapping from the current output position to the
+// pending token, if there is one
+func (e *JSEmitter) flushMark() {
+	if e.pending == nil {
+		return
+	}
+	tok := e.pending
+	e.pending = nil
+
+	out := e.buffer.Bytes()
+	for ; e.scanned < len(out); e.scanned++ {
+		if out[e.scanned] == '\n' {
+			e.line++
+			e.lineStart = e.scanned + 1
+		}
+	}
+	e.mappings = append(e.mappings, sourceMapping{
+		genLine:   e.line,
+		genColumn: utf16Len(string(out[e.lineStart:])),
+		srcLine:   tok.Line - 1,
+		srcColumn: originalColumn(tok, e.content),
+	})
+}
+
 // AST emitter methods
 
 func (e *JSEmitter) emitStatement(stmt Statement) {
+	e.mark(stmt)
 	switch s := stmt.(type) {
 	case *LetStatement:
 		e.emitLetStatement(s)
@@ -123,7 +195,14 @@ func (e *JSEmitter) emitVarStatement(stmt *VarStatement) {
 
 func (e *JSEmitter) emitConstStatement(stmt *ConstStatement) {
 	e.writeIndent()
-	e.write("const %s", stmt.Name.Value)
+	switch {
+	case stmt.Await:
+		e.write("await using %s", stmt.Name.Value)
+	case stmt.Using:
+		e.write("using %s", stmt.Name.Value)
+	default:
+		e.write("const %s", stmt.Name.Value)
+	}
 
 	if stmt.Value != nil {
 		e.write(" = ")
@@ -292,6 +371,7 @@ func (e *JSEmitter) emitSwitchStatement(stmt *SwitchStatement) {
 }
 
 func (e *JSEmitter) emitExpression(expr Expression) {
+	e.mark(expr)
 	switch exp := expr.(type) {
 	case *Identifier:
 		e.write("%s", exp.Value)
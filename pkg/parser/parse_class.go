@@ -147,7 +147,9 @@ func (p *Parser) parseClassDeclaration() Statement {
 // so the checker registers the class type for inheritance/type checking, while the
 // compiler skips code generation for it.
 func (p *Parser) parseDeclareClassStatement() Statement {
+	p.inAmbientContext++
 	classStmt := p.parseClassDeclaration()
+	p.inAmbientContext--
 	if classStmt == nil {
 		return nil
 	}
@@ -370,6 +372,7 @@ func (p *Parser) parseClassBody() *ClassBody {
 		isAbstract := false
 		isOverride := false
 		isAsync := false
+		isAccessor := false
 
 		// Helper to check if current token is likely a field name (not a modifier)
 		// A keyword is a field name if followed by tokens that indicate it's a name, not a modifier
@@ -458,6 +461,10 @@ func (p *Parser) parseClassBody() *ClassBody {
 				isAsync = true
 				seenAsync = true
 				p.nextToken()
+			} else if p.curToken.Type == lexer.IDENT && p.curToken.Literal == "accessor" && !isAccessor && !isFieldName() {
+				// 'accessor' modifier: an auto-accessor field (accessor x = 1)
+				isAccessor = true
+				p.nextToken()
 			} else {
 				break // No more modifiers
 			}
@@ -479,6 +486,7 @@ func (p *Parser) parseClassBody() *ClassBody {
 			}
 		}
 
+		propertyCount, methodCount := len(properties), len(methods)+len(methodSigs)
 		hasNewlineAfterCur := p.peekToken.Line > p.curToken.Line
 		if p.curTokenIs(lexer.GET) && !p.peekTokenIs(lexer.LPAREN) && !hasNewlineAfterCur {
 			// Parse getter method: get propertyName() {}
@@ -652,6 +660,21 @@ func (p *Parser) parseClassBody() *ClassBody {
 			p.addError(p.curToken, "expected identifier, 'get', 'set', or '[' in class body")
 			p.nextToken()
 		}
+
+		if isAccessor {
+			if len(methods)+len(methodSigs) > methodCount {
+				p.addError(p.curToken, "'accessor' modifier can only appear on a property declaration.")
+			}
+			for _, property := range properties[propertyCount:] {
+				property.IsAccessor = true
+				if property.Optional {
+					p.addError(property.Token, "An 'accessor' property cannot be declared optional.")
+				}
+				if isDeclare {
+					p.addError(property.Token, "'accessor' modifier cannot be used with 'declare' modifier.")
+				}
+			}
+		}
 	}
 
 	if !p.curTokenIs(lexer.RBRACE) {
@@ -822,8 +845,8 @@ func (p *Parser) parseMethod(isStatic, isPublic, isPrivate, isProtected, isAbstr
 	}
 
 	// Check if this is a method signature (ends with semicolon) or implementation (has body)
-	// Abstract methods must be signatures (no implementation)
-	if p.peekTokenIs(lexer.SEMICOLON) || isAbstract {
+	// Abstract methods and the methods of ambient classes must be signatures (no implementation)
+	if p.peekTokenIs(lexer.SEMICOLON) || isAbstract || (p.inAmbient() && !p.peekTokenIs(lexer.LBRACE)) {
 		// This is a method signature, not an implementation
 		if p.peekTokenIs(lexer.SEMICOLON) {
 			p.nextToken() // Consume semicolon
@@ -1176,15 +1199,23 @@ func (p *Parser) parseGetter(isStatic, isPublic, isPrivate, isProtected, isOverr
 		}
 	}
 
-	// Parse body - after parseFunctionParameters we should be at ')' with peek being '{'
-	if !p.expectPeek(lexer.LBRACE) {
-		return nil
-	}
+	// Ambient classes declare accessors without a body: get size(): number;
+	if p.inAmbient() && !p.peekTokenIs(lexer.LBRACE) {
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		p.nextToken()
+	} else {
+		// Parse body - after parseFunctionParameters we should be at ')' with peek being '{'
+		if !p.expectPeek(lexer.LBRACE) {
+			return nil
+		}
 
-	functionLiteral.Body = p.parseBlockStatement()
+		functionLiteral.Body = p.parseBlockStatement()
 
-	// parseBlockStatement leaves us at '}', advance past it
-	p.nextToken()
+		// parseBlockStatement leaves us at '}', advance past it
+		p.nextToken()
+	}
 
 	return &MethodDefinition{
 		Token:       getToken,
@@ -1264,15 +1295,23 @@ func (p *Parser) parseSetter(isStatic, isPublic, isPrivate, isProtected, isOverr
 		return nil
 	}
 
-	// Parse body - after parseFunctionParameters we should be at ')' with peek being '{'
-	if !p.expectPeek(lexer.LBRACE) {
-		return nil
-	}
+	// Ambient classes declare accessors without a body: get size(): number;
+	if p.inAmbient() && !p.peekTokenIs(lexer.LBRACE) {
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		p.nextToken()
+	} else {
+		// Parse body - after parseFunctionParameters we should be at ')' with peek being '{'
+		if !p.expectPeek(lexer.LBRACE) {
+			return nil
+		}
 
-	functionLiteral.Body = p.parseBlockStatement()
+		functionLiteral.Body = p.parseBlockStatement()
 
-	// parseBlockStatement leaves us at '}', advance past it
-	p.nextToken()
+		// parseBlockStatement leaves us at '}', advance past it
+		p.nextToken()
+	}
 
 	// Transform destructuring parameters
 	functionLiteral = p.transformFunctionWithDestructuring(functionLiteral)
@@ -1424,8 +1463,8 @@ func (p *Parser) parseComputedMethod(bracketToken *lexer.Token, keyExpr Expressi
 	}
 
 	// Check if this is a method signature (ends with semicolon) or implementation (has body)
-	// Abstract methods must be signatures (no implementation)
-	if p.peekTokenIs(lexer.SEMICOLON) || isAbstract {
+	// Abstract methods and the methods of ambient classes must be signatures (no implementation)
+	if p.peekTokenIs(lexer.SEMICOLON) || isAbstract || (p.inAmbient() && !p.peekTokenIs(lexer.LBRACE)) {
 		// This is a method signature, not an implementation
 		if p.peekTokenIs(lexer.SEMICOLON) {
 			p.nextToken() // Consume semicolon
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/lexer"
+)
+
+// IsDeclarationFile reports whether path names a declaration file (.d.ts,
+// .d.mts or .d.cts), which holds types only
+func IsDeclarationFile(path string) bool {
+	return strings.HasSuffix(path, ".d.ts") || strings.HasSuffix(path, ".d.mts") || strings.HasSuffix(path, ".d.cts")
+}
+
+// inAmbient reports whether declarations being parsed are ambient: in a
+// declaration file or in the body of a `declare` namespace or module
+func (p *Parser) inAmbient() bool {
+	return p.inAmbientContext > 0 || p.declarationFile
+}
+
+// ReferenceDirective is a triple-slash directive at the top of a file:
+//
+//	/// <reference path="./globals.d.ts" />
+//	/// <reference types="node" />
+//	/// <reference lib="es2022" />
+type ReferenceDirective struct {
+	Kind  string // "path", "types" or "lib"
+	Value string
+	Line  int
+}
+
+var referenceDirectivePattern = regexp.MustCompile(`^///\s*<reference\s+(path|types|lib)\s*=\s*["']([^"']*)["']`)
+
+// ParseReferenceDirectives returns the triple-slash reference directives of
+// source. Like tsc, it only looks at the comments before the first
+// statement.
+func ParseReferenceDirectives(source string) []ReferenceDirective {
+	var directives []ReferenceDirective
+	inBlockComment := false
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if inBlockComment {
+			if _, rest, ok := strings.Cut(line, "*/"); ok {
+				inBlockComment = false
+				if strings.TrimSpace(rest) != "" {
+					break
+				}
+			}
+			continue
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#!"):
+		case strings.HasPrefix(line, "///"):
+			if match := referenceDirectivePattern.FindStringSubmatch(line); match != nil {
+				directives = append(directives, ReferenceDirective{Kind: match[1], Value: match[2], Line: i + 1})
+			}
+		case strings.HasPrefix(line, "//"):
+		case strings.HasPrefix(line, "/*"):
+			inBlockComment = !strings.Contains(line[2:], "*/")
+		default:
+			return directives
+		}
+	}
+	return directives
+}
+
+// parseAmbientModuleDeclaration parses the declarations of a module's or the
+// global scope's types:
+//
+//	declare module "name" { ... }
+//	declare module "name";
+//	declare module A.B { ... }  (the old spelling of a namespace)
+//	declare global { ... }
+//
+// On entry curToken is `declare` and peekToken is `module` or `global`. On
+// exit curToken is the closing `}` of the body, or the `;` of the shorthand.
+func (p *Parser) parseAmbientModuleDeclaration() Statement {
+	declareToken := p.curToken
+	p.nextToken() // 'module' or 'global'
+	keyword := p.curToken
+
+	if keyword.Literal == "module" && !p.peekTokenIs(lexer.STRING) {
+		ns := p.parseNamespaceDeclaration(true)
+		if ns == nil {
+			return nil
+		}
+		return ns
+	}
+
+	if p.inAmbientContext > 0 {
+		p.addError(declareToken, "A 'declare' modifier cannot be used in an already ambient context.")
+	}
+
+	if keyword.Literal == "global" {
+		if !p.expectPeek(lexer.LBRACE) {
+			return nil
+		}
+		body := p.parseAmbientBody()
+		if body == nil {
+			return nil
+		}
+		return &GlobalDeclaration{Token: keyword, Body: body}
+	}
+
+	p.nextToken() // the module name
+	decl := &AmbientModuleDeclaration{
+		Token: keyword,
+		Name:  &StringLiteral{Token: p.curToken, Value: p.curToken.Literal},
+	}
+	if !p.peekTokenIs(lexer.LBRACE) {
+		// Shorthand: declare module "name";
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		return decl
+	}
+	p.nextToken() // '{'
+	decl.Body = p.parseAmbientBody()
+	if decl.Body == nil {
+		return nil
+	}
+	return decl
+}
+
+// parseAmbientBody parses the block at curToken as an ambient context
+func (p *Parser) parseAmbientBody() *BlockStatement {
+	p.inAmbientContext++
+	defer func() { p.inAmbientContext-- }()
+	return p.parseBlockStatement()
+}
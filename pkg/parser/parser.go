@@ -43,10 +43,11 @@ type Parser struct {
 	typeInfixParseFns  map[lexer.TokenType]infixParseFn  // Handles type operators (e.g., |, &)
 
 	// Context tracking
-	inGenerator        int // Counter for nested generator contexts (0 = not in generator)
-	inAsyncFunction    int // Counter for nested async function contexts (0 = not in async function)
-	inNonAsyncFunction int // Counter for nested non-async function contexts (for await-as-identifier)
-	inAmbientContext   int // Counter for nested ambient contexts (declare namespace / declare module bodies)
+	inGenerator        int  // Counter for nested generator contexts (0 = not in generator)
+	inAsyncFunction    int  // Counter for nested async function contexts (0 = not in async function)
+	inNonAsyncFunction int  // Counter for nested non-async function contexts (for await-as-identifier)
+	inAmbientContext   int  // Counter for nested ambient contexts (declare namespace / declare module bodies)
+	declarationFile    bool // Parsing a .d.ts file, whose declarations are all ambient
 
 	// Eval context flags
 	disallowSuper bool // When true, super expressions throw SyntaxError (for indirect eval)
@@ -210,6 +211,7 @@ func NewParser(l *lexer.Lexer) *Parser {
 		arena:     NewASTArena(),  // Initialize arena for AST node allocation
 		tokenPool: NewTokenPool(), // Initialize pool for *lexer.Token storage
 	}
+	p.declarationFile = p.source != nil && IsDeclarationFile(p.source.DisplayPath())
 
 	// Initialize Pratt parser maps for VALUE expressions
 	p.prefixParseFns = make(map[lexer.TokenType]prefixParseFn)
@@ -565,6 +567,11 @@ func (p *Parser) ParseProgram() (*Program, []errors.PaseratiError) {
 
 func (p *Parser) parseStatement() Statement {
 	debugPrint("parseStatement: cur='%s' (%s), peek='%s' (%s)", p.curToken.Literal, p.curToken.Type, p.peekToken.Literal, p.peekToken.Type)
+	if p.inAmbient() && (p.curTokenIs(lexer.CONST) || p.curTokenIs(lexer.VAR) ||
+		(p.curTokenIs(lexer.LET) && p.peekTokenIs(lexer.IDENT))) {
+		// Variables of ambient bodies and declaration files are declarations only
+		return p.parseDeclareVarStatement()
+	}
 	switch p.curToken.Type {
 	case lexer.LET:
 		// Check if this is actually a let declaration or just 'let' as an identifier
@@ -718,14 +725,17 @@ func (p *Parser) parseStatement() Statement {
 					p.nextToken() // move to 'namespace'
 					return p.parseNamespaceDeclaration(true)
 				}
-				// declare module/global with empty body — skip silently.
-				// Only skip empty bodies to avoid suppressing TS1038 errors inside non-empty bodies.
-				if (p.peekToken.Literal == "module" || p.peekToken.Literal == "global") &&
-					p.isEmptyDeclareNamespace() {
-					return p.parseDeclareStatement()
+				// declare module "x" { ... } / declare global { ... } — ambient declarations
+				if (p.peekToken.Literal == "module" && (p.lookAhead(1).Type == lexer.STRING || p.lookAhead(1).Type == lexer.IDENT)) ||
+					(p.peekToken.Literal == "global" && p.lookAhead(1).Type == lexer.LBRACE) {
+					return p.parseAmbientModuleDeclaration()
 				}
 			}
 		}
+		// `using x = ...` — explicit resource management (contextual keyword).
+		if p.isUsingDeclaration() {
+			return p.parseUsingStatement()
+		}
 		// `namespace X { ... }` — TypeScript namespace declaration (contextual keyword).
 		if p.curToken.Literal == "namespace" &&
 			(p.peekTokenIs(lexer.IDENT) || p.isKeywordThatCanBeIdentifier(p.peekToken.Type)) {
@@ -747,6 +757,9 @@ func (p *Parser) parseStatement() Statement {
 		if p.peekTokenIs(lexer.COLON) && p.inAsyncFunction == 0 {
 			return p.parseLabeledStatement()
 		}
+		if p.isUsingDeclaration() {
+			return p.parseUsingStatement()
+		}
 		return p.parseExpressionStatement()
 	case lexer.ILLEGAL:
 		// Handle ILLEGAL tokens by adding error and advancing
@@ -911,6 +924,18 @@ func (p *Parser) parseDeclareStatement() Statement {
 	return p.skipDeclareBody()
 }
 
+// parseDeclareInitializer parses the literal initializer an ambient
+// declaration may give a const (`declare const version = "1.0";`), which
+// types it but is never evaluated
+func (p *Parser) parseDeclareInitializer(declarator *VarDeclarator) {
+	if !p.peekTokenIs(lexer.ASSIGN) {
+		return
+	}
+	p.nextToken() // Consume '='
+	p.nextToken() // Move to the expression
+	declarator.Value = p.parseExpression(COMMA)
+}
+
 // parseDeclareVarStatement parses `declare const/let/var name: Type;`
 // It creates a proper AST node with Declare=true so the checker registers
 // the type but the compiler skips code generation.
@@ -934,6 +959,7 @@ func (p *Parser) parseDeclareVarStatement() Statement {
 		p.nextToken() // Move to type expression start
 		declarator.TypeAnnotation = p.parseTypeExpression()
 	}
+	p.parseDeclareInitializer(declarator)
 
 	declarations := []*VarDeclarator{declarator}
 
@@ -952,6 +978,7 @@ func (p *Parser) parseDeclareVarStatement() Statement {
 			p.nextToken()
 			d.TypeAnnotation = p.parseTypeExpression()
 		}
+		p.parseDeclareInitializer(d)
 		declarations = append(declarations, d)
 	}
 
@@ -967,6 +994,7 @@ func (p *Parser) parseDeclareVarStatement() Statement {
 		if len(declarations) > 0 {
 			stmt.Name = declarations[0].Name
 			stmt.TypeAnnotation = declarations[0].TypeAnnotation
+			stmt.Value = declarations[0].Value
 		}
 		return stmt
 	case lexer.LET:
@@ -974,6 +1002,7 @@ func (p *Parser) parseDeclareVarStatement() Statement {
 		if len(declarations) > 0 {
 			stmt.Name = declarations[0].Name
 			stmt.TypeAnnotation = declarations[0].TypeAnnotation
+			stmt.Value = declarations[0].Value
 		}
 		return stmt
 	case lexer.VAR:
@@ -981,74 +1010,13 @@ func (p *Parser) parseDeclareVarStatement() Statement {
 		if len(declarations) > 0 {
 			stmt.Name = declarations[0].Name
 			stmt.TypeAnnotation = declarations[0].TypeAnnotation
+			stmt.Value = declarations[0].Value
 		}
 		return stmt
 	}
 	return nil
 }
 
-// isEmptyDeclareNamespace checks via lookahead whether we're at a 'declare namespace/module X {}'
-// with an empty body. Called when curToken='declare', peek='namespace'/'module'/'global'.
-// e.g. declare module "Foo" {}, declare namespace M {} — returns true.
-// e.g. declare namespace M { function foo(); } — returns false.
-func (p *Parser) isEmptyDeclareNamespace() bool {
-	// pos=0 is peek = "namespace"/"module"/"global"
-	// pos=1 is the name (IDENT or STRING)
-	name := p.lookAhead(1)
-	if name.Type != lexer.IDENT && name.Type != lexer.STRING && !p.isKeywordThatCanBeIdentifier(name.Type) {
-		return false
-	}
-	// Handle dotted names: skip additional IDENT . IDENT segments
-	pos := 2
-	for {
-		next := p.lookAhead(pos)
-		if next.Type == lexer.DOT {
-			pos++
-			component := p.lookAhead(pos)
-			if component.Type != lexer.IDENT && !p.isKeywordThatCanBeIdentifier(component.Type) {
-				return false
-			}
-			pos++
-			continue
-		}
-		break
-	}
-	// pos now points to what should be '{'
-	openBrace := p.lookAhead(pos)
-	if openBrace.Type != lexer.LBRACE {
-		return false
-	}
-	// Scan the body with brace depth tracking.
-	// Skip if empty OR if body contains only ambient function/type declarations.
-	// Don't skip if body has: declare (TS1038), export (TS2304), interface (TS1036), class —
-	// those tests need errors preserved via random-error LOOSE MODE.
-	depth := 1
-	scan := pos + 1
-	for depth > 0 {
-		tok := p.lookAhead(scan)
-		if tok.Type == lexer.EOF {
-			return false
-		}
-		switch tok.Type {
-		case lexer.LBRACE:
-			depth++
-		case lexer.RBRACE:
-			depth--
-		default:
-			if depth == 1 {
-				if tok.Type == lexer.EXPORT || tok.Type == lexer.CLASS || tok.Type == lexer.INTERFACE {
-					return false
-				}
-				if tok.Type == lexer.IDENT && tok.Literal == "declare" {
-					return false
-				}
-			}
-		}
-		scan++
-	}
-	return true
-}
-
 // isEmptyNamespaceDeclaration checks via lookahead whether the current 'namespace' token
 // is followed by a dotted name and then a body that contains ONLY other namespace declarations
 // (including nested declare namespace / namespace blocks).
@@ -1251,7 +1219,7 @@ func (p *Parser) parseFunctionTypeExpression() Expression {
 
 	// Try to parse as function type parameter list
 	var parseErr error
-	params, restParam, parseErr := p.parseFunctionTypeParameterList()
+	params, names, restParam, parseErr := p.parseFunctionTypeParameterList()
 	if parseErr != nil {
 		// Error already added by helper
 		return nil
@@ -1262,6 +1230,7 @@ func (p *Parser) parseFunctionTypeExpression() Expression {
 		// This is a function type: (params) => returnType
 		funcType := &FunctionTypeExpression{Token: startToken}
 		funcType.Parameters = params
+		funcType.ParameterNames = names
 		funcType.RestParameter = restParam
 
 		p.nextToken() // Consume '=>'
@@ -1289,22 +1258,23 @@ func (p *Parser) parseFunctionTypeExpression() Expression {
 
 // --- NEW: Helper for parsing function type parameter list: (), (T1), (name: T1, T2) ---
 // This function should also correctly use parseTypeExpression internally.
-func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, error) {
+func (p *Parser) parseFunctionTypeParameterList() ([]Expression, []string, Expression, error) {
 	// ... existing implementation looks okay, relies on parseTypeExpression calls ...
 	params := []Expression{}
+	var names []string // Parameter names, "" for parameters given only a type
 	var restParam Expression
 
 	if !p.curTokenIs(lexer.LPAREN) {
 		// Should not happen if called correctly
 		msg := fmt.Sprintf("internal parser error: parseFunctionTypeParameterList called without LPAREN, got %s", p.curToken.Type)
 		p.addError(p.curToken, msg)
-		return nil, nil, fmt.Errorf("%s", msg)
+		return nil, nil, nil, fmt.Errorf("%s", msg)
 	}
 
 	// Handle empty parameter list: () => ...
 	if p.peekTokenIs(lexer.RPAREN) {
 		p.nextToken() // Consume ')'
-		return params, nil, nil
+		return params, names, nil, nil
 	}
 
 	// Parse first parameter type
@@ -1315,16 +1285,16 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 		// This is a rest parameter: ...type
 		restParam = p.parseRestParameterType()
 		if restParam == nil {
-			return nil, nil, fmt.Errorf("failed to parse rest parameter type")
+			return nil, nil, nil, fmt.Errorf("failed to parse rest parameter type")
 		}
 		// Expect closing parenthesis after rest parameter
 		if p.curTokenIs(lexer.RPAREN) {
-			return params, restParam, nil
+			return params, names, restParam, nil
 		}
 		if !p.expectPeek(lexer.RPAREN) {
-			return nil, nil, fmt.Errorf("missing closing parenthesis after rest parameter")
+			return nil, nil, nil, fmt.Errorf("missing closing parenthesis after rest parameter")
 		}
-		return params, restParam, nil
+		return params, names, restParam, nil
 	}
 
 	// --- MODIFIED: Handle optional parameter name and 'this' parameter ---
@@ -1339,19 +1309,23 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 		} else {
 			// 'this' was the only parameter
 			if !p.expectPeek(lexer.RPAREN) {
-				return nil, nil, fmt.Errorf("missing closing parenthesis")
+				return nil, nil, nil, fmt.Errorf("missing closing parenthesis")
 			}
-			return params, restParam, nil
+			return params, names, restParam, nil
 		}
 	}
 	parsedFirstParam := false
+	name := ""
 	if p.curTokenIs(lexer.IDENT) {
+		if p.peekTokenIs(lexer.QUESTION) || p.peekTokenIs(lexer.COLON) {
+			name = p.curToken.Literal
+		}
 		if p.peekTokenIs(lexer.QUESTION) {
 			// Optional parameter: name?: type
 			p.nextToken() // Consume IDENT
 			p.nextToken() // Consume '?'
 			if !p.curTokenIs(lexer.COLON) {
-				return nil, nil, fmt.Errorf("expected ':' after '?' in optional parameter")
+				return nil, nil, nil, fmt.Errorf("expected ':' after '?' in optional parameter")
 			}
 			p.nextToken() // Move to the actual type
 		} else if p.peekTokenIs(lexer.COLON) {
@@ -1359,6 +1333,7 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 			p.nextToken() // Consume IDENT
 			p.nextToken() // Consume ':', move to the actual type
 		} else if p.peekTokenIs(lexer.COMMA) || p.peekTokenIs(lexer.RPAREN) {
+			names = append(names, p.curToken.Literal)
 			params = append(params, &Identifier{
 				Token: &lexer.Token{Type: lexer.IDENT, Literal: "any"},
 				Value: "any",
@@ -1372,8 +1347,9 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 	if !parsedFirstParam {
 		paramType := p.parseTypeExpression() // This call will use the updated recursive function
 		if paramType == nil {
-			return nil, nil, fmt.Errorf("failed to parse first function type parameter")
+			return nil, nil, nil, fmt.Errorf("failed to parse first function type parameter")
 		}
+		names = append(names, name)
 		params = append(params, paramType)
 	}
 
@@ -1386,7 +1362,7 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 		if p.curTokenIs(lexer.RPAREN) {
 			// This is a trailing comma, we're already at the closing paren
 			// Just return without expecting another RPAREN
-			return params, restParam, nil
+			return params, names, restParam, nil
 		}
 
 		// Check for rest parameter
@@ -1394,27 +1370,31 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 			// This is a rest parameter: ...type
 			restParam = p.parseRestParameterType()
 			if restParam == nil {
-				return nil, nil, fmt.Errorf("failed to parse rest parameter type")
+				return nil, nil, nil, fmt.Errorf("failed to parse rest parameter type")
 			}
 			// Expect closing parenthesis after rest parameter
 			if p.curTokenIs(lexer.RPAREN) {
-				return params, restParam, nil
+				return params, names, restParam, nil
 			}
 			if !p.expectPeek(lexer.RPAREN) {
-				return nil, nil, fmt.Errorf("missing closing parenthesis after rest parameter")
+				return nil, nil, nil, fmt.Errorf("missing closing parenthesis after rest parameter")
 			}
-			return params, restParam, nil
+			return params, names, restParam, nil
 		}
 
 		// --- MODIFIED: Handle optional parameter name ---
 		parsedParam := false
+		name := ""
 		if p.curTokenIs(lexer.IDENT) {
+			if p.peekTokenIs(lexer.QUESTION) || p.peekTokenIs(lexer.COLON) {
+				name = p.curToken.Literal
+			}
 			if p.peekTokenIs(lexer.QUESTION) {
 				// Optional parameter: name?: type
 				p.nextToken() // Consume IDENT
 				p.nextToken() // Consume '?'
 				if !p.curTokenIs(lexer.COLON) {
-					return nil, nil, fmt.Errorf("expected ':' after '?' in optional parameter")
+					return nil, nil, nil, fmt.Errorf("expected ':' after '?' in optional parameter")
 				}
 				p.nextToken() // Move to the actual type
 			} else if p.peekTokenIs(lexer.COLON) {
@@ -1422,6 +1402,7 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 				p.nextToken() // Consume IDENT
 				p.nextToken() // Consume ':', move to the actual type
 			} else if p.peekTokenIs(lexer.COMMA) || p.peekTokenIs(lexer.RPAREN) {
+				names = append(names, p.curToken.Literal)
 				params = append(params, &Identifier{
 					Token: &lexer.Token{Type: lexer.IDENT, Literal: "any"},
 					Value: "any",
@@ -1438,17 +1419,18 @@ func (p *Parser) parseFunctionTypeParameterList() ([]Expression, Expression, err
 
 		paramType := p.parseTypeExpression() // This call will use the updated recursive function
 		if paramType == nil {
-			return nil, nil, fmt.Errorf("failed to parse subsequent function type parameter")
+			return nil, nil, nil, fmt.Errorf("failed to parse subsequent function type parameter")
 		}
+		names = append(names, name)
 		params = append(params, paramType)
 	}
 
 	// Expect closing parenthesis
 	if !p.expectPeek(lexer.RPAREN) {
-		return nil, nil, fmt.Errorf("missing closing parenthesis in function type parameter list")
+		return nil, nil, nil, fmt.Errorf("missing closing parenthesis in function type parameter list")
 	}
 
-	return params, restParam, nil
+	return params, names, restParam, nil
 }
 
 // parseRestParameterType parses a rest parameter type like ...args: string[]
@@ -1949,6 +1931,43 @@ func (p *Parser) parseConstStatement() Statement {
 	}
 }
 
+// isUsingDeclaration reports whether the current token starts a `using` or
+// `await using` declaration: the contextual keyword followed, on the same
+// line, by the name it binds. `using [x]` and `using\nx` stay expressions.
+func (p *Parser) isUsingDeclaration() bool {
+	if p.curTokenIs(lexer.IDENT) && p.curToken.Literal == "using" {
+		return p.peekTokenIs(lexer.IDENT) && p.peekToken.Line == p.curToken.Line
+	}
+	if p.curTokenIs(lexer.AWAIT) && p.peekTokenIs(lexer.IDENT) && p.peekToken.Literal == "using" &&
+		p.peekToken.Line == p.curToken.Line {
+		next := p.lookAhead(1)
+		return next.Type == lexer.IDENT && next.Line == p.peekToken.Line
+	}
+	return false
+}
+
+// parseUsingStatement parses `using x = e` and `await using x = e`. They
+// are const declarations whose values are disposed of, in reverse order,
+// when the enclosing block, function or module is left.
+func (p *Parser) parseUsingStatement() Statement {
+	startToken := p.curToken
+	isAwait := p.curTokenIs(lexer.AWAIT)
+	if isAwait {
+		if p.inNonAsyncFunction > 0 && p.inAsyncFunction == 0 {
+			p.addError(p.curToken, "'await using' is only allowed in async functions and at the top level of modules")
+		}
+		p.nextToken() // Move to 'using'
+	}
+	stmt, ok := p.parseConstStatement().(*ConstStatement)
+	if !ok || stmt == nil {
+		return nil
+	}
+	stmt.Token = startToken
+	stmt.Using = true
+	stmt.Await = isAwait
+	return stmt
+}
+
 func (p *Parser) parseVarStatement() Statement {
 	varToken := p.curToken // Save the 'var' token
 
@@ -2862,9 +2881,12 @@ func (p *Parser) parseFunctionLiteral(isAsync bool) Expression {
 	}
 
 	// Check if this is a function signature (ends with semicolon) or implementation (has body)
-	if p.peekTokenIs(lexer.SEMICOLON) {
+	// In ambient contexts the semicolon is optional
+	if p.peekTokenIs(lexer.SEMICOLON) || (p.inAmbient() && !p.peekTokenIs(lexer.LBRACE)) {
 		// This is a function signature, not an implementation - return FunctionSignature instead
-		p.nextToken() // Consume semicolon
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken() // Consume semicolon
+		}
 
 		sig := &FunctionSignature{
 			Token:                lit.Token,
@@ -2873,6 +2895,7 @@ func (p *Parser) parseFunctionLiteral(isAsync bool) Expression {
 			Parameters:           lit.Parameters,
 			RestParameter:        lit.RestParameter,
 			ReturnTypeAnnotation: lit.ReturnTypeAnnotation,
+			Declare:              p.inAmbient(),
 		}
 		p.inGenerator = savedGeneratorContext // Restore for signature
 		return sig
@@ -6304,6 +6327,11 @@ func (p *Parser) parseForStatement() Statement {
 		// Save position, advance to check what follows
 		p.nextToken() // Now at IDENT or contextual keyword
 
+		// for (using x of items) / for (await using x of items)
+		if p.isUsingDeclaration() {
+			return p.parseForStatementOrForOf(forToken, isAsync)
+		}
+
 		// Check for member expression patterns: obj.x, obj[x], this.x
 		// BUT NOT for 'await [' which is an await expression, not member access
 		if (p.peekTokenIs(lexer.DOT) || p.peekTokenIs(lexer.LBRACKET)) && !p.curTokenIs(lexer.AWAIT) {
@@ -6811,6 +6839,9 @@ func (p *Parser) parseSwitchCase() *SwitchCase {
 	// Similar loop logic as parseBlockStatement
 	for !p.curTokenIs(lexer.CASE) && !p.curTokenIs(lexer.DEFAULT) && !p.curTokenIs(lexer.RBRACE) && !p.curTokenIs(lexer.EOF) {
 		stmt := p.parseStatement() // parseStatement consumes tokens including optional semicolon
+		if constStmt, ok := stmt.(*ConstStatement); ok && constStmt.Using {
+			p.addError(constStmt.Token, "'using' declarations are not allowed directly in case clauses; wrap them in a block")
+		}
 		if stmt != nil {
 			caseClause.Body.Statements = append(caseClause.Body.Statements, stmt)
 
@@ -6857,6 +6888,11 @@ func (p *Parser) parseTypeIdentifier() Expression {
 		return nil
 	}
 
+	// `unique symbol` is typed as plain symbol
+	if p.curToken.Literal == "unique" && p.peekTokenIs(lexer.IDENT) && p.peekToken.Literal == "symbol" {
+		p.nextToken()
+	}
+
 	// Save the identifier
 	ident := &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
@@ -7982,9 +8018,23 @@ func (p *Parser) parseInterfaceProperty() *InterfaceProperty {
 		}
 	}
 
+	// 'readonly' is a modifier unless it is itself the member name
+	isReadonly := false
+	if p.curTokenIs(lexer.READONLY) && !p.peekTokenIs(lexer.COLON) && !p.peekTokenIs(lexer.QUESTION) &&
+		!p.peekTokenIs(lexer.LPAREN) && !p.peekTokenIs(lexer.LT) &&
+		!p.peekTokenIs(lexer.SEMICOLON) && !p.peekTokenIs(lexer.COMMA) && !p.peekTokenIs(lexer.RBRACE) &&
+		p.peekToken.Line == p.curToken.Line {
+		isReadonly = true
+		p.nextToken()
+	}
+
 	// Check for index signature or computed property: [...]
 	if p.curTokenIs(lexer.LBRACKET) {
-		return p.parseInterfaceBracketProperty()
+		prop := p.parseInterfaceBracketProperty()
+		if prop != nil {
+			prop.Readonly = isReadonly
+		}
+		return prop
 	}
 
 	// Check for shorthand method syntax first (identifier, keyword, or string literal as property name)
@@ -7993,7 +8043,8 @@ func (p *Parser) parseInterfaceProperty() *InterfaceProperty {
 
 	if propName != nil {
 		prop = &InterfaceProperty{
-			Name: propName,
+			Name:     propName,
+			Readonly: isReadonly,
 		}
 	} else if p.curTokenIs(lexer.STRING) {
 		// Handle string literal property names
@@ -8001,12 +8052,14 @@ func (p *Parser) parseInterfaceProperty() *InterfaceProperty {
 		prop = &InterfaceProperty{
 			ComputedName:       stringLit,
 			IsComputedProperty: true,
+			Readonly:           isReadonly,
 		}
 	} else if p.curTokenIs(lexer.NUMBER) {
 		// Handle numeric literal property names like { 0: string; 1: number }
 		numIdent := &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		prop = &InterfaceProperty{
-			Name: numIdent,
+			Name:     numIdent,
+			Readonly: isReadonly,
 		}
 	} else {
 		p.addError(p.curToken, "expected property name (identifier or string literal) or call signature '(' in interface")
@@ -8313,12 +8366,13 @@ func (p *Parser) parseConstructorTypeExpression() Expression {
 	}
 
 	// Parse parameter types (similar to function type parameters)
-	params, restParam, err := p.parseFunctionTypeParameterList()
+	params, names, restParam, err := p.parseFunctionTypeParameterList()
 	if err != nil {
 		p.addError(p.curToken, err.Error())
 		return nil
 	}
 	cte.Parameters = params
+	cte.ParameterNames = names
 	cte.RestParameter = restParam
 
 	// Expect '=>' for return type (constructor types use arrow syntax)
@@ -8368,12 +8422,13 @@ func (p *Parser) parseInterfaceConstructorSignature() Expression {
 	}
 
 	// Parse parameter types (similar to function type parameters)
-	params, restParam, err := p.parseFunctionTypeParameterList()
+	params, names, restParam, err := p.parseFunctionTypeParameterList()
 	if err != nil {
 		p.addError(p.curToken, err.Error())
 		return nil
 	}
 	cte.Parameters = params
+	cte.ParameterNames = names
 	cte.RestParameter = restParam
 
 	// Return type is optional: `new (params): T` or just `new (params)`.
@@ -9089,7 +9144,24 @@ func (p *Parser) parseForStatementOrForOf(forToken *lexer.Token, isAsync bool) S
 	var varStmt Statement
 	var varName string
 
-	if p.curTokenIs(lexer.LET) {
+	if p.isUsingDeclaration() {
+		// using x / await using x: disposed of at the end of each iteration
+		constStmt := &ConstStatement{Token: p.curToken, Using: true, Await: p.curTokenIs(lexer.AWAIT)}
+		if constStmt.Await {
+			p.nextToken() // Move to 'using'
+		}
+		p.nextToken() // Move to the name
+		declarator := &VarDeclarator{}
+		declarator.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		constStmt.Declarations = []*VarDeclarator{declarator}
+		constStmt.Name = declarator.Name
+		if !p.peekTokenIs(lexer.OF) {
+			p.addError(p.peekToken, "'using' declarations in for loops are only supported in for-of heads")
+			return nil
+		}
+		varStmt = constStmt
+		varName = p.curToken.Literal
+	} else if p.curTokenIs(lexer.LET) {
 		// In non-strict mode, 'let' is an identifier when followed by 'in' or 'of'
 		// Per ECMAScript spec: lookahead ∉ { let [ }
 		// So "for (let in obj)" treats 'let' as an identifier, not a declaration
@@ -9712,6 +9784,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 
 	// Parse parameter list (similar to parseFunctionTypeParameterList)
 	params := []Expression{}
+	names := []string{}
 	optionalParams := []bool{}
 	var restParam Expression
 
@@ -9733,7 +9806,11 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 		} else {
 			// Handle optional parameter name with potential '?' token
 			parsedFirstParam := false
+			name := ""
 			if p.curTokenIs(lexer.IDENT) {
+				if p.peekTokenIs(lexer.QUESTION) || p.peekTokenIs(lexer.COLON) {
+					name = p.curToken.Literal
+				}
 				if p.peekTokenIs(lexer.QUESTION) {
 					// Optional parameter: name?: type
 					p.nextToken() // Consume IDENT
@@ -9751,6 +9828,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 					p.nextToken() // Consume ':', move to the actual type
 					optionalParams = append(optionalParams, false)
 				} else if p.peekTokenIs(lexer.COMMA) || p.peekTokenIs(lexer.RPAREN) {
+					names = append(names, p.curToken.Literal)
 					params = append(params, &Identifier{
 						Token: &lexer.Token{Type: lexer.IDENT, Literal: "any"},
 						Value: "any",
@@ -9766,6 +9844,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 				if paramType == nil {
 					return nil
 				}
+				names = append(names, name)
 				params = append(params, paramType)
 				if len(optionalParams) < len(params) {
 					optionalParams = append(optionalParams, false)
@@ -9797,7 +9876,11 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 
 				// Handle optional parameter name with potential '?' token
 				parsedParam := false
+				name := ""
 				if p.curTokenIs(lexer.IDENT) {
+					if p.peekTokenIs(lexer.QUESTION) || p.peekTokenIs(lexer.COLON) {
+						name = p.curToken.Literal
+					}
 					if p.peekTokenIs(lexer.QUESTION) {
 						// Optional parameter: name?: type
 						p.nextToken() // Consume IDENT
@@ -9815,6 +9898,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 						p.nextToken() // Consume ':', move to the actual type
 						optionalParams = append(optionalParams, false)
 					} else if p.peekTokenIs(lexer.COMMA) || p.peekTokenIs(lexer.RPAREN) {
+						names = append(names, p.curToken.Literal)
 						params = append(params, &Identifier{
 							Token: &lexer.Token{Type: lexer.IDENT, Literal: "any"},
 							Value: "any",
@@ -9833,6 +9917,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 				if paramType == nil {
 					return nil
 				}
+				names = append(names, name)
 				params = append(params, paramType)
 				if len(optionalParams) < len(params) {
 					optionalParams = append(optionalParams, false)
@@ -9862,6 +9947,7 @@ func (p *Parser) parseMethodTypeSignature() Expression {
 	funcType := &FunctionTypeExpression{
 		Token:          &lexer.Token{Type: lexer.LPAREN, Literal: "("},
 		Parameters:     params,
+		ParameterNames: names,
 		OptionalParams: optionalParams,
 		RestParameter:  restParam,
 		ReturnType:     returnType,
@@ -9949,6 +10035,14 @@ func GetTokenFromNode(node Node) *lexer.Token {
 		return n.Token // The '?' token
 	case *CallExpression:
 		return n.Token // The '(' token
+	case *ThrowStatement:
+		return n.Token // The 'throw' token
+	case *AwaitExpression:
+		return n.Token // The 'await' token
+	case *YieldExpression:
+		return n.Token // The 'yield' token
+	case *TaggedTemplateExpression:
+		return n.Token // The tag's first token
 	case *NewExpression:
 		return n.Token // The 'new' token
 	case *IndexExpression:
@@ -10216,7 +10310,7 @@ func (p *Parser) parseGenericFunctionTypeExpression() Expression {
 	}
 
 	// Parse function type parameters (for type annotations)
-	params, restParam, parseErr := p.parseFunctionTypeParameterList()
+	params, names, restParam, parseErr := p.parseFunctionTypeParameterList()
 	if parseErr != nil {
 		p.addError(p.curToken, fmt.Sprintf("failed to parse function type parameters: %v", parseErr))
 		return nil
@@ -10240,6 +10334,7 @@ func (p *Parser) parseGenericFunctionTypeExpression() Expression {
 		Token:          p.curToken, // Should be the '(' token
 		TypeParameters: typeParams,
 		Parameters:     params,
+		ParameterNames: names,
 		RestParameter:  restParam,
 		ReturnType:     returnType,
 	}
@@ -11046,6 +11141,29 @@ func (p *Parser) parseImportDeclaration() *ImportDeclaration {
 		return stmt
 	}
 
+	// import x = require("module") in a declaration file binds the module's
+	// export = value, which is its default export
+	if p.inAmbient() && p.curToken.Type == lexer.IDENT && p.peekTokenIs(lexer.ASSIGN) {
+		local := &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken() // '='
+		if !p.expectPeek(lexer.IDENT) || p.curToken.Literal != "require" {
+			p.addError(p.curToken, "expected 'require' in import assignment")
+			return nil
+		}
+		if !p.expectPeek(lexer.LPAREN) || !p.expectPeek(lexer.STRING) {
+			return nil
+		}
+		stmt.Source = &StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Specifiers = []ImportSpecifier{&ImportDefaultSpecifier{Token: local.Token, Local: local}}
+		if !p.expectPeek(lexer.RPAREN) {
+			return nil
+		}
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+
 	// Parse import specifiers for non-bare imports
 	var specifiers []ImportSpecifier
 
@@ -11363,20 +11481,35 @@ func (p *Parser) parseExportDeclaration() Statement {
 		if p.peekTokenIs(lexer.SEMICOLON) {
 			p.nextToken()
 		}
-		if expr != nil {
-			return &ExpressionStatement{Token: exportToken, Expression: expr}
+		if expr == nil {
+			return nil
 		}
-		return nil
+		if p.inAmbient() {
+			// export = Lib; in a declaration file names the module's value
+			return &ExportAssignment{Token: exportToken, Expression: expr}
+		}
+		return &ExpressionStatement{Token: exportToken, Expression: expr}
 
 	case lexer.IDENT:
 		if p.curToken.Literal == "declare" {
 			// export declare const x: number; — ambient declaration
-			return p.parseDeclareStatement()
+			if !p.inAmbient() {
+				return p.parseDeclareStatement()
+			}
+			// In a declaration file the declaration is what the module exports
+			decl := p.parseStatement()
+			if decl == nil {
+				return nil
+			}
+			if ns, ok := decl.(*NamespaceDeclaration); ok {
+				ns.IsExported = true
+			}
+			return &ExportNamedDeclaration{Token: exportToken, Declaration: decl}
 		}
 		// export namespace X { ... } — TypeScript namespace export
 		if p.curToken.Literal == "namespace" &&
 			(p.peekTokenIs(lexer.IDENT) || p.isKeywordThatCanBeIdentifier(p.peekToken.Type)) {
-			ns := p.parseNamespaceDeclaration(false)
+			ns := p.parseNamespaceDeclaration(p.inAmbient())
 			if ns == nil {
 				return nil
 			}
@@ -11385,6 +11518,22 @@ func (p *Parser) parseExportDeclaration() Statement {
 		}
 		return nil
 
+	case lexer.AS:
+		// export as namespace Lib; — the UMD global of a declaration file.
+		// Scripts are not type-checked against UMD globals, so it is dropped.
+		if !p.peekTokenIs(lexer.IDENT) || p.peekToken.Literal != "namespace" {
+			p.addError(p.curToken, "expected 'namespace' after 'export as'")
+			return nil
+		}
+		p.nextToken() // 'namespace'
+		if !p.expectPeek(lexer.IDENT) {
+			return nil
+		}
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		return nil
+
 	default:
 		// Should not reach here due to expectPeek checks above
 		return nil
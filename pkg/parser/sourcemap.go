@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/lexer"
+)
+
+// SourceMap is a Source Map revision 3 document, mapping positions in
+// generated JavaScript back to the TypeScript source it was emitted from
+type SourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file,omitempty"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent,omitempty"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+}
+
+// JSON returns the source map as JSON
+func (m *SourceMap) JSON() []byte {
+	data, _ := json.Marshal(m) // Only strings and ints: cannot fail
+	return data
+}
+
+// sourceMapping maps a generated position to an original one. Lines and
+// columns are 0-based, columns counted in UTF-16 code units.
+type sourceMapping struct {
+	genLine, genColumn int
+	srcLine, srcColumn int
+}
+
+// encodeMappings encodes mappings, sorted by generated position, in the
+// "mappings" format: a base64 VLQ segment per mapping, with fields relative
+// to the previous segment, and generated lines separated by semicolons
+func encodeMappings(mappings []sourceMapping) string {
+	var b strings.Builder
+	line, prevGenColumn, prevSrcLine, prevSrcColumn := 0, 0, 0, 0
+	for i, m := range mappings {
+		if m.genLine != line {
+			for ; line < m.genLine; line++ {
+				b.WriteByte(';')
+			}
+			prevGenColumn = 0
+		} else if i > 0 {
+			b.WriteByte(',')
+		}
+		writeVLQ(&b, m.genColumn-prevGenColumn)
+		writeVLQ(&b, 0) // Source index: there is one source
+		writeVLQ(&b, m.srcLine-prevSrcLine)
+		writeVLQ(&b, m.srcColumn-prevSrcColumn)
+		prevGenColumn, prevSrcLine, prevSrcColumn = m.genColumn, m.srcLine, m.srcColumn
+	}
+	return b.String()
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes v as a base64 VLQ: the sign in the lowest bit, then five
+// bits per digit, least significant first, with 0x20 marking continuation
+func writeVLQ(b *strings.Builder, v int) {
+	u := v << 1
+	if v < 0 {
+		u = (-v << 1) | 1
+	}
+	for {
+		digit := u & 0x1f
+		u >>= 5
+		if u > 0 {
+			digit |= 0x20
+		}
+		b.WriteByte(base64Digits[digit])
+		if u == 0 {
+			return
+		}
+	}
+}
+
+// utf16Len returns the length of s in UTF-16 code units
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2 // Surrogate pair
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// originalColumn returns the 0-based UTF-16 column of tok in content, falling
+// back to the lexer's column when content doesn't hold the token
+func originalColumn(tok *lexer.Token, content string) int {
+	if tok.StartPos > 0 && tok.StartPos <= len(content) {
+		lineStart := strings.LastIndexAny(content[:tok.StartPos], "\n\r") + 1
+		return utf16Len(content[lineStart:tok.StartPos])
+	}
+	if tok.StartPos == 0 && tok.Line == 1 {
+		return 0
+	}
+	return max(tok.Column-1, 0)
+}
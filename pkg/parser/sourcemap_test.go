@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nooga/paserati/pkg/lexer"
+	"github.com/nooga/paserati/pkg/source"
+)
+
+func TestWriteVLQ(t *testing.T) {
+	tests := []struct {
+		value    int
+		expected string
+	}{
+		{0, "A"}, {1, "C"}, {-1, "D"}, {15, "e"}, {16, "gB"}, {-16, "hB"}, {1000, "w+B"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		writeVLQ(&b, tt.value)
+		if b.String() != tt.expected {
+			t.Errorf("writeVLQ(%d) = %q, want %q", tt.value, b.String(), tt.expected)
+		}
+	}
+}
+
+// decodeMappings decodes a "mappings" string back to absolute positions
+func decodeMappings(t *testing.T, mappings string) []sourceMapping {
+	t.Helper()
+	var result []sourceMapping
+	var srcLine, srcColumn int
+	for genLine, line := range strings.Split(mappings, ";") {
+		genColumn := 0
+		for _, segment := range strings.Split(line, ",") {
+			if segment == "" {
+				continue
+			}
+			var fields []int
+			value, shift := 0, 0
+			for _, c := range segment {
+				digit := strings.IndexRune(base64Digits, c)
+				value |= (digit & 0x1f) << shift
+				shift += 5
+				if digit&0x20 == 0 {
+					if value&1 != 0 {
+						fields = append(fields, -(value >> 1))
+					} else {
+						fields = append(fields, value>>1)
+					}
+					value, shift = 0, 0
+				}
+			}
+			if len(fields) != 4 || fields[1] != 0 {
+				t.Fatalf("unexpected segment %q", segment)
+			}
+			genColumn += fields[0]
+			srcLine += fields[2]
+			srcColumn += fields[3]
+			result = append(result, sourceMapping{genLine, genColumn, srcLine, srcColumn})
+		}
+	}
+	return result
+}
+
+func TestEmitWithSourceMap(t *testing.T) {
+	input := "function add(a: number, b: number): number {\n" +
+		"  return a + b;\n" +
+		"}\n" +
+		"const s: string = \"😀\"; let x = add(1, 2);\n"
+	l := lexer.NewLexerWithSource(source.FromFile("add.ts", input))
+	program, errs := NewParser(l).ParseProgram()
+	if len(errs) != 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+
+	emitter := NewJSEmitter()
+	code, sourceMap := emitter.EmitWithSourceMap(program, "add.js")
+	if sourceMap.Version != 3 || sourceMap.File != "add.js" || len(sourceMap.Sources) != 1 || sourceMap.Sources[0] != "add.ts" {
+		t.Fatalf("unexpected source map header: %+v", sourceMap)
+	}
+	if len(sourceMap.SourcesContent) != 1 || sourceMap.SourcesContent[0] != input {
+		t.Errorf("sourcesContent does not hold the source")
+	}
+
+	// Generated and original positions of some tokens: "return", the call
+	// to add (past an astral character, two UTF-16 units) and its argument
+	genLines := strings.Split(code, "\n")
+	find := func(text string, lines []string) (int, int) {
+		for i, line := range lines {
+			if col := strings.Index(line, text); col >= 0 {
+				return i, utf16Len(line[:col])
+			}
+		}
+		t.Fatalf("%q not found", text)
+		return 0, 0
+	}
+	mappings := decodeMappings(t, sourceMap.Mappings)
+	for _, text := range []string{"return", "add(1", "2)"} {
+		genLine, genColumn := find(text, genLines)
+		srcLine, srcColumn := find(text, strings.Split(input, "\n"))
+		found := false
+		for _, m := range mappings {
+			if m.genLine == genLine && m.genColumn == genColumn {
+				found = true
+				if m.srcLine != srcLine || m.srcColumn != srcColumn {
+					t.Errorf("%q at %d:%d maps to %d:%d, want %d:%d", text, genLine, genColumn, m.srcLine, m.srcColumn, srcLine, srcColumn)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no mapping for %q at %d:%d", text, genLine, genColumn)
+		}
+	}
+
+	// Emitting again starts a fresh map
+	again, sourceMapAgain := emitter.EmitWithSourceMap(program, "add.js")
+	if again != code || sourceMapAgain.Mappings != sourceMap.Mappings {
+		t.Errorf("emitting again gave a different result")
+	}
+}
@@ -1,6 +1,9 @@
 package runtime
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // AsyncRuntime provides the async execution environment for Paserati
 // This interface allows plugging in different async execution strategies
@@ -34,17 +37,33 @@ type AsyncRuntime interface {
 }
 
 // DefaultAsyncRuntime is a simple Go-based runtime with a microtask queue
+// and a deadline-ordered macrotask queue for timers
 type DefaultAsyncRuntime struct {
 	microtasks      []func()
 	mu              sync.Mutex
 	pendingExternal int
 	externalCond    *sync.Cond
+	clock           Clock
+	timers          *TimerQueue
+	wake            chan struct{} // signalled when an external op completes
 }
 
-// NewDefaultAsyncRuntime creates a new default async runtime
+// NewDefaultAsyncRuntime creates a new default async runtime using the system clock
 func NewDefaultAsyncRuntime() *DefaultAsyncRuntime {
+	return NewDefaultAsyncRuntimeWithClock(SystemClock{})
+}
+
+// NewDefaultAsyncRuntimeWithClock creates a default async runtime whose timers
+// are driven by the given clock (e.g. a ManualClock in tests)
+func NewDefaultAsyncRuntimeWithClock(clock Clock) *DefaultAsyncRuntime {
+	if clock == nil {
+		clock = SystemClock{}
+	}
 	rt := &DefaultAsyncRuntime{
 		microtasks: make([]func(), 0, 16),
+		clock:      clock,
+		timers:     NewTimerQueue(clock),
+		wake:       make(chan struct{}, 1),
 	}
 	rt.externalCond = sync.NewCond(&rt.mu)
 	return rt
@@ -85,6 +104,7 @@ func (rt *DefaultAsyncRuntime) Reset() {
 	defer rt.mu.Unlock()
 	rt.microtasks = make([]func(), 0, 16)
 	rt.pendingExternal = 0
+	rt.timers.Clear()
 }
 
 // BeginExternalOp marks the start of an external async operation
@@ -101,6 +121,10 @@ func (rt *DefaultAsyncRuntime) EndExternalOp() {
 	rt.pendingExternal--
 	// Signal any waiters that an operation completed
 	rt.externalCond.Broadcast()
+	select {
+	case rt.wake <- struct{}{}:
+	default:
+	}
 }
 
 // HasPendingExternalOps returns true if there are pending external operations
@@ -118,3 +142,75 @@ func (rt *DefaultAsyncRuntime) WaitForExternalOp() {
 		rt.externalCond.Wait()
 	}
 }
+
+// Clock returns the time source used for timer deadlines
+func (rt *DefaultAsyncRuntime) Clock() Clock {
+	return rt.clock
+}
+
+// SetTimer schedules a one-shot or repeating timer
+func (rt *DefaultAsyncRuntime) SetTimer(delay time.Duration, repeat bool, callback TimerCallback) TimerID {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.timers.Add(delay, repeat, callback)
+}
+
+// SetImmediate schedules a macrotask for the next loop turn
+func (rt *DefaultAsyncRuntime) SetImmediate(callback TimerCallback) TimerID {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.timers.AddImmediate(callback)
+}
+
+// ClearTimer cancels a pending timer or immediate
+func (rt *DefaultAsyncRuntime) ClearTimer(id TimerID) bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.timers.Remove(id)
+}
+
+// HasPendingTimers returns true if any timers or immediates are scheduled
+func (rt *DefaultAsyncRuntime) HasPendingTimers() bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.timers.Len() > 0
+}
+
+// RunNextTimer runs the earliest due macrotask, if any
+func (rt *DefaultAsyncRuntime) RunNextTimer() (bool, error) {
+	rt.mu.Lock()
+	callback, ok := rt.timers.PopDue()
+	rt.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	// Run outside the lock: callbacks may schedule or clear timers
+	return true, callback()
+}
+
+// WaitForEvent blocks until the next timer is due or an external operation completes
+func (rt *DefaultAsyncRuntime) WaitForEvent() {
+	rt.mu.Lock()
+	deadline, hasTimer := rt.timers.NextDeadline()
+	hasExternal := rt.pendingExternal > 0
+	rt.mu.Unlock()
+
+	if !hasTimer && !hasExternal {
+		return
+	}
+
+	var timerCh <-chan time.Time
+	if hasTimer {
+		d := deadline.Sub(rt.clock.Now())
+		if d <= 0 {
+			return
+		}
+		timerCh = rt.clock.After(d)
+	}
+
+	select {
+	case <-timerCh:
+	case <-rt.wake:
+	}
+}
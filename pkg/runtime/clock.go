@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the time source used by the timer queue.
+// Production code uses SystemClock; tests inject a ManualClock so that
+// virtual time can be advanced deterministically instead of sleeping.
+type Clock interface {
+	// Now returns the current time according to this clock
+	Now() time.Time
+
+	// After returns a channel that receives the clock's time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is a Clock backed by the wall clock
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After delegates to time.After
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ManualClock is a Clock whose time only moves when Advance or Set is called
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewManualClock creates a manual clock starting at the given time
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the current virtual time
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that fires once virtual time reaches now+d
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+// Advance moves virtual time forward by d, firing any expired After channels
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.setLocked(c.now.Add(d))
+	c.mu.Unlock()
+}
+
+// Set moves virtual time to t. Moving backwards is ignored.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	if t.After(c.now) {
+		c.setLocked(t)
+	}
+	c.mu.Unlock()
+}
+
+func (c *ManualClock) setLocked(t time.Time) {
+	c.now = t
+	sort.Slice(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+	fired := 0
+	for _, w := range c.waiters {
+		if w.deadline.After(t) {
+			break
+		}
+		w.ch <- t
+		fired++
+	}
+	c.waiters = c.waiters[fired:]
+}
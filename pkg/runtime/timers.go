@@ -0,0 +1,212 @@
+package runtime
+
+import (
+	"container/heap"
+	"time"
+)
+
+// TimerID identifies a scheduled macrotask (setTimeout/setInterval/setImmediate)
+type TimerID int
+
+// TimerCallback is invoked when a macrotask fires. A non-nil error is treated
+// as an uncaught exception and stops the event loop.
+type TimerCallback func() error
+
+// TimerRuntime is implemented by async runtimes that can schedule macrotasks.
+// The VM's event loop uses it to interleave timers with microtask draining.
+type TimerRuntime interface {
+	AsyncRuntime
+
+	// Clock returns the time source used for timer deadlines
+	Clock() Clock
+
+	// SetTimer schedules callback to run once delay has elapsed.
+	// If repeat is true the timer is re-armed with the same delay after each run.
+	SetTimer(delay time.Duration, repeat bool, callback TimerCallback) TimerID
+
+	// SetImmediate schedules callback to run on the next turn of the event loop
+	SetImmediate(callback TimerCallback) TimerID
+
+	// ClearTimer cancels a pending timer or immediate. Returns false if not found.
+	ClearTimer(id TimerID) bool
+
+	// HasPendingTimers returns true if any timers or immediates are scheduled
+	HasPendingTimers() bool
+
+	// RunNextTimer runs the earliest due macrotask, if any.
+	// Returns true if a macrotask was run.
+	RunNextTimer() (bool, error)
+
+	// WaitForEvent blocks until a timer becomes due or an external operation
+	// completes. Returns immediately if there is nothing to wait for.
+	WaitForEvent()
+}
+
+// timer is a single entry in the TimerQueue
+type timer struct {
+	id       TimerID
+	deadline time.Time
+	interval time.Duration
+	repeat   bool
+	callback TimerCallback
+	seq      uint64 // insertion order, breaks ties between equal deadlines
+	index    int    // position in the heap, -1 when not queued
+}
+
+// timerHeap orders timers by deadline, then by insertion order
+type timerHeap []*timer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].deadline.Equal(h[j].deadline) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
+
+// TimerQueue holds macrotasks ordered by deadline plus a FIFO of immediates.
+// It is not safe for concurrent use; DefaultAsyncRuntime guards it with its mutex.
+type TimerQueue struct {
+	clock      Clock
+	timers     timerHeap
+	immediates []*timer
+	byID       map[TimerID]*timer
+	nextID     TimerID
+	seq        uint64
+}
+
+// NewTimerQueue creates an empty timer queue driven by the given clock
+func NewTimerQueue(clock Clock) *TimerQueue {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &TimerQueue{
+		clock: clock,
+		byID:  make(map[TimerID]*timer),
+	}
+}
+
+// Add schedules a timer and returns its ID
+func (q *TimerQueue) Add(delay time.Duration, repeat bool, callback TimerCallback) TimerID {
+	// Match Node.js: delays below 1ms are clamped to 1ms
+	if delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	q.nextID++
+	q.seq++
+	t := &timer{
+		id:       q.nextID,
+		deadline: q.clock.Now().Add(delay),
+		interval: delay,
+		repeat:   repeat,
+		callback: callback,
+		seq:      q.seq,
+	}
+	heap.Push(&q.timers, t)
+	q.byID[t.id] = t
+	return t.id
+}
+
+// AddImmediate queues a macrotask that is due on the next loop turn
+func (q *TimerQueue) AddImmediate(callback TimerCallback) TimerID {
+	q.nextID++
+	q.seq++
+	t := &timer{id: q.nextID, callback: callback, seq: q.seq, index: -1}
+	q.immediates = append(q.immediates, t)
+	q.byID[t.id] = t
+	return t.id
+}
+
+// Remove cancels a timer or immediate
+func (q *TimerQueue) Remove(id TimerID) bool {
+	t, ok := q.byID[id]
+	if !ok {
+		return false
+	}
+	delete(q.byID, id)
+	if t.index >= 0 {
+		heap.Remove(&q.timers, t.index)
+		return true
+	}
+	for i, imm := range q.immediates {
+		if imm == t {
+			q.immediates = append(q.immediates[:i], q.immediates[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Len returns the number of pending timers and immediates
+func (q *TimerQueue) Len() int {
+	return len(q.byID)
+}
+
+// NextDeadline returns the time at which the next macrotask becomes due.
+// Immediates are always due now.
+func (q *TimerQueue) NextDeadline() (time.Time, bool) {
+	if len(q.immediates) > 0 {
+		return q.clock.Now(), true
+	}
+	if len(q.timers) > 0 {
+		return q.timers[0].deadline, true
+	}
+	return time.Time{}, false
+}
+
+// PopDue removes and returns the callback of the earliest due macrotask.
+// Expired timers run before immediates, matching Node's timers-then-check phase order.
+// Repeating timers are re-armed relative to the current time before being returned.
+func (q *TimerQueue) PopDue() (TimerCallback, bool) {
+	now := q.clock.Now()
+	if len(q.timers) > 0 && !q.timers[0].deadline.After(now) {
+		t := q.timers[0]
+		if t.repeat {
+			q.seq++
+			t.seq = q.seq
+			t.deadline = now.Add(t.interval)
+			heap.Fix(&q.timers, 0)
+		} else {
+			heap.Pop(&q.timers)
+			delete(q.byID, t.id)
+		}
+		return t.callback, true
+	}
+	if len(q.immediates) > 0 {
+		t := q.immediates[0]
+		q.immediates = q.immediates[1:]
+		delete(q.byID, t.id)
+		return t.callback, true
+	}
+	return nil, false
+}
+
+// Clear drops all pending timers and immediates
+func (q *TimerQueue) Clear() {
+	q.timers = nil
+	q.immediates = nil
+	q.byID = make(map[TimerID]*timer)
+}
@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newManualRuntime() (*DefaultAsyncRuntime, *ManualClock) {
+	clock := NewManualClock(time.Unix(0, 0))
+	return NewDefaultAsyncRuntimeWithClock(clock), clock
+}
+
+// runDue runs every macrotask that is due at the current virtual time
+func runDue(t *testing.T, rt *DefaultAsyncRuntime) {
+	t.Helper()
+	for {
+		ran, err := rt.RunNextTimer()
+		if err != nil {
+			t.Fatalf("unexpected timer error: %v", err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestTimersFireInDeadlineOrder(t *testing.T) {
+	rt, clock := newManualRuntime()
+	var order []string
+	record := func(name string) TimerCallback {
+		return func() error { order = append(order, name); return nil }
+	}
+
+	rt.SetTimer(30*time.Millisecond, false, record("c"))
+	rt.SetTimer(10*time.Millisecond, false, record("a"))
+	rt.SetTimer(10*time.Millisecond, false, record("b")) // same deadline, later insertion
+
+	runDue(t, rt)
+	if len(order) != 0 {
+		t.Fatalf("expected no timers before advancing the clock, got %v", order)
+	}
+
+	clock.Advance(10 * time.Millisecond)
+	runDue(t, rt)
+	if got := len(order); got != 2 || order[0] != "a" || order[1] != "b" {
+		t.Fatalf("expected [a b] after 10ms, got %v", order)
+	}
+
+	clock.Advance(20 * time.Millisecond)
+	runDue(t, rt)
+	if got := len(order); got != 3 || order[2] != "c" {
+		t.Fatalf("expected c after 30ms, got %v", order)
+	}
+	if rt.HasPendingTimers() {
+		t.Fatal("expected no pending timers")
+	}
+}
+
+func TestIntervalRepeatsUntilCleared(t *testing.T) {
+	rt, clock := newManualRuntime()
+	count := 0
+	var id TimerID
+	id = rt.SetTimer(5*time.Millisecond, true, func() error {
+		count++
+		if count == 3 {
+			rt.ClearTimer(id)
+		}
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		clock.Advance(5 * time.Millisecond)
+		runDue(t, rt)
+	}
+	if count != 3 {
+		t.Fatalf("expected interval to run 3 times, ran %d", count)
+	}
+	if rt.HasPendingTimers() {
+		t.Fatal("expected cleared interval to be removed")
+	}
+}
+
+func TestImmediatesAndClamping(t *testing.T) {
+	rt, clock := newManualRuntime()
+	var order []string
+
+	rt.SetTimer(0, false, func() error { order = append(order, "timeout0"); return nil })
+	rt.SetImmediate(func() error { order = append(order, "immediate"); return nil })
+	cancelled := rt.SetImmediate(func() error { order = append(order, "cancelled"); return nil })
+	if !rt.ClearTimer(cancelled) {
+		t.Fatal("expected ClearTimer to find the immediate")
+	}
+	if rt.ClearTimer(cancelled) {
+		t.Fatal("expected second ClearTimer to report not found")
+	}
+
+	// A zero delay is clamped to 1ms, so only the immediate is due now
+	runDue(t, rt)
+	if len(order) != 1 || order[0] != "immediate" {
+		t.Fatalf("expected only the immediate to run, got %v", order)
+	}
+
+	clock.Advance(time.Millisecond)
+	runDue(t, rt)
+	if len(order) != 2 || order[1] != "timeout0" {
+		t.Fatalf("expected clamped timeout to run after 1ms, got %v", order)
+	}
+}
+
+func TestTimerErrorIsReturned(t *testing.T) {
+	rt, clock := newManualRuntime()
+	boom := errors.New("boom")
+	rt.SetTimer(time.Millisecond, false, func() error { return boom })
+
+	clock.Advance(time.Millisecond)
+	ran, err := rt.RunNextTimer()
+	if !ran || err != boom {
+		t.Fatalf("expected timer to run and return its error, got ran=%v err=%v", ran, err)
+	}
+}
+
+func TestWaitForEventWakesOnManualClock(t *testing.T) {
+	rt, clock := newManualRuntime()
+	rt.SetTimer(50*time.Millisecond, false, func() error { return nil })
+
+	done := make(chan struct{})
+	go func() {
+		rt.WaitForEvent()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("WaitForEvent returned before the timer was due")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(50 * time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WaitForEvent did not wake after advancing the clock")
+	}
+}
+
+func TestWaitForEventWakesOnExternalOp(t *testing.T) {
+	rt, _ := newManualRuntime()
+	rt.BeginExternalOp()
+
+	done := make(chan struct{})
+	go func() {
+		rt.WaitForEvent()
+		close(done)
+	}()
+
+	rt.EndExternalOp()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WaitForEvent did not wake after EndExternalOp")
+	}
+}
@@ -0,0 +1,154 @@
+package tsconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultExclude are the directories tsc leaves out of a project when its
+// config sets no exclude
+var defaultExclude = []string{"node_modules", "bower_components", "jspm_packages"}
+
+// SourceFiles returns the source files of the project, sorted: its Files,
+// and the .ts and .tsx files its Include patterns match and its Exclude
+// patterns don't. Like tsc, Include defaults to every file under the
+// directory of the config unless Files is set, and Exclude to the package
+// directories in it. Patterns may use "*" and "?" within a path segment and
+// "**/" for any number of directories; an Include pattern naming a
+// directory means every file under it, and an Exclude pattern everything
+// under what it matches.
+func (c *Config) SourceFiles() ([]string, error) {
+	dir := filepath.Dir(c.Path)
+	include, exclude := c.Include, c.Exclude
+	if include == nil && c.Files == nil {
+		include = []string{filepath.Join(dir, "**", "*")}
+	}
+	if exclude == nil {
+		exclude = absolutePaths(defaultExclude, dir)
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, file := range absolutePaths(c.Files, dir) {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("file %s not found", file)
+		}
+		add(file)
+	}
+
+	excluded := make([]*regexp.Regexp, len(exclude))
+	for i, pattern := range exclude {
+		excluded[i] = patternRegexp(filepath.ToSlash(pattern), true)
+	}
+	isExcluded := func(file string) bool {
+		for _, re := range excluded {
+			if re.MatchString(filepath.ToSlash(file)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pattern := range absolutePaths(include, dir) {
+		pattern = filepath.ToSlash(pattern)
+		if last := path.Base(pattern); !hasWildcard(last) && path.Ext(last) == "" {
+			pattern += "/**/*"
+		}
+		included := patternRegexp(pattern, false)
+		root := filepath.FromSlash(wildcardFreePrefix(pattern))
+		err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if file == root {
+					return filepath.SkipDir // A directory that doesn't exist matches nothing
+				}
+				return err
+			}
+			if isExcluded(file) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.IsDir() && IsSourceFile(file) && included.MatchString(filepath.ToSlash(file)) {
+				add(file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// IsSourceFile reports whether path names a TypeScript source file: a .ts,
+// .d.ts or .tsx file
+func IsSourceFile(path string) bool {
+	return strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx")
+}
+
+// hasWildcard reports whether a pattern has wildcards
+func hasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
+
+// wildcardFreePrefix returns the directory of a slash-separated pattern up
+// to its first segment with wildcards
+func wildcardFreePrefix(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if hasWildcard(segment) {
+			return path.Clean(strings.Join(segments[:i], "/") + "/")
+		}
+	}
+	return path.Dir(pattern)
+}
+
+// patternRegexp compiles a slash-separated include or exclude pattern.
+// Prefix patterns also match everything under the paths they match.
+func patternRegexp(pattern string, prefix bool) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString("^")
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "**" {
+			if last {
+				re.WriteString(".*")
+			} else {
+				re.WriteString("(?:[^/]+/)*")
+			}
+			continue
+		}
+		for _, r := range segment {
+			switch r {
+			case '*':
+				re.WriteString("[^/]*")
+			case '?':
+				re.WriteString("[^/]")
+			default:
+				re.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !last {
+			re.WriteString("/")
+		}
+	}
+	if prefix {
+		re.WriteString("(?:/.*)?")
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
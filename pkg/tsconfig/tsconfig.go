@@ -0,0 +1,362 @@
+// Package tsconfig reads tsconfig.json files.
+package tsconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the config file of a TypeScript project
+const FileName = "tsconfig.json"
+
+// Config is a parsed tsconfig.json
+type Config struct {
+	// Path is the file the config was loaded from, empty for Parse
+	Path string `json:"-"`
+	// Extends names the configs this one extends, in order
+	Extends         []string        `json:"-"`
+	CompilerOptions CompilerOptions `json:"compilerOptions"`
+
+	// Files, Include and Exclude select the source files of the project; see
+	// SourceFiles. They are nil when unset. Parse leaves them as written,
+	// while Load inherits them from the configs extended when unset and
+	// makes them absolute, relative to the config that set them.
+	Files   []string `json:"-"`
+	Include []string `json:"-"`
+	Exclude []string `json:"-"`
+}
+
+// CompilerOptions holds the compilerOptions of a tsconfig.json. Flags are
+// pointers so that options left unset can be told apart from false.
+type CompilerOptions struct {
+	Strict                       *bool `json:"strict"`
+	NoImplicitAny                *bool `json:"noImplicitAny"`
+	StrictNullChecks             *bool `json:"strictNullChecks"`
+	StrictPropertyInitialization *bool `json:"strictPropertyInitialization"`
+	StrictFunctionTypes          *bool `json:"strictFunctionTypes"`
+	StrictBindCallApply          *bool `json:"strictBindCallApply"`
+	NoImplicitThis               *bool `json:"noImplicitThis"`
+	AlwaysStrict                 *bool `json:"alwaysStrict"`
+	UseUnknownInCatchVariables   *bool `json:"useUnknownInCatchVariables"`
+	NoImplicitOverride           *bool `json:"noImplicitOverride"`
+	NoUnusedLocals               *bool `json:"noUnusedLocals"`
+	NoUnusedParameters           *bool `json:"noUnusedParameters"`
+	ExperimentalDecorators       *bool `json:"experimentalDecorators"`
+
+	Target string   `json:"target"`
+	Lib    []string `json:"lib"`
+
+	// BaseURL is the directory non-relative specifiers are looked up in. Load
+	// makes it absolute.
+	BaseURL string              `json:"baseUrl"`
+	Paths   map[string][]string `json:"paths"`
+	// PathsBase is the directory the targets of Paths are relative to: the
+	// base URL, or else the directory of the config that set Paths
+	PathsBase string `json:"-"`
+
+	// raw holds every option as written, for the ones paserati doesn't model
+	raw map[string]json.RawMessage
+}
+
+// StrictFlag returns the value of a flag of the strict family: its own
+// setting if it has one, or else that of strict
+func (o *CompilerOptions) StrictFlag(flag *bool) bool {
+	if flag != nil {
+		return *flag
+	}
+	return o.Strict != nil && *o.Strict
+}
+
+// IsSet reports whether the option name was set
+func (o *CompilerOptions) IsSet(name string) bool {
+	_, ok := o.raw[name]
+	return ok
+}
+
+// Raw returns the JSON value of the option name, nil when it isn't set
+func (o *CompilerOptions) Raw(name string) json.RawMessage {
+	return o.raw[name]
+}
+
+// Names returns the names of the options that are set, sorted
+func (o *CompilerOptions) Names() []string {
+	names := make([]string, 0, len(o.raw))
+	for name := range o.raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configFile is the JSON shape of a tsconfig.json
+type configFile struct {
+	Extends         json.RawMessage            `json:"extends"`
+	CompilerOptions map[string]json.RawMessage `json:"compilerOptions"`
+	Files           []string                   `json:"files"`
+	Include         []string                   `json:"include"`
+	Exclude         []string                   `json:"exclude"`
+}
+
+// Parse parses the content of a tsconfig.json. Like tsc, it accepts
+// comments and trailing commas. Extended configs are not loaded.
+func Parse(data []byte) (*Config, error) {
+	var file configFile
+	if err := json.Unmarshal(StripJSONC(data), &file); err != nil {
+		return nil, err
+	}
+	config := &Config{Files: file.Files, Include: file.Include, Exclude: file.Exclude}
+	if len(file.Extends) > 0 && string(file.Extends) != "null" {
+		var single string
+		if err := json.Unmarshal(file.Extends, &single); err == nil {
+			config.Extends = []string{single}
+		} else if err := json.Unmarshal(file.Extends, &config.Extends); err != nil {
+			return nil, fmt.Errorf("extends must be a string or an array of strings")
+		}
+	}
+	if err := config.CompilerOptions.set(file.CompilerOptions); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// set replaces the options with raw
+func (o *CompilerOptions) set(raw map[string]json.RawMessage) error {
+	*o = CompilerOptions{}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, o); err != nil {
+		return fmt.Errorf("compilerOptions: %w", err)
+	}
+	o.raw = raw
+	if o.raw == nil {
+		o.raw = make(map[string]json.RawMessage)
+	}
+	return nil
+}
+
+// Find looks for a tsconfig.json in dir and its parents, like tsc does when
+// run without a project. It returns "" if there is none.
+func Find(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the tsconfig.json at path along with the configs it extends.
+// The options of a config override those of the configs it extends, later
+// ones in an extends array overriding earlier ones. BaseURL and PathsBase
+// come out absolute.
+func Load(path string) (*Config, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{loading: make(map[string]bool)}
+	raw, pathsBase, selection, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{Path: path, Files: selection.files, Include: selection.include, Exclude: selection.exclude}
+	if err := config.CompilerOptions.set(raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	config.Extends = l.extends
+	options := &config.CompilerOptions
+	if options.BaseURL != "" {
+		options.PathsBase = options.BaseURL
+	} else {
+		options.PathsBase = pathsBase
+	}
+	return config, nil
+}
+
+type loader struct {
+	loading map[string]bool // Configs being loaded, to catch cycles
+	extends []string        // Paths of the configs extended by the root, in order
+}
+
+// selection holds the files, include and exclude settings of a config, with
+// absolute paths
+type selection struct {
+	files, include, exclude []string
+}
+
+// load returns the merged raw compilerOptions of the config at path, with
+// baseUrl made absolute, the directory of the config that set "paths" and
+// the settings selecting the source files
+func (l *loader) load(path string) (map[string]json.RawMessage, string, selection, error) {
+	if l.loading[path] {
+		return nil, "", selection{}, fmt.Errorf("%s: circular extends", path)
+	}
+	l.loading[path] = true
+	defer delete(l.loading, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", selection{}, err
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, "", selection{}, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	merged := make(map[string]json.RawMessage)
+	pathsBase := ""
+	var selected selection
+	for _, name := range config.Extends {
+		base, err := resolveExtends(name, dir)
+		if err != nil {
+			return nil, "", selection{}, fmt.Errorf("%s: %w", path, err)
+		}
+		l.extends = append(l.extends, base)
+		raw, basePaths, baseSelection, err := l.load(base)
+		if err != nil {
+			return nil, "", selection{}, err
+		}
+		for option, value := range raw {
+			merged[option] = value
+		}
+		if _, ok := raw["paths"]; ok {
+			pathsBase = basePaths
+		}
+		if baseSelection.files != nil {
+			selected.files = baseSelection.files
+		}
+		if baseSelection.include != nil {
+			selected.include = baseSelection.include
+		}
+		if baseSelection.exclude != nil {
+			selected.exclude = baseSelection.exclude
+		}
+	}
+	if config.Files != nil {
+		selected.files = absolutePaths(config.Files, dir)
+	}
+	if config.Include != nil {
+		selected.include = absolutePaths(config.Include, dir)
+	}
+	if config.Exclude != nil {
+		selected.exclude = absolutePaths(config.Exclude, dir)
+	}
+
+	for option, value := range config.CompilerOptions.raw {
+		merged[option] = value
+	}
+	if _, ok := config.CompilerOptions.raw["paths"]; ok {
+		pathsBase = dir
+	}
+	if baseURL := config.CompilerOptions.BaseURL; baseURL != "" {
+		if !filepath.IsAbs(baseURL) {
+			baseURL = filepath.Join(dir, filepath.FromSlash(baseURL))
+		}
+		merged["baseUrl"], _ = json.Marshal(baseURL)
+	}
+	return merged, pathsBase, selected, nil
+}
+
+// absolutePaths returns paths, or patterns, made absolute relative to dir
+func absolutePaths(paths []string, dir string) []string {
+	absolute := make([]string, len(paths))
+	for i, path := range paths {
+		absolute[i] = filepath.FromSlash(path)
+		if !filepath.IsAbs(absolute[i]) {
+			absolute[i] = filepath.Join(dir, absolute[i])
+		}
+	}
+	return absolute
+}
+
+// resolveExtends finds the config named by an extends entry of a config in
+// dir: a path relative to dir, or a config shipped in a package under
+// node_modules
+func resolveExtends(name string, dir string) (string, error) {
+	var candidates []string
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") {
+		base := name
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(dir, filepath.FromSlash(name))
+		}
+		candidates = append(candidates, base, base+".json")
+	} else {
+		for d := dir; ; {
+			base := filepath.Join(d, "node_modules", filepath.FromSlash(name))
+			candidates = append(candidates, base, base+".json", filepath.Join(base, FileName))
+			parent := filepath.Dir(d)
+			if parent == d {
+				break
+			}
+			d = parent
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find base config %q", name)
+}
+
+// StripJSONC turns JSON with comments and trailing commas into JSON, keeping
+// offsets: comments become spaces, except for their newlines, and trailing
+// commas become spaces
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+	lastComma := -1 // Offset of a comma only whitespace or comments follow
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out) && !(out[i] == '*' && i+1 < len(out) && out[i+1] == '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			if i < len(out) {
+				out[i], out[i+1] = ' ', ' '
+				i++
+			}
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package tsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1}`, `{"a": 1}`},
+		{`{"a": 1, // comment` + "\n}", `{"a": 1            ` + "\n}"},
+		{`{"a": /* x */ 1}`, `{"a":         1}`},
+		{"/* a\nb */{}", "    \n    {}"},
+		{`[1, 2,]`, `[1, 2 ]`},
+		{`{"a": "// not a comment", "b": "/*,}"}`, `{"a": "// not a comment", "b": "/*,}"}`},
+		{`{"a": "\"//"}`, `{"a": "\"//"}`},
+	}
+
+	for _, test := range tests {
+		if result := string(StripJSONC([]byte(test.input))); result != test.expected {
+			t.Errorf("StripJSONC(%q) = %q, expected %q", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	config, err := Parse([]byte(`{
+		// Path mapping
+		"extends": "./base.json",
+		"compilerOptions": {
+			"strict": true,
+			"noImplicitAny": false,
+			"baseUrl": "./src",
+			"paths": {
+				"@lib/*": ["lib/*", "vendor/*"], /* fallbacks */
+			},
+		},
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	options := &config.CompilerOptions
+	if len(config.Extends) != 1 || config.Extends[0] != "./base.json" {
+		t.Errorf("unexpected extends: %v", config.Extends)
+	}
+	if options.BaseURL != "./src" {
+		t.Errorf("expected baseUrl './src', got %q", options.BaseURL)
+	}
+	if targets := options.Paths["@lib/*"]; len(targets) != 2 || targets[1] != "vendor/*" {
+		t.Errorf("unexpected paths: %v", options.Paths)
+	}
+	if options.StrictFlag(options.NoImplicitAny) {
+		t.Error("expected noImplicitAny to override strict")
+	}
+	if !options.StrictFlag(options.UseUnknownInCatchVariables) {
+		t.Error("expected useUnknownInCatchVariables to follow strict")
+	}
+	if !options.IsSet("strict") || options.IsSet("target") {
+		t.Errorf("unexpected options set: %v", options.Names())
+	}
+
+	config, err = Parse([]byte(`{"extends": ["a", "b"]}`))
+	if err != nil || len(config.Extends) != 2 {
+		t.Errorf("expected two extended configs, got %v (%v)", config, err)
+	}
+
+	for _, bad := range []string{`{"compilerOptions": }`, `{"extends": 1}`, `{"compilerOptions": {"strict": "yes"}}`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"node_modules/@tsconfig/strictest/tsconfig.json": `{"compilerOptions": {"strict": true, "noUnusedLocals": true, "target": "es2022"}}`,
+		"configs/base.json": `{
+			"extends": "@tsconfig/strictest/tsconfig.json",
+			"compilerOptions": {"target": "es2020", "paths": {"@lib/*": ["lib/*"]}}
+		}`,
+		"app/tsconfig.json": `{
+			"extends": ["../configs/base", "../configs/other.json"],
+			"compilerOptions": {"noUnusedLocals": false}
+		}`,
+		"configs/other.json": `{"compilerOptions": {"baseUrl": "../src"}}`,
+		"loop/a.json":        `{"extends": "./b.json"}`,
+		"loop/b.json":        `{"extends": "./a.json"}`,
+	})
+
+	config, err := Load(filepath.Join(dir, "app", FileName))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	options := &config.CompilerOptions
+	if len(config.Extends) != 3 {
+		t.Errorf("expected three extended configs, got %v", config.Extends)
+	}
+	if !options.StrictFlag(options.NoImplicitAny) {
+		t.Error("expected strict to be inherited")
+	}
+	if options.NoUnusedLocals == nil || *options.NoUnusedLocals {
+		t.Error("expected noUnusedLocals to be overridden")
+	}
+	if options.Target != "es2020" {
+		t.Errorf("expected target es2020, got %q", options.Target)
+	}
+	if options.BaseURL != filepath.Join(dir, "src") {
+		t.Errorf("expected baseUrl relative to the config setting it, got %q", options.BaseURL)
+	}
+	if options.PathsBase != options.BaseURL {
+		t.Errorf("expected paths to be relative to baseUrl, got %q", options.PathsBase)
+	}
+
+	config, err = Load(filepath.Join(dir, "configs", "base.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.CompilerOptions.PathsBase != filepath.Join(dir, "configs") {
+		t.Errorf("expected paths to be relative to their config, got %q", config.CompilerOptions.PathsBase)
+	}
+
+	if _, err := Load(filepath.Join(dir, "loop", "a.json")); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected a circular extends error, got %v", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing config")
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName:          `{}`,
+		"src/deep/x.ts":   ``,
+		"pkg/" + FileName: `{}`,
+	})
+
+	if found := Find(filepath.Join(dir, "src", "deep")); found != filepath.Join(dir, FileName) {
+		t.Errorf("expected the root config, got %q", found)
+	}
+	if found := Find(filepath.Join(dir, "pkg")); found != filepath.Join(dir, "pkg", FileName) {
+		t.Errorf("expected the nearest config, got %q", found)
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.json":                      `{"include": ["src"], "exclude": ["src/**/*.test.ts"]}`,
+		"tsconfig.json":                  `{"extends": "./base.json", "files": ["scripts/setup.ts"]}`,
+		"default/tsconfig.json":          `{}`,
+		"default/a.ts":                   ``,
+		"default/b/c.tsx":                ``,
+		"default/types.d.ts":             ``,
+		"default/notes.md":               ``,
+		"default/node_modules/x.ts":      ``,
+		"src/main.ts":                    ``,
+		"src/lib/util.ts":                ``,
+		"src/lib/util.test.ts":           ``,
+		"scripts/setup.ts":               ``,
+		"scripts/other.ts":               ``,
+		"missing/tsconfig.json":          `{"files": ["nope.ts"]}`,
+		"patterns/tsconfig.json":         `{"include": ["**/?.ts"], "exclude": ["gen"]}`,
+		"patterns/a.ts":                  ``,
+		"patterns/ab.ts":                 ``,
+		"patterns/deep/er/b.ts":          ``,
+		"patterns/gen/c.ts":              ``,
+		"patterns/gen-not-excluded/d.ts": ``,
+	})
+
+	for _, test := range []struct {
+		config   string
+		expected []string
+	}{
+		{"tsconfig.json", []string{"scripts/setup.ts", "src/lib/util.ts", "src/main.ts"}},
+		{"default/tsconfig.json", []string{"default/a.ts", "default/b/c.tsx", "default/types.d.ts"}},
+		{"patterns/tsconfig.json", []string{"patterns/a.ts", "patterns/deep/er/b.ts", "patterns/gen-not-excluded/d.ts"}},
+	} {
+		config, err := Load(filepath.Join(dir, filepath.FromSlash(test.config)))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		files, err := config.SourceFiles()
+		if err != nil {
+			t.Fatalf("%s: SourceFiles failed: %v", test.config, err)
+		}
+		var relative []string
+		for _, file := range files {
+			rel, _ := filepath.Rel(dir, file)
+			relative = append(relative, filepath.ToSlash(rel))
+		}
+		if strings.Join(relative, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("%s: expected %v, got %v", test.config, test.expected, relative)
+		}
+	}
+
+	config, err := Load(filepath.Join(dir, "missing", FileName))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := config.SourceFiles(); err == nil || !strings.Contains(err.Error(), "nope.ts") {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+}
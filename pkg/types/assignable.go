@@ -36,15 +36,28 @@ func simplifyMappedType(t Type) Type {
 }
 
 func IsAssignable(source, target Type) bool {
-	return isAssignable(source, target)
+	return isAssignable(source, target, AssignabilityOptions{})
 }
 
-func isAssignable(source, target Type) bool {
+// AssignabilityOptions are the compiler options assignability depends on
+type AssignabilityOptions struct {
+	// StrictNullChecks makes null and undefined assignable only to types that
+	// include them, any and unknown, and undefined to void. Without it they
+	// are assignable to every type but never and void.
+	StrictNullChecks bool
+}
+
+// IsAssignableWithOptions is IsAssignable under the given options
+func IsAssignableWithOptions(source, target Type, opts AssignabilityOptions) bool {
+	return isAssignable(source, target, opts)
+}
+
+func isAssignable(source, target Type, opts AssignabilityOptions) bool {
 	if source == nil || target == nil {
 		return false
 	}
 
-	pairKey := fmt.Sprintf("%T:%p->%T:%p", source, source, target, target)
+	pairKey := fmt.Sprintf("%T:%p->%T:%p:%t", source, source, target, target, opts.StrictNullChecks)
 	if _, visited := assignabilityVisited.LoadOrStore(pairKey, true); visited {
 		return true
 	}
@@ -93,7 +106,7 @@ func isAssignable(source, target Type) bool {
 				return false
 			}
 			for i := range sourceParamRef.TypeArguments {
-				if !isAssignable(sourceParamRef.TypeArguments[i], targetParamRef.TypeArguments[i]) {
+				if !isAssignable(sourceParamRef.TypeArguments[i], targetParamRef.TypeArguments[i], opts) {
 					return false
 				}
 			}
@@ -131,7 +144,7 @@ func isAssignable(source, target Type) bool {
 	}
 	if sourcePred, ok := source.(*TypePredicateType); ok {
 		if targetPred, ok := target.(*TypePredicateType); ok {
-			return isAssignable(sourcePred.Type, targetPred.Type)
+			return isAssignable(sourcePred.Type, targetPred.Type, opts)
 		}
 	}
 
@@ -142,7 +155,7 @@ func isAssignable(source, target Type) bool {
 
 	// strictNullChecks: false (TypeScript default) — null and undefined are assignable
 	// to any non-never, non-void type.
-	if (source == Null || source == Undefined) && target != Never && target != Void {
+	if !opts.StrictNullChecks && (source == Null || source == Undefined) && target != Never && target != Void {
 		return true
 	}
 
@@ -173,12 +186,12 @@ func isAssignable(source, target Type) bool {
 	if sourceInst, ok := source.(*InstantiatedType); ok {
 		// Substitute the generic type with concrete type arguments
 		concreteSource := sourceInst.Substitute()
-		return isAssignable(concreteSource, target)
+		return isAssignable(concreteSource, target, opts)
 	}
 	if targetInst, ok := target.(*InstantiatedType); ok {
 		// Substitute the generic type with concrete type arguments
 		concreteTarget := targetInst.Substitute()
-		return isAssignable(source, concreteTarget)
+		return isAssignable(source, concreteTarget, opts)
 	}
 
 	// GenericType handling - for generic methods in interfaces
@@ -203,14 +216,14 @@ func isAssignable(source, target Type) bool {
 		if sourceGeneric, ok := source.(*GenericType); ok {
 			// Compare type parameter counts and body types
 			if len(sourceGeneric.TypeParameters) == len(targetGeneric.TypeParameters) {
-				return isAssignable(sourceGeneric.Body, targetGeneric.Body)
+				return isAssignable(sourceGeneric.Body, targetGeneric.Body, opts)
 			}
 		}
 	}
 
 	if sourceGeneric, ok := source.(*GenericType); ok {
 		if targetObj, ok := target.(*ObjectType); ok && targetObj.IsCallable() {
-			return isAssignable(sourceGeneric.Body, targetObj)
+			return isAssignable(sourceGeneric.Body, targetObj, opts)
 		}
 	}
 
@@ -224,7 +237,7 @@ func isAssignable(source, target Type) bool {
 			for _, sType := range sourceUnion.Types {
 				assignable := false
 				for _, tType := range targetUnion.Types {
-					if isAssignable(sType, tType) {
+					if isAssignable(sType, tType, opts) {
 						assignable = true
 						break
 					}
@@ -237,7 +250,7 @@ func isAssignable(source, target Type) bool {
 		} else {
 			// Non-union to union: source must be assignable to at least one type in target
 			for _, tType := range targetUnion.Types {
-				if isAssignable(source, tType) {
+				if isAssignable(source, tType, opts) {
 					return true
 				}
 			}
@@ -246,7 +259,7 @@ func isAssignable(source, target Type) bool {
 	} else if sourceIsUnion {
 		// Union to non-union: every type in source must be assignable to target
 		for _, sType := range sourceUnion.Types {
-			if !isAssignable(sType, target) {
+			if !isAssignable(sType, target, opts) {
 				return false
 			}
 		}
@@ -260,7 +273,7 @@ func isAssignable(source, target Type) bool {
 	if targetIsIntersection {
 		// Source must be assignable to ALL types in target intersection
 		for _, tType := range targetIntersection.Types {
-			if !isAssignable(source, tType) {
+			if !isAssignable(source, tType, opts) {
 				return false
 			}
 		}
@@ -268,7 +281,7 @@ func isAssignable(source, target Type) bool {
 	} else if sourceIsIntersection {
 		// At least one type in source intersection must be assignable to target
 		for _, sType := range sourceIntersection.Types {
-			if isAssignable(sType, target) {
+			if isAssignable(sType, target, opts) {
 				return true
 			}
 		}
@@ -309,7 +322,7 @@ func isAssignable(source, target Type) bool {
 		default:
 			return false
 		}
-		return isAssignable(primitiveType, target)
+		return isAssignable(primitiveType, target, opts)
 	} else if targetIsLiteral {
 		// Non-literal to literal: generally false except for special cases
 		return false
@@ -323,7 +336,7 @@ func isAssignable(source, target Type) bool {
 		if sourceArray.ElementType == nil || targetArray.ElementType == nil {
 			return false
 		}
-		return isAssignable(sourceArray.ElementType, targetArray.ElementType)
+		return isAssignable(sourceArray.ElementType, targetArray.ElementType, opts)
 	}
 
 	// Tuple type handling
@@ -337,7 +350,7 @@ func isAssignable(source, target Type) bool {
 		}
 		// All tuple elements must be assignable to the array element type
 		for _, tupleElementType := range sourceTuple.ElementTypes {
-			if !isAssignable(tupleElementType, targetArray.ElementType) {
+			if !isAssignable(tupleElementType, targetArray.ElementType, opts) {
 				return false
 			}
 		}
@@ -355,7 +368,7 @@ func isAssignable(source, target Type) bool {
 
 			if i < sourceLen {
 				sourceElementType := sourceTuple.ElementTypes[i]
-				if !isAssignable(sourceElementType, targetElementType) {
+				if !isAssignable(sourceElementType, targetElementType, opts) {
 					return false
 				}
 			} else if !targetIsOptional {
@@ -384,8 +397,9 @@ func isAssignable(source, target Type) bool {
 		len(targetObj.IndexSignatures) == 0 &&
 		len(targetObj.CallSignatures) == 0 &&
 		len(targetObj.ConstructSignatures) == 0 {
-		// Only exclude null/undefined/never/void (already handled above).
-		if source != Never && source != Void {
+		// Only exclude null/undefined/never/void (null and undefined are
+		// handled above unless strictNullChecks is on).
+		if source != Never && source != Void && source != Null && source != Undefined {
 			return true
 		}
 	}
@@ -404,7 +418,11 @@ func isAssignable(source, target Type) bool {
 					return false
 				}
 			} else {
-				if !isAssignable(sourcePropType, targetPropType) {
+				if opts.StrictNullChecks && targetObj.OptionalProperties != nil && targetObj.OptionalProperties[propName] {
+					// Optional properties may hold undefined
+					targetPropType = NewUnionType(targetPropType, Undefined)
+				}
+				if !isAssignable(sourcePropType, targetPropType, opts) {
 					return false
 				}
 			}
@@ -417,7 +435,7 @@ func isAssignable(source, target Type) bool {
 			for _, idxSig := range targetObj.IndexSignatures {
 				if idxSig.KeyType == String || idxSig.KeyType == Any {
 					for _, sourcePropType := range sourceProps {
-						if !isAssignable(sourcePropType, idxSig.ValueType) {
+						if !isAssignable(sourcePropType, idxSig.ValueType, opts) {
 							return false
 						}
 					}
@@ -435,7 +453,7 @@ func isAssignable(source, target Type) bool {
 			compatible := false
 			for _, targetSig := range targetObj.CallSignatures {
 				for _, sourceSig := range sourceObj.CallSignatures {
-					if isSignatureAssignable(sourceSig, targetSig) {
+					if isSignatureAssignable(sourceSig, targetSig, opts) {
 						compatible = true
 						break
 					}
@@ -458,14 +476,14 @@ func isAssignable(source, target Type) bool {
 
 	if sourceIsReadonly && targetIsReadonly {
 		// readonly T to readonly U: T must be assignable to U
-		return isAssignable(sourceReadonly.InnerType, targetReadonly.InnerType)
+		return isAssignable(sourceReadonly.InnerType, targetReadonly.InnerType, opts)
 	} else if sourceIsReadonly && !targetIsReadonly {
 		// readonly T to T: allowed (covariance)
-		return isAssignable(sourceReadonly.InnerType, target)
+		return isAssignable(sourceReadonly.InnerType, target, opts)
 	} else if !sourceIsReadonly && targetIsReadonly {
 		// T to readonly T: allowed (source is assignable to target inner type)
 		// This is safe because we're making something more restrictive
-		return isAssignable(source, targetReadonly.InnerType)
+		return isAssignable(source, targetReadonly.InnerType, opts)
 	}
 
 	// TypeParameterType handling - type parameters with the same identity are assignable
@@ -490,7 +508,7 @@ func isAssignable(source, target Type) bool {
 		}
 
 		if sourceTypeParam.Parameter.Constraint != nil {
-			return isAssignable(sourceTypeParam.Parameter.Constraint, target)
+			return isAssignable(sourceTypeParam.Parameter.Constraint, target, opts)
 		}
 
 		return false
@@ -501,7 +519,7 @@ func isAssignable(source, target Type) bool {
 		// Check if the source type parameter's constraint is assignable to the target
 		// This handles cases like: U extends Date should be assignable to Date
 		if sourceTypeParam.Parameter.Constraint != nil {
-			return isAssignable(sourceTypeParam.Parameter.Constraint, target)
+			return isAssignable(sourceTypeParam.Parameter.Constraint, target, opts)
 		}
 		// If no constraint, fall back to checking if the type parameter itself can be assigned
 		// (this would typically be false for concrete types)
@@ -518,7 +536,7 @@ func isAssignable(source, target Type) bool {
 }
 
 // Helper function to check signature assignability
-func isSignatureAssignable(source, target *Signature) bool {
+func isSignatureAssignable(source, target *Signature, opts AssignabilityOptions) bool {
 	if source == nil || target == nil {
 		return source == target
 	}
@@ -548,13 +566,19 @@ func isSignatureAssignable(source, target *Signature) bool {
 		sourceParam := source.ParameterTypes[i]
 		// TypeScript uses bivariant parameter checking for method signatures
 		// (contravariant only applies to function types with --strictFunctionTypes)
-		if !isAssignable(targetParam, sourceParam) && !isAssignable(sourceParam, targetParam) {
+		if !isAssignable(targetParam, sourceParam, opts) && !isAssignable(sourceParam, targetParam, opts) {
 			return false
 		}
 	}
 
+	// A void-returning target accepts any source return type: the caller
+	// promises to ignore the result (e.g. callbacks passed to setTimeout)
+	if target.ReturnType == Void {
+		return true
+	}
+
 	// Check return type (covariant)
-	return isAssignable(source.ReturnType, target.ReturnType)
+	return isAssignable(source.ReturnType, target.ReturnType, opts)
 }
 
 // Helper function removed - FunctionType deprecated, use ObjectType with CallSignatures
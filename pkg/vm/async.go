@@ -176,7 +176,9 @@ func (vm *VM) executeAsyncFunctionBody(calleeVal Value, thisValue Value, args []
 	}
 
 	// Execute the VM run loop - it will return when the async function yields or completes
+	callerFinally := vm.swapFinallyState(finallyState{pendingValue: Undefined})
 	status, result := vm.run()
+	bodyFinally := vm.swapFinallyState(callerFinally)
 
 	// CRITICAL: Clean up frames only if OpAwait suspended execution
 	// When the async function completes normally via OpReturn, the sentinel frame
@@ -186,6 +188,9 @@ func (vm *VM) executeAsyncFunctionBody(calleeVal Value, thisValue Value, args []
 	if vm.frameCount > savedFrameCount {
 		vm.frameCount = savedFrameCount
 		vm.nextRegSlot = savedNextRegSlot
+		if promiseObj.Frame != nil {
+			promiseObj.Frame.finally = bodyFinally
+		}
 	}
 
 	if status == InterpretRuntimeError {
@@ -206,3 +211,49 @@ func (vm *VM) executeAsyncFunctionBody(calleeVal Value, thisValue Value, args []
 
 	return result, nil
 }
+
+// settleAsyncFunction settles the promise of an async function resumed after
+// an await with how the function went on: rejected with the exception it
+// threw, resolved with the value it returned, or left pending if it awaits
+// again, for the next await's handlers to settle
+func (vm *VM) settleAsyncFunction(promiseObj *PromiseObject, result Value, err error) {
+	switch {
+	case err != nil:
+		if exErr, ok := err.(ExceptionError); ok {
+			vm.rejectPromise(promiseObj, exErr.GetExceptionValue())
+		} else {
+			vm.rejectPromise(promiseObj, NewString(err.Error()))
+		}
+	case promiseObj.Frame != nil:
+		// Suspended at another await
+	default:
+		vm.resolvePromise(promiseObj, result)
+	}
+}
+
+// finallyState is the completion - a return, a throw, breaks and continues -
+// that the finally blocks of the running function are carrying out. An async
+// function suspended in a finally block keeps its own, so that the code
+// running in the meantime neither loses nor carries it out.
+type finallyState struct {
+	pendingAction   PendingAction
+	pendingValue    Value
+	finallyDepth    int
+	completionStack []Completion
+}
+
+// swapFinallyState makes s the running function's finally state, and
+// returns the one it replaces
+func (vm *VM) swapFinallyState(s finallyState) finallyState {
+	old := finallyState{
+		pendingAction:   vm.pendingAction,
+		pendingValue:    vm.pendingValue,
+		finallyDepth:    vm.finallyDepth,
+		completionStack: vm.completionStack,
+	}
+	vm.pendingAction = s.pendingAction
+	vm.pendingValue = s.pendingValue
+	vm.finallyDepth = s.finallyDepth
+	vm.completionStack = s.completionStack
+	return old
+}
@@ -1,6 +1,10 @@
 package vm
 
-import "github.com/nooga/paserati/pkg/runtime"
+import (
+	"fmt"
+
+	"github.com/nooga/paserati/pkg/runtime"
+)
 
 // SetAsyncRuntime sets the async execution runtime
 func (vm *VM) SetAsyncRuntime(rt runtime.AsyncRuntime) {
@@ -26,3 +30,93 @@ func (vm *VM) DrainMicrotasks() {
 		}
 	}
 }
+
+// RunDueTimers runs every macrotask whose deadline has passed according to the
+// runtime's clock, draining microtasks after each one. It never blocks, which
+// makes it the building block for deterministic tests driven by a ManualClock.
+func (vm *VM) RunDueTimers() error {
+	vm.DrainMicrotasks()
+	timers, ok := vm.GetAsyncRuntime().(runtime.TimerRuntime)
+	if !ok {
+		return nil
+	}
+	for {
+		if vm.cancelled.Load() {
+			return fmt.Errorf("VM execution cancelled")
+		}
+		if term := vm.pollLimits(); term != nil {
+			return term
+		}
+		ran, err := timers.RunNextTimer()
+		if err != nil {
+			return vm.asyncCallbackError(err)
+		}
+		if !ran {
+			return nil
+		}
+		vm.DrainMicrotasks()
+	}
+}
+
+// RunEventLoop keeps running microtasks, timers and external operation
+// completions until none remain. Returns the first uncaught exception raised
+// by a timer callback, or an error if the VM was cancelled.
+func (vm *VM) RunEventLoop() error {
+	for {
+		progressed, err := vm.RunEventLoopTurn()
+		if err != nil {
+			return err
+		}
+		if !progressed {
+			return nil
+		}
+	}
+}
+
+// RunEventLoopTurn drains microtasks and then either runs one due macrotask or
+// blocks until a timer or external operation can make progress.
+// Returns false once there is no pending work left.
+func (vm *VM) RunEventLoopTurn() (bool, error) {
+	if vm.cancelled.Load() {
+		return false, fmt.Errorf("VM execution cancelled")
+	}
+	if term := vm.pollLimits(); term != nil {
+		return false, term
+	}
+	vm.DrainMicrotasks()
+	if term := vm.pollLimits(); term != nil {
+		return false, term
+	}
+
+	rt := vm.GetAsyncRuntime()
+	timers, hasTimers := rt.(runtime.TimerRuntime)
+	if hasTimers {
+		ran, err := timers.RunNextTimer()
+		if err != nil {
+			return false, vm.asyncCallbackError(err)
+		}
+		if ran {
+			return true, nil
+		}
+		if timers.HasPendingTimers() || rt.HasPendingExternalOps() {
+			timers.WaitForEvent()
+			return true, nil
+		}
+		return false, nil
+	}
+
+	if rt.HasPendingExternalOps() {
+		rt.WaitForExternalOp()
+		return true, nil
+	}
+	return false, nil
+}
+
+// asyncCallbackError converts an error escaping a macrotask callback into an
+// "Uncaught exception" error carrying the thrown value's display form
+func (vm *VM) asyncCallbackError(err error) error {
+	if ee, ok := err.(ExceptionError); ok {
+		return fmt.Errorf("Uncaught exception: %s", vm.formatExceptionDisplay(ee.GetExceptionValue()))
+	}
+	return err
+}
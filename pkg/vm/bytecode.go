@@ -4,6 +4,7 @@ import (
 	"fmt"
 	// "github.com/nooga/paserati/pkg/value" // No longer needed
 	//"github.com/nooga/paserati/pkg/value"
+	"sort"
 	"strings"
 )
 
@@ -179,8 +180,11 @@ const (
 	OpLoadNewTarget OpCode = 81 // Rx: Load 'new.target' value from current call context into register Rx
 
 	// --- Decorator Support ---
-	OpMakeAddInitializer OpCode = 166 // Rx Ry: Create addInitializer function in Rx that pushes to array in Ry
-	OpRunInitializers    OpCode = 167 // Rx ThisReg: Run all initializer functions in array Rx with 'this' = ThisReg
+	OpMakeAddInitializer      OpCode = 166 // Rx Ry: Create addInitializer function in Rx that pushes to array in Ry
+	OpRunInitializers         OpCode = 167 // Rx ThisReg: Run all initializer functions in array Rx with 'this' = ThisReg
+	OpApplyInitializers       OpCode = 152 // Rx ArrayReg ThisReg: Rx = each initializer in array ArrayReg called with 'this' = ThisReg on Rx (accessor decorators' init)
+	OpMakeDecoratorMetadata   OpCode = 153 // Rx Ry: Rx = new decorator metadata object inheriting Ry[Symbol.metadata] (Ry = the superclass, or undefined)
+	OpDefineDecoratorMetadata OpCode = 154 // Rx Ry: Define Rx[Symbol.metadata] = Ry on the class Rx
 	// --- END Decorator Support ---
 	// --- END NEW ---
 
@@ -200,6 +204,20 @@ const (
 	// Rx Ry Rz: step the built-in iterator behind next-method Rz: Rx = value, Ry = done. No call, no result object.
 	OpFastIterNext OpCode = 173
 
+	// --- Explicit resource management (using / await using, see dispose.go) ---
+	// Rx: Rx = a new, empty dispose scope
+	OpNewDisposeScope OpCode = 147
+	// Rx Ry: add the value Ry of a using declaration to the dispose scope Rx
+	OpAddDisposable OpCode = 148
+	// Rx Ry: add the value Ry of an await using declaration to the dispose scope Rx
+	OpAddAsyncDisposable OpCode = 149
+	// Rx Ry Rz: dispose of scope Rx's resources; throws the error of the scope's body Ry
+	// (if Rz), suppressed by the errors of the disposals, if any
+	OpDisposeResources OpCode = 150
+	// Rx Ry Rz Rw: like OpDisposeResources, awaiting async disposals: Rx = a promise
+	// rejected with what OpDisposeResources would throw, for OpAwait
+	OpDisposeResourcesAsync OpCode = 151
+
 	// --- NEW: Global Variable Operations ---
 	OpGetGlobal     OpCode = 46 // Rx GlobalIdx(16bit): Rx = Globals[GlobalIdx] (direct indexed access)
 	OpSetGlobal     OpCode = 47 // GlobalIdx(16bit) Ry: Globals[GlobalIdx] = Ry (direct indexed access)
@@ -307,7 +325,12 @@ const (
 	OpSetUpvalue16 OpCode = 136 // UpvalueIdxHi UpvalueIdxLo Ry: Store value from register Ry into upvalue at 16-bit index
 
 	// --- Class Validation ---
-	OpValidateSuperclass OpCode = 144 // Rx: Validate that Rx is a valid superclass (callable constructor or null), throws TypeError if not
+	OpValidateSuperclass OpCode = 144 // Rx Ry: Validate that Rx is a valid superclass (callable constructor or null), throws TypeError if not; Ry = its prototype
+
+	// --- Debugging ---
+	// Trap: planted over an instruction by VM.SetBreakpoint (the original opcode is kept in
+	// the chunk's breakpoint table), or compiled from a debugger statement when debug info is on
+	OpDebug OpCode = 255
 )
 
 // String returns a human-readable name for the OpCode.
@@ -361,6 +384,18 @@ func (op OpCode) String() string {
 		return "OpIterFastCheck"
 	case OpFastIterNext:
 		return "OpFastIterNext"
+	case OpNewDisposeScope:
+		return "OpNewDisposeScope"
+	case OpAddDisposable:
+		return "OpAddDisposable"
+	case OpAddAsyncDisposable:
+		return "OpAddAsyncDisposable"
+	case OpDisposeResources:
+		return "OpDisposeResources"
+	case OpDisposeResourcesAsync:
+		return "OpDisposeResourcesAsync"
+	case OpDebug:
+		return "OpDebug"
 	case OpEqual:
 		return "OpEqual"
 	case OpNotEqual:
@@ -407,6 +442,8 @@ func (op OpCode) String() string {
 		return "OpDefineMethod"
 	case OpIn:
 		return "OpIn"
+	case OpInstanceof:
+		return "OpInstanceof"
 	case OpRemainder:
 		return "OpRemainder"
 	case OpExponent:
@@ -537,6 +574,12 @@ func (op OpCode) String() string {
 		return "OpMakeAddInitializer"
 	case OpRunInitializers:
 		return "OpRunInitializers"
+	case OpApplyInitializers:
+		return "OpApplyInitializers"
+	case OpMakeDecoratorMetadata:
+		return "OpMakeDecoratorMetadata"
+	case OpDefineDecoratorMetadata:
+		return "OpDefineDecoratorMetadata"
 	case OpNew:
 		return "OpNew"
 	case OpSpreadNew:
@@ -759,14 +802,14 @@ type ScopeDescriptor struct {
 type Chunk struct {
 	Code           []byte             // The bytecode instructions (OpCodes and operands)
 	Constants      []Value            // Constant pool (Now uses Value from vm package)
-	Lines          []int              // Line number for each byte in Code (parallel array)
+	Positions      []PositionRun      // Source positions of Code, one run per change of position
 	ExceptionTable []ExceptionHandler // Exception handlers for try/catch blocks
 	IsStrict              bool               // Whether this chunk runs in strict mode
 	HasSimpleParameterList bool              // True if all params are plain identifiers (no defaults, rest, or destructuring)
 	ScopeDesc             *ScopeDescriptor   // Scope info for direct eval (nil if not needed)
 	MaxRegs        int                // Maximum registers needed to execute this chunk
 	NumSpillSlots  int                // Number of spill slots needed (for register overflow)
-	currentLine    int                // Current line for operand bytes (internal use)
+	breakpoints    map[int]OpCode     // Original opcodes of instructions replaced by OpDebug traps, keyed by offset
 	// Inline caches for property access sites within this chunk, indexed by bytecode offset
 	// (the IP where the opcode starts). This avoids a global map lookup per property access.
 	propInlineCaches []*PropInlineCache
@@ -779,16 +822,34 @@ type Chunk struct {
 	floatConstCache   map[float64]uint16 // Cache for float constants
 }
 
-// GetLine returns the source line number corresponding to a given bytecode offset.
-// It assumes the Lines slice is populated correctly (same length as Code, storing line per OpCode).
+// PositionRun attributes the bytecode from Offset up to the next run's
+// offset to a source position. Lines and columns are 1-based; a zero column
+// means only the line is known.
+type PositionRun struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// GetLine returns the source line number corresponding to a given bytecode offset,
+// or 0 if the offset is outside the code
 func (c *Chunk) GetLine(offset int) int {
-	// Basic bounds check
-	if offset < 0 || offset >= len(c.Lines) {
-		// Return 0 or -1 to indicate an invalid offset or missing line info?
-		// Let's return 0, assuming line numbers are 1-based.
-		return 0
+	line, _ := c.GetPosition(offset)
+	return line
+}
+
+// GetPosition returns the source line and column corresponding to a given
+// bytecode offset, or 0, 0 if the offset is outside the code
+func (c *Chunk) GetPosition(offset int) (line, column int) {
+	if offset < 0 || offset >= len(c.Code) {
+		return 0, 0
 	}
-	return c.Lines[offset]
+	// The last run starting at or before offset
+	i := sort.Search(len(c.Positions), func(i int) bool { return c.Positions[i].Offset > offset }) - 1
+	if i < 0 {
+		return 0, 0
+	}
+	return c.Positions[i].Line, c.Positions[i].Column
 }
 
 // NewChunk creates a new, empty Chunk.
@@ -796,7 +857,6 @@ func NewChunk() *Chunk {
 	return &Chunk{
 		Code:             make([]byte, 0),
 		Constants:        make([]Value, 0),
-		Lines:            make([]int, 0),
 		ExceptionTable:   make([]ExceptionHandler, 0),
 		VarGlobalIndices: make([]uint16, 0),
 	}
@@ -816,26 +876,29 @@ func (c *Chunk) AddVarGlobalIndex(idx uint16) {
 // WriteOpCode adds an opcode to the chunk.
 // The line number is tracked for error reporting.
 func (c *Chunk) WriteOpCode(op OpCode, line int) {
+	c.WriteOpCodeAt(op, line, 0)
+}
+
+// WriteOpCodeAt adds an opcode to the chunk, attributing it and its operands
+// to a source line and column.
+func (c *Chunk) WriteOpCodeAt(op OpCode, line, column int) {
+	if n := len(c.Positions); n == 0 || c.Positions[n-1].Line != line || c.Positions[n-1].Column != column {
+		c.Positions = append(c.Positions, PositionRun{Offset: len(c.Code), Line: line, Column: column})
+	}
 	c.Code = append(c.Code, byte(op))
-	c.Lines = append(c.Lines, line)
-	c.currentLine = line // Track for subsequent operand bytes
 }
 
 // EmitByte adds a raw byte (operand) to the chunk.
-// Uses the line number from the most recent WriteOpCode call.
+// It belongs to the position of the most recent WriteOpCode call.
 // Note: Named EmitByte instead of WriteByte to avoid conflict with io.ByteWriter interface.
 func (c *Chunk) EmitByte(b byte) {
 	c.Code = append(c.Code, b)
-	c.Lines = append(c.Lines, c.currentLine) // Keep Lines parallel to Code
 }
 
 // WriteUint16 adds a 16-bit unsigned integer operand (e.g., for larger constant indices or jump offsets).
-// Encoded as Big Endian. Uses the line number from the most recent WriteOpCode call.
+// Encoded as Big Endian. It belongs to the position of the most recent WriteOpCode call.
 func (c *Chunk) WriteUint16(val uint16) {
-	c.Code = append(c.Code, byte(val>>8))
-	c.Lines = append(c.Lines, c.currentLine)
-	c.Code = append(c.Code, byte(val&0xff))
-	c.Lines = append(c.Lines, c.currentLine)
+	c.Code = append(c.Code, byte(val>>8), byte(val&0xff))
 }
 
 // AddConstant adds a value to the chunk's constant pool and returns its index.
@@ -980,16 +1043,16 @@ func (c *Chunk) disassembleInstruction(builder *strings.Builder, offset int) int
 		return offset + 1 // Avoid infinite loop if offset is already bad
 	}
 
-	instruction := OpCode(c.Code[offset])
+	instruction := c.OriginalOpCode(offset)
 	switch instruction {
 	case OpNop:
 		builder.WriteString(fmt.Sprintf("%04d    OpNop\n", offset))
 		return offset + 1 // OpNop has no operands
 	case OpLoadConst:
 		return c.registerConstantInstruction(builder, instruction.String(), offset, true)
-	case OpLoadNull, OpLoadUndefined, OpLoadTrue, OpLoadFalse, OpReturn, OpMakeEmptyObject, OpLoadUninitialized, OpCheckUninitialized, OpCloseUpvalue, OpIteratorCleanupAbrupt, OpValidateSuperclass:
+	case OpLoadNull, OpLoadUndefined, OpLoadTrue, OpLoadFalse, OpReturn, OpMakeEmptyObject, OpLoadUninitialized, OpCheckUninitialized, OpCloseUpvalue, OpIteratorCleanupAbrupt:
 		return c.registerInstruction(builder, instruction.String(), offset) // Rx
-	case OpMakeAddInitializer, OpRunInitializers:
+	case OpMakeAddInitializer, OpRunInitializers, OpValidateSuperclass, OpMakeDecoratorMetadata, OpDefineDecoratorMetadata:
 		return c.registerRegisterInstruction(builder, instruction.String(), offset) // Rx Ry
 	case OpNegate, OpNot, OpTypeof, OpToNumber, OpToNumeric, OpLoadNumericOne, OpBitwiseNot, OpGetLength, OpIsNull, OpIsUndefined, OpIsNullish, OpIteratorCleanupAbruptIfNotDone,
 		OpIncPre, OpIncPost, OpDecPre, OpDecPost:
@@ -998,15 +1061,24 @@ func (c *Chunk) disassembleInstruction(builder *strings.Builder, offset int) int
 		return c.registerRegisterInstruction(builder, instruction.String(), offset) // Rx, Ry
 	case OpAdd, OpSubtract, OpMultiply, OpDivide, OpStringConcat, OpEqual, OpNotEqual, OpStrictEqual, OpStrictNotEqual, OpGreater, OpLess, OpLessEqual, OpGreaterEqual,
 		OpRemainder, OpExponent,
-		OpIn, OpInstanceof,
+		OpIn, OpInstanceof, OpDeleteIndex, OpCopyObjectExcluding,
 		OpBitwiseAnd, OpBitwiseOr, OpBitwiseXor,
 		OpShiftLeft, OpShiftRight, OpUnsignedShiftRight,
-		OpIterFastCheck:
+		OpIterFastCheck, OpApplyInitializers:
 		return c.registerRegisterRegisterInstruction(builder, instruction.String(), offset) // Rx, Ry, Rz
 
-	case OpFastIterNext:
+	case OpFastIterNext, OpDisposeResourcesAsync:
 		return c.registerRegisterRegisterRegisterInstruction(builder, instruction.String(), offset) // Rx, Ry, Rz, Rw
 
+	case OpNewDisposeScope:
+		return c.registerInstruction(builder, instruction.String(), offset) // Rx
+
+	case OpAddDisposable, OpAddAsyncDisposable:
+		return c.registerRegisterInstruction(builder, instruction.String(), offset) // Rx, Ry
+
+	case OpDisposeResources:
+		return c.registerRegisterRegisterInstruction(builder, instruction.String(), offset) // Rx, Ry, Rz
+
 	case OpCall, OpTailCall:
 		return c.callInstruction(builder, instruction.String(), offset)
 	case OpCallMethod, OpTailCallMethod:
@@ -1077,9 +1149,9 @@ func (c *Chunk) disassembleInstruction(builder *strings.Builder, offset int) int
 	case OpDeleteGlobal:
 		return c.registerConstantInstruction(builder, instruction.String(), offset, true)
 	case OpToPropertyKey:
-		return c.simpleInstruction(builder, instruction.String(), offset)
+		return c.registerRegisterInstruction(builder, instruction.String(), offset)
 	case OpTypeofIdentifier:
-		return c.registerConstantInstruction(builder, instruction.String(), offset, false)
+		return c.registerConstantInstruction(builder, instruction.String(), offset, true)
 	case OpGetPrivateField:
 		return c.registerRegisterConstantInstruction(builder, instruction.String(), offset, "NameIdx")
 	case OpSetPrivateField:
@@ -1393,6 +1465,9 @@ func (c *Chunk) disassembleInstruction(builder *strings.Builder, offset int) int
 		builder.WriteString(fmt.Sprintf("%-20s CallerR%d R%d\n", "OpSetCallerLocal", callerReg, srcReg))
 		return offset + 3
 
+	case OpDebug:
+		return c.simpleInstruction(builder, instruction.String(), offset)
+
 	default:
 		builder.WriteString(fmt.Sprintf("Unknown opcode %d\n", instruction))
 		return offset + 1 // Advance by 1 for unknown opcodes
@@ -0,0 +1,216 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// DebugHook is notified by the VM of the chunks it starts running and of the
+// OpDebug traps it reaches. Both methods are called on the goroutine running
+// the VM and may block: execution resumes when they return. While blocked, the
+// hook may inspect frames, evaluate code and set or clear breakpoints.
+type DebugHook interface {
+	// EnterChunk is called before a top-level script or module chunk runs.
+	// path is the module path the chunk was compiled from, if known.
+	EnterChunk(chunk *Chunk, path string)
+	// Trap is called when execution reaches a breakpoint or a debugger
+	// statement at the given instruction offset of chunk. The trapped
+	// instruction runs when Trap returns.
+	Trap(chunk *Chunk, ip int)
+}
+
+// SetDebugHook installs the hook notified of traps and entered chunks, or
+// removes it when hook is nil. Without a hook, debugger statements are no-ops
+// and reaching a breakpoint stops the run with InterpretBreakpoint.
+func (vm *VM) SetDebugHook(hook DebugHook) {
+	vm.debugHook = hook
+}
+
+// SetBreakpoint plants an OpDebug trap over the instruction starting at ip.
+// ip must be an instruction boundary, see Chunk.InstructionOffsets. Setting a
+// breakpoint twice is a no-op. Breakpoints patch the shared bytecode, so they
+// may only be changed while the VM is idle or from within its DebugHook.
+func (vm *VM) SetBreakpoint(chunk *Chunk, ip int) error {
+	if ip < 0 || ip >= len(chunk.Code) {
+		return fmt.Errorf("breakpoint offset %d out of range (code length %d)", ip, len(chunk.Code))
+	}
+	if _, ok := chunk.breakpoints[ip]; ok {
+		return nil
+	}
+	if chunk.breakpoints == nil {
+		chunk.breakpoints = make(map[int]OpCode)
+	}
+	chunk.breakpoints[ip] = OpCode(chunk.Code[ip])
+	chunk.Code[ip] = byte(OpDebug)
+	return nil
+}
+
+// ClearBreakpoint removes the trap planted at ip, restoring the original
+// instruction. Clearing an offset without a breakpoint is a no-op.
+func (vm *VM) ClearBreakpoint(chunk *Chunk, ip int) {
+	orig, ok := chunk.breakpoints[ip]
+	if !ok {
+		return
+	}
+	chunk.Code[ip] = byte(orig)
+	delete(chunk.breakpoints, ip)
+}
+
+// HasBreakpoint reports whether a trap planted by SetBreakpoint is at ip
+func (c *Chunk) HasBreakpoint(ip int) bool {
+	_, ok := c.breakpoints[ip]
+	return ok
+}
+
+// OriginalOpCode returns the opcode at offset, looking through a breakpoint
+// trap to the instruction it replaced
+func (c *Chunk) OriginalOpCode(offset int) OpCode {
+	if orig, ok := c.breakpoints[offset]; ok {
+		return orig
+	}
+	return OpCode(c.Code[offset])
+}
+
+// InstructionOffsets returns the offset of every instruction in the chunk, in
+// order. These are the offsets at which breakpoints may be set.
+func (c *Chunk) InstructionOffsets() []int {
+	var offsets []int
+	var scratch strings.Builder
+	for offset := 0; offset < len(c.Code); {
+		offsets = append(offsets, offset)
+		scratch.Reset()
+		offset = c.disassembleInstruction(&scratch, offset)
+	}
+	return offsets
+}
+
+// DebugFrame describes an active script frame as seen by a debugger
+type DebugFrame struct {
+	Function  *FunctionObject
+	IP        int     // Offset of the instruction the frame is executing
+	Registers []Value // The frame's register window
+	This      Value
+}
+
+// Line returns the source line of the instruction the frame is executing
+func (f DebugFrame) Line() int {
+	return f.Function.Chunk.GetLine(f.IP)
+}
+
+// Column returns the source column of the instruction the frame is
+// executing, or 0 if it is not known
+func (f DebugFrame) Column() int {
+	_, column := f.Function.Chunk.GetPosition(f.IP)
+	return column
+}
+
+// DebugFrames returns the active script frames, innermost first. Native and
+// sentinel frames are skipped. It is meant to be called from a DebugHook,
+// where the innermost frame is the one that reached the trap.
+func (vm *VM) DebugFrames() []DebugFrame {
+	indices := vm.debugFrameIndices()
+	frames := make([]DebugFrame, len(indices))
+	for i, idx := range indices {
+		frame := &vm.frames[idx]
+		ip := frame.ip
+		if idx != vm.frameCount-1 && ip > 0 {
+			// Callers are suspended past their call instruction
+			ip--
+		}
+		frames[i] = DebugFrame{
+			Function:  frame.closure.Fn,
+			IP:        ip,
+			Registers: frame.registers,
+			This:      frame.thisValue,
+		}
+	}
+	return frames
+}
+
+// debugFrameIndices returns the indices in vm.frames of the frames reported
+// by DebugFrames
+func (vm *VM) debugFrameIndices() []int {
+	var indices []int
+	for i := vm.frameCount - 1; i >= 0; i-- {
+		frame := &vm.frames[i]
+		if frame.isNativeFrame || frame.isSentinelFrame || frame.closure == nil || frame.closure.Fn == nil {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// EvalInFrame evaluates code as if by a direct eval in the given frame, where
+// depth indexes the frames returned by DebugFrames. The frame's locals are in
+// scope when its chunk was compiled with a ScopeDescriptor. A thrown exception
+// is returned as an ExceptionError. Evaluating leaves the state of the paused
+// run, including an exception being unwound, untouched.
+func (vm *VM) EvalInFrame(depth int, code string) (Value, error) {
+	if vm.evalDriver == nil {
+		return Undefined, fmt.Errorf("eval: evalDriver is nil")
+	}
+	indices := vm.debugFrameIndices()
+	if depth < 0 || depth >= len(indices) {
+		return Undefined, fmt.Errorf("no frame at depth %d", depth)
+	}
+	frame := &vm.frames[indices[depth]]
+	chunk := frame.closure.Fn.Chunk
+
+	savedErrors := append([]errors.PaseratiError(nil), vm.errors...)
+	savedUnwinding, savedCrossedNative, savedException := vm.unwinding, vm.unwindingCrossedNative, vm.currentException
+	savedRegs, savedThis, savedHasThis, savedHome := vm.evalCallerRegs, vm.evalCallerThis, vm.hasEvalCallerThis, vm.evalCallerHomeObject
+	savedFrameCount, savedRegSlot := vm.frameCount, vm.nextRegSlot
+	vm.unwinding, vm.unwindingCrossedNative, vm.currentException = false, false, Null
+
+	var result Value
+	var errs []error
+	if chunk.ScopeDesc != nil {
+		this := frame.thisValue
+		if !chunk.IsStrict && (this.Type() == TypeUndefined || this.Type() == TypeNull) {
+			if globalThis, ok := vm.GetGlobal("globalThis"); ok {
+				this = globalThis
+			}
+		}
+		result, errs = vm.evalDriver.DirectEvalCode(code, chunk.IsStrict, chunk.ScopeDesc, frame.registers, this, frame.homeObject)
+	} else {
+		result, errs = vm.evalDriver.EvalCode(code, chunk.IsStrict)
+	}
+	thrown := vm.currentException
+	// An uncaught throw stops at the eval frame's boundary without popping it
+	vm.popFramesTo(savedFrameCount, savedRegSlot)
+
+	vm.errors = savedErrors
+	vm.unwinding, vm.unwindingCrossedNative, vm.currentException = savedUnwinding, savedCrossedNative, savedException
+	vm.evalCallerRegs, vm.evalCallerThis, vm.hasEvalCallerThis, vm.evalCallerHomeObject = savedRegs, savedThis, savedHasThis, savedHome
+
+	if thrown.Type() != TypeUndefined && thrown.Type() != TypeNull {
+		return Undefined, exceptionError{exception: thrown}
+	}
+	if len(errs) > 0 {
+		return Undefined, errs[0]
+	}
+	return result, nil
+}
+
+// debugEnterChunk notifies the hook of a chunk about to run. A run cancelled
+// while the hook was paused is terminated before its first instruction.
+func (vm *VM) debugEnterChunk(chunk *Chunk, path string) {
+	vm.debugHook.EnterChunk(chunk, path)
+	vm.pollLimits()
+}
+
+// debugTrap handles an OpDebug reached at trapIP and returns the opcode to
+// dispatch in its place. OpDebug is returned for a debugger statement, which
+// has no instruction of its own to run, and when the run was cancelled while
+// the hook was paused, so the termination is raised first.
+func (vm *VM) debugTrap(chunk *Chunk, trapIP int) OpCode {
+	vm.debugHook.Trap(chunk, trapIP)
+	if vm.pollLimits() != nil {
+		return OpDebug
+	}
+	// The hook may have cleared the breakpoint while it was paused
+	return chunk.OriginalOpCode(trapIP)
+}
@@ -0,0 +1,180 @@
+package vm
+
+// Explicit resource management. `using` and `await using` declarations add
+// their values to a dispose scope, which the compiler creates for the
+// statement list declaring them (OpNewDisposeScope, OpAddDisposable,
+// OpAddAsyncDisposable), and whose resources are disposed of, in reverse
+// order, when the list is left, however it is left (OpDisposeResources,
+// OpDisposeResourcesAsync). An error disposing of a resource doesn't stop
+// the others from being disposed of: the errors are chained into
+// SuppressedErrors, the later one suppressing the earlier. DisposableStack
+// and AsyncDisposableStack keep their resources the same way.
+
+// DisposableResource is a value added to a dispose scope, with the method
+// disposing of it
+type DisposableResource struct {
+	Value  Value
+	Method Value // Undefined for null and undefined added by await using
+	Async  bool  // Whether Method is a Symbol.asyncDispose, whose result is awaited
+}
+
+// NewDisposableResource finds the method disposing of value, for `using`
+// or, when async, `await using`: Symbol.asyncDispose, falling back to
+// Symbol.dispose when async. Null and undefined are nothing to dispose of:
+// ok is false for them, except for await using, which still awaits them.
+func (vm *VM) NewDisposableResource(value Value, async bool) (resource DisposableResource, ok bool, err error) {
+	if value.Type() == TypeNull || value.Type() == TypeUndefined {
+		return DisposableResource{Value: value, Method: Undefined}, async, nil
+	}
+	if !value.IsObject() {
+		return DisposableResource{}, false, vm.NewTypeError("using declarations can only hold objects, null or undefined")
+	}
+	if async {
+		method, _, err := vm.GetSymbolPropertyWithGetter(value, vm.SymbolAsyncDispose)
+		if err != nil {
+			return DisposableResource{}, false, err
+		}
+		if method != Undefined && method != Null {
+			if !method.IsCallable() {
+				return DisposableResource{}, false, vm.NewTypeError("Symbol.asyncDispose is not a function")
+			}
+			return DisposableResource{Value: value, Method: method, Async: true}, true, nil
+		}
+	}
+	method, _, err := vm.GetSymbolPropertyWithGetter(value, vm.SymbolDispose)
+	if err != nil {
+		return DisposableResource{}, false, err
+	}
+	if !method.IsCallable() {
+		if async {
+			return DisposableResource{}, false, vm.NewTypeError("Object is not async disposable: it has neither Symbol.asyncDispose nor Symbol.dispose")
+		}
+		return DisposableResource{}, false, vm.NewTypeError("Object is not disposable: Symbol.dispose is not a function")
+	}
+	return DisposableResource{Value: value, Method: method}, true, nil
+}
+
+// DisposeResources disposes of resources in reverse order, calling their
+// methods without awaiting them. err is the error the scope was left with,
+// if hasErr; the error it is left with after the disposals is returned.
+func (vm *VM) DisposeResources(resources []DisposableResource, err Value, hasErr bool) (Value, bool) {
+	for i := len(resources) - 1; i >= 0; i-- {
+		r := resources[i]
+		if r.Method == Undefined {
+			continue
+		}
+		if _, callErr := vm.Call(r.Method, r.Value, nil); callErr != nil {
+			err, hasErr = vm.suppressError(vm.errorValue(callErr), err, hasErr), true
+		}
+	}
+	return err, hasErr
+}
+
+// DisposeResourcesAsync is DisposeResources awaiting the results of
+// Symbol.asyncDispose methods, one resource after the other. The promise
+// returned is rejected with the error the scope is left with, if any.
+func (vm *VM) DisposeResourcesAsync(resources []DisposableResource, err Value, hasErr bool) Value {
+	promiseVal := vm.NewPendingPromise()
+	promise := promiseVal.AsPromise()
+	var step func(i int)
+	step = func(i int) {
+		for ; i >= 0; i-- {
+			r := resources[i]
+			if r.Method == Undefined {
+				continue
+			}
+			result, callErr := vm.Call(r.Method, r.Value, nil)
+			if callErr != nil {
+				err, hasErr = vm.suppressError(vm.errorValue(callErr), err, hasErr), true
+				continue
+			}
+			if r.Async && result.Type() == TypePromise {
+				next := i - 1
+				vm.AddPromiseReaction(result, true, func(Value) { step(next) })
+				vm.AddPromiseReaction(result, false, func(reason Value) {
+					err, hasErr = vm.suppressError(reason, err, hasErr), true
+					step(next)
+				})
+				return
+			}
+		}
+		if hasErr {
+			vm.rejectPromise(promise, err)
+		} else {
+			vm.resolvePromise(promise, Undefined)
+		}
+	}
+	step(len(resources) - 1)
+	return promiseVal
+}
+
+// suppressError returns the error a scope is left with when disposing of
+// one of its resources fails with err: err itself, or a SuppressedError of
+// err suppressing the error the scope was already left with
+func (vm *VM) suppressError(err, suppressed Value, hasSuppressed bool) Value {
+	if !hasSuppressed {
+		return err
+	}
+	if ctor, _ := vm.GetGlobal("SuppressedError"); ctor != Undefined {
+		prevNewTarget := vm.currentNewTarget
+		vm.currentNewTarget = Undefined
+		errObj, callErr := vm.Call(ctor, Undefined, []Value{err, suppressed})
+		vm.currentNewTarget = prevNewTarget
+		if callErr == nil {
+			return errObj
+		}
+	}
+	// Fallback generic error object
+	obj := NewObject(vm.ErrorPrototype).AsPlainObject()
+	obj.SetOwn("name", NewString("SuppressedError"))
+	obj.SetOwn("message", NewString("An error was suppressed during disposal"))
+	obj.SetOwn("error", err)
+	obj.SetOwn("suppressed", suppressed)
+	return NewValueFromPlainObject(obj)
+}
+
+// errorValue returns the value thrown for err
+func (vm *VM) errorValue(err error) Value {
+	if ee, ok := err.(ExceptionError); ok {
+		return ee.GetExceptionValue()
+	}
+	return NewString(err.Error())
+}
+
+// disposeScope returns the resources of the dispose scope value, an array of
+// value, method, async triples
+func disposeScope(scope Value) *ArrayObject {
+	if scope.Type() != TypeArray {
+		return nil
+	}
+	return scope.AsArray()
+}
+
+// addToDisposeScope adds value, checked by NewDisposableResource, to scope
+func (vm *VM) addToDisposeScope(scope, value Value, async bool) error {
+	arr := disposeScope(scope)
+	if arr == nil {
+		return nil
+	}
+	r, ok, err := vm.NewDisposableResource(value, async)
+	if err != nil || !ok {
+		return err
+	}
+	arr.Append(r.Value)
+	arr.Append(r.Method)
+	arr.Append(BooleanValue(r.Async))
+	return nil
+}
+
+// disposeScopeResources returns the resources scope holds
+func disposeScopeResources(scope Value) []DisposableResource {
+	arr := disposeScope(scope)
+	if arr == nil {
+		return nil
+	}
+	resources := make([]DisposableResource, 0, arr.Length()/3)
+	for i := 0; i+2 < arr.Length(); i += 3 {
+		resources = append(resources, DisposableResource{Value: arr.Get(i), Method: arr.Get(i + 1), Async: arr.Get(i + 2).IsTruthy()})
+	}
+	return resources
+}
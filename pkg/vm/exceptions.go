@@ -63,8 +63,7 @@ func (vm *VM) throwException(value Value) {
 		vm.unwindingCrossedNative = false
 		// Capture throw location from current frame before unwinding starts
 		if vm.frameCount > 0 {
-			vm.lastThrowLine, vm.lastThrowFuncName = vm.getFrameLineInfo(&vm.frames[vm.frameCount-1])
-			vm.lastThrowColumn = 1 // Column tracking not implemented yet
+			vm.lastThrowLine, vm.lastThrowColumn, vm.lastThrowFuncName = vm.getFrameLineInfo(&vm.frames[vm.frameCount-1])
 		} else {
 			vm.lastThrowLine = 1
 			vm.lastThrowColumn = 1
@@ -279,11 +278,11 @@ func (vm *VM) handleFinallyBlock(handler *ExceptionHandler) {
 	// check for pending actions and execute them appropriately.
 }
 
-// getFrameLineInfo extracts the line number from a frame at the current instruction position
-// Returns (line, functionName) where line is 1 if no info available
-func (vm *VM) getFrameLineInfo(frame *CallFrame) (int, string) {
+// getFrameLineInfo extracts the position of a frame's current instruction
+// Returns (line, column, functionName) where line and column are 1 if no info available
+func (vm *VM) getFrameLineInfo(frame *CallFrame) (int, int, string) {
 	if frame == nil || frame.closure == nil || frame.closure.Fn == nil {
-		return 1, "<script>"
+		return 1, 1, "<script>"
 	}
 
 	fn := frame.closure.Fn
@@ -293,32 +292,53 @@ func (vm *VM) getFrameLineInfo(frame *CallFrame) (int, string) {
 	}
 
 	if fn.Chunk == nil {
-		return 1, funcName
+		return 1, 1, funcName
 	}
+	ip := frame.ip
+	if ip >= 0 && ip < len(fn.Chunk.Code) && fn.Chunk.OriginalOpCode(ip) == OpThrow {
+		// OpThrow leaves ip at its own opcode for the handler lookup
+		ip++
+	}
+	line, column := executingPosition(fn.Chunk, ip)
+	if line == 0 {
+		line = 1
+	}
+	if column == 0 {
+		column = 1
+	}
+	return line, column, funcName
+}
 
-	chunk := fn.Chunk
+// executingPosition returns the source position of the instruction a frame
+// with the given ip is executing, or 0, 0 if the chunk has no position info
+func executingPosition(chunk *Chunk, ip int) (line, column int) {
 	// IP points to the NEXT instruction, error occurred at ip-1
-	instructionPos := frame.ip - 1
-
-	if instructionPos >= 0 && instructionPos < len(chunk.Lines) {
-		return chunk.GetLine(instructionPos), funcName
+	if line, column = chunk.GetPosition(ip - 1); line > 0 {
+		return line, column
 	}
 	// Fallback to ip itself if ip-1 is invalid
-	if frame.ip >= 0 && frame.ip < len(chunk.Lines) {
-		return chunk.GetLine(frame.ip), funcName
+	if line, column = chunk.GetPosition(ip); line > 0 {
+		return line, column
 	}
-	// Last resort: first line in chunk
-	if len(chunk.Lines) > 0 {
-		return chunk.Lines[0], funcName
+	// Last resort: first position in chunk
+	if len(chunk.Positions) > 0 {
+		return chunk.Positions[0].Line, chunk.Positions[0].Column
 	}
-
-	return 1, funcName
+	return 0, 0
 }
 
 // handleUncaughtException handles uncaught exceptions by terminating execution
 func (vm *VM) handleUncaughtException() {
 	// fmt.Printf("[DEBUG] handleUncaughtException called, exception=%s\n", vm.currentException.ToString())
 
+	// A native call that hit a resource limit has its termination surfaced as
+	// an exception; the termination, not the exception, is the run's error
+	if vm.termination != nil {
+		vm.terminate(vm.termination)
+		vm.frameCount = 0
+		return
+	}
+
 	// Use the stored throw location (captured in throwException before frames were popped)
 	line := vm.lastThrowLine
 	funcName := vm.lastThrowFuncName
@@ -392,6 +412,7 @@ func (vm *VM) handleUncaughtException() {
 		Msg:          errorMsg,
 		FunctionName: funcName,
 		FileName:     "<script>", // TODO: Add actual filename tracking
+		Cause:        exceptionError{exception: vm.currentException}, // Lets embedders recover the thrown value
 	}
 	vm.errors = append(vm.errors, runtimeErr)
 
@@ -401,6 +422,12 @@ func (vm *VM) handleUncaughtException() {
 	vm.frameCount = 0
 }
 
+// FormatException returns the display form of a thrown value ("Name: message"
+// for Error objects), as used in "Uncaught exception" reports
+func (vm *VM) FormatException(exception Value) string {
+	return vm.formatExceptionDisplay(exception)
+}
+
 // formatExceptionDisplay converts a thrown exception Value into a human-readable string.
 // For Error objects, it extracts name and message properties (matching Error.prototype.toString behavior).
 // For non-Error objects, it falls back to ToString().
@@ -508,12 +535,11 @@ func (vm *VM) getStackFrames() []StackFrame {
 			line := 1
 			column := 1
 			if fn.Chunk != nil {
-				instructionPos := frame.ip - 1
-				if instructionPos >= 0 && instructionPos < len(fn.Chunk.Lines) {
-					line = fn.Chunk.GetLine(instructionPos)
-				} else if frame.ip >= 0 && frame.ip < len(fn.Chunk.Lines) {
-					// Fallback to ip if ip-1 is invalid
-					line = fn.Chunk.GetLine(frame.ip)
+				if l, c := executingPosition(fn.Chunk, frame.ip); l > 0 {
+					line = l
+					if c > 0 {
+						column = c
+					}
 				}
 			}
 
@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"sort"
+
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// A module can be replaced while the program runs (see ReloadModule). The
+// version running can prepare for it through import.meta.hot:
+//
+//	import.meta.hot.data          an object kept across versions
+//	import.meta.hot.dispose(cb)   cb(data) runs before the next version does
+//	import.meta.hot.accept(cb?)   accepts being replaced; cb(namespace) runs
+//	                              with the next version's namespace
+//
+// The modules importing it see the next version's exports without running
+// again: imported names read the exporting module's globals on every use.
+
+// hotModule is the import.meta.hot state of a module
+type hotModule struct {
+	data     Value   // import.meta.hot.data, kept across versions
+	hot      Value   // import.meta.hot of the running version, Undefined until asked for
+	accepted bool    // Whether the running version called accept
+	accepts  []Value // Callbacks the running version passed to accept
+	disposes []Value // Callbacks the running version passed to dispose
+}
+
+// hotObject returns import.meta.hot for the module run as modulePath
+func (vm *VM) hotObject(modulePath string) Value {
+	if vm.hotModules == nil {
+		vm.hotModules = make(map[string]*hotModule)
+	}
+	hm := vm.hotModules[modulePath]
+	if hm == nil {
+		hm = &hotModule{data: NewObject(vm.ObjectPrototype), hot: Undefined}
+		vm.hotModules[modulePath] = hm
+	}
+	if hm.hot != Undefined {
+		return hm.hot
+	}
+
+	hot := NewObject(vm.ObjectPrototype).AsPlainObject()
+	hot.SetOwn("data", hm.data)
+	hot.SetOwn("accept", NewNativeFunction(1, false, "accept", func(args []Value) (Value, error) {
+		if len(args) > 0 && args[0] != Undefined {
+			if !args[0].IsCallable() {
+				return Undefined, vm.NewTypeError("import.meta.hot.accept: the callback must be a function; accepting updates of dependencies is not supported")
+			}
+			hm.accepts = append(hm.accepts, args[0])
+		}
+		hm.accepted = true
+		return Undefined, nil
+	}))
+	hot.SetOwn("dispose", NewNativeFunction(1, false, "dispose", func(args []Value) (Value, error) {
+		if len(args) == 0 || !args[0].IsCallable() {
+			return Undefined, vm.NewTypeError("import.meta.hot.dispose: the callback must be a function")
+		}
+		hm.disposes = append(hm.disposes, args[0])
+		return Undefined, nil
+	}))
+	hm.hot = NewValueFromPlainObject(hot)
+	return hm.hot
+}
+
+// ReloadModule runs the module whose running version was compiled to old
+// again, with the chunk the module loader has for it now: the running
+// version's dispose callbacks run first, then the new version, then the
+// running version's accept callbacks with the new version's namespace.
+// Reports whether the module had run, and so was reloaded, and whether the
+// version replaced had accepted it. A module that hasn't run yet runs its
+// new version when first imported. On errors the new version may have run
+// in part.
+func (vm *VM) ReloadModule(old *Chunk) (reloaded, accepted bool, errs []errors.PaseratiError) {
+	var paths []string
+	for path, ctx := range vm.moduleContexts {
+		if ctx.chunk == old && ctx.executed {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		hm := vm.hotModules[path]
+		var accepts []Value
+		if hm != nil {
+			for _, dispose := range hm.disposes {
+				if _, err := vm.Call(dispose, Undefined, []Value{hm.data}); err != nil {
+					return true, false, []errors.PaseratiError{vm.hotCallbackError(err)}
+				}
+			}
+			accepted, accepts = hm.accepted, hm.accepts
+			hm.hot, hm.accepted, hm.accepts, hm.disposes = Undefined, false, nil, nil
+		}
+
+		delete(vm.moduleContexts, path)
+		savedPath := vm.currentModulePath
+		status, _ := vm.executeModule(path)
+		vm.currentModulePath = savedPath
+		if status != InterpretOK {
+			errs = append(errs, vm.errors...)
+			vm.errors = vm.errors[:0]
+			return true, accepted, errs
+		}
+
+		if len(accepts) > 0 {
+			namespace := vm.createModuleNamespace(path)
+			for _, accept := range accepts {
+				if _, err := vm.Call(accept, Undefined, []Value{namespace}); err != nil {
+					return true, accepted, []errors.PaseratiError{vm.hotCallbackError(err)}
+				}
+			}
+		}
+	}
+	return len(paths) > 0, accepted, nil
+}
+
+// hotCallbackError converts an error escaping an import.meta.hot callback
+// into a runtime error
+func (vm *VM) hotCallbackError(err error) errors.PaseratiError {
+	return &errors.RuntimeError{Msg: vm.asyncCallbackError(err).Error(), Cause: err}
+}
@@ -0,0 +1,217 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/nooga/paserati/pkg/errors"
+)
+
+// Limits bounds the resources a single run may consume. Zero fields are
+// unlimited. Limits are armed with SetLimits and stay in effect, across nested
+// calls, microtasks and timer callbacks, until ClearLimits is called.
+type Limits struct {
+	// MaxInstructions caps the number of bytecode instructions executed
+	MaxInstructions int64
+	// Deadline is the wall-clock time at which execution is stopped
+	Deadline time.Time
+	// MaxCallDepth caps the number of active call frames. Values at or above
+	// MaxFrames have no effect: the VM's own stack overflow RangeError comes first.
+	MaxCallDepth int
+	// MaxAllocation caps the approximate number of bytes allocated for objects,
+	// array elements and strings. The count is cumulative over the run, not a
+	// measure of the live heap.
+	MaxAllocation int64
+}
+
+// TerminationReason identifies which limit stopped execution
+type TerminationReason int
+
+const (
+	TerminationCancelled TerminationReason = iota
+	TerminationInstructionLimit
+	TerminationDeadline
+	TerminationCallDepth
+	TerminationAllocationLimit
+)
+
+// TerminationError reports that the VM stopped a run because a limit was
+// exceeded or execution was cancelled. Unlike exceptions it cannot be caught
+// by script code: once raised, every frame of the run is abandoned.
+type TerminationError struct {
+	Reason TerminationReason
+	Limit  int64 // The exceeded limit; zero for deadline and cancellation
+}
+
+func (e *TerminationError) Error() string {
+	switch e.Reason {
+	case TerminationInstructionLimit:
+		return fmt.Sprintf("execution terminated: instruction limit of %d exceeded", e.Limit)
+	case TerminationDeadline:
+		return "execution terminated: deadline exceeded"
+	case TerminationCallDepth:
+		return fmt.Sprintf("execution terminated: maximum call depth of %d exceeded", e.Limit)
+	case TerminationAllocationLimit:
+		return fmt.Sprintf("execution terminated: allocation limit of %d bytes exceeded", e.Limit)
+	default:
+		return "VM execution cancelled"
+	}
+}
+
+// Approximate allocation costs, in bytes, charged against Limits.MaxAllocation
+// for each object and array element
+const (
+	ObjectAllocCost  = 64
+	ElementAllocCost = int64(unsafe.Sizeof(Value{}))
+)
+
+// limitPollInterval is the number of instructions between deadline and
+// context checks, which are too expensive to perform on every instruction
+const limitPollInterval = 1024
+
+// limitState is the bookkeeping for armed Limits
+type limitState struct {
+	Limits
+	done         <-chan struct{}
+	instructions int64
+	allocated    int64
+	nextPoll     int64
+	wakeTimer    *time.Timer
+	stopWake     func() bool
+}
+
+// SetLimits arms resource limits for subsequent execution and resets the
+// usage counters. The context's deadline, if earlier, replaces
+// limits.Deadline, and cancelling it terminates the run.
+func (vm *VM) SetLimits(ctx context.Context, limits Limits) {
+	vm.ClearLimits()
+	if deadline, ok := ctx.Deadline(); ok && (limits.Deadline.IsZero() || deadline.Before(limits.Deadline)) {
+		limits.Deadline = deadline
+	}
+	ls := &limitState{Limits: limits, done: ctx.Done(), nextPoll: limitPollInterval}
+
+	// The event loop may be blocked waiting for a far-off timer; wake it when
+	// the deadline passes or the context is cancelled so the run can stop.
+	rt := vm.GetAsyncRuntime()
+	wake := func() {
+		rt.BeginExternalOp()
+		rt.EndExternalOp()
+	}
+	if !limits.Deadline.IsZero() {
+		ls.wakeTimer = time.AfterFunc(time.Until(limits.Deadline), wake)
+	}
+	if ls.done != nil {
+		ls.stopWake = context.AfterFunc(ctx, wake)
+	}
+	vm.limits = ls
+}
+
+// ClearLimits disarms resource limits and forgets any termination, making the
+// VM usable for further runs.
+func (vm *VM) ClearLimits() {
+	if ls := vm.limits; ls != nil {
+		if ls.wakeTimer != nil {
+			ls.wakeTimer.Stop()
+		}
+		if ls.stopWake != nil {
+			ls.stopWake()
+		}
+	}
+	vm.limits = nil
+	vm.termination = nil
+}
+
+// Termination returns the error that stopped the current run, or nil
+func (vm *VM) Termination() *TerminationError {
+	return vm.termination
+}
+
+// ChargeAllocation accounts an approximate allocation of the given number of
+// bytes against Limits.MaxAllocation. Builtins that allocate proportionally to
+// their arguments call it before allocating and return the error if any.
+func (vm *VM) ChargeAllocation(bytes int64) error {
+	ls := vm.limits
+	if ls == nil {
+		return nil
+	}
+	if vm.termination != nil {
+		return vm.termination
+	}
+	if ls.MaxAllocation <= 0 {
+		return nil
+	}
+	ls.allocated += bytes
+	if ls.allocated > ls.MaxAllocation {
+		vm.termination = &TerminationError{Reason: TerminationAllocationLimit, Limit: ls.MaxAllocation}
+		return vm.termination
+	}
+	return nil
+}
+
+// checkLimits runs before every instruction while limits are armed and
+// returns the termination to raise, if any
+func (vm *VM) checkLimits() *TerminationError {
+	if vm.termination != nil {
+		return vm.termination
+	}
+	ls := vm.limits
+	ls.instructions++
+	if ls.MaxInstructions > 0 && ls.instructions > ls.MaxInstructions {
+		vm.termination = &TerminationError{Reason: TerminationInstructionLimit, Limit: ls.MaxInstructions}
+	} else if ls.MaxCallDepth > 0 && vm.frameCount > ls.MaxCallDepth {
+		vm.termination = &TerminationError{Reason: TerminationCallDepth, Limit: int64(ls.MaxCallDepth)}
+	} else if ls.instructions >= ls.nextPoll {
+		ls.nextPoll = ls.instructions + limitPollInterval
+		vm.pollLimits()
+	}
+	return vm.termination
+}
+
+// pollLimits checks the deadline and context of the armed limits
+func (vm *VM) pollLimits() *TerminationError {
+	ls := vm.limits
+	if ls == nil || vm.termination != nil {
+		return vm.termination
+	}
+	if !ls.Deadline.IsZero() && !time.Now().Before(ls.Deadline) {
+		vm.termination = &TerminationError{Reason: TerminationDeadline}
+		return vm.termination
+	}
+	select {
+	case <-ls.done:
+		vm.termination = &TerminationError{Reason: TerminationCancelled}
+	default:
+	}
+	return vm.termination
+}
+
+// cancellation returns the termination raised once Cancel has been called
+func (vm *VM) cancellation() *TerminationError {
+	if vm.termination == nil {
+		vm.termination = &TerminationError{Reason: TerminationCancelled}
+	}
+	return vm.termination
+}
+
+// terminate records a termination as the run's runtime error. Nested runs
+// unwinding the same termination report it only once.
+func (vm *VM) terminate(term *TerminationError) InterpretResult {
+	vm.termination = term
+	for _, err := range vm.errors {
+		if rtErr, ok := err.(*errors.RuntimeError); ok && rtErr.Cause == error(term) {
+			return InterpretRuntimeError
+		}
+	}
+	if vm.frameCount == 0 {
+		vm.errors = append(vm.errors, &errors.RuntimeError{Msg: term.Error()})
+	} else {
+		vm.runtimeError("%s", term.Error())
+	}
+	if rtErr, ok := vm.errors[len(vm.errors)-1].(*errors.RuntimeError); ok {
+		rtErr.ErrorCode = errors.PS4003
+		rtErr.Cause = term
+	}
+	return InterpretRuntimeError
+}
@@ -203,6 +203,10 @@ type PlainObject struct {
 	// %MapIteratorPrototype%/%SetIteratorPrototype% next natives and the
 	// VM's for-of fast path.
 	internalIterState *BuiltinIterState
+	// Go state of an object created by a host builtin (a ReadableStream's
+	// stream, say), so builtins can recognize their own objects when they
+	// are passed back in. Nil for ordinary objects.
+	hostData any
 }
 
 // InternalIterState returns the Map/Set iterator internal state, or nil for
@@ -217,6 +221,16 @@ func (o *PlainObject) SetInternalIterState(st *BuiltinIterState) {
 	o.internalIterState = st
 }
 
+// HostData returns the Go state a builtin attached to the object, or nil
+func (o *PlainObject) HostData() any {
+	return o.hostData
+}
+
+// SetHostData attaches Go state to an object a builtin creates
+func (o *PlainObject) SetHostData(data any) {
+	o.hostData = data
+}
+
 // lookupStringField returns the index of the string-keyed field with the given
 // name, or -1 if not present. Uses the lazy per-shape name index for large
 // shapes; scans for small ones.
@@ -190,6 +190,10 @@ func (vm *VM) opGetProp(frame *CallFrame, ip int, objVal *Value, propName string
 		switch objVal.Type() {
 		case TypeNull, TypeUndefined:
 			// Throw JS TypeError: Cannot read property 'X' of null/undefined
+			// Sync ip first so that the error's stack points at this access
+			if frame != nil && !frameWasNil {
+				frame.ip = ip - 4
+			}
 			var excVal Value
 			if typeErrCtor, ok := vm.GetGlobal("TypeError"); ok {
 				if res, callErr := vm.Call(typeErrCtor, Undefined, []Value{NewString(fmt.Sprintf("Cannot read property '%s' of %s", propName, objVal.TypeName()))}); callErr == nil {
@@ -202,9 +206,6 @@ func (vm *VM) opGetProp(frame *CallFrame, ip int, objVal *Value, propName string
 				eo.SetOwn("message", NewString(fmt.Sprintf("Cannot read property '%s' of %s", propName, objVal.TypeName())))
 				excVal = NewValueFromPlainObject(eo)
 			}
-			if frame != nil && !frameWasNil {
-				frame.ip = ip - 4
-			}
 			vm.throwException(excVal)
 			if !vm.unwinding {
 				return false, InterpretOK, Undefined
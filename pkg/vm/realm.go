@@ -93,6 +93,8 @@ type Realm struct {
 	SymbolUnscopables        Value
 	SymbolAsyncIterator      Value
 	SymbolDispose            Value
+	SymbolAsyncDispose       Value
+	SymbolMetadata           Value
 
 	// Symbol registry for Symbol.for()
 	SymbolRegistry map[string]Value
@@ -222,6 +224,8 @@ func (r *Realm) InitializeSymbols() {
 	r.SymbolUnscopables = NewSymbol("Symbol.unscopables")
 	r.SymbolAsyncIterator = NewSymbol("Symbol.asyncIterator")
 	r.SymbolDispose = NewSymbol("Symbol.dispose")
+	r.SymbolAsyncDispose = NewSymbol("Symbol.asyncDispose")
+	r.SymbolMetadata = NewSymbol("Symbol.metadata")
 }
 
 // GetGlobal retrieves a global variable by name from this realm.
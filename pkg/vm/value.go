@@ -258,6 +258,10 @@ type SuspendedFrame struct {
 	outputReg  byte    // Register where sent/resolved value should be stored on resumption
 	thisValue  Value   // The 'this' value for this frame (must be preserved across suspensions)
 	homeObject Value   // The [[HomeObject]] for super property access (must be preserved for object literal methods)
+
+	// The completion the frame's finally blocks are carrying out, when an async
+	// function is suspended in one (see swapFinallyState)
+	finally finallyState
 }
 
 // GeneratorFrame is an alias for backwards compatibility
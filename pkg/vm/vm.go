@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -181,6 +182,20 @@ type VM struct {
 	// the interpreter loop, so it must be atomic to satisfy the Go memory model.
 	cancelled atomic.Bool
 
+	// Context handed to Go code working on behalf of the VM, cancelled by
+	// Cancel (see Context)
+	cancelMu  sync.Mutex
+	cancelCtx context.Context
+	cancelFn  context.CancelFunc
+
+	// Resource limits armed by SetLimits, and the termination that stopped the
+	// current run. Both are only touched by the goroutine running the VM.
+	limits      *limitState
+	termination *TerminationError
+
+	// Debugger hook notified of entered chunks and OpDebug traps (see debug.go)
+	debugHook DebugHook
+
 	// Cache statistics for debugging/profiling
 	cacheStats ICacheStats
 
@@ -267,6 +282,8 @@ type VM struct {
 	SymbolUnscopables        Value
 	SymbolAsyncIterator      Value
 	SymbolDispose            Value
+	SymbolAsyncDispose       Value
+	SymbolMetadata           Value
 
 	// %ThrowTypeError% intrinsic - singleton function used for strict mode arguments callee/caller
 	// Per ECMAScript spec, this function is NOT extensible (unlike normal functions)
@@ -358,6 +375,7 @@ type VM struct {
 	moduleContexts    map[string]*ModuleContext // Cached module contexts by path
 	moduleLoader      ModuleLoader              // Reference to module loader for loading modules
 	currentModulePath string                    // Currently executing module path (for module-scoped globals)
+	hotModules        map[string]*hotModule     // import.meta.hot state by module path (see hot.go)
 
 	// Async runtime (Phase 6 - Async/Await)
 	asyncRuntime runtime.AsyncRuntime
@@ -392,6 +410,7 @@ const (
 	InterpretOK InterpretResult = iota
 	InterpretCompileError
 	InterpretRuntimeError
+	InterpretBreakpoint // Stopped at a breakpoint trap with no DebugHook to report it to
 )
 
 // funcName returns a human-friendly name of the current function for debug prints.
@@ -761,6 +780,8 @@ func (vm *VM) syncPrototypesFromRealm() {
 	vm.SymbolUnscopables = r.SymbolUnscopables
 	vm.SymbolAsyncIterator = r.SymbolAsyncIterator
 	vm.SymbolDispose = r.SymbolDispose
+	vm.SymbolAsyncDispose = r.SymbolAsyncDispose
+	vm.SymbolMetadata = r.SymbolMetadata
 
 	// Constructors
 	vm.ErrorConstructor = r.ErrorConstructor
@@ -855,6 +876,8 @@ func (vm *VM) SyncPrototypesToRealm() {
 	r.SymbolUnscopables = vm.SymbolUnscopables
 	r.SymbolAsyncIterator = vm.SymbolAsyncIterator
 	r.SymbolDispose = vm.SymbolDispose
+	r.SymbolAsyncDispose = vm.SymbolAsyncDispose
+	r.SymbolMetadata = vm.SymbolMetadata
 
 	// Constructors
 	r.ErrorConstructor = vm.ErrorConstructor
@@ -1043,16 +1066,51 @@ func (vm *VM) Reset() {
 	vm.pendingAction = ActionNone
 	vm.pendingValue = Undefined
 	vm.finallyDepth = 0
-	// Reset cancellation flag
+	// Reset cancellation flag and resource limits
 	vm.cancelled.Store(false)
+	vm.cancelMu.Lock()
+	if vm.cancelCtx != nil && vm.cancelCtx.Err() != nil {
+		vm.cancelCtx, vm.cancelFn = nil, nil
+	}
+	vm.cancelMu.Unlock()
+	vm.ClearLimits()
 	// Clear regex cache to free memory from compiled regexes
 	vm.regexCache = nil
 }
 
+// ResetModules forgets the modules executed so far and their import.meta.hot
+// data, so that the next import of each runs it again, with the chunk the
+// module loader has for it then
+func (vm *VM) ResetModules() {
+	clear(vm.moduleContexts)
+	clear(vm.hotModules)
+}
+
 // Cancel signals the VM to stop execution at the next safe point. Safe to call
 // from any goroutine.
 func (vm *VM) Cancel() {
 	vm.cancelled.Store(true)
+	vm.cancelMu.Lock()
+	if vm.cancelFn != nil {
+		vm.cancelFn()
+	}
+	vm.cancelMu.Unlock()
+}
+
+// Context returns a context that is cancelled when the VM is, for Go code
+// doing work on behalf of scripts, like async native functions. Reset hands
+// out a fresh context once the previous one was cancelled. Safe to call from
+// any goroutine.
+func (vm *VM) Context() context.Context {
+	vm.cancelMu.Lock()
+	defer vm.cancelMu.Unlock()
+	if vm.cancelCtx == nil {
+		vm.cancelCtx, vm.cancelFn = context.WithCancel(context.Background())
+		if vm.cancelled.Load() {
+			vm.cancelFn()
+		}
+	}
+	return vm.cancelCtx
 }
 
 // Interpret starts executing the given chunk of bytecode.
@@ -1111,6 +1169,12 @@ func (vm *VM) Interpret(chunk *Chunk) (Value, []errors.PaseratiError) {
 	}
 
 	// --- Push the new frame ---
+	isTopLevel := vm.frameCount == 0
+	entryRegSlot := vm.nextRegSlot
+	if isTopLevel && vm.limits == nil {
+		// A termination only outlives its run while limits remain armed
+		vm.termination = nil
+	}
 	frame := &vm.frames[vm.frameCount] // Get pointer to the frame slot
 	// Initialize the first frame to run the mainClosureObj
 	// IMPORTANT: Initialize ALL fields to avoid stale values from previous frame usage
@@ -1194,7 +1258,20 @@ func (vm *VM) Interpret(chunk *Chunk) (Value, []errors.PaseratiError) {
 		// fmt.Printf("// [VM] Interpret: WARNING - Nested vm.run() call detected!\n")
 	}
 
+	if isTopLevel && vm.debugHook != nil {
+		vm.debugEnterChunk(chunk, vm.currentModulePath)
+	}
+
 	resultStatus, finalValue := vm.run() // Capture both status and value
+
+	// A top-level script returns without popping its frame. Pop it here, closing
+	// any variables captured by closures, so the next top-level Interpret on this
+	// VM is not mistaken for a nested (eval) call. An uncaught exception has
+	// already been recorded in vm.errors, so the unwinding state is cleared too.
+	if isTopLevel {
+		vm.popFramesTo(0, entryRegSlot)
+		vm.ClearUnwindingState()
+	}
 	// fmt.Printf("// [VM] Interpret: vm.run() returned for chunk '%s' with status %v\n", mainFuncObj.Name, resultStatus)
 	// fmt.Printf("// [VM] Interpret: vm.errors length: %d\n", len(vm.errors))
 	// for i, err := range vm.errors {
@@ -1217,6 +1294,20 @@ func (vm *VM) Interpret(chunk *Chunk) (Value, []errors.PaseratiError) {
 	}
 }
 
+// popFramesTo discards frames above count, closing variables captured by
+// closures so they outlive the frame, and releases registers back to regSlot
+func (vm *VM) popFramesTo(count int, regSlot int) {
+	for vm.frameCount > count {
+		vm.frameCount--
+		leftover := &vm.frames[vm.frameCount]
+		vm.closeUpvalues(leftover.registers)
+		leftover.closure = nil
+		leftover.registers = nil
+		leftover.isSentinelFrame = false
+	}
+	vm.nextRegSlot = regSlot
+}
+
 // InterpretWithCallerScope executes a chunk with access to the caller's local variables, 'this', and homeObject.
 // This is used for direct eval to allow reading/writing caller's registers and inheriting 'this' and homeObject.
 // callerRegs is the slice of caller's registers that can be accessed by OpGetCallerLocal/OpSetCallerLocal.
@@ -1334,8 +1425,13 @@ startExecution:
 		// Check for cancellation request
 		if vm.cancelled.Load() {
 			frame.ip = ip
-			status := vm.runtimeError("VM execution cancelled")
-			return status, Undefined
+			return vm.terminate(vm.cancellation()), Undefined
+		}
+		if vm.limits != nil {
+			if term := vm.checkLimits(); term != nil {
+				frame.ip = ip
+				return vm.terminate(term), Undefined
+			}
 		}
 
 		opcode := OpCode(code[ip]) // Use local OpCode
@@ -1377,12 +1473,32 @@ startExecution:
 
 		ip++ // Advance IP past the opcode itself
 
+	dispatch:
 		switch opcode {
 		case OpNop:
 			// No operation - continue to next instruction
 			// OpNop has no operands, IP already advanced past opcode
 			continue
 
+		case OpDebug:
+			// Breakpoint or debugger statement trap. Report it to the debug hook,
+			// then run the instruction the trap replaced with IP past its opcode.
+			trapIP := ip - 1
+			frame.ip = trapIP
+			if vm.debugHook == nil {
+				if !function.Chunk.HasBreakpoint(trapIP) {
+					continue // debugger statement without a debugger
+				}
+				vm.runtimeError("Breakpoint at line %d reached with no debugger attached", function.Chunk.GetLine(trapIP))
+				return InterpretBreakpoint, Undefined
+			}
+			orig := vm.debugTrap(function.Chunk, trapIP)
+			if orig == OpDebug {
+				continue
+			}
+			opcode = orig
+			goto dispatch
+
 		case OpLoadConst:
 			reg := code[ip]
 			constIdxHi := code[ip+1]
@@ -1700,6 +1816,50 @@ startExecution:
 			registers[valueReg] = v
 			registers[doneReg] = BooleanValue(done)
 
+		case OpNewDisposeScope:
+			destReg := code[ip]
+			ip++
+			registers[destReg] = NewArray()
+
+		case OpAddDisposable, OpAddAsyncDisposable, OpDisposeResources:
+			// Dispose scopes of using declarations (see dispose.go). Adding a
+			// value that can't be disposed of throws, as does leaving a scope
+			// with an error, from its body or its disposals.
+			opIP := ip - 1
+			var thrown Value
+			var hasThrown bool
+			if opcode == OpDisposeResources {
+				scopeReg, errReg, hasErrReg := code[ip], code[ip+1], code[ip+2]
+				ip += 3
+				thrown, hasThrown = vm.DisposeResources(disposeScopeResources(registers[scopeReg]), registers[errReg], registers[hasErrReg].IsTruthy())
+			} else {
+				scopeReg, valueReg := code[ip], code[ip+1]
+				ip += 2
+				if err := vm.addToDisposeScope(registers[scopeReg], registers[valueReg], opcode == OpAddAsyncDisposable); err != nil {
+					thrown, hasThrown = vm.errorValue(err), true
+				}
+			}
+			if hasThrown {
+				frame.ip = opIP
+				vm.throwException(thrown)
+				if vm.unwinding && (vm.frameCount == 0 || vm.unwindingCrossedNative) {
+					return InterpretRuntimeError, vm.currentException
+				}
+				frame = &vm.frames[vm.frameCount-1]
+				closure = frame.closure
+				function = closure.Fn
+				code = function.Chunk.Code
+				constants = function.Chunk.Constants
+				registers = frame.registers
+				ip = frame.ip
+				continue
+			}
+
+		case OpDisposeResourcesAsync:
+			destReg, scopeReg, errReg, hasErrReg := code[ip], code[ip+1], code[ip+2], code[ip+3]
+			ip += 4
+			registers[destReg] = vm.DisposeResourcesAsync(disposeScopeResources(registers[scopeReg]), registers[errReg], registers[hasErrReg].IsTruthy())
+
 		case OpTypeof:
 			destReg := code[ip]
 			srcReg := code[ip+1]
@@ -1931,6 +2091,9 @@ startExecution:
 			// Now convert primitives to strings
 			leftStr := leftVal.ToString()
 			rightStr := rightVal.ToString()
+			if vm.limits != nil && vm.ChargeAllocation(int64(len(leftStr)+len(rightStr))) != nil {
+				continue
+			}
 			registers[destReg] = String(leftStr + rightStr)
 
 		case OpAdd, OpSubtract, OpMultiply, OpDivide,
@@ -2020,7 +2183,11 @@ startExecution:
 						ip = frame.ip
 						continue
 					}
-					registers[destReg] = String(leftPrim.ToString() + rightPrim.ToString())
+					leftStr, rightStr := leftPrim.ToString(), rightPrim.ToString()
+					if vm.limits != nil && vm.ChargeAllocation(int64(len(leftStr)+len(rightStr))) != nil {
+						continue
+					}
+					registers[destReg] = String(leftStr + rightStr)
 				} else if leftPrim.IsBigInt() && rightPrim.IsBigInt() {
 					// Both are BigInt: do BigInt addition
 					result := new(big.Int).Add(leftPrim.AsBigInt(), rightPrim.AsBigInt())
@@ -5612,6 +5779,9 @@ startExecution:
 			funcConstIdx := uint16(funcConstIdxHi)<<8 | uint16(funcConstIdxLo)
 			upvalueCount := int(code[ip+3])
 			ip += 4
+			if vm.limits != nil && vm.ChargeAllocation(ObjectAllocCost+int64(upvalueCount)*8) != nil {
+				continue
+			}
 
 			if int(funcConstIdx) >= len(constants) {
 				frame.ip = ip
@@ -6119,6 +6289,10 @@ startExecution:
 				return status, Undefined
 			}
 
+			if vm.limits != nil && vm.ChargeAllocation(ObjectAllocCost+int64(count)*ElementAllocCost) != nil {
+				continue
+			}
+
 			// Copy elements; if count==0, leave elements empty
 			var elements []Value
 			if count > 0 {
@@ -7656,6 +7830,9 @@ startExecution:
 				if idx < len(arr.elements) {
 					arr.elements[idx] = valueVal
 				} else if idx == len(arr.elements) {
+					if vm.limits != nil && vm.ChargeAllocation(ElementAllocCost) != nil {
+						continue
+					}
 					arr.elements = append(arr.elements, valueVal)
 					// Only update length if the new index exceeds current length
 					if len(arr.elements) > arr.length {
@@ -7681,6 +7858,9 @@ startExecution:
 						}
 						// Don't update array length for out-of-range indices stored as properties
 					} else {
+						if vm.limits != nil && vm.ChargeAllocation(int64(neededCapacity-len(arr.elements))*ElementAllocCost) != nil {
+							continue
+						}
 						if cap(arr.elements) < neededCapacity {
 							newElements := make([]Value, len(arr.elements), neededCapacity)
 							copy(newElements, arr.elements)
@@ -8582,6 +8762,9 @@ startExecution:
 		case OpMakeEmptyObject:
 			destReg := code[ip]
 			ip++
+			if vm.limits != nil && vm.ChargeAllocation(ObjectAllocCost) != nil {
+				continue
+			}
 			// Create a new empty object value
 			// Create a new empty object using the shape-based PlainObject with VM's ObjectPrototype
 			registers[destReg] = NewObject(vm.ObjectPrototype)
@@ -9609,6 +9792,9 @@ startExecution:
 			flags := code[ip+3]          // Flags byte: bit0=inherit new.target from caller
 			ip += 4
 			inheritNewTarget := (flags & 0x01) != 0
+			if vm.limits != nil && vm.ChargeAllocation(ObjectAllocCost) != nil {
+				continue
+			}
 
 			// Capture caller context before potential frame switch
 			callerRegisters := registers
@@ -12001,6 +12187,7 @@ startExecution:
 			// In a real environment this would be a file:// URL, but we use the module path
 			if vm.currentModulePath != "" {
 				importMetaObj.SetOwn("url", NewString(vm.currentModulePath))
+				importMetaObj.SetOwn("hot", vm.hotObject(vm.currentModulePath))
 			} else {
 				// If not in a module context, use undefined (though this shouldn't happen)
 				importMetaObj.SetOwn("url", Undefined)
@@ -13372,6 +13559,12 @@ startExecution:
 				vm.pendingAction = ActionNone
 				vm.pendingValue = Undefined
 				vm.throwException(savedValue)
+				// Like OpThrow: an uncaught exception, or one reaching a native
+				// boundary (async functions, generators, calls from Go), is the
+				// caller's to handle
+				if vm.unwinding && (vm.frameCount == 0 || vm.unwindingCrossedNative) {
+					return InterpretRuntimeError, vm.currentException
+				}
 				// If handler was found (unwinding=false), refresh local state from frame
 				// because throwException -> unwindException may have changed frame.ip
 				if !vm.unwinding {
@@ -13875,13 +14068,23 @@ startExecution:
 				if awaitedPromise.State == PromisePending {
 					rt := vm.GetAsyncRuntime()
 					for awaitedPromise.State == PromisePending {
+						if vm.termination != nil {
+							frame.ip = ip
+							return vm.terminate(vm.termination), Undefined
+						}
 						if !rt.RunUntilIdle() {
-							// No microtasks to run - check for pending external operations
-							hasPending := rt.HasPendingExternalOps()
-							if hasPending {
-								// Wait for an external operation to complete
-								// This will block until EndExternalOp is called
-								rt.WaitForExternalOp()
+							// No microtasks to run - run a timer or wait for an
+							// external operation to complete
+							progressed, err := vm.RunEventLoopTurn()
+							if err != nil {
+								frame.ip = ip
+								if term, ok := err.(*TerminationError); ok {
+									return vm.terminate(term), Undefined
+								}
+								status := vm.runtimeError("%s", err.Error())
+								return status, Undefined
+							}
+							if progressed {
 								continue
 							}
 							frame.ip = ip
@@ -13894,6 +14097,23 @@ startExecution:
 				if awaitedPromise.State == PromiseFulfilled {
 					registers[resultReg] = awaitedPromise.Result
 					continue
+				} else if frame.generatorObj != nil {
+					// An async generator body is driven like a generator's, with
+					// no promise of its own: throw the reason into it, so its
+					// catch and finally blocks (and await using disposals) see it
+					frame.ip = ip - 3 // The OpAwait itself, for handler lookup
+					vm.throwException(awaitedPromise.Result)
+					if vm.unwinding && (vm.frameCount == 0 || vm.unwindingCrossedNative) {
+						return InterpretRuntimeError, vm.currentException
+					}
+					frame = &vm.frames[vm.frameCount-1]
+					closure = frame.closure
+					function = closure.Fn
+					code = function.Chunk.Code
+					constants = function.Chunk.Constants
+					registers = frame.registers
+					ip = frame.ip
+					continue
 				} else {
 					frame.ip = ip
 					status := vm.runtimeError("Uncaught (in promise): %s", awaitedPromise.Result.Inspect())
@@ -13939,15 +14159,7 @@ startExecution:
 				}
 				rt.ScheduleMicrotask(func() {
 					result, err := vm.resumeAsyncFunction(asyncPromise, fulfilledValue)
-					if err != nil {
-						vm.rejectPromise(asyncPromise, NewString(err.Error()))
-					} else if asyncPromise.Frame != nil {
-						// Async function hit another await and suspended again
-						// Don't resolve - the new await's handlers will take over
-					} else {
-						// Async function completed - resolve with final value
-						vm.resolvePromise(asyncPromise, result)
-					}
+					vm.settleAsyncFunction(asyncPromise, result, err)
 				})
 				return InterpretOK, Undefined
 
@@ -13956,16 +14168,7 @@ startExecution:
 				rejectedReason := awaitedPromise.Result
 				rt.ScheduleMicrotask(func() {
 					result, err := vm.resumeAsyncFunctionWithException(asyncPromise, rejectedReason)
-					if err != nil {
-						// Exception wasn't caught - reject the async promise
-						vm.rejectPromise(asyncPromise, rejectedReason)
-					} else if asyncPromise.Frame != nil {
-						// Async function hit another await and suspended again
-						// Don't resolve - the new await's handlers will take over
-					} else {
-						// Async function completed - resolve with final value
-						vm.resolvePromise(asyncPromise, result)
-					}
+					vm.settleAsyncFunction(asyncPromise, result, err)
 				})
 				return InterpretOK, Undefined
 
@@ -13979,16 +14182,7 @@ startExecution:
 					Resolve: func(value Value) {
 						// Resume async function with fulfilled value
 						result, err := vm.resumeAsyncFunction(asyncPromise, value)
-						if err != nil {
-							// Resume failed - reject the async promise
-							vm.rejectPromise(asyncPromise, NewString(err.Error()))
-						} else if asyncPromise.Frame != nil {
-							// Async function hit another await and suspended again
-							// Don't resolve - the new await's handlers will take over
-						} else {
-							// Async function completed - resolve with final value
-							vm.resolvePromise(asyncPromise, result)
-						}
+						vm.settleAsyncFunction(asyncPromise, result, err)
 					},
 					Reject: func(reason Value) {
 						// This is called if the Resolve handler throws
@@ -14006,16 +14200,7 @@ startExecution:
 					Reject: func(reason Value) {
 						// Resume async function with rejected value (it will throw)
 						result, err := vm.resumeAsyncFunctionWithException(asyncPromise, reason)
-						if err != nil {
-							// Exception wasn't caught - reject the async promise
-							vm.rejectPromise(asyncPromise, reason)
-						} else if asyncPromise.Frame != nil {
-							// Async function hit another await and suspended again
-							// Don't resolve - the new await's handlers will take over
-						} else {
-							// Async function completed - resolve with final value
-							vm.resolvePromise(asyncPromise, result)
-						}
+						vm.settleAsyncFunction(asyncPromise, result, err)
 					},
 				})
 
@@ -14956,6 +15141,109 @@ startExecution:
 				ip = frame.ip
 			}
 
+		case OpApplyInitializers:
+			// OpApplyInitializers: ValueReg ArrayReg ThisReg
+			// Pass the initial value of an auto-accessor through the init functions
+			// its decorators returned, in the order they were applied
+			opIP := ip - 1
+			valueReg := code[ip]
+			arrayReg := code[ip+1]
+			thisReg := code[ip+2]
+			ip += 3
+
+			if arrayVal := registers[arrayReg]; arrayVal.Type() == TypeArray {
+				arr := arrayVal.AsArray()
+				value := registers[valueReg]
+				thisVal := registers[thisReg]
+				frame.ip = ip
+				thrown := false
+				for i := 0; i < arr.Length() && !thrown; i++ {
+					result, callErr := vm.Call(arr.Get(i), thisVal, []Value{value})
+					if callErr != nil {
+						frame.ip = opIP
+						vm.throwException(vm.errorValue(callErr))
+						if vm.unwinding && (vm.frameCount == 0 || vm.unwindingCrossedNative) {
+							return InterpretRuntimeError, vm.currentException
+						}
+						thrown = true
+					}
+					value = result
+				}
+				if thrown {
+					frame = &vm.frames[vm.frameCount-1]
+					closure = frame.closure
+					function = closure.Fn
+					code = function.Chunk.Code
+					constants = function.Chunk.Constants
+					registers = frame.registers
+					ip = frame.ip
+					continue
+				}
+				registers[valueReg] = value
+			}
+
+		case OpMakeDecoratorMetadata:
+			// OpMakeDecoratorMetadata: Rx SuperReg
+			// Create the metadata object a decorated class's decorators share. It
+			// inherits from the superclass's Symbol.metadata, if that's an object.
+			destReg := code[ip]
+			superReg := code[ip+1]
+			ip += 2
+
+			parentMetadata := Undefined
+			if superVal := registers[superReg]; superVal.IsObject() || superVal.IsCallable() {
+				frame.ip = ip
+				vm.helperCallDepth++
+				_, status, _ := vm.opGetPropSymbol(frame, ip, &superVal, vm.SymbolMetadata, &parentMetadata)
+				vm.helperCallDepth--
+				if status == InterpretRuntimeError {
+					if vm.frameCount == 0 {
+						return InterpretRuntimeError, vm.currentException
+					}
+					frame = &vm.frames[vm.frameCount-1]
+					closure = frame.closure
+					function = closure.Fn
+					code = function.Chunk.Code
+					constants = function.Chunk.Constants
+					registers = frame.registers
+					ip = frame.ip
+					continue
+				}
+			}
+			if !parentMetadata.IsObject() {
+				parentMetadata = Null
+			}
+			registers[destReg] = NewObject(parentMetadata)
+
+		case OpDefineDecoratorMetadata:
+			// OpDefineDecoratorMetadata: ClassReg MetadataReg
+			// Define the class's Symbol.metadata, once its members are decorated
+			classVal := registers[code[ip]]
+			metadata := registers[code[ip+1]]
+			ip += 2
+
+			var props *PlainObject
+			switch classVal.Type() {
+			case TypeClosure:
+				classClosure := classVal.AsClosure()
+				if classClosure.Properties == nil {
+					classClosure.Properties = &PlainObject{prototype: Undefined, shape: RootShape}
+				}
+				props = classClosure.Properties
+			case TypeFunction:
+				funcObj := classVal.AsFunction()
+				if funcObj.Properties == nil {
+					funcObj.Properties = &PlainObject{prototype: Undefined, shape: RootShape}
+				}
+				props = funcObj.Properties
+			case TypeObject:
+				props = classVal.AsPlainObject()
+			}
+			if props != nil {
+				writable, enumerable, configurable := true, false, true
+				props.DefineOwnPropertyByKey(NewSymbolKey(vm.SymbolMetadata), metadata, &writable, &enumerable, &configurable)
+			}
+
 		default:
 			frame.ip = ip // Save IP before erroring
 			// Extra diagnostics for opcode 255 (often indicates unpatched jump placeholder bytes)
@@ -15317,12 +15605,10 @@ func (vm *VM) runtimeError(format string, args ...interface{}) InterpretResult {
 	}
 
 	frame := &vm.frames[vm.frameCount-1]
-	// ip points to the *next* instruction, error occurred at ip-1
-	instructionPos := frame.ip - 1
-	line := 0
+	line, column := 0, 0
 	funcName := "<script>"
 
-	// Safety check for chunk and bounds before calling GetLine
+	// Safety check for chunk before looking up the position
 	if frame.closure != nil && frame.closure.Fn != nil && frame.closure.Fn.Chunk != nil {
 		fn := frame.closure.Fn
 		chunk := fn.Chunk
@@ -15334,28 +15620,23 @@ func (vm *VM) runtimeError(format string, args ...interface{}) InterpretResult {
 			funcName = "<anonymous>"
 		}
 
-		// Ensure instructionPos is valid (non-negative and within bounds)
-		if instructionPos >= 0 && instructionPos < len(chunk.Lines) {
-			line = chunk.GetLine(instructionPos)
-		} else if frame.ip >= 0 && frame.ip < len(chunk.Lines) {
-			// If ip-1 is invalid, try using ip itself
-			line = chunk.GetLine(frame.ip)
-		} else if len(chunk.Lines) > 0 {
-			// Fallback: use the first line if available
-			line = chunk.Lines[0]
-		}
+		// ip points to the *next* instruction, error occurred at ip-1
+		line, column = executingPosition(chunk, frame.ip)
 		// If line is still 0 and we have code, default to line 1
 		if line == 0 && len(chunk.Code) > 0 {
 			line = 1
 		}
 	}
+	if column == 0 {
+		column = 1 // Default to column 1
+	}
 
 	msg := fmt.Sprintf(format, args...)
 
 	runtimeErr := &errors.RuntimeError{
 		Position: errors.Position{
 			Line:     line,
-			Column:   1, // Default to column 1
+			Column:   column,
 			StartPos: 0,
 			EndPos:   0,
 		},
@@ -16964,7 +17245,7 @@ func (vm *VM) resumeGenerator(genObj *GeneratorObject, sentValue Value) (Value,
 		// So code[pc-3] is resultReg
 		code := funcObj.Chunk.Code
 		savedPC := genObj.Frame.pc
-		if savedPC >= 3 && code[savedPC] == byte(OpJump) {
+		if savedPC >= 3 && funcObj.Chunk.OriginalOpCode(savedPC) == OpJump {
 			resultReg := code[savedPC-3]
 
 			// Create an iterator result object with the delegation result
@@ -17351,29 +17632,16 @@ func (vm *VM) resumeGeneratorWithReturn(genObj *GeneratorObject, returnValue Val
 	// Update generator state
 	genObj.State = GeneratorExecuting
 
-	// Check if the generator's current position is covered by finally or iterator cleanup handlers
-	handlers := vm.findAllExceptionHandlers(genObj.Frame.pc)
-	hasFinallyHandler := false
-	hasIteratorCleanup := false
-	var finallyHandler *ExceptionHandler
-	var iteratorCleanupHandler *ExceptionHandler
-	for _, handler := range handlers {
-		if handler.IsFinally {
-			hasFinallyHandler = true
-			finallyHandler = handler
-		} else if handler.IsIteratorCleanup && !hasIteratorCleanup {
-			// Track the first (innermost) iterator cleanup handler
-			hasIteratorCleanup = true
-			iteratorCleanupHandler = handler
-		}
-	}
-
-	// Determine which handler to run first (innermost - finally before iterator cleanup)
+	// Check if the generator's current position is covered by finally or iterator
+	// cleanup handlers (including the finally of a using declaration's dispose
+	// region). Run the innermost, the first in the table: OpHandlePending at its
+	// end chains to the next one out, like a return from inside the try would.
 	var handlerToRun *ExceptionHandler
-	if hasFinallyHandler {
-		handlerToRun = finallyHandler
-	} else if hasIteratorCleanup {
-		handlerToRun = iteratorCleanupHandler
+	for _, handler := range vm.findAllExceptionHandlers(genObj.Frame.pc) {
+		if handler.IsFinally || handler.IsIteratorCleanup {
+			handlerToRun = handler
+			break
+		}
 	}
 
 	if handlerToRun != nil {
@@ -17540,7 +17808,9 @@ func (vm *VM) resumeAsyncFunction(promiseObj *PromiseObject, resolvedValue Value
 	// promiseObj.Frame = nil  // Don't clear yet - might await again
 
 	// Execute the VM run loop - it will return when the async function completes or awaits again
+	callerFinally := vm.swapFinallyState(promiseObj.Frame.finally)
 	status, result := vm.run()
+	bodyFinally := vm.swapFinallyState(callerFinally)
 
 	// Clean up frames if function suspended at another await
 	// When the function completes normally via OpReturn, frames are already cleaned up.
@@ -17550,6 +17820,7 @@ func (vm *VM) resumeAsyncFunction(promiseObj *PromiseObject, resolvedValue Value
 		vm.frameCount = savedFrameCount
 		vm.nextRegSlot = savedNextRegSlot
 		// promiseObj.Frame remains set for the next resumption
+		promiseObj.Frame.finally = bodyFinally
 	} else {
 		// Function completed normally - clear saved frame to signal completion
 		promiseObj.Frame = nil
@@ -17557,7 +17828,13 @@ func (vm *VM) resumeAsyncFunction(promiseObj *PromiseObject, resolvedValue Value
 
 	if status == InterpretRuntimeError {
 		if vm.unwinding && vm.currentException != Null {
-			return Undefined, exceptionError{exception: vm.currentException}
+			// Clear the exception state so it doesn't leak to the caller's run, like
+			// executeAsyncFunctionBody does
+			exc := vm.currentException
+			vm.currentException = Null
+			vm.unwinding = false
+			vm.unwindingCrossedNative = false
+			return Undefined, exceptionError{exception: exc}
 		}
 		return Undefined, exceptionError{exception: NewString("runtime error during async function resumption")}
 	}
@@ -17650,16 +17927,19 @@ func (vm *VM) resumeAsyncFunctionWithException(promiseObj *PromiseObject, except
 
 	// Throw the exception at the await point
 	// This will be handled by the VM's exception handling system
+	callerFinally := vm.swapFinallyState(promiseObj.Frame.finally)
 	vm.throwException(exception)
 
 	// Check if the exception unwound all frames (uncaught exception)
 	if vm.frameCount == 0 && vm.unwinding {
 		// Exception propagated through all frames - surface as ExceptionError
+		vm.swapFinallyState(callerFinally)
 		return Undefined, exceptionError{exception: vm.currentException}
 	}
 
 	// Execute the VM run loop - it will return when the exception is handled or propagates
 	status, result := vm.run()
+	bodyFinally := vm.swapFinallyState(callerFinally)
 
 	// Clean up frames if function suspended at another await
 	if vm.frameCount > savedFrameCount {
@@ -17667,6 +17947,7 @@ func (vm *VM) resumeAsyncFunctionWithException(promiseObj *PromiseObject, except
 		vm.frameCount = savedFrameCount
 		vm.nextRegSlot = savedNextRegSlot
 		// promiseObj.Frame remains set for the next resumption
+		promiseObj.Frame.finally = bodyFinally
 	} else {
 		// Function completed normally - clear saved frame to signal completion
 		promiseObj.Frame = nil
@@ -17674,7 +17955,11 @@ func (vm *VM) resumeAsyncFunctionWithException(promiseObj *PromiseObject, except
 
 	if status == InterpretRuntimeError {
 		if vm.currentException != Null {
-			return Undefined, exceptionError{exception: vm.currentException}
+			exc := vm.currentException
+			vm.currentException = Null
+			vm.unwinding = false
+			vm.unwindingCrossedNative = false
+			return Undefined, exceptionError{exception: exc}
 		}
 		return Undefined, exceptionError{exception: NewString("runtime error during async exception handling")}
 	}
@@ -17931,6 +18216,10 @@ func (vm *VM) executeModule(modulePath string) (InterpretResult, Value) {
 
 	// fmt.Printf("// [VM DEBUG] executeModule: Module '%s' executing with frameCount=%d (isolated)\n", modulePath, vm.frameCount)
 
+	if vm.debugHook != nil {
+		vm.debugEnterChunk(chunk, modulePath)
+	}
+
 	// Execute module directly using isolated vm.run() call
 	// Now the module will execute as frameCount=1 and OpReturn will exit at frameCount=0
 	resultStatus, result := vm.run()
@@ -17964,6 +18253,7 @@ func (vm *VM) executeModule(modulePath string) (InterpretResult, Value) {
 			runtimeErr := &errors.RuntimeError{
 				Position: errors.Position{Line: 1, Column: 1},
 				Msg:      fmt.Sprintf("Uncaught exception: %s", displayStr),
+				Cause:    exceptionError{exception: moduleException},
 			}
 			errs = []errors.PaseratiError{runtimeErr}
 			vm.errors = append(vm.errors[:0], runtimeErr) // Clear and add only the exception
@@ -1651,6 +1651,23 @@ func (vm *VM) executeUserFunctionSafe(fn Value, thisValue Value, args []Value) (
 		vm.unwindingCrossedNative = false
 	}
 
+	// Calls made from the host while no script is running (embedders, timer
+	// callbacks) have no interpreter loop to unwind into. If such a call throws,
+	// the frame stack and unwinding state are restored before returning.
+	if vm.frameCount == 0 {
+		entryRegSlot := vm.nextRegSlot
+		defer func() {
+			if vm.unwinding && vm.currentException == Null {
+				vm.popFramesTo(0, entryRegSlot)
+				vm.unwinding = false
+				vm.unwindingCrossedNative = false
+			}
+		}()
+	}
+
+	entryFrameCount := vm.frameCount
+	entrySlot := vm.nextRegSlot
+
 	// Set up the caller context first (pooled 1-element result holder)
 	callerRegisters := vm.getSentinelReg()
 	defer vm.putSentinelReg(callerRegisters)
@@ -1689,6 +1706,13 @@ func (vm *VM) executeUserFunctionSafe(fn Value, thisValue Value, args []Value) (
 	status, result := vm.run()
 
 	if status == InterpretRuntimeError {
+		// A termination abandons every frame this call pushed and is handed to
+		// the native caller as is. Whatever the caller does with it, the
+		// interpreter stops again before the next instruction.
+		if vm.termination != nil {
+			vm.popFramesTo(entryFrameCount, entrySlot)
+			return Undefined, vm.termination
+		}
 		// If the VM is unwinding an exception, surface it as an ExceptionError
 		if vm.unwinding && vm.currentException != Null {
 			ex := vm.currentException
@@ -0,0 +1,29 @@
+// Test AbortSignal as an EventTarget: onabort, abort listeners and AbortSignal.any
+// expect: onabort true abort,listener,any why true | why | true true | Illegal constructor
+const results: string[] = [];
+
+const controller = new AbortController();
+const log: string[] = [];
+controller.signal.onabort = (e: Event) => log.push("onabort " + e.isTrusted + " " + e.type);
+controller.signal.addEventListener("abort", () => log.push("listener"), { once: true });
+const any = AbortSignal.any([new AbortController().signal, controller.signal]);
+any.addEventListener("abort", () => log.push("any " + any.reason + " " + any.aborted));
+controller.abort("why");
+controller.abort("again");
+results.push(log.join(","));
+
+try {
+  controller.signal.throwIfAborted();
+} catch (e) {
+  results.push(e);
+}
+
+results.push((controller.signal instanceof EventTarget) + " " + (Object.getPrototypeOf(AbortSignal) === EventTarget));
+
+try {
+  new (AbortSignal as any)();
+} catch (e) {
+  results.push(e.message);
+}
+
+results.join(" | ");
@@ -0,0 +1,32 @@
+// Test AbortSignal.timeout aborting with a TimeoutError and firing abort
+// expect: false | onabort abort,listener,any | true TimeoutError true TimeoutError | TypeError
+
+const results: string[] = [];
+const fired: string[] = [];
+
+const signal = AbortSignal.timeout(10);
+results.push(String(signal.aborted));
+signal.onabort = (e) => fired.push("onabort " + e.type);
+signal.addEventListener("abort", () => fired.push("listener"));
+AbortSignal.any([signal]).addEventListener("abort", () => fired.push("any"));
+
+await new Promise((resolve) => setTimeout(resolve, 30));
+results.push(fired.join(","));
+
+let thrown = "";
+try {
+  signal.throwIfAborted();
+} catch (e) {
+  thrown = e.name;
+}
+results.push(
+  signal.aborted + " " + signal.reason.name + " " + (signal.reason instanceof Error) + " " + thrown
+);
+
+try {
+  AbortSignal.timeout(-1);
+} catch (e) {
+  results.push(e.name);
+}
+
+results.join(" | ");
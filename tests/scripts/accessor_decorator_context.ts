@@ -0,0 +1,30 @@
+// Test the access and metadata of accessor decorator contexts, and Symbol.metadata
+// expect: 1 2 3 | true true false false true | 10 20 30 | n,#p,s | true true
+const accesses: ClassAccessorDecoratorContext["access"][] = [];
+function keep(target: any, context: ClassAccessorDecoratorContext) {
+  accesses.push(context.access);
+  context.metadata[String(context.name)] = true;
+}
+class C {
+  @keep accessor n = 1;
+  @keep accessor #p = 2;
+  @keep static accessor s = 3;
+  readP() { return this.#p; }
+}
+class D extends C {
+  @keep accessor m = 4;
+}
+const c = new C();
+const [n, p, s] = accesses;
+const results: string[] = [];
+results.push([n.get(c), p.get(c), s.get(C)].join(" "));
+results.push([n.has(c), p.has(c), p.has({}), n.has({}), s.has(C)].join(" "));
+n.set(c, 10);
+p.set(c, 20);
+s.set(C, 30);
+results.push([c.n, c.readP(), C.s].join(" "));
+const metadata: any = (C as any)[Symbol.metadata];
+const derived: any = (D as any)[Symbol.metadata];
+results.push(Object.keys(metadata).join(","));
+results.push(derived.m + " " + (Object.getPrototypeOf(derived) === metadata));
+results.join(" | ");
@@ -0,0 +1,6 @@
+// expect: init failed
+function boom(t: any, ctx: any) { return { init(v: any): number { throw new Error("init failed"); } }; }
+class G { @boom accessor z = 0; }
+let message = "";
+try { new G(); } catch (e) { message = e.message; }
+message;
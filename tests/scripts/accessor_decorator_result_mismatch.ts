@@ -0,0 +1,3 @@
+// expect_compile_error: can't decorate accessor 'n: number' of class 'C'
+function stringly(target: any, context: any) { return { get(): string { return "one"; } }; }
+class C { @stringly accessor n: number = 1; }
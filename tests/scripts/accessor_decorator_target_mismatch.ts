@@ -0,0 +1,3 @@
+// expect_compile_error: can't decorate accessor 'n: number' of class 'C'
+function notATarget(target: number, context: any) {}
+class C { @notATarget accessor n: number = 1; }
@@ -0,0 +1,10 @@
+// expect: 2
+function logged<This, Value>(target: ClassAccessorDecoratorTarget<This, Value>, context: ClassAccessorDecoratorContext<This, Value>): ClassAccessorDecoratorResult<This, Value> {
+  return {
+    get(): Value { return target.get.call(this); },
+    init(v: Value): Value { return v; },
+  };
+}
+class C { @logged accessor n: number = 1; }
+const ctxKind: ClassAccessorDecoratorContext<C, number>["kind"] = "accessor";
+new C().n + 1;
@@ -0,0 +1,3 @@
+// expect_compile_error: can't decorate accessor 'n: number' of class 'C'
+function upper(target: ClassAccessorDecoratorTarget<unknown, string>, context: ClassAccessorDecoratorContext<unknown, string>) {}
+class C { @upper accessor n: number = 1; }
@@ -0,0 +1,21 @@
+// expect: accessor:n:false, init 2, get 20, set 3, get 3, 3
+const log: string[] = [];
+function logged(target: any, ctx: any) {
+  log.push(ctx.kind + ":" + ctx.name + ":" + ctx.static);
+  return {
+    get() { const v = target.get.call(this); log.push("get " + v); return v; },
+    set(v: any) { log.push("set " + v); target.set.call(this, v); },
+    init(v: any) { log.push("init " + v); return v * 10; },
+  };
+}
+function double(target: any, ctx: any) {
+  return { init(v: any) { return v * 2; } };
+}
+class C {
+  @logged @double accessor n = 1;
+}
+const c = new C();
+c.n;
+c.n = 3;
+log.push(String(c.n));
+log.join(", ");
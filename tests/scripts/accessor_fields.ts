@@ -0,0 +1,19 @@
+// expect: 7, function function 0, 11, s!, 6
+const log: string[] = [];
+class Point {
+  accessor x = 1;
+  accessor y: number = 2;
+  static accessor count = 10;
+  accessor #secret = "s";
+  static accessor #hidden = 5;
+  reveal() { this.#secret = this.#secret + "!"; return this.#secret; }
+  static bump() { Point.#hidden++; return Point.#hidden; }
+}
+const p = new Point();
+p.x = 5;
+log.push(String(p.x + p.y));
+const d = Object.getOwnPropertyDescriptor((Point as any).prototype, "x") as any;
+log.push(typeof d.get + " " + typeof d.set + " " + Object.keys(p).length);
+Point.count++;
+log.push(String(Point.count), p.reveal(), String(Point.bump()));
+log.join(", ");
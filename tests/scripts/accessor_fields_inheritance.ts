@@ -0,0 +1,16 @@
+// expect: base, base+, 1, 2, 4
+const log: string[] = [];
+const k = "dyn";
+class Base { accessor a = "base"; }
+class Derived extends Base {
+  accessor [k] = 1;
+  accessor b = (this as any).a + "+";
+  constructor() { super(); }
+}
+const d: any = new Derived();
+log.push(d.a, d.b, String(d.dyn));
+const E = class { accessor v = 2; };
+log.push(String(new E().v));
+class F { accessor = 4; }
+log.push(String(new F().accessor));
+log.join(", ");
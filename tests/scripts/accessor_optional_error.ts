@@ -0,0 +1,4 @@
+// expect_compile_error: An 'accessor' property cannot be declared optional.
+class C {
+  accessor x?: number;
+}
@@ -0,0 +1,21 @@
+// Test await using awaiting Symbol.asyncDispose, falling back to Symbol.dispose
+// expect: body, close sync, close async, after
+
+const log: string[] = [];
+async function run() {
+  {
+    await using a = {
+      async [Symbol.asyncDispose]() {
+        await null;
+        log.push("close async");
+      },
+    };
+    await using b = { [Symbol.dispose]() { log.push("close sync"); } };
+    await using c = null;
+    log.push("body");
+  }
+  log.push("after");
+}
+
+await run();
+log.join(", ");
@@ -0,0 +1,39 @@
+// Test await using in an async generator disposing on break, return() and throw()
+// expect: dispose break, dispose return, dispose throw, caught x, dispose done
+
+const log: string[] = [];
+function res(name: string) {
+  return {
+    [Symbol.asyncDispose]() {
+      return Promise.resolve(null).then(() => {
+        log.push("dispose " + name);
+      });
+    },
+  };
+}
+async function* g(name: string) {
+  await using r = res(name);
+  yield 1;
+  yield 2;
+}
+
+async function run() {
+  for await (const v of g("break")) break;
+
+  const it = g("return");
+  await it.next();
+  await it.return(undefined);
+
+  const t = g("throw");
+  await t.next();
+  try {
+    await t.throw(new Error("x"));
+  } catch (e) {
+    log.push("caught " + e.message);
+  }
+
+  for await (const v of g("done")) {}
+}
+
+await run();
+log.join(", ");
@@ -0,0 +1,38 @@
+// Test crypto.getRandomValues, crypto.randomUUID and the Web Crypto classes
+// expect: true 16 | true 4 | TypeMismatchError | QuotaExceededError | true true | true | [object Crypto] [object SubtleCrypto] | Illegal constructor
+const results: string[] = [];
+
+// getRandomValues fills the array in place and returns it
+const bytes = new Uint8Array(16);
+const filled = crypto.getRandomValues(bytes);
+filled[0] = 1;
+filled[1] = 2;
+results.push((bytes[0] + bytes[1] === 3) + " " + bytes.length);
+const words = crypto.getRandomValues(new Uint32Array(64));
+results.push(words.some((w: number) => w !== 0) + " " + words.BYTES_PER_ELEMENT);
+
+try {
+  crypto.getRandomValues(new Float64Array(2));
+} catch (e) {
+  results.push(e.name);
+}
+try {
+  crypto.getRandomValues(new Uint8Array(65537));
+} catch (e) {
+  results.push(e.name);
+}
+
+const uuid = crypto.randomUUID();
+const uuidPattern = /^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/;
+results.push(uuidPattern.test(uuid) + " " + (uuid !== crypto.randomUUID()));
+
+results.push(String(crypto.subtle === crypto.subtle && crypto instanceof Crypto && crypto.subtle instanceof SubtleCrypto));
+results.push(Object.prototype.toString.call(crypto) + " " + Object.prototype.toString.call(crypto.subtle));
+
+try {
+  new CryptoKey();
+} catch (e) {
+  results.push(e.message);
+}
+
+results.join(" | ");
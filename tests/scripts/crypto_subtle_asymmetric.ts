@@ -0,0 +1,54 @@
+// Test crypto.subtle ECDSA, Ed25519 and RSA-PSS keys, signatures and key formats
+// expect: public verify private sign P-256 | 64 true false | true true true 65 | EC P-256 ES256 true | ES512 132 true | 64 true true | OKP Ed25519 true | 2048 1,0,1 SHA-256 | 256 true PS256 true | true
+const results: string[] = [];
+const subtle = crypto.subtle;
+
+const data = new Uint8Array([1, 2, 3]);
+const other = new Uint8Array([1, 2, 4]);
+const hex = (buf: ArrayBuffer) => Array.from(new Uint8Array(buf)).map((b: number) => b.toString(16).padStart(2, "0")).join("");
+
+// ECDSA
+const ecdsa = { name: "ECDSA", hash: "SHA-256" };
+const ec = await subtle.generateKey({ name: "ECDSA", namedCurve: "P-256" }, true, ["sign", "verify"]);
+results.push(ec.publicKey.type + " " + ec.publicKey.usages.join(",") + " " + ec.privateKey.type + " " + ec.privateKey.usages.join(",") + " " + ec.privateKey.algorithm.namedCurve);
+const ecSignature = await subtle.sign(ecdsa, ec.privateKey, data);
+results.push(ecSignature.byteLength + " " + await subtle.verify(ecdsa, ec.publicKey, ecSignature, data) + " " + await subtle.verify(ecdsa, ec.publicKey, ecSignature, other));
+
+// Public keys round trip through spki, raw and JWK, private ones through pkcs8
+const curve = { name: "ECDSA", namedCurve: "P-256" };
+const fromSpki = await subtle.importKey("spki", await subtle.exportKey("spki", ec.publicKey), curve, true, ["verify"]);
+const raw = await subtle.exportKey("raw", ec.publicKey);
+const fromRaw = await subtle.importKey("raw", raw, curve, true, ["verify"]);
+const fromPkcs8 = await subtle.importKey("pkcs8", await subtle.exportKey("pkcs8", ec.privateKey), curve, false, ["sign"]);
+results.push(await subtle.verify(ecdsa, fromSpki, ecSignature, data) + " " + await subtle.verify(ecdsa, fromRaw, ecSignature, data) + " " + await subtle.verify(ecdsa, ec.publicKey, await subtle.sign(ecdsa, fromPkcs8, data), data) + " " + raw.byteLength);
+const ecJwk = await subtle.exportKey("jwk", ec.privateKey);
+const fromJwk = await subtle.importKey("jwk", ecJwk, curve, false, ["sign"]);
+results.push(ecJwk.kty + " " + ecJwk.crv + " " + ecJwk.alg + " " + await subtle.verify(ecdsa, ec.publicKey, await subtle.sign(ecdsa, fromJwk, data), data));
+
+const p521 = await subtle.generateKey({ name: "ECDSA", namedCurve: "P-521" }, false, ["sign", "verify"]);
+const p521Signature = await subtle.sign({ name: "ECDSA", hash: "SHA-512" }, p521.privateKey, data);
+const p521Jwk = await subtle.exportKey("jwk", p521.publicKey);
+results.push(p521Jwk.alg + " " + p521Signature.byteLength + " " + await subtle.verify({ name: "ECDSA", hash: "SHA-512" }, p521.publicKey, p521Signature, data));
+
+// Ed25519 signatures are deterministic
+const ed = await subtle.generateKey("Ed25519", true, ["sign", "verify"]);
+const edSignature = await subtle.sign("Ed25519", ed.privateKey, data);
+const edJwk = await subtle.exportKey("jwk", ed.privateKey);
+const edImported = await subtle.importKey("jwk", edJwk, "Ed25519", false, ["sign"]);
+results.push(edSignature.byteLength + " " + await subtle.verify("Ed25519", ed.publicKey, edSignature, data) + " " + (hex(await subtle.sign("Ed25519", edImported, data)) === hex(edSignature)));
+const edRaw = await subtle.importKey("raw", await subtle.exportKey("raw", ed.publicKey), "Ed25519", true, ["verify"]);
+results.push(edJwk.kty + " " + edJwk.crv + " " + await subtle.verify({ name: "Ed25519" }, edRaw, edSignature, data));
+
+// RSA-PSS
+const rsa = await subtle.generateKey({ name: "RSA-PSS", modulusLength: 2048, publicExponent: new Uint8Array([1, 0, 1]), hash: "SHA-256" }, true, ["sign", "verify"]);
+const rsaAlgorithm = rsa.publicKey.algorithm;
+results.push(rsaAlgorithm.modulusLength + " " + Array.from(rsaAlgorithm.publicExponent).join(",") + " " + rsaAlgorithm.hash.name);
+const pss = { name: "RSA-PSS", saltLength: 32 };
+const rsaSignature = await subtle.sign(pss, rsa.privateKey, data);
+const rsaJwk = await subtle.exportKey("jwk", rsa.privateKey);
+const rsaImported = await subtle.importKey("jwk", rsaJwk, { name: "RSA-PSS", hash: "SHA-256" }, false, ["sign"]);
+results.push(rsaSignature.byteLength + " " + await subtle.verify(pss, rsa.publicKey, rsaSignature, data) + " " + rsaJwk.alg + " " + await subtle.verify(pss, rsa.publicKey, await subtle.sign(pss, rsaImported, data), data));
+const rsaSpki = await subtle.importKey("spki", await subtle.exportKey("spki", rsa.publicKey), { name: "RSA-PSS", hash: "SHA-256" }, false, ["verify"]);
+results.push(String(await subtle.verify(pss, rsaSpki, rsaSignature, data)));
+
+results.join(" | ");
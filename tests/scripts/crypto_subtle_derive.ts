@@ -0,0 +1,44 @@
+// Test crypto.subtle PBKDF2 and HKDF against known vectors, ECDH and X25519 key agreement, and deriveKey
+// expect: ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957 | 8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8 | 48 true 4 | 32 true true | private deriveKey,deriveBits | AES-GCM 128 true | HMAC 512 false
+const results: string[] = [];
+const subtle = crypto.subtle;
+
+const hex = (buf: ArrayBuffer) => Array.from(new Uint8Array(buf)).map((b: number) => b.toString(16).padStart(2, "0")).join("");
+const ascii = (s: string) => new Uint8Array(Array.from(s).map((c: string) => c.charCodeAt(0)));
+
+// RFC 6070: PBKDF2-HMAC-SHA1, "password", "salt", 2 iterations
+const password = await subtle.importKey("raw", ascii("password"), "PBKDF2", false, ["deriveBits", "deriveKey"]);
+results.push(hex(await subtle.deriveBits({ name: "PBKDF2", salt: ascii("salt"), iterations: 2, hash: "SHA-1" }, password, 160)));
+
+// RFC 5869 test case 3: HKDF-SHA256 with no salt or info
+const ikm = await subtle.importKey("raw", new Uint8Array(22).fill(0x0b), "HKDF", false, ["deriveBits"]);
+const empty = new Uint8Array(0);
+results.push(hex(await subtle.deriveBits({ name: "HKDF", hash: "SHA-256", salt: empty, info: empty }, ikm, 336)));
+
+// ECDH: both sides agree, and a length takes the leading bits
+const alice = await subtle.generateKey({ name: "ECDH", namedCurve: "P-384" }, true, ["deriveBits"]);
+const bob = await subtle.generateKey({ name: "ECDH", namedCurve: "P-384" }, true, ["deriveBits"]);
+const aliceSecret = await subtle.deriveBits({ name: "ECDH", public: bob.publicKey }, alice.privateKey, 384);
+const bobSecret = await subtle.deriveBits({ name: "ECDH", public: alice.publicKey }, bob.privateKey, 384);
+const short = await subtle.deriveBits({ name: "ECDH", public: bob.publicKey }, alice.privateKey, 28);
+results.push(aliceSecret.byteLength + " " + (hex(aliceSecret) === hex(bobSecret)) + " " + short.byteLength);
+
+// X25519, with the public key round tripping through spki
+const x = await subtle.generateKey("X25519", true, ["deriveBits", "deriveKey"]);
+const y = await subtle.generateKey("X25519", true, ["deriveBits"]);
+const xPublic = await subtle.importKey("spki", await subtle.exportKey("spki", x.publicKey), "X25519", true, []);
+const xy = await subtle.deriveBits({ name: "X25519", public: y.publicKey }, x.privateKey);
+const yx = await subtle.deriveBits({ name: "X25519", public: xPublic }, y.privateKey, 256);
+const yJwk = await subtle.exportKey("jwk", y.privateKey);
+const yImported = await subtle.importKey("jwk", yJwk, "X25519", false, ["deriveBits"]);
+const fromJwk = await subtle.deriveBits({ name: "X25519", public: x.publicKey }, yImported);
+results.push(xy.byteLength + " " + (hex(xy) === hex(yx)) + " " + (hex(fromJwk) === hex(xy)));
+results.push(x.privateKey.type + " " + x.privateKey.usages.join(","));
+
+// deriveKey makes AES and HMAC keys
+const aes = await subtle.deriveKey({ name: "X25519", public: y.publicKey }, x.privateKey, { name: "AES-GCM", length: 128 }, true, ["encrypt"]);
+results.push(aes.algorithm.name + " " + aes.algorithm.length + " " + aes.extractable);
+const hmac = await subtle.deriveKey({ name: "PBKDF2", salt: ascii("salt"), iterations: 1000, hash: "SHA-256" }, password, { name: "HMAC", hash: "SHA-256" }, false, ["sign"]);
+results.push(hmac.algorithm.name + " " + hmac.algorithm.length + " " + hmac.extractable);
+
+results.join(" | ");
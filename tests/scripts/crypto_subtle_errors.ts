@@ -0,0 +1,43 @@
+// Test how crypto.subtle rejects: unknown algorithms, bad arguments, usages and key data
+// expect: NotSupportedError | TypeError | TypeError | InvalidAccessError | InvalidAccessError | InvalidAccessError | DataError | DataError | SyntaxError | SyntaxError | SyntaxError | TypeError | OperationError | NotSupportedError | OperationError
+const subtle = crypto.subtle;
+
+async function errorName(operation: () => Promise<any>) {
+  try {
+    await operation();
+    return "fulfilled";
+  } catch (e) {
+    return e.name;
+  }
+}
+
+async function run() {
+  const results: string[] = [];
+  const bytes = new Uint8Array(16);
+  const hmacKey = await subtle.importKey("raw", bytes, { name: "HMAC", hash: "SHA-256" }, false, ["sign"]);
+  const aesKey = await subtle.importKey("raw", bytes, "AES-CBC", false, ["encrypt", "decrypt"]);
+
+  results.push(await errorName(() => subtle.digest("MD5", bytes)));
+  // A required algorithm member is missing
+  results.push(await errorName(() => subtle.encrypt({ name: "AES-CBC" }, aesKey, bytes)));
+  results.push(await errorName(() => subtle.sign("HMAC", hmacKey, "text" as any)));
+  // The key is for another algorithm, doesn't allow the usage, or isn't extractable
+  results.push(await errorName(() => subtle.sign("Ed25519", hmacKey, bytes)));
+  results.push(await errorName(() => subtle.verify("HMAC", hmacKey, bytes, bytes)));
+  results.push(await errorName(() => subtle.exportKey("raw", hmacKey)));
+  // Bad key data
+  results.push(await errorName(() => subtle.importKey("raw", new Uint8Array(5), "AES-GCM", true, ["encrypt"])));
+  results.push(await errorName(() => subtle.importKey("jwk", { kty: "oct", k: "AAAAAAAAAAAAAAAAAAAAAA", alg: "A256GCM" }, "AES-GCM", true, ["encrypt"])));
+  // Usages the algorithm doesn't have, none at all, and extractable derivation keys
+  results.push(await errorName(() => subtle.importKey("raw", bytes, "AES-GCM", true, ["sign"])));
+  results.push(await errorName(() => subtle.importKey("raw", bytes, "AES-GCM", true, [])));
+  results.push(await errorName(() => subtle.importKey("raw", bytes, "PBKDF2", true, ["deriveBits"])));
+  results.push(await errorName(() => subtle.importKey("raw", bytes, "AES-GCM", true, ["bogus" as any])));
+  results.push(await errorName(() => subtle.generateKey({ name: "AES-CBC", length: 100 }, true, ["encrypt"])));
+  results.push(await errorName(() => subtle.generateKey({ name: "ECDSA", namedCurve: "P-192" }, true, ["sign"])));
+  // Ciphertext that isn't whole blocks
+  results.push(await errorName(() => subtle.decrypt({ name: "AES-CBC", iv: bytes }, aesKey, new Uint8Array(7))));
+  return results.join(" | ");
+}
+
+await run();
@@ -0,0 +1,62 @@
+// Test crypto.subtle digest, HMAC and AES-GCM/CBC/CTR against known vectors
+// expect: ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad | a9993e364706816aba3e25717850c26c9cd0d89d 48 64 | 9c196e32dc0175f86f4b1cb89289d6619de6bee699e4c378e68309ed97a1a6ab true false | secret HMAC SHA-256 24 sign,verify | {"kty":"oct","alg":"HS256","k":"a2V5","key_ops":["sign","verify"],"ext":true} | 42831ec2217774244b7221b784d0d49c 4d5c2af327cd64a62cf35abd2ba6fab4 true | OperationError | 7649abac8119b246cee98e9b12e9197d 32 true | 874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff | AES-GCM 256 true
+const results: string[] = [];
+const subtle = crypto.subtle;
+
+const hex = (buf: ArrayBuffer) => Array.from(new Uint8Array(buf)).map((b: number) => b.toString(16).padStart(2, "0")).join("");
+const fromHex = (s: string) => new Uint8Array(s.match(/../g)!.map((h: string) => parseInt(h, 16)));
+const utf8 = (s: string) => new Uint8Array(new TextEncoder().encode(s));
+const abc = utf8("abc");
+
+results.push(hex(await subtle.digest("SHA-256", abc)));
+const sha1 = await subtle.digest({ name: "sha-1" }, abc);
+const sha384 = await subtle.digest("SHA-384", abc);
+const sha512 = await subtle.digest("SHA-512", abc.buffer);
+results.push(hex(sha1) + " " + sha384.byteLength + " " + sha512.byteLength);
+
+// HMAC-SHA256("key", "abc")
+const hmacKey = await subtle.importKey("raw", utf8("key"), { name: "HMAC", hash: "SHA-256" }, true, ["sign", "verify"]);
+const mac = await subtle.sign("HMAC", hmacKey, abc);
+const valid = await subtle.verify("HMAC", hmacKey, mac, abc);
+const forged = await subtle.verify("HMAC", hmacKey, mac, utf8("abd"));
+results.push(hex(mac) + " " + valid + " " + forged);
+const alg = hmacKey.algorithm;
+results.push(hmacKey.type + " " + alg.name + " " + alg.hash.name + " " + alg.length + " " + hmacKey.usages.join(","));
+results.push(JSON.stringify(await subtle.exportKey("jwk", hmacKey)));
+
+// AES-GCM, the GCM spec's test case 3
+const gcmKey = await subtle.importKey("raw", fromHex("feffe9928665731c6d6a8f9467308308"), "AES-GCM", false, ["encrypt", "decrypt"]);
+const gcmParams = { name: "AES-GCM", iv: fromHex("cafebabefacedbaddecaf888") };
+const plaintext = fromHex("d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255");
+const sealed = hex(await subtle.encrypt(gcmParams, gcmKey, plaintext));
+const opened = await subtle.decrypt(gcmParams, gcmKey, fromHex(sealed));
+results.push(sealed.slice(0, 32) + " " + sealed.slice(-32) + " " + (hex(opened) === hex(plaintext.buffer)));
+
+async function tampered() {
+  try {
+    await subtle.decrypt(gcmParams, gcmKey, fromHex("00" + sealed.slice(2)));
+    return "decrypted";
+  } catch (e) {
+    return e.name;
+  }
+}
+results.push(await tampered());
+
+// AES-CBC and AES-CTR, from NIST SP 800-38A
+const nistKey = fromHex("2b7e151628aed2a6abf7158809cf4f3c");
+const cbcKey = await subtle.importKey("raw", nistKey, "AES-CBC", false, ["encrypt", "decrypt"]);
+const cbcParams = { name: "AES-CBC", iv: fromHex("000102030405060708090a0b0c0d0e0f") };
+const cbc = await subtle.encrypt(cbcParams, cbcKey, fromHex("6bc1bee22e409f96e93d7e117393172a"));
+const uncbc = await subtle.decrypt(cbcParams, cbcKey, cbc);
+results.push(hex(cbc).slice(0, 32) + " " + cbc.byteLength + " " + (hex(uncbc) === "6bc1bee22e409f96e93d7e117393172a"));
+const ctrKey = await subtle.importKey("raw", nistKey, "AES-CTR", false, ["encrypt"]);
+const ctrParams = { name: "AES-CTR", counter: fromHex("f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"), length: 64 };
+results.push(hex(await subtle.encrypt(ctrParams, ctrKey, fromHex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51"))));
+
+// A generated key round trips
+const generated = await subtle.generateKey({ name: "AES-GCM", length: 256 }, true, ["encrypt", "decrypt"]);
+const iv = crypto.getRandomValues(new Uint8Array(12));
+const roundTrip = await subtle.decrypt({ name: "AES-GCM", iv }, generated, await subtle.encrypt({ name: "AES-GCM", iv }, generated, abc));
+results.push(generated.algorithm.name + " " + generated.algorithm.length + " " + (hex(roundTrip) === "616263"));
+
+results.join(" | ");
@@ -0,0 +1,34 @@
+// Test Event and CustomEvent properties and subclassing EventTarget
+// expect: ping 5 true true true | 0 true null | [object CustomEvent] true | TypeError | TypeError
+const results: string[] = [];
+
+class Emitter extends EventTarget {
+  ping(detail: number) {
+    return this.dispatchEvent(new CustomEvent("ping", { detail }));
+  }
+}
+const emitter = new Emitter();
+emitter.addEventListener("ping", (e: CustomEvent<number>) => {
+  results.push(e.type + " " + e.detail + " " + (e.target === emitter) + " " + (e.currentTarget === emitter) + " " + (e instanceof Event));
+});
+emitter.ping(5);
+
+const event = new Event("done", { bubbles: true });
+emitter.dispatchEvent(event);
+results.push(event.eventPhase + " " + (event.target === emitter) + " " + event.currentTarget);
+
+const custom = new CustomEvent("c");
+results.push(Object.prototype.toString.call(custom) + " " + (custom.detail === null));
+
+try {
+  emitter.dispatchEvent({ type: "fake" } as any);
+} catch (e) {
+  results.push(e.name);
+}
+try {
+  (Event as any)("x");
+} catch (e) {
+  results.push(e.name);
+}
+
+results.join(" | ");
@@ -0,0 +1,9 @@
+// expect_compile_error: cannot assign type 'number' to constant 'label' of type 'string'
+// A bodiless ambient getter has its declared type.
+declare class Box {
+  get size(): number;
+}
+function describe(b: Box): string {
+  const label: string = b.size;
+  return label;
+}
@@ -0,0 +1,12 @@
+// expect: ok
+// Ambient classes declare accessors without a body; they type as properties.
+declare class Box {
+  get size(): number;
+  set size(value: number);
+  static get count(): number;
+}
+function area(b: Box): number {
+  b.size = 2;
+  return b.size * Box.count;
+}
+"ok";
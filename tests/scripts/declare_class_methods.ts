@@ -0,0 +1,9 @@
+// expect_compile_error: cannot assign type 'number' to variable 'wrong' of type 'string'
+// An ambient class's method signatures, overloads included, are its methods.
+declare class Parser {
+  parse(input: string): number;
+  parse(input: string, radix: number): number;
+  static create(): Parser;
+}
+const ok: number = Parser.create().parse("1", 10);
+const wrong: string = Parser.create().parse("1");
@@ -0,0 +1,26 @@
+// Test DisposableStack and AsyncDisposableStack
+// expect: moved true false, use, adopt 7, defer, true [object DisposableStack], ReferenceError, async defer, async use, true
+
+const log: string[] = [];
+const stack = new DisposableStack();
+stack.defer(() => log.push("defer"));
+stack.adopt(7, (v: any) => log.push("adopt " + v));
+stack.use({ [Symbol.dispose]() { log.push("use"); } });
+
+const moved = stack.move();
+log.push("moved " + stack.disposed + " " + moved.disposed);
+moved[Symbol.dispose]();
+log.push(moved.disposed + " " + Object.prototype.toString.call(moved));
+try {
+  stack.defer(() => {});
+} catch (e) {
+  log.push(e.name);
+}
+
+const asyncStack = new AsyncDisposableStack();
+asyncStack.use({ async [Symbol.asyncDispose]() { log.push("async use"); } });
+asyncStack.defer(async () => { log.push("async defer"); });
+await asyncStack.disposeAsync();
+log.push(String(asyncStack.disposed));
+
+log.join(", ");
@@ -0,0 +1,43 @@
+// Test EventTarget listener options, dispatch order and propagation
+// expect: cap,b:2,once,obj,cap,b:2,obj | 1 | true false | w-skipped | after,done
+const results: string[] = [];
+
+const log: string[] = [];
+const target = new EventTarget();
+const listener = (e: Event) => log.push("b:" + e.eventPhase);
+target.addEventListener("x", listener);
+target.addEventListener("x", listener); // duplicates are ignored
+target.addEventListener("x", () => log.push("cap"), { capture: true });
+target.addEventListener("x", () => log.push("once"), { once: true });
+target.addEventListener("x", { handleEvent() { log.push("obj"); } });
+target.dispatchEvent(new Event("x"));
+target.dispatchEvent(new Event("x"));
+results.push(log.join(","));
+
+const stopped: string[] = [];
+target.addEventListener("y", (e: Event) => { stopped.push("1"); e.stopImmediatePropagation(); });
+target.addEventListener("y", () => stopped.push("2"));
+target.dispatchEvent(new Event("y"));
+results.push(stopped.join(","));
+
+// preventDefault is ignored in passive listeners
+target.addEventListener("z", (e: Event) => e.preventDefault(), { passive: true });
+const passive = target.dispatchEvent(new Event("z", { cancelable: true }));
+target.addEventListener("z", (e: Event) => e.preventDefault());
+results.push(passive + " " + target.dispatchEvent(new Event("z", { cancelable: true })));
+
+const controller = new AbortController();
+let ran = "w-skipped";
+target.addEventListener("w", () => { ran = "w-ran"; }, { signal: controller.signal });
+controller.abort();
+target.dispatchEvent(new Event("w"));
+results.push(ran);
+
+// Listeners run synchronously inside dispatchEvent
+const order: string[] = [];
+target.addEventListener("v", () => order.push("after"));
+target.dispatchEvent(new Event("v"));
+order.push("done");
+results.push(order.join(","));
+
+results.join(" | ");
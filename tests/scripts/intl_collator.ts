@@ -0,0 +1,10 @@
+// Test Intl.Collator and String.prototype.localeCompare
+// expect: a,ä,b,z | a,b,z,ä | -1 | 0 | 2,9,10 | -1 1 0
+const results: string[] = [];
+results.push(["b", "a", "ä", "z"].sort(new Intl.Collator("de").compare).join(","));
+results.push(["b", "a", "ä", "z"].sort(new Intl.Collator("sv").compare).join(","));
+results.push(String("a".localeCompare("B")));
+results.push(String(new Intl.Collator("en", { sensitivity: "base" }).compare("a", "Á")));
+results.push(["10", "9", "2"].sort(new Intl.Collator(undefined, { numeric: true }).compare).join(","));
+results.push(["a".localeCompare("b"), "b".localeCompare("a"), "résumé".localeCompare("RESUME", "en", { sensitivity: "base" })].join(" "));
+results.join(" | ");
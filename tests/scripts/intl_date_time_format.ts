@@ -0,0 +1,20 @@
+// Test Intl.DateTimeFormat components, styles and time zones
+// expect: 1/15/2024 | Monday 15 January 2024 at 14:05:09 UTC | 15. Januar 2024 um 15:05 | 2024年1月15日月曜日 23:05 | lundi 15 janvier | 15 de enero de 2024 | 9:05 AM EST | hour:2 literal:: minute:05 literal:  dayPeriod:PM | Asia/Kolkata h23 | 01/15/2024, 14:05:09.123
+const date = new Date(Date.UTC(2024, 0, 15, 14, 5, 9, 123));
+const results: string[] = [];
+results.push(new Intl.DateTimeFormat("en-US", { timeZone: "UTC" }).format(date));
+results.push(new Intl.DateTimeFormat("en-GB", { timeZone: "UTC", dateStyle: "full", timeStyle: "long" }).format(date));
+results.push(new Intl.DateTimeFormat("de-DE", { timeZone: "Europe/Berlin", dateStyle: "long", timeStyle: "short" }).format(date));
+results.push(new Intl.DateTimeFormat("ja-JP", { timeZone: "Asia/Tokyo", dateStyle: "full", timeStyle: "short" }).format(date));
+results.push(new Intl.DateTimeFormat("fr-FR", { timeZone: "UTC", weekday: "long", month: "long", day: "numeric" }).format(date));
+results.push(new Intl.DateTimeFormat("es", { timeZone: "UTC", dateStyle: "long" }).format(date));
+results.push(new Intl.DateTimeFormat("en-US", { timeZone: "America/New_York", hour: "numeric", minute: "2-digit", timeZoneName: "short" }).format(date));
+results.push(new Intl.DateTimeFormat("en-US", { timeZone: "UTC", hour: "numeric", minute: "2-digit" })
+  .formatToParts(date).map((p: any) => p.type + ":" + p.value).join(" "));
+const options = new Intl.DateTimeFormat("en-IN", { timeZone: "Asia/Kolkata", hour: "numeric", hourCycle: "h23" }).resolvedOptions();
+results.push(options.timeZone + " " + options.hourCycle);
+results.push(new Intl.DateTimeFormat("en-US", {
+  timeZone: "UTC", year: "numeric", month: "2-digit", day: "2-digit",
+  hour: "2-digit", minute: "2-digit", second: "2-digit", fractionalSecondDigits: 3, hour12: false,
+}).format(date));
+results.join(" | ");
@@ -0,0 +1,15 @@
+// Test Date.prototype.toLocale*String going through Intl.DateTimeFormat
+// expect: 1/15/2024, 2:05:09 PM | 15/01/2024 | 14:05:09 | 15.1.2024 | Invalid Date | RangeError
+const date = new Date(Date.UTC(2024, 0, 15, 14, 5, 9));
+const results: string[] = [];
+results.push(date.toLocaleString("en-US", { timeZone: "UTC" }));
+results.push(date.toLocaleDateString("en-GB", { timeZone: "UTC" }));
+results.push(date.toLocaleTimeString("en-US", { timeZone: "UTC", hour12: false }));
+results.push(date.toLocaleDateString("de", { timeZone: "UTC" }));
+results.push(new Date(NaN).toLocaleString());
+try {
+  date.toLocaleString("en-US", { timeZone: "Mars/Olympus_Mons" });
+} catch (e) {
+  results.push(e.name);
+}
+results.join(" | ");
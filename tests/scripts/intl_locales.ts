@@ -0,0 +1,16 @@
+// Test Intl locale negotiation and locale-aware string methods
+// expect: en-US,de-DE | fr,de | RangeError | İSTANBUL | ı | 1 234,5 | [object Intl.Collator] | 1,234.5
+const results: string[] = [];
+results.push(Intl.getCanonicalLocales(["EN-us", "de-de", "en-US"]).join(","));
+results.push(Intl.NumberFormat.supportedLocalesOf(["fr", "xx", "de"]).join(","));
+try {
+  Intl.getCanonicalLocales("en_US");
+} catch (e) {
+  results.push(e.name);
+}
+results.push("istanbul".toLocaleUpperCase("tr"));
+results.push("I".toLocaleLowerCase("tr"));
+results.push((1234.5).toLocaleString("fr-FR"));
+results.push(Object.prototype.toString.call(new Intl.Collator()));
+results.push((1234.5).toLocaleString());
+results.join(" | ");
@@ -0,0 +1,16 @@
+// Test Intl.NumberFormat styles, notations and formatToParts
+// expect: 1,234,567.891 | 1.234,50 € | ($42.10) | 25.6% | 1.2M | 1,23,45,678 | 12,345,678,901,234,567,890 | 50 km/h | 1.234E3 | integer:1 group:, integer:000 decimal:. fraction:5 | en-US USD 2
+const results: string[] = [];
+results.push(new Intl.NumberFormat("en-US").format(1234567.891));
+results.push(new Intl.NumberFormat("de-DE", { style: "currency", currency: "EUR" }).format(1234.5));
+results.push(new Intl.NumberFormat("en-US", { style: "currency", currency: "USD", currencySign: "accounting" }).format(-42.1));
+results.push(new Intl.NumberFormat("en-US", { style: "percent", maximumFractionDigits: 1 }).format(0.256));
+results.push(new Intl.NumberFormat("en", { notation: "compact" }).format(1234567));
+results.push(new Intl.NumberFormat("hi-IN").format(12345678));
+results.push(new Intl.NumberFormat("en-US").format(12345678901234567890n));
+results.push(new Intl.NumberFormat("en-US", { style: "unit", unit: "kilometer-per-hour" }).format(50));
+results.push(new Intl.NumberFormat("en-US", { notation: "scientific" }).format(1234));
+results.push(new Intl.NumberFormat("en-US").formatToParts(1000.5).map((p: any) => p.type + ":" + p.value).join(" "));
+const options = new Intl.NumberFormat("en-US", { style: "currency", currency: "usd" }).resolvedOptions();
+results.push(options.locale + " " + options.currency + " " + options.maximumFractionDigits);
+results.join(" | ");
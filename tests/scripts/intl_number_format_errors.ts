@@ -0,0 +1,18 @@
+// Test Intl.NumberFormat option validation
+// expect: TypeError RangeError RangeError RangeError
+const errors: string[] = [];
+const attempts = [
+  () => new Intl.NumberFormat("en", { style: "currency" }),
+  () => new Intl.NumberFormat("en", { style: "fancy" }),
+  () => new Intl.NumberFormat("en", { maximumFractionDigits: 101 }),
+  () => new Intl.NumberFormat("en_US"),
+];
+for (const attempt of attempts) {
+  try {
+    attempt();
+    errors.push("none");
+  } catch (e) {
+    errors.push(e.name);
+  }
+}
+errors.join(" ");
@@ -0,0 +1,12 @@
+// Test Intl.PluralRules cardinal and ordinal categories
+// expect: one,other,other | one,two,few,other,other,one | zero,one,two,few,many,other | one,few,many | other
+const results: string[] = [];
+const cardinal = new Intl.PluralRules("en-US");
+results.push([1, 0, 2].map(n => cardinal.select(n)).join(","));
+const ordinal = new Intl.PluralRules("en-US", { type: "ordinal" });
+results.push([1, 2, 3, 4, 11, 21].map(n => ordinal.select(n)).join(","));
+results.push(new Intl.PluralRules("ar").resolvedOptions().pluralCategories.join(","));
+const polish = new Intl.PluralRules("pl");
+results.push([1, 3, 5].map(n => polish.select(n)).join(","));
+results.push(new Intl.PluralRules("en", { minimumFractionDigits: 1 }).select(1));
+results.join(" | ");
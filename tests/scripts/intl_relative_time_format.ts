@@ -0,0 +1,19 @@
+// Test Intl.RelativeTimeFormat phrases and parts
+// expect: yesterday | in 2 weeks | last month | 3 days ago | in 1 yr. | hace 3 días | vor 1 Tag | literal:in  integer:100:day literal: days | RangeError
+const results: string[] = [];
+const auto = new Intl.RelativeTimeFormat("en", { numeric: "auto" });
+results.push(auto.format(-1, "day"));
+results.push(auto.format(2, "weeks"));
+results.push(auto.format(-1, "month"));
+results.push(new Intl.RelativeTimeFormat("en").format(-3, "day"));
+results.push(new Intl.RelativeTimeFormat("en", { style: "short" }).format(1, "year"));
+results.push(new Intl.RelativeTimeFormat("es").format(-3, "day"));
+results.push(new Intl.RelativeTimeFormat("de").format(-1, "day"));
+results.push(new Intl.RelativeTimeFormat("en").formatToParts(100, "day")
+  .map((p: any) => p.type + ":" + p.value + (p.unit ? ":" + p.unit : "")).join(" "));
+try {
+  new Intl.RelativeTimeFormat("en").format(1, "fortnight");
+} catch (e) {
+  results.push(e.name);
+}
+results.join(" | ");
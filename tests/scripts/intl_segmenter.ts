@@ -0,0 +1,12 @@
+// Test Intl.Segmenter grapheme, word and sentence granularity
+// expect: 4 | Hello|world|Don't|stop | [Hi there. ][How are you? ][Fine, e.g. okay.] | world 7 | undefined
+const results: string[] = [];
+results.push(String([...new Intl.Segmenter().segment("é👨‍👩‍👧🇵🇱x")].length));
+const words = new Intl.Segmenter("en", { granularity: "word" }).segment("Hello, world! Don't stop.");
+results.push([...words].filter(s => s.isWordLike).map(s => s.segment).join("|"));
+const sentences = new Intl.Segmenter("en", { granularity: "sentence" }).segment("Hi there. How are you? Fine, e.g. okay.");
+results.push([...sentences].map(s => "[" + s.segment + "]").join(""));
+const found = words.containing(9)!;
+results.push(found.segment + " " + found.index);
+results.push(String(words.containing(100)));
+results.join(" | ");
@@ -0,0 +1,26 @@
+// Test Blob.stream() and Response/Request bodies as ReadableStreams
+// expect: 10 blob bytes | 3 | streamed | true true | cloned cloned | null | piped
+const results: string[] = [];
+
+let blobSize = 0;
+for await (const chunk of new Blob(["blob ", "bytes"]).stream()) blobSize += chunk.length;
+results.push(blobSize + " " + (await new Response(new Blob(["blob ", "bytes"]).stream()).text()));
+
+const chunk = await new Response("abc").body.getReader().read();
+results.push(String(chunk.value.length));
+
+const streamed = new Response(ReadableStream.from(["str", "eamed"]).pipeThrough(new TextEncoderStream()));
+results.push(await streamed.text());
+results.push(streamed.bodyUsed + " " + streamed.body.locked);
+
+const original = new Response("cloned");
+const copy = original.clone();
+original.body;
+results.push((await original.text()) + " " + (await copy.text()));
+
+results.push(String(new Request("http://example.com").body));
+
+const request = new Request("http://example.com", { method: "POST", body: ReadableStream.from(["pi", "ped"]).pipeThrough(new TextEncoderStream()) });
+results.push(await request.text());
+
+results.join(" | ");
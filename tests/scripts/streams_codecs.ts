@@ -0,0 +1,34 @@
+// Test TextEncoderStream, TextDecoderStream, CompressionStream and DecompressionStream
+// expect: true true | é€ | TypeError
+const results: string[] = [];
+
+async function collect(rs: any) {
+  const out: any[] = [];
+  for await (const chunk of rs) out.push(chunk);
+  return out;
+}
+
+const text = "héllo wörld ".repeat(200);
+const gzipped = await collect(ReadableStream.from([text]).pipeThrough(new TextEncoderStream()).pipeThrough(new CompressionStream("gzip")));
+let size = 0;
+for (const chunk of gzipped) size += chunk.byteLength;
+const roundTrip = await collect(ReadableStream.from(gzipped).pipeThrough(new DecompressionStream("gzip")).pipeThrough(new TextDecoderStream()));
+results.push((roundTrip.join("") === text) + " " + (size < 200));
+
+// A multi-byte character split across chunks
+const bytes = new Uint8Array([0xc3, 0xa9, 0xe2, 0x82, 0xac]);
+const parts = [bytes.subarray(0, 1), bytes.subarray(1, 3), bytes.subarray(3)];
+results.push((await collect(ReadableStream.from(parts).pipeThrough(new TextDecoderStream()))).join(""));
+
+async function corrupt() {
+  try {
+    const decoded = ReadableStream.from([new Uint8Array([1, 2, 3])]).pipeThrough(new DecompressionStream("deflate"));
+    for await (const chunk of decoded) {}
+  } catch (e) {
+    return e.name;
+  }
+  return "decoded";
+}
+results.push(await corrupt());
+
+results.join(" | ");
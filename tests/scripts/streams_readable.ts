@@ -0,0 +1,50 @@
+// Test ReadableStream async iteration, ReadableStream.from, tee and BYOB readers
+// expect: abc | 1,2,3 | 1 2,3 | 1,2,3/4,5/true0 | boom | ReadableStream is locked to a reader | [object ReadableStream]
+const results: string[] = [];
+
+const rs = new ReadableStream({
+  start(c) { c.enqueue("a"); c.enqueue("b"); },
+  pull(c) { c.enqueue("c"); c.close(); },
+});
+const letters: string[] = [];
+for await (const chunk of rs) letters.push(chunk);
+results.push(letters.join(""));
+
+const numbers: number[] = [];
+for await (const n of ReadableStream.from([1, 2, 3])) numbers.push(n);
+results.push(numbers.join(","));
+
+const [left, right] = ReadableStream.from([1, 2, 3]).tee();
+const first = await left.getReader().read();
+const rest: number[] = [];
+for await (const v of right) rest.push(v);
+results.push(first.value + " " + rest.slice(1).join(","));
+
+const bytes = new ReadableStream({ type: "bytes", start(c) { c.enqueue(new Uint8Array([1, 2, 3, 4, 5])); c.close(); } });
+const byob = bytes.getReader({ mode: "byob" });
+const r1 = await byob.read(new Uint8Array(3));
+const r2 = await byob.read(new Uint8Array(3));
+const r3 = await byob.read(new Uint8Array(3));
+results.push(Array.from(r1.value).join(",") + "/" + Array.from(r2.value).join(",") + "/" + r3.done + r3.value.length);
+
+const broken = new ReadableStream({ start(c) { c.error(new TypeError("boom")); } });
+async function readBroken() {
+  try {
+    await broken.getReader().read();
+    return "read";
+  } catch (e) {
+    return e.message;
+  }
+}
+results.push(await readBroken());
+
+const held = new ReadableStream();
+held.getReader();
+try {
+  held.getReader();
+} catch (e) {
+  results.push(e.message);
+}
+results.push(Object.prototype.toString.call(held));
+
+results.join(" | ");
@@ -0,0 +1,35 @@
+// Test TransformStream with pipeThrough, pipeTo and flush
+// expect: X,Y,! | 4 | cancelled: stop
+const results: string[] = [];
+
+const written: string[] = [];
+const upper = new TransformStream({
+  transform(chunk, c) { c.enqueue(chunk.toUpperCase()); },
+  flush(c) { c.enqueue("!"); },
+});
+await ReadableStream.from(["x", "y"]).pipeThrough(upper).pipeTo(new WritableStream({ write(chunk) { written.push(chunk); } }));
+results.push(written.join(","));
+
+const identity = new TransformStream();
+const writer = identity.writable.getWriter();
+writer.write(4);
+const read = await identity.readable.getReader().read();
+results.push(String(read.value));
+
+let cancelled = "";
+const source = new ReadableStream({ cancel(reason) { cancelled = reason; } });
+const controller = new AbortController();
+const piping = source.pipeTo(new WritableStream(), { signal: controller.signal });
+controller.abort("stop");
+async function waitPipe() {
+  try {
+    await piping;
+  } catch (e) {
+    return "cancelled: " + e;
+  }
+  return "done";
+}
+await waitPipe();
+results.push("cancelled: " + cancelled);
+
+results.join(" | ");
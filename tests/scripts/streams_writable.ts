@@ -0,0 +1,35 @@
+// Test WritableStream backpressure with a queuing strategy, close and abort
+// expect: d2,d0,w1,d0,d-1,w2,d0,w3,close,abort:why
+const log: string[] = [];
+let release: any;
+const ws = new WritableStream({
+  write(chunk) {
+    log.push("w" + chunk);
+    return new Promise(r => { release = r; });
+  },
+  close() { log.push("close"); },
+}, new CountQueuingStrategy({ highWaterMark: 2 }));
+
+const writer = ws.getWriter();
+log.push("d" + writer.desiredSize);
+writer.write(1);
+writer.write(2);
+log.push("d" + writer.desiredSize);
+await new Promise(r => setTimeout(r, 0));
+log.push("d" + writer.desiredSize);
+writer.write(3);
+log.push("d" + writer.desiredSize);
+release();
+await new Promise(r => setTimeout(r, 0));
+log.push("d" + writer.desiredSize);
+release();
+await new Promise(r => setTimeout(r, 0));
+release();
+await new Promise(r => setTimeout(r, 0));
+await writer.ready;
+await writer.close();
+
+const aborted = new WritableStream({ abort(reason) { log.push("abort:" + reason); } });
+await aborted.abort("why");
+
+log.join(",");
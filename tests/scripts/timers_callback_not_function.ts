@@ -0,0 +1,4 @@
+// setTimeout rejects non-callable handlers
+// expect_runtime_error: must be of type function
+// no-typecheck
+setTimeout("1 + 1" as any, 1);
@@ -0,0 +1,14 @@
+// clearTimeout and clearImmediate cancel pending callbacks; the rest fire
+// immediates first, then timers by delay
+// expect: immediate,kept,later
+const fired: string[] = [];
+setTimeout(() => fired.push("later"), 40);
+const a = setTimeout(() => fired.push("cleared"), 1);
+setTimeout(() => fired.push("kept"), 20);
+clearTimeout(a);
+const b = setImmediate(() => fired.push("cleared immediate"));
+clearImmediate(b);
+setImmediate(() => fired.push("immediate"));
+clearTimeout(undefined);
+await new Promise<void>((resolve) => setTimeout(resolve, 60));
+fired.join(",");
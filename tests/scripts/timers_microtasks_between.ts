@@ -0,0 +1,13 @@
+// Each timer callback's microtasks run before the next timer fires
+// expect: a,a-micro,b,b-micro
+const events: string[] = [];
+setTimeout(() => {
+  events.push("a");
+  Promise.resolve(0).then(() => events.push("a-micro"));
+}, 1);
+setTimeout(() => {
+  events.push("b");
+  Promise.resolve(0).then(() => events.push("b-micro"));
+}, 1);
+await new Promise<void>((resolve) => setTimeout(resolve, 5));
+events.join(",");
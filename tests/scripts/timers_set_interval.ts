@@ -0,0 +1,15 @@
+// setInterval repeats until cleared, forwarding extra arguments
+// expect: tick:a,tick:a,tick:a
+const ticks: string[] = [];
+let count = 0;
+await new Promise<void>((resolve) => {
+  const id = setInterval((label: string) => {
+    ticks.push("tick:" + label);
+    count++;
+    if (count === 3) {
+      clearInterval(id);
+      resolve();
+    }
+  }, 2, "a");
+});
+ticks.join(",");
@@ -0,0 +1,11 @@
+// Timers fire in deadline order with microtasks drained between them
+// expect: sync,micro,t1,t2,t3
+
+const log: string[] = [];
+setTimeout(() => log.push("t3"), 30);
+setTimeout(() => log.push("t1"), 1);
+setTimeout(() => log.push("t2"), 10);
+Promise.resolve(0).then(() => log.push("micro"));
+log.push("sync");
+await new Promise<void>((resolve) => setTimeout(resolve, 40));
+log.join(",");
@@ -0,0 +1,6 @@
+// An exception thrown by a timer callback surfaces as an uncaught error
+// expect_runtime_error: Uncaught exception: Error: boom
+setTimeout(() => {
+  throw new Error("boom");
+}, 1);
+await new Promise<void>((resolve) => setTimeout(resolve, 5));
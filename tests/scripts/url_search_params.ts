@@ -0,0 +1,49 @@
+// Test URLSearchParams: init forms, sort, iteration and form encoding
+// expect: a=2&b=%C3%A9&z=1&z=0 1,0 true false | a=2&b=%C3%A9&z=only | a=2,b=é,z=only a/b/z 2/é/only | a=1&b=x+y c=3&d=4 e=5 | TypeError | a:2,b:é,z:only | %7E*._-+%21%27%28%29%2B | string https://a.example/x?y=1 string | site=https%3A%2F%2Fa.example%2Fx%3Fy%3D1&n=5 | application/x-www-form-urlencoded;charset=UTF-8 x=1&y=2
+const results: string[] = [];
+
+const params = new URLSearchParams("?z=1&a=2&z=0&b=é");
+params.sort();
+results.push(params.toString() + " " + params.getAll("z") + " " + params.has("z", "0") + " " + params.has("z", "9"));
+
+params.set("z", "only");
+results.push(params.toString());
+
+const pairs: string[] = [];
+for (const [name, value] of params) {
+  pairs.push(name + "=" + value);
+}
+results.push(pairs.join(",") + " " + [...params.keys()].join("/") + " " + [...params.values()].join("/"));
+
+results.push(
+  new URLSearchParams({ a: "1", b: "x y" }).toString() + " " +
+  new URLSearchParams([["c", "3"], ["d", "4"]]).toString() + " " +
+  new URLSearchParams(new URLSearchParams("e=5")).toString()
+);
+
+try {
+  new URLSearchParams([["x"]] as any);
+} catch (e) {
+  results.push(e.name);
+}
+
+const seen: string[] = [];
+params.forEach((value, name) => seen.push(name + ":" + value));
+results.push(seen.join(","));
+
+results.push(new URLSearchParams({ "~*._-": " !'()+" }).toString().replace("=", ""));
+
+// FormData stores URLs as strings, and URLSearchParams reads FormData
+const form = new FormData();
+form.append("site", new URL("https://a.example/x?y=1"));
+form.append("n", 5 as any);
+results.push(typeof form.get("site") + " " + form.get("site") + " " + typeof form.get("n"));
+results.push(new URLSearchParams(form as any).toString());
+
+const response = new Response(new URLSearchParams("x=1&y=2"));
+async function body() {
+  return response.headers.get("Content-Type") + " " + await response.text();
+}
+results.push(await body());
+
+results.join(" | ");
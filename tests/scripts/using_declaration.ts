@@ -0,0 +1,34 @@
+// Test using declarations disposing of their resources in reverse order,
+// however the block is left
+// expect: open a, open b, body, close b, close a, close f, f, loop 1, close 1, loop 2, close 2, close t, catch boom
+
+const log: string[] = [];
+function resource(name: string) {
+  log.push("open " + name);
+  return { [Symbol.dispose]() { log.push("close " + name); } };
+}
+
+{
+  using a = resource("a"), b = resource("b");
+  log.length = 0;
+  log.push("open a", "open b", "body");
+}
+
+function f(): string {
+  using r = { [Symbol.dispose]() { log.push("close f"); } };
+  return "f";
+}
+log.push(f());
+
+for (using r of [1, 2].map((n) => ({ n, [Symbol.dispose]() { log.push("close " + n); } }))) {
+  log.push("loop " + r.n);
+}
+
+try {
+  using t = { [Symbol.dispose]() { log.push("close t"); } };
+  throw new Error("boom");
+} catch (e) {
+  log.push("catch " + e.message);
+}
+
+log.join(", ");
@@ -0,0 +1,29 @@
+// Test using in a generator disposing on break, return() and throw()
+// expect: dispose break, dispose return, dispose throw, caught x, dispose done
+
+const log: string[] = [];
+function res(name: string) {
+  return { [Symbol.dispose]() { log.push("dispose " + name); } };
+}
+function* g(name: string) {
+  using r = res(name);
+  yield 1;
+  yield 2;
+}
+
+for (const v of g("break")) break;
+
+const it = g("return");
+it.next();
+it.return(undefined);
+
+const t = g("throw");
+t.next();
+try {
+  t.throw(new Error("x"));
+} catch (e) {
+  log.push("caught " + e.message);
+}
+
+for (const v of g("done")) {}
+log.join(", ");
@@ -0,0 +1,7 @@
+// Test that using declarations can't hold primitives
+
+{
+  using n = 42;
+}
+
+// expect_compile_error: type 'number' is not disposable
@@ -0,0 +1,30 @@
+// Test errors thrown disposing of resources chaining into SuppressedErrors
+// expect: SuppressedError a true body | b | TypeError
+
+function failing(name: string) {
+  return { [Symbol.dispose]() { throw new Error(name); } };
+}
+
+const results: string[] = [];
+try {
+  using a = failing("a");
+  using b = failing("b");
+  throw new Error("body");
+} catch (e) {
+  // a's error suppresses b's, which suppresses the block's
+  results.push(e.name + " " + e.error.message + " " + (e instanceof SuppressedError) + " " + e.suppressed.suppressed.message);
+}
+
+try {
+  using b = failing("b");
+} catch (e) {
+  results.push(e.message);
+}
+
+try {
+  using x = {} as any;
+} catch (e) {
+  results.push(e.name);
+}
+
+results.join(" | ");
@@ -266,6 +266,10 @@ func initializeVMBuiltins(vmInstance *vm.VM) error {
 			globalVariables[name] = value
 			return nil
 		},
+		GetGlobal: func(name string) (vm.Value, bool) {
+			value, ok := globalVariables[name]
+			return value, ok
+		},
 	}
 
 	// Initialize all builtins runtime values
@@ -243,6 +243,10 @@ func compileAndInitializeVMFromString(source string) (*vm.Chunk, *vm.VM, []error
 			globalVariables[name] = value
 			return nil
 		},
+		GetGlobal: func(name string) (vm.Value, bool) {
+			value, ok := globalVariables[name]
+			return value, ok
+		},
 	}
 
 	// Initialize all builtins runtime values